@@ -3,6 +3,7 @@ package agent
 
 import (
 	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
 	"github.com/samuelfneumann/golearn/timestep"
 	"gonum.org/v1/gonum/mat"
 	G "gorgonia.org/gorgonia"
@@ -24,6 +25,16 @@ type Closer interface {
 	Close() error
 }
 
+// EGreedyAgent is an agent that selects actions using an ε-greedy
+// behaviour policy. The ε of the behaviour policy may change over time
+// if the agent uses an ε schedule.
+type EGreedyAgent interface {
+	Agent
+
+	// Epsilon returns the current ε of the behaviour policy
+	Epsilon() float64
+}
+
 // Learner implements a learning algorithm that defines how weights are
 // updated.
 type Learner interface {
@@ -86,6 +97,74 @@ type EGreedyNNPolicy interface {
 	Epsilon() float64
 }
 
+// BoltzmannNNPolicy implements a Boltzmann (softmax) policy over
+// action values using neural network function approximation. Actions
+// are selected with probability proportional to exp(q(s, a) / τ) for
+// temperature τ, which can be set and retrieved.
+type BoltzmannNNPolicy interface {
+	NNPolicy
+	SetTemperature(float64)
+	Temperature() float64
+}
+
+// RecurrentNNPolicy implements a policy using a recurrent neural
+// network. The policy carries a hidden state between calls to
+// SelectAction, which should be reset at the end of each episode.
+type RecurrentNNPolicy interface {
+	NNPolicy
+	ResetState() error
+}
+
+// GonumPolicy represents a policy that uses a neural network from
+// package gonumnet.
+//
+// Unlike an NNPolicy, a GonumPolicy needs no VM and its network
+// accepts inputs of any batch size. A single GonumPolicy can therefore
+// be used both for action selection and for learning its weights.
+type GonumPolicy interface {
+	Policy
+	Network() gonumnet.Net
+}
+
+// GonumEGreedyPolicy implements an epsilon greedy policy using a
+// neural network from package gonumnet.
+type GonumEGreedyPolicy interface {
+	GonumPolicy
+	SetEpsilon(float64)
+	Epsilon() float64
+}
+
+// GonumBoltzmannPolicy implements a Boltzmann (softmax) policy over
+// action values using a neural network from package gonumnet.
+type GonumBoltzmannPolicy interface {
+	GonumPolicy
+	SetTemperature(float64)
+	Temperature() float64
+}
+
+// GonumLogPdfOfer implements a GonumPolicy that can calculate the log
+// of the probability density function of the policy for taking some
+// (externally inputted) action in some (externally inputted) state,
+// as well as the gradient of these log probabilities.
+type GonumLogPdfOfer interface {
+	GonumPolicy
+
+	// LogPdfOf returns the log probability of taking the argument
+	// actions in the argument states. Inputs should be constructed in
+	// row major order.
+	LogPdfOf(states, actions []float64) ([]float64, error)
+
+	// LogPdfBackward computes the gradient of
+	//
+	//	Σᵢ wᵢ ln π(aᵢ | sᵢ) + β H(π(⋅ | sᵢ))
+	//
+	// with respect to the weights of the policy's network, where the
+	// states sᵢ and actions aᵢ are those most recently input with
+	// LogPdfOf, and H is the entropy. The gradient is stored as the
+	// gradient of the network's learnable parameters.
+	LogPdfBackward(w []float64, β float64) error
+}
+
 // LogPdfOfer implements a policy type that can calculate the log
 // of the probability density function of the policy for taking some
 // (externally inputted) action in some (externally inputted) state.
@@ -107,3 +186,14 @@ type LogPdfOfer interface {
 	// row major order.
 	LogPdfOf(states, actions []float64) (*G.Node, error)
 }
+
+// EntropyLogPdfOfer implements a LogPdfOfer that can also calculate
+// the entropy of its action distribution in each (externally inputted)
+// state. The states are those most recently input with LogPdfOf().
+type EntropyLogPdfOfer interface {
+	LogPdfOfer
+
+	// EntropyNode returns the node that calculates the entropy of the
+	// policy in each input state
+	EntropyNode() *G.Node
+}
@@ -25,7 +25,8 @@ type ConfigList interface {
 }
 
 // ConfigAt returns the Config at index i % configs.Len() in the
-// ConfigList.
+// ConfigList. ConfigList fields with no values are considered optional
+// and are left as the zero value in the returned Config.
 func ConfigAt(i int, configs ConfigList) Config {
 	return configAt(i, configs)
 }
@@ -58,6 +59,10 @@ func configAt(i int, configs ConfigList) Config {
 
 		case reflect.Slice:
 			numSettings := settings.Len()
+			if numSettings == 0 {
+				// Optional field, leave as the zero value
+				continue
+			}
 			reflectConfig.FieldByName(fieldName).Set(settings.Index(((i /
 				accum) % numSettings)))
 			accum *= numSettings
@@ -70,6 +75,18 @@ func configAt(i int, configs ConfigList) Config {
 	return reflectConfig.Interface().(Config)
 }
 
+// NumSettings returns the number of settings of a ConfigList field with
+// n values. Fields with no values are optional and count as a single
+// setting, since they are left as the zero value in each Config. This
+// function should be used to compute the Len() of ConfigLists with
+// optional fields.
+func NumSettings(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
 // Config represents a configuration for creating an agent
 type Config interface {
 	// CreateAgent creates the agent that the config describes
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	G "gorgonia.org/gorgonia"
+)
+
+// NewNetwork builds the network described by spec for environment e
+// on graph g. Inputs to the network have batch size batch, and each
+// head of the network produces outputs predictions. The network
+// samples dropout masks and NoisyNet noise using seed.
+//
+// The number of input features is the size of the environment's
+// observations. If spec describes a convolutional network, the
+// environment must be an ImageEnvironment, and the network takes as
+// input the images of the environment. See network.Spec for more
+// details.
+func NewNetwork(spec *network.Spec, e environment.Environment, batch,
+	outputs int, g *G.ExprGraph, seed uint64) (network.NeuralNet, error) {
+	features := []int{e.ObservationSpec().Shape.Len()}
+
+	if len(spec.Conv) > 0 {
+		imageEnv, ok := e.(environment.ImageEnvironment)
+		if !ok {
+			return nil, fmt.Errorf("newNetwork: convolutional networks "+
+				"require an environment with image observations "+
+				"\n\thave(%T)", e)
+		}
+		features = []int{imageEnv.Channels(), imageEnv.Rows(),
+			imageEnv.Cols()}
+	}
+
+	net, err := spec.Build(features, batch, outputs, g)
+	if err != nil {
+		return nil, fmt.Errorf("newNetwork: %v", err)
+	}
+	network.SetSeed(net, seed)
+	return net, nil
+}
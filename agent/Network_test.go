@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	"github.com/samuelfneumann/golearn/environment/minatar"
+	"github.com/samuelfneumann/golearn/network"
+	G "gorgonia.org/gorgonia"
+)
+
+// TestNewNetwork tests that networks described by JSON Specs take the
+// observations of an environment as input, and that convolutional
+// networks take its images as input
+func TestNewNetwork(t *testing.T) {
+	breakout, _, err := minatar.NewBreakout(minatar.NewPlay(100), 0, 0.99, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	constantEnv, _, err := constant.New(2, 1, environment.NewStepLimit(1),
+		0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const init = `"Init": {"Type": "GlorotU", "Config": {"Gain": 1.0}}`
+	mlp := `{` + init + `, "Heads": [{"Layers": [{"Units": 4,
+		"Activation": "relu"}]}]}`
+	conv := `{` + init + `, "Conv": [{"Type": "Conv2D", "Filters": 2,
+		"Kernel": [3, 3], "Activation": "relu"}, {"Type": "MaxPool",
+		"Kernel": [2, 2]}], "Heads": [{"Layers": [{"Units": 4}]}]}`
+
+	tests := []struct {
+		name     string
+		data     string
+		env      environment.Environment
+		features int
+		err      bool
+	}{
+		{"MLP", mlp, breakout, breakout.ObservationSpec().Shape.Len(), false},
+		{"Conv", conv, breakout, breakout.ObservationSpec().Shape.Len(), false},
+		{"ConvWithoutImages", conv, constantEnv, 0, true},
+	}
+
+	for _, test := range tests {
+		spec := &network.Spec{}
+		if err := json.Unmarshal([]byte(test.data), spec); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		net, err := NewNetwork(spec, test.env, 1, 3, G.NewGraph(), 1)
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		if features := net.Features()[0]; features != test.features {
+			t.Errorf("%v: features: have(%v) want(%v)", test.name, features,
+				test.features)
+		}
+		if outputs := net.Outputs()[0]; outputs != 3 {
+			t.Errorf("%v: outputs: have(%v) want(3)", test.name, outputs)
+		}
+	}
+}
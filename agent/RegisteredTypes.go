@@ -14,6 +14,9 @@ import (
 type Type string
 
 const (
+	// Baseline methods
+	Random Type = "Random"
+
 	// Linear methods
 	EGreedyQLearningLinear    Type = "EGreedyQLearning-Linear"
 	EGreedyESarsaLinear       Type = "EGreedyESarsa-Linear"
@@ -21,14 +24,20 @@ const (
 
 	// Deep methods
 	// Policy learning methods
-	CategoricalVanillaPGMLP  Type = "CategoricalVanillaPG-MLP"
-	GaussianVanillaPGTreeMLP Type = "GaussianVanillaPG-TreeMLP"
+	CategoricalVanillaPGMLP          Type = "CategoricalVanillaPG-MLP"
+	GaussianVanillaPGTreeMLP         Type = "GaussianVanillaPG-TreeMLP"
+	SquashedGaussianVanillaPGTreeMLP Type = "SquashedGaussianVanillaPG-TreeMLP"
+	BetaVanillaPGTreeMLP             Type = "BetaVanillaPG-TreeMLP"
 
-	GaussianVanillaACTreeMLP Type = "GaussianVanillaAC-TreeMLP"
-	CategoricalVanillaACMLP  Type = "CategoricalVanillaAC-MLP"
+	GaussianVanillaACTreeMLP         Type = "GaussianVanillaAC-TreeMLP"
+	SquashedGaussianVanillaACTreeMLP Type = "SquashedGaussianVanillaAC-TreeMLP"
+	BetaVanillaACTreeMLP             Type = "BetaVanillaAC-TreeMLP"
+	CategoricalVanillaACMLP          Type = "CategoricalVanillaAC-MLP"
 
 	// Value-based methods
-	EGreedyDeepQMLP Type = "EGreedyDeepQ-MLP"
+	EGreedyDeepQMLP          Type = "EGreedyDeepQ-MLP"
+	EGreedyDeepQConvMLP      Type = "EGreedyDeepQ-ConvMLP"
+	EGreedyDeepQRecurrentMLP Type = "EGreedyDeepQ-RecurrentMLP"
 )
 
 // Registered types with the package. Once a Type has been registered
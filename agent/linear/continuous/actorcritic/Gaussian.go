@@ -134,13 +134,11 @@ func (l *LinearGaussian) TdError(t ts.Transition) float64 {
 	state := t.State
 	nextState := t.NextState
 
-	r := l.nextStep.Reward
-	ℽ := l.nextStep.Discount
+	r := t.Reward
+	ℽ := t.Discount
 	var stateValue, nextStateValue float64
 
 	if l.useIndexTileCoding {
-		stateValue := 0.0
-		nextStateValue := 0.0
 		var index int
 		for i := 0; i < state.Len(); i++ {
 			index = int(state.AtVec(i))
@@ -168,7 +166,7 @@ func (l *LinearGaussian) stepIndex() error {
 
 	// Calculate TD error δ
 	r := l.nextStep.Reward
-	ℽ := l.nextStep.Discount
+	ℽ := l.nextStep.BootstrapDiscount()
 	stateValue := 0.0
 	nextStateValue := 0.0
 	var index int
@@ -304,7 +302,7 @@ func (l *LinearGaussian) Step() error {
 
 	// Calculate TD error δ
 	r := l.nextStep.Reward
-	ℽ := l.nextStep.Discount
+	ℽ := l.nextStep.BootstrapDiscount()
 	stateValue := mat.Dot(l.criticWeights, state)
 	nextStateValue := mat.Dot(l.criticWeights, nextState)
 	δ := r + ℽ*nextStateValue - stateValue
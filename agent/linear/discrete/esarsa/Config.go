@@ -6,6 +6,7 @@ import (
 
 	"github.com/samuelfneumann/golearn/agent"
 	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/schedule"
 	"github.com/samuelfneumann/golearn/utils/matutils/initializers/weights"
 )
 
@@ -23,6 +24,10 @@ type ConfigList struct {
 	BehaviourE   []float64
 	TargetE      []float64
 	LearningRate []float64
+
+	// Optional schedule for the behaviour epsilon, which replaces
+	// BehaviourE
+	EpsilonSchedule []*schedule.Schedule
 }
 
 // NewConfigList returns a new ConfigList as an agent.TypedConfigList
@@ -58,7 +63,9 @@ func (c ConfigList) NumFields() int {
 
 // Len returns the number of Configs stored by the list
 func (c ConfigList) Len() int {
-	return len(c.BehaviourE) * len(c.TargetE) * len(c.LearningRate)
+	return agent.NumSettings(len(c.BehaviourE)) * len(c.TargetE) *
+		len(c.LearningRate) *
+		agent.NumSettings(len(c.EpsilonSchedule))
 }
 
 // Config represents a configuration for the ESarsa agent.
@@ -66,6 +73,11 @@ type Config struct {
 	BehaviourE   float64 // epislon for behaviour policy
 	TargetE      float64 // epsilon for target policy
 	LearningRate float64
+
+	// Optional schedule for the behaviour epsilon. If non-nil, the
+	// behaviour policy's epsilon follows the schedule and BehaviourE
+	// is ignored.
+	EpsilonSchedule *schedule.Schedule
 }
 
 // CreateAgent creates the agent from the Config. Agent weights are
@@ -96,6 +108,11 @@ func (c Config) Validate() error {
 	if c.TargetE < 0 {
 		return fmt.Errorf("target epislon cannot be lower than 0")
 	}
+	if c.EpsilonSchedule != nil {
+		if err := c.EpsilonSchedule.Validate(); err != nil {
+			return fmt.Errorf("invalid epsilon schedule: %v", err)
+		}
+	}
 	return nil
 }
 
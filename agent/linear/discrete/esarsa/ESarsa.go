@@ -8,6 +8,7 @@ import (
 	"github.com/samuelfneumann/golearn/agent/linear/discrete/policy"
 	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/environment/wrappers"
+	"github.com/samuelfneumann/golearn/schedule"
 	"github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/matutils/initializers/weights"
 	"gonum.org/v1/gonum/mat"
@@ -23,6 +24,12 @@ type ESarsa struct {
 	seed         uint64
 	eval         bool // Whether or not in evaluation mode
 
+	behaviour *policy.EGreedy // Same policy as the embedded Policy
+
+	// Schedule for the ε of the behaviour policy, nil if ε is constant
+	epsilonSchedule *schedule.Schedule
+	steps           int // Steps taken in training mode
+
 	// indexTileCoding represents whether the environment is using
 	// tile coding and returning the non-zero indices as features
 	indexTileCoding bool
@@ -33,14 +40,17 @@ type ESarsa struct {
 // environment.ESarsa or environment.QLearing, New will panic.
 func New(env environment.Environment, c agent.Config,
 	init weights.Initializer, seed uint64) (agent.Agent, error) {
-	// Ensure environment has discrete actions
-	if env.ActionSpec().Cardinality != environment.Discrete {
+	// Ensure environment has discrete actions enumerated from 0
+	spec := env.ActionSpec()
+	if spec.Cardinality != environment.Discrete &&
+		spec.Cardinality != environment.MultiDiscrete {
 		return nil, fmt.Errorf("esarsa: cannot use non-discrete actions")
 	}
-	if env.ActionSpec().LowerBound.Len() > 1 {
-		return nil, fmt.Errorf("esarsa: actions must be 1-dimensional")
+	if spec.Cardinality == environment.Discrete && spec.Shape.Len() > 1 {
+		return nil, fmt.Errorf("esarsa: discrete actions must be " +
+			"1-dimensional")
 	}
-	if env.ActionSpec().LowerBound.AtVec(0) != 0.0 {
+	if mat.Min(spec.LowerBound) != 0.0 || mat.Max(spec.LowerBound) != 0.0 {
 		return nil, fmt.Errorf("esarsa: actions must be enumerated " +
 			"starting from 0")
 	}
@@ -59,6 +69,9 @@ func New(env environment.Environment, c agent.Config,
 
 	// Get the behaviour policy
 	behaviourE := config.BehaviourE
+	if config.EpsilonSchedule != nil {
+		behaviourE = config.EpsilonSchedule.Value(0)
+	}
 	behaviourPol, err := policy.NewEGreedy(behaviourE, seed, env)
 	if err != nil {
 		return &ESarsa{}, fmt.Errorf("esarsa: invalid behaviour policy: %v",
@@ -105,6 +118,8 @@ func New(env environment.Environment, c agent.Config,
 		seed:            seed,
 		eval:            false,
 		indexTileCoding: indexTileCoding,
+		behaviour:       behaviour,
+		epsilonSchedule: config.EpsilonSchedule,
 	}, nil
 }
 
@@ -112,6 +127,10 @@ func New(env environment.Environment, c agent.Config,
 // target policy. The policy depends on whether or not the agent is in
 // evaluation mode or training mode.
 func (e *ESarsa) SelectAction(t timestep.TimeStep) *mat.VecDense {
+	if !e.IsEval() {
+		e.anneal()
+	}
+
 	if !e.eval {
 		return e.Policy.SelectAction(t)
 	}
@@ -126,3 +145,19 @@ func (e *ESarsa) Step() error {
 	}
 	return e.Learner.Step()
 }
+
+// anneal sets the ε of the behaviour policy as determined by the ε
+// schedule and advances the schedule by one step. If no schedule is
+// used, anneal does nothing.
+func (e *ESarsa) anneal() {
+	if e.epsilonSchedule == nil {
+		return
+	}
+	e.behaviour.SetEpsilon(e.epsilonSchedule.Value(e.steps))
+	e.steps++
+}
+
+// Epsilon returns the current ε of the behaviour policy
+func (e *ESarsa) Epsilon() float64 {
+	return e.behaviour.Epsilon()
+}
@@ -82,12 +82,12 @@ func (e *ESarsaLearner) ObserveFirst(t timestep.TimeStep) error {
 // Observe observes and records any timestep other than the first timestep
 func (e *ESarsaLearner) Observe(action mat.Vector,
 	nextStep timestep.TimeStep) error {
-	if action.Len() != 1 {
-		return fmt.Errorf("observe: cannot observe multi-dimensional "+
-			"actions (action dim = %d)", action.Len())
+	if want := e.policy.ActionSpec().Shape.Len(); action.Len() != want {
+		return fmt.Errorf("observe: illegal action dimension \n\twant(%d)"+
+			"\n\thave(%d)", want, action.Len())
 	}
 	e.step = e.nextStep
-	e.action = int(action.AtVec(0))
+	e.action = e.policy.ActionIndex(action)
 	e.nextStep = nextStep
 
 	return nil
@@ -96,10 +96,7 @@ func (e *ESarsaLearner) Observe(action mat.Vector,
 // TdError calculates the TD error generated by the learner on some
 // transition.
 func (e *ESarsaLearner) TdError(t timestep.Transition) float64 {
-	if t.Action.Len() > 1 || t.NextAction.Len() > 1 {
-		panic("actions should be 1-dimensional")
-	}
-	action := int(t.Action.AtVec(0))
+	action := e.policy.ActionIndex(t.Action)
 	actionVal := mat.Dot(e.weights.RowView(action), t.State)
 
 	// Find the next action values
@@ -147,7 +144,7 @@ func (e *ESarsaLearner) stepIndex() {
 	expectedVal := mat.Dot(targetProbs, actionValues)
 
 	// Create the update target
-	discount := e.nextStep.Discount
+	discount := e.nextStep.BootstrapDiscount()
 	target := e.nextStep.Reward + discount*expectedVal
 
 	// Find current estimate of the taken action
@@ -182,7 +179,7 @@ func (e *ESarsaLearner) Step() error {
 		targetProbs := e.target.ActionProbabilities(nextState)
 
 		// Create the update target
-		discount := e.nextStep.Discount
+		discount := e.nextStep.BootstrapDiscount()
 		expectedQ := mat.Dot(targetProbs, actionValues)
 		target := e.nextStep.Reward + discount*expectedQ
 
@@ -0,0 +1,29 @@
+package esarsa
+
+import (
+	"testing"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/agent/linear/discrete/policy"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/internal/agenttest"
+	"github.com/samuelfneumann/golearn/utils/matutils/initializers/weights"
+)
+
+// TestBootstrap tests that ESarsa bootstraps from the last state of
+// truncated episodes but not from the last state of terminated
+// episodes, so that its value estimates are unbiased.
+func TestBootstrap(t *testing.T) {
+	newAgent := func(env environment.Environment) (agent.Agent, error) {
+		args := Config{BehaviourE: 0.1, TargetE: 0.1, LearningRate: 0.1}
+		init := weights.NewLinearUV(weights.NewZeroUV())
+		return New(env, args, init, 1)
+	}
+
+	value := func(a agent.Agent) float64 {
+		target := a.(*ESarsa).Target.(*policy.EGreedy)
+		return target.Weights()[policy.WeightsKey].At(0, 0)
+	}
+
+	agenttest.Bootstrap(t, newAgent, value)
+}
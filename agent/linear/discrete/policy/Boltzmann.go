@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/rand"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/wrappers"
+	"github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Boltzmann implements a Boltzmann (softmax) policy using linear
+// function approximation. Actions are selected with probability
+// proportional to exp(q(s, a) / τ), where τ is the temperature of the
+// policy. In evaluation mode, the policy is greedy. Like EGreedy,
+// MultiDiscrete actions are supported by enumerating all combinations
+// of actions.
+type Boltzmann struct {
+	weights     *mat.Dense
+	temperature float64
+	rng         *rand.Rand // Seed for random number generation
+	eval        bool
+	spec        environment.Spec // Action specification
+
+	// indexTileCoding represents whether the environment is using
+	// tile coding and returning the non-zero indices as features
+	indexTileCoding bool
+}
+
+// NewBoltzmann constructs a new Boltzmann policy with temperature τ
+// for the environment env. Higher temperatures result in more uniform
+// action selection, while lower temperatures result in more greedy
+// action selection.
+func NewBoltzmann(τ float64, seed uint64,
+	env environment.Environment) (agent.Policy, error) {
+	if τ <= 0 {
+		return &Boltzmann{}, fmt.Errorf("boltzmann: temperature must be " +
+			"positive")
+	}
+
+	source := rand.NewSource(seed)
+	rng := rand.New(source)
+
+	// Ensure actions are discrete
+	spec := env.ActionSpec()
+	if err := validateSpec(spec); err != nil {
+		return &Boltzmann{}, fmt.Errorf("boltzmann: %v", err)
+	}
+
+	// Create the weight matrix: rows = actions, cols = features
+	actions := spec.NumDiscrete()
+	features := env.ObservationSpec().Shape.Len()
+	weights := mat.NewDense(actions, features, nil)
+
+	// Check if the environment uses tile coding and returns the
+	// indices of non-zero elements of the tile-coded vectors as
+	// state representations
+	_, indexTileCoding := env.(*wrappers.IndexTileCoding)
+
+	return &Boltzmann{weights, τ, rng, false, spec, indexTileCoding}, nil
+}
+
+// Weights gets and returns the weights of the Boltzmann policy as a
+// string description -> weights
+func (p *Boltzmann) Weights() map[string]*mat.Dense {
+	weights := make(map[string]*mat.Dense)
+	weights[WeightsKey] = p.weights
+
+	return weights
+}
+
+// SetWeights sets the weight pointers to point to a new set of weights.
+// The SetWeights function can take the output of a call to Weights()
+// on another linear Policy directly
+func (p *Boltzmann) SetWeights(weights map[string]*mat.Dense) error {
+	newWeights, ok := weights[WeightsKey]
+	if !ok {
+		return fmt.Errorf("SetWeights: no weights named \"weights\"")
+	}
+
+	p.weights = newWeights
+	return nil
+}
+
+// SetTemperature sets the temperature of the policy
+func (p *Boltzmann) SetTemperature(τ float64) { p.temperature = τ }
+
+// Temperature returns the temperature of the policy
+func (p *Boltzmann) Temperature() float64 { return p.temperature }
+
+// Eval sets the policy to evaluation mode
+func (p *Boltzmann) Eval() { p.eval = true }
+
+// IsEval returns whether the policy is in evaulation mode or not
+func (p *Boltzmann) IsEval() bool { return p.eval }
+
+// Train sets the policy to training mode
+func (p *Boltzmann) Train() { p.eval = false }
+
+// SelectAction selects an action from the Boltzmann policy
+func (p *Boltzmann) SelectAction(t timestep.TimeStep) *mat.VecDense {
+	actionValues := actionValues(p.weights, t.Observation,
+		p.indexTileCoding).RawVector().Data
+
+	var action int
+	if p.IsEval() {
+		// If multiple actions have max value, return a random
+		// max-valued action
+		maxIndices := floatutils.ArgMax(actionValues...)
+		action = maxIndices[p.rng.Int()%len(maxIndices)]
+	} else {
+		probs := floatutils.Softmax(p.temperature, actionValues...)
+		action = sample(p.rng.Float64(), probs)
+	}
+
+	return p.spec.DiscreteValue(action)
+}
+
+// ActionProbabilites returns the probability of taking each action in
+// a given state
+func (p *Boltzmann) ActionProbabilities(obs mat.Vector) mat.Vector {
+	values := actionValues(p.weights, obs, p.indexTileCoding)
+	probs := floatutils.Softmax(p.temperature, values.RawVector().Data...)
+
+	return mat.NewVecDense(len(probs), probs)
+}
+
+// sample returns the index of the category of probs in which the
+// uniform random number u in [0, 1) falls
+func sample(u float64, probs []float64) int {
+	cumulative := 0.0
+	for i, prob := range probs {
+		cumulative += prob
+		if u < cumulative {
+			return i
+		}
+	}
+	return len(probs) - 1
+}
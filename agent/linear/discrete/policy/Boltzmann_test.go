@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	"gonum.org/v1/gonum/mat"
+)
+
+// TestBoltzmann tests that a Boltzmann policy selects actions with
+// frequencies matching the softmax of the action values at a fixed
+// temperature, and greedily in evaluation mode
+func TestBoltzmann(t *testing.T) {
+	const samples = 20000
+	values := []float64{1, 0, -1}
+
+	env, step, err := constant.New(len(values), 1,
+		environment.NewStepLimit(1), 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBoltzmann(0, 1, env); err == nil {
+		t.Error("expected an error for a temperature of 0")
+	}
+
+	for _, τ := range []float64{0.5, 2} {
+		policy, err := NewBoltzmann(τ, 1, env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := policy.(*Boltzmann)
+
+		// The single state observation is [1], so that the action
+		// values are the weights of the policy
+		weights := mat.NewDense(len(values), 1, append([]float64{},
+			values...))
+		if err := p.SetWeights(map[string]*mat.Dense{
+			WeightsKey: weights}); err != nil {
+			t.Fatal(err)
+		}
+
+		sum := 0.0
+		want := make([]float64, len(values))
+		for i, v := range values {
+			want[i] = math.Exp(v / τ)
+			sum += want[i]
+		}
+		for i := range want {
+			want[i] /= sum
+		}
+
+		probs := p.ActionProbabilities(step.Observation)
+		for i := range want {
+			if math.Abs(probs.AtVec(i)-want[i]) > 1e-12 {
+				t.Errorf("τ=%v: probability of action %v: have(%v) want(%v)",
+					τ, i, probs.AtVec(i), want[i])
+			}
+		}
+
+		counts := make([]float64, len(values))
+		for i := 0; i < samples; i++ {
+			counts[int(p.SelectAction(step).AtVec(0))]++
+		}
+		for i := range counts {
+			if freq := counts[i] / samples; math.Abs(freq-want[i]) > 0.015 {
+				t.Errorf("τ=%v: frequency of action %v: have(%v) want(%v)", τ,
+					i, freq, want[i])
+			}
+		}
+
+		p.Eval()
+		for i := 0; i < 100; i++ {
+			if a := p.SelectAction(step).AtVec(0); a != 0 {
+				t.Fatalf("τ=%v: evaluation mode: have(%v) want(0)", τ, a)
+			}
+		}
+	}
+}
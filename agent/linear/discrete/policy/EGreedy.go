@@ -21,12 +21,15 @@ const (
 )
 
 // EGreedy implements an ε-greedy policy using linear function
-// approximation
+// approximation. MultiDiscrete actions are supported by enumerating
+// all combinations of actions, so that the policy has one row of
+// weights per combination.
 type EGreedy struct {
 	weights *mat.Dense
 	epsilon float64
 	rng     *rand.Rand // Seed for random number generation
 	eval    bool
+	spec    environment.Spec // Action specification
 
 	// indexTileCoding represents whether the environment is using
 	// tile coding and returning the non-zero indices as features
@@ -42,20 +45,14 @@ func NewEGreedy(e float64, seed uint64,
 	source := rand.NewSource(seed)
 	rng := rand.New(source)
 
-	// Ensure actions are 1-dimensional
-	if env.ActionSpec().Shape.Len() != 1 {
-		return &EGreedy{}, fmt.Errorf("egreedy: can only use " +
-			"1-dimensional actions")
-	}
-
 	// Ensure actions are discrete
-	if env.ActionSpec().Cardinality != environment.Discrete {
-		return &EGreedy{}, fmt.Errorf("egreedy: can only use " +
-			"discrete actions")
+	spec := env.ActionSpec()
+	if err := validateSpec(spec); err != nil {
+		return &EGreedy{}, fmt.Errorf("egreedy: %v", err)
 	}
 
 	// Calculate the number of actions
-	actions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+	actions := spec.NumDiscrete()
 
 	// Calculate the number of features
 	features := env.ObservationSpec().Shape.Len()
@@ -68,8 +65,20 @@ func NewEGreedy(e float64, seed uint64,
 	// state representations
 	_, indexTileCoding := env.(*wrappers.IndexTileCoding)
 
-	return &EGreedy{weights, e, rng, false, indexTileCoding}, nil
+	return &EGreedy{weights, e, rng, false, spec, indexTileCoding}, nil
+}
 
+// validateSpec ensures that an action specification describes discrete
+// actions which can be enumerated by a linear policy
+func validateSpec(spec environment.Spec) error {
+	if spec.Cardinality == environment.Discrete && spec.Shape.Len() != 1 {
+		return fmt.Errorf("discrete actions must be 1-dimensional")
+	}
+	if spec.Cardinality != environment.Discrete &&
+		spec.Cardinality != environment.MultiDiscrete {
+		return fmt.Errorf("can only use discrete actions")
+	}
+	return nil
 }
 
 // Weights gets and returns the weights of the EGreedy policy as a
@@ -96,21 +105,37 @@ func (p *EGreedy) SetWeights(weights map[string]*mat.Dense) error {
 
 // actionValues calculates the values of each action in a state
 func (e *EGreedy) actionValues(obs mat.Vector) *mat.VecDense {
-	numActions, _ := e.weights.Dims()
+	return actionValues(e.weights, obs, e.indexTileCoding)
+}
+
+// actionValues calculates the values of each action in a state given
+// linear weights with one row per action. If indexTileCoding is true,
+// obs is assumed to hold the indices of the non-zero features.
+func actionValues(weights *mat.Dense, obs mat.Vector,
+	indexTileCoding bool) *mat.VecDense {
+	numActions, _ := weights.Dims()
 	actionValues := mat.NewVecDense(numActions, nil)
 
-	if e.indexTileCoding {
+	if indexTileCoding {
 		for i := 0; i < obs.Len(); i++ {
 			index := obs.AtVec(i) // Index of non-zero feature
-			actionValues.AddVec(actionValues, e.weights.ColView(int(index)))
+			actionValues.AddVec(actionValues, weights.ColView(int(index)))
 		}
 		return actionValues
 	} else {
-		actionValues.MulVec(e.weights, obs)
+		actionValues.MulVec(weights, obs)
 		return actionValues
 	}
 }
 
+// SetEpsilon sets the probability with which a random action is
+// selected
+func (p *EGreedy) SetEpsilon(e float64) { p.epsilon = e }
+
+// Epsilon returns the probability with which a random action is
+// selected
+func (p *EGreedy) Epsilon() float64 { return p.epsilon }
+
 // Eval sets the policy to evaluation mode
 func (p *EGreedy) Eval() { p.eval = true }
 
@@ -135,7 +160,7 @@ func (p *EGreedy) SelectAction(t timestep.TimeStep) *mat.VecDense {
 		// With probability epsilon return a random action
 		if probability := rand.Float64(); probability < p.epsilon {
 			action := rand.Int() % numActions
-			return mat.NewVecDense(1, []float64{float64(action)})
+			return p.spec.DiscreteValue(action)
 		}
 
 		// Get the actions of maximum value
@@ -144,7 +169,15 @@ func (p *EGreedy) SelectAction(t timestep.TimeStep) *mat.VecDense {
 
 	// If multiple actions have max value, return a random max-valued action
 	action := maxIndices[p.rng.Int()%len(maxIndices)]
-	return mat.NewVecDense(1, []float64{float64(action)})
+	return p.spec.DiscreteValue(action)
+}
+
+// ActionSpec returns the action specification of the policy
+func (p *EGreedy) ActionSpec() environment.Spec { return p.spec }
+
+// ActionIndex returns the index of the row of weights for action a
+func (p *EGreedy) ActionIndex(a mat.Vector) int {
+	return p.spec.DiscreteIndex(a)
 }
 
 // ActionProbabilites returns the probability of taking each action in
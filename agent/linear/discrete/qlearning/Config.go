@@ -6,6 +6,7 @@ import (
 
 	"github.com/samuelfneumann/golearn/agent"
 	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/schedule"
 	"github.com/samuelfneumann/golearn/utils/matutils/initializers/weights"
 )
 
@@ -22,6 +23,12 @@ func init() {
 type ConfigList struct {
 	Epsilon      []float64
 	LearningRate []float64
+
+	// Optional schedule for epsilon, which replaces Epsilon
+	EpsilonSchedule []*schedule.Schedule
+
+	// Optional Boltzmann temperature, which replaces Epsilon
+	Temperature []float64
 }
 
 // NewConfigList returns a new ConfigList as an agent.TypedConfigList
@@ -52,13 +59,24 @@ func (c ConfigList) NumFields() int {
 
 // Len returns the number of Configs stored by the list
 func (c ConfigList) Len() int {
-	return len(c.Epsilon) * len(c.LearningRate)
+	return agent.NumSettings(len(c.Epsilon)) * len(c.LearningRate) *
+		agent.NumSettings(len(c.EpsilonSchedule)) *
+		agent.NumSettings(len(c.Temperature))
 }
 
 // Config represents a configuration for the QLearning agent
 type Config struct {
 	Epsilon      float64 // epislon for behaviour policy
 	LearningRate float64
+
+	// Optional schedule for epsilon. If non-nil, the behaviour policy's
+	// epsilon follows the schedule and Epsilon is ignored.
+	EpsilonSchedule *schedule.Schedule
+
+	// Optional temperature of a Boltzmann behaviour policy. If
+	// positive, the behaviour policy is a Boltzmann policy with this
+	// temperature instead of an ε-greedy policy, and Epsilon is ignored.
+	Temperature float64
 }
 
 // CreateAgent creates the agent from the Config. Agent weights are
@@ -86,6 +104,18 @@ func (c Config) Validate() error {
 	if c.Epsilon < 0 {
 		return fmt.Errorf("epislon cannot be lower than 0")
 	}
+	if c.Temperature < 0 {
+		return fmt.Errorf("temperature cannot be lower than 0")
+	}
+	if c.Temperature > 0 && c.EpsilonSchedule != nil {
+		return fmt.Errorf("cannot use an epsilon schedule with a " +
+			"Boltzmann behaviour policy")
+	}
+	if c.EpsilonSchedule != nil {
+		if err := c.EpsilonSchedule.Validate(); err != nil {
+			return fmt.Errorf("invalid epsilon schedule: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -26,6 +26,8 @@ type QLearner struct {
 
 	learningRate float64
 
+	policy *policy.EGreedy // The greedy target policy
+
 	// indexTileCoding represents whether the environment is using
 	// tile coding and returning the non-zero indices as features
 	indexTileCoding bool
@@ -39,7 +41,8 @@ func NewQLearner(egreedy *policy.EGreedy,
 	step := timestep.TimeStep{}
 	nextStep := timestep.TimeStep{}
 
-	learner := &QLearner{nil, step, 0, nextStep, learningRate, indexTileCoding}
+	learner := &QLearner{nil, step, 0, nextStep, learningRate, egreedy,
+		indexTileCoding}
 	weights := egreedy.Weights()
 
 	err := learner.SetWeights(weights)
@@ -62,12 +65,12 @@ func (q *QLearner) ObserveFirst(t timestep.TimeStep) error {
 // Observe observes and records any timestep other than the first timestep
 func (q *QLearner) Observe(action mat.Vector,
 	nextStep timestep.TimeStep) error {
-	if action.Len() != 1 {
-		return fmt.Errorf("observe: cannot observe multi-dimensional "+
-			"actions (action dim = %d)", action.Len())
+	if want := q.policy.ActionSpec().Shape.Len(); action.Len() != want {
+		return fmt.Errorf("observe: illegal action dimension \n\twant(%d)"+
+			"\n\thave(%d)", want, action.Len())
 	}
 	q.step = q.nextStep
-	q.action = int(action.AtVec(0))
+	q.action = q.policy.ActionIndex(action)
 	q.nextStep = nextStep
 
 	return nil
@@ -76,11 +79,7 @@ func (q *QLearner) Observe(action mat.Vector,
 // TdError calculates the TD error generated by the learner on some
 // transition.
 func (q *QLearner) TdError(t timestep.Transition) float64 {
-	if t.Action.Len() > 1 || t.NextAction.Len() > 1 {
-		panic("actions should be 1-dimensional")
-	}
-
-	action := int(t.Action.AtVec(0))
+	action := q.policy.ActionIndex(t.Action)
 	actionVal := mat.Dot(q.weights.RowView(action), t.State)
 
 	// Find the max next action value
@@ -118,7 +117,7 @@ func (q *QLearner) stepIndex() {
 	maxVal := mat.Max(actionValues)
 
 	// Create the update target
-	discount := q.nextStep.Discount
+	discount := q.nextStep.BootstrapDiscount()
 	target := q.nextStep.Reward + discount*maxVal
 
 	// Find current estimate of the taken action
@@ -153,7 +152,7 @@ func (q *QLearner) Step() error {
 		maxVal := mat.Max(actionValues)
 
 		// Create the update target
-		discount := q.nextStep.Discount
+		discount := q.nextStep.BootstrapDiscount()
 		target := q.nextStep.Reward + discount*maxVal
 
 		// Find the current estimate of the taken action
@@ -13,6 +13,7 @@ import (
 	"github.com/samuelfneumann/golearn/agent/linear/discrete/policy"
 	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/environment/wrappers"
+	"github.com/samuelfneumann/golearn/schedule"
 	"github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/matutils/initializers/weights"
 	"gonum.org/v1/gonum/mat"
@@ -20,7 +21,8 @@ import (
 
 // QLearning implements the online Q-Learning algorithm. Actions selected by
 // this algorithm will always be enumerated as (0, 1, 2, ... N) where
-// N is the maximum possible action.
+// N is the maximum possible action. MultiDiscrete actions are
+// supported by learning the value of each combination of actions.
 type QLearning struct {
 	agent.Learner
 	agent.Policy // Behaviour
@@ -28,6 +30,14 @@ type QLearning struct {
 	seed         uint64
 	eval         bool // Whether or not in evaluation mode
 
+	// Same policy as the embedded Policy, nil if the behaviour policy
+	// is a Boltzmann policy
+	behaviour *policy.EGreedy
+
+	// Schedule for the ε of the behaviour policy, nil if ε is constant
+	epsilonSchedule *schedule.Schedule
+	steps           int // Steps taken in training mode
+
 	// indexTileCoding represents whether the environment is using
 	// tile coding and returning the non-zero indices as features
 	indexTileCoding bool
@@ -38,18 +48,19 @@ type QLearning struct {
 // will panic.
 func New(env environment.Environment, config agent.Config,
 	init weights.Initializer, seed uint64) (agent.Agent, error) {
-	// Ensure environment has discrete actions
-	if env.ActionSpec().Cardinality != environment.Discrete {
-		return nil, fmt.Errorf("qlearning: cannot use non-discrete " +
-			"actions")
+	// Ensure environment has discrete actions enumerated from 0
+	spec := env.ActionSpec()
+	if spec.Cardinality != environment.Discrete &&
+		spec.Cardinality != environment.MultiDiscrete {
+		return nil, fmt.Errorf("qlearning: cannot use non-discrete actions")
 	}
-	if env.ActionSpec().LowerBound.Len() > 1 {
-		return nil, fmt.Errorf("qlearning: actions must be " +
+	if spec.Cardinality == environment.Discrete && spec.Shape.Len() > 1 {
+		return nil, fmt.Errorf("qlearning: discrete actions must be " +
 			"1-dimensional")
 	}
-	if env.ActionSpec().LowerBound.AtVec(0) != 0.0 {
-		return nil, fmt.Errorf("qlearning: actions must be " +
-			"enumerated starting from 0")
+	if mat.Min(spec.LowerBound) != 0.0 || mat.Max(spec.LowerBound) != 0.0 {
+		return nil, fmt.Errorf("qlearning: actions must be enumerated " +
+			"starting from 0")
 	}
 	if !config.ValidAgent(&QLearning{}) {
 		return nil, fmt.Errorf("qlearning: invalid agent for configuration "+
@@ -66,13 +77,22 @@ func New(env environment.Environment, config agent.Config,
 	}
 
 	// Get the behaviour policy
-	e := c.Epsilon
-	behaviourPol, err := policy.NewEGreedy(e, seed, env)
+	var behaviourPol agent.Policy
+	if c.Temperature > 0 {
+		behaviourPol, err = policy.NewBoltzmann(c.Temperature, seed, env)
+	} else {
+		e := c.Epsilon
+		if c.EpsilonSchedule != nil {
+			e = c.EpsilonSchedule.Value(0)
+		}
+		behaviourPol, err = policy.NewEGreedy(e, seed, env)
+	}
 	if err != nil {
 		return &QLearning{}, fmt.Errorf("qlearning: invalid behaviour "+
 			"policy: %v", err)
 	}
-	behaviour := behaviourPol.(*policy.EGreedy)
+	behaviour := behaviourPol.(linearPolicy)
+	egreedy, _ := behaviourPol.(*policy.EGreedy)
 
 	// Get the target policy
 	targetPol, err := policy.NewGreedy(seed, env)
@@ -94,7 +114,7 @@ func New(env environment.Environment, config agent.Config,
 	// state representations
 	_, indexTileCoding := env.(*wrappers.IndexTileCoding)
 
-	learner, err := NewQLearner(behaviour, learningRate, indexTileCoding)
+	learner, err := NewQLearner(target, learningRate, indexTileCoding)
 	if err != nil {
 		err := fmt.Errorf("qlearning: cannot create learner")
 		return &QLearning{}, err
@@ -112,6 +132,8 @@ func New(env environment.Environment, config agent.Config,
 		seed:            seed,
 		eval:            false,
 		indexTileCoding: indexTileCoding,
+		behaviour:       egreedy,
+		epsilonSchedule: c.EpsilonSchedule,
 	}, nil
 }
 
@@ -119,6 +141,10 @@ func New(env environment.Environment, config agent.Config,
 // target policy. The policy depends on whether or not the agent is in
 // evaluation mode or training mode.
 func (q *QLearning) SelectAction(t timestep.TimeStep) *mat.VecDense {
+	if !q.IsEval() {
+		q.anneal()
+	}
+
 	if !q.eval {
 		return q.Policy.SelectAction(t)
 	}
@@ -133,3 +159,30 @@ func (q *QLearning) Step() error {
 	}
 	return q.Learner.Step()
 }
+
+// anneal sets the ε of the behaviour policy as determined by the ε
+// schedule and advances the schedule by one step. If no schedule is
+// used, anneal does nothing.
+func (q *QLearning) anneal() {
+	if q.epsilonSchedule == nil || q.behaviour == nil {
+		return
+	}
+	q.behaviour.SetEpsilon(q.epsilonSchedule.Value(q.steps))
+	q.steps++
+}
+
+// Epsilon returns the current ε of the behaviour policy. If the
+// behaviour policy is a Boltzmann policy, Epsilon returns 0.
+func (q *QLearning) Epsilon() float64 {
+	if q.behaviour == nil {
+		return 0
+	}
+	return q.behaviour.Epsilon()
+}
+
+// linearPolicy is a Policy which uses linear function approximation
+type linearPolicy interface {
+	agent.Policy
+	Weights() map[string]*mat.Dense
+	SetWeights(map[string]*mat.Dense) error
+}
@@ -4,13 +4,116 @@ import (
 	"testing"
 	"time"
 
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/agent/linear/discrete/policy"
 	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/environment/box2d/lunarlander"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	"github.com/samuelfneumann/golearn/environment/lights"
 	"github.com/samuelfneumann/golearn/environment/wrappers"
+	"github.com/samuelfneumann/golearn/internal/agenttest"
 	"github.com/samuelfneumann/golearn/utils/matutils/initializers/weights"
+	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/spatial/r1"
 )
 
+// TestBootstrap tests that QLearning bootstraps from the last state of
+// truncated episodes but not from the last state of terminated
+// episodes, so that its value estimates are unbiased.
+func TestBootstrap(t *testing.T) {
+	newAgent := func(env environment.Environment) (agent.Agent, error) {
+		args := Config{Epsilon: 0.1, LearningRate: 0.1}
+		init := weights.NewLinearUV(weights.NewZeroUV())
+		return New(env, args, init, 1)
+	}
+
+	value := func(a agent.Agent) float64 {
+		target := a.(*QLearning).Target.(*policy.EGreedy)
+		return target.Weights()[policy.WeightsKey].At(0, 0)
+	}
+
+	agenttest.Bootstrap(t, newAgent, value)
+}
+
+// TestObserveActionDimension tests that QLearning returns an error when
+// observing an action with the wrong number of dimensions
+func TestObserveActionDimension(t *testing.T) {
+	env, step, err := constant.New(1, 1.0, environment.NewStepLimit(3), 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := Config{Epsilon: 0.1, LearningRate: 0.1}
+	init := weights.NewLinearUV(weights.NewZeroUV())
+	q, err := New(env, args, init, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.ObserveFirst(step); err != nil {
+		t.Fatal(err)
+	}
+	action := mat.NewVecDense(2, nil)
+	if err := q.Observe(action, step); err == nil {
+		t.Errorf("observe: expected error for action of dimension %d",
+			action.Len())
+	}
+}
+
+// TestMultiDiscrete tests that QLearning learns to select all
+// components of multi-discrete actions correctly
+func TestMultiDiscrete(t *testing.T) {
+	env, step, err := lights.New(2, 3, environment.NewStepLimit(10), 0.9, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := Config{Epsilon: 0.1, LearningRate: 0.1}
+	init := weights.NewLinearUV(weights.NewZeroUV())
+	q, err := New(env, args, init, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.ObserveFirst(step)
+	for i := 0; i < 20000; i++ {
+		action := q.SelectAction(step)
+		step, _, err = env.Step(action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q.Observe(action, step)
+		q.Step()
+
+		if step.Last() {
+			q.EndEpisode()
+			if step, err = env.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			q.ObserveFirst(step)
+		}
+	}
+
+	// The greedy policy should set all lights to their targets
+	target := q.(*QLearning).Target
+	for i := 0; i < 20; i++ {
+		step, err := env.Reset()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		action := target.SelectAction(step)
+		want := env.(*lights.Lights).Target()
+		for j := range want {
+			if int(action.AtVec(j)) != want[j] {
+				t.Errorf("action: have(%v) want(%v)", action.RawVector().Data,
+					want)
+				break
+			}
+		}
+	}
+}
+
 func BenchmarkIndexTileCoderLunarLanderAgentStep(b *testing.B) {
 	// Set up the lunar lander environment
 	seed := uint64(time.Now().UnixNano())
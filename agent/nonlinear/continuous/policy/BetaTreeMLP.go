@@ -0,0 +1,294 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// BetaTreeMLP implements a Beta policy parameterized by a tree MLP.
+// The MLP has a single root network which breaks off into two leaf
+// networks, which predict the shape parameters α and β of a Beta
+// distribution for each action dimension. To ensure the Beta
+// distribution is unimodal, the shape parameters are computed from
+// the network predictions x as softplus(x) + 1, so that α, β ≥ 1.
+//
+// Actions are selected by sampling x ~ Beta(α, β) and scaling x to
+// the action bounds of the environment:
+//
+//	action := lower + (upper - lower) * x
+//
+// Since the Beta distribution has bounded support, the selected
+// actions are always within the action bounds, and the log
+// probability of actions includes the correction for this scaling.
+// In evaluation mode, the mean of the Beta distribution, α / (α + β),
+// is used in place of x. The environment must have finite action
+// bounds.
+type BetaTreeMLP struct {
+	vm  G.VM
+	net network.NeuralNet
+
+	actions    *G.Node
+	logPdfNode *G.Node
+	logPdfVal  G.Value
+
+	rng             *rand.Rand
+	actionDims      int
+	batchForLogProb int
+
+	// Affine transformation from (0, 1) to the action bounds
+	lower []float64
+	width []float64
+
+	alphaVal G.Value
+	betaVal  G.Value
+
+	eval bool
+}
+
+// NewBetaTreeMLP returns a new BetaTreeMLP policy. The parameters are
+// the same as those of NewGaussianTreeMLP, but the two leaf networks
+// predict α and β instead of the mean and log standard deviation.
+func NewBetaTreeMLP(env environment.Environment, batchForLogProb int,
+	g *G.ExprGraph, rootHiddenSizes []int, rootBiases []bool,
+	rootActivations []*network.Activation, leafHiddenSizes [][]int,
+	leafBiases [][]bool, leafActivations [][]*network.Activation,
+	init G.InitWFn, seed uint64) (agent.LogPdfOfer, error) {
+
+	if env.ActionSpec().Cardinality != environment.Continuous {
+		return nil, fmt.Errorf("newBetaTreeMLP: actions should be " +
+			"continuous")
+	}
+	if len(leafHiddenSizes) != 2 {
+		return nil, fmt.Errorf("newBetaTreeMLP: beta policy requires 2 " +
+			"leaf networks only")
+	}
+
+	lower, upper, err := actionBounds(env)
+	if err != nil {
+		return nil, fmt.Errorf("newBetaTreeMLP: %v", err)
+	}
+
+	features := env.ObservationSpec().Shape.Len()
+	actionDims := env.ActionSpec().Shape.Len()
+
+	net, err := network.NewTreeMLP(
+		features,
+		batchForLogProb,
+		actionDims,
+		G.NewGraph(),
+		rootHiddenSizes,
+		rootBiases,
+		rootActivations,
+		leafHiddenSizes,
+		leafBiases,
+		leafActivations,
+		init,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("newBetaTreeMLP: could not create "+
+			"network: %v", err)
+	}
+
+	// Calculate the affine transformation to the action bounds
+	width := make([]float64, actionDims)
+	logWidth := 0.0
+	for i := range width {
+		width[i] = upper[i] - lower[i]
+		logWidth += math.Log(width[i])
+	}
+
+	// Calculate the shape parameters
+	one := G.NewConstant(1.0)
+	alpha := G.Must(G.Softplus(net.Prediction()[0]))
+	alpha = G.Must(G.Add(alpha, one))
+	beta := G.Must(G.Softplus(net.Prediction()[1]))
+	beta = G.Must(G.Add(beta, one))
+
+	// Calculate log probability of input actions, which are stored
+	// in the actions node after being transformed to (0, 1)
+	var actions *G.Node
+	var logPdfNode *G.Node
+	if batchForLogProb > 1 {
+		actions = G.NewMatrix(
+			net.Graph(),
+			tensor.Float64,
+			G.WithName("InputActions"),
+			G.WithShape(batchForLogProb, actionDims),
+			G.WithInit(G.Zeroes()),
+		)
+		logPdfNode = betaLogPdf(alpha, beta, actions)
+		logPdfNode = G.Must(G.Sub(logPdfNode, G.NewConstant(logWidth)))
+	}
+
+	pol := &BetaTreeMLP{
+		net: net,
+
+		actions:    actions,
+		logPdfNode: logPdfNode,
+
+		rng:             rand.New(rand.NewSource(seed)),
+		actionDims:      actionDims,
+		batchForLogProb: batchForLogProb,
+
+		lower: lower,
+		width: width,
+
+		eval: false,
+	}
+
+	// Record values of Gorgonia nodes
+	if batchForLogProb > 1 {
+		G.Read(pol.logPdfNode, &pol.logPdfVal)
+	}
+	G.Read(alpha, &pol.alphaVal)
+	G.Read(beta, &pol.betaVal)
+
+	// Policy can select actions at each timestep only if using a batch
+	// size of 1.
+	if net.BatchSize() == 1 {
+		pol.vm = G.NewTapeMachine(net.Graph())
+	}
+
+	return pol, nil
+}
+
+// LogPdfOf sets the state and action inputs of the policy's
+// computational graph to the argument state and actions (s and a
+// respectively) so that when a VM of the policy is run, the log
+// probabliity of actions a taken in states s will be computed and
+// stored in the policy's associate log PDF node, which is returned.
+//
+// Actions on or outside the action bounds are moved just inside the
+// bounds so that their log probability is finite.
+func (b *BetaTreeMLP) LogPdfOf(s, a []float64) (*G.Node, error) {
+	if err := b.Network().SetInput(s); err != nil {
+		return nil, fmt.Errorf("logPdfOf: could not set states: %v", err)
+	}
+
+	// Transform actions to (0, 1)
+	scaled := make([]float64, len(a))
+	for i := range a {
+		dim := i % b.actionDims
+		scaled[i] = floatutils.Clip((a[i]-b.lower[dim])/b.width[dim],
+			boundOffset, 1-boundOffset)
+	}
+
+	actionsTensor := tensor.NewDense(tensor.Float64,
+		[]int{b.batchForLogProb, b.actionDims},
+		tensor.WithBacking(scaled),
+	)
+	err := G.Let(b.actions, actionsTensor)
+	if err != nil {
+		return nil, fmt.Errorf("logPdfOf: could not set actions: %v", err)
+	}
+
+	return b.LogPdfNode(), nil
+}
+
+// SelectAction selects and returns an action at the argument timestep
+// t.
+func (b *BetaTreeMLP) SelectAction(t timestep.TimeStep) *mat.VecDense {
+	if size := b.Network().BatchSize(); size != 1 {
+		panic(fmt.Sprintf("selectAction: action selection can only be done "+
+			"with a policy with batch size 1 \n\twant(1) \n\thave(%v)", size))
+	}
+
+	obs := t.Observation.RawVector().Data
+	if err := b.Network().SetInput(obs); err != nil {
+		panic(fmt.Sprintf("selectAction: cannot set input: %v", err))
+	}
+
+	if err := b.vm.RunAll(); err != nil {
+		panic(fmt.Sprintf("selectAction: could not run policy VM: %v", err))
+	}
+	defer b.vm.Reset()
+
+	alpha := b.alphaVal.Data().([]float64)
+	beta := b.betaVal.Data().([]float64)
+
+	action := make([]float64, b.actionDims)
+	for i := range action {
+		var x float64
+		if b.IsEval() {
+			x = alpha[i] / (alpha[i] + beta[i])
+		} else {
+			dist := distuv.Beta{Alpha: alpha[i], Beta: beta[i], Src: b.rng}
+			x = dist.Rand()
+		}
+		action[i] = b.lower[i] + b.width[i]*x
+	}
+
+	return mat.NewVecDense(b.actionDims, action)
+}
+
+// LogPdfNode returns the node that will hold the log probability
+// of actions when the comptuational graph is run.
+func (b *BetaTreeMLP) LogPdfNode() *G.Node {
+	return b.logPdfNode
+}
+
+// LogPdfVal returns the value of the node returned by LogPdfNode()
+func (b *BetaTreeMLP) LogPdfVal() G.Value {
+	return b.logPdfVal
+}
+
+// Clone clones a BetaTreeMLP
+func (b *BetaTreeMLP) Clone() (agent.NNPolicy, error) {
+	panic("clone: not implemented")
+}
+
+// CloneWithBatch clones a BetaTreeMLP with a new batch size
+func (b *BetaTreeMLP) CloneWithBatch(batch int) (agent.NNPolicy, error) {
+	panic("cloneWithBatch: not implemented")
+}
+
+// Network returns the network of the BetaTreeMLP
+func (b *BetaTreeMLP) Network() network.NeuralNet {
+	return b.net
+}
+
+// Train sets the policy to training mode
+func (b *BetaTreeMLP) Train() {
+	b.eval = false
+	network.SetEval(b.net, false)
+}
+
+// Eval sets the policy to evaluation mode
+func (b *BetaTreeMLP) Eval() {
+	b.eval = true
+	network.SetEval(b.net, true)
+}
+
+// IsEval returns whether or not the policy is in evaluation mode
+func (b *BetaTreeMLP) IsEval() bool {
+	return b.eval
+}
+
+// Alpha returns the α shape parameter of the Beta distribution
+func (b *BetaTreeMLP) Alpha() G.Value {
+	return b.alphaVal
+}
+
+// Beta returns the β shape parameter of the Beta distribution
+func (b *BetaTreeMLP) Beta() G.Value {
+	return b.betaVal
+}
+
+// Close cleans up resources after the policy is no longer needed
+func (b *BetaTreeMLP) Close() error {
+	if b.vm != nil {
+		return b.vm.Close()
+	}
+	return nil
+}
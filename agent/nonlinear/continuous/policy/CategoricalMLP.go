@@ -36,6 +36,13 @@ const minProb float64 = 1e-5
 // the gradient of sampling from a categorical distribution?
 //
 // https://towardsdatascience.com/what-is-gumbel-softmax-7f6d9cdcb90e
+//
+// Environments with MultiDiscrete actions are supported using a product
+// of categorical distributions, one for each action dimension. The MLP
+// predicts the logits of the actions in each dimension, and the action
+// in each dimension is selected independently, so that the log
+// probability of an action is the sum of the log probabilities of the
+// actions in each dimension.
 type CategoricalMLP struct {
 	net network.NeuralNet
 	vm  G.VM
@@ -57,6 +64,9 @@ type CategoricalMLP struct {
 	logProbInputActions    *G.Node
 	logProbInputActionsVal G.Value
 
+	// Entropy of the policy in each state input to the policy
+	entropy *G.Node
+
 	// Matrix of one-hot rows, where each row specifies which action
 	// to calculate the log prob of. These actions are input to the
 	// policy, they may or may not have been selected by the policy
@@ -66,16 +76,12 @@ type CategoricalMLP struct {
 
 	batchForLogProb int         // Number of actions to comput log prob of
 	numActions      int         // Number of avalable actions in each state
+	branches        []int       // Number of actions in each action dimension
 	source          rand.Source // Source for action selection RNG
 	seed            uint64      // Seed for source
 	rng             *rand.Rand  // RNG for breaking action ties in eval mode
 
-	// Fields needed for cloning
-	hiddenSizes []int
-	biases      []bool
-	activations []*network.Activation
-	features    int
-	eval        bool
+	eval bool
 }
 
 // NewCategoricalMLP creates a new CategoricalMLP. The CategoricalMLP
@@ -102,7 +108,7 @@ func NewCategoricalMLP(env environment.Environment, batchForLogProb int,
 	}
 
 	features := env.ObservationSpec().Shape.Len()
-	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+	branches, numActions := actionBranches(env)
 
 	// Create the MLP for predicting the action logits in each state
 	net, err := network.NewMultiHeadMLP(features, batchForLogProb, numActions,
@@ -112,6 +118,37 @@ func NewCategoricalMLP(env environment.Environment, batchForLogProb int,
 			"not create policy network: %v", err)
 	}
 
+	return newCategoricalMLP(net, branches, seed)
+}
+
+// actionBranches returns the number of actions in each dimension of
+// the discrete actions of env as well as the total number of actions
+// over all dimensions
+func actionBranches(env environment.Environment) ([]int, int) {
+	branches := env.ActionSpec().DiscreteSizes()
+
+	numActions := 0
+	for _, n := range branches {
+		numActions += n
+	}
+	return branches, numActions
+}
+
+// newCategoricalMLP returns a new CategoricalMLP which uses net to
+// predict action logits. The batch size of net determines the number
+// of (state, action) pairs used when predicting the log probability of
+// input actions. The branches parameter is the number of actions in
+// each action dimension.
+func newCategoricalMLP(net network.NeuralNet, branches []int,
+	seed uint64) (*CategoricalMLP, error) {
+	if outputs := net.Outputs(); len(outputs) != 1 {
+		err := fmt.Errorf("newCategoricalMLP: policy network should have a "+
+			"single output layer \n\twant(1) \n\thave(%v)", len(outputs))
+		return &CategoricalMLP{}, err
+	}
+	batchForLogProb := net.BatchSize()
+	numActions := net.Outputs()[0]
+
 	// Logits and probabilities of action selection for the current
 	// policy in the state(s) inputted to the policy's neural net.
 	logits := net.Prediction()[0]
@@ -134,7 +171,31 @@ func NewCategoricalMLP(env environment.Environment, batchForLogProb int,
 	)
 	logitsInputActions := G.Must(G.HadamardProd(actionIndices, logits))
 	logitsInputActions = G.Must(G.Sum(logitsInputActions, 1))
-	inputsLogSumExp := op.LogSumExp(logits, 1)
+
+	// The log normalizing constant and entropy of a product of
+	// categorical distributions are the sums of those of the
+	// distribution in each action dimension
+	var inputsLogSumExp, entropy *G.Node
+	if len(branches) == 1 {
+		inputsLogSumExp = op.LogSumExp(logits, 1)
+		entropy = op.CategoricalEntropy(logits)
+	} else {
+		start := 0
+		for _, n := range branches {
+			branchLogits := G.Must(G.Slice(logits, nil, G.S(start, start+n)))
+			start += n
+
+			branchLogSumExp := op.LogSumExp(branchLogits, 1)
+			branchEntropy := op.CategoricalEntropy(branchLogits)
+			if inputsLogSumExp == nil {
+				inputsLogSumExp, entropy = branchLogSumExp, branchEntropy
+			} else {
+				inputsLogSumExp = G.Must(G.Add(inputsLogSumExp,
+					branchLogSumExp))
+				entropy = G.Must(G.Add(entropy, branchEntropy))
+			}
+		}
+	}
 	logProbInputActions := G.Must(G.Sub(logitsInputActions, inputsLogSumExp))
 
 	// Create the rng for breaking action ties
@@ -149,19 +210,17 @@ func NewCategoricalMLP(env environment.Environment, batchForLogProb int,
 		actionIndices: actionIndices,
 
 		logProbInputActions: logProbInputActions,
+		entropy:             entropy,
 
 		batchForLogProb: batchForLogProb,
 		numActions:      numActions,
+		branches:        branches,
 
 		source: source,
 		rng:    rng,
 		seed:   seed,
 
-		hiddenSizes: hiddenSizes,
-		biases:      biases,
-		activations: activations,
-		features:    features,
-		eval:        false,
+		eval: false,
 	}
 
 	// Keep track of some node's values
@@ -193,7 +252,8 @@ func (c *CategoricalMLP) Logits() G.Value {
 // graph will populate the log probability node with the log probability
 // of selecting actions a in states s. The argument a should be a
 // slice of discrete actions (0, 1, ..., N) and *not* a one-hot encoded
-// version of these actions.
+// version of these actions. For multi-discrete actions, a should hold
+// the action in each dimension for each state consecutively.
 //
 // The reason this function does not return the log PDF of actions is
 // because this would require running the policy's VM, which does
@@ -206,10 +266,15 @@ func (c *CategoricalMLP) LogPdfOf(s, a []float64) (*G.Node, error) {
 		panic(err)
 	}
 
+	dims := len(c.branches)
 	actionIndices := make([]float64, 0, c.numActions*c.batchForLogProb)
-	for i := range a {
+	for i := 0; i < len(a); i += dims {
 		row := make([]float64, c.numActions)
-		row[int(a[i])] = 1.0
+		start := 0
+		for j, n := range c.branches {
+			row[start+int(a[i+j])] = 1.0
+			start += n
+		}
 		actionIndices = append(actionIndices, row...)
 	}
 	actionIndicesTensor := tensor.NewDense(tensor.Float64,
@@ -237,23 +302,31 @@ func (c *CategoricalMLP) SelectAction(t timestep.TimeStep) *mat.VecDense {
 	if err := c.vm.RunAll(); err != nil {
 		panic(fmt.Sprintf("selectAction: could not run policy VM: %v", err))
 	}
-	logits := c.probsVal.Data().([]float64)
+	probs := c.probsVal.Data().([]float64)
 	c.vm.Reset()
 
-	// If in evalutaion mode, select the highest probability action
-	if c.IsEval() {
-		maxActions := floatutils.ArgMax(logits...)
-
-		// If multiple actions have the highest probability, choose
-		// from them uniformly randomly
-		action := maxActions[c.rng.Int()%len(maxActions)]
-		return mat.NewVecDense(1, []float64{float64(action)})
+	// Select the action in each action dimension independently
+	action := mat.NewVecDense(len(c.branches), nil)
+	start := 0
+	for i, n := range c.branches {
+		logits := probs[start : start+n]
+		start += n
+
+		// If in evalutaion mode, select the highest probability action
+		if c.IsEval() {
+			maxActions := floatutils.ArgMax(logits...)
+
+			// If multiple actions have the highest probability, choose
+			// from them uniformly randomly
+			selected := maxActions[c.rng.Int()%len(maxActions)]
+			action.SetVec(i, float64(selected))
+			continue
+		}
+
+		dist := distuv.NewCategorical(logits, c.source)
+		action.SetVec(i, dist.Rand())
 	}
 
-	dist := distuv.NewCategorical(logits, c.source)
-	selected := dist.Rand()
-	action := mat.NewVecDense(1, []float64{selected})
-
 	return action
 }
 
@@ -268,6 +341,13 @@ func (c *CategoricalMLP) LogPdfVal() G.Value {
 	return c.logProbInputActionsVal
 }
 
+// EntropyNode returns the node that will hold the entropy of the
+// policy in each state input with LogPdfOf() when the computational
+// graph is run.
+func (c *CategoricalMLP) EntropyNode() *G.Node {
+	return c.entropy
+}
+
 // Clone clones a CategoricalMLP
 func (c *CategoricalMLP) Clone() (agent.NNPolicy, error) {
 	return c.CloneWithBatch(c.Network().BatchSize())
@@ -275,75 +355,23 @@ func (c *CategoricalMLP) Clone() (agent.NNPolicy, error) {
 
 // CloneWithBatch clones a CategoricalMLP with a new batch size
 func (c *CategoricalMLP) CloneWithBatch(batch int) (agent.NNPolicy, error) {
-	net, err := network.NewMultiHeadMLP(c.features, batch, c.numActions,
-		G.NewGraph(), c.hiddenSizes, c.biases, G.Zeroes(), c.activations)
+	net, err := c.net.CloneWithBatch(batch)
 	if err != nil {
-		return &CategoricalMLP{}, fmt.Errorf("newCategoricalMLP: could "+
-			"not create policy network: %v", err)
+		return &CategoricalMLP{}, fmt.Errorf("cloneWithBatch: could "+
+			"not clone policy network: %v", err)
 	}
 
-	// Logits and probabilities of action selection for the current
-	// policy in the state(s) inputted to the policy's neural net.
-	logits := net.Prediction()[0]
-	probs := G.Must(G.Exp(logits))
-
-	// Compute the log probability of actions that are input by an
-	// external source using the LogProbOf() method.
-	actionIndices := G.NewMatrix(
-		net.Graph(),
-		tensor.Float64,
-		G.WithShape(logits.Shape()...),
-		G.WithInit(G.Zeroes()),
-		G.WithName("Action Indices"),
-	)
-	logitsInputActions := G.Must(G.HadamardProd(actionIndices, logits))
-	logitsInputActions = G.Must(G.Sum(logitsInputActions, 1))
-	inputsLogSumExp := op.LogSumExp(logits, 1)
-	logProbInputActions := G.Must(G.Sub(logitsInputActions, inputsLogSumExp))
-
-	// Create the rng for breaking action ties
-	source := rand.NewSource(c.seed)
-	rng := rand.New(source)
-
-	pol := &CategoricalMLP{
-		net:    net,
-		logits: logits,
-		probs:  probs,
-
-		actionIndices: actionIndices,
-
-		logProbInputActions: logProbInputActions,
-
-		batchForLogProb: batch,
-		numActions:      c.numActions,
-
-		source: source,
-		rng:    rng,
-		seed:   c.seed,
-
-		hiddenSizes: c.hiddenSizes,
-		biases:      c.biases,
-		activations: c.activations,
-		features:    c.features,
-		eval:        c.eval,
+	// Set the prototype's weights to be the original net's weights.
+	if err := network.Set(net, c.net); err != nil {
+		return &CategoricalMLP{}, fmt.Errorf("cloneWithBatch: could "+
+			"not set policy network weights: %v", err)
 	}
 
-	// Keep track of some node's values
-	G.Read(pol.logits, &pol.logitsVals)
-	G.Read(pol.logProbInputActions, &pol.logProbInputActionsVal)
-	G.Read(probs, &pol.probsVal)
-
-	// If using a batch to compute the log probabilities of action
-	// selection, then the policy cannot be used for action selection
-	// at each timestep (which has only 1 action => batch size 1).
-	// In this case, it's assumed that an external VM will be used for
-	// learning the policy's weights.
-	if batch == 1 {
-		pol.vm = G.NewTapeMachine(net.Graph())
+	pol, err := newCategoricalMLP(net, c.branches, c.seed)
+	if err != nil {
+		return &CategoricalMLP{}, fmt.Errorf("cloneWithBatch: %v", err)
 	}
-
-	// Set the prototype's weights to be the original net's weights.
-	network.Set(net, c.net)
+	pol.eval = c.eval
 
 	return pol, nil
 }
@@ -356,11 +384,13 @@ func (c *CategoricalMLP) Network() network.NeuralNet {
 // Train sets the policy to training mode
 func (c *CategoricalMLP) Train() {
 	c.eval = false
+	network.SetEval(c.net, false)
 }
 
 // Eval sets the policy to evaluation mode
 func (c *CategoricalMLP) Eval() {
 	c.eval = true
+	network.SetEval(c.net, true)
 }
 
 // IsEval returns whether or not the policy is in evaluation mode
@@ -375,3 +405,59 @@ func (c *CategoricalMLP) Close() error {
 	}
 	return nil
 }
+
+// categoricalFile is the content of a file holding a saved
+// CategoricalMLP
+type categoricalFile struct {
+	Model *network.Model
+	Seed  uint64
+}
+
+// Save saves the CategoricalMLP, including the architecture and weights
+// of its network, to a file at path. The policy can be reloaded onto a
+// new computational graph using LoadCategoricalMLP.
+func (c *CategoricalMLP) Save(path string) error {
+	model, err := network.NewModel(c.net)
+	if err != nil {
+		return fmt.Errorf("save: %v", err)
+	}
+
+	if err := writeFile(path, categoricalFile{model, c.seed}); err != nil {
+		return fmt.Errorf("save: %v", err)
+	}
+	return nil
+}
+
+// LoadCategoricalMLP loads a CategoricalMLP saved to the file at path,
+// building its network on graph g. The loaded policy selects actions
+// in environment env, and the batchForLogProb parameter has the same
+// meaning as in NewCategoricalMLP.
+func LoadCategoricalMLP(path string, env environment.Environment,
+	batchForLogProb int, g *G.ExprGraph) (agent.LogPdfOfer, error) {
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("loadCategoricalMLP: softmax policy cannot be " +
+			"used with continuous actions")
+		return &CategoricalMLP{}, err
+	}
+
+	var file categoricalFile
+	if err := readFile(path, &file); err != nil {
+		return &CategoricalMLP{}, fmt.Errorf("loadCategoricalMLP: %v", err)
+	}
+
+	net, err := file.Model.BuildWithBatch(batchForLogProb, g)
+	if err != nil {
+		return &CategoricalMLP{}, fmt.Errorf("loadCategoricalMLP: %v", err)
+	}
+
+	branches, numActions := actionBranches(env)
+	if outputs := net.Outputs(); len(outputs) != 1 ||
+		outputs[0] != numActions {
+		err := fmt.Errorf("loadCategoricalMLP: saved policy does not "+
+			"match environment actions \n\twant([%v]) \n\thave(%v)",
+			numActions, outputs)
+		return &CategoricalMLP{}, err
+	}
+
+	return newCategoricalMLP(net, branches, file.Seed)
+}
@@ -48,8 +48,10 @@ type GaussianTreeMLP struct {
 	actions    *G.Node
 	logPdfNode *G.Node
 	logPdfVal  G.Value
+	entropy    *G.Node
 
 	normal          distmv.Rander
+	seed            uint64
 	actionDims      int
 	batchForLogProb int
 
@@ -113,6 +115,26 @@ func NewGaussianTreeMLP(env environment.Environment, batchForLogProb int,
 		panic(err)
 	}
 
+	return newGaussianTreeMLP(env, net, seed)
+}
+
+// newGaussianTreeMLP returns a new GaussianTreeMLP which uses net to
+// predict the mean and log standard deviation of the policy in
+// environment env. The batch size of net determines the batch size
+// used when computing the log probability of actions.
+func newGaussianTreeMLP(env environment.Environment, net network.NeuralNet,
+	seed uint64) (*GaussianTreeMLP, error) {
+	actionDims := env.ActionSpec().Shape.Len()
+	if outputs := net.Outputs(); len(outputs) != 2 ||
+		outputs[0] != actionDims || outputs[1] != actionDims {
+		err := fmt.Errorf("newGaussianTreeMLP: policy network should "+
+			"predict the mean and log standard deviation of each action "+
+			"\n\twant([%v %v]) \n\thave(%v)", actionDims, actionDims,
+			outputs)
+		return &GaussianTreeMLP{}, err
+	}
+	batchForLogProb := net.BatchSize()
+
 	// Scale the mean to be within the action bounds
 	mean := net.Prediction()[0]
 
@@ -125,7 +147,7 @@ func NewGaussianTreeMLP(env environment.Environment, batchForLogProb int,
 	upperBound := make([]float64, actionLen*batchForLogProb)
 	for i := 0; i < len(upperBound); i += actionLen {
 		copy(
-			upperBound[i*actionLen:(i+1)*actionLen],
+			upperBound[i:i+actionLen],
 			env.ActionSpec().UpperBound.(*mat.VecDense).RawVector().Data,
 		)
 	}
@@ -148,9 +170,11 @@ func NewGaussianTreeMLP(env environment.Environment, batchForLogProb int,
 	std := G.Must(G.Exp(logStd))
 	std = G.Must(G.Add(offset, std))
 
-	// Calculate log probability of input actions
+	// Calculate log probability of input actions and entropy in input
+	// states
 	var actions *G.Node
 	var logPdfNode *G.Node
+	var entropy *G.Node
 	if batchForLogProb > 1 {
 		actions = G.NewMatrix(
 			net.Graph(),
@@ -160,6 +184,7 @@ func NewGaussianTreeMLP(env environment.Environment, batchForLogProb int,
 			G.WithInit(G.Zeroes()),
 		)
 		logPdfNode = op.GaussianLogPdf(mean, std, actions)
+		entropy = op.GaussianEntropy(std)
 	}
 
 	// Create standard normal for action selection
@@ -178,8 +203,10 @@ func NewGaussianTreeMLP(env environment.Environment, batchForLogProb int,
 
 		actions:    actions,
 		logPdfNode: logPdfNode,
+		entropy:    entropy,
 
 		normal:          normal,
+		seed:            seed,
 		actionDims:      actionDims,
 		batchForLogProb: batchForLogProb,
 		eval:            false,
@@ -284,6 +311,14 @@ func (c *GaussianTreeMLP) LogPdfVal() G.Value {
 	return c.logPdfVal
 }
 
+// EntropyNode returns the node that will hold the entropy of the
+// policy in each state input with LogPdfOf() when the computational
+// graph is run. The returned node is nil if the policy has a batch
+// size of 1.
+func (c *GaussianTreeMLP) EntropyNode() *G.Node {
+	return c.entropy
+}
+
 // Clone clones a GaussianTreeMLP
 func (c *GaussianTreeMLP) Clone() (agent.NNPolicy, error) {
 	panic("clone: not implemented")
@@ -302,11 +337,13 @@ func (g *GaussianTreeMLP) Network() network.NeuralNet {
 // Train sets the policy to training mode
 func (g *GaussianTreeMLP) Train() {
 	g.eval = false
+	network.SetEval(g.net, false)
 }
 
 // Eval sets the policy to evaluation mode
 func (g *GaussianTreeMLP) Eval() {
 	g.eval = true
+	network.SetEval(g.net, true)
 }
 
 // IsEval returns whether or not the policy is in evaluation mode
@@ -329,3 +366,54 @@ func (g *GaussianTreeMLP) Close() error {
 	}
 	return nil
 }
+
+// gaussianTreeFile is the content of a file holding a saved
+// GaussianTreeMLP
+type gaussianTreeFile struct {
+	Model *network.Model
+	Seed  uint64
+}
+
+// Save saves the GaussianTreeMLP, including the architecture and
+// weights of its network, to a file at path. The policy can be
+// reloaded onto a new computational graph using LoadGaussianTreeMLP.
+func (g *GaussianTreeMLP) Save(path string) error {
+	model, err := network.NewModel(g.net)
+	if err != nil {
+		return fmt.Errorf("save: %v", err)
+	}
+
+	if err := writeFile(path, gaussianTreeFile{model, g.seed}); err != nil {
+		return fmt.Errorf("save: %v", err)
+	}
+	return nil
+}
+
+// LoadGaussianTreeMLP loads a GaussianTreeMLP saved to the file at
+// path, building its network on graph g. The loaded policy selects
+// actions in environment env, and the batchForLogProb parameter has
+// the same meaning as in NewGaussianTreeMLP.
+func LoadGaussianTreeMLP(path string, env environment.Environment,
+	batchForLogProb int, g *G.ExprGraph) (agent.LogPdfOfer, error) {
+	if env.ActionSpec().Cardinality != environment.Continuous {
+		err := fmt.Errorf("loadGaussianTreeMLP: actions should be " +
+			"continuous")
+		return &GaussianTreeMLP{}, err
+	}
+
+	var file gaussianTreeFile
+	if err := readFile(path, &file); err != nil {
+		return &GaussianTreeMLP{}, fmt.Errorf("loadGaussianTreeMLP: %v", err)
+	}
+
+	net, err := file.Model.BuildWithBatch(batchForLogProb, g)
+	if err != nil {
+		return &GaussianTreeMLP{}, fmt.Errorf("loadGaussianTreeMLP: %v", err)
+	}
+
+	pol, err := newGaussianTreeMLP(env, net, file.Seed)
+	if err != nil {
+		return &GaussianTreeMLP{}, fmt.Errorf("loadGaussianTreeMLP: %v", err)
+	}
+	return pol, nil
+}
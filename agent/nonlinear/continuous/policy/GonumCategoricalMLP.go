@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+
+	"golang.org/x/exp/rand"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
+	"github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+	G "gorgonia.org/gorgonia"
+)
+
+// GonumCategoricalMLP implements a categorical policy using a
+// gonumnet.MLP to predict action logits in each state. Given an
+// environment with N actions in each state, the probabilities of
+// selecting any action are:
+//
+//	π(a|s) := softmax(MLP(s))
+//
+// GonumCategoricalMLP is the gonum counterpart of CategoricalMLP.
+// Since gonumnet networks accept inputs of any batch size, the same
+// GonumCategoricalMLP can be used to select actions and to compute
+// the log probability of a batch of actions.
+type GonumCategoricalMLP struct {
+	net *gonumnet.MLP
+
+	numActions int
+	features   int
+	source     rand.Source // Source for action selection RNG
+	rng        *rand.Rand  // RNG for breaking action ties in eval mode
+
+	// Actions and action probabilities in the states most recently
+	// input with LogPdfOf, used to compute gradients
+	actions  []int
+	probs    []float64
+	logProbs []float64
+	grad     *mat.Dense
+
+	eval bool
+}
+
+// NewGonumCategoricalMLP creates and returns a new GonumCategoricalMLP.
+// The arguments are the same as those of NewCategoricalMLP, except
+// that no batch size or computational graph is needed.
+func NewGonumCategoricalMLP(env environment.Environment, hiddenSizes []int,
+	biases []bool, activations []*network.Activation, init G.InitWFn,
+	seed uint64) (agent.GonumLogPdfOfer, error) {
+	// Categorical policies can only be used with discrete actions
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("newGonumCategoricalMLP: softmax policy cannot " +
+			"be used with continuous actions")
+		return &GonumCategoricalMLP{}, err
+	}
+	if env.ActionSpec().Cardinality == environment.MultiDiscrete {
+		err := fmt.Errorf("newGonumCategoricalMLP: cannot use multi-discrete actions")
+		return &GonumCategoricalMLP{}, err
+	}
+
+	features := env.ObservationSpec().Shape.Len()
+	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+
+	// Create the MLP for predicting the action logits in each state
+	net, err := gonumnet.NewMLP(features, numActions, hiddenSizes, biases,
+		activations, init)
+	if err != nil {
+		return &GonumCategoricalMLP{}, fmt.Errorf("newGonumCategoricalMLP: "+
+			"could not create policy network: %v", err)
+	}
+
+	source := rand.NewSource(seed)
+	return &GonumCategoricalMLP{
+		net:        net,
+		numActions: numActions,
+		features:   features,
+		source:     source,
+		rng:        rand.New(source),
+		eval:       false,
+	}, nil
+}
+
+// SelectAction selects and returns an action at the argument timestep
+// t.
+func (c *GonumCategoricalMLP) SelectAction(
+	t timestep.TimeStep) *mat.VecDense {
+	obs := t.Observation.RawVector().Data
+	logits, err := c.net.Forward(mat.NewDense(1, len(obs), obs))
+	if err != nil {
+		panic(fmt.Sprintf("selectAction: could not predict logits: %v", err))
+	}
+
+	// Compute the unnormalized probabilities, offset for numerical
+	// stability
+	probs := make([]float64, c.numActions)
+	copy(probs, logits[0].RawMatrix().Data)
+	max := floatutils.Max(probs...)
+	for i := range probs {
+		probs[i] = math.Exp(probs[i]-max) + minProb
+	}
+
+	// If in evalutaion mode, select the highest probability action
+	if c.IsEval() {
+		maxActions := floatutils.ArgMax(probs...)
+
+		// If multiple actions have the highest probability, choose
+		// from them uniformly randomly
+		action := maxActions[c.rng.Int()%len(maxActions)]
+		return mat.NewVecDense(1, []float64{float64(action)})
+	}
+
+	dist := distuv.NewCategorical(probs, c.source)
+	return mat.NewVecDense(1, []float64{dist.Rand()})
+}
+
+// LogPdfOf returns the log probability of taking actions a in states
+// s. Inputs should be constructed in row major order.
+func (c *GonumCategoricalMLP) LogPdfOf(s, a []float64) ([]float64, error) {
+	batch := len(a)
+	if len(s) != batch*c.features {
+		return nil, fmt.Errorf("logPdfOf: states and actions have "+
+			"different batch sizes \n\twant(%v) \n\thave(%v)", batch*c.features,
+			len(s))
+	}
+
+	logits, err := c.net.Forward(mat.NewDense(batch, c.features, s))
+	if err != nil {
+		return nil, fmt.Errorf("logPdfOf: could not predict logits: %v", err)
+	}
+
+	if len(c.actions) != batch {
+		c.actions = make([]int, batch)
+		c.probs = make([]float64, batch*c.numActions)
+		c.logProbs = make([]float64, batch*c.numActions)
+		c.grad = mat.NewDense(batch, c.numActions, nil)
+	}
+
+	// Compute the log softmax of the logits
+	logPdf := make([]float64, batch)
+	for i := 0; i < batch; i++ {
+		row := logits[0].RawRowView(i)
+		logProbs := c.logProbs[i*c.numActions : (i+1)*c.numActions]
+		probs := c.probs[i*c.numActions : (i+1)*c.numActions]
+
+		max := floatutils.Max(row...)
+		sum := 0.0
+		for j := range row {
+			sum += math.Exp(row[j] - max)
+		}
+		logSumExp := max + math.Log(sum)
+
+		for j := range row {
+			logProbs[j] = row[j] - logSumExp
+			probs[j] = math.Exp(logProbs[j])
+		}
+
+		c.actions[i] = int(a[i])
+		logPdf[i] = logProbs[c.actions[i]]
+	}
+
+	return logPdf, nil
+}
+
+// LogPdfBackward computes the gradient of
+//
+//	Σᵢ wᵢ ln π(aᵢ | sᵢ) + β H(π(⋅ | sᵢ))
+//
+// with respect to the weights of the policy's network, where the
+// states sᵢ and actions aᵢ are those most recently input with
+// LogPdfOf.
+func (c *GonumCategoricalMLP) LogPdfBackward(w []float64, β float64) error {
+	if len(w) != len(c.actions) {
+		return fmt.Errorf("logPdfBackward: invalid number of weights "+
+			"\n\twant(%v) \n\thave(%v)", len(c.actions), len(w))
+	}
+
+	grad := c.grad.RawMatrix().Data
+	for i := range c.actions {
+		logProbs := c.logProbs[i*c.numActions : (i+1)*c.numActions]
+		probs := c.probs[i*c.numActions : (i+1)*c.numActions]
+
+		entropy := 0.0
+		for j := range probs {
+			entropy -= probs[j] * logProbs[j]
+		}
+
+		// ∂ln π(a)/∂zⱼ = 𝟙(a = j) - πⱼ
+		// ∂H/∂zⱼ = -πⱼ (ln πⱼ + H)
+		for j := range probs {
+			g := -w[i] * probs[j]
+			if j == c.actions[i] {
+				g += w[i]
+			}
+			g -= β * probs[j] * (logProbs[j] + entropy)
+			grad[i*c.numActions+j] = g
+		}
+	}
+
+	if err := c.net.Backward([]*mat.Dense{c.grad}); err != nil {
+		return fmt.Errorf("logPdfBackward: %v", err)
+	}
+	return nil
+}
+
+// Network returns the network of the GonumCategoricalMLP
+func (c *GonumCategoricalMLP) Network() gonumnet.Net {
+	return c.net
+}
+
+// Train sets the policy to training mode
+func (c *GonumCategoricalMLP) Train() {
+	c.eval = false
+}
+
+// Eval sets the policy to evaluation mode
+func (c *GonumCategoricalMLP) Eval() {
+	c.eval = true
+}
+
+// IsEval returns whether or not the policy is in evaluation mode
+func (c *GonumCategoricalMLP) IsEval() bool {
+	return c.eval
+}
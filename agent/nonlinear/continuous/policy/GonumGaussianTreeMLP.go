@@ -0,0 +1,249 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
+	"github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distmv"
+	G "gorgonia.org/gorgonia"
+)
+
+// GonumGaussianTreeMLP implements a Gaussian policy using a
+// gonumnet.TreeMLP. The tree MLP has a single root network which
+// breaks off into two leaf networks. One predicts the mean, and the
+// other the log standard deviation of a Gaussian distribution with
+// diagonal covariance.
+//
+// As with GaussianTreeMLP, the mean is squashed by a hyperbolic
+// tangent and scaled by the upper action bound, and the standard
+// deviation is offset by a small constant for numerical stability.
+// In evaluation mode, the mean action is selected.
+//
+// GonumGaussianTreeMLP is the gonum counterpart of GaussianTreeMLP.
+// Since gonumnet networks accept inputs of any batch size, the same
+// GonumGaussianTreeMLP can be used to select actions and to compute
+// the log probability of a batch of actions.
+type GonumGaussianTreeMLP struct {
+	net *gonumnet.TreeMLP
+
+	normal     distmv.Rander
+	actionDims int
+	features   int
+	scale      []float64 // Upper action bound, which scales the mean
+
+	// Actions and the distribution parameters in the states most
+	// recently input with LogPdfOf, used to compute gradients
+	actions    []float64
+	mean       []float64
+	tanhMean   []float64
+	stddev     []float64
+	meanGrad   *mat.Dense
+	logStdGrad *mat.Dense
+
+	eval bool
+}
+
+// NewGonumGaussianTreeMLP creates and returns a new
+// GonumGaussianTreeMLP. The arguments are the same as those of
+// NewGaussianTreeMLP, except that no batch size or computational graph
+// is needed.
+func NewGonumGaussianTreeMLP(env environment.Environment,
+	rootHiddenSizes []int, rootBiases []bool,
+	rootActivations []*network.Activation, leafHiddenSizes [][]int,
+	leafBiases [][]bool, leafActivations [][]*network.Activation,
+	init G.InitWFn, seed uint64) (agent.GonumLogPdfOfer, error) {
+	if env.ActionSpec().Cardinality != environment.Continuous {
+		return nil, fmt.Errorf("newGonumGaussianTreeMLP: actions should " +
+			"be continuous")
+	}
+	if len(leafHiddenSizes) != 2 {
+		return nil, fmt.Errorf("newGonumGaussianTreeMLP: gaussian policy " +
+			"requires 2 leaf networks only")
+	}
+
+	features := env.ObservationSpec().Shape.Len()
+	actionDims := env.ActionSpec().Shape.Len()
+
+	net, err := gonumnet.NewTreeMLP(features, actionDims, rootHiddenSizes,
+		rootBiases, rootActivations, leafHiddenSizes, leafBiases,
+		leafActivations, init)
+	if err != nil {
+		return nil, fmt.Errorf("newGonumGaussianTreeMLP: could not create "+
+			"network: %v", err)
+	}
+
+	scale := make([]float64, actionDims)
+	for i := range scale {
+		scale[i] = env.ActionSpec().UpperBound.AtVec(i)
+	}
+
+	// Create standard normal for action selection
+	means := make([]float64, actionDims)
+	stds := mat.NewDiagDense(actionDims, floatutils.Ones(actionDims))
+	normal, ok := distmv.NewNormal(means, stds, rand.NewSource(seed))
+	if !ok {
+		// This should never happen
+		panic("newGonumGaussianTreeMLP: could not create standard normal " +
+			"for action selection")
+	}
+
+	return &GonumGaussianTreeMLP{
+		net:        net,
+		normal:     normal,
+		actionDims: actionDims,
+		features:   features,
+		scale:      scale,
+		eval:       false,
+	}, nil
+}
+
+// distribution computes the mean and standard deviation in each of the
+// states s, which has batch rows. The hyperbolic tangent of the
+// network's mean prediction is also returned.
+func (g *GonumGaussianTreeMLP) distribution(s []float64, batch int) (mean,
+	tanhMean, stddev []float64, err error) {
+	outputs, err := g.net.Forward(mat.NewDense(batch, g.features, s))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	meanPred := outputs[0].RawMatrix().Data
+	logStd := outputs[1].RawMatrix().Data
+
+	mean = make([]float64, len(meanPred))
+	tanhMean = make([]float64, len(meanPred))
+	stddev = make([]float64, len(logStd))
+	for i := range mean {
+		tanhMean[i] = math.Tanh(meanPred[i])
+		mean[i] = g.scale[i%g.actionDims] * tanhMean[i]
+		stddev[i] = math.Exp(logStd[i]) + stdOffset
+	}
+	return mean, tanhMean, stddev, nil
+}
+
+// SelectAction selects and returns an action at the argument timestep
+// t.
+func (g *GonumGaussianTreeMLP) SelectAction(
+	t timestep.TimeStep) *mat.VecDense {
+	obs := t.Observation.RawVector().Data
+	mean, _, stddev, err := g.distribution(obs, 1)
+	if err != nil {
+		panic(fmt.Sprintf("selectAction: could not predict action "+
+			"distribution: %v", err))
+	}
+
+	// If in evaluation mode, return the mean action only
+	if g.IsEval() {
+		return mat.NewVecDense(g.actionDims, mean)
+	}
+
+	eps := g.normal.Rand(nil)
+	for i := range mean {
+		mean[i] += stddev[i] * eps[i]
+	}
+	return mat.NewVecDense(g.actionDims, mean)
+}
+
+// LogPdfOf returns the log probability of taking actions a in states
+// s. Inputs should be constructed in row major order.
+func (g *GonumGaussianTreeMLP) LogPdfOf(s, a []float64) ([]float64, error) {
+	batch := len(a) / g.actionDims
+	if len(s) != batch*g.features || len(a) != batch*g.actionDims {
+		return nil, fmt.Errorf("logPdfOf: states and actions have " +
+			"different batch sizes")
+	}
+
+	var err error
+	g.mean, g.tanhMean, g.stddev, err = g.distribution(s, batch)
+	if err != nil {
+		return nil, fmt.Errorf("logPdfOf: could not predict action "+
+			"distribution: %v", err)
+	}
+	g.actions = a
+
+	logPdf := make([]float64, batch)
+	constant := -float64(g.actionDims) / 2.0 * math.Log(2*math.Pi)
+	for i := range logPdf {
+		logPdf[i] = constant
+		for j := i * g.actionDims; j < (i+1)*g.actionDims; j++ {
+			z := (a[j] - g.mean[j]) / g.stddev[j]
+			logPdf[i] -= 0.5*z*z + math.Log(g.stddev[j])
+		}
+	}
+
+	return logPdf, nil
+}
+
+// LogPdfBackward computes the gradient of
+//
+//	Σᵢ wᵢ ln π(aᵢ | sᵢ) + β H(π(⋅ | sᵢ))
+//
+// with respect to the weights of the policy's network, where the
+// states sᵢ and actions aᵢ are those most recently input with
+// LogPdfOf.
+func (g *GonumGaussianTreeMLP) LogPdfBackward(w []float64,
+	β float64) error {
+	batch := len(g.actions) / g.actionDims
+	if len(w) != batch {
+		return fmt.Errorf("logPdfBackward: invalid number of weights "+
+			"\n\twant(%v) \n\thave(%v)", batch, len(w))
+	}
+
+	if g.meanGrad == nil || g.meanGrad.RawMatrix().Rows != batch {
+		g.meanGrad = mat.NewDense(batch, g.actionDims, nil)
+		g.logStdGrad = mat.NewDense(batch, g.actionDims, nil)
+	}
+	meanGrad := g.meanGrad.RawMatrix().Data
+	logStdGrad := g.logStdGrad.RawMatrix().Data
+
+	for j := range meanGrad {
+		i := j / g.actionDims
+		diff := g.actions[j] - g.mean[j]
+		variance := g.stddev[j] * g.stddev[j]
+
+		// ∂ln π/∂μ = (a - μ) / σ², and μ = scale * tanh(x)
+		dMean := w[i] * diff / variance
+		meanGrad[j] = dMean * g.scale[j%g.actionDims] *
+			(1 - g.tanhMean[j]*g.tanhMean[j])
+
+		// ∂ln π/∂σ = (a - μ)² / σ³ - 1 / σ, ∂H/∂σ = 1 / σ, and
+		// σ = exp(x) + stdOffset
+		dStd := w[i]*(diff*diff/(variance*g.stddev[j])-1/g.stddev[j]) +
+			β/g.stddev[j]
+		logStdGrad[j] = dStd * (g.stddev[j] - stdOffset)
+	}
+
+	err := g.net.Backward([]*mat.Dense{g.meanGrad, g.logStdGrad})
+	if err != nil {
+		return fmt.Errorf("logPdfBackward: %v", err)
+	}
+	return nil
+}
+
+// Network returns the network of the GonumGaussianTreeMLP
+func (g *GonumGaussianTreeMLP) Network() gonumnet.Net {
+	return g.net
+}
+
+// Train sets the policy to training mode
+func (g *GonumGaussianTreeMLP) Train() {
+	g.eval = false
+}
+
+// Eval sets the policy to evaluation mode
+func (g *GonumGaussianTreeMLP) Eval() {
+	g.eval = true
+}
+
+// IsEval returns whether or not the policy is in evaluation mode
+func (g *GonumGaussianTreeMLP) IsEval() bool {
+	return g.eval
+}
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"math"
+
+	"github.com/samuelfneumann/golearn/utils/op"
+	G "gorgonia.org/gorgonia"
+)
+
+// logGamma calculates the log of the gamma function of each element
+// of x, which must be positive.
+//
+// The recurrence lnΓ(x) = lnΓ(x + 6) - ln(x(x+1)...(x+5)) is used to
+// shift x so that Stirling's series can be used to approximate
+// lnΓ(x + 6) to high precision for all positive x.
+func logGamma(x *G.Node) *G.Node {
+	const shift = 6
+
+	// Calculate ln(x(x+1)...(x+5))
+	correction := G.Must(G.Log(x))
+	for k := 1; k < shift; k++ {
+		xk := G.Must(G.Add(x, G.NewConstant(float64(k))))
+		correction = G.Must(G.Add(correction, G.Must(G.Log(xk))))
+	}
+
+	// Calculate Stirling's series for lnΓ(z), z = x + 6:
+	// (z - 0.5) ln(z) - z + 0.5 ln(2π) + 1/(12z) - 1/(360z³) +
+	// 1/(1260z⁵) - 1/(1680z⁷)
+	z := G.Must(G.Add(x, G.NewConstant(float64(shift))))
+	zHalf := G.Must(G.Sub(z, G.NewConstant(0.5)))
+	stirling := G.Must(G.HadamardProd(zHalf, G.Must(G.Log(z))))
+	stirling = G.Must(G.Sub(stirling, z))
+	stirling = G.Must(G.Add(stirling, G.NewConstant(0.5*math.Log(2*math.Pi))))
+
+	inv := G.Must(G.Inverse(z))
+	inv2 := G.Must(G.Square(inv))
+	series := G.Must(G.Mul(inv2, G.NewConstant(1.0/1680.0)))
+	series = G.Must(G.Sub(G.NewConstant(1.0/1260.0), series))
+	series = G.Must(G.HadamardProd(inv2, series))
+	series = G.Must(G.Sub(G.NewConstant(1.0/360.0), series))
+	series = G.Must(G.HadamardProd(inv2, series))
+	series = G.Must(G.Sub(G.NewConstant(1.0/12.0), series))
+	series = G.Must(G.HadamardProd(inv, series))
+	stirling = G.Must(G.Add(stirling, series))
+
+	return G.Must(G.Sub(stirling, correction))
+}
+
+// betaLogPdf calculates the log of the probability density function of
+// x drawn from a product of independent Beta distributions with shape
+// parameters alpha and beta.
+//
+// All arguments should be two-dimensional and of the same size m x n,
+// where the rows (m) denote the samples in the batch and the columns
+// (n) denote the dimensions of x. Each element of x must be in (0, 1).
+// The returned node has one log probability per sample in the batch.
+func betaLogPdf(alpha, beta, x *G.Node) *G.Node {
+	graph := alpha.Graph()
+	if graph != beta.Graph() || graph != x.Graph() {
+		panic("betaLogPdf: all nodes must share the same graph")
+	}
+
+	one := G.NewConstant(1.0)
+
+	// Calculate (α - 1) ln(x) + (β - 1) ln(1 - x)
+	alphaTerm := G.Must(G.HadamardProd(G.Must(G.Sub(alpha, one)),
+		G.Must(G.Log(x))))
+	betaTerm := G.Must(G.HadamardProd(G.Must(G.Sub(beta, one)),
+		G.Must(G.Log(G.Must(G.Sub(one, x))))))
+
+	// Calculate ln(B(α, β)) = lnΓ(α) + lnΓ(β) - lnΓ(α + β)
+	logNorm := G.Must(G.Add(logGamma(alpha), logGamma(beta)))
+	logNorm = G.Must(G.Sub(logNorm, logGamma(G.Must(G.Add(alpha, beta)))))
+
+	logProb := G.Must(G.Add(alphaTerm, betaTerm))
+	logProb = G.Must(G.Sub(logProb, logNorm))
+
+	return G.Must(G.Sum(logProb, 1))
+}
+
+// squashedGaussianLogPdf calculates the log of the probability density
+// function of actions tanh(u), where u is drawn from a diagonal
+// Gaussian distribution with mean mean and standard deviation std.
+//
+// All arguments should be two-dimensional and of the same size m x n,
+// as outlined in op.GaussianLogPdf. Each element of actions must be in
+// (-1, 1). The log probability includes the correction for the
+// Jacobian of the tanh function:
+//
+//	ln π(a) = ln N(atanh(a); μ, σ) - Σᵢ ln(1 - aᵢ²)
+func squashedGaussianLogPdf(mean, std, actions *G.Node) *G.Node {
+	one := G.NewConstant(1.0)
+	half := G.NewConstant(0.5)
+
+	// Calculate u = atanh(a) = 0.5 * (ln(1 + a) - ln(1 - a))
+	onePlus := G.Must(G.Log(G.Must(G.Add(one, actions))))
+	oneMinus := G.Must(G.Log(G.Must(G.Sub(one, actions))))
+	u := G.Must(G.HadamardProd(half, G.Must(G.Sub(onePlus, oneMinus))))
+
+	// Calculate the Jacobian correction Σᵢ ln(1 - aᵢ²)
+	jacobian := G.Must(G.Sub(one, G.Must(G.Square(actions))))
+	jacobian = G.Must(G.Sum(G.Must(G.Log(jacobian)), 1))
+
+	return G.Must(G.Sub(op.GaussianLogPdf(mean, std, u), jacobian))
+}
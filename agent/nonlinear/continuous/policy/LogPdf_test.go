@@ -0,0 +1,250 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/classiccontrol/pendulum"
+	"github.com/samuelfneumann/golearn/network"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/floats/scalar"
+	"gonum.org/v1/gonum/spatial/r1"
+	"gonum.org/v1/gonum/stat/distuv"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// eval returns the value of the node returned by f when given inputs
+// of shape rows x cols holding the values of each argument
+func eval(t *testing.T, rows, cols int, f func(...*G.Node) *G.Node,
+	args ...[]float64) []float64 {
+	t.Helper()
+
+	g := G.NewGraph()
+	nodes := make([]*G.Node, len(args))
+	for i, arg := range args {
+		value := tensor.NewDense(tensor.Float64, []int{rows, cols},
+			tensor.WithBacking(arg))
+		nodes[i] = G.NewMatrix(g, tensor.Float64, G.WithShape(rows, cols),
+			G.WithName(fmt.Sprintf("arg%d", i)), G.WithValue(value))
+	}
+
+	var out G.Value
+	G.Read(f(nodes...), &out)
+
+	vm := G.NewTapeMachine(g)
+	defer vm.Close()
+	if err := vm.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	switch data := out.Data().(type) {
+	case []float64:
+		return data
+	case float64:
+		return []float64{data}
+	default:
+		t.Fatalf("unexpected output type %T", data)
+		return nil
+	}
+}
+
+// checkSums checks that the log probability of each multi-dimensional
+// sample in have is the sum of the log probabilities of each dimension,
+// which are consecutive elements of want
+func checkSums(t *testing.T, name string, have, want []float64,
+	tol float64) {
+	t.Helper()
+
+	dims := len(want) / len(have)
+	for i := range have {
+		sum := floats.Sum(want[i*dims : (i+1)*dims])
+		if !scalar.EqualWithinAbsOrRel(have[i], sum, tol, tol) {
+			t.Errorf("%v multi-dimensional sample %d: have(%v) want(%v)",
+				name, i, have[i], sum)
+		}
+	}
+}
+
+// TestLogGamma tests logGamma against math.Lgamma
+func TestLogGamma(t *testing.T) {
+	x := []float64{0.01, 0.3, 0.5, 1, 1.5, 2, 7.5, 30, 200}
+
+	logGamma := func(nodes ...*G.Node) *G.Node { return logGamma(nodes[0]) }
+	have := eval(t, len(x), 1, logGamma, x)
+
+	for i := range x {
+		want, _ := math.Lgamma(x[i])
+		if !scalar.EqualWithinAbsOrRel(have[i], want, 1e-10, 1e-10) {
+			t.Errorf("logGamma(%v): have(%v) want(%v)", x[i], have[i], want)
+		}
+	}
+}
+
+// TestBetaLogPdf tests betaLogPdf against distuv.Beta.LogProb,
+// including shape parameters below 1, for which the density is
+// unbounded at the edges of its support, and values near 0 and 1
+func TestBetaLogPdf(t *testing.T) {
+	tests := []struct {
+		alpha, beta, x float64
+	}{
+		{1, 1, 0.5},
+		{2, 3, 0.4},
+		{5, 1, 0.9},
+		{50, 20, 0.7},
+		{0.5, 0.5, 0.1},
+		{0.5, 0.5, 0.999},
+		{0.3, 2, 1e-4},
+		{0.8, 0.2, 0.95},
+		{2, 0.7, 1 - 1e-6},
+		{1.5, 4, 1e-6},
+	}
+
+	alpha := make([]float64, len(tests))
+	beta := make([]float64, len(tests))
+	x := make([]float64, len(tests))
+	want := make([]float64, len(tests))
+	for i, test := range tests {
+		alpha[i], beta[i], x[i] = test.alpha, test.beta, test.x
+		want[i] = distuv.Beta{Alpha: test.alpha, Beta: test.beta}.LogProb(test.x)
+	}
+
+	betaLogPdf := func(nodes ...*G.Node) *G.Node {
+		return betaLogPdf(nodes[0], nodes[1], nodes[2])
+	}
+
+	// Each row holds a single sample of one dimension
+	have := eval(t, len(tests), 1, betaLogPdf, alpha, beta, x)
+	for i, test := range tests {
+		if !scalar.EqualWithinAbsOrRel(have[i], want[i], 1e-9, 1e-9) {
+			t.Errorf("betaLogPdf(α=%v, β=%v, x=%v): have(%v) want(%v)",
+				test.alpha, test.beta, test.x, have[i], want[i])
+		}
+	}
+
+	// Each row holds a single sample of many dimensions, whose log
+	// probability is the sum of that of each dimension
+	checkSums(t, "betaLogPdf", eval(t, 2, len(tests)/2, betaLogPdf, alpha,
+		beta, x), want, 1e-9)
+}
+
+// TestSquashedGaussianLogPdf tests squashedGaussianLogPdf against the
+// Gaussian log density corrected by a numerically differentiated tanh
+// Jacobian, including actions near -1 and 1
+func TestSquashedGaussianLogPdf(t *testing.T) {
+	tests := []struct {
+		mean, std, action float64
+	}{
+		{0, 1, 0},
+		{0.5, 0.3, 0.2},
+		{-1, 2, -0.9},
+		{0, 1, 0.999},
+		{0, 1, -0.999},
+		{2, 0.5, 0.99999},
+		{-3, 1.5, -0.99999},
+		{1, 0.01, 0.76},
+	}
+
+	// The derivative of tanh is numerically approximated by a central
+	// difference of width 2h
+	const h = 1e-4
+	mean := make([]float64, len(tests))
+	std := make([]float64, len(tests))
+	action := make([]float64, len(tests))
+	want := make([]float64, len(tests))
+	for i, test := range tests {
+		mean[i], std[i], action[i] = test.mean, test.std, test.action
+
+		u := math.Atanh(test.action)
+		jacobian := (math.Tanh(u+h) - math.Tanh(u-h)) / (2 * h)
+		normal := distuv.Normal{Mu: test.mean, Sigma: test.std}
+		want[i] = normal.LogProb(u) - math.Log(jacobian)
+	}
+
+	squashedGaussianLogPdf := func(nodes ...*G.Node) *G.Node {
+		return squashedGaussianLogPdf(nodes[0], nodes[1], nodes[2])
+	}
+
+	have := eval(t, len(tests), 1, squashedGaussianLogPdf, mean, std, action)
+	for i, test := range tests {
+		if !scalar.EqualWithinAbsOrRel(have[i], want[i], 1e-6, 1e-6) {
+			t.Errorf("squashedGaussianLogPdf(μ=%v, σ=%v, a=%v): have(%v) "+
+				"want(%v)", test.mean, test.std, test.action, have[i], want[i])
+		}
+	}
+
+	checkSums(t, "squashedGaussianLogPdf", eval(t, 2, len(tests)/2,
+		squashedGaussianLogPdf, mean, std, action), want, 1e-6)
+}
+
+// TestBoundedPolicyDensity tests that the densities of bounded
+// policies integrate to 1 over the action bounds of an environment, so
+// that the scaling of actions to the bounds is accounted for
+func TestBoundedPolicyDensity(t *testing.T) {
+	const points = 4000
+
+	// Pendulum has a single action dimension bounded in [-2, 2]
+	angle := r1.Interval{Min: -pendulum.AngleBound, Max: pendulum.AngleBound}
+	speed := r1.Interval{Min: -1.0, Max: 1.0}
+	starter := environment.NewUniformStarter([]r1.Interval{angle, speed}, 1)
+	env, _, err := pendulum.NewContinuous(pendulum.NewSwingUp(starter, 100),
+		0.99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lower := env.ActionSpec().LowerBound.AtVec(0)
+	upper := env.ActionSpec().UpperBound.AtVec(0)
+
+	relu := network.ReLU()
+	constructors := map[string]func(g *G.ExprGraph) (agent.LogPdfOfer, error){
+		"Beta": func(g *G.ExprGraph) (agent.LogPdfOfer, error) {
+			return NewBetaTreeMLP(env, points, g, []int{4}, []bool{true},
+				[]*network.Activation{relu}, [][]int{{4}, {4}},
+				[][]bool{{true}, {true}},
+				[][]*network.Activation{{relu}, {relu}}, G.Zeroes(), 1)
+		},
+		"SquashedGaussian": func(g *G.ExprGraph) (agent.LogPdfOfer, error) {
+			return NewSquashedGaussianTreeMLP(env, points, g, []int{4},
+				[]bool{true}, []*network.Activation{relu}, [][]int{{4}, {4}},
+				[][]bool{{true}, {true}},
+				[][]*network.Activation{{relu}, {relu}}, G.Zeroes(), 1)
+		},
+	}
+
+	for name, newPolicy := range constructors {
+		p, err := newPolicy(G.NewGraph())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Evaluate the density at the midpoints of a grid over the
+		// action bounds
+		width := (upper - lower) / points
+		actions := make([]float64, points)
+		for i := range actions {
+			actions[i] = lower + width*(float64(i)+0.5)
+		}
+		states := make([]float64, points*env.ObservationSpec().Shape.Len())
+		if _, err := p.LogPdfOf(states, actions); err != nil {
+			t.Fatal(err)
+		}
+
+		vm := G.NewTapeMachine(p.Network().Graph())
+		if err := vm.RunAll(); err != nil {
+			t.Fatal(err)
+		}
+		vm.Close()
+
+		integral := 0.0
+		for _, logProb := range p.LogPdfVal().Data().([]float64) {
+			integral += math.Exp(logProb) * width
+		}
+		if math.Abs(integral-1) > 1e-3 {
+			t.Errorf("%v: integral of density: have(%v) want(1)", name,
+				integral)
+		}
+	}
+}
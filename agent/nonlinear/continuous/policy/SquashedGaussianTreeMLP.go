@@ -0,0 +1,353 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distmv"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// Actions given to bounded policies are moved this far inside the
+// action bounds when computing log probabilities so that the log
+// probability of actions on the bounds is finite.
+const boundOffset float64 = 1e-6
+
+// SquashedGaussianTreeMLP implements a tanh-squashed Gaussian policy
+// parameterized by a tree MLP. Similar to GaussianTreeMLP, the MLP has
+// a single root network which breaks off into two leaf networks. One
+// predicts the mean, and the other the log standard deviation of a
+// Gaussian distribution.
+//
+// Given a network prediction of the mean μ and standard deviation σ,
+// actions are selected by sampling from the standard normal
+// ɛ ~ N(0, 1) and computing u := μ + σ * ɛ. The sample u is then
+// squashed by a hyperbolic tangent and scaled to the action bounds of
+// the environment:
+//
+//	action := offset + scale * tanh(u)
+//
+// where offset = (upper + lower) / 2 and scale = (upper - lower) / 2.
+// Unlike GaussianTreeMLP, the selected actions are therefore always
+// within the action bounds, and the log probability of actions
+// includes the correction for the Jacobian of this transformation:
+//
+//	ln π(a) = ln N(u; μ, σ) - Σᵢ ln(scaleᵢ(1 - tanh²(uᵢ)))
+//
+// In evaluation mode, the action offset + scale * tanh(μ) is selected.
+// The environment must have finite action bounds.
+type SquashedGaussianTreeMLP struct {
+	vm  G.VM
+	net network.NeuralNet
+
+	actions    *G.Node
+	logPdfNode *G.Node
+	logPdfVal  G.Value
+
+	normal          distmv.Rander
+	actionDims      int
+	batchForLogProb int
+
+	// Affine transformation from (-1, 1) to the action bounds
+	offset []float64
+	scale  []float64
+
+	meanVal   G.Value
+	stddevVal G.Value
+
+	eval bool
+}
+
+// NewSquashedGaussianTreeMLP returns a new SquashedGaussianTreeMLP
+// policy. The parameters are the same as those of NewGaussianTreeMLP.
+func NewSquashedGaussianTreeMLP(env environment.Environment,
+	batchForLogProb int, g *G.ExprGraph, rootHiddenSizes []int,
+	rootBiases []bool, rootActivations []*network.Activation,
+	leafHiddenSizes [][]int, leafBiases [][]bool,
+	leafActivations [][]*network.Activation, init G.InitWFn,
+	seed uint64) (agent.LogPdfOfer, error) {
+
+	if env.ActionSpec().Cardinality != environment.Continuous {
+		return nil, fmt.Errorf("newSquashedGaussianTreeMLP: actions " +
+			"should be continuous")
+	}
+	if len(leafHiddenSizes) != 2 {
+		return nil, fmt.Errorf("newSquashedGaussianTreeMLP: squashed " +
+			"gaussian policy requires 2 leaf networks only")
+	}
+
+	lower, upper, err := actionBounds(env)
+	if err != nil {
+		return nil, fmt.Errorf("newSquashedGaussianTreeMLP: %v", err)
+	}
+
+	features := env.ObservationSpec().Shape.Len()
+	actionDims := env.ActionSpec().Shape.Len()
+
+	net, err := network.NewTreeMLP(
+		features,
+		batchForLogProb,
+		actionDims,
+		G.NewGraph(),
+		rootHiddenSizes,
+		rootBiases,
+		rootActivations,
+		leafHiddenSizes,
+		leafBiases,
+		leafActivations,
+		init,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("newSquashedGaussianTreeMLP: could not "+
+			"create network: %v", err)
+	}
+
+	// Calculate the affine transformation to the action bounds
+	offset := make([]float64, actionDims)
+	scale := make([]float64, actionDims)
+	logScale := 0.0
+	for i := range offset {
+		offset[i] = (upper[i] + lower[i]) / 2.0
+		scale[i] = (upper[i] - lower[i]) / 2.0
+		logScale += math.Log(scale[i])
+	}
+
+	// Calculate the standard deviation and offset it for numerical
+	// stability
+	mean := net.Prediction()[0]
+	stdOffsetNode := G.NewConstant(stdOffset)
+	logStd := net.Prediction()[1]
+	std := G.Must(G.Exp(logStd))
+	std = G.Must(G.Add(stdOffsetNode, std))
+
+	// Calculate log probability of input actions, which are stored
+	// in the actions node after being transformed to (-1, 1)
+	var actions *G.Node
+	var logPdfNode *G.Node
+	if batchForLogProb > 1 {
+		actions = G.NewMatrix(
+			net.Graph(),
+			tensor.Float64,
+			G.WithName("InputActions"),
+			G.WithShape(batchForLogProb, actionDims),
+			G.WithInit(G.Zeroes()),
+		)
+		logPdfNode = squashedGaussianLogPdf(mean, std, actions)
+		logPdfNode = G.Must(G.Sub(logPdfNode, G.NewConstant(logScale)))
+	}
+
+	// Create standard normal for action selection
+	means := make([]float64, actionDims)
+	stds := mat.NewDiagDense(actionDims, floatutils.Ones(actionDims))
+	source := rand.NewSource(seed)
+	normal, ok := distmv.NewNormal(means, stds, source)
+	if !ok {
+		// This should never happen
+		panic("newSquashedGaussianTreeMLP: could not create standard " +
+			"normal for action selection")
+	}
+
+	pol := &SquashedGaussianTreeMLP{
+		net: net,
+
+		actions:    actions,
+		logPdfNode: logPdfNode,
+
+		normal:          normal,
+		actionDims:      actionDims,
+		batchForLogProb: batchForLogProb,
+
+		offset: offset,
+		scale:  scale,
+
+		eval: false,
+	}
+
+	// Record values of Gorgonia nodes
+	if batchForLogProb > 1 {
+		G.Read(pol.logPdfNode, &pol.logPdfVal)
+	}
+	G.Read(mean, &pol.meanVal)
+	G.Read(std, &pol.stddevVal)
+
+	// Policy can select actions at each timestep only if using a batch
+	// size of 1.
+	if net.BatchSize() == 1 {
+		pol.vm = G.NewTapeMachine(net.Graph())
+	}
+
+	return pol, nil
+}
+
+// LogPdfOf sets the state and action inputs of the policy's
+// computational graph to the argument state and actions (s and a
+// respectively) so that when a VM of the policy is run, the log
+// probabliity of actions a taken in states s will be computed and
+// stored in the policy's associate log PDF node, which is returned.
+//
+// Actions on or outside the action bounds are moved just inside the
+// bounds so that their log probability is finite.
+func (s *SquashedGaussianTreeMLP) LogPdfOf(states,
+	a []float64) (*G.Node, error) {
+	if err := s.Network().SetInput(states); err != nil {
+		return nil, fmt.Errorf("logPdfOf: could not set states: %v", err)
+	}
+
+	// Transform actions to (-1, 1)
+	squashed := make([]float64, len(a))
+	for i := range a {
+		dim := i % s.actionDims
+		squashed[i] = floatutils.Clip((a[i]-s.offset[dim])/s.scale[dim],
+			-1+boundOffset, 1-boundOffset)
+	}
+
+	actionsTensor := tensor.NewDense(tensor.Float64,
+		[]int{s.batchForLogProb, s.actionDims},
+		tensor.WithBacking(squashed),
+	)
+	err := G.Let(s.actions, actionsTensor)
+	if err != nil {
+		return nil, fmt.Errorf("logPdfOf: could not set actions: %v", err)
+	}
+
+	return s.LogPdfNode(), nil
+}
+
+// SelectAction selects and returns an action at the argument timestep
+// t.
+func (s *SquashedGaussianTreeMLP) SelectAction(
+	t timestep.TimeStep) *mat.VecDense {
+	if size := s.Network().BatchSize(); size != 1 {
+		panic(fmt.Sprintf("selectAction: action selection can only be done "+
+			"with a policy with batch size 1 \n\twant(1) \n\thave(%v)", size))
+	}
+
+	obs := t.Observation.RawVector().Data
+	if err := s.Network().SetInput(obs); err != nil {
+		panic(fmt.Sprintf("selectAction: cannot set input: %v", err))
+	}
+
+	if err := s.vm.RunAll(); err != nil {
+		panic(fmt.Sprintf("selectAction: could not run policy VM: %v", err))
+	}
+	defer s.vm.Reset()
+
+	mean := s.meanVal.Data().([]float64)
+	stddev := s.stddevVal.Data().([]float64)
+
+	// Sample from the Gaussian, using only the mean in evaluation mode
+	u := make([]float64, s.actionDims)
+	copy(u, mean)
+	if !s.IsEval() {
+		eps := s.normal.Rand(nil)
+		for i := range u {
+			u[i] += stddev[i] * eps[i]
+		}
+	}
+
+	// Squash the sample to the action bounds
+	action := make([]float64, s.actionDims)
+	for i := range action {
+		action[i] = s.offset[i] + s.scale[i]*math.Tanh(u[i])
+	}
+
+	return mat.NewVecDense(s.actionDims, action)
+}
+
+// LogPdfNode returns the node that will hold the log probability
+// of actions when the comptuational graph is run.
+func (s *SquashedGaussianTreeMLP) LogPdfNode() *G.Node {
+	return s.logPdfNode
+}
+
+// LogPdfVal returns the value of the node returned by LogPdfNode()
+func (s *SquashedGaussianTreeMLP) LogPdfVal() G.Value {
+	return s.logPdfVal
+}
+
+// Clone clones a SquashedGaussianTreeMLP
+func (s *SquashedGaussianTreeMLP) Clone() (agent.NNPolicy, error) {
+	panic("clone: not implemented")
+}
+
+// CloneWithBatch clones a SquashedGaussianTreeMLP with a new batch size
+func (s *SquashedGaussianTreeMLP) CloneWithBatch(
+	batch int) (agent.NNPolicy, error) {
+	panic("cloneWithBatch: not implemented")
+}
+
+// Network returns the network of the SquashedGaussianTreeMLP
+func (s *SquashedGaussianTreeMLP) Network() network.NeuralNet {
+	return s.net
+}
+
+// Train sets the policy to training mode
+func (s *SquashedGaussianTreeMLP) Train() {
+	s.eval = false
+	network.SetEval(s.net, false)
+}
+
+// Eval sets the policy to evaluation mode
+func (s *SquashedGaussianTreeMLP) Eval() {
+	s.eval = true
+	network.SetEval(s.net, true)
+}
+
+// IsEval returns whether or not the policy is in evaluation mode
+func (s *SquashedGaussianTreeMLP) IsEval() bool {
+	return s.eval
+}
+
+// Mean returns the mean of the Gaussian distribution before squashing
+func (s *SquashedGaussianTreeMLP) Mean() G.Value {
+	return s.meanVal
+}
+
+// StdDev returns the standard deviation of the Gaussian distribution
+// before squashing
+func (s *SquashedGaussianTreeMLP) StdDev() G.Value {
+	return s.stddevVal
+}
+
+// Close cleans up resources after the policy is no longer needed
+func (s *SquashedGaussianTreeMLP) Close() error {
+	if s.vm != nil {
+		return s.vm.Close()
+	}
+	return nil
+}
+
+// actionBounds returns the lower and upper bounds of actions in env.
+// An error is returned if any bound is infinite.
+func actionBounds(env environment.Environment) (lower, upper []float64,
+	err error) {
+	spec := env.ActionSpec()
+	dims := spec.Shape.Len()
+
+	lower = make([]float64, dims)
+	upper = make([]float64, dims)
+	for i := 0; i < dims; i++ {
+		lower[i] = spec.LowerBound.AtVec(i)
+		upper[i] = spec.UpperBound.AtVec(i)
+
+		if math.IsInf(lower[i], 0) || math.IsInf(upper[i], 0) {
+			return nil, nil, fmt.Errorf("actionBounds: action bounds must " +
+				"be finite")
+		}
+		if lower[i] >= upper[i] {
+			return nil, nil, fmt.Errorf("actionBounds: lower action bound "+
+				"%v must be less than upper action bound %v", lower[i],
+				upper[i])
+		}
+	}
+
+	return lower, upper, nil
+}
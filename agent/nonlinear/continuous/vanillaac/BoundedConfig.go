@@ -0,0 +1,155 @@
+package vanillaac
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/agent/nonlinear/continuous/policy"
+	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+)
+
+func init() {
+	// Register ConfigList types so that they can be typed using
+	// agent.TypedConfigList to help with serialization/deserialization.
+	agent.Register(agent.SquashedGaussianVanillaACTreeMLP,
+		SquashedGaussianTreeMLPConfigList{})
+	agent.Register(agent.BetaVanillaACTreeMLP, BetaTreeMLPConfigList{})
+}
+
+// SquashedGaussianTreeMLPConfigList implements functionality for
+// storing a list of SquashedGaussianTreeMLPConfig's in a simple way.
+// It has the same fields as GaussianTreeMLPConfigList.
+type SquashedGaussianTreeMLPConfigList GaussianTreeMLPConfigList
+
+// Config returns an empty Config that is of the type stored by
+// SquashedGaussianTreeMLPConfigList
+func (s SquashedGaussianTreeMLPConfigList) Config() agent.Config {
+	return SquashedGaussianTreeMLPConfig{}
+}
+
+// Type returns the type of Config stored in the list
+func (s SquashedGaussianTreeMLPConfigList) Type() agent.Type {
+	return s.Config().Type()
+}
+
+// Len returns the number of configurations stored in the list
+func (s SquashedGaussianTreeMLPConfigList) Len() int {
+	return GaussianTreeMLPConfigList(s).Len()
+}
+
+// NumFields gets the total number of settable fields/hyperparameters
+// for the agent configuration
+func (s SquashedGaussianTreeMLPConfigList) NumFields() int {
+	rValue := reflect.ValueOf(s)
+	return rValue.NumField()
+}
+
+// SquashedGaussianTreeMLPConfig implements a configuration for a
+// tanh-squashed Gaussian policy vanilla actor critic agent. The configuration is the
+// same as that of GaussianTreeMLPConfig, but the policy is a
+// policy.SquashedGaussianTreeMLP, which always selects actions within
+// the action bounds of the environment.
+type SquashedGaussianTreeMLPConfig GaussianTreeMLPConfig
+
+// BatchSize gets the batch size for the policy generated by this config
+func (s SquashedGaussianTreeMLPConfig) BatchSize() int {
+	return GaussianTreeMLPConfig(s).BatchSize()
+}
+
+// Validate checks a Config to ensure it is a valid configuration
+func (s SquashedGaussianTreeMLPConfig) Validate() error {
+	if s.Backend == network.Gonum {
+		return fmt.Errorf("squashed Gaussian policy does not support " +
+			"the Gonum backend")
+	}
+	return GaussianTreeMLPConfig(s).Validate()
+}
+
+// ValidAgent returns true if the argument agent can be constructed
+// from the Config and false otherwise.
+func (s SquashedGaussianTreeMLPConfig) ValidAgent(a agent.Agent) bool {
+	return GaussianTreeMLPConfig(s).ValidAgent(a)
+}
+
+// Type returns the type of agent constructed by the Config
+func (s SquashedGaussianTreeMLPConfig) Type() agent.Type {
+	return agent.SquashedGaussianVanillaACTreeMLP
+}
+
+// CreateAgent creates and returns the agent determine by the
+// configuration
+func (s SquashedGaussianTreeMLPConfig) CreateAgent(e env.Environment,
+	seed uint64) (agent.Agent, error) {
+	return GaussianTreeMLPConfig(s).createAgent(e, seed,
+		policy.NewSquashedGaussianTreeMLP)
+}
+
+// BetaTreeMLPConfigList implements functionality for storing a list
+// of BetaTreeMLPConfig's in a simple way. It has the same fields as
+// GaussianTreeMLPConfigList.
+type BetaTreeMLPConfigList GaussianTreeMLPConfigList
+
+// Config returns an empty Config that is of the type stored by
+// BetaTreeMLPConfigList
+func (b BetaTreeMLPConfigList) Config() agent.Config {
+	return BetaTreeMLPConfig{}
+}
+
+// Type returns the type of Config stored in the list
+func (b BetaTreeMLPConfigList) Type() agent.Type {
+	return b.Config().Type()
+}
+
+// Len returns the number of configurations stored in the list
+func (b BetaTreeMLPConfigList) Len() int {
+	return GaussianTreeMLPConfigList(b).Len()
+}
+
+// NumFields gets the total number of settable fields/hyperparameters
+// for the agent configuration
+func (b BetaTreeMLPConfigList) NumFields() int {
+	rValue := reflect.ValueOf(b)
+	return rValue.NumField()
+}
+
+// BetaTreeMLPConfig implements a configuration for a Beta policy
+// vanilla actor critic agent. The configuration is the same as that of
+// GaussianTreeMLPConfig, but the policy is a policy.BetaTreeMLP, whose
+// leaf networks predict the shape parameters of a Beta distribution
+// over the action bounds of the environment.
+type BetaTreeMLPConfig GaussianTreeMLPConfig
+
+// BatchSize gets the batch size for the policy generated by this config
+func (b BetaTreeMLPConfig) BatchSize() int {
+	return GaussianTreeMLPConfig(b).BatchSize()
+}
+
+// Validate checks a Config to ensure it is a valid configuration
+func (b BetaTreeMLPConfig) Validate() error {
+	if b.Backend == network.Gonum {
+		return fmt.Errorf("beta policy does not support the Gonum " +
+			"backend")
+	}
+	return GaussianTreeMLPConfig(b).Validate()
+}
+
+// ValidAgent returns true if the argument agent can be constructed
+// from the Config and false otherwise.
+func (b BetaTreeMLPConfig) ValidAgent(a agent.Agent) bool {
+	return GaussianTreeMLPConfig(b).ValidAgent(a)
+}
+
+// Type returns the type of agent constructed by the Config
+func (b BetaTreeMLPConfig) Type() agent.Type {
+	return agent.BetaVanillaACTreeMLP
+}
+
+// CreateAgent creates and returns the agent determine by the
+// configuration
+func (b BetaTreeMLPConfig) CreateAgent(e env.Environment,
+	seed uint64) (agent.Agent, error) {
+	return GaussianTreeMLPConfig(b).createAgent(e, seed,
+		policy.NewBetaTreeMLP)
+}
@@ -12,6 +12,7 @@ import (
 	env "github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/initwfn"
 	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
 	"github.com/samuelfneumann/golearn/solver"
 )
 
@@ -46,6 +47,26 @@ type CategoricalMLPConfigList struct {
 
 	Tau                  []float64
 	TargetUpdateInterval []int
+
+	// Optional state value function architecture, which replaces
+	// ValueFnLayers, ValueFnBiases, and ValueFnActivations
+	ValueFn []*network.Spec
+
+	// Optional coefficient of the policy entropy bonus in the policy
+	// loss. If unset, no entropy bonus is used.
+	EntropyCoefficient []float64
+
+	// Optional setting of whether standardizing TD error advantages
+	// over each sampled batch before each policy update is disabled. If
+	// unset, advantages are standardized.
+	DisableAdvantageNormalization []bool
+
+	// Optional neural network backend
+	Backend []network.Backend
+
+	// Optional flag to select uniformly random actions until the
+	// experience replay buffer can be sampled from
+	RandomWarmup []bool
 }
 
 func NewCategoricalMLPConfigList(
@@ -102,10 +123,16 @@ func (c CategoricalMLPConfigList) Type() agent.Type {
 // Len returns the number of configurations stored in the list
 func (c CategoricalMLPConfigList) Len() int {
 	return len(c.Layers) * len(c.Biases) * len(c.Activations) *
-		len(c.ValueFnLayers) * len(c.ValueFnBiases) *
-		len(c.ValueFnActivations) * len(c.InitWFn) * len(c.PolicySolver) *
-		len(c.VSolver) * len(c.ValueGradSteps) *
-		len(c.ExpReplay) * len(c.Tau) * len(c.TargetUpdateInterval)
+		agent.NumSettings(len(c.ValueFnLayers)) *
+		agent.NumSettings(len(c.ValueFnBiases)) *
+		agent.NumSettings(len(c.ValueFnActivations)) * len(c.InitWFn) *
+		len(c.PolicySolver) * len(c.VSolver) * len(c.ValueGradSteps) *
+		len(c.ExpReplay) * len(c.Tau) * len(c.TargetUpdateInterval) *
+		agent.NumSettings(len(c.ValueFn)) *
+		agent.NumSettings(len(c.EntropyCoefficient)) *
+		agent.NumSettings(len(c.DisableAdvantageNormalization)) *
+		agent.NumSettings(len(c.Backend)) *
+		agent.NumSettings(len(c.RandomWarmup))
 }
 
 // NumFields gets the total number of settable fields/hyperparameters
@@ -153,6 +180,36 @@ type CategoricalMLPConfig struct {
 
 	Tau                  float64
 	TargetUpdateInterval int
+
+	// Optional state value function architecture. If non-nil, ValueFn
+	// is used to construct the state value function instead of
+	// ValueFnLayers, ValueFnBiases, and ValueFnActivations.
+	ValueFn *network.Spec
+
+	// Coefficient of the policy entropy bonus in the policy loss
+	EntropyCoefficient float64
+
+	// Whether standardizing TD error advantages to mean 0 and standard
+	// deviation 1 over each sampled batch is disabled. Advantages are
+	// never standardized over batches of a single sample.
+	DisableAdvantageNormalization bool
+
+	// Policy and state value function to train when using the Gonum
+	// backend
+	gonumTrainPolicy  agent.GonumLogPdfOfer
+	gonumTrainValueFn gonumnet.Net
+
+	// Optional neural network backend. If Backend is network.Gonum,
+	// then a GonumVAC agent is created, which uses networks from
+	// package gonumnet. The Gonum backend cannot be used with ValueFn.
+	// The default backend is network.Gorgonia.
+	Backend network.Backend
+
+	// Optional flag to select actions uniformly randomly from the
+	// action space of the environment, instead of with the behaviour
+	// policy, until the experience replay buffer holds
+	// ExpReplay.MinReplayCapacity samples
+	RandomWarmup bool
 }
 
 // BatchSize gets the batch size for the policy generated by this config
@@ -166,12 +223,36 @@ func (g CategoricalMLPConfig) Validate() error {
 		return fmt.Errorf("cannot have batch size %v < 1", g.BatchSize())
 	}
 
+	if g.ValueFn != nil {
+		if err := g.ValueFn.Validate(); err != nil {
+			return fmt.Errorf("invalid value function: %v", err)
+		}
+		if len(g.ValueFn.Heads) > 1 {
+			return fmt.Errorf("value function must have a single head")
+		}
+	}
+
+	if g.EntropyCoefficient < 0 {
+		return fmt.Errorf("cannot have negative entropy coefficient")
+	}
+
+	if !g.Backend.IsValid() {
+		return fmt.Errorf("invalid backend %q", g.Backend)
+	}
+	if g.Backend == network.Gonum && g.ValueFn != nil {
+		return fmt.Errorf("cannot use ValueFn with the Gonum backend")
+	}
+
 	return nil
 }
 
 // ValidAgent returns true if the argument agent can be constructed
 // from the Config and false otherwise.
 func (g CategoricalMLPConfig) ValidAgent(a agent.Agent) bool {
+	if g.Backend == network.Gonum {
+		_, ok := a.(*GonumVAC)
+		return ok
+	}
 	_, ok := a.(*VAC)
 	return ok
 }
@@ -185,6 +266,10 @@ func (c CategoricalMLPConfig) Type() agent.Type {
 // configuration
 func (g CategoricalMLPConfig) CreateAgent(e env.Environment,
 	seed uint64) (agent.Agent, error) {
+	if g.Backend == network.Gonum {
+		return g.createGonumAgent(e, seed)
+	}
+
 	behaviour, err := policy.NewCategoricalMLP(
 		e,
 		1,
@@ -216,43 +301,38 @@ func (g CategoricalMLPConfig) CreateAgent(e env.Environment,
 
 	features := e.ObservationSpec().Shape.Len()
 
-	valueFn, err := network.NewSingleHeadMLP(
-		features,
-		1,
-		G.NewGraph(),
-		g.ValueFnLayers,
-		g.ValueFnBiases,
-		g.InitWFn.InitWFn(),
-		g.ValueFnActivations,
-	)
+	// newValueFn creates a new state value function with the given
+	// batch size, using the configured network architecture
+	newValueFn := func(batch int) (network.NeuralNet, error) {
+		if g.ValueFn != nil {
+			return agent.NewNetwork(g.ValueFn, e, batch, 1,
+				G.NewGraph(), seed)
+		}
+
+		return network.NewSingleHeadMLP(
+			features,
+			batch,
+			G.NewGraph(),
+			g.ValueFnLayers,
+			g.ValueFnBiases,
+			g.InitWFn.InitWFn(),
+			g.ValueFnActivations,
+		)
+	}
+
+	valueFn, err := newValueFn(1)
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create "+
 			"value function: %v", err)
 	}
 
-	trainValueFn, err := network.NewSingleHeadMLP(
-		features,
-		g.BatchSize(),
-		G.NewGraph(),
-		g.ValueFnLayers,
-		g.ValueFnBiases,
-		g.InitWFn.InitWFn(),
-		g.ValueFnActivations,
-	)
+	trainValueFn, err := newValueFn(g.BatchSize())
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create train "+
 			"value function: %v", err)
 	}
 
-	targetValueFn, err := network.NewSingleHeadMLP(
-		features,
-		g.BatchSize(),
-		G.NewGraph(),
-		g.ValueFnLayers,
-		g.ValueFnBiases,
-		g.InitWFn.InitWFn(),
-		g.ValueFnActivations,
-	)
+	targetValueFn, err := newValueFn(g.BatchSize())
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create train "+
 			"value function: %v", err)
@@ -270,6 +350,41 @@ func (g CategoricalMLPConfig) CreateAgent(e env.Environment,
 	return New(e, g, int64(seed))
 }
 
+// createGonumAgent creates and returns a GonumVAC agent determined by
+// the configuration
+func (g CategoricalMLPConfig) createGonumAgent(e env.Environment,
+	seed uint64) (agent.Agent, error) {
+	p, err := policy.NewGonumCategoricalMLP(
+		e,
+		g.Layers,
+		g.Biases,
+		g.Activations,
+		g.InitWFn.InitWFn(),
+		seed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("createAgent: could not create policy: %v", err)
+	}
+
+	valueFn, err := gonumnet.NewMLP(
+		e.ObservationSpec().Shape.Len(),
+		1,
+		g.ValueFnLayers,
+		g.ValueFnBiases,
+		g.ValueFnActivations,
+		g.InitWFn.InitWFn(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("createAgent: could not create "+
+			"value function: %v", err)
+	}
+
+	g.gonumTrainPolicy = p
+	g.gonumTrainValueFn = valueFn
+
+	return NewGonum(e, g, int64(seed))
+}
+
 // Below implemented to satisfy the vanillapg.config interface
 // See the Config.go file in the vanillapg package for more details.
 
@@ -338,3 +453,38 @@ func (c CategoricalMLPConfig) tau() float64 {
 func (c CategoricalMLPConfig) targetUpdateInterval() int {
 	return c.TargetUpdateInterval
 }
+
+// entropyCoefficient returns the coefficient of the entropy bonus in
+// the policy loss
+func (c CategoricalMLPConfig) entropyCoefficient() float64 {
+	return c.EntropyCoefficient
+}
+
+// normalizeAdvantages returns whether advantages should be
+// standardized before each policy update
+func (c CategoricalMLPConfig) normalizeAdvantages() bool {
+	return !c.DisableAdvantageNormalization
+}
+
+// randomWarmup returns whether uniformly random actions should be
+// selected until the experience replay buffer can be sampled from
+func (c CategoricalMLPConfig) randomWarmup() bool {
+	return c.RandomWarmup
+}
+
+// backend returns the neural network backend to use
+func (c CategoricalMLPConfig) backend() network.Backend {
+	return c.Backend
+}
+
+// gonumPolicy returns the constructed policy to train when using the
+// Gonum backend
+func (c CategoricalMLPConfig) gonumPolicy() agent.GonumLogPdfOfer {
+	return c.gonumTrainPolicy
+}
+
+// gonumValueFn returns the constructed value function to train when
+// using the Gonum backend
+func (c CategoricalMLPConfig) gonumValueFn() gonumnet.Net {
+	return c.gonumTrainValueFn
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/samuelfneumann/golearn/buffer/expreplay"
 	"github.com/samuelfneumann/golearn/initwfn"
 	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
 	"github.com/samuelfneumann/golearn/solver"
 )
 
@@ -38,4 +39,23 @@ type config interface {
 
 	tau() float64
 	targetUpdateInterval() int
+
+	// Coefficient of the entropy bonus in the policy loss
+	entropyCoefficient() float64
+
+	// Whether advantages are standardized before each policy update
+	normalizeAdvantages() bool
+
+	// Whether uniformly random actions are selected until the
+	// experience replay buffer can be sampled from
+	randomWarmup() bool
+
+	// Neural network backend, which determines whether a VAC or a
+	// GonumVAC is constructed
+	backend() network.Backend
+
+	// Policy and state value function to train when using the Gonum
+	// backend
+	gonumPolicy() agent.GonumLogPdfOfer
+	gonumValueFn() gonumnet.Net
 }
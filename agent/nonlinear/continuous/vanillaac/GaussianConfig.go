@@ -12,6 +12,7 @@ import (
 	env "github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/initwfn"
 	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
 	"github.com/samuelfneumann/golearn/solver"
 )
 
@@ -54,6 +55,26 @@ type GaussianTreeMLPConfigList struct {
 
 	Tau                  []float64
 	TargetUpdateInterval []int
+
+	// Optional state value function architecture, which replaces
+	// ValueFnLayers, ValueFnBiases, and ValueFnActivations
+	ValueFn []*network.Spec
+
+	// Optional coefficient of the policy entropy bonus in the policy
+	// loss. If unset, no entropy bonus is used.
+	EntropyCoefficient []float64
+
+	// Optional setting of whether standardizing TD error advantages
+	// over each sampled batch before each policy update is disabled. If
+	// unset, advantages are standardized.
+	DisableAdvantageNormalization []bool
+
+	// Optional neural network backend
+	Backend []network.Backend
+
+	// Optional flag to select uniformly random actions until the
+	// experience replay buffer can be sampled from
+	RandomWarmup []bool
 }
 
 // NewGaussianTreeMLPConfigList returns a new GaussianTreeMLPConfigList
@@ -122,10 +143,16 @@ func (g GaussianTreeMLPConfigList) Type() agent.Type {
 func (g GaussianTreeMLPConfigList) Len() int {
 	return len(g.RootLayers) * len(g.RootBiases) * len(g.RootActivations) *
 		len(g.LeafLayers) * len(g.LeafBiases) * len(g.LeafActivations) *
-		len(g.ValueFnLayers) * len(g.ValueFnBiases) *
-		len(g.ValueFnActivations) * len(g.InitWFn) * len(g.PolicySolver) *
-		len(g.VSolver) * len(g.ValueGradSteps) *
-		len(g.ExpReplay) * len(g.Tau) * len(g.TargetUpdateInterval)
+		agent.NumSettings(len(g.ValueFnLayers)) *
+		agent.NumSettings(len(g.ValueFnBiases)) *
+		agent.NumSettings(len(g.ValueFnActivations)) * len(g.InitWFn) *
+		len(g.PolicySolver) * len(g.VSolver) * len(g.ValueGradSteps) *
+		len(g.ExpReplay) * len(g.Tau) * len(g.TargetUpdateInterval) *
+		agent.NumSettings(len(g.ValueFn)) *
+		agent.NumSettings(len(g.EntropyCoefficient)) *
+		agent.NumSettings(len(g.DisableAdvantageNormalization)) *
+		agent.NumSettings(len(g.Backend)) *
+		agent.NumSettings(len(g.RandomWarmup))
 }
 
 // NumFields gets the total number of settable fields/hyperparameters
@@ -177,6 +204,36 @@ type GaussianTreeMLPConfig struct {
 
 	Tau                  float64
 	TargetUpdateInterval int
+
+	// Optional state value function architecture. If non-nil, ValueFn
+	// is used to construct the state value function instead of
+	// ValueFnLayers, ValueFnBiases, and ValueFnActivations.
+	ValueFn *network.Spec
+
+	// Coefficient of the policy entropy bonus in the policy loss
+	EntropyCoefficient float64
+
+	// Whether standardizing TD error advantages to mean 0 and standard
+	// deviation 1 over each sampled batch is disabled. Advantages are
+	// never standardized over batches of a single sample.
+	DisableAdvantageNormalization bool
+
+	// Policy and state value function to train when using the Gonum
+	// backend
+	gonumTrainPolicy  agent.GonumLogPdfOfer
+	gonumTrainValueFn gonumnet.Net
+
+	// Optional neural network backend. If Backend is network.Gonum,
+	// then a GonumVAC agent is created, which uses networks from
+	// package gonumnet. The Gonum backend cannot be used with ValueFn.
+	// The default backend is network.Gorgonia.
+	Backend network.Backend
+
+	// Optional flag to select actions uniformly randomly from the
+	// action space of the environment, instead of with the behaviour
+	// policy, until the experience replay buffer holds
+	// ExpReplay.MinReplayCapacity samples
+	RandomWarmup bool
 }
 
 // BatchSize gets the batch size for the policy generated by this config
@@ -190,12 +247,36 @@ func (g GaussianTreeMLPConfig) Validate() error {
 		return fmt.Errorf("cannot have batch size %v < 1", g.BatchSize())
 	}
 
+	if g.ValueFn != nil {
+		if err := g.ValueFn.Validate(); err != nil {
+			return fmt.Errorf("invalid value function: %v", err)
+		}
+		if len(g.ValueFn.Heads) > 1 {
+			return fmt.Errorf("value function must have a single head")
+		}
+	}
+
+	if g.EntropyCoefficient < 0 {
+		return fmt.Errorf("cannot have negative entropy coefficient")
+	}
+
+	if !g.Backend.IsValid() {
+		return fmt.Errorf("invalid backend %q", g.Backend)
+	}
+	if g.Backend == network.Gonum && g.ValueFn != nil {
+		return fmt.Errorf("cannot use ValueFn with the Gonum backend")
+	}
+
 	return nil
 }
 
 // ValidAgent returns true if the argument agent can be constructed
 // from the Config and false otherwise.
 func (g GaussianTreeMLPConfig) ValidAgent(a agent.Agent) bool {
+	if g.Backend == network.Gonum {
+		_, ok := a.(*GonumVAC)
+		return ok
+	}
 	_, ok := a.(*VAC)
 	return ok
 }
@@ -209,7 +290,66 @@ func (c GaussianTreeMLPConfig) Type() agent.Type {
 // configuration
 func (g GaussianTreeMLPConfig) CreateAgent(e env.Environment,
 	seed uint64) (agent.Agent, error) {
-	behaviour, err := policy.NewGaussianTreeMLP(
+	if g.Backend == network.Gonum {
+		return g.createGonumAgent(e, seed)
+	}
+	return g.createAgent(e, seed, policy.NewGaussianTreeMLP)
+}
+
+// createGonumAgent creates and returns a GonumVAC agent determined by
+// the configuration
+func (g GaussianTreeMLPConfig) createGonumAgent(e env.Environment,
+	seed uint64) (agent.Agent, error) {
+	p, err := policy.NewGonumGaussianTreeMLP(
+		e,
+		g.RootLayers,
+		g.RootBiases,
+		g.RootActivations,
+		g.LeafLayers,
+		g.LeafBiases,
+		g.LeafActivations,
+		g.InitWFn.InitWFn(),
+		seed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("createAgent: could not create policy: %v", err)
+	}
+
+	valueFn, err := gonumnet.NewMLP(
+		e.ObservationSpec().Shape.Len(),
+		1,
+		g.ValueFnLayers,
+		g.ValueFnBiases,
+		g.ValueFnActivations,
+		g.InitWFn.InitWFn(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("createAgent: could not create "+
+			"value function: %v", err)
+	}
+
+	g.gonumTrainPolicy = p
+	g.gonumTrainValueFn = valueFn
+
+	return NewGonum(e, g, int64(seed))
+}
+
+// treeMLPPolicy constructs a policy parameterized by a tree MLP, such
+// as policy.NewGaussianTreeMLP
+type treeMLPPolicy func(env.Environment, int, *G.ExprGraph, []int, []bool,
+	[]*network.Activation, [][]int, [][]bool, [][]*network.Activation,
+	G.InitWFn, uint64) (agent.LogPdfOfer, error)
+
+// createAgent creates and returns the agent determined by the
+// configuration, using newPolicy to construct the agent's policies
+func (g GaussianTreeMLPConfig) createAgent(e env.Environment,
+	seed uint64, newPolicy treeMLPPolicy) (agent.Agent, error) {
+	if g.Backend == network.Gonum {
+		return nil, fmt.Errorf("createAgent: policy does not support " +
+			"the Gonum backend")
+	}
+
+	behaviour, err := newPolicy(
 		e,
 		1,
 		G.NewGraph(),
@@ -227,7 +367,7 @@ func (g GaussianTreeMLPConfig) CreateAgent(e env.Environment,
 			"behaviour policy: %v", err)
 	}
 
-	p, err := policy.NewGaussianTreeMLP(
+	p, err := newPolicy(
 		e,
 		g.BatchSize(),
 		G.NewGraph(),
@@ -246,43 +386,38 @@ func (g GaussianTreeMLPConfig) CreateAgent(e env.Environment,
 
 	features := e.ObservationSpec().Shape.Len()
 
-	valueFn, err := network.NewSingleHeadMLP(
-		features,
-		1,
-		G.NewGraph(),
-		g.ValueFnLayers,
-		g.ValueFnBiases,
-		g.InitWFn.InitWFn(),
-		g.ValueFnActivations,
-	)
+	// newValueFn creates a new state value function with the given
+	// batch size, using the configured network architecture
+	newValueFn := func(batch int) (network.NeuralNet, error) {
+		if g.ValueFn != nil {
+			return agent.NewNetwork(g.ValueFn, e, batch, 1,
+				G.NewGraph(), seed)
+		}
+
+		return network.NewSingleHeadMLP(
+			features,
+			batch,
+			G.NewGraph(),
+			g.ValueFnLayers,
+			g.ValueFnBiases,
+			g.InitWFn.InitWFn(),
+			g.ValueFnActivations,
+		)
+	}
+
+	valueFn, err := newValueFn(1)
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create "+
 			"value function: %v", err)
 	}
 
-	trainValueFn, err := network.NewSingleHeadMLP(
-		features,
-		g.BatchSize(),
-		G.NewGraph(),
-		g.ValueFnLayers,
-		g.ValueFnBiases,
-		g.InitWFn.InitWFn(),
-		g.ValueFnActivations,
-	)
+	trainValueFn, err := newValueFn(g.BatchSize())
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create train "+
 			"value function: %v", err)
 	}
 
-	targetValueFn, err := network.NewSingleHeadMLP(
-		features,
-		g.BatchSize(),
-		G.NewGraph(),
-		g.ValueFnLayers,
-		g.ValueFnBiases,
-		g.InitWFn.InitWFn(),
-		g.ValueFnActivations,
-	)
+	targetValueFn, err := newValueFn(g.BatchSize())
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create target "+
 			"value function: %v", err)
@@ -369,3 +504,38 @@ func (g GaussianTreeMLPConfig) tau() float64 {
 func (g GaussianTreeMLPConfig) targetUpdateInterval() int {
 	return g.TargetUpdateInterval
 }
+
+// entropyCoefficient returns the coefficient of the entropy bonus in
+// the policy loss
+func (g GaussianTreeMLPConfig) entropyCoefficient() float64 {
+	return g.EntropyCoefficient
+}
+
+// normalizeAdvantages returns whether advantages should be
+// standardized before each policy update
+func (g GaussianTreeMLPConfig) normalizeAdvantages() bool {
+	return !g.DisableAdvantageNormalization
+}
+
+// randomWarmup returns whether uniformly random actions should be
+// selected until the experience replay buffer can be sampled from
+func (g GaussianTreeMLPConfig) randomWarmup() bool {
+	return g.RandomWarmup
+}
+
+// backend returns the neural network backend to use
+func (g GaussianTreeMLPConfig) backend() network.Backend {
+	return g.Backend
+}
+
+// gonumPolicy returns the constructed policy to train when using the
+// Gonum backend
+func (g GaussianTreeMLPConfig) gonumPolicy() agent.GonumLogPdfOfer {
+	return g.gonumTrainPolicy
+}
+
+// gonumValueFn returns the constructed value function to train when
+// using the Gonum backend
+func (g GaussianTreeMLPConfig) gonumValueFn() gonumnet.Net {
+	return g.gonumTrainValueFn
+}
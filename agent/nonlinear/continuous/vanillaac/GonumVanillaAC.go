@@ -0,0 +1,398 @@
+package vanillaac
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/agent/random"
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
+	"github.com/samuelfneumann/golearn/solver"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// GonumVAC implements the vanilla actor-critic algorithm using neural
+// networks from package gonumnet. The algorithm is identical to that
+// of VAC, but since gonumnet networks need no VMs and accept inputs of
+// any batch size, the policy used to select actions is the same policy
+// that is learned, and the online critic is the same critic that is
+// learned.
+type GonumVAC struct {
+	// Policy
+	policy       agent.GonumLogPdfOfer
+	policySolver *solver.Solver
+	entropyCoeff float64
+	normalizeAdv bool
+
+	replay expreplay.ExperienceReplayer
+
+	// Selects uniformly random actions until the replay buffer can be
+	// sampled from, nil if no random warmup is used
+	warmup *random.Warmup
+
+	prevStep   ts.TimeStep
+	actionDims int
+	features   int
+	batchSize  int
+
+	// State value critic
+	valueFn        gonumnet.Net
+	vSolver        *solver.Solver
+	valueGradSteps int
+	vGrad          *mat.Dense
+
+	// Target value function
+	targetValueFn        gonumnet.Net
+	tau                  float64
+	targetUpdateInterval int
+	stepsSinceUpdate     int
+}
+
+// NewGonum returns a new GonumVAC as described by the configuration c
+// with actions selected for the environment e
+func NewGonum(e env.Environment, c agent.Config,
+	seed int64) (agent.Agent, error) {
+	if !c.ValidAgent(&GonumVAC{}) {
+		return nil, fmt.Errorf("newGonum: invalid configuration type: %T", c)
+	}
+
+	// Ensure we have a VAC config, as described in this package
+	config, ok := c.(config)
+	if !ok {
+		return nil, fmt.Errorf("newGonum: invalid configuration type: %T", c)
+	}
+
+	err := config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("newGonum: %v", err)
+	}
+
+	// Create the experience replay buffer
+	featureSize := e.ObservationSpec().Shape.Len()
+	actionSize := e.ActionSpec().Shape.Len()
+	replay, err := config.expReplay().Create(featureSize, actionSize, seed,
+		false)
+	if err != nil {
+		return nil, fmt.Errorf("newGonum: could not construct experience "+
+			"replay buffer: %v", err)
+	}
+
+	var warmup *random.Warmup
+	if config.randomWarmup() {
+		warmup, err = random.NewWarmup(e, replay, uint64(seed))
+		if err != nil {
+			return nil, fmt.Errorf("newGonum: could not create random "+
+				"warmup: %v", err)
+		}
+	}
+
+	// The target value function starts with the same weights as the
+	// value function whose weights are learned
+	valueFn := config.gonumValueFn()
+	targetValueFn := valueFn.Clone()
+
+	return &GonumVAC{
+		policy:       config.gonumPolicy(),
+		policySolver: config.policySolver(),
+		entropyCoeff: config.entropyCoefficient(),
+		normalizeAdv: config.normalizeAdvantages(),
+
+		replay: replay,
+		warmup: warmup,
+
+		actionDims: actionSize,
+		features:   featureSize,
+		batchSize:  config.batchSize(),
+
+		valueFn:        valueFn,
+		vSolver:        config.vSolver(),
+		valueGradSteps: config.valueGradSteps(),
+		vGrad:          mat.NewDense(config.batchSize(), 1, nil),
+
+		targetValueFn:        targetValueFn,
+		tau:                  config.tau(),
+		targetUpdateInterval: config.targetUpdateInterval(),
+		stepsSinceUpdate:     0,
+	}, nil
+}
+
+// SelectAction returns an action for the timestep t
+func (v *GonumVAC) SelectAction(t ts.TimeStep) *mat.VecDense {
+	// Select uniformly random actions until the replay buffer can be
+	// sampled from
+	if !v.IsEval() && v.warmup != nil && v.warmup.Active() {
+		return v.warmup.SelectAction(t)
+	}
+	return v.policy.SelectAction(t)
+}
+
+// EndEpisode performs cleanup at the end of an episode
+func (v *GonumVAC) EndEpisode() {}
+
+// Eval sets the agent into evaluation mode
+func (v *GonumVAC) Eval() { v.policy.Eval() }
+
+// Train sets the agent into training mode
+func (v *GonumVAC) Train() { v.policy.Train() }
+
+// IsEval returns whether the agent is in evaluation mode or not
+func (v *GonumVAC) IsEval() bool { return v.policy.IsEval() }
+
+// ObserveFirst stores the first timestep in the episode
+func (v *GonumVAC) ObserveFirst(t ts.TimeStep) error {
+	if !t.First() {
+		return fmt.Errorf("observeFirst: timestep "+
+			"called on the first timestep (current timestep = %d)", t.Number)
+	}
+
+	v.prevStep = t
+	return nil
+}
+
+// Observe stores an action taken in the environment and the next
+// time step as a result of taking that action
+func (v *GonumVAC) Observe(action mat.Vector, nextStep ts.TimeStep) error {
+	if !nextStep.First() {
+		nextAction := mat.NewVecDense(v.actionDims, nil)
+		transition := ts.NewTransition(v.prevStep, action.(*mat.VecDense),
+			nextStep, nextAction)
+		err := v.replay.Add(transition)
+		if err != nil {
+			return fmt.Errorf("observe: could not add to replay buffer: %v",
+				err)
+		}
+	}
+
+	v.prevStep = nextStep
+	return nil
+}
+
+// Step performs the update of the agent, updating both the policy and
+// value function
+func (v *GonumVAC) Step() error {
+	// If in evaluation mode, don't update
+	if v.IsEval() {
+		return nil
+	}
+
+	// Sample transitions from the replay buffer
+	S, A, rewards, discounts, NextS, _, err := v.replay.Sample()
+	if expreplay.IsEmptyBuffer(err) || expreplay.IsInsufficientSamples(err) {
+		return nil
+	}
+
+	// === === Get Values Needed To Compute Losses === ===
+	stateValue, err := v.targetValues(S)
+	if err != nil {
+		return fmt.Errorf("step: could not compute state value: %v", err)
+	}
+	nextStateValue, err := v.targetValues(NextS)
+	if err != nil {
+		return fmt.Errorf("step: could not compute next state value: %v",
+			err)
+	}
+
+	// Compute the critic's update target and the advantage 𝔸:
+	// 𝔸 = r + ℽ * v(s') - v(s)
+	target := make([]float64, v.batchSize)
+	advantage := make([]float64, v.batchSize)
+	for i := range target {
+		target[i] = rewards[i] + discounts[i]*nextStateValue[i]
+		advantage[i] = target[i] - stateValue[i]
+	}
+	// Standardizing a single advantage would zero it, stopping the
+	// policy from learning
+	if v.normalizeAdv && len(advantage) > 1 {
+		standardizeFloats(advantage)
+	}
+
+	// === === Policy Step === ===
+	// The policy loss is -𝔼[ln(π) * 𝔸] - β * 𝔼[H(π)], so the gradient
+	// of the loss is the gradient of Σᵢ wᵢ ln π(aᵢ | sᵢ) + β' H(π(⋅ | sᵢ))
+	// with wᵢ = -𝔸ᵢ / n and β' = -β / n
+	_, err = v.policy.LogPdfOf(S, A)
+	if err != nil {
+		return fmt.Errorf("step: could not compute log PDF: %v", err)
+	}
+	n := float64(v.batchSize)
+	weights := make([]float64, v.batchSize)
+	for i := range weights {
+		weights[i] = -advantage[i] / n
+	}
+	err = v.policy.LogPdfBackward(weights, -v.entropyCoeff/n)
+	if err != nil {
+		return fmt.Errorf("step: could not compute policy gradient: %v", err)
+	}
+	err = v.policySolver.Step(v.policy.Network().Model())
+	if err != nil {
+		return fmt.Errorf("step: could not step policy solver: %v", err)
+	}
+
+	// === === Value Function Train === ===
+	states := mat.NewDense(v.batchSize, v.features, S)
+	vGrad := v.vGrad.RawMatrix().Data
+	for i := 0; i < v.valueGradSteps; i++ {
+		prediction, err := v.valueFn.Forward(states)
+		if err != nil {
+			return fmt.Errorf("step: could not predict state values on "+
+				"training iteration %d: %v", i, err)
+		}
+
+		// Gradient of the critic MSE loss with respect to the
+		// predicted state values
+		values := prediction[0].RawMatrix().Data
+		for j := range vGrad {
+			vGrad[j] = 2 * (values[j] - target[j]) / n
+		}
+
+		err = v.valueFn.Backward([]*mat.Dense{v.vGrad})
+		if err != nil {
+			return fmt.Errorf("step: could not compute critic gradient on "+
+				"training iteration %d: %v", i, err)
+		}
+		err = v.vSolver.Step(v.valueFn.Model())
+		if err != nil {
+			return fmt.Errorf("step: could not run step critic solver on "+
+				"training iteration %d: %v", i, err)
+		}
+	}
+
+	// Update the target network
+	v.stepsSinceUpdate++
+	if v.stepsSinceUpdate%v.targetUpdateInterval == 0 {
+		if v.tau == 1.0 {
+			err = gonumnet.Set(v.targetValueFn, v.valueFn)
+		} else {
+			err = gonumnet.Polyak(v.targetValueFn, v.valueFn, v.tau)
+		}
+		if err != nil {
+			return fmt.Errorf("step: could not update target critic: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// targetValues returns a copy of the state values predicted by the
+// target critic in each of the states in the batch s
+func (v *GonumVAC) targetValues(s []float64) ([]float64, error) {
+	prediction, err := v.targetValueFn.Forward(
+		mat.NewDense(len(s)/v.features, v.features, s))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, len(s)/v.features)
+	copy(values, prediction[0].RawMatrix().Data)
+	return values, nil
+}
+
+// TdError computes the TD error of a single transition
+func (v *GonumVAC) TdError(t ts.Transition) float64 {
+	states := make([]float64, 0, 2*v.features)
+	states = append(states, t.State.RawVector().Data...)
+	states = append(states, t.NextState.RawVector().Data...)
+
+	prediction, err := v.valueFn.Forward(mat.NewDense(2, v.features, states))
+	if err != nil {
+		panic(fmt.Sprintf("tdError: could not predict state values: %v",
+			err))
+	}
+	values := prediction[0].RawMatrix().Data
+
+	return t.Reward + t.Discount*values[1] - values[0]
+}
+
+// Close cleans up any used resources. Since a GonumVAC uses no VMs,
+// Close does nothing.
+func (v *GonumVAC) Close() error {
+	return nil
+}
+
+// standardizeFloats standardizes x in place to have mean 0 and
+// standard deviation 1
+func standardizeFloats(x []float64) {
+	mean := 0.0
+	for i := range x {
+		mean += x[i]
+	}
+	mean /= float64(len(x))
+
+	std := 0.0
+	for i := range x {
+		x[i] -= mean
+		std += x[i] * x[i]
+	}
+	std = math.Sqrt(std/float64(len(x))) + 1e-8
+
+	for i := range x {
+		x[i] /= std
+	}
+}
+
+// gonumVACCheckpoint is the serialized form of a GonumVAC agent
+type gonumVACCheckpoint struct {
+	Policy           [][]float64
+	ValueFn          [][]float64
+	TargetValueFn    [][]float64
+	PolicySolver     *solver.Solver
+	VSolver          *solver.Solver
+	StepsSinceUpdate int
+}
+
+// GobEncode implements the gob.GobEncoder interface. The weights of
+// the policy and critics, the number of steps taken since the target
+// critic was last updated, and the solvers with the progress of their
+// step size schedules are encoded. The experience replay buffer and
+// the internal states of the solvers, such as moment estimates, are
+// not saved.
+func (v *GonumVAC) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err := enc.Encode(gonumVACCheckpoint{
+		Policy:           gonumnet.Weights(v.policy.Network()),
+		ValueFn:          gonumnet.Weights(v.valueFn),
+		TargetValueFn:    gonumnet.Weights(v.targetValueFn),
+		PolicySolver:     v.policySolver,
+		VSolver:          v.vSolver,
+		StepsSinceUpdate: v.stepsSinceUpdate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface. The GonumVAC
+// agent must have been created with the same configuration as the
+// agent which was encoded.
+func (v *GonumVAC) GobDecode(in []byte) error {
+	var checkpoint gonumVACCheckpoint
+	dec := gob.NewDecoder(bytes.NewReader(in))
+	if err := dec.Decode(&checkpoint); err != nil {
+		return fmt.Errorf("gobdecode: %v", err)
+	}
+
+	err := gonumnet.SetWeights(v.policy.Network(), checkpoint.Policy)
+	if err != nil {
+		return fmt.Errorf("gobdecode: policy: %v", err)
+	}
+	err = gonumnet.SetWeights(v.valueFn, checkpoint.ValueFn)
+	if err != nil {
+		return fmt.Errorf("gobdecode: value function: %v", err)
+	}
+	err = gonumnet.SetWeights(v.targetValueFn, checkpoint.TargetValueFn)
+	if err != nil {
+		return fmt.Errorf("gobdecode: target value function: %v", err)
+	}
+
+	v.policySolver = checkpoint.PolicySolver
+	v.vSolver = checkpoint.VSolver
+	v.stepsSinceUpdate = checkpoint.StepsSinceUpdate
+	return nil
+}
@@ -7,14 +7,17 @@
 package vanillaac
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"strings"
 
 	"github.com/samuelfneumann/golearn/agent"
-	"github.com/samuelfneumann/golearn/agent/nonlinear/continuous/policy"
+	"github.com/samuelfneumann/golearn/agent/random"
 	"github.com/samuelfneumann/golearn/buffer/expreplay"
 	env "github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/solver"
 	ts "github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/floatutils"
 	"gonum.org/v1/gonum/mat"
@@ -37,7 +40,7 @@ type VAC struct {
 	// Policy
 	behaviour         agent.NNPolicy   // Has its own VM
 	trainPolicy       agent.LogPdfOfer // Policy struct that is learned
-	trainPolicySolver G.Solver
+	trainPolicySolver *solver.Solver
 	trainPolicyVM     G.VM
 	pStateValue       *G.Node // For computing the advantage
 	pNextStateValue   *G.Node // For computing the advantage
@@ -48,6 +51,10 @@ type VAC struct {
 
 	replay expreplay.ExperienceReplayer
 
+	// Selects uniformly random actions until the replay buffer can be
+	// sampled from, nil if no random warmup is used
+	warmup *random.Warmup
+
 	prevStep   ts.TimeStep
 	actionDims int
 
@@ -56,7 +63,7 @@ type VAC struct {
 	vVM             G.VM
 	vTrainValueFn   network.NeuralNet
 	vTrainValueFnVM G.VM
-	vSolver         G.Solver
+	vSolver         *solver.Solver
 	valueGradSteps  int
 	vNextStateValue *G.Node
 	vDiscount       *G.Node
@@ -99,6 +106,15 @@ func New(e env.Environment, c agent.Config, seed int64) (agent.Agent, error) {
 			"replay buffer: %v", err)
 	}
 
+	var warmup *random.Warmup
+	if config.randomWarmup() {
+		warmup, err = random.NewWarmup(e, replay, uint64(seed))
+		if err != nil {
+			return nil, fmt.Errorf("new: could not create random "+
+				"warmup: %v", err)
+		}
+	}
+
 	// Create the online prediction value function
 	valueFn := config.valueFn()
 	vVM := G.NewTapeMachine(valueFn.Graph())
@@ -135,8 +151,11 @@ func New(e env.Environment, c agent.Config, seed int64) (agent.Agent, error) {
 	trainValueFnTargets := G.Must(G.HadamardProd(vDiscount, vNextStateValue))
 	trainValueFnTargets = G.Must(G.Add(vReward, trainValueFnTargets))
 
-	// Critic MSE loss
-	prediction := trainValueFn.Prediction()[0]
+	// Critic MSE loss. The prediction has shape (batch, 1) and is
+	// flattened so that its shape matches that of the targets, even
+	// when the batch holds a single sample.
+	prediction := G.Must(G.Reshape(trainValueFn.Prediction()[0],
+		tensor.Shape{config.batchSize()}))
 	valueFnLoss := G.Must(G.Sub(prediction, trainValueFnTargets))
 	valueFnLoss = G.Must(G.Square(valueFnLoss))
 	valueFnLoss = G.Must(G.Mean(valueFnLoss))
@@ -193,6 +212,11 @@ func New(e env.Environment, c agent.Config, seed int64) (agent.Agent, error) {
 	advantage := G.Must(G.HadamardProd(pDiscount, pNextStateValue))
 	advantage = G.Must(G.Add(pReward, advantage))
 	advantage = G.Must(G.Sub(advantage, pStateValue))
+	// Standardizing a single advantage would zero it, stopping the
+	// policy from learning
+	if config.normalizeAdvantages() && config.batchSize() > 1 {
+		advantage = standardize(advantage)
+	}
 	G.Read(advantage, &Adv)
 	G.Read(pNextStateValue, &PNextStateValue)
 	G.Read(pStateValue, &PStateValue)
@@ -203,6 +227,18 @@ func New(e env.Environment, c agent.Config, seed int64) (agent.Agent, error) {
 	// Where the negation ensures gradient ascent
 	policyLoss := G.Must(G.HadamardProd(logProb, advantage))
 	policyLoss = G.Must(G.Mean(policyLoss))
+
+	// Add the entropy bonus: -𝔼[ln(π) * 𝔸] - β * 𝔼[H(π)]
+	if β := config.entropyCoefficient(); β != 0 {
+		entropyPolicy, ok := trainPolicy.(agent.EntropyLogPdfOfer)
+		if !ok {
+			return nil, fmt.Errorf("new: policy %T cannot compute entropy "+
+				"for entropy regularization", trainPolicy)
+		}
+		entropy := G.Must(G.Mean(entropyPolicy.EntropyNode()))
+		entropy = G.Must(G.Mul(entropy, G.NewConstant(β)))
+		policyLoss = G.Must(G.Add(policyLoss, entropy))
+	}
 	policyLoss = G.Must(G.Neg(policyLoss))
 	G.Read(policyLoss, &PLoss)
 
@@ -249,18 +285,19 @@ func New(e env.Environment, c agent.Config, seed int64) (agent.Agent, error) {
 		stepsSinceUpdate:     0,
 
 		replay:     replay,
+		warmup:     warmup,
 		actionDims: e.ActionSpec().Shape.Len(),
 	}, nil
 }
 
 // SelectAction returns an action for the timestep t
 func (v *VAC) SelectAction(t ts.TimeStep) *mat.VecDense {
-	a := v.behaviour.SelectAction(t)
-
-	// ! Standard deviation is taking off to infinity. Maybe use gop.Clamp()
-	fmt.Println()
-	fmt.Println(v.trainPolicy.(*policy.GaussianTreeMLP).StdDev())
-	return a
+	// Select uniformly random actions until the replay buffer can be
+	// sampled from
+	if !v.IsEval() && v.warmup != nil && v.warmup.Active() {
+		return v.warmup.SelectAction(t)
+	}
+	return v.behaviour.SelectAction(t)
 }
 
 // EndEpisode performs cleanup at the end of an episode
@@ -588,3 +625,103 @@ func (v *VAC) Close() error {
 	}
 	return nil
 }
+
+// standardize returns a node which standardizes the vector x to have
+// mean 0 and standard deviation 1
+func standardize(x *G.Node) *G.Node {
+	centered := G.Must(G.Sub(x, G.Must(G.Mean(x))))
+
+	std := G.Must(G.Mean(G.Must(G.Square(centered))))
+	std = G.Must(G.Sqrt(std))
+	std = G.Must(G.Add(std, G.NewConstant(1e-8)))
+
+	return G.Must(G.Div(centered, std))
+}
+
+// vacCheckpoint is the serialized form of a VAC agent
+type vacCheckpoint struct {
+	Policy           *network.Model
+	ValueFn          *network.Model
+	TargetValueFn    *network.Model
+	PolicySolver     *solver.Solver
+	VSolver          *solver.Solver
+	StepsSinceUpdate int
+}
+
+// GobEncode implements the gob.GobEncoder interface. The weights of
+// the policy and critics, the number of steps taken since the target
+// critic was last updated, and the solvers with the progress of their
+// step size schedules are encoded. The experience replay buffer and
+// the internal states of the solvers, such as moment estimates, are
+// not saved.
+func (v *VAC) GobEncode() ([]byte, error) {
+	policy, err := network.NewModel(v.trainPolicy.Network())
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode policy: %v",
+			err)
+	}
+	valueFn, err := network.NewModel(v.vTrainValueFn)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode value "+
+			"function: %v", err)
+	}
+	targetValueFn, err := network.NewModel(v.vTargetValueFn)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode target value "+
+			"function: %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err = enc.Encode(vacCheckpoint{
+		Policy:           policy,
+		ValueFn:          valueFn,
+		TargetValueFn:    targetValueFn,
+		PolicySolver:     v.trainPolicySolver,
+		VSolver:          v.vSolver,
+		StepsSinceUpdate: v.stepsSinceUpdate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface. The VAC agent
+// must have been created with the same configuration as the agent
+// which was encoded.
+func (v *VAC) GobDecode(in []byte) error {
+	var checkpoint vacCheckpoint
+	dec := gob.NewDecoder(bytes.NewReader(in))
+	if err := dec.Decode(&checkpoint); err != nil {
+		return fmt.Errorf("gobdecode: %v", err)
+	}
+
+	err := checkpoint.Policy.SetWeights(v.trainPolicy.Network())
+	if err != nil {
+		return fmt.Errorf("gobdecode: policy: %v", err)
+	}
+	err = network.Set(v.behaviour.Network(), v.trainPolicy.Network())
+	if err != nil {
+		return fmt.Errorf("gobdecode: behaviour policy: %v", err)
+	}
+
+	err = checkpoint.ValueFn.SetWeights(v.vTrainValueFn)
+	if err != nil {
+		return fmt.Errorf("gobdecode: value function: %v", err)
+	}
+	err = network.Set(v.vValueFn, v.vTrainValueFn)
+	if err != nil {
+		return fmt.Errorf("gobdecode: online value function: %v", err)
+	}
+
+	err = checkpoint.TargetValueFn.SetWeights(v.vTargetValueFn)
+	if err != nil {
+		return fmt.Errorf("gobdecode: target value function: %v", err)
+	}
+
+	v.trainPolicySolver = checkpoint.PolicySolver
+	v.vSolver = checkpoint.VSolver
+	v.stepsSinceUpdate = checkpoint.StepsSinceUpdate
+	return nil
+}
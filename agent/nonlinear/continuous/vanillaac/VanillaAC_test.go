@@ -0,0 +1,225 @@
+package vanillaac
+
+import (
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	"github.com/samuelfneumann/golearn/initwfn"
+	"github.com/samuelfneumann/golearn/internal/agenttest"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/solver"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// newCategoricalConfig returns a CategoricalMLPConfig with linear
+// policy and state value function networks, which samples batches of
+// batchSize transitions
+func newCategoricalConfig(batchSize int) (CategoricalMLPConfig, error) {
+	policySolver, err := solver.NewDefaultAdam(0.001, batchSize)
+	if err != nil {
+		return CategoricalMLPConfig{}, err
+	}
+	vSolver, err := solver.NewDefaultAdam(0.01, batchSize)
+	if err != nil {
+		return CategoricalMLPConfig{}, err
+	}
+	init, err := initwfn.NewZeroes()
+	if err != nil {
+		return CategoricalMLPConfig{}, err
+	}
+
+	return CategoricalMLPConfig{
+		Layers:             []int{},
+		Biases:             []bool{},
+		Activations:        []*network.Activation{},
+		ValueFnLayers:      []int{},
+		ValueFnBiases:      []bool{},
+		ValueFnActivations: []*network.Activation{},
+		InitWFn:            init,
+		PolicySolver:       policySolver,
+		VSolver:            vSolver,
+		ValueGradSteps:     1,
+		ExpReplay: expreplay.Config{
+			RemoveMethod:      expreplay.Fifo,
+			SampleMethod:      expreplay.Uniform,
+			RemoveSize:        1,
+			SampleSize:        batchSize,
+			MaxReplayCapacity: 10,
+			MinReplayCapacity: batchSize,
+		},
+		Tau:                  1.0,
+		TargetUpdateInterval: 1,
+	}, nil
+}
+
+// TestBootstrap tests that VanillaAC bootstraps from the last state of
+// truncated episodes but not from the last state of terminated
+// episodes, so that its value estimates are unbiased.
+func TestBootstrap(t *testing.T) {
+	newAgent := func(env environment.Environment) (agent.Agent, error) {
+		c, err := newCategoricalConfig(2)
+		if err != nil {
+			return nil, err
+		}
+		return c.CreateAgent(env, 1)
+	}
+
+	agenttest.Bootstrap(t, newAgent, stateValue)
+}
+
+// stateValue returns the value that agent a predicts for the single
+// state of a constant.Constant environment
+func stateValue(a agent.Agent) float64 {
+	obs := mat.NewVecDense(1, []float64{1})
+	return -a.(*VAC).TdError(ts.Transition{State: obs, NextState: obs})
+}
+
+// policyParams returns the backing data of the parameters of the
+// policy learned by agent a
+func policyParams(t *testing.T, a agent.Agent) [][]float64 {
+	t.Helper()
+
+	var params [][]float64
+	switch a := a.(type) {
+	case *VAC:
+		for _, node := range a.trainPolicy.Network().Learnables() {
+			params = append(params, node.Value().Data().([]float64))
+		}
+	case *GonumVAC:
+		for _, param := range a.policy.Network().Model() {
+			params = append(params, param.Value().Data().([]float64))
+		}
+	default:
+		t.Fatalf("unexpected agent type %T", a)
+	}
+	return params
+}
+
+// logits returns the action logits of a linear policy with parameters
+// params in the single state of a constant.Constant environment, whose
+// observation is 1
+func logits(params [][]float64) []float64 {
+	logits := make([]float64, len(params[0]))
+	for _, param := range params {
+		floats.Add(logits, param)
+	}
+	return logits
+}
+
+// TestBatchSizeOne tests that the policy learns from batches of a
+// single transition when advantages are standardized, which would
+// otherwise zero each advantage
+func TestBatchSizeOne(t *testing.T) {
+	for _, backend := range []network.Backend{network.Gorgonia,
+		network.Gonum} {
+		c, err := newCategoricalConfig(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Backend = backend
+
+		env, step, err := constant.New(2, 1.0, environment.NewStepLimit(10),
+			0.9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := c.CreateAgent(env, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		agenttest.Run(t, a, env, step, 10)
+
+		learned := false
+		for _, param := range policyParams(t, a) {
+			learned = learned || floats.Norm(param, 2) != 0
+		}
+		if !learned {
+			t.Errorf("%v: policy was not updated", backend)
+		}
+	}
+}
+
+// TestEntropyBonus tests that the entropy bonus increases the entropy
+// of the policy. The agent observes no rewards, so that all
+// advantages are 0 and only the entropy bonus can change the policy.
+func TestEntropyBonus(t *testing.T) {
+	for _, backend := range []network.Backend{network.Gorgonia,
+		network.Gonum} {
+		for _, β := range []float64{0, 0.1} {
+			c, err := newCategoricalConfig(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Backend = backend
+			c.EntropyCoefficient = β
+
+			env, step, err := constant.New(3, 0.0,
+				environment.NewStepLimit(10), 0.9)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := c.CreateAgent(env, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Start from a policy with low entropy
+			copy(policyParams(t, a)[0], []float64{2, 0, -2})
+			before := agenttest.SoftmaxEntropy(logits(policyParams(t, a)))
+
+			agenttest.Run(t, a, env, step, 500)
+			after := agenttest.SoftmaxEntropy(logits(policyParams(t, a)))
+
+			if β == 0 && after != before {
+				t.Errorf("%v: entropy changed without an entropy bonus: "+
+					"have(%v) want(%v)", backend, after, before)
+			} else if β != 0 && after < before+0.1 {
+				t.Errorf("%v: entropy did not increase with an entropy "+
+					"bonus: have(%v) want(> %v)", backend, after, before+0.1)
+			}
+		}
+	}
+}
+
+// TestStandardize tests that advantages are standardized to mean 0 and
+// standard deviation 1
+func TestStandardize(t *testing.T) {
+	x := []float64{1, 2, 4, 9, -3}
+
+	g := G.NewGraph()
+	node := G.NewVector(g, tensor.Float64, G.WithShape(len(x)),
+		G.WithName("x"), G.WithValue(tensor.NewDense(tensor.Float64,
+			[]int{len(x)}, tensor.WithBacking(floatutils.Duplicate(x)))))
+	var out G.Value
+	G.Read(standardize(node), &out)
+	vm := G.NewTapeMachine(g)
+	defer vm.Close()
+	if err := vm.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	gonum := floatutils.Duplicate(x)
+	standardizeFloats(gonum)
+
+	for name, have := range map[string][]float64{
+		"standardize":       out.Data().([]float64),
+		"standardizeFloats": gonum,
+	} {
+		mean, std := stat.PopMeanStdDev(have, nil)
+		if math.Abs(mean) > 1e-10 || math.Abs(std-1) > 1e-6 {
+			t.Errorf("%v: have(mean=%v, std=%v) want(mean=0, std=1)", name,
+				mean, std)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/samuelfneumann/golearn/agent"
 	"github.com/samuelfneumann/golearn/agent/nonlinear/continuous/policy"
+	"github.com/samuelfneumann/golearn/buffer/gae"
 	env "github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/initwfn"
 	"github.com/samuelfneumann/golearn/network"
@@ -48,6 +49,20 @@ type CategoricalMLPConfigList struct {
 	// Generalized Advantage Estimation
 	Lambda []float64
 	Gamma  []float64
+
+	// Optional state value function architecture, which replaces
+	// ValueFnLayers, ValueFnBiases, and ValueFnActivations
+	ValueFn []*network.Spec
+
+	// Optional coefficient of the policy entropy bonus in the policy
+	// loss. If unset, no entropy bonus is used.
+	EntropyCoefficient []float64
+
+	// Optional advantage estimation method and whether standardizing
+	// advantages before each policy update is disabled. If unset,
+	// GAE(λ) is used and advantages are standardized.
+	Advantage                     []gae.Advantage
+	DisableAdvantageNormalization []bool
 }
 
 // NewCategoricalMLPConfigList returns a new CategoricalMLPConfigList
@@ -116,10 +131,15 @@ func (c CategoricalMLPConfigList) Config() agent.Config {
 // Len returns the number of configurations stored in the list
 func (c CategoricalMLPConfigList) Len() int {
 	return len(c.Lambda) * len(c.Gamma) * len(c.ValueGradSteps) *
-		len(c.EpochLength) * len(c.InitWFn) * len(c.ValueFnActivations) *
-		len(c.ValueFnBiases) * len(c.ValueFnLayers) * len(c.PolicySolver) *
+		len(c.EpochLength) * len(c.InitWFn) *
+		agent.NumSettings(len(c.ValueFnActivations)) *
+		agent.NumSettings(len(c.ValueFnBiases)) *
+		agent.NumSettings(len(c.ValueFnLayers)) * len(c.PolicySolver) *
 		len(c.VSolver) * len(c.PolicyActivations) * len(c.PolicyBiases) *
-		len(c.PolicyLayers)
+		len(c.PolicyLayers) * agent.NumSettings(len(c.ValueFn)) *
+		agent.NumSettings(len(c.EntropyCoefficient)) *
+		agent.NumSettings(len(c.Advantage)) *
+		agent.NumSettings(len(c.DisableAdvantageNormalization))
 }
 
 // CategoricalMLPConfig implements a configuration for a categorical
@@ -167,6 +187,20 @@ type CategoricalMLPConfig struct {
 	// Generalized Advantage Estimation
 	Lambda float64
 	Gamma  float64
+
+	// Optional state value function architecture. If non-nil, ValueFn
+	// is used to construct the state value function instead of
+	// ValueFnLayers, ValueFnBiases, and ValueFnActivations.
+	ValueFn *network.Spec
+
+	// Coefficient of the policy entropy bonus in the policy loss
+	EntropyCoefficient float64
+
+	// Advantage estimation method, which defaults to gae.GAE if unset,
+	// and whether standardizing advantages to mean 0 and standard
+	// deviation 1 before each policy update is disabled
+	Advantage                     gae.Advantage
+	DisableAdvantageNormalization bool
 }
 
 // BatchSize gets the batch size for the policy generated by this config
@@ -180,6 +214,23 @@ func (c CategoricalMLPConfig) Validate() error {
 		return fmt.Errorf("cannot have epoch length < 1")
 	}
 
+	if c.ValueFn != nil {
+		if err := c.ValueFn.Validate(); err != nil {
+			return fmt.Errorf("invalid value function: %v", err)
+		}
+		if len(c.ValueFn.Heads) > 1 {
+			return fmt.Errorf("value function must have a single head")
+		}
+	}
+
+	if c.EntropyCoefficient < 0 {
+		return fmt.Errorf("cannot have negative entropy coefficient")
+	}
+
+	if c.Advantage != "" && !c.Advantage.IsValid() {
+		return fmt.Errorf("invalid advantage %q", c.Advantage)
+	}
+
 	return nil
 }
 
@@ -234,31 +285,34 @@ func (c CategoricalMLPConfig) CreateAgent(e env.Environment,
 
 	features := e.ObservationSpec().Shape.Len()
 
+	// newValueFn creates a new state value function with the given
+	// batch size, using the configured network architecture
+	newValueFn := func(batch int) (network.NeuralNet, error) {
+		if c.ValueFn != nil {
+			return agent.NewNetwork(c.ValueFn, e, batch, 1,
+				G.NewGraph(), seed)
+		}
+
+		return network.NewSingleHeadMLP(
+			features,
+			batch,
+			G.NewGraph(),
+			c.ValueFnLayers,
+			c.ValueFnBiases,
+			c.InitWFn.InitWFn(),
+			c.ValueFnActivations,
+		)
+	}
+
 	// Value function for single samples
-	valueFn, err := network.NewSingleHeadMLP(
-		features,
-		1,
-		G.NewGraph(),
-		c.ValueFnLayers,
-		c.ValueFnBiases,
-		c.InitWFn.InitWFn(),
-		c.ValueFnActivations,
-	)
+	valueFn, err := newValueFn(1)
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create value "+
 			"function: %v", err)
 	}
 
 	// Value function whose weights are learned
-	trainValueFn, err := network.NewSingleHeadMLP(
-		features,
-		c.EpochLength,
-		G.NewGraph(),
-		c.ValueFnLayers,
-		c.ValueFnBiases,
-		c.InitWFn.InitWFn(),
-		c.ValueFnActivations,
-	)
+	trainValueFn, err := newValueFn(c.EpochLength)
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create "+
 			"train value function: %v", err)
@@ -350,3 +404,23 @@ func (g CategoricalMLPConfig) lambda() float64 {
 func (g CategoricalMLPConfig) gamma() float64 {
 	return g.Gamma
 }
+
+// entropyCoefficient returns the coefficient of the entropy bonus in
+// the policy loss
+func (g CategoricalMLPConfig) entropyCoefficient() float64 {
+	return g.EntropyCoefficient
+}
+
+// advantage returns the method of advantage estimation
+func (g CategoricalMLPConfig) advantage() gae.Advantage {
+	if g.Advantage == "" {
+		return gae.GAE
+	}
+	return g.Advantage
+}
+
+// normalizeAdvantages returns whether advantages should be
+// standardized before each policy update
+func (g CategoricalMLPConfig) normalizeAdvantages() bool {
+	return !g.DisableAdvantageNormalization
+}
@@ -2,6 +2,7 @@ package vanillapg
 
 import (
 	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/buffer/gae"
 	"github.com/samuelfneumann/golearn/initwfn"
 	"github.com/samuelfneumann/golearn/network"
 	"github.com/samuelfneumann/golearn/solver"
@@ -44,4 +45,11 @@ type config interface {
 	// Generalized Advantage Estimation
 	lambda() float64
 	gamma() float64
+
+	// Coefficient of the entropy bonus in the policy loss
+	entropyCoefficient() float64
+
+	// Advantage estimation and standardization
+	advantage() gae.Advantage
+	normalizeAdvantages() bool
 }
@@ -7,6 +7,7 @@ import (
 	G "gorgonia.org/gorgonia"
 	"github.com/samuelfneumann/golearn/agent"
 	"github.com/samuelfneumann/golearn/agent/nonlinear/continuous/policy"
+	"github.com/samuelfneumann/golearn/buffer/gae"
 	env "github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/initwfn"
 	"github.com/samuelfneumann/golearn/network"
@@ -61,6 +62,20 @@ type GaussianTreeMLPConfigList struct {
 	// Generalized Advantage Estimation
 	Lambda []float64
 	Gamma  []float64
+
+	// Optional state value function architecture, which replaces
+	// ValueFnLayers, ValueFnBiases, and ValueFnActivations
+	ValueFn []*network.Spec
+
+	// Optional coefficient of the policy entropy bonus in the policy
+	// loss. If unset, no entropy bonus is used.
+	EntropyCoefficient []float64
+
+	// Optional advantage estimation method and whether standardizing
+	// advantages before each policy update is disabled. If unset,
+	// GAE(λ) is used and advantages are standardized.
+	Advantage                     []gae.Advantage
+	DisableAdvantageNormalization []bool
 }
 
 // NewGaussianTreeMLPConfigList returns a new GaussianTreeMLPConfigList
@@ -130,10 +145,15 @@ func (g GaussianTreeMLPConfigList) Type() agent.Type {
 func (g GaussianTreeMLPConfigList) Len() int {
 	return len(g.RootLayers) * len(g.RootBiases) * len(g.RootActivations) *
 		len(g.LeafLayers) * len(g.LeafBiases) * len(g.LeafActivations) *
-		len(g.ValueFnLayers) * len(g.ValueFnBiases) *
-		len(g.ValueFnActivations) * len(g.InitWFn) * len(g.PolicySolver) *
-		len(g.VSolver) * len(g.ValueGradSteps) * len(g.EpochLength) *
-		len(g.FinishEpisodeOnEpochEnd) * len(g.Lambda) * len(g.Gamma)
+		agent.NumSettings(len(g.ValueFnLayers)) *
+		agent.NumSettings(len(g.ValueFnBiases)) *
+		agent.NumSettings(len(g.ValueFnActivations)) * len(g.InitWFn) *
+		len(g.PolicySolver) * len(g.VSolver) * len(g.ValueGradSteps) *
+		len(g.EpochLength) * len(g.FinishEpisodeOnEpochEnd) *
+		len(g.Lambda) * len(g.Gamma) * agent.NumSettings(len(g.ValueFn)) *
+		agent.NumSettings(len(g.EntropyCoefficient)) *
+		agent.NumSettings(len(g.Advantage)) *
+		agent.NumSettings(len(g.DisableAdvantageNormalization))
 }
 
 // NumFields gets the total number of settable fields/hyperparameters
@@ -192,6 +212,20 @@ type GaussianTreeMLPConfig struct {
 	// Generalized Advantage Estimation
 	Lambda float64
 	Gamma  float64
+
+	// Optional state value function architecture. If non-nil, ValueFn
+	// is used to construct the state value function instead of
+	// ValueFnLayers, ValueFnBiases, and ValueFnActivations.
+	ValueFn *network.Spec
+
+	// Coefficient of the policy entropy bonus in the policy loss
+	EntropyCoefficient float64
+
+	// Advantage estimation method, which defaults to gae.GAE if unset,
+	// and whether standardizing advantages to mean 0 and standard
+	// deviation 1 before each policy update is disabled
+	Advantage                     gae.Advantage
+	DisableAdvantageNormalization bool
 }
 
 // BatchSize gets the batch size for the policy generated by this config
@@ -205,6 +239,23 @@ func (c GaussianTreeMLPConfig) Validate() error {
 		return fmt.Errorf("cannot have epoch length < 1")
 	}
 
+	if c.ValueFn != nil {
+		if err := c.ValueFn.Validate(); err != nil {
+			return fmt.Errorf("invalid value function: %v", err)
+		}
+		if len(c.ValueFn.Heads) > 1 {
+			return fmt.Errorf("value function must have a single head")
+		}
+	}
+
+	if c.EntropyCoefficient < 0 {
+		return fmt.Errorf("cannot have negative entropy coefficient")
+	}
+
+	if c.Advantage != "" && !c.Advantage.IsValid() {
+		return fmt.Errorf("invalid advantage %q", c.Advantage)
+	}
+
 	return nil
 }
 
@@ -224,7 +275,20 @@ func (c GaussianTreeMLPConfig) Type() agent.Type {
 // configuration
 func (c GaussianTreeMLPConfig) CreateAgent(e env.Environment,
 	seed uint64) (agent.Agent, error) {
-	behaviour, err := policy.NewGaussianTreeMLP(
+	return c.createAgent(e, seed, policy.NewGaussianTreeMLP)
+}
+
+// treeMLPPolicy constructs a policy parameterized by a tree MLP, such
+// as policy.NewGaussianTreeMLP
+type treeMLPPolicy func(env.Environment, int, *G.ExprGraph, []int, []bool,
+	[]*network.Activation, [][]int, [][]bool, [][]*network.Activation,
+	G.InitWFn, uint64) (agent.LogPdfOfer, error)
+
+// createAgent creates and returns the agent determined by the
+// configuration, using newPolicy to construct the agent's policies
+func (c GaussianTreeMLPConfig) createAgent(e env.Environment,
+	seed uint64, newPolicy treeMLPPolicy) (agent.Agent, error) {
+	behaviour, err := newPolicy(
 		e,
 		1,
 		G.NewGraph(),
@@ -242,7 +306,7 @@ func (c GaussianTreeMLPConfig) CreateAgent(e env.Environment,
 			"behaviour policy: %v", err)
 	}
 
-	p, err := policy.NewGaussianTreeMLP(
+	p, err := newPolicy(
 		e,
 		c.EpochLength,
 		G.NewGraph(),
@@ -261,29 +325,32 @@ func (c GaussianTreeMLPConfig) CreateAgent(e env.Environment,
 
 	features := e.ObservationSpec().Shape.Len()
 
-	critic, err := network.NewSingleHeadMLP(
-		features,
-		1,
-		G.NewGraph(),
-		c.ValueFnLayers,
-		c.ValueFnBiases,
-		c.InitWFn.InitWFn(),
-		c.ValueFnActivations,
-	)
+	// newValueFn creates a new state value function with the given
+	// batch size, using the configured network architecture
+	newValueFn := func(batch int) (network.NeuralNet, error) {
+		if c.ValueFn != nil {
+			return agent.NewNetwork(c.ValueFn, e, batch, 1,
+				G.NewGraph(), seed)
+		}
+
+		return network.NewSingleHeadMLP(
+			features,
+			batch,
+			G.NewGraph(),
+			c.ValueFnLayers,
+			c.ValueFnBiases,
+			c.InitWFn.InitWFn(),
+			c.ValueFnActivations,
+		)
+	}
+
+	critic, err := newValueFn(1)
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create "+
 			"value function: %v", err)
 	}
 
-	trainValueFn, err := network.NewSingleHeadMLP(
-		features,
-		c.EpochLength,
-		G.NewGraph(),
-		c.ValueFnLayers,
-		c.ValueFnBiases,
-		c.InitWFn.InitWFn(),
-		c.ValueFnActivations,
-	)
+	trainValueFn, err := newValueFn(c.EpochLength)
 	if err != nil {
 		return nil, fmt.Errorf("createAgent: could not create train "+
 			"value function: %v", err)
@@ -371,3 +438,23 @@ func (g GaussianTreeMLPConfig) lambda() float64 {
 func (g GaussianTreeMLPConfig) gamma() float64 {
 	return g.Gamma
 }
+
+// entropyCoefficient returns the coefficient of the entropy bonus in
+// the policy loss
+func (g GaussianTreeMLPConfig) entropyCoefficient() float64 {
+	return g.EntropyCoefficient
+}
+
+// advantage returns the method of advantage estimation
+func (g GaussianTreeMLPConfig) advantage() gae.Advantage {
+	if g.Advantage == "" {
+		return gae.GAE
+	}
+	return g.Advantage
+}
+
+// normalizeAdvantages returns whether advantages should be
+// standardized before each policy update
+func (g GaussianTreeMLPConfig) normalizeAdvantages() bool {
+	return !g.DisableAdvantageNormalization
+}
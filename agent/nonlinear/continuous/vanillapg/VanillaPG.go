@@ -1,6 +1,8 @@
 package vanillapg
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"strings"
 
@@ -8,6 +10,7 @@ import (
 	"github.com/samuelfneumann/golearn/buffer/gae"
 	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/solver"
 	ts "github.com/samuelfneumann/golearn/timestep"
 	"gonum.org/v1/gonum/mat"
 	G "gorgonia.org/gorgonia"
@@ -45,7 +48,9 @@ import (
 // to ensure that the policy will update again at the end of training.
 
 // VPG implements the Vanilla Policy Gradient algorithm with generalized
-// advantage estimation. This implementation is adapted from:
+// advantage estimation. Advantages may instead be estimated with the
+// Monte Carlo rewards-to-go or one-step TD errors, and an entropy bonus
+// may be added to the policy loss. This implementation is adapted from:
 //
 // https://spinningup.openai.com/en/latest/algorithms/vpg.html
 // https://github.com/openai/spinningup/blob/master/spinup/algos/tf1/vpg/vpg.py
@@ -53,7 +58,7 @@ type VPG struct {
 	// Policy
 	behaviour         agent.NNPolicy   // Has its own VM
 	trainPolicy       agent.LogPdfOfer // Policy struct that is learned
-	trainPolicySolver G.Solver
+	trainPolicySolver *solver.Solver
 	trainPolicyVM     G.VM
 	advantages        *G.Node // For gradient construction
 	logProb           *G.Node // For gradient construction
@@ -89,7 +94,7 @@ type VPG struct {
 	vTrainValueFn        network.NeuralNet
 	vTrainValueFnVM      G.VM
 	vTrainValueFnTargets *G.Node
-	vSolver              G.Solver
+	vSolver              *solver.Solver
 	valueGradSteps       int
 }
 
@@ -115,8 +120,12 @@ func New(env environment.Environment, c agent.Config,
 	// Create the VPG buffer
 	features := env.ObservationSpec().Shape.Len()
 	actionDims := env.ActionSpec().Shape.Len()
-	buffer := gae.New(features, actionDims, config.batchSize(),
-		config.lambda(), config.gamma())
+	buffer, err := gae.NewWithOptions(features, actionDims,
+		config.batchSize(), config.lambda(), config.gamma(),
+		config.advantage(), config.normalizeAdvantages())
+	if err != nil {
+		return nil, fmt.Errorf("new: could not create buffer: %v", err)
+	}
 
 	// Create the prediction value function
 	valueFn := config.valueFn()
@@ -158,6 +167,18 @@ func New(env environment.Environment, c agent.Config,
 
 	policyLoss := G.Must(G.HadamardProd(logProb, advantages))
 	policyLoss = G.Must(G.Mean(policyLoss))
+
+	// Add the entropy bonus: -𝔼[ln(π) * 𝔸] - β * 𝔼[H(π)]
+	if β := config.entropyCoefficient(); β != 0 {
+		entropyPolicy, ok := trainPolicy.(agent.EntropyLogPdfOfer)
+		if !ok {
+			return nil, fmt.Errorf("new: policy %T cannot compute entropy "+
+				"for entropy regularization", trainPolicy)
+		}
+		entropy := G.Must(G.Mean(entropyPolicy.EntropyNode()))
+		entropy = G.Must(G.Mul(entropy, G.NewConstant(β)))
+		policyLoss = G.Must(G.Add(policyLoss, entropy))
+	}
 	policyLoss = G.Must(G.Neg(policyLoss))
 
 	_, err = G.Grad(policyLoss, trainPolicy.Network().Learnables()...)
@@ -265,7 +286,7 @@ func (v *VPG) Observe(action mat.Vector, nextStep ts.TimeStep) error {
 	v.currentEpochStep++
 	terminal := nextStep.Last() || v.currentEpochStep == v.epochLength
 	if terminal {
-		if nextStep.TerminalEnd() {
+		if nextStep.Terminal() {
 			v.buffer.FinishPath(0.0)
 		} else {
 			err := v.vValueFn.SetInput(o)
@@ -445,3 +466,78 @@ func (v *VPG) Close() error {
 	}
 	return nil
 }
+
+// vpgCheckpoint is the serialized form of a VPG agent
+type vpgCheckpoint struct {
+	Policy          *network.Model
+	ValueFn         *network.Model
+	PolicySolver    *solver.Solver
+	VSolver         *solver.Solver
+	CompletedEpochs int
+}
+
+// GobEncode implements the gob.GobEncoder interface. The weights of
+// the policy and critic, the number of completed epochs, and the
+// solvers with the progress of their step size schedules are encoded.
+// The data collected in the current epoch and the internal states of
+// the solvers, such as moment estimates, are not saved.
+func (v *VPG) GobEncode() ([]byte, error) {
+	policy, err := network.NewModel(v.trainPolicy.Network())
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode policy: %v",
+			err)
+	}
+	valueFn, err := network.NewModel(v.vTrainValueFn)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode value "+
+			"function: %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err = enc.Encode(vpgCheckpoint{
+		Policy:          policy,
+		ValueFn:         valueFn,
+		PolicySolver:    v.trainPolicySolver,
+		VSolver:         v.vSolver,
+		CompletedEpochs: v.completedEpochs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface. The VPG agent
+// must have been created with the same configuration as the agent
+// which was encoded.
+func (v *VPG) GobDecode(in []byte) error {
+	var checkpoint vpgCheckpoint
+	dec := gob.NewDecoder(bytes.NewReader(in))
+	if err := dec.Decode(&checkpoint); err != nil {
+		return fmt.Errorf("gobdecode: %v", err)
+	}
+
+	err := checkpoint.Policy.SetWeights(v.trainPolicy.Network())
+	if err != nil {
+		return fmt.Errorf("gobdecode: policy: %v", err)
+	}
+	err = network.Set(v.behaviour.Network(), v.trainPolicy.Network())
+	if err != nil {
+		return fmt.Errorf("gobdecode: behaviour policy: %v", err)
+	}
+
+	err = checkpoint.ValueFn.SetWeights(v.vTrainValueFn)
+	if err != nil {
+		return fmt.Errorf("gobdecode: value function: %v", err)
+	}
+	err = network.Set(v.vValueFn, v.vTrainValueFn)
+	if err != nil {
+		return fmt.Errorf("gobdecode: prediction value function: %v", err)
+	}
+
+	v.trainPolicySolver = checkpoint.PolicySolver
+	v.vSolver = checkpoint.VSolver
+	v.completedEpochs = checkpoint.CompletedEpochs
+	return nil
+}
@@ -0,0 +1,121 @@
+package vanillapg
+
+import (
+	"testing"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	"github.com/samuelfneumann/golearn/initwfn"
+	"github.com/samuelfneumann/golearn/internal/agenttest"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/solver"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// newCategoricalConfig returns a CategoricalMLPConfig with linear
+// policy and state value function networks
+func newCategoricalConfig(epochLength int,
+	discount float64) (CategoricalMLPConfig, error) {
+	policySolver, err := solver.NewDefaultAdam(0.001, epochLength)
+	if err != nil {
+		return CategoricalMLPConfig{}, err
+	}
+	vSolver, err := solver.NewDefaultAdam(0.05, epochLength)
+	if err != nil {
+		return CategoricalMLPConfig{}, err
+	}
+	init, err := initwfn.NewZeroes()
+	if err != nil {
+		return CategoricalMLPConfig{}, err
+	}
+
+	return CategoricalMLPConfig{
+		PolicyLayers:       []int{},
+		PolicyBiases:       []bool{},
+		PolicyActivations:  []*network.Activation{},
+		ValueFnLayers:      []int{},
+		ValueFnBiases:      []bool{},
+		ValueFnActivations: []*network.Activation{},
+		InitWFn:            init,
+		PolicySolver:       policySolver,
+		VSolver:            vSolver,
+		ValueGradSteps:     10,
+		EpochLength:        epochLength,
+		Lambda:             1.0,
+		Gamma:              discount,
+	}, nil
+}
+
+// TestBootstrap tests that VanillaPG bootstraps from the last state of
+// truncated episodes but not from the last state of terminated
+// episodes, so that its value estimates are unbiased.
+func TestBootstrap(t *testing.T) {
+	newAgent := func(env environment.Environment) (agent.Agent, error) {
+		discount := env.DiscountSpec().LowerBound.AtVec(0)
+		c, err := newCategoricalConfig(30, discount)
+		if err != nil {
+			return nil, err
+		}
+		return c.CreateAgent(env, 1)
+	}
+
+	agenttest.Bootstrap(t, newAgent, stateValue)
+}
+
+// stateValue returns the value that agent a predicts for the single
+// state of a constant.Constant environment
+func stateValue(a agent.Agent) float64 {
+	obs := mat.NewVecDense(1, []float64{1})
+	return -a.(*VPG).TdError(ts.Transition{State: obs, NextState: obs})
+}
+
+// TestEntropyBonus tests that the entropy bonus increases the entropy
+// of the policy. The agent observes no rewards, so that all
+// advantages are 0 and only the entropy bonus can change the policy.
+func TestEntropyBonus(t *testing.T) {
+	for _, β := range []float64{0, 0.1} {
+		c, err := newCategoricalConfig(10, 0.9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.EntropyCoefficient = β
+
+		env, step, err := constant.New(3, 0.0, environment.NewStepLimit(10),
+			0.9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := c.CreateAgent(env, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Start from a policy with low entropy. The policy is linear and
+		// the single state's observation is 1, so that the logits are the
+		// sum of the policy's weights and biases.
+		params := a.(*VPG).trainPolicy.Network().Learnables()
+		copy(params[0].Value().Data().([]float64), []float64{2, 0, -2})
+		logits := func() []float64 {
+			logits := make([]float64, 3)
+			for _, param := range params {
+				floats.Add(logits, param.Value().Data().([]float64))
+			}
+			return logits
+		}
+		before := agenttest.SoftmaxEntropy(logits())
+
+		agenttest.Run(t, a, env, step, 2000)
+		after := agenttest.SoftmaxEntropy(logits())
+
+		if β == 0 && after != before {
+			t.Errorf("entropy changed without an entropy bonus: have(%v) "+
+				"want(%v)", after, before)
+		} else if β != 0 && after < before+0.1 {
+			t.Errorf("entropy did not increase with an entropy bonus: "+
+				"have(%v) want(> %v)", after, before+0.1)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	env "github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/initwfn"
 	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/schedule"
 	"github.com/samuelfneumann/golearn/solver"
 	G "gorgonia.org/gorgonia"
 )
@@ -33,12 +34,34 @@ type ConfigList struct {
 
 	Epsilon []float64 // Behaviour policy epsilon
 
+	// Optional schedule for the behaviour policy epsilon, which
+	// replaces Epsilon
+	EpsilonSchedule []*schedule.Schedule
+
+	// Optional Boltzmann behaviour policy temperature, which replaces
+	// Epsilon
+	Temperature []float64
+
+	// Optional flag to use NoisyNet layers for exploration
+	Noisy []bool
+
 	// Experience replay parameters
 	ExpReplay []expreplay.Config
 
 	// Target net updates
 	Tau                  []float64 // Polyak averaging constant
 	TargetUpdateInterval []int     // Number of steps target network updates
+
+	// Optional network architecture, which replaces Layers, Biases,
+	// Activations, and InitWFn
+	Network []*network.Spec
+
+	// Optional neural network backend
+	Backend []network.Backend
+
+	// Optional flag to select uniformly random actions until the
+	// experience replay buffer can be sampled from
+	RandomWarmup []bool
 }
 
 // NewConfigList returns a new ConfigList as an agent.TypedConfigList.
@@ -90,9 +113,16 @@ func (c ConfigList) Config() agent.Config {
 
 // Len returns the number of Config's in the list
 func (c ConfigList) Len() int {
-	return len(c.Layers) * len(c.Biases) * len(c.Activations) *
-		len(c.Solver) * len(c.InitWFn) * len(c.Epsilon) * len(c.ExpReplay) *
-		len(c.Tau) * len(c.TargetUpdateInterval)
+	return agent.NumSettings(len(c.Layers)) *
+		agent.NumSettings(len(c.Biases)) *
+		agent.NumSettings(len(c.Activations)) * len(c.Solver) *
+		agent.NumSettings(len(c.InitWFn)) *
+		agent.NumSettings(len(c.Epsilon)) * len(c.ExpReplay) * len(c.Tau) *
+		len(c.TargetUpdateInterval) * agent.NumSettings(len(c.Network)) *
+		agent.NumSettings(len(c.EpsilonSchedule)) *
+		agent.NumSettings(len(c.Temperature)) *
+		agent.NumSettings(len(c.Noisy)) * agent.NumSettings(len(c.Backend)) *
+		agent.NumSettings(len(c.RandomWarmup))
 }
 
 // Config implements a configuration for a DeepQ agent
@@ -108,18 +138,55 @@ type Config struct {
 	// The behaviourPolicy selects actions at the current step. The
 	// targetPolicy looks at the next action and selects that with the
 	// highest value for the Q-learning update.
-	policy    agent.EGreedyNNPolicy // Action selection
+	policy    agent.NNPolicy // Action selection
 	targetNet network.NeuralNet
 	trainNet  network.NeuralNet
 
+	// Action selection policy when using the Gonum backend
+	gonumPolicy agent.GonumPolicy
+
 	Epsilon float64 // Behaviour policy epsilon
 
+	// Optional schedule for the behaviour policy epsilon. If non-nil,
+	// the behaviour policy's epsilon follows the schedule and Epsilon
+	// is ignored.
+	EpsilonSchedule *schedule.Schedule
+
+	// Optional temperature of a Boltzmann behaviour policy. If
+	// positive, the behaviour policy is a Boltzmann policy with this
+	// temperature instead of an ε-greedy policy, and Epsilon is ignored.
+	Temperature float64
+
+	// Optional flag to use NoisyNet layers. If true, every layer of the
+	// network, including the output layer, is a NoisyNet layer, so
+	// that the agent can explore with Epsilon set to zero. To use
+	// NoisyNet layers with Network, set the Noisy options of its layers
+	// instead.
+	Noisy bool
+
 	// Experience replay parameters
 	ExpReplay expreplay.Config
 
 	// Target net updates
 	Tau                  float64 // Polyak averaging constant
 	TargetUpdateInterval int     // Number of steps target network updates
+
+	// Optional network architecture. If non-nil, Network is used to
+	// construct the neural net instead of Layers, Biases, Activations,
+	// and InitWFn.
+	Network *network.Spec
+
+	// Optional neural network backend. If Backend is network.Gonum,
+	// then a GonumDeepQ agent is created, which uses networks from
+	// package gonumnet. The Gonum backend cannot be used with Network
+	// or Noisy. The default backend is network.Gorgonia.
+	Backend network.Backend
+
+	// Optional flag to select actions uniformly randomly from the
+	// action space of the environment, instead of with the behaviour
+	// policy, until the experience replay buffer holds
+	// ExpReplay.MinReplayCapacity samples
+	RandomWarmup bool
 }
 
 // BatchSize returns the batch size of the agent constructed using this
@@ -136,7 +203,52 @@ func (c Config) Type() agent.Type {
 // Validate checks a Config to ensure it is a valid configuration of a
 // DeepQ agent.
 func (c Config) Validate() error {
+	if c.TargetUpdateInterval < 1 {
+		err := fmt.Errorf("new: target networks must be updated at positive "+
+			"timestep intervals \n\twant(>0) \n\thave(%v)",
+			c.TargetUpdateInterval)
+		return err
+	}
+
+	if c.EpsilonSchedule != nil {
+		if err := c.EpsilonSchedule.Validate(); err != nil {
+			return fmt.Errorf("new: invalid epsilon schedule: %v", err)
+		}
+	}
+
+	if c.Temperature < 0 {
+		return fmt.Errorf("new: temperature cannot be lower than 0")
+	}
+	if c.Temperature > 0 && c.EpsilonSchedule != nil {
+		return fmt.Errorf("new: cannot use an epsilon schedule with a " +
+			"Boltzmann behaviour policy")
+	}
+
+	if !c.Backend.IsValid() {
+		return fmt.Errorf("new: invalid backend %q", c.Backend)
+	}
+	if c.Backend == network.Gonum && (c.Network != nil || c.Noisy) {
+		return fmt.Errorf("new: cannot use Network or Noisy with the " +
+			"Gonum backend")
+	}
+
+	if c.Network != nil {
+		if c.Noisy {
+			return fmt.Errorf("new: cannot use Noisy with Network, use " +
+				"the Noisy options of the network layers instead")
+		}
+
+		if err := c.Network.Validate(); err != nil {
+			return fmt.Errorf("new: invalid network: %v", err)
+		}
+		return nil
+	}
+
 	// Error checking
+	if c.InitWFn == nil {
+		return fmt.Errorf("new: a weight initializer must be specified")
+	}
+
 	if len(c.Layers) != len(c.Biases) {
 		msg := fmt.Sprintf("new: invalid number of biases\n\twant(%v)"+
 			"\n\thave(%v)", len(c.Layers), len(c.Biases))
@@ -149,96 +261,123 @@ func (c Config) Validate() error {
 		return fmt.Errorf(msg)
 	}
 
-	if c.TargetUpdateInterval < 1 {
-		err := fmt.Errorf("new: target networks must be updated at positive "+
-			"timestep intervals \n\twant(>0) \n\thave(%v)",
-			c.TargetUpdateInterval)
-		return err
+	return nil
+}
+
+// epsilon returns the initial epsilon of the behaviour policy
+func (c Config) epsilon() float64 {
+	if c.EpsilonSchedule != nil {
+		return c.EpsilonSchedule.Value(0)
 	}
+	return c.Epsilon
+}
 
-	return nil
+// spec returns the network architecture of the Config as a Spec, or
+// nil if the architecture is described by Layers, Biases, Activations,
+// and InitWFn and does not use NoisyNet layers.
+func (c Config) spec() *network.Spec {
+	if !c.Noisy {
+		return c.Network
+	}
+
+	noisy := network.LayerOptions{Noisy: true}
+	layers := make([]network.LayerSpec, len(c.Layers))
+	for i := range c.Layers {
+		layers[i] = network.LayerSpec{
+			Units:        c.Layers[i],
+			Bias:         c.Biases[i],
+			Activation:   c.Activations[i],
+			LayerOptions: noisy,
+		}
+	}
+
+	return &network.Spec{
+		Init:   c.InitWFn,
+		Heads:  []network.BranchSpec{{Layers: layers}},
+		Output: noisy,
+	}
 }
 
 // ValidAgent returns whether the agent is valid for the configuration.
 // That is, whether Agent a can be constructed with Config c.
 func (c Config) ValidAgent(a agent.Agent) bool {
+	if c.Backend == network.Gonum {
+		_, ok := a.(*GonumDeepQ)
+		return ok
+	}
 	_, ok := a.(*DeepQ)
 	return ok
 }
 
 // CreateAgent creates a new DeepQ agent based on the configuration
 func (c Config) CreateAgent(e env.Environment, s uint64) (agent.Agent, error) {
+	if err := c.Validate(); err != nil {
+		return &DeepQ{}, err
+	}
 	seed := int64(s)
 
-	// Extract configuration variables
-	hiddenSizes := c.Layers
-	biases := c.Biases
-	activations := c.Activations
-	init := c.InitWFn.InitWFn()
-	ε := c.Epsilon
+	if c.Backend == network.Gonum {
+		return c.createGonumAgent(e, seed)
+	}
+
+	// newPolicy creates a new policy with the given epsilon and batch
+	// size, using the configured network architecture
+	spec := c.spec()
+	newPolicy := func(ε float64, batch int) (agent.EGreedyNNPolicy, error) {
+		if spec != nil {
+			return policy.NewMultiHeadEGreedySpec(ε, batch, e, G.NewGraph(),
+				spec, seed)
+		}
+
+		return policy.NewMultiHeadEGreedyMLP(
+			ε,
+			batch,
+			e,
+			G.NewGraph(),
+			c.Layers,
+			c.Biases,
+			c.InitWFn.InitWFn(),
+			c.Activations,
+			seed,
+		)
+	}
 
 	// Behaviour policy
-	behaviourPolicy, err := policy.NewMultiHeadEGreedyMLP(
-		ε,
-		1,
-		e,
-		G.NewGraph(),
-		hiddenSizes,
-		biases,
-		init,
-		activations,
-		seed,
-	)
+	var behaviourPolicy agent.NNPolicy
+	var err error
+	switch {
+	case c.Temperature > 0 && spec != nil:
+		behaviourPolicy, err = policy.NewMultiHeadBoltzmannSpec(
+			c.Temperature, 1, e, G.NewGraph(), spec, seed)
+
+	case c.Temperature > 0:
+		behaviourPolicy, err = policy.NewMultiHeadBoltzmannMLP(
+			c.Temperature, 1, e, G.NewGraph(), c.Layers, c.Biases,
+			c.InitWFn.InitWFn(), c.Activations, seed)
+
+	default:
+		behaviourPolicy, err = newPolicy(c.epsilon(), 1)
+	}
 	if err != nil {
 		return &DeepQ{}, fmt.Errorf("createAgent: could not create "+
 			"behaviour policy: %v", err)
 	}
 
 	// Create the target (greedy) policy
-	targetPolicy, err := policy.NewMultiHeadEGreedyMLP(
-		0.0,
-		1,
-		e,
-		G.NewGraph(),
-		hiddenSizes,
-		biases,
-		init,
-		activations,
-		seed,
-	)
+	targetPolicy, err := newPolicy(0.0, 1)
 	if err != nil {
 		return &DeepQ{}, fmt.Errorf("new: could not create target policy")
 	}
 
 	// Create the target network
-	targetNetPolicy, err := policy.NewMultiHeadEGreedyMLP(
-		0.0,
-		c.BatchSize(),
-		e,
-		G.NewGraph(),
-		hiddenSizes,
-		biases,
-		init,
-		activations,
-		seed,
-	)
+	targetNetPolicy, err := newPolicy(0.0, c.BatchSize())
 	if err != nil {
 		return &DeepQ{}, fmt.Errorf("new: could not create target policy")
 	}
 	c.targetNet = targetNetPolicy.Network()
 
 	// Create the target network
-	trainNetPolicy, err := policy.NewMultiHeadEGreedyMLP(
-		0.0,
-		c.BatchSize(),
-		e,
-		G.NewGraph(),
-		hiddenSizes,
-		biases,
-		init,
-		activations,
-		seed,
-	)
+	trainNetPolicy, err := newPolicy(0.0, c.BatchSize())
 	if err != nil {
 		return &DeepQ{}, fmt.Errorf("new: could not create target policy")
 	}
@@ -250,9 +389,30 @@ func (c Config) CreateAgent(e env.Environment, s uint64) (agent.Agent, error) {
 	network.Set(c.trainNet, targetPolicy.Network())
 
 	// Behaviour policy can be set to evaluation mode to get the target
-	// policy since it is an EGreedy policy and DeepQ's target policy
-	// is greedy with respect to action values.
-	c.policy = behaviourPolicy.(agent.EGreedyNNPolicy)
+	// policy since both EGreedy and Boltzmann policies are greedy in
+	// evaluation mode, and DeepQ's target policy is greedy with respect
+	// to action values.
+	c.policy = behaviourPolicy
 
 	return New(e, c, seed)
 }
+
+// createGonumAgent creates a new GonumDeepQ agent based on the
+// configuration
+func (c Config) createGonumAgent(e env.Environment,
+	seed int64) (agent.Agent, error) {
+	var err error
+	if c.Temperature > 0 {
+		c.gonumPolicy, err = policy.NewGonumBoltzmannMLP(c.Temperature, e,
+			c.Layers, c.Biases, c.InitWFn.InitWFn(), c.Activations, seed)
+	} else {
+		c.gonumPolicy, err = policy.NewGonumEGreedyMLP(c.epsilon(), e,
+			c.Layers, c.Biases, c.InitWFn.InitWFn(), c.Activations, seed)
+	}
+	if err != nil {
+		return &GonumDeepQ{}, fmt.Errorf("createAgent: could not create "+
+			"behaviour policy: %v", err)
+	}
+
+	return NewGonum(e, c, seed)
+}
@@ -0,0 +1,272 @@
+package deepq
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/agent/nonlinear/discrete/policy"
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/initwfn"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/schedule"
+	"github.com/samuelfneumann/golearn/solver"
+	G "gorgonia.org/gorgonia"
+)
+
+func init() {
+	// Register ConvConfigList type so that it can be typed using
+	// agent.TypedConfigList to help with serialization/deserialization.
+	agent.Register(agent.EGreedyDeepQConvMLP, ConvConfigList{})
+}
+
+// ConvConfigList implements a list of ConvConfig's in a more efficient
+// manner than simply using a slice of ConvConfig's.
+type ConvConfigList struct {
+	// Convolutional and pooling layers of the neural net
+	ConvLayers [][]network.ConvLayerConfig
+
+	Layers      [][]int                 // Fully connected layer sizes
+	Biases      [][]bool                // Whether each layer should have a bias
+	Activations [][]*network.Activation // Activation of each layer
+	Solver      []*solver.Solver        // Solver for learning weights
+
+	// Initialization algorithm for weights
+	InitWFn []*initwfn.InitWFn
+
+	Epsilon []float64 // Behaviour policy epsilon
+
+	// Optional schedule for the behaviour policy epsilon, which
+	// replaces Epsilon
+	EpsilonSchedule []*schedule.Schedule
+
+	// Experience replay parameters
+	ExpReplay []expreplay.Config
+
+	// Target net updates
+	Tau                  []float64 // Polyak averaging constant
+	TargetUpdateInterval []int     // Number of steps target network updates
+
+	// Optional flag to select uniformly random actions until the
+	// experience replay buffer can be sampled from
+	RandomWarmup []bool
+}
+
+// NewConvConfigList returns a new ConvConfigList as an
+// agent.TypedConfigList. Because the returned value is a TypedList, it
+// can safely be JSON serialized and deserialized without specifying
+// what the type of the ConvConfigList is.
+func NewConvConfigList(
+	ConvLayers [][]network.ConvLayerConfig,
+	Layers [][]int,
+	Biases [][]bool,
+	Activations [][]*network.Activation,
+	Solver []*solver.Solver,
+	InitWFn []*initwfn.InitWFn,
+	Epsilon []float64,
+	ExpReplay []expreplay.Config,
+	Tau []float64,
+	TargetUpdateInterval []int,
+) agent.TypedConfigList {
+	configs := ConvConfigList{
+		ConvLayers:           ConvLayers,
+		Layers:               Layers,
+		Biases:               Biases,
+		Activations:          Activations,
+		Solver:               Solver,
+		InitWFn:              InitWFn,
+		Epsilon:              Epsilon,
+		ExpReplay:            ExpReplay,
+		Tau:                  Tau,
+		TargetUpdateInterval: TargetUpdateInterval,
+	}
+
+	return agent.NewTypedConfigList(configs)
+}
+
+// Type returns the type of Config stored in the list
+func (c ConvConfigList) Type() agent.Type {
+	return c.Config().Type()
+}
+
+// NumFields returns the number of settable fields in a ConvConfig
+func (c ConvConfigList) NumFields() int {
+	rValue := reflect.ValueOf(c)
+	return rValue.NumField()
+}
+
+// Config returns an empty Config of the same type as that stored
+// by the ConvConfigList
+func (c ConvConfigList) Config() agent.Config {
+	return ConvConfig{}
+}
+
+// Len returns the number of ConvConfig's in the list
+func (c ConvConfigList) Len() int {
+	return len(c.ConvLayers) * len(c.Layers) * len(c.Biases) *
+		len(c.Activations) * len(c.Solver) * len(c.InitWFn) *
+		agent.NumSettings(len(c.Epsilon)) * len(c.ExpReplay) * len(c.Tau) *
+		len(c.TargetUpdateInterval) *
+		agent.NumSettings(len(c.EpsilonSchedule)) *
+		agent.NumSettings(len(c.RandomWarmup))
+}
+
+// ConvConfig implements a configuration for a DeepQ agent which uses
+// a convolutional neural network to predict action values. The
+// environment used with a ConvConfig must satisfy the
+// environment.ImageEnvironment interface.
+type ConvConfig struct {
+	// Convolutional and pooling layers of the neural net
+	ConvLayers []network.ConvLayerConfig
+
+	Layers      []int                 // Fully connected layer sizes
+	Biases      []bool                // Whether each layer should have a bias
+	Activations []*network.Activation // Activation of each layer
+	Solver      *solver.Solver        // Solver for learning weights
+
+	// Initialization algorithm for weights
+	InitWFn *initwfn.InitWFn
+
+	Epsilon float64 // Behaviour policy epsilon
+
+	// Optional schedule for the behaviour policy epsilon. If non-nil,
+	// the behaviour policy's epsilon follows the schedule and Epsilon
+	// is ignored.
+	EpsilonSchedule *schedule.Schedule
+
+	// Experience replay parameters
+	ExpReplay expreplay.Config
+
+	// Target net updates
+	Tau                  float64 // Polyak averaging constant
+	TargetUpdateInterval int     // Number of steps target network updates
+
+	// Optional flag to select actions uniformly randomly from the
+	// action space of the environment, instead of with the behaviour
+	// policy, until the experience replay buffer holds
+	// ExpReplay.MinReplayCapacity samples
+	RandomWarmup bool
+}
+
+// BatchSize returns the batch size of the agent constructed using this
+// ConvConfig
+func (c ConvConfig) BatchSize() int {
+	return c.ExpReplay.SampleSize
+}
+
+// Type returns the type of the configuration
+func (c ConvConfig) Type() agent.Type {
+	return agent.EGreedyDeepQConvMLP
+}
+
+// Validate checks a ConvConfig to ensure it is a valid configuration
+// of a DeepQ agent.
+func (c ConvConfig) Validate() error {
+	for i, layer := range c.ConvLayers {
+		if err := layer.Validate(); err != nil {
+			return fmt.Errorf("new: invalid convolutional layer %v: %v", i,
+				err)
+		}
+	}
+
+	return c.config().Validate()
+}
+
+// ValidAgent returns whether the agent is valid for the configuration.
+// That is, whether Agent a can be constructed with ConvConfig c.
+func (c ConvConfig) ValidAgent(a agent.Agent) bool {
+	_, ok := a.(*DeepQ)
+	return ok
+}
+
+// config returns the Config with the same settings as the ConvConfig,
+// excluding the convolutional layers
+func (c ConvConfig) config() Config {
+	return Config{
+		Layers:               c.Layers,
+		Biases:               c.Biases,
+		Activations:          c.Activations,
+		Solver:               c.Solver,
+		InitWFn:              c.InitWFn,
+		Epsilon:              c.Epsilon,
+		EpsilonSchedule:      c.EpsilonSchedule,
+		ExpReplay:            c.ExpReplay,
+		Tau:                  c.Tau,
+		TargetUpdateInterval: c.TargetUpdateInterval,
+		RandomWarmup:         c.RandomWarmup,
+	}
+}
+
+// CreateAgent creates a new DeepQ agent based on the configuration
+func (c ConvConfig) CreateAgent(e env.Environment,
+	s uint64) (agent.Agent, error) {
+	if err := c.Validate(); err != nil {
+		return &DeepQ{}, err
+	}
+	seed := int64(s)
+
+	// Extract configuration variables
+	init := c.InitWFn.InitWFn()
+
+	// newPolicy creates a new policy with the given epsilon and batch
+	// size, using the configured network architecture
+	newPolicy := func(ε float64, batch int) (agent.EGreedyNNPolicy, error) {
+		return policy.NewMultiHeadEGreedyConvMLP(
+			ε,
+			batch,
+			e,
+			G.NewGraph(),
+			c.ConvLayers,
+			c.Layers,
+			c.Biases,
+			init,
+			c.Activations,
+			seed,
+		)
+	}
+
+	// Behaviour policy
+	behaviourPolicy, err := newPolicy(c.config().epsilon(), 1)
+	if err != nil {
+		return &DeepQ{}, fmt.Errorf("createAgent: could not create "+
+			"behaviour policy: %v", err)
+	}
+
+	// Create the target (greedy) policy
+	targetPolicy, err := newPolicy(0.0, 1)
+	if err != nil {
+		return &DeepQ{}, fmt.Errorf("createAgent: could not create target "+
+			"policy: %v", err)
+	}
+
+	// Create the target network
+	targetNetPolicy, err := newPolicy(0.0, c.BatchSize())
+	if err != nil {
+		return &DeepQ{}, fmt.Errorf("createAgent: could not create target "+
+			"network: %v", err)
+	}
+
+	// Create the training network
+	trainNetPolicy, err := newPolicy(0.0, c.BatchSize())
+	if err != nil {
+		return &DeepQ{}, fmt.Errorf("createAgent: could not create "+
+			"training network: %v", err)
+	}
+
+	config := c.config()
+	config.targetNet = targetNetPolicy.Network()
+	config.trainNet = trainNetPolicy.Network()
+
+	// Set the policies to have the same weights
+	network.Set(behaviourPolicy.Network(), targetPolicy.Network())
+	network.Set(config.targetNet, targetPolicy.Network())
+	network.Set(config.trainNet, targetPolicy.Network())
+
+	// Behaviour policy can be set to evaluation mode to get the target
+	// policy since it is an EGreedy policy and DeepQ's target policy
+	// is greedy with respect to action values.
+	config.policy = behaviourPolicy
+
+	return New(e, config, seed)
+}
@@ -1,17 +1,22 @@
 package deepq
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"strings"
 
 	"github.com/samuelfneumann/golearn/agent"
 	"github.com/samuelfneumann/golearn/agent/linear/discrete/qlearning"
+	"github.com/samuelfneumann/golearn/agent/random"
 	"github.com/samuelfneumann/golearn/buffer/expreplay"
 	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/initwfn"
 	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/schedule"
 	"github.com/samuelfneumann/golearn/solver"
 	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/mat"
 	G "gorgonia.org/gorgonia"
 	"gorgonia.org/tensor"
@@ -19,17 +24,25 @@ import (
 
 // DeepQ implements the deep Q-learning algorithm. This algorithm is
 // conceptually similar to DQN, but uses the MSE loss.
+//
+// Environments with MultiDiscrete actions are supported using a
+// branching architecture. The network predicts the value of each
+// action in each action dimension, and the values in each dimension are
+// learned independently using the update target
+// r + γ * max[Q_d(s', a')] for dimension d. The loss is the mean
+// squared TD error over all dimensions.
 type DeepQ struct {
 	// Action selection policy. We only need a single policy for both
 	// target and behaviour policy. DeepQ's target policy is greedy
 	// with respect to action values, which we can get by setting
-	// the policy to evaluation mode.
-	policy agent.EGreedyNNPolicy
+	// the policy to evaluation mode. The policy is either an
+	// agent.EGreedyNNPolicy or an agent.BoltzmannNNPolicy.
+	policy agent.NNPolicy
 
 	// Policy for learning weights that takes in batches of inputs
 	trainNet   network.NeuralNet // Policy whose weights are adapted
 	trainNetVM G.VM
-	solver     G.Solver // Adapts the weights of trainNet
+	solver     *solver.Solver // Adapts the weights of trainNet
 
 	// Policy that provides the update target for a batch of inputs
 	// Note that this is a target network, providing the update target.
@@ -43,24 +56,32 @@ type DeepQ struct {
 	gradientSteps        int
 
 	selectedActions *G.Node // Actions taken at the previous states
-	numActions      int
+	numActions      int     // Total actions over all action dimensions
+	branches        []int   // Number of actions in each action dimension
 
 	replay expreplay.ExperienceReplayer
 
-	// nextStateActionValues is the input node in the graph of trainNet that
-	// is given the action values of the next state. For update:
+	// Selects uniformly random actions until the replay buffer can be
+	// sampled from, nil if no random warmup is used
+	warmup *random.Warmup
+
+	// updateTargets is the input node in the graph of trainNet that
+	// is given the update target of each action. For update:
 	//
-	// Q(s, a) <- Q(s, a) + α * (r + Q(s', a') - Q(s, a)) ∇Q(s, a)
+	// Q(s, a) <- Q(s, a) + α * (r + γ * max[Q(s', a')] - Q(s, a)) ∇Q(s, a)
 	//
-	// nextStateActionValues provides Q(s', a') for all a' in s' and is
-	// computed by targetNet.
-	nextStateActionValues *G.Node
-	rewards               *G.Node
-	discounts             *G.Node
+	// updateTargets provides r + γ * max[Q(s', a')], where Q(s', a')
+	// is computed by targetNet. Each action in an action dimension has
+	// the same update target.
+	updateTargets *G.Node
 
 	// Keep track of previous states and actions to add to replay buffer
 	prevStep ts.TimeStep
 
+	// Schedule for the ε of the behaviour policy, nil if ε is constant
+	epsilonSchedule *schedule.Schedule
+	steps           int // Steps taken in training mode
+
 	batchSize int
 }
 
@@ -71,20 +92,22 @@ func New(env environment.Environment, c agent.Config,
 		return nil, fmt.Errorf("new: invalid configuration type: %T", c)
 	}
 
-	// Ensure environment has discrete actions
-	if env.ActionSpec().Cardinality != environment.Discrete {
+	// Ensure environment has discrete or multi-discrete actions
+	spec := env.ActionSpec()
+	if spec.Cardinality != environment.Discrete &&
+		spec.Cardinality != environment.MultiDiscrete {
 		return &DeepQ{}, fmt.Errorf("deepq: cannot use non-discrete " +
 			"actions")
 	}
 
-	// Ensure actions are one-dimensional
-	if env.ActionSpec().LowerBound.Len() > 1 {
-		return &DeepQ{}, fmt.Errorf("deepq: actions must be " +
+	// Ensure discrete actions are one-dimensional
+	if spec.Cardinality == environment.Discrete && spec.Shape.Len() > 1 {
+		return &DeepQ{}, fmt.Errorf("deepq: discrete actions must be " +
 			"1-dimensional")
 	}
 
 	// Ensure actions are enumerated from 0
-	if env.ActionSpec().LowerBound.AtVec(0) != 0.0 {
+	if mat.Min(spec.LowerBound) != 0.0 || mat.Max(spec.LowerBound) != 0.0 {
 		return &DeepQ{}, fmt.Errorf("deepq: actions must be " +
 			"enumerated starting from 0")
 	}
@@ -99,7 +122,11 @@ func New(env environment.Environment, c agent.Config,
 
 	// Extract configuration variables
 	batchSize := config.BatchSize()
-	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+	branches := spec.DiscreteSizes()
+	numActions := 0
+	for _, n := range branches {
+		numActions += n
+	}
 
 	// Create the target network which provides the update target
 	targetNet := config.targetNet
@@ -119,22 +146,16 @@ func New(env environment.Environment, c agent.Config,
 	trainNet := config.trainNet
 	gTrain := trainNet.Graph()
 
-	// Create nodes to compute the update target: r + γ * max[Q(s', a')]
-	nextStateActionValues := G.NewMatrix(gTrain, tensor.Float64,
-		G.WithShape(batchSize, numActions), G.WithName("targetActionVals"))
-	rewards := G.NewVector(gTrain, tensor.Float64, G.WithShape(batchSize),
-		G.WithName("reward"))
-	discounts := G.NewVector(gTrain, tensor.Float64, G.WithShape(batchSize),
-		G.WithName("discount"))
-
-	// Compute the update target
-	updateTarget := G.Must(G.Max(nextStateActionValues, 1))
-	updateTarget = G.Must(G.HadamardProd(updateTarget, discounts))
-	updateTarget = G.Must(G.Add(updateTarget, rewards))
+	// Create the node holding the update target r + γ * max[Q(s', a')]
+	// of each action
+	updateTargets := G.NewMatrix(gTrain, tensor.Float64,
+		G.WithShape(batchSize, numActions), G.WithName("updateTargets"))
 
 	// Action selected in the previous state. This is needed to compute
 	// the loss using the correct action value since the network outputs N
-	// action values, one for each environmental action
+	// action values, one for each environmental action. For
+	// multi-discrete actions, one action is selected in each action
+	// dimension.
 	selectedActions := G.NewMatrix(
 		gTrain,
 		tensor.Float64,
@@ -144,14 +165,17 @@ func New(env environment.Environment, c agent.Config,
 
 	var cost *G.Node
 	for _, pred := range trainNet.Prediction() {
-		selectedActionsValue := G.Must(G.HadamardProd(pred,
-			selectedActions))
-		selectedActionsValue = G.Must(G.Sum(selectedActionsValue, 1))
+		// Compute the TD error of the selected actions only
+		tdError := G.Must(G.Sub(updateTargets, pred))
+		tdError = G.Must(G.HadamardProd(tdError, selectedActions))
 
 		// Compute the Mean Squarred TD error
-		loss := G.Must(G.Sub(updateTarget, selectedActionsValue))
-		loss = G.Must(G.Square(loss))
+		loss := G.Must(G.Square(tdError))
+		loss = G.Must(G.Sum(loss, 1))
 		loss = G.Must(G.Mean(loss))
+		if len(branches) > 1 {
+			loss = G.Must(G.Div(loss, G.NewConstant(float64(len(branches)))))
+		}
 		if cost == nil {
 			cost = loss
 		} else {
@@ -174,7 +198,7 @@ func New(env environment.Environment, c agent.Config,
 	solver := config.Solver
 
 	// Create the experience replay buffer. The replay buffer stores
-	// actions selected as one-hot vectors
+	// actions selected as one-hot vectors in each action dimension
 	numFeatures := env.ObservationSpec().Shape.Len()
 	replay, err := config.ExpReplay.Create(numFeatures, numActions, seed,
 		false)
@@ -183,24 +207,34 @@ func New(env environment.Environment, c agent.Config,
 		return &DeepQ{}, fmt.Errorf(msg, err)
 	}
 
+	var warmup *random.Warmup
+	if config.RandomWarmup {
+		warmup, err = random.NewWarmup(env, replay, uint64(seed))
+		if err != nil {
+			return &DeepQ{}, fmt.Errorf("new: could not create random "+
+				"warmup: %v", err)
+		}
+	}
+
 	return &DeepQ{
-		policy:                config.policy,
-		trainNet:              trainNet,
-		trainNetVM:            trainNetVM,
-		solver:                solver,
-		targetNet:             targetNet,
-		targetNetVM:           targetNetVM,
-		tau:                   tau,
-		targetUpdateInterval:  targetUpdateInterval,
-		gradientSteps:         0,
-		selectedActions:       selectedActions,
-		numActions:            numActions,
-		replay:                replay,
-		nextStateActionValues: nextStateActionValues,
-		rewards:               rewards,
-		discounts:             discounts,
-		prevStep:              ts.TimeStep{},
-		batchSize:             batchSize,
+		policy:               config.policy,
+		trainNet:             trainNet,
+		trainNetVM:           trainNetVM,
+		solver:               solver,
+		targetNet:            targetNet,
+		targetNetVM:          targetNetVM,
+		tau:                  tau,
+		targetUpdateInterval: targetUpdateInterval,
+		gradientSteps:        0,
+		selectedActions:      selectedActions,
+		numActions:           numActions,
+		branches:             branches,
+		replay:               replay,
+		warmup:               warmup,
+		updateTargets:        updateTargets,
+		prevStep:             ts.TimeStep{},
+		epsilonSchedule:      config.EpsilonSchedule,
+		batchSize:            batchSize,
 	}, nil
 }
 
@@ -215,12 +249,14 @@ func NewQlearning(env environment.Environment, config qlearning.Config,
 		return nil, fmt.Errorf("newQLearning: cannot create solver: %v", err)
 	}
 	deepQConfig := &Config{
-		Epsilon:     config.Epsilon,
-		Layers:      []int{},
-		Biases:      []bool{},
-		Activations: []*network.Activation{},
-		InitWFn:     InitWFn,
-		Solver:      sol,
+		Epsilon:         config.Epsilon,
+		EpsilonSchedule: config.EpsilonSchedule,
+		Temperature:     config.Temperature,
+		Layers:          []int{},
+		Biases:          []bool{},
+		Activations:     []*network.Activation{},
+		InitWFn:         InitWFn,
+		Solver:          sol,
 
 		Tau:                  1.0,
 		TargetUpdateInterval: 1,
@@ -240,15 +276,19 @@ func (d *DeepQ) ObserveFirst(t ts.TimeStep) error {
 
 // Observe observes and records any timestep other than the first timestep
 func (d *DeepQ) Observe(a mat.Vector, nextStep ts.TimeStep) error {
-	if a.Len() != 1 {
-		return fmt.Errorf("observe: cannot observe "+
-			"multi-dimensional action (action dim = %d) for DeepQ", a.Len())
+	if a.Len() != len(d.branches) {
+		return fmt.Errorf("observe: cannot observe action of dimension "+
+			"%d for DeepQ (want dimension %d)", a.Len(), len(d.branches))
 	}
 
 	// Add to replay buffer
 	if !nextStep.First() {
 		action := mat.NewVecDense(d.numActions, nil)
-		action.SetVec(int(a.AtVec(0)), 1.0)
+		start := 0
+		for i, n := range d.branches {
+			action.SetVec(start+int(a.AtVec(i)), 1.0)
+			start += n
+		}
 		nextAction := mat.NewVecDense(d.numActions, nil)
 
 		transition := ts.NewTransition(d.prevStep, action, nextStep, nextAction)
@@ -286,29 +326,31 @@ func (d *DeepQ) Step() error {
 		return fmt.Errorf("step: could not run target vm: %v", err)
 	}
 
-	// Set the action values for the actions in the next state
-	err = G.Let(d.nextStateActionValues, d.targetNet.Output()[0])
-	if err != nil {
-		return fmt.Errorf("step: could not set next state-action values: %v",
-			err)
+	// Compute the update target r + γ * max[Q(s', a')] in each action
+	// dimension
+	nextActionValues := d.targetNet.Output()[0].Data().([]float64)
+	targets := make([]float64, d.batchSize*d.numActions)
+	for i := 0; i < d.batchSize; i++ {
+		start := i * d.numActions
+		for _, n := range d.branches {
+			maxValue := floats.Max(nextActionValues[start : start+n])
+			for j := start; j < start+n; j++ {
+				targets[j] = R[i] + discount[i]*maxValue
+			}
+			start += n
+		}
 	}
 
 	d.targetNetVM.Reset()
 
-	// Set the reward for the current action
-	rewardTensor := tensor.New(tensor.WithBacking(R),
-		tensor.WithShape(d.batchSize))
-	err = G.Let(d.rewards, rewardTensor)
-	if err != nil {
-		return fmt.Errorf("step: could not set reward: %v", err)
-	}
-
-	// Set the discount for the next action value
-	discountTensor := tensor.New(tensor.WithBacking(discount),
-		tensor.WithShape(d.batchSize))
-	err = G.Let(d.discounts, discountTensor)
+	// Set the update target for the current actions
+	targetTensor := tensor.New(
+		tensor.WithShape(d.batchSize, d.numActions),
+		tensor.WithBacking(targets),
+	)
+	err = G.Let(d.updateTargets, targetTensor)
 	if err != nil {
-		return fmt.Errorf("step: could not set discount: %v", err)
+		return fmt.Errorf("step: could not set update targets: %v", err)
 	}
 
 	// Previous action one-hot vectors
@@ -368,29 +410,76 @@ func (d *DeepQ) Step() error {
 // SelectAction runs the necessary VMs and then returns an action
 // selected by the behaviour policy.
 func (d *DeepQ) SelectAction(t ts.TimeStep) *mat.VecDense {
+	if !d.IsEval() {
+		d.anneal()
+
+		// Select uniformly random actions until the replay buffer can
+		// be sampled from
+		if d.warmup != nil && d.warmup.Active() {
+			return d.warmup.SelectAction(t)
+		}
+	}
+
 	// Select action from target or behaviour policy depending on if
 	// in training or eval mode
 	return d.policy.SelectAction(t)
 }
 
 // TdError calculates the TD error generated by the learner on some
-// transition.
+// transition. For multi-discrete actions, the TD error is averaged over
+// all action dimensions.
 func (d *DeepQ) TdError(t ts.Transition) float64 {
 	step := ts.TimeStep{Observation: t.State}
-	action := int(d.policy.SelectAction(step).AtVec(0))
+	action := d.policy.SelectAction(step)
 	actionValues := d.policy.Network().Output()[0].Data()
-	actionValue := actionValues.([]float64)[action]
+	actionValue := d.actionValue(actionValues.([]float64), action)
 
 	d.policy.Eval()
 	step.Observation = t.NextState
-	nextAction := int(d.policy.SelectAction(step).AtVec(0))
+	nextAction := d.policy.SelectAction(step)
 	nextActionValues := d.policy.Network().Output()[0].Data()
-	nextActionValue := nextActionValues.([]float64)[nextAction]
+	nextActionValue := d.actionValue(nextActionValues.([]float64),
+		nextAction)
 	d.policy.Train()
 
 	return t.Reward + t.Discount*nextActionValue - actionValue
 }
 
+// actionValue returns the value of action given the values of each
+// action in each action dimension. For multi-discrete actions, the
+// value is the average value of the action in each dimension.
+func (d *DeepQ) actionValue(actionValues []float64,
+	action mat.Vector) float64 {
+	value := 0.0
+	start := 0
+	for i, n := range d.branches {
+		value += actionValues[start+int(action.AtVec(i))]
+		start += n
+	}
+	return value / float64(len(d.branches))
+}
+
+// anneal sets the ε of the behaviour policy as determined by the ε
+// schedule and advances the schedule by one step. If no schedule is
+// used, anneal does nothing.
+func (d *DeepQ) anneal() {
+	egreedy, ok := d.policy.(agent.EGreedyNNPolicy)
+	if d.epsilonSchedule == nil || !ok {
+		return
+	}
+	egreedy.SetEpsilon(d.epsilonSchedule.Value(d.steps))
+	d.steps++
+}
+
+// Epsilon returns the current ε of the behaviour policy. If the
+// behaviour policy is a Boltzmann policy, Epsilon returns 0.
+func (d *DeepQ) Epsilon() float64 {
+	if egreedy, ok := d.policy.(agent.EGreedyNNPolicy); ok {
+		return egreedy.Epsilon()
+	}
+	return 0
+}
+
 // Eval sets the agent into evaluation mode
 func (d *DeepQ) Eval() {
 	d.policy.Eval()
@@ -447,3 +536,75 @@ func (d *DeepQ) Close() error {
 	}
 	return nil
 }
+
+// deepQCheckpoint is the serialized form of a DeepQ agent
+type deepQCheckpoint struct {
+	TrainNet      *network.Model
+	TargetNet     *network.Model
+	Solver        *solver.Solver
+	Steps         int
+	GradientSteps int
+}
+
+// GobEncode implements the gob.GobEncoder interface. The weights of
+// the agent's networks, the progress of its ε schedule, the number of
+// gradient steps taken, and the solver with the progress of its step
+// size schedule are encoded. The experience replay buffer and the
+// internal state of the solver, such as moment estimates, are not
+// saved.
+func (d *DeepQ) GobEncode() ([]byte, error) {
+	trainNet, err := network.NewModel(d.trainNet)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode train "+
+			"network: %v", err)
+	}
+	targetNet, err := network.NewModel(d.targetNet)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode target "+
+			"network: %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err = enc.Encode(deepQCheckpoint{
+		TrainNet:      trainNet,
+		TargetNet:     targetNet,
+		Solver:        d.solver,
+		Steps:         d.steps,
+		GradientSteps: d.gradientSteps,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface. The DeepQ agent
+// must have been created with the same configuration as the agent
+// which was encoded.
+func (d *DeepQ) GobDecode(in []byte) error {
+	var checkpoint deepQCheckpoint
+	dec := gob.NewDecoder(bytes.NewReader(in))
+	if err := dec.Decode(&checkpoint); err != nil {
+		return fmt.Errorf("gobdecode: %v", err)
+	}
+
+	if err := checkpoint.TrainNet.SetWeights(d.trainNet); err != nil {
+		return fmt.Errorf("gobdecode: train network: %v", err)
+	}
+	if err := checkpoint.TargetNet.SetWeights(d.targetNet); err != nil {
+		return fmt.Errorf("gobdecode: target network: %v", err)
+	}
+	if err := network.Set(d.policy.Network(), d.trainNet); err != nil {
+		return fmt.Errorf("gobdecode: policy network: %v", err)
+	}
+
+	d.solver = checkpoint.Solver
+	d.gradientSteps = checkpoint.GradientSteps
+	d.steps = checkpoint.Steps
+	egreedy, ok := d.policy.(agent.EGreedyNNPolicy)
+	if d.epsilonSchedule != nil && ok {
+		egreedy.SetEpsilon(d.epsilonSchedule.Value(d.steps))
+	}
+	return nil
+}
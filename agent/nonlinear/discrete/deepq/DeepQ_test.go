@@ -0,0 +1,320 @@
+package deepq
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	"github.com/samuelfneumann/golearn/environment/minatar"
+	"github.com/samuelfneumann/golearn/initwfn"
+	"github.com/samuelfneumann/golearn/internal/agenttest"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/solver"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// TestBootstrap tests that DeepQ bootstraps from the last state of
+// truncated episodes but not from the last state of terminated
+// episodes, so that its value estimates are unbiased.
+func TestBootstrap(t *testing.T) {
+	newAgent := func(env environment.Environment) (agent.Agent, error) {
+		adam, err := solver.NewDefaultAdam(0.01, 1)
+		if err != nil {
+			return nil, err
+		}
+		init, err := initwfn.NewZeroes()
+		if err != nil {
+			return nil, err
+		}
+
+		c := Config{
+			Layers:      []int{},
+			Biases:      []bool{},
+			Activations: []*network.Activation{},
+			Solver:      adam,
+			InitWFn:     init,
+			ExpReplay: expreplay.Config{
+				RemoveMethod:      expreplay.Fifo,
+				SampleMethod:      expreplay.Uniform,
+				RemoveSize:        1,
+				SampleSize:        1,
+				MaxReplayCapacity: 10,
+				MinReplayCapacity: 1,
+			},
+			Tau:                  1.0,
+			TargetUpdateInterval: 1,
+		}
+		return c.CreateAgent(env, 1)
+	}
+
+	agenttest.Bootstrap(t, newAgent, stateValue)
+}
+
+// stateValue returns the value that agent a predicts for the single
+// action in the single state of a constant.Constant environment
+func stateValue(a agent.Agent) float64 {
+	obs := mat.NewVecDense(1, []float64{1})
+	action := mat.NewVecDense(1, nil)
+	return -a.(*DeepQ).TdError(ts.Transition{State: obs, Action: action,
+		NextState: obs})
+}
+
+// TestConvConfig tests that a DeepQ agent created from a ConvConfig
+// updates the weights of its convolutional and fully connected layers
+// with a single learning step on image observations
+func TestConvConfig(t *testing.T) {
+	env, step, err := minatar.NewBreakout(minatar.NewPlay(100), 0, 0.99, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adam, err := solver.NewDefaultAdam(0.01, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	init, err := initwfn.NewGlorotU(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := ConvConfig{
+		ConvLayers: []network.ConvLayerConfig{
+			{Type: network.Conv2D, Filters: 2, Kernel: []int{3, 3},
+				Bias: true, Activation: network.ReLU()},
+			{Type: network.AvgPool, Kernel: []int{2, 2}},
+		},
+		Layers:      []int{8},
+		Biases:      []bool{true},
+		Activations: []*network.Activation{network.ReLU()},
+		Solver:      adam,
+		InitWFn:     init,
+		Epsilon:     0.1,
+		ExpReplay: expreplay.Config{
+			RemoveMethod:      expreplay.Fifo,
+			SampleMethod:      expreplay.Uniform,
+			RemoveSize:        1,
+			SampleSize:        2,
+			MaxReplayCapacity: 10,
+			MinReplayCapacity: 2,
+		},
+		Tau:                  1.0,
+		TargetUpdateInterval: 1,
+	}
+	a, err := c.CreateAgent(env, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Store the initial weights of the network, which are updated in
+	// place
+	var before [][]float64
+	learnables := a.(*DeepQ).trainNet.Learnables()
+	for _, node := range learnables {
+		before = append(before, append([]float64{},
+			node.Value().Data().([]float64)...))
+	}
+
+	// The first step fills the replay buffer to the minimum capacity
+	// needed to sample, so that only the second step updates the network
+	agenttest.Run(t, a, env, step, 2)
+
+	for i, node := range learnables {
+		if floats.Equal(before[i], node.Value().Data().([]float64)) {
+			t.Errorf("%v was not updated", node.Name())
+		}
+	}
+}
+
+// newRecurrentAgent returns a new RecurrentDeepQ agent with GRU
+// layers acting in env
+func newRecurrentAgent(t *testing.T, env environment.Environment) agent.Agent {
+	t.Helper()
+
+	adam, err := solver.NewDefaultAdam(0.01, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	init, err := initwfn.NewGlorotU(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := RecurrentConfig{
+		CellType:    network.GRU,
+		CellSizes:   []int{4},
+		Layers:      []int{},
+		Biases:      []bool{},
+		Activations: []*network.Activation{},
+		Solver:      adam,
+		InitWFn:     init,
+		Epsilon:     0.1,
+		ExpReplay: expreplay.SequenceConfig{
+			SampleSize:        2,
+			SequenceLength:    3,
+			MaxReplayCapacity: 20,
+			MinReplayCapacity: 2,
+		},
+		Tau:                  1.0,
+		TargetUpdateInterval: 1,
+	}
+	a, err := c.CreateAgent(env, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// TestRecurrentResetState tests that the hidden state of the
+// behaviour policy of RecurrentDeepQ is carried between timesteps and
+// is reset at the end of each episode
+func TestRecurrentResetState(t *testing.T) {
+	env, step, err := constant.New(3, 1.0, environment.NewStepLimit(3), 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newRecurrentAgent(t, env)
+
+	// actionValues returns the action values predicted by the behaviour
+	// policy when selecting an action in the current hidden state
+	actionValues := func() []float64 {
+		a.SelectAction(step)
+		net := a.(*RecurrentDeepQ).policy.Network()
+		return append([]float64{}, net.Output()[0].Data().([]float64)...)
+	}
+
+	// Observations are constant, so action values only change within
+	// an episode because the hidden state changes
+	first := actionValues()
+	if second := actionValues(); floats.Equal(first, second) {
+		t.Errorf("hidden state was not carried between timesteps")
+	}
+
+	a.EndEpisode()
+	if have := actionValues(); !floats.Equal(have, first) {
+		t.Errorf("hidden state was not reset at the end of the episode: "+
+			"have(%v) want(%v)", have, first)
+	}
+}
+
+// TestRecurrentStep tests that RecurrentDeepQ updates the weights of
+// its network when learning from sequences
+func TestRecurrentStep(t *testing.T) {
+	env, step, err := constant.New(3, 1.0, environment.NewStepLimit(3), 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newRecurrentAgent(t, env)
+
+	var before [][]float64
+	learnables := a.(*RecurrentDeepQ).trainNet.Learnables()
+	for _, node := range learnables {
+		before = append(before, append([]float64{},
+			node.Value().Data().([]float64)...))
+	}
+
+	agenttest.Run(t, a, env, step, 10)
+
+	for i, node := range learnables {
+		if floats.Equal(before[i], node.Value().Data().([]float64)) {
+			t.Errorf("%v was not updated", node.Name())
+		}
+	}
+}
+
+// TestNoisyZeroEpsilon tests that DeepQ with NoisyNet layers explores
+// with ε = 0, since the noise of its network is resampled before each
+// action is selected, that it acts greedily without noise in
+// evaluation mode, and that it learns the noise scales of its network
+func TestNoisyZeroEpsilon(t *testing.T) {
+	env, step, err := constant.New(3, 1.0, environment.NewStepLimit(10), 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newAgent := func(noisy bool) agent.Agent {
+		adam, err := solver.NewDefaultAdam(0.01, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		init, err := initwfn.NewGlorotU(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := Config{
+			Layers:      []int{4},
+			Biases:      []bool{true},
+			Activations: []*network.Activation{network.ReLU()},
+			Solver:      adam,
+			InitWFn:     init,
+			Epsilon:     0,
+			Noisy:       noisy,
+			ExpReplay: expreplay.Config{
+				RemoveMethod:      expreplay.Fifo,
+				SampleMethod:      expreplay.Uniform,
+				RemoveSize:        1,
+				SampleSize:        2,
+				MaxReplayCapacity: 10,
+				MinReplayCapacity: 2,
+			},
+			Tau:                  1.0,
+			TargetUpdateInterval: 1,
+		}
+		a, err := c.CreateAgent(env, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	// actions returns the number of distinct actions a selects in the
+	// single state of the environment
+	actions := func(a agent.Agent) int {
+		selected := make(map[float64]bool)
+		for i := 0; i < 100; i++ {
+			selected[a.SelectAction(step).AtVec(0)] = true
+		}
+		return len(selected)
+	}
+
+	if n := actions(newAgent(false)); n != 1 {
+		t.Errorf("without noise: distinct actions: have(%v) want(1)", n)
+	}
+
+	a := newAgent(true)
+	if n := actions(a); n < 2 {
+		t.Errorf("with noise: distinct actions: have(%v) want(>1)", n)
+	}
+	a.Eval()
+	if n := actions(a); n != 1 {
+		t.Errorf("evaluation mode: distinct actions: have(%v) want(1)", n)
+	}
+	a.Train()
+
+	var before [][]float64
+	var scales int
+	learnables := a.(*DeepQ).trainNet.Learnables()
+	for _, node := range learnables {
+		before = append(before, append([]float64{},
+			node.Value().Data().([]float64)...))
+		if strings.Contains(node.Name(), "NoiseScale") {
+			scales++
+		}
+	}
+	if scales == 0 {
+		t.Fatal("network has no noise scales")
+	}
+
+	agenttest.Run(t, a, env, step, 10)
+
+	for i, node := range learnables {
+		if floats.Equal(before[i], node.Value().Data().([]float64)) {
+			t.Errorf("%v was not updated", node.Name())
+		}
+	}
+}
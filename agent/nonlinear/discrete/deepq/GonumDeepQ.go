@@ -0,0 +1,409 @@
+package deepq
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/agent/random"
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
+	"github.com/samuelfneumann/golearn/schedule"
+	"github.com/samuelfneumann/golearn/solver"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// GonumDeepQ implements the deep Q-learning algorithm using neural
+// networks from package gonumnet. The algorithm is identical to that
+// of DeepQ, but since gonumnet networks need no VMs and accept inputs
+// of any batch size, the behaviour policy and the network whose
+// weights are learned share a single network.
+type GonumDeepQ struct {
+	// Action selection policy, which is greedy with respect to
+	// action values in evaluation mode. The policy is either an
+	// agent.GonumEGreedyPolicy or an agent.GonumBoltzmannPolicy.
+	policy agent.GonumPolicy
+
+	// Network whose weights are adapted, which is the network of the
+	// policy
+	trainNet gonumnet.Net
+	solver   *solver.Solver // Adapts the weights of trainNet
+
+	// Network that provides the update target
+	targetNet gonumnet.Net
+
+	// Variables to track target network updates
+	tau                  float64 // Polyak averaging constant
+	targetUpdateInterval int     // Steps between target updates
+	gradientSteps        int
+
+	features   int
+	numActions int
+
+	replay expreplay.ExperienceReplayer
+
+	// Selects uniformly random actions until the replay buffer can be
+	// sampled from, nil if no random warmup is used
+	warmup *random.Warmup
+
+	// Gradient of the loss with respect to the action values
+	grad *mat.Dense
+
+	// Keep track of previous states and actions to add to replay buffer
+	prevStep ts.TimeStep
+
+	// Schedule for the ε of the behaviour policy, nil if ε is constant
+	epsilonSchedule *schedule.Schedule
+	steps           int // Steps taken in training mode
+
+	batchSize int
+}
+
+// NewGonum creates and returns a new GonumDeepQ agent
+func NewGonum(env environment.Environment, c agent.Config,
+	seed int64) (agent.Agent, error) {
+	if !c.ValidAgent(&GonumDeepQ{}) {
+		return nil, fmt.Errorf("newGonum: invalid configuration type: %T", c)
+	}
+
+	// Ensure environment has discrete actions
+	if env.ActionSpec().Cardinality != environment.Discrete {
+		return &GonumDeepQ{}, fmt.Errorf("newGonum: cannot use " +
+			"non-discrete actions")
+	}
+
+	// Ensure actions are one-dimensional
+	if env.ActionSpec().LowerBound.Len() > 1 {
+		return &GonumDeepQ{}, fmt.Errorf("newGonum: actions must be " +
+			"1-dimensional")
+	}
+
+	// Ensure actions are enumerated from 0
+	if env.ActionSpec().LowerBound.AtVec(0) != 0.0 {
+		return &GonumDeepQ{}, fmt.Errorf("newGonum: actions must be " +
+			"enumerated starting from 0")
+	}
+
+	// Ensure the configuration is valid
+	err := c.Validate()
+	if err != nil {
+		return &GonumDeepQ{}, err
+	}
+
+	config := c.(Config)
+
+	batchSize := config.BatchSize()
+	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+	features := env.ObservationSpec().Shape.Len()
+
+	// The training network is the network of the behaviour policy, and
+	// the target network starts with the same weights
+	trainNet := config.gonumPolicy.Network()
+	targetNet := trainNet.Clone()
+
+	// Create the experience replay buffer. The replay buffer stores
+	// actions selected as one-hot vectors
+	replay, err := config.ExpReplay.Create(features, numActions, seed,
+		false)
+	if err != nil {
+		msg := "newGonum: could not create experience replay buffer: %v"
+		return &GonumDeepQ{}, fmt.Errorf(msg, err)
+	}
+
+	var warmup *random.Warmup
+	if config.RandomWarmup {
+		warmup, err = random.NewWarmup(env, replay, uint64(seed))
+		if err != nil {
+			return &GonumDeepQ{}, fmt.Errorf("newGonum: could not create random "+
+				"warmup: %v", err)
+		}
+	}
+
+	return &GonumDeepQ{
+		policy:               config.gonumPolicy,
+		trainNet:             trainNet,
+		solver:               config.Solver,
+		targetNet:            targetNet,
+		tau:                  config.Tau,
+		targetUpdateInterval: config.TargetUpdateInterval,
+		gradientSteps:        0,
+		features:             features,
+		numActions:           numActions,
+		replay:               replay,
+		warmup:               warmup,
+		grad:                 mat.NewDense(batchSize, numActions, nil),
+		prevStep:             ts.TimeStep{},
+		epsilonSchedule:      config.EpsilonSchedule,
+		batchSize:            batchSize,
+	}, nil
+}
+
+// ObserveFirst observes and records the first episodic timestep
+func (d *GonumDeepQ) ObserveFirst(t ts.TimeStep) error {
+	if !t.First() {
+		return fmt.Errorf("observeFirst: timestep is not first "+
+			"(current timestep = %d)", t.Number)
+	}
+	d.prevStep = t
+	return nil
+}
+
+// Observe observes and records any timestep other than the first timestep
+func (d *GonumDeepQ) Observe(a mat.Vector, nextStep ts.TimeStep) error {
+	if a.Len() != 1 {
+		return fmt.Errorf("observe: cannot observe "+
+			"multi-dimensional action (action dim = %d) for DeepQ", a.Len())
+	}
+
+	// Add to replay buffer
+	if !nextStep.First() {
+		action := mat.NewVecDense(d.numActions, nil)
+		action.SetVec(int(a.AtVec(0)), 1.0)
+		nextAction := mat.NewVecDense(d.numActions, nil)
+
+		transition := ts.NewTransition(d.prevStep, action, nextStep, nextAction)
+		err := d.replay.Add(transition)
+		if err != nil {
+			return fmt.Errorf("observe: could not add to replay buffer: %v",
+				err)
+		}
+	}
+
+	d.prevStep = nextStep
+	return nil
+}
+
+// Step updates the weights of the Agent's Policies.
+func (d *GonumDeepQ) Step() error {
+	if d.IsEval() {
+		return nil
+	}
+
+	// Don't update if replay buffer is empty or has insufficient
+	// samples to sample
+	S, A, R, discount, NextS, _, err := d.replay.Sample()
+	if expreplay.IsEmptyBuffer(err) || expreplay.IsInsufficientSamples(err) {
+		return nil
+	}
+
+	// Compute the update target: r + γ * max[Q(s', a')]
+	nextStateActionValues, err := d.targetNet.Forward(
+		mat.NewDense(d.batchSize, d.features, NextS))
+	if err != nil {
+		return fmt.Errorf("step: could not predict next state-action "+
+			"values: %v", err)
+	}
+	updateTarget := make([]float64, d.batchSize)
+	for i := range updateTarget {
+		row := nextStateActionValues[0].RawRowView(i)
+		updateTarget[i] = R[i] + discount[i]*max(row)
+	}
+
+	// Predict the action values in state S
+	actionValues, err := d.trainNet.Forward(
+		mat.NewDense(d.batchSize, d.features, S))
+	if err != nil {
+		return fmt.Errorf("step: could not predict action values: %v", err)
+	}
+
+	// Compute the gradient of the Mean Squared TD error with respect to
+	// the action values. Actions are stored in A as one-hot vectors.
+	values := actionValues[0].RawMatrix().Data
+	grad := d.grad.RawMatrix().Data
+	for i := 0; i < d.batchSize; i++ {
+		row := i * d.numActions
+		selectedActionValue := 0.0
+		for j := 0; j < d.numActions; j++ {
+			selectedActionValue += A[row+j] * values[row+j]
+		}
+
+		tdError := updateTarget[i] - selectedActionValue
+		for j := 0; j < d.numActions; j++ {
+			grad[row+j] = -2 * tdError * A[row+j] / float64(d.batchSize)
+		}
+	}
+
+	// Run the learning step
+	err = d.trainNet.Backward([]*mat.Dense{d.grad})
+	if err != nil {
+		return fmt.Errorf("step: could not compute gradient: %v", err)
+	}
+	err = d.solver.Step(d.trainNet.Model())
+	if err != nil {
+		return fmt.Errorf("step: could not step solver: %v", err)
+	}
+	d.gradientSteps++
+
+	// Update the target network
+	if d.gradientSteps%d.targetUpdateInterval == 0 {
+		if d.tau == 1.0 {
+			err = gonumnet.Set(d.targetNet, d.trainNet)
+		} else {
+			err = gonumnet.Polyak(d.targetNet, d.trainNet, d.tau)
+		}
+		if err != nil {
+			return fmt.Errorf("step: could not update target network: %v",
+				err)
+		}
+	}
+
+	return nil
+}
+
+// SelectAction returns an action selected by the behaviour policy.
+func (d *GonumDeepQ) SelectAction(t ts.TimeStep) *mat.VecDense {
+	if !d.IsEval() {
+		d.anneal()
+
+		// Select uniformly random actions until the replay buffer can
+		// be sampled from
+		if d.warmup != nil && d.warmup.Active() {
+			return d.warmup.SelectAction(t)
+		}
+	}
+	return d.policy.SelectAction(t)
+}
+
+// TdError calculates the TD error generated by the learner on some
+// transition.
+func (d *GonumDeepQ) TdError(t ts.Transition) float64 {
+	stateValues := d.actionValues(t.State)
+	actionValue := stateValues[int(t.Action.AtVec(0))]
+
+	nextActionValue := max(d.actionValues(t.NextState))
+
+	return t.Reward + t.Discount*nextActionValue - actionValue
+}
+
+// actionValues returns the action values predicted in state
+func (d *GonumDeepQ) actionValues(state *mat.VecDense) []float64 {
+	input := mat.NewDense(1, state.Len(), state.RawVector().Data)
+	output, err := d.trainNet.Forward(input)
+	if err != nil {
+		panic(fmt.Sprintf("actionValues: could not predict action "+
+			"values: %v", err))
+	}
+
+	values := make([]float64, d.numActions)
+	copy(values, output[0].RawMatrix().Data)
+	return values
+}
+
+// anneal sets the ε of the behaviour policy as determined by the ε
+// schedule and advances the schedule by one step. If no schedule is
+// used, anneal does nothing.
+func (d *GonumDeepQ) anneal() {
+	egreedy, ok := d.policy.(agent.GonumEGreedyPolicy)
+	if d.epsilonSchedule == nil || !ok {
+		return
+	}
+	egreedy.SetEpsilon(d.epsilonSchedule.Value(d.steps))
+	d.steps++
+}
+
+// Epsilon returns the current ε of the behaviour policy. If the
+// behaviour policy is a Boltzmann policy, Epsilon returns 0.
+func (d *GonumDeepQ) Epsilon() float64 {
+	if egreedy, ok := d.policy.(agent.GonumEGreedyPolicy); ok {
+		return egreedy.Epsilon()
+	}
+	return 0
+}
+
+// Eval sets the agent into evaluation mode
+func (d *GonumDeepQ) Eval() {
+	d.policy.Eval()
+}
+
+// Train sets the agent into training mode
+func (d *GonumDeepQ) Train() {
+	d.policy.Train()
+}
+
+// IsEval returns whether the agent is in evaluation mode
+func (d *GonumDeepQ) IsEval() bool {
+	return d.policy.IsEval()
+}
+
+// EndEpisode performs cleanup at the end of an episode
+func (d *GonumDeepQ) EndEpisode() {}
+
+// Close cleans up any used resources. Since a GonumDeepQ uses no VMs,
+// Close does nothing.
+func (d *GonumDeepQ) Close() error {
+	return nil
+}
+
+// max returns the maximum value in values
+func max(values []float64) float64 {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// gonumDeepQCheckpoint is the serialized form of a GonumDeepQ agent
+type gonumDeepQCheckpoint struct {
+	TrainNet      [][]float64
+	TargetNet     [][]float64
+	Solver        *solver.Solver
+	Steps         int
+	GradientSteps int
+}
+
+// GobEncode implements the gob.GobEncoder interface. The weights of
+// the agent's networks, the progress of its ε schedule, the number of
+// gradient steps taken, and the solver with the progress of its step
+// size schedule are encoded. The experience replay buffer and the
+// internal state of the solver, such as moment estimates, are not
+// saved.
+func (d *GonumDeepQ) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err := enc.Encode(gonumDeepQCheckpoint{
+		TrainNet:      gonumnet.Weights(d.trainNet),
+		TargetNet:     gonumnet.Weights(d.targetNet),
+		Solver:        d.solver,
+		Steps:         d.steps,
+		GradientSteps: d.gradientSteps,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface. The GonumDeepQ
+// agent must have been created with the same configuration as the
+// agent which was encoded.
+func (d *GonumDeepQ) GobDecode(in []byte) error {
+	var checkpoint gonumDeepQCheckpoint
+	dec := gob.NewDecoder(bytes.NewReader(in))
+	if err := dec.Decode(&checkpoint); err != nil {
+		return fmt.Errorf("gobdecode: %v", err)
+	}
+
+	err := gonumnet.SetWeights(d.trainNet, checkpoint.TrainNet)
+	if err != nil {
+		return fmt.Errorf("gobdecode: train network: %v", err)
+	}
+	err = gonumnet.SetWeights(d.targetNet, checkpoint.TargetNet)
+	if err != nil {
+		return fmt.Errorf("gobdecode: target network: %v", err)
+	}
+
+	d.solver = checkpoint.Solver
+	d.gradientSteps = checkpoint.GradientSteps
+	d.steps = checkpoint.Steps
+	egreedy, ok := d.policy.(agent.GonumEGreedyPolicy)
+	if d.epsilonSchedule != nil && ok {
+		egreedy.SetEpsilon(d.epsilonSchedule.Value(d.steps))
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package deepq
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/agent/nonlinear/discrete/policy"
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/initwfn"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/schedule"
+	"github.com/samuelfneumann/golearn/solver"
+	G "gorgonia.org/gorgonia"
+)
+
+func init() {
+	// Register RecurrentConfigList type so that it can be typed using
+	// agent.TypedConfigList to help with serialization/deserialization.
+	agent.Register(agent.EGreedyDeepQRecurrentMLP, RecurrentConfigList{})
+}
+
+// RecurrentConfigList implements a list of RecurrentConfig's in a more
+// efficient manner than simply using a slice of RecurrentConfig's.
+type RecurrentConfigList struct {
+	CellType  []network.CellType // Type of recurrent layers
+	CellSizes [][]int            // Recurrent layer sizes
+
+	Layers      [][]int                 // Fully connected layer sizes
+	Biases      [][]bool                // Whether each layer should have a bias
+	Activations [][]*network.Activation // Activation of each layer
+	Solver      []*solver.Solver        // Solver for learning weights
+
+	// Initialization algorithm for weights
+	InitWFn []*initwfn.InitWFn
+
+	Epsilon []float64 // Behaviour policy epsilon
+
+	// Optional schedule for the behaviour policy epsilon, which
+	// replaces Epsilon
+	EpsilonSchedule []*schedule.Schedule
+
+	// Sequence experience replay parameters
+	ExpReplay []expreplay.SequenceConfig
+
+	// Target net updates
+	Tau                  []float64 // Polyak averaging constant
+	TargetUpdateInterval []int     // Number of steps target network updates
+}
+
+// NewRecurrentConfigList returns a new RecurrentConfigList as an
+// agent.TypedConfigList. Because the returned value is a TypedList, it
+// can safely be JSON serialized and deserialized without specifying
+// what the type of the RecurrentConfigList is.
+func NewRecurrentConfigList(
+	CellType []network.CellType,
+	CellSizes [][]int,
+	Layers [][]int,
+	Biases [][]bool,
+	Activations [][]*network.Activation,
+	Solver []*solver.Solver,
+	InitWFn []*initwfn.InitWFn,
+	Epsilon []float64,
+	ExpReplay []expreplay.SequenceConfig,
+	Tau []float64,
+	TargetUpdateInterval []int,
+) agent.TypedConfigList {
+	configs := RecurrentConfigList{
+		CellType:             CellType,
+		CellSizes:            CellSizes,
+		Layers:               Layers,
+		Biases:               Biases,
+		Activations:          Activations,
+		Solver:               Solver,
+		InitWFn:              InitWFn,
+		Epsilon:              Epsilon,
+		ExpReplay:            ExpReplay,
+		Tau:                  Tau,
+		TargetUpdateInterval: TargetUpdateInterval,
+	}
+
+	return agent.NewTypedConfigList(configs)
+}
+
+// Type returns the type of Config stored in the list
+func (c RecurrentConfigList) Type() agent.Type {
+	return c.Config().Type()
+}
+
+// NumFields returns the number of settable fields in a RecurrentConfig
+func (c RecurrentConfigList) NumFields() int {
+	rValue := reflect.ValueOf(c)
+	return rValue.NumField()
+}
+
+// Config returns an empty Config of the same type as that stored
+// by the RecurrentConfigList
+func (c RecurrentConfigList) Config() agent.Config {
+	return RecurrentConfig{}
+}
+
+// Len returns the number of RecurrentConfig's in the list
+func (c RecurrentConfigList) Len() int {
+	return len(c.CellType) * len(c.CellSizes) * len(c.Layers) *
+		len(c.Biases) * len(c.Activations) * len(c.Solver) *
+		len(c.InitWFn) * agent.NumSettings(len(c.Epsilon)) *
+		len(c.ExpReplay) * len(c.Tau) * len(c.TargetUpdateInterval) *
+		agent.NumSettings(len(c.EpsilonSchedule))
+}
+
+// RecurrentConfig implements a configuration for a RecurrentDeepQ
+// agent, which uses a recurrent neural network to predict action
+// values.
+type RecurrentConfig struct {
+	CellType  network.CellType // Type of recurrent layers
+	CellSizes []int            // Recurrent layer sizes
+
+	Layers      []int                 // Fully connected layer sizes
+	Biases      []bool                // Whether each layer should have a bias
+	Activations []*network.Activation // Activation of each layer
+	Solver      *solver.Solver        // Solver for learning weights
+
+	// Initialization algorithm for weights
+	InitWFn *initwfn.InitWFn
+
+	// Action selection policy and networks for learning
+	policy    agent.RecurrentNNPolicy
+	targetNet network.Recurrent
+	trainNet  network.Recurrent
+
+	Epsilon float64 // Behaviour policy epsilon
+
+	// Optional schedule for the behaviour policy epsilon. If non-nil,
+	// the behaviour policy's epsilon follows the schedule and Epsilon
+	// is ignored.
+	EpsilonSchedule *schedule.Schedule
+
+	// Sequence experience replay parameters
+	ExpReplay expreplay.SequenceConfig
+
+	// Target net updates
+	Tau                  float64 // Polyak averaging constant
+	TargetUpdateInterval int     // Number of steps target network updates
+}
+
+// BatchSize returns the number of sequences in each batch of the agent
+// constructed using this RecurrentConfig
+func (c RecurrentConfig) BatchSize() int {
+	return c.ExpReplay.SampleSize
+}
+
+// SequenceLength returns the length of sequences used to train the
+// agent constructed using this RecurrentConfig
+func (c RecurrentConfig) SequenceLength() int {
+	return c.ExpReplay.SequenceLength
+}
+
+// Type returns the type of the configuration
+func (c RecurrentConfig) Type() agent.Type {
+	return agent.EGreedyDeepQRecurrentMLP
+}
+
+// Validate checks a RecurrentConfig to ensure it is a valid
+// configuration of a RecurrentDeepQ agent.
+func (c RecurrentConfig) Validate() error {
+	if len(c.CellSizes) == 0 {
+		return fmt.Errorf("new: at least one recurrent layer is required")
+	}
+
+	if c.SequenceLength() < 1 {
+		return fmt.Errorf("new: sequence length must be positive "+
+			"\n\twant(>0) \n\thave(%v)", c.SequenceLength())
+	}
+
+	return c.config().Validate()
+}
+
+// ValidAgent returns whether the agent is valid for the configuration.
+// That is, whether Agent a can be constructed with RecurrentConfig c.
+func (c RecurrentConfig) ValidAgent(a agent.Agent) bool {
+	_, ok := a.(*RecurrentDeepQ)
+	return ok
+}
+
+// config returns the Config with the same fully connected layer and
+// target network settings as the RecurrentConfig
+func (c RecurrentConfig) config() Config {
+	return Config{
+		Layers:               c.Layers,
+		Biases:               c.Biases,
+		Activations:          c.Activations,
+		Solver:               c.Solver,
+		InitWFn:              c.InitWFn,
+		Epsilon:              c.Epsilon,
+		EpsilonSchedule:      c.EpsilonSchedule,
+		Tau:                  c.Tau,
+		TargetUpdateInterval: c.TargetUpdateInterval,
+	}
+}
+
+// CreateAgent creates a new RecurrentDeepQ agent based on the
+// configuration
+func (c RecurrentConfig) CreateAgent(e env.Environment,
+	s uint64) (agent.Agent, error) {
+	if err := c.Validate(); err != nil {
+		return &RecurrentDeepQ{}, err
+	}
+	seed := int64(s)
+
+	// Extract configuration variables
+	init := c.InitWFn.InitWFn()
+
+	// newPolicy creates a new policy with the given epsilon, batch
+	// size, and sequence length, using the configured network
+	// architecture
+	newPolicy := func(ε float64, batch,
+		seqLen int) (agent.RecurrentNNPolicy, error) {
+		return policy.NewRecurrentEGreedyMLP(
+			ε,
+			batch,
+			seqLen,
+			e,
+			G.NewGraph(),
+			c.CellType,
+			c.CellSizes,
+			c.Layers,
+			c.Biases,
+			init,
+			c.Activations,
+			seed,
+		)
+	}
+
+	// Behaviour policy, which selects a single action at a time
+	behaviourPolicy, err := newPolicy(c.config().epsilon(), 1, 1)
+	if err != nil {
+		return &RecurrentDeepQ{}, fmt.Errorf("createAgent: could not "+
+			"create behaviour policy: %v", err)
+	}
+
+	// Create the target network
+	targetNetPolicy, err := newPolicy(0.0, c.BatchSize(), c.SequenceLength())
+	if err != nil {
+		return &RecurrentDeepQ{}, fmt.Errorf("createAgent: could not "+
+			"create target network: %v", err)
+	}
+
+	// Create the training network
+	trainNetPolicy, err := newPolicy(0.0, c.BatchSize(), c.SequenceLength())
+	if err != nil {
+		return &RecurrentDeepQ{}, fmt.Errorf("createAgent: could not "+
+			"create training network: %v", err)
+	}
+
+	c.targetNet = targetNetPolicy.Network().(network.Recurrent)
+	c.trainNet = trainNetPolicy.Network().(network.Recurrent)
+
+	// Set the networks to have the same weights
+	network.Set(c.targetNet, behaviourPolicy.Network())
+	network.Set(c.trainNet, behaviourPolicy.Network())
+
+	c.policy = behaviourPolicy
+
+	return NewRecurrent(e, c, seed)
+}
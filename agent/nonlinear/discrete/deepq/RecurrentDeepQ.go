@@ -0,0 +1,521 @@
+package deepq
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"strings"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/schedule"
+	"github.com/samuelfneumann/golearn/solver"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// RecurrentDeepQ implements the deep Q-learning algorithm using a
+// recurrent neural network to predict action values. The behaviour
+// policy carries the hidden state of its recurrent network between
+// timesteps, and this hidden state is reset at the end of each
+// episode.
+//
+// Weights are learned from sequences of consecutive transitions
+// sampled from a sequence replay buffer. Sequences never cross
+// episode boundaries. The recurrent network is unrolled over each
+// sequence starting from a zero hidden state, and gradients are
+// backpropagated through the sequence only, which results in
+// truncated backpropagation through time. Sequences shorter than the
+// configured sequence length are padded, and padded transitions are
+// masked out of the loss.
+type RecurrentDeepQ struct {
+	// Action selection policy. We only need a single policy for both
+	// target and behaviour policy. RecurrentDeepQ's target policy is
+	// greedy with respect to action values, which we can get by
+	// setting the policy to evaluation mode.
+	policy agent.RecurrentNNPolicy
+
+	// Network whose weights are adapted, which takes in batches of
+	// sequences as inputs
+	trainNet   network.Recurrent
+	trainNetVM G.VM
+	solver     *solver.Solver // Adapts the weights of trainNet
+
+	// Network that provides the update target for a batch of sequences
+	targetNet   network.Recurrent
+	targetNetVM G.VM
+
+	// Variables to track target network updates
+	tau                  float64 // Polyak averaging constant
+	targetUpdateInterval int     // Steps between target updates
+	gradientSteps        int
+
+	selectedActions *G.Node // Actions taken at the previous states
+	numActions      int
+
+	replay expreplay.SequenceReplayer
+
+	// Nodes for computing the update target r + γ * max[Q(s', a')]
+	// for each transition in each sequence. The mask is used to weight
+	// the squared TD error of each transition, so that padded
+	// transitions do not contribute to the loss.
+	nextStateActionValues *G.Node
+	rewards               *G.Node
+	discounts             *G.Node
+	mask                  *G.Node
+
+	// Keep track of previous states and actions to add to replay buffer
+	prevStep ts.TimeStep
+
+	// Schedule for the ε of the behaviour policy, nil if ε is constant
+	epsilonSchedule *schedule.Schedule
+	steps           int // Steps taken in training mode
+
+	batchSize int
+	seqLen    int
+}
+
+// NewRecurrent creates and returns a new RecurrentDeepQ agent
+func NewRecurrent(env environment.Environment, c agent.Config,
+	seed int64) (agent.Agent, error) {
+	if !c.ValidAgent(&RecurrentDeepQ{}) {
+		return nil, fmt.Errorf("newRecurrent: invalid configuration "+
+			"type: %T", c)
+	}
+
+	// Ensure environment has discrete actions
+	if env.ActionSpec().Cardinality != environment.Discrete {
+		return &RecurrentDeepQ{}, fmt.Errorf("recurrentDeepQ: cannot use " +
+			"non-discrete actions")
+	}
+
+	// Ensure actions are one-dimensional
+	if env.ActionSpec().LowerBound.Len() > 1 {
+		return &RecurrentDeepQ{}, fmt.Errorf("recurrentDeepQ: actions " +
+			"must be 1-dimensional")
+	}
+
+	// Ensure actions are enumerated from 0
+	if env.ActionSpec().LowerBound.AtVec(0) != 0.0 {
+		return &RecurrentDeepQ{}, fmt.Errorf("recurrentDeepQ: actions " +
+			"must be enumerated starting from 0")
+	}
+
+	// Ensure the configuration is valid
+	err := c.Validate()
+	if err != nil {
+		return &RecurrentDeepQ{}, err
+	}
+
+	config := c.(RecurrentConfig)
+
+	// Extract configuration variables
+	batchSize := config.BatchSize()
+	seqLen := config.SequenceLength()
+	rows := batchSize * seqLen
+	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+
+	// Create the target network which provides the update target
+	targetNet := config.targetNet
+	if layers := targetNet.OutputLayers(); layers != 1 {
+		msg := "newRecurrent: target net should return a single target " +
+			"prediction \n\twant(1)\n\thave(%v)"
+		return &RecurrentDeepQ{}, fmt.Errorf(msg, layers)
+	}
+	targetNetVM := G.NewTapeMachine(targetNet.Graph())
+
+	// Create the training network, which is the network whose weights
+	// are learned
+	trainNet := config.trainNet
+	gTrain := trainNet.Graph()
+
+	// Create nodes to compute the update target: r + γ * max[Q(s', a')]
+	nextStateActionValues := G.NewMatrix(gTrain, tensor.Float64,
+		G.WithShape(rows, numActions), G.WithName("targetActionVals"))
+	rewards := G.NewVector(gTrain, tensor.Float64, G.WithShape(rows),
+		G.WithName("reward"))
+	discounts := G.NewVector(gTrain, tensor.Float64, G.WithShape(rows),
+		G.WithName("discount"))
+	mask := G.NewVector(gTrain, tensor.Float64, G.WithShape(rows),
+		G.WithName("mask"))
+
+	// Compute the update target
+	updateTarget := G.Must(G.Max(nextStateActionValues, 1))
+	updateTarget = G.Must(G.HadamardProd(updateTarget, discounts))
+	updateTarget = G.Must(G.Add(updateTarget, rewards))
+
+	// Action selected in the previous state. This is needed to compute
+	// the loss using the correct action value since the network outputs N
+	// action values, one for each environmental action
+	selectedActions := G.NewMatrix(
+		gTrain,
+		tensor.Float64,
+		G.WithName("actionSelected"),
+		G.WithShape(rows, numActions),
+	)
+
+	pred := trainNet.Prediction()[0]
+	selectedActionsValue := G.Must(G.HadamardProd(pred, selectedActions))
+	selectedActionsValue = G.Must(G.Sum(selectedActionsValue, 1))
+
+	// Compute the masked mean squared TD error. The mask is normalized
+	// before being set so that its elements sum to 1.
+	cost := G.Must(G.Sub(updateTarget, selectedActionsValue))
+	cost = G.Must(G.Square(cost))
+	cost = G.Must(G.HadamardProd(cost, mask))
+	cost = G.Must(G.Sum(cost))
+
+	// Compute the gradient with respect to the masked mean squared TD
+	// error
+	_, err = G.Grad(cost, trainNet.Learnables()...)
+	if err != nil {
+		msg := fmt.Sprintf("newRecurrent: could not compute gradient: %v",
+			err)
+		panic(msg)
+	}
+
+	// Compile the trainNet graph into a VM
+	trainNetVM := G.NewTapeMachine(
+		gTrain,
+		G.BindDualValues(trainNet.Learnables()...),
+	)
+	solver := config.Solver
+
+	// Create the sequence replay buffer. The replay buffer stores
+	// actions selected as one-hot vectors
+	numFeatures := env.ObservationSpec().Shape.Len()
+	replay, err := config.ExpReplay.Create(numFeatures, numActions, seed)
+	if err != nil {
+		msg := "newRecurrent: could not create sequence replay buffer: %v"
+		return &RecurrentDeepQ{}, fmt.Errorf(msg, err)
+	}
+
+	return &RecurrentDeepQ{
+		policy:                config.policy,
+		trainNet:              trainNet,
+		trainNetVM:            trainNetVM,
+		solver:                solver,
+		targetNet:             targetNet,
+		targetNetVM:           targetNetVM,
+		tau:                   config.Tau,
+		targetUpdateInterval:  config.TargetUpdateInterval,
+		gradientSteps:         0,
+		selectedActions:       selectedActions,
+		numActions:            numActions,
+		replay:                replay,
+		nextStateActionValues: nextStateActionValues,
+		rewards:               rewards,
+		discounts:             discounts,
+		mask:                  mask,
+		prevStep:              ts.TimeStep{},
+		epsilonSchedule:       config.EpsilonSchedule,
+		batchSize:             batchSize,
+		seqLen:                seqLen,
+	}, nil
+}
+
+// ObserveFirst observes and records the first episodic timestep
+func (d *RecurrentDeepQ) ObserveFirst(t ts.TimeStep) error {
+	if !t.First() {
+		return fmt.Errorf("observeFirst: timestep is not first "+
+			"(current timestep = %d)", t.Number)
+	}
+	d.prevStep = t
+	return nil
+}
+
+// Observe observes and records any timestep other than the first timestep
+func (d *RecurrentDeepQ) Observe(a mat.Vector, nextStep ts.TimeStep) error {
+	if a.Len() != 1 {
+		return fmt.Errorf("observe: cannot observe "+
+			"multi-dimensional action (action dim = %d) for "+
+			"RecurrentDeepQ", a.Len())
+	}
+
+	// Add to replay buffer
+	if !nextStep.First() {
+		action := mat.NewVecDense(d.numActions, nil)
+		action.SetVec(int(a.AtVec(0)), 1.0)
+		nextAction := mat.NewVecDense(d.numActions, nil)
+
+		transition := ts.NewTransition(d.prevStep, action, nextStep, nextAction)
+		err := d.replay.Add(transition, nextStep.Last())
+		if err != nil {
+			return fmt.Errorf("observe: could not add to replay buffer: %v",
+				err)
+		}
+	}
+
+	d.prevStep = nextStep
+	return nil
+}
+
+// Step updates the weights of the Agent's Policies.
+func (d *RecurrentDeepQ) Step() error {
+	if d.IsEval() {
+		return nil
+	}
+
+	// Don't update if replay buffer is empty or has insufficient
+	// samples to sample
+	S, A, R, discount, NextS, mask, err := d.replay.Sample()
+	if expreplay.IsEmptyBuffer(err) || expreplay.IsInsufficientSamples(err) {
+		return nil
+	}
+
+	// Predict the action values in the next states NextS
+	err = d.targetNet.SetInput(NextS)
+	if err != nil {
+		return fmt.Errorf("step: could not set target net input: %v", err)
+	}
+	err = d.targetNetVM.RunAll()
+	if err != nil {
+		return fmt.Errorf("step: could not run target vm: %v", err)
+	}
+
+	// Set the action values for the actions in the next states
+	err = G.Let(d.nextStateActionValues, d.targetNet.Output()[0])
+	if err != nil {
+		return fmt.Errorf("step: could not set next state-action values: %v",
+			err)
+	}
+
+	d.targetNetVM.Reset()
+
+	rows := d.batchSize * d.seqLen
+
+	// Set the reward for the current actions
+	rewardTensor := tensor.New(tensor.WithBacking(R),
+		tensor.WithShape(rows))
+	err = G.Let(d.rewards, rewardTensor)
+	if err != nil {
+		return fmt.Errorf("step: could not set reward: %v", err)
+	}
+
+	// Set the discount for the next action values
+	discountTensor := tensor.New(tensor.WithBacking(discount),
+		tensor.WithShape(rows))
+	err = G.Let(d.discounts, discountTensor)
+	if err != nil {
+		return fmt.Errorf("step: could not set discount: %v", err)
+	}
+
+	// Normalize the mask so that the loss is the mean squared TD error
+	// over all non-padded transitions
+	var total float64
+	for _, m := range mask {
+		total += m
+	}
+	for i := range mask {
+		mask[i] /= total
+	}
+	maskTensor := tensor.New(tensor.WithBacking(mask),
+		tensor.WithShape(rows))
+	err = G.Let(d.mask, maskTensor)
+	if err != nil {
+		return fmt.Errorf("step: could not set mask: %v", err)
+	}
+
+	// Previous action one-hot vectors
+	prevActions := tensor.New(
+		tensor.WithShape(rows, d.numActions),
+		tensor.WithBacking(A),
+	)
+	err = G.Let(d.selectedActions, prevActions)
+	if err != nil {
+		return fmt.Errorf("step: could not set previous actions: %v", err)
+	}
+
+	// Predict the action values in states S
+	err = d.trainNet.SetInput(S)
+	if err != nil {
+		return fmt.Errorf("step: could not set trainNet input: %v", err)
+	}
+
+	// Run the learning step
+	err = d.trainNetVM.RunAll()
+	if err != nil {
+		return fmt.Errorf("step: could not run train vm: %v", err)
+	}
+
+	err = d.solver.Step(d.trainNet.Model())
+	if err != nil {
+		return fmt.Errorf("step: could not step solver: %v", err)
+	}
+
+	d.trainNetVM.Reset()
+	d.gradientSteps++
+
+	// Update the target network by setting its weights to the newly learned
+	// weights
+	if d.gradientSteps%d.targetUpdateInterval == 0 {
+		if d.tau == 1.0 {
+			err = network.Set(d.targetNet, d.trainNet)
+			if err != nil {
+				return fmt.Errorf("step: could not update target network")
+			}
+		} else {
+			err = network.Polyak(d.targetNet, d.trainNet, d.tau)
+			if err != nil {
+				return fmt.Errorf("step: could not update target network")
+			}
+		}
+	}
+
+	err = network.Set(d.policy.Network(), d.trainNet)
+	if err != nil {
+		return fmt.Errorf("step: could not update policy network")
+	}
+
+	return nil
+}
+
+// SelectAction runs the necessary VMs and then returns an action
+// selected by the behaviour policy.
+func (d *RecurrentDeepQ) SelectAction(t ts.TimeStep) *mat.VecDense {
+	if !d.IsEval() {
+		d.anneal()
+	}
+
+	// Select action from target or behaviour policy depending on if
+	// in training or eval mode
+	return d.policy.SelectAction(t)
+}
+
+// anneal sets the ε of the behaviour policy as determined by the ε
+// schedule and advances the schedule by one step. If no schedule is
+// used, anneal does nothing.
+func (d *RecurrentDeepQ) anneal() {
+	if d.epsilonSchedule == nil {
+		return
+	}
+	d.policy.(agent.EGreedyNNPolicy).SetEpsilon(d.epsilonSchedule.Value(d.steps))
+	d.steps++
+}
+
+// Epsilon returns the current ε of the behaviour policy
+func (d *RecurrentDeepQ) Epsilon() float64 {
+	return d.policy.(agent.EGreedyNNPolicy).Epsilon()
+}
+
+// Eval sets the agent into evaluation mode
+func (d *RecurrentDeepQ) Eval() {
+	d.policy.Eval()
+}
+
+// Train sets the agent into training mode
+func (d *RecurrentDeepQ) Train() {
+	d.policy.Train()
+}
+
+// IsEval returns whether the agent is in evaluation mode
+func (d *RecurrentDeepQ) IsEval() bool {
+	return d.policy.IsEval()
+}
+
+// EndEpisode performs cleanup at the end of an episode. The hidden
+// state of the behaviour policy is reset so that the next episode
+// starts from a zero hidden state.
+func (d *RecurrentDeepQ) EndEpisode() {
+	err := d.policy.ResetState()
+	if err != nil {
+		msg := fmt.Sprintf("endEpisode: could not reset policy hidden "+
+			"state: %v", err)
+		panic(msg)
+	}
+}
+
+// Close cleans up any used resources
+func (d *RecurrentDeepQ) Close() error {
+	policyErr := d.policy.Close()
+	trainVMErr := d.trainNetVM.Close()
+	targetVMErr := d.targetNetVM.Close()
+
+	errs := make([]string, 0, 3)
+	if policyErr != nil {
+		errs = append(errs, "policy")
+	}
+	if trainVMErr != nil {
+		errs = append(errs, "train network")
+	}
+	if targetVMErr != nil {
+		errs = append(errs, "target network")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("close: could not close %v",
+			strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface. The weights of
+// the agent's networks, the progress of its ε schedule, the number of
+// gradient steps taken, and the solver with the progress of its step
+// size schedule are encoded. The sequence replay buffer and the
+// internal state of the solver, such as moment estimates, are not
+// saved.
+func (d *RecurrentDeepQ) GobEncode() ([]byte, error) {
+	trainNet, err := network.NewModel(d.trainNet)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode train "+
+			"network: %v", err)
+	}
+	targetNet, err := network.NewModel(d.targetNet)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode target "+
+			"network: %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err = enc.Encode(deepQCheckpoint{
+		TrainNet:      trainNet,
+		TargetNet:     targetNet,
+		Solver:        d.solver,
+		Steps:         d.steps,
+		GradientSteps: d.gradientSteps,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface. The
+// RecurrentDeepQ agent must have been created with the same
+// configuration as the agent which was encoded. The hidden state of
+// the behaviour policy is reset.
+func (d *RecurrentDeepQ) GobDecode(in []byte) error {
+	var checkpoint deepQCheckpoint
+	dec := gob.NewDecoder(bytes.NewReader(in))
+	if err := dec.Decode(&checkpoint); err != nil {
+		return fmt.Errorf("gobdecode: %v", err)
+	}
+
+	if err := checkpoint.TrainNet.SetWeights(d.trainNet); err != nil {
+		return fmt.Errorf("gobdecode: train network: %v", err)
+	}
+	if err := checkpoint.TargetNet.SetWeights(d.targetNet); err != nil {
+		return fmt.Errorf("gobdecode: target network: %v", err)
+	}
+	if err := network.Set(d.policy.Network(), d.trainNet); err != nil {
+		return fmt.Errorf("gobdecode: policy network: %v", err)
+	}
+	if err := d.policy.ResetState(); err != nil {
+		return fmt.Errorf("gobdecode: %v", err)
+	}
+
+	d.solver = checkpoint.Solver
+	d.gradientSteps = checkpoint.GradientSteps
+	d.steps = checkpoint.Steps
+	if d.epsilonSchedule != nil {
+		egreedy := d.policy.(agent.EGreedyNNPolicy)
+		egreedy.SetEpsilon(d.epsilonSchedule.Value(d.steps))
+	}
+	return nil
+}
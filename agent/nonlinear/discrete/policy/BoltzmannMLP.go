@@ -0,0 +1,354 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/experiment/checkpointer"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+)
+
+// MultiHeadBoltzmannMLP implements a Boltzmann (softmax) policy using
+// a feedforward neural network/MLP. Given an environment with N
+// actions, the neural network will produce N outputs, each predicting
+// the value of a distinct action. Actions are then selected with
+// probability proportional to exp(q(s, a) / τ), where τ is the
+// temperature of the policy. In evaluation mode, the policy is greedy
+// with respect to the predicted action values.
+//
+// Like MultiHeadEGreedyMLP, environments with MultiDiscrete actions
+// are supported with a branching architecture, where the action in
+// each dimension is selected independently using the network outputs
+// for that dimension.
+type MultiHeadBoltzmannMLP struct {
+	network.NeuralNet
+	temperature float64
+
+	rng  *rand.Rand
+	seed int64
+
+	// Number of actions in each action dimension, nil if actions are
+	// 1-dimensional
+	branches []int
+
+	vm G.VM // VM for action selection
+
+	eval bool
+}
+
+// NewMultiHeadBoltzmannMLP creates and returns a new
+// MultiHeadBoltzmannMLP with temperature τ. A final linear layer is
+// always added so that the number of network outputs equals the
+// number of environmental actions.
+//
+// See NewMultiHeadEGreedyMLP for more details on the remaining
+// arguments.
+func NewMultiHeadBoltzmannMLP(τ float64, batch int, env env.Environment,
+	g *G.ExprGraph, hiddenSizes []int, biases []bool,
+	init G.InitWFn, activations []*network.Activation,
+	seed int64) (agent.BoltzmannNNPolicy, error) {
+
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("newMultiHeadBoltzmannMLP: cannot use " +
+			"boltzmann policy with continuous actions")
+		return &MultiHeadBoltzmannMLP{}, err
+	}
+
+	// Calculate the number of actions and state features
+	branches, numActions := actionBranches(env)
+	features := env.ObservationSpec().Shape.Len()
+
+	net, err := network.NewMultiHeadMLP(features, batch, numActions, g,
+		hiddenSizes, biases, init, activations)
+	if err != nil {
+		return &MultiHeadBoltzmannMLP{},
+			fmt.Errorf("new: could not create policy: %v", err)
+	}
+
+	return newMultiHeadBoltzmann(τ, batch, net, branches, seed)
+}
+
+// NewMultiHeadBoltzmannSpec creates and returns a new
+// MultiHeadBoltzmannMLP with temperature τ which uses the network
+// described by spec as its function approximator. The network must
+// have a single head, which predicts the value of each environmental
+// action. Recurrent networks are not supported.
+//
+// See NewMultiHeadEGreedyMLP for more details.
+func NewMultiHeadBoltzmannSpec(τ float64, batch int,
+	env env.Environment, g *G.ExprGraph, spec *network.Spec,
+	seed int64) (agent.BoltzmannNNPolicy, error) {
+
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("newMultiHeadBoltzmannSpec: cannot use " +
+			"boltzmann policy with continuous actions")
+		return &MultiHeadBoltzmannMLP{}, err
+	}
+
+	if spec.Recurrent != nil {
+		err := fmt.Errorf("newMultiHeadBoltzmannSpec: cannot use " +
+			"recurrent networks")
+		return &MultiHeadBoltzmannMLP{}, err
+	}
+
+	// Calculate the number of actions
+	branches, numActions := actionBranches(env)
+
+	net, err := agent.NewNetwork(spec, env, batch, numActions, g,
+		uint64(seed))
+	if err != nil {
+		return &MultiHeadBoltzmannMLP{},
+			fmt.Errorf("new: could not create policy: %v", err)
+	}
+
+	return newMultiHeadBoltzmann(τ, batch, net, branches, seed)
+}
+
+// newMultiHeadBoltzmann returns a new MultiHeadBoltzmannMLP which uses
+// net to predict action values. The branches parameter is the number
+// of actions in each action dimension, or nil if actions are
+// 1-dimensional.
+func newMultiHeadBoltzmann(τ float64, batch int, net network.NeuralNet,
+	branches []int, seed int64) (agent.BoltzmannNNPolicy, error) {
+	if τ <= 0 {
+		return &MultiHeadBoltzmannMLP{}, fmt.Errorf("new: temperature " +
+			"must be positive")
+	}
+
+	if predictions := len(net.Prediction()); predictions != 1 {
+		msg := "new: boltzmann policy expects function approximator to " +
+			"output a single prediction node\n\twant(1)\n\thave(%v)"
+		return &MultiHeadBoltzmannMLP{}, fmt.Errorf(msg, predictions)
+	}
+
+	// Create RNG for sampling actions
+	source := rand.NewSource(seed)
+	rng := rand.New(source)
+
+	var vm G.VM
+	if batch == 1 {
+		vm = G.NewTapeMachine(net.Graph())
+	}
+
+	// Create the policy
+	nn := MultiHeadBoltzmannMLP{
+		temperature: τ,
+		rng:         rng,
+		seed:        seed,
+		branches:    branches,
+		NeuralNet:   net,
+		vm:          vm,
+		eval:        false,
+	}
+
+	return &nn, nil
+}
+
+// Train sets the policy to training mode
+func (b *MultiHeadBoltzmannMLP) Train() {
+	b.eval = false
+	network.SetEval(b.NeuralNet, false)
+}
+
+// Eval sets the policy to evaluation mode
+func (b *MultiHeadBoltzmannMLP) Eval() {
+	b.eval = true
+	network.SetEval(b.NeuralNet, true)
+}
+
+// IsEval returns whether or not the policy is in evaluation mode
+func (b *MultiHeadBoltzmannMLP) IsEval() bool {
+	return b.eval
+}
+
+// Network returns the neural network function approximator that the
+// policy uses.
+func (b *MultiHeadBoltzmannMLP) Network() network.NeuralNet {
+	return b.NeuralNet
+}
+
+// Clone clones a MultiHeadBoltzmannMLP
+func (b *MultiHeadBoltzmannMLP) Clone() (agent.NNPolicy, error) {
+	return b.CloneWithBatch(b.BatchSize())
+}
+
+// CloneWithBatch clones a MultiHeadBoltzmannMLP with a new input
+// batch size.
+func (b *MultiHeadBoltzmannMLP) CloneWithBatch(
+	batchSize int) (agent.NNPolicy, error) {
+	net, err := b.Network().CloneWithBatch(batchSize)
+	if err != nil {
+		msg := "clonewithbatch: could not clone policy: %v"
+		return &MultiHeadBoltzmannMLP{}, fmt.Errorf(msg, err)
+	}
+
+	policy, err := newMultiHeadBoltzmann(b.temperature, batchSize, net,
+		b.branches, b.seed)
+	if err != nil {
+		return &MultiHeadBoltzmannMLP{}, fmt.Errorf("clonewithbatch: %v",
+			err)
+	}
+	if b.eval {
+		policy.Eval()
+	}
+
+	return policy, nil
+}
+
+// SetTemperature sets the temperature of the policy
+func (b *MultiHeadBoltzmannMLP) SetTemperature(τ float64) {
+	b.temperature = τ
+}
+
+// Temperature gets the temperature of the policy
+func (b *MultiHeadBoltzmannMLP) Temperature() float64 {
+	return b.temperature
+}
+
+// SelectAction selects an action according to the Boltzmann policy
+func (b *MultiHeadBoltzmannMLP) SelectAction(
+	t timestep.TimeStep) *mat.VecDense {
+	if b.BatchSize() != 1 {
+		log.Fatal("selectAction: cannot select an action from batch policy, " +
+			"can only learn weights using a batch policy")
+	}
+
+	obs := t.Observation.RawVector().Data
+	b.SetInput(obs)
+	b.vm.RunAll()
+
+	// Get the action values from the last run of the computational graph
+	actionValues := b.Output()[0].Data().([]float64)
+	b.vm.Reset()
+
+	branches := b.Branches()
+	action := mat.NewVecDense(len(branches), nil)
+
+	start := 0
+	for i, n := range branches {
+		values := actionValues[start : start+n]
+		start += n
+
+		if b.IsEval() {
+			// If multiple actions have max value, return a random
+			// max-valued action
+			maxIndices := floatutils.ArgMax(values...)
+			action.SetVec(i, float64(maxIndices[b.rng.Int()%len(maxIndices)]))
+		} else {
+			probs := floatutils.Softmax(b.temperature, values...)
+			action.SetVec(i, float64(sample(b.rng.Float64(), probs)))
+		}
+	}
+
+	return action
+}
+
+// Branches returns the number of actions in each action dimension. The
+// network of the policy outputs the values of the actions in each
+// dimension in order.
+func (b *MultiHeadBoltzmannMLP) Branches() []int {
+	if len(b.branches) == 0 {
+		return []int{b.Outputs()[0]}
+	}
+	return b.branches
+}
+
+// Close cleans up resources after the policy is no longer needed
+func (b *MultiHeadBoltzmannMLP) Close() error {
+	if b.vm != nil {
+		return b.vm.Close()
+	}
+	return nil
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (b *MultiHeadBoltzmannMLP) GobDecode(in []byte) error {
+	buf := bytes.NewReader(in)
+	dec := gob.NewDecoder(buf)
+
+	err := dec.Decode(&b.NeuralNet)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode network: %v", err)
+	}
+
+	err = dec.Decode(&b.temperature)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode temperature: %v", err)
+	}
+
+	err = dec.Decode(&b.seed)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode seed: %v", err)
+	}
+	b.rng = rand.New(rand.NewSource(b.seed))
+
+	// Policies encoded before MultiDiscrete actions were supported do
+	// not encode their branches
+	b.branches = nil
+	err = dec.Decode(&b.branches)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("gobdecode: could not decode branches: %v", err)
+	}
+
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface
+func (b *MultiHeadBoltzmannMLP) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	serializableNet, ok := b.NeuralNet.(checkpointer.Serializable)
+	if !ok {
+		return nil, fmt.Errorf("gobencode: neural network not serializable")
+	}
+
+	err := enc.Encode(serializableNet)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode network: %v", err)
+	}
+
+	err = enc.Encode(b.temperature)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode temperature: %v",
+			err)
+	}
+
+	err = enc.Encode(b.seed)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode seed: %v", err)
+	}
+
+	err = enc.Encode(b.branches)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode branches: %v",
+			err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// sample returns the index of the category of probs in which the
+// uniform random number u in [0, 1) falls
+func sample(u float64, probs []float64) int {
+	cumulative := 0.0
+	for i, prob := range probs {
+		cumulative += prob
+		if u < cumulative {
+			return i
+		}
+	}
+	return len(probs) - 1
+}
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	"github.com/samuelfneumann/golearn/network"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// TestMultiHeadBoltzmannMLP tests that a MultiHeadBoltzmannMLP selects
+// actions with frequencies matching the softmax of the action values
+// at a fixed temperature, and greedily in evaluation mode
+func TestMultiHeadBoltzmannMLP(t *testing.T) {
+	const samples = 20000
+	values := []float64{1, 0, -1}
+
+	for _, τ := range []float64{0.5, 2} {
+		env, step, err := constant.New(len(values), 1,
+			environment.NewStepLimit(1), 0.9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewMultiHeadBoltzmannMLP(τ, 1, env, G.NewGraph(), []int{},
+			[]bool{}, G.Zeroes(), []*network.Activation{}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The single state observation is [1], so that the action
+		// values are the weights of the network
+		layers := p.Network().(*network.MultiHeadMLP).Layers()
+		weights := tensor.New(tensor.WithShape(1, len(values)),
+			tensor.WithBacking(append([]float64{}, values...)))
+		if err := G.Let(layers[0].Weights(), weights); err != nil {
+			t.Fatal(err)
+		}
+
+		sum := 0.0
+		want := make([]float64, len(values))
+		for i, v := range values {
+			want[i] = math.Exp(v / τ)
+			sum += want[i]
+		}
+
+		counts := make([]float64, len(values))
+		for i := 0; i < samples; i++ {
+			counts[int(p.SelectAction(step).AtVec(0))]++
+		}
+		for i := range counts {
+			want[i] /= sum
+			if freq := counts[i] / samples; math.Abs(freq-want[i]) > 0.015 {
+				t.Errorf("τ=%v: frequency of action %v: have(%v) want(%v)", τ,
+					i, freq, want[i])
+			}
+		}
+
+		p.Eval()
+		for i := 0; i < 100; i++ {
+			if a := p.SelectAction(step).AtVec(0); a != 0 {
+				t.Fatalf("τ=%v: evaluation mode: have(%v) want(0)", τ, a)
+			}
+		}
+		p.Close()
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 
@@ -27,6 +28,12 @@ import (
 // the neural network will produce N outputs, each predicting the
 // value of a distinct action.
 //
+// Environments with MultiDiscrete actions are supported with a
+// branching architecture: the network produces one output for each
+// action in each action dimension, and the action in each dimension is
+// chosen greedily with respect to that dimension's outputs. With
+// probability epsilon, a random action is chosen in all dimensions.
+//
 // MultiHeadEGreedyMLP simply populates a gorgonia.ExprGraph with
 // the neural network function approximator and selects actions
 // based on the output of this neural network, which predicts the
@@ -38,6 +45,10 @@ type MultiHeadEGreedyMLP struct {
 	rng  *rand.Rand
 	seed int64
 
+	// Number of actions in each action dimension, nil if actions are
+	// 1-dimensional
+	branches []int
+
 	vm G.VM // VM for action selection
 
 	eval bool
@@ -82,7 +93,7 @@ func NewMultiHeadEGreedyMLP(epsilon float64, batch int, env env.Environment,
 	}
 
 	// Calculate the number of actions and state features
-	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+	branches, numActions := actionBranches(env)
 	features := env.ObservationSpec().Shape.Len()
 
 	net, err := network.NewMultiHeadMLP(features, batch, numActions, g,
@@ -91,6 +102,116 @@ func NewMultiHeadEGreedyMLP(epsilon float64, batch int, env env.Environment,
 		return &MultiHeadEGreedyMLP{},
 			fmt.Errorf("new: could not create policy: %v", err)
 	}
+
+	return newMultiHeadEGreedy(epsilon, batch, net, branches, seed)
+}
+
+// NewMultiHeadEGreedyConvMLP creates and returns a new
+// MultiHeadEGreedyMLP which uses a network.ConvMLP as its function
+// approximator. The environment must have image observations, and
+// the convLayers parameter determines the convolutional and pooling
+// layers of the network, which are followed by fully connected layers
+// described by hiddenSizes, biases, and activations.
+//
+// Similar to NewMultiHeadEGreedyMLP, a final linear layer is always
+// added so that the number of network outputs equals the number of
+// environmental actions.
+//
+// See NewMultiHeadEGreedyMLP for more details.
+func NewMultiHeadEGreedyConvMLP(epsilon float64, batch int,
+	env env.Environment, g *G.ExprGraph,
+	convLayers []network.ConvLayerConfig, hiddenSizes []int, biases []bool,
+	init G.InitWFn, activations []*network.Activation,
+	seed int64) (agent.EGreedyNNPolicy, error) {
+
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("newMultiHeadEGreedyConvMLP: cannot use egreedy " +
+			"policy with continuous actions")
+		return &MultiHeadEGreedyMLP{}, err
+	}
+
+	imageEnv, ok := env.(environment.ImageEnvironment)
+	if !ok {
+		err := fmt.Errorf("newMultiHeadEGreedyConvMLP: environment must "+
+			"have image observations \n\thave(%T)", env)
+		return &MultiHeadEGreedyMLP{}, err
+	}
+
+	// Calculate the number of actions
+	branches, numActions := actionBranches(env)
+
+	net, err := network.NewConvMLP(imageEnv.Channels(), imageEnv.Rows(),
+		imageEnv.Cols(), batch, numActions, g, convLayers, hiddenSizes,
+		biases, init, activations)
+	if err != nil {
+		return &MultiHeadEGreedyMLP{},
+			fmt.Errorf("new: could not create policy: %v", err)
+	}
+
+	return newMultiHeadEGreedy(epsilon, batch, net, branches, seed)
+}
+
+// NewMultiHeadEGreedySpec creates and returns a new MultiHeadEGreedyMLP
+// which uses the network described by spec as its function
+// approximator. The network must have a single head, which predicts
+// the value of each environmental action. Recurrent networks are not
+// supported, see NewRecurrentEGreedyMLP instead.
+//
+// See NewMultiHeadEGreedyMLP for more details.
+func NewMultiHeadEGreedySpec(epsilon float64, batch int,
+	env env.Environment, g *G.ExprGraph, spec *network.Spec,
+	seed int64) (agent.EGreedyNNPolicy, error) {
+
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("newMultiHeadEGreedySpec: cannot use egreedy " +
+			"policy with continuous actions")
+		return &MultiHeadEGreedyMLP{}, err
+	}
+
+	if spec.Recurrent != nil {
+		err := fmt.Errorf("newMultiHeadEGreedySpec: cannot use recurrent " +
+			"networks")
+		return &MultiHeadEGreedyMLP{}, err
+	}
+
+	// Calculate the number of actions
+	branches, numActions := actionBranches(env)
+
+	net, err := agent.NewNetwork(spec, env, batch, numActions, g,
+		uint64(seed))
+	if err != nil {
+		return &MultiHeadEGreedyMLP{},
+			fmt.Errorf("new: could not create policy: %v", err)
+	}
+
+	return newMultiHeadEGreedy(epsilon, batch, net, branches, seed)
+}
+
+// actionBranches returns the number of actions in each dimension of
+// the discrete actions of env, or nil if actions are 1-dimensional,
+// as well as the total number of actions over all dimensions. The
+// total number of actions is the number of outputs of a network which
+// predicts the value of each action.
+func actionBranches(env env.Environment) ([]int, int) {
+	branches := env.ActionSpec().DiscreteSizes()
+
+	numActions := 0
+	for _, n := range branches {
+		numActions += n
+	}
+
+	if len(branches) == 1 {
+		return nil, numActions
+	}
+	return branches, numActions
+}
+
+// newMultiHeadEGreedy returns a new MultiHeadEGreedyMLP which uses net
+// to predict action values. The branches parameter is the number of
+// actions in each action dimension, or nil if actions are
+// 1-dimensional.
+func newMultiHeadEGreedy(epsilon float64, batch int, net network.NeuralNet,
+	branches []int, seed int64) (agent.EGreedyNNPolicy, error) {
 	if predictions := len(net.Prediction()); predictions != 1 {
 		msg := "new: egreedy policy expects function approximator to output " +
 			"a single prediction node\n\twant(1)\n\thave(%v)"
@@ -111,6 +232,7 @@ func NewMultiHeadEGreedyMLP(epsilon float64, batch int, env env.Environment,
 		epsilon:   epsilon,
 		rng:       rng,
 		seed:      seed,
+		branches:  branches,
 		NeuralNet: net,
 		vm:        vm,
 		eval:      false,
@@ -122,11 +244,13 @@ func NewMultiHeadEGreedyMLP(epsilon float64, batch int, env env.Environment,
 // Train sets the policy to training mode
 func (e *MultiHeadEGreedyMLP) Train() {
 	e.eval = false
+	network.SetEval(e.NeuralNet, false)
 }
 
 // Eval sets the policy to evaluation mode
 func (e *MultiHeadEGreedyMLP) Eval() {
 	e.eval = true
+	network.SetEval(e.NeuralNet, true)
 }
 
 // IsEval returns whether or not the policy is in evaluation mode
@@ -171,6 +295,7 @@ func (e *MultiHeadEGreedyMLP) CloneWithBatch(
 		epsilon:   e.epsilon,
 		rng:       rng,
 		seed:      e.seed,
+		branches:  e.branches,
 		NeuralNet: net,
 		vm:        vm,
 		eval:      e.eval,
@@ -204,23 +329,53 @@ func (e *MultiHeadEGreedyMLP) SelectAction(t timestep.TimeStep) *mat.VecDense {
 	actionValues := e.Output()[0].Data().([]float64)
 	e.vm.Reset()
 
-	var maxIndices []int
-	if e.IsEval() {
-		maxIndices = floatutils.ArgMax(actionValues...)
-	} else {
-		// With probability epsilon return a random action
-		if probability := rand.Float64(); probability < e.epsilon {
-			action := rand.Int() % e.numActions()
-			return mat.NewVecDense(1, []float64{float64(action)})
+	return e.selectFrom(actionValues)
+}
+
+// selectFrom selects an action according to the epsilon greedy policy
+// given the values of each action
+func (e *MultiHeadEGreedyMLP) selectFrom(
+	actionValues []float64) *mat.VecDense {
+	branches := e.Branches()
+	action := mat.NewVecDense(len(branches), nil)
+
+	// With probability epsilon return a random action
+	random := !e.IsEval() && rand.Float64() < e.epsilon
+
+	start := 0
+	for i, n := range branches {
+		values := actionValues[start : start+n]
+		start += n
+
+		if random {
+			action.SetVec(i, float64(rand.Int()%n))
+			continue
 		}
 
 		// Get the actions of maximum value
-		_, maxIndices = floatutils.MaxSlice(actionValues)
+		var maxIndices []int
+		if e.IsEval() {
+			maxIndices = floatutils.ArgMax(values...)
+		} else {
+			_, maxIndices = floatutils.MaxSlice(values)
+		}
+
+		// If multiple actions have max value, choose a random
+		// max-valued action
+		action.SetVec(i, float64(maxIndices[e.rng.Int()%len(maxIndices)]))
 	}
 
-	// If multiple actions have max value, return a random max-valued action
-	action := maxIndices[e.rng.Int()%len(maxIndices)]
-	return mat.NewVecDense(1, []float64{float64(action)})
+	return action
+}
+
+// Branches returns the number of actions in each action dimension. The
+// network of the policy outputs the values of the actions in each
+// dimension in order.
+func (e *MultiHeadEGreedyMLP) Branches() []int {
+	if len(e.branches) == 0 {
+		return []int{e.numActions()}
+	}
+	return e.branches
 }
 
 // Close cleans up resources after the policy is no longer needed
@@ -269,6 +424,14 @@ func (m *MultiHeadEGreedyMLP) GobDecode(in []byte) error {
 		return fmt.Errorf("gobdecode: could not decode seed: %v", err)
 	}
 
+	// Policies encoded before MultiDiscrete actions were supported do
+	// not encode their branches
+	m.branches = nil
+	err = dec.Decode(&m.branches)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("gobdecode: could not decode branches: %v", err)
+	}
+
 	return nil
 }
 
@@ -305,5 +468,77 @@ func (m *MultiHeadEGreedyMLP) GobEncode() ([]byte, error) {
 		return nil, fmt.Errorf("gobencode: could not encode seed: %v", err)
 	}
 
+	err = enc.Encode(m.branches)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode branches: %v",
+			err)
+	}
+
 	return buf.Bytes(), nil
 }
+
+// egreedyFile is the content of a file holding a saved
+// MultiHeadEGreedyMLP
+type egreedyFile struct {
+	Model   *network.Model
+	Epsilon float64
+	Seed    int64
+}
+
+// Save saves the MultiHeadEGreedyMLP, including the architecture and
+// weights of its network, to a file at path. The policy can be
+// reloaded onto a new computational graph using
+// LoadMultiHeadEGreedyMLP.
+func (e *MultiHeadEGreedyMLP) Save(path string) error {
+	model, err := network.NewModel(e.NeuralNet)
+	if err != nil {
+		return fmt.Errorf("save: %v", err)
+	}
+
+	err = writeFile(path, egreedyFile{
+		Model:   model,
+		Epsilon: e.epsilon,
+		Seed:    e.seed,
+	})
+	if err != nil {
+		return fmt.Errorf("save: %v", err)
+	}
+	return nil
+}
+
+// LoadMultiHeadEGreedyMLP loads a MultiHeadEGreedyMLP saved to the
+// file at path, building its network on graph g. The loaded policy
+// selects actions in environment env and takes inputs of batch size
+// batch. See NewMultiHeadEGreedyMLP for details on the batch size.
+func LoadMultiHeadEGreedyMLP(path string, batch int, env env.Environment,
+	g *G.ExprGraph) (agent.EGreedyNNPolicy, error) {
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("loadMultiHeadEGreedyMLP: cannot use egreedy " +
+			"policy with continuous actions")
+		return &MultiHeadEGreedyMLP{}, err
+	}
+
+	var file egreedyFile
+	if err := readFile(path, &file); err != nil {
+		return &MultiHeadEGreedyMLP{},
+			fmt.Errorf("loadMultiHeadEGreedyMLP: %v", err)
+	}
+
+	net, err := file.Model.BuildWithBatch(batch, g)
+	if err != nil {
+		return &MultiHeadEGreedyMLP{},
+			fmt.Errorf("loadMultiHeadEGreedyMLP: %v", err)
+	}
+
+	branches, numActions := actionBranches(env)
+	if outputs := net.Outputs(); len(outputs) != 1 ||
+		outputs[0] != numActions {
+		err := fmt.Errorf("loadMultiHeadEGreedyMLP: saved policy does not "+
+			"match environment actions \n\twant([%v]) \n\thave(%v)",
+			numActions, outputs)
+		return &MultiHeadEGreedyMLP{}, err
+	}
+
+	return newMultiHeadEGreedy(file.Epsilon, batch, net, branches,
+		file.Seed)
+}
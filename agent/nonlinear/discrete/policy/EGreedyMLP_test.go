@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	"github.com/samuelfneumann/golearn/environment/minatar"
+	"github.com/samuelfneumann/golearn/network"
+	"gonum.org/v1/gonum/floats"
+	G "gorgonia.org/gorgonia"
+)
+
+// TestMultiHeadEGreedyConvMLP tests that a MultiHeadEGreedyMLP using a
+// ConvMLP takes the images of an environment as input, predicts the
+// value of each action, and greedily selects actions when ε = 0
+func TestMultiHeadEGreedyConvMLP(t *testing.T) {
+	conv := []network.ConvLayerConfig{
+		{Type: network.Conv2D, Filters: 2, Kernel: []int{3, 3},
+			Activation: network.ReLU()},
+		{Type: network.MaxPool, Kernel: []int{2, 2}},
+	}
+
+	// Only environments with image observations can be used
+	env, _, err := constant.New(2, 1, environment.NewStepLimit(1), 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewMultiHeadEGreedyConvMLP(0, 1, env, G.NewGraph(), conv, []int{},
+		[]bool{}, G.GlorotU(1), []*network.Activation{}, 1)
+	if err == nil {
+		t.Error("expected an error for an environment without images")
+	}
+
+	env, step, err := minatar.NewBreakout(minatar.NewPlay(100), 0, 0.99, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewMultiHeadEGreedyConvMLP(0, 1, env, G.NewGraph(), conv,
+		[]int{8}, []bool{true}, G.GlorotU(1),
+		[]*network.Activation{network.ReLU()}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	image := env.(environment.ImageEnvironment)
+	net, ok := p.Network().(*network.ConvMLP)
+	if !ok {
+		t.Fatalf("network: have(%T) want(*network.ConvMLP)", p.Network())
+	}
+	if shape := net.InputShape(); shape[0] != image.Channels() ||
+		shape[1] != image.Rows() || shape[2] != image.Cols() {
+		t.Errorf("input shape: have(%v) want([%v %v %v])", shape,
+			image.Channels(), image.Rows(), image.Cols())
+	}
+
+	// Breakout has 3 actions
+	action := p.SelectAction(step).AtVec(0)
+	values := net.Output()[0].Data().([]float64)
+	if len(values) != 3 {
+		t.Fatalf("action values: have(%v) want(3 values)", values)
+	}
+	if greedy := floats.MaxIdx(values); action != float64(greedy) {
+		t.Errorf("action: have(%v) want(%v) for values %v", action, greedy,
+			values)
+	}
+}
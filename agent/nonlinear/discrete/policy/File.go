@@ -0,0 +1,36 @@
+package policy
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+)
+
+// writeFile gob encodes v to a new file at path, overwriting any
+// existing file
+func writeFile(path string, v interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create file: %v", err)
+	}
+
+	if err := gob.NewEncoder(file).Encode(v); err != nil {
+		file.Close()
+		return fmt.Errorf("could not write file: %v", err)
+	}
+	return file.Close()
+}
+
+// readFile gob decodes the file at path into v
+func readFile(path string, v interface{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open file: %v", err)
+	}
+	defer file.Close()
+
+	if err := gob.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("could not read file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"fmt"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
+	"github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+)
+
+// GonumBoltzmannMLP implements a Boltzmann (softmax) policy using a
+// gonumnet.MLP. Actions are selected with probability proportional to
+// exp(q(s, a) / τ), where τ is the temperature of the policy. In
+// evaluation mode, the policy is greedy with respect to the predicted
+// action values.
+//
+// GonumBoltzmannMLP is the gonum counterpart of MultiHeadBoltzmannMLP.
+type GonumBoltzmannMLP struct {
+	net         *gonumnet.MLP
+	temperature float64
+
+	rng  *rand.Rand
+	seed int64
+
+	eval bool
+}
+
+// NewGonumBoltzmannMLP creates and returns a new GonumBoltzmannMLP
+// with temperature τ. A final linear layer is always added so that the
+// number of network outputs equals the number of environmental
+// actions.
+//
+// See NewGonumEGreedyMLP for more details on the remaining arguments.
+func NewGonumBoltzmannMLP(τ float64, env env.Environment,
+	hiddenSizes []int, biases []bool, init G.InitWFn,
+	activations []*network.Activation,
+	seed int64) (agent.GonumBoltzmannPolicy, error) {
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("newGonumBoltzmannMLP: cannot use " +
+			"boltzmann policy with continuous actions")
+		return &GonumBoltzmannMLP{}, err
+	}
+	if env.ActionSpec().Cardinality == environment.MultiDiscrete {
+		err := fmt.Errorf("newGonumBoltzmannMLP: cannot use multi-discrete actions")
+		return &GonumBoltzmannMLP{}, err
+	}
+
+	// Calculate the number of actions and state features
+	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+	features := env.ObservationSpec().Shape.Len()
+
+	net, err := gonumnet.NewMLP(features, numActions, hiddenSizes, biases,
+		activations, init)
+	if err != nil {
+		return &GonumBoltzmannMLP{},
+			fmt.Errorf("newGonumBoltzmannMLP: could not create policy: %v",
+				err)
+	}
+
+	return &GonumBoltzmannMLP{
+		net:         net,
+		temperature: τ,
+		rng:         rand.New(rand.NewSource(seed)),
+		seed:        seed,
+		eval:        false,
+	}, nil
+}
+
+// Train sets the policy to training mode
+func (b *GonumBoltzmannMLP) Train() {
+	b.eval = false
+}
+
+// Eval sets the policy to evaluation mode
+func (b *GonumBoltzmannMLP) Eval() {
+	b.eval = true
+}
+
+// IsEval returns whether or not the policy is in evaluation mode
+func (b *GonumBoltzmannMLP) IsEval() bool {
+	return b.eval
+}
+
+// Network returns the neural network function approximator that the
+// policy uses.
+func (b *GonumBoltzmannMLP) Network() gonumnet.Net {
+	return b.net
+}
+
+// SetTemperature sets the temperature τ of the policy
+func (b *GonumBoltzmannMLP) SetTemperature(τ float64) {
+	b.temperature = τ
+}
+
+// Temperature returns the temperature τ of the policy
+func (b *GonumBoltzmannMLP) Temperature() float64 {
+	return b.temperature
+}
+
+// SelectAction selects an action according to the Boltzmann policy
+func (b *GonumBoltzmannMLP) SelectAction(
+	t timestep.TimeStep) *mat.VecDense {
+	actionValues := gonumActionValues(b.net, t)
+
+	var action int
+	if b.IsEval() {
+		// If multiple actions have max value, return a random
+		// max-valued action
+		maxIndices := floatutils.ArgMax(actionValues...)
+		action = maxIndices[b.rng.Int()%len(maxIndices)]
+	} else {
+		probs := floatutils.Softmax(b.temperature, actionValues...)
+		action = sample(b.rng.Float64(), probs)
+	}
+
+	return mat.NewVecDense(1, []float64{float64(action)})
+}
@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"fmt"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/network/gonumnet"
+	"github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+)
+
+// GonumEGreedyMLP implements an epsilon greedy policy using a
+// gonumnet.MLP. Given an environment with N actions, the neural
+// network will produce N outputs, each predicting the value of a
+// distinct action.
+//
+// GonumEGreedyMLP is the gonum counterpart of MultiHeadEGreedyMLP.
+// Since gonumnet networks accept inputs of any batch size, the
+// network of a GonumEGreedyMLP can be used both for action selection
+// and to learn the action values.
+type GonumEGreedyMLP struct {
+	net     *gonumnet.MLP
+	epsilon float64
+
+	rng  *rand.Rand
+	seed int64
+
+	eval bool
+}
+
+// NewGonumEGreedyMLP creates and returns a new GonumEGreedyMLP. The
+// arguments are the same as those of NewMultiHeadEGreedyMLP, except
+// that no batch size or computational graph is needed.
+//
+// Note that this constructor will always add an additional hidden
+// layer (with a bias unit and no activation) such that the number of
+// network outputs equals the number of actions in the environment.
+func NewGonumEGreedyMLP(epsilon float64, env env.Environment,
+	hiddenSizes []int, biases []bool, init G.InitWFn,
+	activations []*network.Activation,
+	seed int64) (agent.GonumEGreedyPolicy, error) {
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("newGonumEGreedyMLP: cannot use egreedy " +
+			"policy with continuous actions")
+		return &GonumEGreedyMLP{}, err
+	}
+	if env.ActionSpec().Cardinality == environment.MultiDiscrete {
+		err := fmt.Errorf("newGonumEGreedyMLP: cannot use multi-discrete actions")
+		return &GonumEGreedyMLP{}, err
+	}
+
+	// Calculate the number of actions and state features
+	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+	features := env.ObservationSpec().Shape.Len()
+
+	net, err := gonumnet.NewMLP(features, numActions, hiddenSizes, biases,
+		activations, init)
+	if err != nil {
+		return &GonumEGreedyMLP{},
+			fmt.Errorf("newGonumEGreedyMLP: could not create policy: %v",
+				err)
+	}
+
+	return &GonumEGreedyMLP{
+		net:     net,
+		epsilon: epsilon,
+		rng:     rand.New(rand.NewSource(seed)),
+		seed:    seed,
+		eval:    false,
+	}, nil
+}
+
+// Train sets the policy to training mode
+func (e *GonumEGreedyMLP) Train() {
+	e.eval = false
+}
+
+// Eval sets the policy to evaluation mode
+func (e *GonumEGreedyMLP) Eval() {
+	e.eval = true
+}
+
+// IsEval returns whether or not the policy is in evaluation mode
+func (e *GonumEGreedyMLP) IsEval() bool {
+	return e.eval
+}
+
+// Network returns the neural network function approximator that the
+// policy uses.
+func (e *GonumEGreedyMLP) Network() gonumnet.Net {
+	return e.net
+}
+
+// SetEpsilon sets the value for epsilon in the epsilon greedy policy.
+func (e *GonumEGreedyMLP) SetEpsilon(ε float64) {
+	e.epsilon = ε
+}
+
+// Epsilon gets the value of epsilon for the policy.
+func (e *GonumEGreedyMLP) Epsilon() float64 {
+	return e.epsilon
+}
+
+// SelectAction selects an action according to the epsilon greedy policy
+func (e *GonumEGreedyMLP) SelectAction(t timestep.TimeStep) *mat.VecDense {
+	actionValues := gonumActionValues(e.net, t)
+
+	if !e.IsEval() && e.rng.Float64() < e.epsilon {
+		action := e.rng.Int() % len(actionValues)
+		return mat.NewVecDense(1, []float64{float64(action)})
+	}
+
+	// If multiple actions have max value, return a random max-valued action
+	maxIndices := floatutils.ArgMax(actionValues...)
+	action := maxIndices[e.rng.Int()%len(maxIndices)]
+	return mat.NewVecDense(1, []float64{float64(action)})
+}
+
+// gonumActionValues returns the action values predicted by net for the
+// observation of timestep t
+func gonumActionValues(net *gonumnet.MLP, t timestep.TimeStep) []float64 {
+	obs := t.Observation.RawVector().Data
+	input := mat.NewDense(1, len(obs), obs)
+
+	output, err := net.Forward(input)
+	if err != nil {
+		panic(fmt.Sprintf("selectAction: could not predict action "+
+			"values: %v", err))
+	}
+	return output[0].RawMatrix().Data
+}
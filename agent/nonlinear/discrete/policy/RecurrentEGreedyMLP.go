@@ -0,0 +1,162 @@
+package policy
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/timestep"
+)
+
+// RecurrentEGreedyMLP implements an epsilon greedy policy using a
+// recurrent neural network, a network.RecurrentMLP. Given an
+// environment with N actions, the neural network will produce N
+// outputs, each predicting the value of a distinct action.
+//
+// The hidden state of the recurrent network is carried between calls
+// to SelectAction, so that the action values predicted at each
+// timestep depend on the history of the episode. The hidden state
+// should be reset at the end of each episode by calling ResetState.
+type RecurrentEGreedyMLP struct {
+	MultiHeadEGreedyMLP
+}
+
+// NewRecurrentEGreedyMLP creates and returns a new
+// RecurrentEGreedyMLP. The cellType and cellSizes parameters determine
+// the type and number of hidden units of each recurrent layer, which
+// are followed by fully connected layers described by hiddenSizes,
+// biases, and activations. The forward pass of the network is unrolled
+// over seqLen timesteps with batch inputs at each timestep.
+//
+// Similar to NewMultiHeadEGreedyMLP, a final linear layer is always
+// added so that the number of network outputs equals the number of
+// environmental actions.
+//
+// Actions can only be selected if both batch and seqLen are 1. Other
+// policies can only be used to learn weights with an external VM, in
+// which case inputs to the network should be time-major. See
+// network.RecurrentMLP for more details.
+func NewRecurrentEGreedyMLP(epsilon float64, batch, seqLen int,
+	env env.Environment, g *G.ExprGraph, cellType network.CellType,
+	cellSizes, hiddenSizes []int, biases []bool, init G.InitWFn,
+	activations []*network.Activation,
+	seed int64) (agent.RecurrentNNPolicy, error) {
+
+	if env.ActionSpec().Cardinality == environment.Continuous {
+		err := fmt.Errorf("newRecurrentEGreedyMLP: cannot use egreedy " +
+			"policy with continuous actions")
+		return &RecurrentEGreedyMLP{}, err
+	}
+	if env.ActionSpec().Cardinality == environment.MultiDiscrete {
+		err := fmt.Errorf("newRecurrentEGreedyMLP: cannot use multi-discrete actions")
+		return &RecurrentEGreedyMLP{}, err
+	}
+
+	// Calculate the number of actions and state features
+	numActions := int(env.ActionSpec().UpperBound.AtVec(0)) + 1
+	features := env.ObservationSpec().Shape.Len()
+
+	net, err := network.NewRecurrentMLP(features, batch, seqLen,
+		numActions, g, cellType, cellSizes, hiddenSizes, biases, init,
+		activations)
+	if err != nil {
+		return &RecurrentEGreedyMLP{},
+			fmt.Errorf("new: could not create policy: %v", err)
+	}
+
+	return newRecurrentEGreedy(epsilon, net, seed), nil
+}
+
+// newRecurrentEGreedy returns a new RecurrentEGreedyMLP which uses net
+// to predict action values
+func newRecurrentEGreedy(epsilon float64, net network.Recurrent,
+	seed int64) *RecurrentEGreedyMLP {
+	// Create RNG for sampling actions
+	source := rand.NewSource(seed)
+	rng := rand.New(source)
+
+	// Policies with batch sizes or sequence lengths > 1 should not be
+	// used for action selection
+	var vm G.VM
+	if net.BatchSize() == 1 && net.SequenceLength() == 1 {
+		vm = G.NewTapeMachine(net.Graph())
+	}
+
+	return &RecurrentEGreedyMLP{
+		MultiHeadEGreedyMLP: MultiHeadEGreedyMLP{
+			epsilon:   epsilon,
+			rng:       rng,
+			seed:      seed,
+			NeuralNet: net,
+			vm:        vm,
+			eval:      false,
+		},
+	}
+}
+
+// recurrent returns the recurrent network used by the policy
+func (r *RecurrentEGreedyMLP) recurrent() network.Recurrent {
+	return r.NeuralNet.(network.Recurrent)
+}
+
+// Clone clones a RecurrentEGreedyMLP
+func (r *RecurrentEGreedyMLP) Clone() (agent.NNPolicy, error) {
+	return r.CloneWithBatch(r.BatchSize())
+}
+
+// CloneWithBatch clones a RecurrentEGreedyMLP with a new input batch
+// size. The sequence length of the clone is the same as that of the
+// receiver.
+func (r *RecurrentEGreedyMLP) CloneWithBatch(
+	batchSize int) (agent.NNPolicy, error) {
+	net, err := r.Network().CloneWithBatch(batchSize)
+	if err != nil {
+		msg := "clonewithbatch: could not clone policy: %v"
+		return &RecurrentEGreedyMLP{}, fmt.Errorf(msg, err)
+	}
+
+	policy := newRecurrentEGreedy(r.epsilon, net.(network.Recurrent),
+		r.seed)
+	policy.eval = r.eval
+
+	return policy, nil
+}
+
+// ResetState resets the hidden state of the policy's recurrent
+// network. This should be called at the end of each episode.
+func (r *RecurrentEGreedyMLP) ResetState() error {
+	return r.recurrent().ResetState()
+}
+
+// SelectAction selects an action according to the epsilon greedy
+// policy, carrying the hidden state of the recurrent network over to
+// the next call to SelectAction
+func (r *RecurrentEGreedyMLP) SelectAction(
+	t timestep.TimeStep) *mat.VecDense {
+	if r.vm == nil {
+		log.Fatal("selectAction: cannot select an action from batch " +
+			"policy, can only learn weights using a batch policy")
+	}
+
+	obs := t.Observation.RawVector().Data
+	r.SetInput(obs)
+	r.vm.RunAll()
+
+	// Get the action values from the last run of the computational graph
+	// and carry the hidden state over to the next timestep
+	actionValues := r.Output()[0].Data().([]float64)
+	err := r.recurrent().CarryState()
+	if err != nil {
+		log.Fatalf("selectAction: could not carry hidden state: %v", err)
+	}
+	r.vm.Reset()
+
+	return r.selectFrom(actionValues)
+}
@@ -0,0 +1,75 @@
+package random
+
+import (
+	"reflect"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+)
+
+func init() {
+	// Register ConfigList type so that it can be typed using
+	// agent.TypedConfigList to help with serialization/deserialization.
+	agent.Register(agent.Random, ConfigList{})
+}
+
+// ConfigList implements functionality for storing a number of Config's
+// in a simple manner. Random agents have no hyperparameters, so a
+// ConfigList always stores a single Config.
+type ConfigList struct{}
+
+// NewConfigList returns a new ConfigList as an agent.TypedConfigList
+// so that it can easily be JSON serialized/deserialized without
+// knowing the underlying concrete type.
+func NewConfigList() agent.TypedConfigList {
+	return agent.NewTypedConfigList(ConfigList{})
+}
+
+// Config returns an empty Config that is of the type stored by
+// ConfigList
+func (c ConfigList) Config() agent.Config {
+	return Config{}
+}
+
+// Type returns the type of agent that can be constructed by Config's
+// stored by the list
+func (c ConfigList) Type() agent.Type {
+	return c.Config().Type()
+}
+
+// NumFields returns the number of settable fields for the ConfigList
+func (c ConfigList) NumFields() int {
+	rValue := reflect.ValueOf(c)
+	return rValue.NumField()
+}
+
+// Len returns the number of Configs stored by the list
+func (c ConfigList) Len() int {
+	return 1
+}
+
+// Config represents a configuration for the Random agent
+type Config struct{}
+
+// CreateAgent creates the agent from the Config
+func (c Config) CreateAgent(env environment.Environment,
+	seed uint64) (agent.Agent, error) {
+	return New(env, seed)
+}
+
+// ValidAgent returns whether the argument agent is a valid agent for
+// construction with the Config
+func (c Config) ValidAgent(a agent.Agent) bool {
+	_, ok := a.(*Random)
+	return ok
+}
+
+// Validate ensures that the Config is valid
+func (c Config) Validate() error {
+	return nil
+}
+
+// Type returns the type of the agent constructed by the Config
+func (c Config) Type() agent.Type {
+	return agent.Random
+}
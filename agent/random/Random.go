@@ -0,0 +1,76 @@
+// Package random implements an agent which selects actions uniformly
+// randomly
+package random
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/timestep"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Random implements an agent which selects actions uniformly randomly
+// from the action space of an environment, as given by
+// environment.Spec.Sample. Random agents do not learn and can be used
+// to establish baseline performance in an environment.
+type Random struct {
+	spec environment.Spec
+	rng  *rand.Rand
+	eval bool
+}
+
+// New returns a new Random agent which selects actions uniformly
+// randomly from the action space of env
+func New(env environment.Environment, seed uint64) (*Random, error) {
+	spec := env.ActionSpec()
+	if spec.Type != environment.Action {
+		return nil, fmt.Errorf("new: cannot sample actions from spec of "+
+			"type %v", spec.Type)
+	}
+
+	return &Random{
+		spec: spec,
+		rng:  rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// SelectAction selects a uniformly random action
+func (r *Random) SelectAction(timestep.TimeStep) *mat.VecDense {
+	return r.spec.Sample(r.rng)
+}
+
+// Step performs a single update to the agent. Random agents do not
+// learn, so Step does nothing.
+func (r *Random) Step() error {
+	return nil
+}
+
+// Observe records that an action lead to some timestep
+func (r *Random) Observe(mat.Vector, timestep.TimeStep) error {
+	return nil
+}
+
+// ObserveFirst records the first timestep in an episode
+func (r *Random) ObserveFirst(timestep.TimeStep) error {
+	return nil
+}
+
+// EndEpisode performs cleanup at the end of an episode
+func (r *Random) EndEpisode() {}
+
+// Eval sets the agent into evaluation mode
+func (r *Random) Eval() {
+	r.eval = true
+}
+
+// Train sets the agent into training mode
+func (r *Random) Train() {
+	r.eval = false
+}
+
+// IsEval returns whether the agent is in evaluation mode
+func (r *Random) IsEval() bool {
+	return r.eval
+}
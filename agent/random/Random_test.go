@@ -0,0 +1,69 @@
+package random
+
+import (
+	"testing"
+
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/lights"
+	ts "github.com/samuelfneumann/golearn/timestep"
+)
+
+// TestWarmup tests that a Warmup selects legal actions and is active
+// only until the replay buffer can be sampled from
+func TestWarmup(t *testing.T) {
+	const minCapacity = 10
+
+	env, step, err := lights.New(2, 3, environment.NewStepLimit(5), 0.99, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := expreplay.Config{
+		RemoveMethod:      expreplay.Fifo,
+		SampleMethod:      expreplay.Uniform,
+		RemoveSize:        1,
+		SampleSize:        1,
+		MaxReplayCapacity: 100,
+		MinReplayCapacity: minCapacity,
+	}
+	replay, err := config.Create(env.ObservationSpec().Shape.Len(),
+		env.ActionSpec().Shape.Len(), 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warmup, err := NewWarmup(env, replay, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2*minCapacity; i++ {
+		if active := warmup.Active(); active != (i < minCapacity) {
+			t.Fatalf("active after %v samples: have(%v) want(%v)", i,
+				active, i < minCapacity)
+		}
+
+		action := warmup.SelectAction(step)
+		if !env.ActionSpec().Contains(action) {
+			t.Fatalf("selectAction: illegal action %v",
+				action.RawVector().Data)
+		}
+
+		nextStep, last, err := env.Step(action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := replay.Add(ts.NewTransition(step, action, nextStep,
+			action)); err != nil {
+			t.Fatal(err)
+		}
+
+		step = nextStep
+		if last {
+			if step, err = env.Reset(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
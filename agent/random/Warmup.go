@@ -0,0 +1,34 @@
+package random
+
+import (
+	"github.com/samuelfneumann/golearn/buffer/expreplay"
+	"github.com/samuelfneumann/golearn/environment"
+)
+
+// Warmup implements a Random agent which is used by agents with an
+// experience replay buffer to select actions uniformly randomly until
+// the buffer holds enough samples to be sampled from. Agents should
+// select actions with the Warmup while Active returns true.
+type Warmup struct {
+	*Random
+	replay expreplay.ExperienceReplayer
+}
+
+// NewWarmup returns a new Warmup which selects actions uniformly
+// randomly from the action space of env while replay has fewer than
+// replay.MinCapacity() samples
+func NewWarmup(env environment.Environment,
+	replay expreplay.ExperienceReplayer, seed uint64) (*Warmup, error) {
+	r, err := New(env, seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Warmup{Random: r, replay: replay}, nil
+}
+
+// Active returns whether the experience replay buffer has too few
+// samples to be sampled from, in which case actions should be
+// selected by the Warmup
+func (w *Warmup) Active() bool {
+	return w.replay.Capacity() < w.replay.MinCapacity()
+}
@@ -0,0 +1,84 @@
+// Package scripted implements agents which select actions with a fixed,
+// user-provided policy
+package scripted
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Scripted implements an agent which selects actions with a fixed,
+// user-provided function of the current timestep. Scripted agents do
+// not learn and can be used to establish baseline performance in an
+// environment, for example by following a hand-crafted controller.
+type Scripted struct {
+	script func(timestep.TimeStep) *mat.VecDense
+	eval   bool
+}
+
+// New returns a new Scripted agent which selects the action returned
+// by script in each timestep
+func New(script func(timestep.TimeStep) *mat.VecDense) (*Scripted, error) {
+	if script == nil {
+		return nil, fmt.Errorf("new: script cannot be nil")
+	}
+	return &Scripted{script: script}, nil
+}
+
+// NewConstant returns a new Scripted agent which always selects the
+// argument action, which must be a legal action in env
+func NewConstant(env environment.Environment,
+	action mat.Vector) (*Scripted, error) {
+	if !env.ActionSpec().Contains(action) {
+		return nil, fmt.Errorf("newConstant: illegal action %v",
+			mat.Formatted(action.T()))
+	}
+
+	a := mat.VecDenseCopyOf(action)
+	return New(func(timestep.TimeStep) *mat.VecDense {
+		return mat.VecDenseCopyOf(a)
+	})
+}
+
+// SelectAction selects the action returned by the script for
+// timestep t
+func (s *Scripted) SelectAction(t timestep.TimeStep) *mat.VecDense {
+	return s.script(t)
+}
+
+// Step performs a single update to the agent. Scripted agents do not
+// learn, so Step does nothing.
+func (s *Scripted) Step() error {
+	return nil
+}
+
+// Observe records that an action lead to some timestep
+func (s *Scripted) Observe(mat.Vector, timestep.TimeStep) error {
+	return nil
+}
+
+// ObserveFirst records the first timestep in an episode
+func (s *Scripted) ObserveFirst(timestep.TimeStep) error {
+	return nil
+}
+
+// EndEpisode performs cleanup at the end of an episode
+func (s *Scripted) EndEpisode() {}
+
+// Eval sets the agent into evaluation mode
+func (s *Scripted) Eval() {
+	s.eval = true
+}
+
+// Train sets the agent into training mode
+func (s *Scripted) Train() {
+	s.eval = false
+}
+
+// IsEval returns whether the agent is in evaluation mode
+func (s *Scripted) IsEval() bool {
+	return s.eval
+}
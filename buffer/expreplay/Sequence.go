@@ -0,0 +1,269 @@
+package expreplay
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/samuelfneumann/golearn/timestep"
+)
+
+// SequenceConfig implements a specific configuration of a
+// SequenceReplayer
+type SequenceConfig struct {
+	SampleSize        int // Number of sequences in a batch
+	SequenceLength    int // Maximum number of transitions in a sequence
+	MaxReplayCapacity int
+	MinReplayCapacity int
+}
+
+// BatchSize returns the number of sequences in batches sampled from
+// the SequenceReplayer defined by the config
+func (c SequenceConfig) BatchSize() int {
+	return c.SampleSize
+}
+
+// Create creates and returns the SequenceReplayer with the specified
+// SequenceConfig.
+func (c SequenceConfig) Create(featureSize, actionSize int,
+	seed int64) (SequenceReplayer, error) {
+	return NewSequence(c.MinReplayCapacity, c.MaxReplayCapacity,
+		featureSize, actionSize, c.SampleSize, c.SequenceLength, seed)
+}
+
+// SequenceReplayer implements an experience replay buffer which
+// samples sequences of consecutive transitions rather than individual
+// transitions. Sequences never cross episode boundaries.
+type SequenceReplayer interface {
+	// Add adds a transition to the buffer. The last parameter denotes
+	// whether the transition is the last transition in an episode.
+	Add(t timestep.Transition, last bool) error
+
+	// Sample samples a batch of sequences from the buffer and returns
+	// the batch of (state, action, reward, discount, next state)
+	// tuples as well as a mask as []float64. All returned values are
+	// time-major: the data for sequence b at timestep t is stored at
+	// row t * BatchSize() + b. Sequences shorter than
+	// SequenceLength() are padded with zeroes, and the mask is 1 for
+	// each real transition and 0 for each padded transition.
+	Sample() ([]float64, []float64, []float64, []float64, []float64,
+		[]float64, error)
+
+	// Capacity returns the current number of transitions in the buffer
+	Capacity() int
+
+	// MaxCapacity returns the maximum allowable transitions in the
+	// buffer
+	MaxCapacity() int
+
+	// MinCapacity returns the number of transitions required to be in
+	// the buffer before the buffer can be sampled
+	MinCapacity() int
+
+	// BatchSize returns the number of sequences returned by Sample()
+	BatchSize() int
+
+	// SequenceLength returns the maximum length of sampled sequences
+	SequenceLength() int
+}
+
+// sequenceCache implements a concrete SequenceReplayer. Transitions
+// are stored in a circular buffer and are removed in a FiFo manner.
+// Sequences are sampled by choosing a starting transition uniformly
+// at random and then taking the following transitions until either
+// the sequence length is reached, the end of the episode is reached,
+// or no more transitions are in the buffer.
+type sequenceCache struct {
+	stateCache     []float64
+	actionCache    []float64
+	rewardCache    []float64
+	discountCache  []float64
+	nextStateCache []float64
+	lastCache      []bool // Whether a transition ends an episode
+
+	next int // Index at which the next transition is inserted
+	size int // Number of transitions in the buffer
+
+	rng *rand.Rand
+
+	batchSize   int
+	seqLen      int
+	minCapacity int
+	maxCapacity int
+	featureSize int
+	actionSize  int
+}
+
+// NewSequence creates and returns a new SequenceReplayer. The
+// featureSize and actionSize parameters define the size of the
+// feature and action vectors. The minCapacity parameter determines
+// the minimum number of transitions that should be in the buffer
+// before sampling is allowed. The maxCapacity parameter determines the
+// maximum number of transitions allowed in the buffer at any given
+// time. Each call to Sample returns batchSize sequences of at most
+// seqLen transitions.
+//
+// Pixel observations should be flattened before adding to the buffer.
+func NewSequence(minCapacity, maxCapacity, featureSize, actionSize,
+	batchSize, seqLen int, seed int64) (SequenceReplayer, error) {
+	if minCapacity <= 0 {
+		return &sequenceCache{}, fmt.Errorf("newSequence: minCapacity " +
+			"must be > 0")
+	}
+	if maxCapacity < minCapacity {
+		return &sequenceCache{}, fmt.Errorf("newSequence: cannot have "+
+			"minCapacity (%v) > maxCapacity (%v)", minCapacity, maxCapacity)
+	}
+	if batchSize < 1 {
+		return &sequenceCache{}, fmt.Errorf("newSequence: batch size " +
+			"must be >= 1")
+	}
+	if seqLen < 1 {
+		return &sequenceCache{}, fmt.Errorf("newSequence: sequence " +
+			"length must be >= 1")
+	}
+
+	return &sequenceCache{
+		stateCache:     make([]float64, maxCapacity*featureSize),
+		actionCache:    make([]float64, maxCapacity*actionSize),
+		rewardCache:    make([]float64, maxCapacity),
+		discountCache:  make([]float64, maxCapacity),
+		nextStateCache: make([]float64, maxCapacity*featureSize),
+		lastCache:      make([]bool, maxCapacity),
+
+		rng: rand.New(rand.NewSource(seed)),
+
+		batchSize:   batchSize,
+		seqLen:      seqLen,
+		minCapacity: minCapacity,
+		maxCapacity: maxCapacity,
+		featureSize: featureSize,
+		actionSize:  actionSize,
+	}, nil
+}
+
+// Add adds a transition to the cache, removing the oldest transition
+// if the cache is full
+func (s *sequenceCache) Add(t timestep.Transition, last bool) error {
+	if t.State.Len() != s.featureSize || t.NextState.Len() != s.featureSize {
+		return fmt.Errorf("add: invalid feature size \n\twant(%v)\n\thave(%v)",
+			s.featureSize, t.State.Len())
+	}
+	if t.Action.Len() != s.actionSize {
+		return fmt.Errorf("add: invalid action size \n\twant(%v)\n\thave(%v)",
+			s.actionSize, t.Action.Len())
+	}
+
+	index := s.next
+	stateInd := index * s.featureSize
+	copyInto(s.stateCache, stateInd, stateInd+s.featureSize,
+		t.State.RawVector().Data)
+	copyInto(s.nextStateCache, stateInd, stateInd+s.featureSize,
+		t.NextState.RawVector().Data)
+
+	actionInd := index * s.actionSize
+	copyInto(s.actionCache, actionInd, actionInd+s.actionSize,
+		t.Action.RawVector().Data)
+
+	s.rewardCache[index] = t.Reward
+	s.discountCache[index] = t.Discount
+	s.lastCache[index] = last
+
+	s.next = (s.next + 1) % s.maxCapacity
+	if s.size < s.maxCapacity {
+		s.size++
+	}
+
+	return nil
+}
+
+// Sample samples and returns a batch of sequences from the replay
+// buffer. The returned values are the state, action, reward, discount,
+// next state, and mask.
+func (s *sequenceCache) Sample() ([]float64, []float64, []float64,
+	[]float64, []float64, []float64, error) {
+	if s.Capacity() == 0 {
+		err := &ExpReplayError{
+			Op:  "sample",
+			Err: errEmptyCache,
+		}
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	if s.Capacity() < s.MinCapacity() {
+		err := &ExpReplayError{
+			Op:  "sample",
+			Err: errInsufficientSamples,
+		}
+		return nil, nil, nil, nil, nil, nil, err
+	}
+
+	rows := s.seqLen * s.batchSize
+	stateBatch := make([]float64, rows*s.featureSize)
+	actionBatch := make([]float64, rows*s.actionSize)
+	rewardBatch := make([]float64, rows)
+	discountBatch := make([]float64, rows)
+	nextStateBatch := make([]float64, rows*s.featureSize)
+	mask := make([]float64, rows)
+
+	// The oldest transition in the buffer is stored at index oldest
+	oldest := (s.next - s.size + s.maxCapacity) % s.maxCapacity
+
+	for b := 0; b < s.batchSize; b++ {
+		start := s.rng.Intn(s.size)
+
+		for t := 0; t < s.seqLen && start+t < s.size; t++ {
+			index := (oldest + start + t) % s.maxCapacity
+			row := t*s.batchSize + b
+
+			batchInd := row * s.featureSize
+			expInd := index * s.featureSize
+			copyInto(stateBatch, batchInd, batchInd+s.featureSize,
+				s.stateCache[expInd:expInd+s.featureSize])
+			copyInto(nextStateBatch, batchInd, batchInd+s.featureSize,
+				s.nextStateCache[expInd:expInd+s.featureSize])
+
+			batchInd = row * s.actionSize
+			expInd = index * s.actionSize
+			copyInto(actionBatch, batchInd, batchInd+s.actionSize,
+				s.actionCache[expInd:expInd+s.actionSize])
+
+			rewardBatch[row] = s.rewardCache[index]
+			discountBatch[row] = s.discountCache[index]
+			mask[row] = 1.0
+
+			// Sequences do not cross episode boundaries
+			if s.lastCache[index] {
+				break
+			}
+		}
+	}
+
+	return stateBatch, actionBatch, rewardBatch, discountBatch,
+		nextStateBatch, mask, nil
+}
+
+// Capacity returns the current number of transitions in the cache
+func (s *sequenceCache) Capacity() int {
+	return s.size
+}
+
+// MaxCapacity returns the maximum number of transitions that are
+// allowed in the cache
+func (s *sequenceCache) MaxCapacity() int {
+	return s.maxCapacity
+}
+
+// MinCapacity returns the minimum number of transitions required in
+// the cache before sampling is allowed
+func (s *sequenceCache) MinCapacity() int {
+	return s.minCapacity
+}
+
+// BatchSize returns the number of sequences sampled using Sample()
+func (s *sequenceCache) BatchSize() int {
+	return s.batchSize
+}
+
+// SequenceLength returns the maximum length of sampled sequences
+func (s *sequenceCache) SequenceLength() int {
+	return s.seqLen
+}
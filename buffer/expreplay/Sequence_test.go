@@ -0,0 +1,128 @@
+package expreplay
+
+import (
+	"testing"
+
+	"github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// addEpisodes adds episodes of the given lengths to buffer. The state
+// of each transition is its index over all episodes, its next state is
+// the following index, and its reward is the index of its episode.
+func addEpisodes(t *testing.T, buffer SequenceReplayer, lengths []int) {
+	t.Helper()
+
+	index := 0
+	for episode, length := range lengths {
+		for i := 0; i < length; i++ {
+			transition := timestep.Transition{
+				State:     mat.NewVecDense(1, []float64{float64(index)}),
+				Action:    mat.NewVecDense(1, []float64{0}),
+				Reward:    float64(episode),
+				Discount:  1,
+				NextState: mat.NewVecDense(1, []float64{float64(index + 1)}),
+			}
+			if err := buffer.Add(transition, i == length-1); err != nil {
+				t.Fatal(err)
+			}
+			index++
+		}
+	}
+}
+
+// TestSequenceEpisodeBoundaries tests that sampled sequences consist of
+// consecutive transitions of a single episode, padded with zeroes
+func TestSequenceEpisodeBoundaries(t *testing.T) {
+	const (
+		batch    = 4
+		seqLen   = 3
+		capacity = 8
+	)
+
+	buffer, err := NewSequence(1, capacity, 1, 1, batch, seqLen, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The buffer only holds the last 8 transitions, so the first
+	// episode is partly removed from the buffer
+	lengths := []int{4, 1, 3, 2, 2}
+	addEpisodes(t, buffer, lengths)
+	if c := buffer.Capacity(); c != capacity {
+		t.Fatalf("capacity: have(%v) want(%v)", c, capacity)
+	}
+
+	// Index of the first transition in the buffer and of the last
+	// transition of each episode
+	oldest := 12 - capacity
+	last := map[float64]bool{3: true, 4: true, 7: true, 9: true, 11: true}
+
+	for i := 0; i < 100; i++ {
+		state, _, reward, _, nextState, mask, err := buffer.Sample()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for b := 0; b < batch; b++ {
+			if mask[b] != 1 {
+				t.Fatalf("sequence %v is empty", b)
+			}
+			if state[b] < float64(oldest) {
+				t.Fatalf("sequence %v starts with removed transition %v", b,
+					state[b])
+			}
+
+			ended := false
+			for step := 1; step < seqLen; step++ {
+				row, prev := step*batch+b, (step-1)*batch+b
+				ended = ended || last[state[prev]] || mask[row] == 0
+
+				if ended {
+					if mask[row] != 0 || state[row] != 0 || reward[row] != 0 ||
+						nextState[row] != 0 {
+						t.Fatalf("sequence %v is not padded after transition "+
+							"%v: state(%v) mask(%v)", b, state[prev], state[row],
+							mask[row])
+					}
+					continue
+				}
+
+				if state[row] != state[prev]+1 || state[row] != nextState[prev] {
+					t.Fatalf("sequence %v is not consecutive: %v followed by %v",
+						b, state[prev], state[row])
+				}
+				if reward[row] != reward[prev] {
+					t.Fatalf("sequence %v crosses the end of episode %v", b,
+						reward[prev])
+				}
+			}
+		}
+	}
+}
+
+// TestSequenceInsufficientSamples tests that sequences cannot be
+// sampled before the buffer holds the minimum number of transitions
+func TestSequenceInsufficientSamples(t *testing.T) {
+	buffer, err := NewSequence(3, 10, 1, 1, 1, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, _, _, _, _, err = buffer.Sample()
+	if !IsEmptyBuffer(err) {
+		t.Errorf("empty buffer: have(%v) want(empty buffer error)", err)
+	}
+
+	addEpisodes(t, buffer, []int{2})
+	_, _, _, _, _, _, err = buffer.Sample()
+	if !IsInsufficientSamples(err) {
+		t.Errorf("buffer of 2 transitions: have(%v) want(insufficient "+
+			"samples error)", err)
+	}
+
+	addEpisodes(t, buffer, []int{1})
+	if _, _, _, _, _, _, err = buffer.Sample(); err != nil {
+		t.Errorf("buffer of 3 transitions: %v", err)
+	}
+}
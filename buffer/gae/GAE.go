@@ -13,6 +13,33 @@ import (
 
 // Interesting: This is a GAE(λ) buffer. What about n-Step GAE?
 
+// Advantage determines how a Buffer estimates advantages
+type Advantage string
+
+const (
+	// GAE estimates advantages using GAE(λ)
+	GAE Advantage = "GAE"
+
+	// RewardToGo estimates advantages as the Monte Carlo
+	// rewards-to-go from each state, without a state value baseline.
+	// To use the rewards-to-go with a state value baseline, use GAE
+	// with λ = 1.
+	RewardToGo Advantage = "RewardToGo"
+
+	// TD estimates advantages as the one-step TD error
+	// r + ℽv(s') - v(s), regardless of λ.
+	TD Advantage = "TD"
+)
+
+// IsValid returns whether the Advantage is a valid Advantage
+func (a Advantage) IsValid() bool {
+	switch a {
+	case GAE, RewardToGo, TD:
+		return true
+	}
+	return false
+}
+
 // Buffer implements a forward view generalized advantage estimate -
 // GAE(λ) - buffer following https://arxiv.org/abs/1506.02438. This
 // implementation is adapted from:
@@ -29,6 +56,9 @@ type Buffer struct {
 	lambda float64 // λ for GAE(λ) calculation
 	gamma  float64 // Discount factor ℽ; overwrites env discount factor
 
+	advantage Advantage // How advantages are estimated
+	normalize bool      // Whether advantages are standardized by Get()
+
 	// Buffers for storing data
 	obsBuffer []float64
 	actBuffer []float64
@@ -38,8 +68,29 @@ type Buffer struct {
 	valBuffer []float64
 }
 
-// New creates and returns a new GAE(λ) buffer
+// New creates and returns a new GAE(λ) buffer. Advantages are
+// standardized when sampled from the buffer.
 func New(obsDim, actDim, size int, lambda, gamma float64) *Buffer {
+	buffer, err := NewWithOptions(obsDim, actDim, size, lambda, gamma, GAE,
+		true)
+	if err != nil {
+		// This should never happen
+		panic(fmt.Sprintf("new: %v", err))
+	}
+	return buffer
+}
+
+// NewWithOptions creates and returns a new buffer which estimates
+// advantages as determined by advantage. If normalize is true, then
+// advantages are standardized to mean 0 and standard deviation 1 when
+// sampled from the buffer.
+func NewWithOptions(obsDim, actDim, size int, lambda, gamma float64,
+	advantage Advantage, normalize bool) (*Buffer, error) {
+	if !advantage.IsValid() {
+		return nil, fmt.Errorf("newWithOptions: invalid advantage %q",
+			advantage)
+	}
+
 	obsBuffer := make([]float64, size*obsDim)
 	actBuffer := make([]float64, size*actDim)
 	advBuffer := make([]float64, size)
@@ -55,13 +106,15 @@ func New(obsDim, actDim, size int, lambda, gamma float64) *Buffer {
 		pathStartIdx: 0,
 		lambda:       lambda,
 		gamma:        gamma,
+		advantage:    advantage,
+		normalize:    normalize,
 		obsBuffer:    obsBuffer,
 		actBuffer:    actBuffer,
 		advBuffer:    advBuffer,
 		rewBuffer:    rewBuffer,
 		retBuffer:    retBuffer,
 		valBuffer:    valBuffer,
-	}
+	}, nil
 }
 
 // Store stores a single timestep state, action, reward, and value to
@@ -96,7 +149,7 @@ func (v *Buffer) Store(obs, act []float64, rew, val float64) error {
 	return nil
 }
 
-// FinishPath computes advatange estimates using GAE(λ) and
+// FinishPath computes advatange estimates, using GAE(λ) by default, and
 // rewards-to-go estiamtes for each state for the current trajectory.
 // This should be called at the end of a trajectory or when one gets
 // cut off by an epoch ending.
@@ -115,7 +168,7 @@ func (v *Buffer) FinishPath(lastVal float64) {
 	rews := append(v.rewBuffer[start:stop], lastVal)
 	vals := append(v.valBuffer[start:stop], lastVal)
 
-	// GAE-lambda advantage calculation
+	// TD error calculation
 	stateVals := mat.NewVecDense(len(vals)-1, vals[:len(vals)-1])
 	nextStateVals := mat.NewVecDense(len(vals)-1, vals[1:])
 	rewards := mat.NewVecDense(len(rews)-1, rews[:len(rews)-1])
@@ -124,21 +177,32 @@ func (v *Buffer) FinishPath(lastVal float64) {
 	deltas.AddScaledVec(rewards, v.gamma, nextStateVals)
 	deltas.SubVec(deltas, stateVals)
 
-	copy(v.advBuffer[start:stop],
-		discountCumSum(deltas, v.gamma*v.lambda))
-
 	// Rewards-to-go
 	rewards = mat.NewVecDense(len(rews), rews)
 	rewsToGo := discountCumSum(rewards, v.gamma)
 
 	copy(v.retBuffer[start:stop], rewsToGo[:len(rewsToGo)-1])
 
+	// Advantage calculation
+	switch v.advantage {
+	case GAE:
+		copy(v.advBuffer[start:stop],
+			discountCumSum(deltas, v.gamma*v.lambda))
+
+	case RewardToGo:
+		copy(v.advBuffer[start:stop], rewsToGo[:len(rewsToGo)-1])
+
+	case TD:
+		copy(v.advBuffer[start:stop], deltas.RawVector().Data)
+	}
+
 	v.pathStartIdx = v.currentPos
 }
 
 // Get returns the observations, action, advantages, and returns stored
-// in the buffer. Advantages are first standardized to mean 0 and
-// standard deviation 1.
+// in the buffer. If the buffer normalizes advantages, then advantages
+// are first standardized to mean 0 and standard deviation 1, unless
+// the buffer holds a single advantage, which is never standardized.
 func (v *Buffer) Get() ([]float64, []float64, []float64, []float64, error) {
 	if v.currentPos != v.maxSize {
 		err := fmt.Errorf("get: buffer must be full before sampling")
@@ -148,6 +212,10 @@ func (v *Buffer) Get() ([]float64, []float64, []float64, []float64, error) {
 	v.currentPos = 0
 	v.pathStartIdx = 0
 
+	if !v.normalize || len(v.advBuffer) < 2 {
+		return v.obsBuffer, v.actBuffer, v.advBuffer, v.retBuffer, nil
+	}
+
 	// Advantage normalization
 	adv := mat.NewVecDense(len(v.advBuffer), v.advBuffer)
 	ones := matutils.VecOnes(adv.Len())
@@ -0,0 +1,155 @@
+package gae
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
+)
+
+const (
+	gamma  = 0.9
+	lambda = 0.5
+)
+
+// path is a trajectory stored in a Buffer
+type path struct {
+	rewards []float64
+	values  []float64
+	lastVal float64 // 0 if the trajectory ended in a terminal state
+}
+
+// paths are stored consecutively in a buffer, so that the advantages
+// and returns of each are computed separately
+var paths = []path{
+	{[]float64{1, -2}, []float64{0.5, 1}, 0},
+	{[]float64{1, 2, 3}, []float64{0.5, -1, 1.5}, 2},
+}
+
+// tdErrors returns the one-step TD errors r + ℽv(s') - v(s) of p
+func (p path) tdErrors() []float64 {
+	deltas := make([]float64, len(p.rewards))
+	for i := range deltas {
+		next := p.lastVal
+		if i+1 < len(p.values) {
+			next = p.values[i+1]
+		}
+		deltas[i] = p.rewards[i] + gamma*next - p.values[i]
+	}
+	return deltas
+}
+
+// gae returns the GAE(λ) advantages of p
+func (p path) gae() []float64 {
+	deltas := p.tdErrors()
+	adv := make([]float64, len(deltas))
+	for i := range adv {
+		for k := i; k < len(deltas); k++ {
+			adv[i] += math.Pow(gamma*lambda, float64(k-i)) * deltas[k]
+		}
+	}
+	return adv
+}
+
+// rewardsToGo returns the discounted rewards-to-go of p, bootstrapped
+// from lastVal
+func (p path) rewardsToGo() []float64 {
+	ret := make([]float64, len(p.rewards))
+	for i := range ret {
+		for k := i; k < len(p.rewards); k++ {
+			ret[i] += math.Pow(gamma, float64(k-i)) * p.rewards[k]
+		}
+		ret[i] += math.Pow(gamma, float64(len(p.rewards)-i)) * p.lastVal
+	}
+	return ret
+}
+
+// fill stores the paths in a new buffer and returns the advantages and
+// returns sampled from it
+func fill(t *testing.T, advantage Advantage, normalize bool,
+	paths []path) ([]float64, []float64) {
+	t.Helper()
+
+	size := 0
+	for _, p := range paths {
+		size += len(p.rewards)
+	}
+
+	buffer, err := NewWithOptions(1, 1, size, lambda, gamma, advantage,
+		normalize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths {
+		for i := range p.rewards {
+			err := buffer.Store([]float64{0}, []float64{0}, p.rewards[i],
+				p.values[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		buffer.FinishPath(p.lastVal)
+	}
+
+	_, _, adv, ret, err := buffer.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return adv, ret
+}
+
+// TestAdvantages tests the advantages and returns computed by each
+// method of advantage estimation against hand-computed values
+func TestAdvantages(t *testing.T) {
+	tests := []struct {
+		advantage Advantage
+		want      func(path) []float64
+	}{
+		{GAE, path.gae},
+		{RewardToGo, path.rewardsToGo},
+		{TD, path.tdErrors},
+	}
+
+	var wantRet []float64
+	for _, p := range paths {
+		wantRet = append(wantRet, p.rewardsToGo()...)
+	}
+
+	for _, test := range tests {
+		var wantAdv []float64
+		for _, p := range paths {
+			wantAdv = append(wantAdv, test.want(p)...)
+		}
+
+		adv, ret := fill(t, test.advantage, false, paths)
+		if !floats.EqualApprox(adv, wantAdv, 1e-12) {
+			t.Errorf("%v: advantages: have(%v) want(%v)", test.advantage, adv,
+				wantAdv)
+		}
+		if !floats.EqualApprox(ret, wantRet, 1e-12) {
+			t.Errorf("%v: returns: have(%v) want(%v)", test.advantage, ret,
+				wantRet)
+		}
+	}
+}
+
+// TestNormalize tests that advantages are standardized to mean 0 and
+// standard deviation 1, except when the buffer holds a single
+// advantage
+func TestNormalize(t *testing.T) {
+	for _, advantage := range []Advantage{GAE, RewardToGo, TD} {
+		adv, _ := fill(t, advantage, true, paths)
+		mean, std := stat.MeanStdDev(adv, nil)
+		if math.Abs(mean) > 1e-10 || math.Abs(std-1) > 1e-6 {
+			t.Errorf("%v: have(mean=%v, std=%v) want(mean=0, std=1)",
+				advantage, mean, std)
+		}
+	}
+
+	single := []path{{[]float64{1}, []float64{0.5}, 0}}
+	adv, _ := fill(t, TD, true, single)
+	if want := single[0].tdErrors(); !floats.EqualApprox(adv, want, 1e-12) {
+		t.Errorf("single advantage: have(%v) want(%v)", adv, want)
+	}
+}
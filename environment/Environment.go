@@ -4,6 +4,7 @@ package environment
 
 import (
 	"image"
+	"image/draw"
 
 	"github.com/fogleman/gg"
 	"github.com/samuelfneumann/golearn/timestep"
@@ -47,17 +48,76 @@ type Environment interface {
 
 // PixelEnvironment describes an environment that can represent its
 // current state as an image
+//
+// Pixels draws the current state of the environment onto the drawing
+// context dc, where each unit of the environment's natural drawing
+// size is scaled by scale pixels. If save is true, the drawing is
+// kept on dc. Otherwise, the state is drawn on a copy of dc so that
+// dc is left unmodified. In either case, the resulting image is
+// returned. See PixelContext.
 type PixelEnvironment interface {
 	Environment
 	Pixels(scale float64, dc gg.Context, save bool) image.Image
 }
 
+// ImageEnvironment describes an environment whose state observations
+// are images. Observations are flattened images of shape (Channels(),
+// Rows(), Cols()) stored in channel-major order. That is, the feature
+// for channel c at row i and column j of the image is located at index
+// (c * Rows() * Cols()) + (i * Cols()) + j of the observation vector.
+type ImageEnvironment interface {
+	Environment
+	Channels() int
+	Rows() int
+	Cols() int
+}
+
+// PixelContext returns the context that a PixelEnvironment should
+// draw on given the arguments to its Pixels method. If save is true,
+// the returned context draws directly on the image of dc. Otherwise,
+// the returned context draws on a copy of the image of dc.
+func PixelContext(dc gg.Context, save bool) *gg.Context {
+	if save {
+		return &dc
+	}
+
+	img := image.NewRGBA(dc.Image().Bounds())
+	draw.Draw(img, img.Bounds(), dc.Image(), img.Bounds().Min, draw.Src)
+
+	return gg.NewContextForRGBA(img)
+}
+
 // Closer is an environment which can be closed
 type Closer interface {
 	Environment
 	Close() error
 }
 
+// Wrapper is an environment which wraps another environment, for
+// example to alter its observations or rewards
+type Wrapper interface {
+	Environment
+	Unwrap() Environment // Returns the wrapped environment
+}
+
+// Unwrap returns the first environment in the chain of environments
+// wrapped by e, starting with e itself, that satisfies f. If no such
+// environment exists, Unwrap returns nil.
+func Unwrap(e Environment, f func(Environment) bool) Environment {
+	for e != nil {
+		if f(e) {
+			return e
+		}
+
+		wrapper, ok := e.(Wrapper)
+		if !ok {
+			return nil
+		}
+		e = wrapper.Unwrap()
+	}
+	return nil
+}
+
 // RowColer is an environment that can return the rows and columns of
 // its underlying state space. In effect, this is an interface
 // which all tabular environments will satisfy.
@@ -1,8 +1,13 @@
 package environment
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 
+	"github.com/samuelfneumann/golearn/utils/floatutils"
+	"golang.org/x/exp/rand"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -22,10 +27,14 @@ const (
 // Cardinality determines the cardinality of a number (discrete or continuous)
 type Cardinality string
 
-// Whether the values described by the Spec are continuous or discrete
+// Whether the values described by the Spec are continuous or discrete.
+// MultiDiscrete values are vectors of discrete values, each of which is
+// chosen independently, such as actions made up of multiple
+// simultaneous discrete controls.
 const (
-	Continuous Cardinality = "Continuous"
-	Discrete   Cardinality = "Discrete"
+	Continuous    Cardinality = "Continuous"
+	Discrete      Cardinality = "Discrete"
+	MultiDiscrete Cardinality = "MultiDiscrete"
 )
 
 // Spec implements an environment specification, which tells the type,
@@ -57,3 +66,276 @@ func NewSpec(shape mat.Vector, t SpecType, lowerBound,
 	}
 	return Spec{shape, t, lowerBound, upperBound, cardinality}
 }
+
+// NewMultiDiscreteSpec constructs a new MultiDiscrete environment
+// specification of type t. Each dimension i of the values described
+// by the specification takes on one of the n[i] values
+// 0, 1, ..., n[i]-1.
+func NewMultiDiscreteSpec(t SpecType, n ...int) Spec {
+	if len(n) == 0 {
+		panic("multi-discrete spec must have at least one dimension")
+	}
+
+	upperBound := mat.NewVecDense(len(n), nil)
+	for i := range n {
+		if n[i] < 1 {
+			panic(fmt.Sprintf("dimension %v must have at least one value "+
+				"(have %v)", i, n[i]))
+		}
+		upperBound.SetVec(i, float64(n[i]-1))
+	}
+
+	shape := mat.NewVecDense(len(n), nil)
+	lowerBound := mat.NewVecDense(len(n), nil)
+
+	return NewSpec(shape, t, lowerBound, upperBound, MultiDiscrete)
+}
+
+// DiscreteSizes returns the number of values which each dimension of a
+// Discrete or MultiDiscrete Spec can take on
+func (s Spec) DiscreteSizes() []int {
+	if s.Cardinality != Discrete && s.Cardinality != MultiDiscrete {
+		panic(fmt.Sprintf("cannot compute sizes of %v spec", s.Cardinality))
+	}
+
+	sizes := make([]int, s.UpperBound.Len())
+	for i := range sizes {
+		sizes[i] = int(s.UpperBound.AtVec(i)-s.LowerBound.AtVec(i)) + 1
+	}
+	return sizes
+}
+
+// NumDiscrete returns the number of distinct values described by a
+// Discrete or MultiDiscrete Spec, which is the product of its
+// DiscreteSizes
+func (s Spec) NumDiscrete() int {
+	num := 1
+	for _, size := range s.DiscreteSizes() {
+		num *= size
+	}
+	return num
+}
+
+// DiscreteIndex returns the index of the value v in the enumeration of
+// all NumDiscrete values described by a Discrete or MultiDiscrete
+// Spec. Values are enumerated with the last dimension varying fastest.
+// DiscreteIndex is the inverse of DiscreteValue.
+func (s Spec) DiscreteIndex(v mat.Vector) int {
+	sizes := s.DiscreteSizes()
+	if v.Len() != len(sizes) {
+		panic(fmt.Sprintf("value length %v must match spec length %v",
+			v.Len(), len(sizes)))
+	}
+
+	index := 0
+	for i, size := range sizes {
+		index = index*size + int(v.AtVec(i)-s.LowerBound.AtVec(i))
+	}
+	return index
+}
+
+// DiscreteValue returns the value at index in the enumeration of all
+// NumDiscrete values described by a Discrete or MultiDiscrete Spec.
+// DiscreteValue is the inverse of DiscreteIndex.
+func (s Spec) DiscreteValue(index int) *mat.VecDense {
+	sizes := s.DiscreteSizes()
+	value := mat.NewVecDense(len(sizes), nil)
+
+	for i := len(sizes) - 1; i >= 0; i-- {
+		value.SetVec(i, float64(index%sizes[i])+s.LowerBound.AtVec(i))
+		index /= sizes[i]
+	}
+	return value
+}
+
+// NewImageSpec constructs a new environment specification of type t
+// for flattened images with the given number of channels, rows, and
+// columns. Images are flattened in channel-major order, and each pixel
+// is bounded in [lowerBound, upperBound].
+func NewImageSpec(t SpecType, channels, rows, cols int, lowerBound,
+	upperBound float64, cardinality Cardinality) Spec {
+	length := channels * rows * cols
+	if length < 1 {
+		panic(fmt.Sprintf("image must have at least one pixel (have "+
+			"%v x %v x %v)", channels, rows, cols))
+	}
+
+	shape := mat.NewVecDense(length, nil)
+	lower := mat.NewVecDense(length, nil)
+	upper := mat.NewVecDense(length, nil)
+	for i := 0; i < length; i++ {
+		lower.SetVec(i, lowerBound)
+		upper.SetVec(i, upperBound)
+	}
+
+	return NewSpec(shape, t, lower, upper, cardinality)
+}
+
+// FlatIndex returns the index of the pixel at the given channel, row,
+// and column in an image with the given number of rows and columns
+// which has been flattened in channel-major order, as described by
+// NewImageSpec
+func FlatIndex(channel, row, col, rows, cols int) int {
+	return (channel*rows+row)*cols + col
+}
+
+// Sample returns a uniformly random value described by the Spec. The
+// values in each dimension of Discrete and MultiDiscrete Specs are
+// sampled uniformly from the integers between the bounds. The values
+// in each dimension of Continuous Specs are sampled uniformly between
+// the bounds if both bounds are finite. If only one bound is finite,
+// the value is sampled from an exponential distribution shifted to the
+// finite bound, and if neither bound is finite, the value is sampled
+// from a standard normal distribution.
+func (s Spec) Sample(rng *rand.Rand) *mat.VecDense {
+	value := mat.NewVecDense(s.Shape.Len(), nil)
+
+	for i := 0; i < value.Len(); i++ {
+		lower, upper := s.LowerBound.AtVec(i), s.UpperBound.AtVec(i)
+
+		switch {
+		case s.Cardinality != Continuous:
+			value.SetVec(i, lower+float64(rng.Intn(int(upper-lower)+1)))
+
+		case !math.IsInf(lower, 0) && !math.IsInf(upper, 0):
+			value.SetVec(i, lower+rng.Float64()*(upper-lower))
+
+		case !math.IsInf(lower, 0):
+			value.SetVec(i, lower+rng.ExpFloat64())
+
+		case !math.IsInf(upper, 0):
+			value.SetVec(i, upper-rng.ExpFloat64())
+
+		default:
+			value.SetVec(i, rng.NormFloat64())
+		}
+	}
+	return value
+}
+
+// Contains returns whether v is a value described by the Spec. The
+// value v must have the same length as the Spec and must be within
+// the Spec's bounds. The values in each dimension of Discrete and
+// MultiDiscrete Specs must also be integers.
+func (s Spec) Contains(v mat.Vector) bool {
+	if v.Len() != s.Shape.Len() {
+		return false
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		value := v.AtVec(i)
+		if math.IsNaN(value) || value < s.LowerBound.AtVec(i) ||
+			value > s.UpperBound.AtVec(i) {
+			return false
+		}
+		if s.Cardinality != Continuous && value != math.Trunc(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clip returns a copy of v with each dimension clipped to the bounds
+// of the Spec
+func (s Spec) Clip(v mat.Vector) *mat.VecDense {
+	if v.Len() != s.Shape.Len() {
+		panic(fmt.Sprintf("value length %v must match spec length %v",
+			v.Len(), s.Shape.Len()))
+	}
+
+	clipped := mat.NewVecDense(v.Len(), nil)
+	for i := 0; i < v.Len(); i++ {
+		clipped.SetVec(i, floatutils.Clip(v.AtVec(i), s.LowerBound.AtVec(i),
+			s.UpperBound.AtVec(i)))
+	}
+	return clipped
+}
+
+// jsonSpec is the JSON representation of a Spec. Bounds are stored as
+// jsonFloats so that infinite bounds can be serialized.
+type jsonSpec struct {
+	Type        SpecType
+	Length      int
+	LowerBound  []jsonFloat
+	UpperBound  []jsonFloat
+	Cardinality Cardinality
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (s Spec) MarshalJSON() ([]byte, error) {
+	j := jsonSpec{
+		Type:        s.Type,
+		Length:      s.Shape.Len(),
+		LowerBound:  make([]jsonFloat, s.LowerBound.Len()),
+		UpperBound:  make([]jsonFloat, s.UpperBound.Len()),
+		Cardinality: s.Cardinality,
+	}
+	for i := range j.LowerBound {
+		j.LowerBound[i] = jsonFloat(s.LowerBound.AtVec(i))
+		j.UpperBound[i] = jsonFloat(s.UpperBound.AtVec(i))
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (s *Spec) UnmarshalJSON(data []byte) error {
+	var j jsonSpec
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	if len(j.LowerBound) != j.Length || len(j.UpperBound) != j.Length {
+		return fmt.Errorf("unmarshalJSON: bounds must have length %v",
+			j.Length)
+	}
+	if j.Length < 1 {
+		return fmt.Errorf("unmarshalJSON: spec must have at least one " +
+			"dimension")
+	}
+
+	lower := mat.NewVecDense(j.Length, nil)
+	upper := mat.NewVecDense(j.Length, nil)
+	for i := 0; i < j.Length; i++ {
+		lower.SetVec(i, float64(j.LowerBound[i]))
+		upper.SetVec(i, float64(j.UpperBound[i]))
+	}
+
+	*s = NewSpec(mat.NewVecDense(j.Length, nil), j.Type, lower, upper,
+		j.Cardinality)
+	return nil
+}
+
+// jsonFloat is a float64 which can be serialized to JSON even if it is
+// infinite or NaN, in which case it is serialized as one of the
+// strings "+Inf", "-Inf", or "NaN"
+type jsonFloat float64
+
+// MarshalJSON implements the json.Marshaler interface
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	value := float64(f)
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return json.Marshal(strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	return json.Marshal(value)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return err
+		}
+		*f = jsonFloat(value)
+		return nil
+	}
+
+	var value float64
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*f = jsonFloat(value)
+	return nil
+}
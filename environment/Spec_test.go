@@ -0,0 +1,137 @@
+package environment
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// TestDiscreteIndex tests that DiscreteIndex and DiscreteValue
+// enumerate all values of a MultiDiscrete Spec
+func TestDiscreteIndex(t *testing.T) {
+	spec := NewMultiDiscreteSpec(Action, 2, 3, 4)
+	if num := spec.NumDiscrete(); num != 24 {
+		t.Fatalf("numDiscrete: have(%v) want(24)", num)
+	}
+
+	seen := make(map[[3]float64]bool)
+	for i := 0; i < spec.NumDiscrete(); i++ {
+		value := spec.DiscreteValue(i)
+		if index := spec.DiscreteIndex(value); index != i {
+			t.Errorf("discreteIndex(%v): have(%v) want(%v)",
+				value.RawVector().Data, index, i)
+		}
+
+		var key [3]float64
+		copy(key[:], value.RawVector().Data)
+		for j := range key {
+			if key[j] < 0 || key[j] > spec.UpperBound.AtVec(j) {
+				t.Errorf("discreteValue(%v): value %v out of bounds", i, key)
+			}
+		}
+		seen[key] = true
+	}
+	if len(seen) != spec.NumDiscrete() {
+		t.Errorf("discreteValue: have(%v) distinct values want(%v)",
+			len(seen), spec.NumDiscrete())
+	}
+
+	// Values are enumerated with the last dimension varying fastest
+	value := mat.NewVecDense(3, []float64{1, 0, 2})
+	if index := spec.DiscreteIndex(value); index != 14 {
+		t.Errorf("discreteIndex([1 0 2]): have(%v) want(14)", index)
+	}
+}
+
+// TestSample tests that sampled values are contained in the Spec and
+// that clipped values are contained in continuous Specs
+func TestSample(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	inf := math.Inf(1)
+
+	continuous := NewSpec(
+		mat.NewVecDense(4, nil),
+		Action,
+		mat.NewVecDense(4, []float64{-1, 0, -inf, -inf}),
+		mat.NewVecDense(4, []float64{1, inf, 0, inf}),
+		Continuous,
+	)
+	multiDiscrete := NewMultiDiscreteSpec(Action, 2, 3, 4)
+
+	for _, spec := range []Spec{continuous, multiDiscrete} {
+		for i := 0; i < 1000; i++ {
+			if value := spec.Sample(rng); !spec.Contains(value) {
+				t.Fatalf("sample: %v spec does not contain sample %v",
+					spec.Cardinality, value.RawVector().Data)
+			}
+		}
+	}
+
+	// Every discrete value should be sampled
+	seen := make(map[int]bool)
+	for i := 0; i < 1000; i++ {
+		seen[multiDiscrete.DiscreteIndex(multiDiscrete.Sample(rng))] = true
+	}
+	if len(seen) != multiDiscrete.NumDiscrete() {
+		t.Errorf("sample: have(%v) distinct values want(%v)", len(seen),
+			multiDiscrete.NumDiscrete())
+	}
+
+	illegal := [][]float64{{0, 1, 2.5}, {0, 3, 0}, {-1, 0, 0}, {0, 0}}
+	for _, value := range illegal {
+		if multiDiscrete.Contains(mat.NewVecDense(len(value), value)) {
+			t.Errorf("contains(%v): have(true) want(false)", value)
+		}
+	}
+
+	clipped := continuous.Clip(mat.NewVecDense(4, []float64{-2, -1, 1, 5}))
+	want := []float64{-1, 0, 0, 5}
+	if !floats.Equal(clipped.RawVector().Data, want) {
+		t.Errorf("clip: have(%v) want(%v)", clipped.RawVector().Data, want)
+	}
+	if !continuous.Contains(clipped) {
+		t.Errorf("clip: spec does not contain clipped value %v",
+			clipped.RawVector().Data)
+	}
+}
+
+// TestSpecJSON tests that Specs with infinite bounds can be serialized
+// to and deserialized from JSON
+func TestSpecJSON(t *testing.T) {
+	inf := math.Inf(1)
+	spec := NewSpec(
+		mat.NewVecDense(3, nil),
+		Observation,
+		mat.NewVecDense(3, []float64{-inf, 0, -0.5}),
+		mat.NewVecDense(3, []float64{inf, 1, 0.5}),
+		Continuous,
+	)
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Spec
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Type != spec.Type || decoded.Cardinality != spec.Cardinality {
+		t.Errorf("unmarshal: have(%v, %v) want(%v, %v)", decoded.Type,
+			decoded.Cardinality, spec.Type, spec.Cardinality)
+	}
+	if decoded.Shape.Len() != spec.Shape.Len() ||
+		!mat.Equal(decoded.LowerBound, spec.LowerBound) ||
+		!mat.Equal(decoded.UpperBound, spec.UpperBound) {
+		t.Errorf("unmarshal: have(%v, %v) want(%v, %v)",
+			mat.Formatted(decoded.LowerBound.T()),
+			mat.Formatted(decoded.UpperBound.T()),
+			mat.Formatted(spec.LowerBound.T()),
+			mat.Formatted(spec.UpperBound.T()))
+	}
+}
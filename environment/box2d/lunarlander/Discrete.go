@@ -154,6 +154,11 @@ func (c *Discrete) ActionSpec() environment.Spec {
 // will cause an error to be returned.
 func (c *Discrete) Step(action *mat.VecDense) (timestep.TimeStep, bool,
 	error) {
+	if !c.ActionSpec().Contains(action) {
+		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action "+
+			"selection, expected action ϵ [0, 1, 2, 3], received action "+
+			"= %v", action.RawVector().Data)
+	}
 	a := int(action.AtVec(0))
 
 	if a == 0 {
@@ -67,6 +67,14 @@ const (
 	InitialRandom float64 = 1000.0 // Set 1500 to make game harder
 )
 
+// Keys of the diagnostics stored in the Info of each TimeStep
+const (
+	InfoLeg1Contact     = "Leg1Contact"     // 1 if leg 1 touches the ground
+	InfoLeg2Contact     = "Leg2Contact"     // 1 if leg 2 touches the ground
+	InfoMainEnginePower = "MainEnginePower" // Power of the main engine
+	InfoSideEnginePower = "SideEnginePower" // Power of the side engines
+)
+
 var (
 	LanderPoly [][]float64 = [][]float64{
 		{-14, 17},
@@ -729,6 +737,10 @@ func (l *lunarLander) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
 	reward := l.GetReward(l.prevStep.Observation, a, stateVec)
 	t := ts.New(ts.Mid, reward, l.discount, stateVec,
 		l.prevStep.Number+1)
+	t.SetInfo(InfoLeg1Contact, leg1GroundContact)
+	t.SetInfo(InfoLeg2Contact, leg2GroundContact)
+	t.SetInfo(InfoMainEnginePower, mPower)
+	t.SetInfo(InfoSideEnginePower, sPower)
 	l.End(&t)
 
 	l.prevStep = t
@@ -3,8 +3,11 @@ package acrobot
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"math"
 
+	"github.com/fogleman/gg"
 	"github.com/samuelfneumann/golearn/environment"
 	env "github.com/samuelfneumann/golearn/environment"
 	ts "github.com/samuelfneumann/golearn/timestep"
@@ -103,16 +106,16 @@ func validateState(state *mat.VecDense, angleBounds, vel1Bounds,
 	if l := state.Len(); l != 4 {
 		return fmt.Errorf("illegal state length \n\twant(4) \n\thave(%v)", l)
 	}
-	if angleBounds.Min < state.AtVec(0) || angleBounds.Max > state.AtVec(0) {
+	if angleBounds.Min > state.AtVec(0) || angleBounds.Max < state.AtVec(0) {
 		return fmt.Errorf("angle 1 out of bounds")
 	}
-	if angleBounds.Min < state.AtVec(1) || angleBounds.Max > state.AtVec(1) {
+	if angleBounds.Min > state.AtVec(1) || angleBounds.Max < state.AtVec(1) {
 		return fmt.Errorf("angle 2 out of bounds")
 	}
-	if vel1Bounds.Min < state.AtVec(2) || vel1Bounds.Max > state.AtVec(2) {
+	if vel1Bounds.Min > state.AtVec(2) || vel1Bounds.Max < state.AtVec(2) {
 		return fmt.Errorf("angular velocity 1 out of bounds")
 	}
-	if vel2Bounds.Min < state.AtVec(3) || vel2Bounds.Max > state.AtVec(3) {
+	if vel2Bounds.Min > state.AtVec(3) || vel2Bounds.Max < state.AtVec(3) {
 		return fmt.Errorf("angular velocity 2 out of bounds")
 	}
 	return nil
@@ -252,6 +255,58 @@ func (a *base) String() string {
 		state.AtVec(0), state.AtVec(1), state.AtVec(2), state.AtVec(3))
 }
 
+// Pixels draws the current state of the environment on an area of
+// 10 x 10 units, with 2 units per metre of the environment. The
+// acrobot is drawn as two links hanging from a fixed base in the
+// centre of the area. If the environment's Task is a SwingUp, the
+// goal height is drawn as a horizontal line.
+//
+// See environment.PixelEnvironment for more details.
+func (a *base) Pixels(scale float64, dc gg.Context, save bool) image.Image {
+	ctx := env.PixelContext(dc, save)
+	const unitsPerMetre = 2.0
+	const size = 10.0
+
+	// Draw background
+	ctx.SetColor(color.White)
+	ctx.DrawRectangle(0, 0, scale*size, scale*size)
+	ctx.Fill()
+
+	// Draw the goal height
+	x, y := size/2, size/2
+	if task, ok := a.Task.(*SwingUp); ok {
+		goalY := y - task.goalHeight*unitsPerMetre
+		ctx.SetColor(color.Black)
+		ctx.SetLineWidth(scale * 0.05)
+		ctx.DrawLine(0, scale*goalY, scale*size, scale*goalY)
+		ctx.Stroke()
+	}
+
+	// Draw the links, with angles measured from the negative y-axis.
+	// The angle of the second link is relative to the first link.
+	state := a.lastStep.Observation
+	theta1, theta2 := state.AtVec(0), state.AtVec(0)+state.AtVec(1)
+	x1 := x + LinkLength1*unitsPerMetre*math.Sin(theta1)
+	y1 := y + LinkLength1*unitsPerMetre*math.Cos(theta1)
+	x2 := x1 + LinkLength2*unitsPerMetre*math.Sin(theta2)
+	y2 := y1 + LinkLength2*unitsPerMetre*math.Cos(theta2)
+
+	ctx.SetRGB255(0, 204, 204)
+	ctx.SetLineWidth(scale * 0.4)
+	ctx.SetLineCap(gg.LineCapRound)
+	ctx.DrawLine(scale*x, scale*y, scale*x1, scale*y1)
+	ctx.DrawLine(scale*x1, scale*y1, scale*x2, scale*y2)
+	ctx.Stroke()
+
+	// Draw the joints
+	ctx.SetRGB255(204, 204, 0)
+	ctx.DrawCircle(scale*x, scale*y, scale*0.2)
+	ctx.DrawCircle(scale*x1, scale*y1, scale*0.2)
+	ctx.Fill()
+
+	return ctx.Image()
+}
+
 // dsDt calculate ds/dt for the environment, where s = the current
 // environment state
 func dsDt(sAugmented *mat.VecDense, t float64) []float64 {
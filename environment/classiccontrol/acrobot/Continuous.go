@@ -6,7 +6,6 @@ import (
 	"github.com/samuelfneumann/golearn/environment"
 	env "github.com/samuelfneumann/golearn/environment"
 	ts "github.com/samuelfneumann/golearn/timestep"
-	"github.com/samuelfneumann/golearn/utils/floatutils"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -75,14 +74,13 @@ func (d *Continuous) ActionSpec() environment.Spec {
 // this range will cause the environment to panic.
 func (d *Continuous) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
 	// Ensure action is 1-dimensional
-	if a.Len() > ActionDims {
+	if a.Len() != ActionDims {
 		return ts.TimeStep{}, true, fmt.Errorf("Actions should be " +
 			"1-dimensional")
 	}
 
 	// Calculate the torque applied
-	torque := floatutils.Clip(a.AtVec(0), MinContinuousAction,
-		MaxContinuousAction)
+	torque := d.ActionSpec().Clip(a).AtVec(0)
 
 	// Calculate the next state given the force/action
 	newState, err := d.nextState(torque)
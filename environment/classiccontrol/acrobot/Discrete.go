@@ -82,33 +82,21 @@ func (d *Discrete) ActionSpec() environment.Spec {
 // {MinDiscreteAction, MinDiscreteAction+1, ..., MaxDiscreteAction}.
 // Actions outside this range will cause an error to be returned.
 func (d *Discrete) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
-	// Ensure action is 1-dimensional
-	if a.Len() > ActionDims {
-		return ts.TimeStep{}, true, fmt.Errorf("Actions should be " +
-			"1-dimensional")
-	}
-
-	// Discrete action in {0, 1, 2}
-	action := a.AtVec(0)
-
 	// Ensure a legal action was selected
-	intAction := int(action)
-	if intAction > MaxDiscreteAction || intAction < MinDiscreteAction {
+	if !d.ActionSpec().Contains(a) {
 		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v "+
-			"\u2209 (0, 1, 2)", intAction)
+			"\u2209 (0, 1, 2)", a.RawVector().Data)
 	}
 
 	// Calculate the torque applied
 	var torque float64
-	if intAction == MinDiscreteAction {
+	switch int(a.AtVec(0)) {
+	case MinDiscreteAction:
 		torque = MinTorque
-	} else if intAction == MaxDiscreteAction {
+	case MaxDiscreteAction:
 		torque = MaxTorque
-	} else if intAction == 0 {
+	default:
 		torque = 0.0
-	} else {
-		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v "+
-			"\u2209 (0, 1, 2)", intAction)
 	}
 
 	// Calculate the next state given the force/action
@@ -37,8 +37,9 @@ type SwingUp struct {
 	environment.Starter
 	stepLimitEnder environment.Ender // Ends when step limit reached
 
-	f         func(*mat.VecDense) bool // Function for lineEnder
-	lineEnder environment.Ender        // Ends tip is above a line
+	f          func(*mat.VecDense) bool // Function for lineEnder
+	lineEnder  environment.Ender        // Ends tip is above a line
+	goalHeight float64
 }
 
 // NewSwingUp returns a new SwingUp task with start state distribution
@@ -63,7 +64,7 @@ func NewSwingUp(s environment.Starter, stepLimit int,
 
 	lineEnder := environment.NewFunctionEnder(endFunc, ts.TerminalStateReached)
 
-	return &SwingUp{s, stepLimitEnder, endFunc, lineEnder}
+	return &SwingUp{s, stepLimitEnder, endFunc, lineEnder, goalHeight}
 }
 
 // AtGoal returns whether the argument state is a goal state
@@ -3,8 +3,11 @@ package cartpole
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"math"
 
+	"github.com/fogleman/gg"
 	env "github.com/samuelfneumann/golearn/environment"
 	ts "github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/floatutils"
@@ -271,6 +274,54 @@ func (c *base) String() string {
 	return fmt.Sprintf(msg, position, speed, angle, velocity)
 }
 
+// Pixels draws the current state of the environment on an area of
+// 24 x 12 units, with 2.5 units per metre of the environment. The
+// cart is drawn as a rectangle on a horizontal track and the pole as
+// a line rising from the cart.
+//
+// See environment.PixelEnvironment for more details.
+func (c *base) Pixels(scale float64, dc gg.Context, save bool) image.Image {
+	ctx := env.PixelContext(dc, save)
+	const unitsPerMetre = 2.5
+	const width, height, trackY = 24.0, 12.0, 9.0
+
+	// Draw background and track
+	ctx.SetColor(color.White)
+	ctx.DrawRectangle(0, 0, scale*width, scale*height)
+	ctx.Fill()
+	ctx.SetColor(color.Black)
+	ctx.SetLineWidth(scale * 0.05)
+	ctx.DrawLine(0, scale*trackY, scale*width, scale*trackY)
+	ctx.Stroke()
+
+	state := c.lastStep.Observation
+	position, angle := state.AtVec(0), state.AtVec(2)
+
+	// Draw the cart
+	cartX := width/2 + position*unitsPerMetre
+	cartWidth, cartHeight := 0.5*unitsPerMetre, 0.3*unitsPerMetre
+	ctx.DrawRectangle(scale*(cartX-cartWidth/2), scale*(trackY-cartHeight),
+		scale*cartWidth, scale*cartHeight)
+	ctx.Fill()
+
+	// Draw the pole, rotated clockwise from the positive y-axis
+	poleLength := 2 * c.halfPoleLength * unitsPerMetre
+	poleY := trackY - cartHeight
+	ctx.SetRGB255(202, 152, 101)
+	ctx.SetLineWidth(scale * 0.25)
+	ctx.DrawLine(scale*cartX, scale*poleY,
+		scale*(cartX+poleLength*math.Sin(angle)),
+		scale*(poleY-poleLength*math.Cos(angle)))
+	ctx.Stroke()
+
+	// Draw the axle
+	ctx.SetRGB255(129, 132, 203)
+	ctx.DrawCircle(scale*cartX, scale*poleY, scale*0.125)
+	ctx.Fill()
+
+	return ctx.Image()
+}
+
 // normalizeAngle normalizes the pole angle to the appropriate limits
 func normalizeAngle(th float64, angleBounds r1.Interval) float64 {
 	if angleBounds.Max != -angleBounds.Min {
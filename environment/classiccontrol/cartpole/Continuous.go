@@ -6,7 +6,6 @@ import (
 
 	env "github.com/samuelfneumann/golearn/environment"
 	ts "github.com/samuelfneumann/golearn/timestep"
-	"github.com/samuelfneumann/golearn/utils/floatutils"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -66,15 +65,13 @@ func (c *Continuous) ActionSpec() env.Spec {
 // outside the legal range of [-1, 1] are clipped to stay within this range.
 func (c *Continuous) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
 	// Ensure action is 1-dimensional
-	if a.Len() > ActionDims {
+	if a.Len() != ActionDims {
 		return ts.TimeStep{}, true, fmt.Errorf("step: actions should be " +
 			"1-dimensional")
 	}
 
 	// Continuous action in [-1, 1]
-	directionMagnitude := a.AtVec(0)
-	directionMagnitude = floatutils.Clip(directionMagnitude,
-		MinContinuousAction, MaxContinuousAction)
+	directionMagnitude := c.ActionSpec().Clip(a).AtVec(0)
 
 	// Calculate the next state given the direction to apply force
 	nextState := c.nextState(directionMagnitude)
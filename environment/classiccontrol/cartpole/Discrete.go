@@ -67,22 +67,15 @@ func (c *Discrete) ActionSpec() env.Spec {
 // no force to the cart. Legal actions are in the set {0, 1, 2}.
 // Actions outside this range will cause an error to be returned.
 func (c *Discrete) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
-	// Ensure action is 1-dimensional
-	if a.Len() > ActionDims {
-		return ts.TimeStep{}, true, fmt.Errorf("step: actions should be " +
-			"1-dimensional")
+	// Ensure a legal action was selected
+	if !c.ActionSpec().Contains(a) {
+		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v "+
+			"\u2209 (0, 1, 2)", a.RawVector().Data)
 	}
 
 	// Discrete action in {0, 1, 2}
 	direction := a.AtVec(0)
 
-	// Ensure a legal action was selected
-	intAction := int(direction)
-	if intAction < MinDiscreteAction || intAction > MaxDiscreteAction {
-		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v "+
-			"\u2209 (0, 1, 2)", intAction)
-	}
-
 	// Convert action (0, 1, 2) to a direction (-1, 0, 1)
 	direction--
 
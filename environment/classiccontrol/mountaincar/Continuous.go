@@ -5,7 +5,6 @@ import (
 
 	env "github.com/samuelfneumann/golearn/environment"
 	ts "github.com/samuelfneumann/golearn/timestep"
-	"github.com/samuelfneumann/golearn/utils/floatutils"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -69,14 +68,13 @@ func (m *Continuous) ActionSpec() env.Spec {
 // range.
 func (m *Continuous) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
 	// Ensure action is 1-dimensional
-	if a.Len() > ActionDims {
+	if a.Len() != ActionDims {
 		return ts.TimeStep{}, true, fmt.Errorf("Actions should be " +
 			"1-dimensional")
 	}
 
 	// Clip action to legal range
-	force := floatutils.Clip(a.AtVec(0), MinContinuousAction,
-		MaxContinuousAction)
+	force := m.ActionSpec().Clip(a).AtVec(0)
 
 	// Calculate the next state given the force/action
 	newState := m.nextState(force)
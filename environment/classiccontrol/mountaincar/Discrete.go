@@ -73,22 +73,15 @@ func (m *Discrete) ActionSpec() env.Spec {
 // to the car. Legal actions are in the set {0, 1, 2}. Actions outside
 // this range will cause an error to be returned.
 func (m *Discrete) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
-	// Ensure action is 1-dimensional
-	if a.Len() > ActionDims {
-		return ts.TimeStep{}, true, fmt.Errorf("step: ctions should be " +
-			"1-dimensional")
+	// Ensure a legal action was selected
+	if !m.ActionSpec().Contains(a) {
+		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v "+
+			"\u2209 (0, 1, 2)", a.RawVector().Data)
 	}
 
 	// Discrete action in {0, 1, 2}
 	action := a.AtVec(0)
 
-	// Ensure a legal action was selected
-	intAction := int(action)
-	if intAction > MaxDiscreteAction || intAction < MinDiscreteAction {
-		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v "+
-			"\u2209 (0, 1, 2)", intAction)
-	}
-
 	// Calculate the force
 	force := action - 1.0
 
@@ -2,10 +2,13 @@ package mountaincar
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"math"
 	"os"
 	"strings"
 
+	"github.com/fogleman/gg"
 	env "github.com/samuelfneumann/golearn/environment"
 	ts "github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/floatutils"
@@ -215,6 +218,67 @@ func (m *base) Render() {
 
 }
 
+// Pixels draws the current state of the environment on an area of
+// 18 x 14 units, with 10 units per unit of position. The hill is
+// drawn as a curve, the car as a circle on the hill, and the goal (if
+// the environment's Task is a Goal) as a flag.
+//
+// See environment.PixelEnvironment for more details.
+func (m *base) Pixels(scale float64, dc gg.Context, save bool) image.Image {
+	ctx := env.PixelContext(dc, save)
+	const unitsPerPosition = 10.0
+	const width, height = 18.0, 14.0
+
+	// toPixels converts a position on the hill to pixel coordinates
+	toPixels := func(position float64) (float64, float64) {
+		x := (position - m.positionBounds.Min) * unitsPerPosition
+		y := height - 1 - hillHeight(position)*unitsPerPosition
+		return scale * x, scale * y
+	}
+
+	// Draw background
+	ctx.SetColor(color.White)
+	ctx.DrawRectangle(0, 0, scale*width, scale*height)
+	ctx.Fill()
+
+	// Draw the hill
+	const points = 100
+	ctx.SetColor(color.Black)
+	ctx.SetLineWidth(scale * 0.1)
+	for i := 0; i <= points; i++ {
+		position := m.positionBounds.Min +
+			float64(i)*(m.positionBounds.Max-m.positionBounds.Min)/points
+		ctx.LineTo(toPixels(position))
+	}
+	ctx.Stroke()
+
+	// Draw the goal flag
+	if goal, ok := m.Task.(*Goal); ok {
+		x, y := toPixels(goal.goalX)
+		ctx.DrawLine(x, y, x, y-scale*1.5)
+		ctx.Stroke()
+		ctx.SetRGB255(204, 204, 0)
+		ctx.MoveTo(x, y-scale*1.5)
+		ctx.LineTo(x, y-scale)
+		ctx.LineTo(x+scale*0.75, y-scale*1.25)
+		ctx.ClosePath()
+		ctx.Fill()
+	}
+
+	// Draw the car
+	x, y := toPixels(m.lastStep.Observation.AtVec(0))
+	ctx.SetRGB255(204, 0, 0)
+	ctx.DrawCircle(x, y-scale*0.5, scale*0.5)
+	ctx.Fill()
+
+	return ctx.Image()
+}
+
+// hillHeight returns the height of the hill at the argument position
+func hillHeight(position float64) float64 {
+	return 0.45*math.Sin(3*position) + 0.55
+}
+
 // String returns a string representation of the environment
 func (m *base) String() string {
 	str := "Mountain Car  |  Position: %v  |  Speed: %v"
@@ -6,7 +6,6 @@ import (
 
 	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/timestep"
-	"github.com/samuelfneumann/golearn/utils/floatutils"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -61,15 +60,14 @@ func NewContinuous(t environment.Task,
 func (p *Continuous) Step(action *mat.VecDense) (timestep.TimeStep, bool,
 	error) {
 	// Ensure action is 1-dimensional
-	if action.Len() > ActionDims {
+	if action.Len() != ActionDims {
 		return timestep.TimeStep{}, true, fmt.Errorf("step: ctions should be" +
 			" 1-dimensional")
 	}
 
 	// Clip action to ensure that it is in the legal range of continuous
 	// actions
-	torque := floatutils.Clip(action.AtVec(0), MinContinuousAction,
-		MaxContinuousAction)
+	torque := p.ActionSpec().Clip(action).AtVec(0)
 
 	// Calculate the next state given the torque/action
 	nextState := p.nextState(p.lastStep, torque)
@@ -67,15 +67,15 @@ func NewDiscrete(t environment.Task,
 // error to be returned
 func (p *Discrete) Step(action *mat.VecDense) (timestep.TimeStep, bool,
 	error) {
-	// Ensure action is 1-dimensional
-	if action.Len() > ActionDims {
-		return timestep.TimeStep{}, true, fmt.Errorf("step: ctions should be " +
-			"1-dimensional")
+	// Ensure a legal action was selected
+	if !p.ActionSpec().Contains(action) {
+		return timestep.TimeStep{}, true, fmt.Errorf("step: illegal action %v",
+			action.RawVector().Data)
 	}
 
 	// Convert discrete action to torque applied to fixed base
 	var torque float64
-	if action.AtVec(0) <= 0.0 {
+	if action.AtVec(0) == 0.0 {
 		torque = MinContinuousAction
 	} else if action.AtVec(0) == 1.0 {
 		torque = MinContinuousAction / 2.0
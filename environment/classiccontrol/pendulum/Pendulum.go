@@ -3,9 +3,12 @@ package pendulum
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"math"
 	"os"
 
+	"github.com/fogleman/gg"
 	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/floatutils"
@@ -210,6 +213,41 @@ func (p *base) Render() {
 
 }
 
+// Pixels draws the current state of the environment on an area of
+// 10 x 10 units, with 4 units per metre of the environment. The
+// pendulum is drawn as a rod rotating about a fixed base in the
+// centre of the area.
+//
+// See environment.PixelEnvironment for more details.
+func (p *base) Pixels(scale float64, dc gg.Context, save bool) image.Image {
+	ctx := environment.PixelContext(dc, save)
+	const unitsPerMetre = 4.0
+	const size = 10.0
+
+	// Draw background
+	ctx.SetColor(color.White)
+	ctx.DrawRectangle(0, 0, scale*size, scale*size)
+	ctx.Fill()
+
+	// Draw the pendulum, rotated clockwise from the positive y-axis
+	angle := p.lastStep.Observation.AtVec(0)
+	length := p.length * unitsPerMetre
+	x, y := size/2, size/2
+	ctx.SetRGB255(204, 77, 77)
+	ctx.SetLineWidth(scale * 0.8)
+	ctx.SetLineCap(gg.LineCapRound)
+	ctx.DrawLine(scale*x, scale*y, scale*(x+length*math.Sin(angle)),
+		scale*(y-length*math.Cos(angle)))
+	ctx.Stroke()
+
+	// Draw the fixed base
+	ctx.SetColor(color.Black)
+	ctx.DrawCircle(scale*x, scale*y, scale*0.2)
+	ctx.Fill()
+
+	return ctx.Image()
+}
+
 // normalizeAngle normalizes the pendulum angle to the appropriate limits
 func normalizeAngle(th float64, angleBounds r1.Interval) float64 {
 	if angleBounds.Max != -angleBounds.Min {
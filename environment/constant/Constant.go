@@ -0,0 +1,139 @@
+// Package constant implements an environment with a single state and
+// constant rewards, whose value function is known in closed form. It
+// is useful for testing that agents bootstrap correctly at the ends of
+// episodes.
+package constant
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Constant implements an environment with a single state, in which
+// every action yields the same reward. The state observation is always
+// the vector [1], so that the value of the state can be learned as the
+// weight of a single feature.
+//
+// Episodes are ended by an environment.Ender. If episodes are only
+// truncated by a timeout, such as with an environment.StepLimit, then
+// the environment is continuing and the value of each action is
+// reward / (1 - discount), independent of the episode cutoff. If
+// instead episodes terminate after a single step, for example with an
+// environment.FunctionEnder with ending type
+// timestep.TerminalStateReached, the value of each action is reward.
+// Value estimates which differ from these values are biased by
+// incorrect handling of terminal or truncated TimeSteps.
+type Constant struct {
+	environment.Ender
+	actions     int
+	reward      float64
+	discount    float64
+	currentStep ts.TimeStep
+}
+
+// New returns a new Constant environment with the given number of
+// discrete actions, each of which yields reward. Episodes are ended
+// by e.
+func New(actions int, reward float64, e environment.Ender,
+	discount float64) (environment.Environment, ts.TimeStep, error) {
+	if actions < 1 {
+		return nil, ts.TimeStep{}, fmt.Errorf("new: must have at least " +
+			"one action")
+	}
+
+	c := &Constant{
+		Ender:    e,
+		actions:  actions,
+		reward:   reward,
+		discount: discount,
+	}
+
+	step, err := c.Reset()
+	if err != nil {
+		return nil, ts.TimeStep{}, fmt.Errorf("new: %v", err)
+	}
+	return c, step, nil
+}
+
+// Start returns the starting state observation
+func (c *Constant) Start() *mat.VecDense {
+	return mat.NewVecDense(1, []float64{1.0})
+}
+
+// GetReward returns the reward for a transition, which is the same for
+// all transitions
+func (c *Constant) GetReward(_, _, _ mat.Vector) float64 {
+	return c.reward
+}
+
+// AtGoal returns whether the argument state is a goal state. Constant
+// has no goal states.
+func (c *Constant) AtGoal(mat.Matrix) bool {
+	return false
+}
+
+// Reset resets the environment between episodes
+func (c *Constant) Reset() (ts.TimeStep, error) {
+	c.currentStep = ts.New(ts.First, 0, c.discount, c.Start(), 0)
+	return c.currentStep, nil
+}
+
+// Step takes one environmental step given some action
+func (c *Constant) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
+	if !c.ActionSpec().Contains(a) {
+		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v",
+			a.RawVector().Data)
+	}
+
+	obs := c.Start()
+	reward := c.GetReward(c.currentStep.Observation, a, obs)
+	step := ts.New(ts.Mid, reward, c.discount, obs, c.currentStep.Number+1)
+	last := c.End(&step)
+
+	c.currentStep = step
+	return step, last, nil
+}
+
+// CurrentTimeStep returns the last TimeStep that occurred in the
+// environment
+func (c *Constant) CurrentTimeStep() ts.TimeStep {
+	return c.currentStep
+}
+
+// DiscountSpec returns the discount specification of the environment
+func (c *Constant) DiscountSpec() environment.Spec {
+	shape := mat.NewVecDense(1, nil)
+	bound := mat.NewVecDense(1, []float64{c.discount})
+
+	return environment.NewSpec(shape, environment.Discount, bound, bound,
+		environment.Continuous)
+}
+
+// ObservationSpec returns the observation specification of the
+// environment
+func (c *Constant) ObservationSpec() environment.Spec {
+	shape := mat.NewVecDense(1, nil)
+	bound := mat.NewVecDense(1, []float64{1.0})
+
+	return environment.NewSpec(shape, environment.Observation, bound, bound,
+		environment.Continuous)
+}
+
+// ActionSpec returns the action specification of the environment
+func (c *Constant) ActionSpec() environment.Spec {
+	shape := mat.NewVecDense(1, nil)
+	lowerBound := mat.NewVecDense(1, []float64{0})
+	upperBound := mat.NewVecDense(1, []float64{float64(c.actions - 1)})
+
+	return environment.NewSpec(shape, environment.Action, lowerBound,
+		upperBound, environment.Discrete)
+}
+
+// String returns a string representation of the environment
+func (c *Constant) String() string {
+	return fmt.Sprintf("Constant | Actions: %v | Reward: %v", c.actions,
+		c.reward)
+}
@@ -4,6 +4,7 @@
 package envconfig
 
 import (
+	"encoding/json"
 	"fmt"
 
 	env "github.com/samuelfneumann/golearn/environment"
@@ -13,7 +14,9 @@ import (
 	"github.com/samuelfneumann/golearn/environment/classiccontrol/mountaincar"
 	"github.com/samuelfneumann/golearn/environment/classiccontrol/pendulum"
 	"github.com/samuelfneumann/golearn/environment/gridworld"
+	"github.com/samuelfneumann/golearn/environment/lights"
 	"github.com/samuelfneumann/golearn/environment/maze"
+	"github.com/samuelfneumann/golearn/environment/minatar"
 	"github.com/samuelfneumann/golearn/environment/mujoco/hopper"
 	"github.com/samuelfneumann/golearn/environment/mujoco/reacher"
 	"github.com/samuelfneumann/golearn/environment/wrappers"
@@ -37,6 +40,13 @@ const (
 	Hopper      EnvName = "Hopper"
 	Reacher     EnvName = "Reacher"
 	Maze        EnvName = "Maze"
+	Lights      EnvName = "Lights"
+
+	// MinAtar-style pixel games
+	Breakout      EnvName = "Breakout"
+	Freeway       EnvName = "Freeway"
+	Asterix       EnvName = "Asterix"
+	SpaceInvaders EnvName = "SpaceInvaders"
 )
 
 // TaskName stores the tasks that can be configured with this package.
@@ -50,6 +60,11 @@ const (
 //	Pendulum			SwingUp
 // 	Acrobot				SwingUp
 //						Balance (soon to come)
+//	Lights				Match
+//	Breakout			Play
+//	Freeway				Play
+//	Asterix				Play
+//	SpaceInvaders		Play
 type TaskName string
 
 // Tasks available for configuration
@@ -60,6 +75,8 @@ const (
 	Land    TaskName = "Land"
 	Hop     TaskName = "Hop"
 	Reach   TaskName = "Reach"
+	Play    TaskName = "Play"
+	Match   TaskName = "Match"
 )
 
 // Config implements a specific configuration of a specific environment
@@ -77,6 +94,25 @@ type Config struct {
 	// TileCoding indicates if tile coding should be used and if so,
 	// what bins should be used
 	TileCoding tileCodingConfig
+
+	// MinAtar determines the settings of MinAtar-style pixel games and
+	// is ignored for all other environments
+	MinAtar minAtarConfig
+
+	// Discretization indicates if continuous actions should be
+	// discretized and if so, how. Only environments with continuous
+	// actions can be discretized.
+	Discretization discretizationConfig
+
+	// PixelObservation indicates if images of the environment should
+	// be used as observations and if so, what size the images should
+	// be. Only environments which can be drawn can use images as
+	// observations.
+	PixelObservation pixelObservationConfig
+
+	// Recording indicates if episodes should be recorded and if so,
+	// how. Only environments which can be drawn can be recorded.
+	Recording recordingConfig
 }
 
 // NewConfig returns a new environment Config describing an environment with
@@ -94,9 +130,31 @@ func NewConfig(envName EnvName, taskName TaskName, continuousActions bool,
 		EpisodeCutoff:     episodeCutoff,
 		Discount:          discount,
 		Gym:               gym,
+		MinAtar: minAtarConfig{
+			StickyActionProb: minatar.DefaultStickyActionProb,
+		},
 	}
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface. Settings
+// missing from the JSON data take their default values, so that
+// MinAtar games use minatar.DefaultStickyActionProb unless a sticky
+// action probability is given.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type config Config
+	decoded := config{
+		MinAtar: minAtarConfig{
+			StickyActionProb: minatar.DefaultStickyActionProb,
+		},
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*c = Config(decoded)
+	return nil
+}
+
 // CreateEnv returns the environment described by the Config as well as
 // the first timestep of the environment.
 func (c Config) CreateEnv(seed uint64) (env.Environment, ts.TimeStep,
@@ -141,11 +199,38 @@ func (c Config) CreateEnv(seed uint64) (env.Environment, ts.TimeStep,
 		e, step, err = CreateReacher(c.ContinuousActions, c.Task,
 			int(c.EpisodeCutoff), seed, c.Discount)
 
+	case Lights:
+		e, step, err = CreateLights(c.ContinuousActions, c.Task,
+			int(c.EpisodeCutoff), seed, c.Discount)
+
+	case Breakout, Freeway, Asterix, SpaceInvaders:
+		e, step, err = CreateMinAtar(c.Environment, c.ContinuousActions,
+			c.Task, int(c.EpisodeCutoff), seed, c.Discount,
+			c.MinAtar.StickyActionProb, c.MinAtar.Ramping)
+
 	default:
 		return nil, ts.TimeStep{}, fmt.Errorf("createEnv: cannot create "+
 			"environment %v, no such environment", c.Environment)
 	}
 
+	if err == nil && c.Discretization.Discretize {
+		if !c.ContinuousActions {
+			return nil, ts.TimeStep{}, fmt.Errorf("createEnv: cannot " +
+				"discretize discrete actions")
+		}
+		e, step, err = wrappers.NewDiscretize(e,
+			c.Discretization.DiscretizeConfig)
+	}
+
+	if c.PixelObservation.UsePixels {
+		if c.TileCoding.UseTileCoding {
+			return nil, ts.TimeStep{}, fmt.Errorf("createEnv: cannot use " +
+				"tile coding with pixel observations")
+		}
+		e, step, err = wrappers.NewPixelObservation(e,
+			c.PixelObservation.PixelObservationConfig)
+	}
+
 	if c.TileCoding.UseTileCoding {
 		if c.TileCoding.UseIndices {
 			e, step, err = wrappers.NewIndexTileCoding(e, c.TileCoding.Bins, seed)
@@ -154,6 +239,12 @@ func (c Config) CreateEnv(seed uint64) (env.Environment, ts.TimeStep,
 		}
 	}
 
+	// Record the environment last so that all other wrappers are
+	// applied to the recorded episodes
+	if err == nil && c.Recording.Record {
+		e, step, err = wrappers.NewRecorder(e, c.Recording.RecorderConfig)
+	}
+
 	if err != nil {
 		return nil, ts.TimeStep{}, fmt.Errorf("createEnv: %v", err)
 	}
@@ -446,6 +537,102 @@ func CreateReacher(continuousActions bool, taskName TaskName,
 	return env, firstStep, nil
 }
 
+// CreateMinAtar is a factory for creating the MinAtar-style game
+// environment envName with the Play task.
+func CreateMinAtar(envName EnvName, continuousActions bool,
+	taskName TaskName, cutoff int, seed uint64, discount,
+	stickyActionProb float64, ramping bool) (env.Environment, ts.TimeStep,
+	error) {
+	if continuousActions {
+		return nil, ts.TimeStep{}, fmt.Errorf("createMinAtar: %v only "+
+			"supports discrete actions", envName)
+	}
+
+	var task env.Task
+	switch taskName {
+	case Play:
+		task = minatar.NewPlay(cutoff)
+
+	default:
+		return nil, ts.TimeStep{}, fmt.Errorf("createMinAtar: %v "+
+			"environment has no task %v", envName, taskName)
+	}
+
+	switch envName {
+	case Breakout:
+		return minatar.NewBreakout(task, stickyActionProb, discount, seed)
+
+	case Freeway:
+		return minatar.NewFreeway(task, stickyActionProb, discount, seed)
+
+	case Asterix:
+		return minatar.NewAsterix(task, stickyActionProb, discount, ramping,
+			seed)
+
+	case SpaceInvaders:
+		return minatar.NewSpaceInvaders(task, stickyActionProb, discount,
+			ramping, seed)
+
+	default:
+		return nil, ts.TimeStep{}, fmt.Errorf("createMinAtar: no such "+
+			"MinAtar environment %v", envName)
+	}
+}
+
+// CreateLights is a factory for creating a Lights environment with
+// 3 lights, each with 3 brightness levels. Lights only supports
+// multi-discrete actions.
+func CreateLights(continuousActions bool, taskName TaskName, cutoff int,
+	seed uint64, discount float64) (env.Environment, ts.TimeStep, error) {
+	if continuousActions {
+		return nil, ts.TimeStep{}, fmt.Errorf("createLights: Lights only " +
+			"supports multi-discrete actions")
+	}
+
+	switch taskName {
+	case Match:
+		return lights.New(3, 3, env.NewStepLimit(cutoff), discount, seed)
+
+	default:
+		return nil, ts.TimeStep{}, fmt.Errorf("createLights: Lights "+
+			"environment has no task %v", taskName)
+	}
+}
+
+// minAtarConfig implements configuration settings for MinAtar-style
+// pixel games
+type minAtarConfig struct {
+	// StickyActionProb is the probability of repeating the previous
+	// action instead of the selected action
+	StickyActionProb float64
+
+	// Ramping determines whether games which support difficulty
+	// ramping (Asterix and SpaceInvaders) become harder over time
+	Ramping bool
+}
+
+// discretizationConfig implements configuration settings for
+// discretizing continuous actions. See wrappers.DiscretizeConfig.
+type discretizationConfig struct {
+	Discretize bool
+	wrappers.DiscretizeConfig
+}
+
+// pixelObservationConfig implements configuration settings for using
+// images of environments as observations. See
+// wrappers.PixelObservationConfig.
+type pixelObservationConfig struct {
+	UsePixels bool
+	wrappers.PixelObservationConfig
+}
+
+// recordingConfig implements configuration settings for recording
+// episodes of environments. See wrappers.RecorderConfig.
+type recordingConfig struct {
+	Record bool
+	wrappers.RecorderConfig
+}
+
 // tileCodingConfig implements configuration settings for tile coding
 // of environments. A separate struct for the environment config is
 // used to make the JSON file look prettier.
@@ -0,0 +1,75 @@
+package envconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment/minatar"
+)
+
+// TestUnmarshalStickyActionProb tests that MinAtar games use the
+// default sticky action probability unless one is given in the JSON
+// configuration
+func TestUnmarshalStickyActionProb(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want float64
+	}{
+		{
+			name: "NoMinAtar",
+			data: `{"Environment": "Breakout", "Task": "Play",
+				"EpisodeCutoff": 100, "Discount": 0.99}`,
+			want: minatar.DefaultStickyActionProb,
+		},
+		{
+			name: "NoStickyActionProb",
+			data: `{"Environment": "Breakout", "Task": "Play",
+				"EpisodeCutoff": 100, "Discount": 0.99,
+				"MinAtar": {"Ramping": true}}`,
+			want: minatar.DefaultStickyActionProb,
+		},
+		{
+			name: "ZeroStickyActionProb",
+			data: `{"Environment": "Breakout", "Task": "Play",
+				"EpisodeCutoff": 100, "Discount": 0.99,
+				"MinAtar": {"StickyActionProb": 0}}`,
+			want: 0,
+		},
+		{
+			name: "StickyActionProb",
+			data: `{"Environment": "Breakout", "Task": "Play",
+				"EpisodeCutoff": 100, "Discount": 0.99,
+				"MinAtar": {"StickyActionProb": 0.25}}`,
+			want: 0.25,
+		},
+	}
+
+	for _, test := range tests {
+		var c Config
+		if err := json.Unmarshal([]byte(test.data), &c); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if c.MinAtar.StickyActionProb != test.want {
+			t.Errorf("%v: config: have(%v) want(%v)", test.name,
+				c.MinAtar.StickyActionProb, test.want)
+		}
+		if c.Environment != Breakout || c.EpisodeCutoff != 100 ||
+			c.Discount != 0.99 {
+			t.Errorf("%v: other settings not decoded: %+v", test.name, c)
+		}
+
+		e, _, err := c.CreateEnv(1)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		game, ok := e.(*minatar.MinAtar)
+		if !ok {
+			t.Fatalf("%v: have(%T) want(*minatar.MinAtar)", test.name, e)
+		}
+		if p := game.StickyActionProb(); p != test.want {
+			t.Errorf("%v: environment: have(%v) want(%v)", test.name, p,
+				test.want)
+		}
+	}
+}
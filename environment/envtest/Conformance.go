@@ -0,0 +1,251 @@
+// Package envtest implements a conformance test suite for environments.
+//
+// The suite checks that an environment follows the conventions which
+// agents and environment wrappers rely on:
+//
+//   - Specs have the correct SpecType, matching shape and bound lengths,
+//     ordered bounds, and integer bounds if they are discrete. The
+//     DiscountSpec is 1-dimensional.
+//   - Episodes begin with a First TimeStep numbered 0, followed by Mid
+//     TimeSteps and a single Last TimeStep. TimeStep numbers increase by
+//     1 on each step, and the bool returned by Step() is true exactly
+//     when the TimeStep is Last.
+//   - The EndType of each Last TimeStep is set to either
+//     timestep.TerminalStateReached or timestep.Timeout, and the EndType
+//     of all other TimeSteps is not.
+//   - CurrentTimeStep() returns the most recent TimeStep returned by
+//     Reset() or Step().
+//   - Observations are contained in the ObservationSpec, discounts are
+//     contained in the DiscountSpec, and rewards are finite.
+//   - Environments created with equal seeds, and which are given equal
+//     actions, produce equal TimeSteps.
+//
+// Environments are created from an envconfig.Config and are acted in by
+// selecting actions uniformly randomly from their ActionSpec().
+package envtest
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/envconfig"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Run runs the conformance test suite on the environment described by
+// c, created with the given seed, for the given number of steps. Each
+// check is run as a subtest of t.
+func Run(t *testing.T, c envconfig.Config, seed uint64, steps int) {
+	t.Helper()
+
+	t.Run("Specs", func(t *testing.T) {
+		env, _ := create(t, c, seed)
+		checkSpec(t, env.ObservationSpec(), environment.Observation)
+		checkSpec(t, env.ActionSpec(), environment.Action)
+		checkSpec(t, env.DiscountSpec(), environment.Discount)
+
+		if length := env.DiscountSpec().Shape.Len(); length != 1 {
+			t.Errorf("discount spec length: have(%v) want(1)", length)
+		}
+	})
+
+	var trajectory []ts.TimeStep
+	t.Run("Episodes", func(t *testing.T) {
+		trajectory = rollout(t, c, seed, steps)
+	})
+
+	t.Run("Determinism", func(t *testing.T) {
+		if trajectory == nil {
+			t.Skip("episodes are not conformant")
+		}
+
+		other := rollout(t, c, seed, steps)
+		if len(other) != len(trajectory) {
+			t.Fatalf("environments with equal seeds had different "+
+				"numbers of episodes: have(%v) want(%v) timesteps",
+				len(other), len(trajectory))
+		}
+		for i := range trajectory {
+			if !equal(trajectory[i], other[i]) {
+				t.Fatalf("step %v: environments with equal seeds "+
+					"diverged:\n\thave(%v)\n\twant(%v)", i,
+					format(other[i]), format(trajectory[i]))
+			}
+		}
+	})
+}
+
+// create creates the environment described by c with the given seed
+func create(t *testing.T, c envconfig.Config,
+	seed uint64) (environment.Environment, ts.TimeStep) {
+	t.Helper()
+
+	env, step, err := c.CreateEnv(seed)
+	if err != nil {
+		t.Fatalf("could not create environment: %v", err)
+	}
+	return env, step
+}
+
+// checkSpec checks that spec is a well-formed Spec of type want
+func checkSpec(t *testing.T, spec environment.Spec,
+	want environment.SpecType) {
+	t.Helper()
+
+	if spec.Type != want {
+		t.Errorf("spec type: have(%v) want(%v)", spec.Type, want)
+	}
+
+	length := spec.Shape.Len()
+	if spec.LowerBound.Len() != length || spec.UpperBound.Len() != length {
+		t.Fatalf("spec %v bounds: have lengths (%v, %v) want(%v)", want,
+			spec.LowerBound.Len(), spec.UpperBound.Len(), length)
+	}
+
+	for i := 0; i < length; i++ {
+		lower, upper := spec.LowerBound.AtVec(i), spec.UpperBound.AtVec(i)
+		if math.IsNaN(lower) || math.IsNaN(upper) || lower > upper {
+			t.Errorf("spec %v dimension %v: illegal bounds [%v, %v]", want,
+				i, lower, upper)
+		}
+
+		if spec.Cardinality != environment.Continuous &&
+			(lower != math.Trunc(lower) || upper != math.Trunc(upper)) {
+			t.Errorf("spec %v dimension %v: discrete bounds [%v, %v] must "+
+				"be integers", want, i, lower, upper)
+		}
+	}
+}
+
+// rollout acts in a new environment described by c for the given
+// number of steps, checking each TimeStep, and returns the TimeSteps
+// of the interaction. The returned TimeSteps include those returned
+// by Reset().
+func rollout(t *testing.T, c envconfig.Config, seed uint64,
+	steps int) []ts.TimeStep {
+	t.Helper()
+
+	env, step := create(t, c, seed)
+	rng := rand.New(rand.NewSource(seed))
+
+	checkFirst(t, env, step)
+	trajectory := []ts.TimeStep{copyStep(step)}
+
+	for i := 0; i < steps; i++ {
+		action := env.ActionSpec().Sample(rng)
+		next, last, err := env.Step(action)
+		if err != nil {
+			t.Fatalf("step %v: could not step environment: %v", i, err)
+		}
+
+		if next.StepType != ts.Mid && next.StepType != ts.Last {
+			t.Fatalf("step %v: step type: have(%v) want(Mid or Last)", i,
+				next.StepType)
+		}
+		if last != next.Last() {
+			t.Fatalf("step %v: step returned last = %v for %v step", i,
+				last, next.StepType)
+		}
+		if next.Number != step.Number+1 {
+			t.Fatalf("step %v: step number: have(%v) want(%v)", i,
+				next.Number, step.Number+1)
+		}
+
+		terminal, cutoff := next.TerminalEnd(), next.CutoffEnd()
+		if last && terminal == cutoff {
+			t.Fatalf("step %v: last step must end due to either a "+
+				"terminal state or a timeout (have %q)", i, next.EndType)
+		}
+		if !last && (terminal || cutoff) {
+			t.Fatalf("step %v: %v step has end type %q", i, next.StepType,
+				next.EndType)
+		}
+
+		checkStep(t, env, next)
+		trajectory = append(trajectory, copyStep(next))
+		step = next
+
+		if last {
+			if step, err = env.Reset(); err != nil {
+				t.Fatalf("step %v: could not reset environment: %v", i, err)
+			}
+			checkFirst(t, env, step)
+			trajectory = append(trajectory, copyStep(step))
+		}
+	}
+
+	return trajectory
+}
+
+// checkFirst checks that step is the first TimeStep of an episode
+func checkFirst(t *testing.T, env environment.Environment, step ts.TimeStep) {
+	t.Helper()
+
+	if !step.First() {
+		t.Fatalf("first step type: have(%v) want(First)", step.StepType)
+	}
+	if step.Number != 0 {
+		t.Fatalf("first step number: have(%v) want(0)", step.Number)
+	}
+	checkStep(t, env, step)
+}
+
+// checkStep checks that the TimeStep step, which was most recently
+// returned by env, is consistent with the Specs and current TimeStep
+// of env
+func checkStep(t *testing.T, env environment.Environment, step ts.TimeStep) {
+	t.Helper()
+
+	obsSpec := env.ObservationSpec()
+	if step.Observation.Len() != obsSpec.Shape.Len() {
+		t.Fatalf("%v step %v: observation length: have(%v) want(%v)",
+			step.StepType, step.Number, step.Observation.Len(),
+			obsSpec.Shape.Len())
+	}
+	if !obsSpec.Contains(step.Observation) {
+		t.Fatalf("%v step %v: observation %v not in observation spec",
+			step.StepType, step.Number, mat.Formatted(step.Observation.T()))
+	}
+
+	discount := mat.NewVecDense(1, []float64{step.Discount})
+	if !env.DiscountSpec().Contains(discount) {
+		t.Fatalf("%v step %v: discount %v not in discount spec",
+			step.StepType, step.Number, step.Discount)
+	}
+
+	if math.IsNaN(step.Reward) || math.IsInf(step.Reward, 0) {
+		t.Fatalf("%v step %v: reward %v is not finite", step.StepType,
+			step.Number, step.Reward)
+	}
+
+	if current := env.CurrentTimeStep(); !equal(current, step) {
+		t.Fatalf("%v step %v: current timestep:\n\thave(%v)\n\twant(%v)",
+			step.StepType, step.Number, format(current), format(step))
+	}
+}
+
+// copyStep returns a copy of step which does not share its observation
+// with step, so that the copy is unaffected if the environment reuses
+// the observation vector
+func copyStep(step ts.TimeStep) ts.TimeStep {
+	step.Observation = mat.VecDenseCopyOf(step.Observation)
+	return step
+}
+
+// equal returns whether two TimeSteps are equal, ignoring their Info
+func equal(a, b ts.TimeStep) bool {
+	return a.StepType == b.StepType && a.Number == b.Number &&
+		a.EndType == b.EndType && a.Reward == b.Reward &&
+		a.Discount == b.Discount && mat.Equal(a.Observation, b.Observation)
+}
+
+// format returns a string representation of step for error messages
+func format(step ts.TimeStep) string {
+	return fmt.Sprintf("%v %v | reward: %v | discount: %v | end: %v | "+
+		"observation: %v", step.StepType, step.Number, step.Reward,
+		step.Discount, step.EndType, step.Observation.RawVector().Data)
+}
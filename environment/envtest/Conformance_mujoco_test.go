@@ -0,0 +1,14 @@
+//go:build mujoco
+// +build mujoco
+
+package envtest
+
+import "github.com/samuelfneumann/golearn/environment/envconfig"
+
+// Add the MuJoCo environments to the conformance tests
+func init() {
+	builtIn = append(builtIn,
+		builtInTest{envconfig.Hopper, envconfig.Hop, []bool{true}},
+		builtInTest{envconfig.Reacher, envconfig.Reach, []bool{true}},
+	)
+}
@@ -0,0 +1,134 @@
+package envtest
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+
+	"github.com/fogleman/gg"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/envconfig"
+)
+
+// builtInTest describes a built-in environment and task to run the
+// conformance test suite on
+type builtInTest struct {
+	env        envconfig.EnvName
+	task       envconfig.TaskName
+	continuous []bool
+}
+
+// builtIn lists the built-in environments and tasks to test. Those
+// which require MuJoCo are only added with the mujoco build tag.
+var builtIn = []builtInTest{
+	{envconfig.Gridworld, envconfig.Goal, []bool{false}},
+	{envconfig.Maze, envconfig.Goal, []bool{false}},
+	{envconfig.MountainCar, envconfig.Goal, []bool{false, true}},
+	{envconfig.Cartpole, envconfig.Balance, []bool{false, true}},
+	{envconfig.Pendulum, envconfig.SwingUp, []bool{false, true}},
+	{envconfig.Acrobot, envconfig.SwingUp, []bool{false, true}},
+	{envconfig.LunarLander, envconfig.Land, []bool{false, true}},
+	{envconfig.Lights, envconfig.Match, []bool{false}},
+	{envconfig.Breakout, envconfig.Play, []bool{false}},
+	{envconfig.Freeway, envconfig.Play, []bool{false}},
+	{envconfig.Asterix, envconfig.Play, []bool{false}},
+	{envconfig.SpaceInvaders, envconfig.Play, []bool{false}},
+}
+
+// TestBuiltIn runs the conformance test suite on every built-in
+// environment and task
+func TestBuiltIn(t *testing.T) {
+	const (
+		cutoff   = 50
+		discount = 0.99
+		seed     = 1
+		steps    = 250
+	)
+
+	for _, test := range builtIn {
+		for _, continuous := range test.continuous {
+			name := fmt.Sprintf("%v/%v", test.env, test.task)
+			if continuous {
+				name += "/Continuous"
+			}
+
+			c := envconfig.NewConfig(test.env, test.task, continuous, cutoff,
+				discount, false)
+			t.Run(name, func(t *testing.T) {
+				Run(t, c, seed, steps)
+			})
+		}
+	}
+}
+
+// TestPixels tests that environments which can be drawn render a frame
+// filling the area given by their natural drawing size, without
+// modifying the drawing context unless the frame is saved
+func TestPixels(t *testing.T) {
+	const scale, margin = 10, 5
+
+	tests := []struct {
+		env           envconfig.EnvName
+		task          envconfig.TaskName
+		width, height int // Natural drawing size of the environment
+	}{
+		{envconfig.Cartpole, envconfig.Balance, 24, 12},
+		{envconfig.Gridworld, envconfig.Goal, 5, 5},
+		{envconfig.Maze, envconfig.Goal, 5, 5},
+	}
+
+	for _, test := range tests {
+		c := envconfig.NewConfig(test.env, test.task, false, 50, 0.99, false)
+		e, _ := create(t, c, 1)
+		pixels, ok := environment.Unwrap(e, func(
+			e environment.Environment) bool {
+			_, ok := e.(environment.PixelEnvironment)
+			return ok
+		}).(environment.PixelEnvironment)
+		if !ok {
+			t.Errorf("%v: environment %T cannot be drawn", test.env, e)
+			continue
+		}
+
+		width, height := scale*test.width, scale*test.height
+		dc := gg.NewContext(width+2*margin, height+2*margin)
+		img := pixels.Pixels(scale, *dc, false)
+		if bounds := img.Bounds(); bounds != dc.Image().Bounds() {
+			t.Errorf("%v: bounds: have(%v) want(%v)", test.env, bounds,
+				dc.Image().Bounds())
+			continue
+		}
+
+		// The frame should be opaque and not a single colour inside its
+		// natural drawing size, and transparent outside of it
+		colours := make(map[color.RGBA]bool)
+		for x := 0; x < width+2*margin; x++ {
+			for y := 0; y < height+2*margin; y++ {
+				pixel := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				if x < width && y < height {
+					if pixel.A != 255 {
+						t.Fatalf("%v: pixel (%v, %v) is not opaque: %v",
+							test.env, x, y, pixel)
+					}
+					colours[pixel] = true
+				} else if (x > width+1 || y > height+1) && pixel.A != 0 {
+					t.Fatalf("%v: pixel (%v, %v) outside the frame is drawn: "+
+						"%v", test.env, x, y, pixel)
+				}
+			}
+		}
+		if len(colours) < 2 {
+			t.Errorf("%v: frame is blank: %v", test.env, colours)
+		}
+
+		if _, _, _, a := dc.Image().At(0, 0).RGBA(); a != 0 {
+			t.Errorf("%v: unsaved frame modified the drawing context",
+				test.env)
+		}
+		pixels.Pixels(scale, *dc, true)
+		if _, _, _, a := dc.Image().At(0, 0).RGBA(); a == 0 {
+			t.Errorf("%v: saved frame not drawn on the drawing context",
+				test.env)
+		}
+	}
+}
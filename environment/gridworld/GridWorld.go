@@ -3,7 +3,10 @@ package gridworld
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 
+	"github.com/fogleman/gg"
 	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/matutils"
@@ -117,6 +120,11 @@ func (g *GridWorld) NextObs(action *mat.VecDense) *mat.VecDense {
 // Step takes an action in the environemnt
 func (g *GridWorld) Step(action *mat.VecDense) (timestep.TimeStep, bool,
 	error) {
+	if !g.ActionSpec().Contains(action) {
+		return timestep.TimeStep{}, true, fmt.Errorf("step: illegal "+
+			"action %v", action.RawVector().Data)
+	}
+
 	newPosition := g.NextObs(action)
 	g.position = g.vToInd(newPosition)
 
@@ -252,3 +260,55 @@ func (g *GridWorld) ActionSpec() environment.Spec {
 	return environment.NewSpec(shape, environment.Action, min, max,
 		environment.Discrete)
 }
+
+// Pixels draws the current state of the GridWorld, with each cell of
+// the grid drawn as a scale x scale square. The agent is drawn in
+// blue and, if the GridWorld's Task is a Goal, goal cells are drawn
+// in green. The cell at (x, y) = (0, 0) is drawn in the bottom left
+// corner.
+//
+// See environment.PixelEnvironment for more details.
+func (g *GridWorld) Pixels(scale float64, dc gg.Context,
+	save bool) image.Image {
+	ctx := environment.PixelContext(dc, save)
+
+	// Draw background
+	ctx.SetColor(color.White)
+	ctx.DrawRectangle(0, 0, scale*float64(g.c), scale*float64(g.r))
+	ctx.Fill()
+
+	// drawCell draws the cell at coordinates (x, y)
+	drawCell := func(x, y int) {
+		ctx.DrawRectangle(scale*float64(x), scale*float64(g.r-1-y), scale,
+			scale)
+		ctx.Fill()
+	}
+
+	// Draw the goals
+	if goal, ok := g.Task.(*Goal); ok {
+		ctx.SetRGB255(0, 170, 0)
+		rows, _ := goal.goals.Dims()
+		for i := 0; i < rows; i++ {
+			drawCell(int(goal.goals.At(i, 0)), int(goal.goals.At(i, 1)))
+		}
+	}
+
+	// Draw the agent
+	ctx.SetRGB255(0, 0, 204)
+	drawCell(g.Coordinates())
+
+	// Draw the grid lines
+	ctx.SetColor(color.Gray{Y: 128})
+	ctx.SetLineWidth(1)
+	for x := 0; x <= g.c; x++ {
+		ctx.DrawLine(scale*float64(x), 0, scale*float64(x),
+			scale*float64(g.r))
+	}
+	for y := 0; y <= g.r; y++ {
+		ctx.DrawLine(0, scale*float64(y), scale*float64(g.c),
+			scale*float64(y))
+	}
+	ctx.Stroke()
+
+	return ctx.Image()
+}
@@ -147,6 +147,7 @@ func (g *Goal) Max() float64 {
 func (g *Goal) End(t *timestep.TimeStep) bool {
 	if g.AtGoal(t.Observation) {
 		t.StepType = timestep.Last
+		t.SetEnd(timestep.TerminalStateReached)
 		return true
 	}
 
@@ -0,0 +1,197 @@
+// Package lights implements an environment in which multiple discrete
+// controls must be set simultaneously. It is useful for testing agents
+// which support environment.MultiDiscrete actions.
+package lights
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/matutils"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Lights implements an environment with a panel of lights, each of
+// which has a number of brightness levels. At the start of each
+// episode, a target brightness level is chosen uniformly randomly for
+// each light. On each step, the agent sets the brightness of every
+// light at once, so that actions are environment.MultiDiscrete with
+// one dimension per light. Action dimension i determines the
+// brightness level of light i.
+//
+// Observations are the concatenated one-hot encodings of the target
+// brightness level of each light. The reward on each step is
+// (m / n) - 1, where m is the number of lights set to their target
+// brightness and n is the total number of lights. The episode
+// terminates once all lights are set to their target brightness, or
+// is ended by an environment.Ender.
+type Lights struct {
+	environment.Ender
+	lights      int
+	levels      int
+	discount    float64
+	target      []int
+	rng         *rand.Rand
+	currentStep ts.TimeStep
+}
+
+// New returns a new Lights environment with the given number of lights,
+// each of which has the given number of brightness levels. Episodes
+// which do not terminate are ended by e.
+func New(lights, levels int, e environment.Ender, discount float64,
+	seed uint64) (environment.Environment, ts.TimeStep, error) {
+	if lights < 1 {
+		return nil, ts.TimeStep{}, fmt.Errorf("new: must have at least " +
+			"one light")
+	}
+	if levels < 2 {
+		return nil, ts.TimeStep{}, fmt.Errorf("new: lights must have at " +
+			"least two brightness levels")
+	}
+
+	l := &Lights{
+		Ender:    e,
+		lights:   lights,
+		levels:   levels,
+		discount: discount,
+		target:   make([]int, lights),
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+
+	step, err := l.Reset()
+	if err != nil {
+		return nil, ts.TimeStep{}, fmt.Errorf("new: %v", err)
+	}
+	return l, step, nil
+}
+
+// Target returns the target brightness level of each light in the
+// current episode
+func (l *Lights) Target() []int {
+	target := make([]int, len(l.target))
+	copy(target, l.target)
+	return target
+}
+
+// Start chooses a new target brightness level for each light and
+// returns the starting state observation
+func (l *Lights) Start() *mat.VecDense {
+	for i := range l.target {
+		l.target[i] = l.rng.Intn(l.levels)
+	}
+	return l.observe()
+}
+
+// GetReward returns the reward for setting the brightness of the
+// lights to action a when the target brightness levels are given by
+// the state observation
+func (l *Lights) GetReward(state, a, _ mat.Vector) float64 {
+	return float64(l.matches(state, a))/float64(l.lights) - 1
+}
+
+// AtGoal returns whether the argument state is a goal state. The goal
+// of Lights is reached by actions rather than states, so there are no
+// goal states.
+func (l *Lights) AtGoal(mat.Matrix) bool {
+	return false
+}
+
+// Reset resets the environment between episodes
+func (l *Lights) Reset() (ts.TimeStep, error) {
+	l.currentStep = ts.New(ts.First, 0, l.discount, l.Start(), 0)
+	return l.currentStep, nil
+}
+
+// Step takes one environmental step given some action
+func (l *Lights) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
+	if !l.ActionSpec().Contains(a) {
+		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v",
+			a.RawVector().Data)
+	}
+
+	state := l.currentStep.Observation
+	obs := l.observe()
+	reward := l.GetReward(state, a, obs)
+	step := ts.New(ts.Mid, reward, l.discount, obs, l.currentStep.Number+1)
+
+	// The episode terminates once all lights match their targets,
+	// otherwise the Ender decides whether the episode ends
+	var last bool
+	if l.matches(state, a) == l.lights {
+		step.StepType = ts.Last
+		step.SetEnd(ts.TerminalStateReached)
+		last = true
+	} else {
+		last = l.End(&step)
+	}
+
+	l.currentStep = step
+	return step, last, nil
+}
+
+// matches returns the number of lights which action a sets to the
+// target brightness levels given by the state observation
+func (l *Lights) matches(state, a mat.Vector) int {
+	matches := 0
+	for i := 0; i < l.lights; i++ {
+		if state.AtVec(i*l.levels+int(a.AtVec(i))) == 1.0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// observe returns the observation of the current target brightness
+// levels
+func (l *Lights) observe() *mat.VecDense {
+	obs := mat.NewVecDense(l.lights*l.levels, nil)
+	for i, level := range l.target {
+		obs.SetVec(i*l.levels+level, 1.0)
+	}
+	return obs
+}
+
+// CurrentTimeStep returns the last TimeStep that occurred in the
+// environment
+func (l *Lights) CurrentTimeStep() ts.TimeStep {
+	return l.currentStep
+}
+
+// DiscountSpec returns the discount specification of the environment
+func (l *Lights) DiscountSpec() environment.Spec {
+	shape := mat.NewVecDense(1, nil)
+	bound := mat.NewVecDense(1, []float64{l.discount})
+
+	return environment.NewSpec(shape, environment.Discount, bound, bound,
+		environment.Continuous)
+}
+
+// ObservationSpec returns the observation specification of the
+// environment
+func (l *Lights) ObservationSpec() environment.Spec {
+	features := l.lights * l.levels
+	shape := mat.NewVecDense(features, nil)
+	lowerBound := mat.NewVecDense(features, nil)
+	upperBound := matutils.VecOnes(features)
+
+	return environment.NewSpec(shape, environment.Observation, lowerBound,
+		upperBound, environment.Continuous)
+}
+
+// ActionSpec returns the action specification of the environment
+func (l *Lights) ActionSpec() environment.Spec {
+	levels := make([]int, l.lights)
+	for i := range levels {
+		levels[i] = l.levels
+	}
+
+	return environment.NewMultiDiscreteSpec(environment.Action, levels...)
+}
+
+// String returns a string representation of the environment
+func (l *Lights) String() string {
+	return fmt.Sprintf("Lights | Lights: %v | Levels: %v", l.lights,
+		l.levels)
+}
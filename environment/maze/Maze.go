@@ -3,7 +3,11 @@ package maze
 
 import (
 	"fmt"
+	"image"
+	"image/color"
+	"math"
 
+	"github.com/fogleman/gg"
 	env "github.com/samuelfneumann/golearn/environment"
 	ts "github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/floatutils"
@@ -75,9 +79,9 @@ func New(t env.Task, rows, cols int, init gomaze.Initer,
 
 // Step takes a single environmental step given some action
 func (m *Maze) Step(action *mat.VecDense) (ts.TimeStep, bool, error) {
-	if action.Len() > 1 {
-		return ts.TimeStep{}, false, fmt.Errorf("step: actions must be " +
-			"1-dimensional")
+	if !m.ActionSpec().Contains(action) {
+		return ts.TimeStep{}, false, fmt.Errorf("step: illegal action %v",
+			action.RawVector().Data)
 	}
 
 	// Calculate the next position given the action
@@ -146,13 +150,15 @@ func (m *Maze) ObservationSpec() env.Spec {
 		env.Discrete)
 }
 
-// DiscountSpec returns the discount specification of the environment
+// DiscountSpec returns the discount specification of the environment.
+// The discount is continuous, so that fractional discount factors are
+// contained in the specification.
 func (m *Maze) DiscountSpec() env.Spec {
 	shape := mat.NewVecDense(1, nil)
 	lowerBound := mat.NewVecDense(1, []float64{m.discount})
 
 	return env.NewSpec(shape, env.Discount, lowerBound, lowerBound,
-		env.Discrete)
+		env.Continuous)
 }
 
 // String returns a string representation of the environment
@@ -160,6 +166,62 @@ func (m *Maze) String() string {
 	return m.maze.String()
 }
 
+// Pixels draws the current state of the maze, with each cell of the
+// maze drawn as a scale x scale square. The top left cell of the maze
+// is drawn in the top left corner. Walls are drawn in black, the
+// agent in blue, and, if the Maze's Task is a Goal, goal cells in
+// green.
+//
+// See environment.PixelEnvironment for more details.
+func (m *Maze) Pixels(scale float64, dc gg.Context, save bool) image.Image {
+	ctx := env.PixelContext(dc, save)
+	rows, cols := m.maze.Rows(), m.maze.Cols()
+
+	// Draw background
+	ctx.SetColor(color.White)
+	ctx.DrawRectangle(0, 0, scale*float64(cols), scale*float64(rows))
+	ctx.Fill()
+
+	// Draw the goals
+	if goal, ok := m.Task.(*Goal); ok {
+		ctx.SetRGB255(0, 170, 0)
+		for i := range goal.goalCol {
+			ctx.DrawRectangle(scale*float64(goal.goalCol[i]),
+				scale*float64(goal.goalRow[i]), scale, scale)
+		}
+		ctx.Fill()
+	}
+
+	// Draw the agent
+	obs := m.currentStep.Observation
+	for i := 0; i < obs.Len(); i++ {
+		if obs.AtVec(i) != 0 {
+			ctx.SetRGB255(0, 0, 204)
+			ctx.DrawCircle(scale*(float64(i%cols)+0.5),
+				scale*(float64(i/cols)+0.5), scale*0.3)
+			ctx.Fill()
+		}
+	}
+
+	// Draw the outer walls, then the east and south walls of each cell
+	ctx.SetColor(color.Black)
+	ctx.SetLineWidth(math.Max(1, scale*0.1))
+	ctx.SetLineCap(gg.LineCapSquare)
+	ctx.DrawRectangle(0, 0, scale*float64(cols), scale*float64(rows))
+	for _, cell := range m.maze.Cells() {
+		x, y := float64(cell.Col()), float64(cell.Row())
+		if !cell.CanMoveEast() {
+			ctx.DrawLine(scale*(x+1), scale*y, scale*(x+1), scale*(y+1))
+		}
+		if !cell.CanMoveSouth() {
+			ctx.DrawLine(scale*x, scale*(y+1), scale*(x+1), scale*(y+1))
+		}
+	}
+	ctx.Stroke()
+
+	return ctx.Image()
+}
+
 // validateState returns an error if the given state is invalid. The
 // rows and cols parameters are the number of rows and columns in the
 // maze.
@@ -69,8 +69,8 @@ func (s *Goal) End(t *ts.TimeStep) bool {
 	}
 
 	if s.AtGoal(t.Observation) {
-		t.SetEnd(ts.TerminalStateReached)
 		t.StepType = ts.Last
+		t.SetEnd(ts.TerminalStateReached)
 		return true
 	}
 	return false
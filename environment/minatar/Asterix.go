@@ -0,0 +1,284 @@
+package minatar
+
+import (
+	"image/color"
+
+	env "github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/intutils"
+	"golang.org/x/exp/rand"
+)
+
+const (
+	// asterixSpawnSpeed is the initial number of timesteps between
+	// entity spawns
+	asterixSpawnSpeed int = 10
+
+	// asterixMoveInterval is the initial number of timesteps between
+	// entity movements
+	asterixMoveInterval int = 5
+
+	// asterixRampInterval is the number of timesteps between
+	// difficulty increases when ramping is used
+	asterixRampInterval int = 100
+
+	// asterixGoldProb is the probability of a spawned entity being
+	// gold
+	asterixGoldProb float64 = 1.0 / 3.0
+
+	// asterixSlots is the number of rows that entities can occupy
+	asterixSlots int = 8
+)
+
+// Channels of Asterix observations
+const (
+	asterixPlayer int = iota
+	asterixEnemy
+	asterixTrail
+	asterixGold
+	asterixChannels
+)
+
+// entity is an enemy or piece of treasure in the Asterix game
+type entity struct {
+	x, y  int
+	right bool // Whether the entity moves right
+	gold  bool
+}
+
+// asterix implements the dynamics of the Asterix game
+type asterix struct {
+	rng     *rand.Rand
+	ramping bool
+
+	playerX, playerY int
+	entities         [asterixSlots]*entity
+	spawnSpeed       int
+	spawnTimer       int
+	moveSpeed        int
+	moveTimer        int
+	rampTimer        int
+	rampIndex        int
+	terminal         bool
+}
+
+// NewAsterix returns a new Asterix environment. In this game, the
+// player moves freely in all four directions on the grid. Enemies and
+// gold spawn from the sides of the grid on rows 1-8 and move
+// horizontally across the grid. A reward of +1 is given for picking up
+// gold, and the game ends if the player collides with an enemy.
+//
+// If ramping is true, the speed at which entities spawn and move
+// increases over time, making the game increasingly difficult.
+//
+// State observations consist of 4 channels:
+//
+//	Channel		Meaning
+//	  0			Player
+//	  1			Enemies
+//	  2			Trail (indicating the direction of movement of entities)
+//	  3			Gold
+//
+// Actions are discrete in the set {0, 1, 2, 3, 4}:
+//
+//	Action		Meaning
+//	  0			No operation
+//	  1			Move left
+//	  2			Move up
+//	  3			Move right
+//	  4			Move down
+//
+// With probability stickyActionProb, the previous action is repeated
+// instead of the selected action.
+func NewAsterix(t env.Task, stickyActionProb, discount float64,
+	ramping bool, seed uint64) (env.Environment, ts.TimeStep, error) {
+	rng := rand.New(rand.NewSource(seed))
+	g := &asterix{rng: rng, ramping: ramping}
+
+	return newMinAtar(t, g, "Asterix", stickyActionProb, discount, rng)
+}
+
+// reset resets the game to a starting state
+func (a *asterix) reset() {
+	a.playerX, a.playerY = Cols/2, Rows/2
+	a.entities = [asterixSlots]*entity{}
+	a.spawnSpeed = asterixSpawnSpeed
+	a.spawnTimer = a.spawnSpeed
+	a.moveSpeed = asterixMoveInterval
+	a.moveTimer = a.moveSpeed
+	a.rampTimer = asterixRampInterval
+	a.rampIndex = 0
+	a.terminal = false
+}
+
+// spawnEntity spawns a new entity in a random empty row. If no row is
+// empty, no entity is spawned.
+func (a *asterix) spawnEntity() {
+	right := a.rng.Intn(2) == 0
+	gold := a.rng.Float64() < asterixGoldProb
+
+	x := Cols - 1
+	if right {
+		x = 0
+	}
+
+	slots := make([]int, 0, asterixSlots)
+	for i := range a.entities {
+		if a.entities[i] == nil {
+			slots = append(slots, i)
+		}
+	}
+	if len(slots) == 0 {
+		return
+	}
+
+	slot := slots[a.rng.Intn(len(slots))]
+	a.entities[slot] = &entity{x: x, y: slot + 1, right: right, gold: gold}
+}
+
+// collide checks for a collision between the player and the entity
+// in slot i, removing the entity if it is gold and ending the game if
+// it is an enemy. The reward for the collision is returned.
+func (a *asterix) collide(i int) float64 {
+	e := a.entities[i]
+	if e == nil || e.x != a.playerX || e.y != a.playerY {
+		return 0.0
+	}
+
+	if e.gold {
+		a.entities[i] = nil
+		return 1.0
+	}
+	a.terminal = true
+	return 0.0
+}
+
+// act takes a single step in the game
+func (a *asterix) act(act action) float64 {
+	reward := 0.0
+	if a.terminal {
+		return reward
+	}
+
+	// Spawn an entity if the timer is up
+	if a.spawnTimer == 0 {
+		a.spawnEntity()
+		a.spawnTimer = a.spawnSpeed
+	}
+
+	// Move the player
+	switch act {
+	case left:
+		a.playerX = intutils.Max(0, a.playerX-1)
+	case right:
+		a.playerX = intutils.Min(Cols-1, a.playerX+1)
+	case up:
+		a.playerY = intutils.Max(1, a.playerY-1)
+	case down:
+		a.playerY = intutils.Min(asterixSlots, a.playerY+1)
+	}
+
+	// Check for collisions with the player's new position
+	for i := range a.entities {
+		reward += a.collide(i)
+	}
+
+	// Move the entities
+	if a.moveTimer == 0 {
+		a.moveTimer = a.moveSpeed
+		for i, e := range a.entities {
+			if e == nil {
+				continue
+			}
+
+			if e.right {
+				e.x++
+			} else {
+				e.x--
+			}
+
+			if e.x < 0 || e.x > Cols-1 {
+				a.entities[i] = nil
+				continue
+			}
+			reward += a.collide(i)
+		}
+	}
+
+	// Update timers
+	a.spawnTimer--
+	a.moveTimer--
+
+	// Increase the difficulty if the ramp interval has elapsed
+	if a.ramping && (a.spawnSpeed > 1 || a.moveSpeed > 1) {
+		if a.rampTimer >= 0 {
+			a.rampTimer--
+		} else {
+			if a.moveSpeed > 1 && a.rampIndex%2 == 1 {
+				a.moveSpeed--
+			}
+			if a.spawnSpeed > 1 {
+				a.spawnSpeed--
+			}
+			a.rampIndex++
+			a.rampTimer = asterixRampInterval
+		}
+	}
+
+	return reward
+}
+
+// over returns whether the game is over
+func (a *asterix) over() bool {
+	return a.terminal
+}
+
+// timeUp returns whether the game's internal time limit was reached.
+// Asterix has no internal time limit.
+func (a *asterix) timeUp() bool {
+	return false
+}
+
+// observe fills obs with the current state observation
+func (a *asterix) observe(obs []float64) {
+	set(obs, asterixPlayer, a.playerY, a.playerX)
+	for _, e := range a.entities {
+		if e == nil {
+			continue
+		}
+
+		c := asterixEnemy
+		if e.gold {
+			c = asterixGold
+		}
+		set(obs, c, e.y, e.x)
+
+		backX := e.x + 1
+		if e.right {
+			backX = e.x - 1
+		}
+		if backX >= 0 && backX <= Cols-1 {
+			set(obs, asterixTrail, e.y, backX)
+		}
+	}
+}
+
+// channels returns the number of observation channels
+func (a *asterix) channels() int {
+	return asterixChannels
+}
+
+// actions returns the minimal action set
+func (a *asterix) actions() []action {
+	return []action{noop, left, up, right, down}
+}
+
+// colours returns the colours used to draw each channel
+func (a *asterix) colours() []color.Color {
+	return []color.Color{
+		color.RGBA{R: 80, G: 160, B: 255, A: 255}, // Player
+		color.RGBA{R: 200, G: 72, B: 72, A: 255},  // Enemy
+		color.RGBA{R: 90, G: 90, B: 90, A: 255},   // Trail
+		color.RGBA{R: 255, G: 215, B: 0, A: 255},  // Gold
+	}
+}
@@ -0,0 +1,202 @@
+package minatar
+
+import (
+	"image/color"
+
+	env "github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/intutils"
+	"golang.org/x/exp/rand"
+)
+
+// Channels of Breakout observations
+const (
+	breakoutPaddle int = iota
+	breakoutBall
+	breakoutTrail
+	breakoutBrick
+	breakoutChannels
+)
+
+// breakout implements the dynamics of the Breakout game
+type breakout struct {
+	rng *rand.Rand
+
+	ballX, ballY int
+	ballDir      int // 0: up-left, 1: up-right, 2: down-right, 3: down-left
+	lastX, lastY int
+	pos          int // Paddle position
+	bricks       grid
+	strike       bool
+	terminal     bool
+}
+
+// NewBreakout returns a new Breakout environment. In this game, the
+// player controls a paddle on the bottom row of the grid and must
+// bounce a ball to break the three rows of bricks at the top of the
+// grid. The ball travels only along diagonals, and a reward of +1 is
+// given for each brick broken. When all bricks are broken, a new set
+// of bricks is added. The game ends when the player misses the ball.
+//
+// State observations consist of 4 channels:
+//
+//	Channel		Meaning
+//	  0			Paddle
+//	  1			Ball
+//	  2			Trail (the previous position of the ball)
+//	  3			Bricks
+//
+// Actions are discrete in the set {0, 1, 2}:
+//
+//	Action		Meaning
+//	  0			No operation
+//	  1			Move paddle left
+//	  2			Move paddle right
+//
+// With probability stickyActionProb, the previous action is repeated
+// instead of the selected action.
+func NewBreakout(t env.Task, stickyActionProb, discount float64,
+	seed uint64) (env.Environment, ts.TimeStep, error) {
+	rng := rand.New(rand.NewSource(seed))
+	g := &breakout{rng: rng}
+
+	return newMinAtar(t, g, "Breakout", stickyActionProb, discount, rng)
+}
+
+// reset resets the game to a starting state
+func (b *breakout) reset() {
+	b.ballY = 3
+	if b.rng.Intn(2) == 0 {
+		b.ballX, b.ballDir = 0, 2
+	} else {
+		b.ballX, b.ballDir = Cols-1, 3
+	}
+	b.pos = Cols / 2
+	b.bricks = grid{}
+	b.addBricks()
+	b.strike = false
+	b.lastX, b.lastY = b.ballX, b.ballY
+	b.terminal = false
+}
+
+// addBricks adds three rows of bricks to the top of the grid
+func (b *breakout) addBricks() {
+	for i := 1; i < 4; i++ {
+		for j := 0; j < Cols; j++ {
+			b.bricks[i][j] = true
+		}
+	}
+}
+
+// act takes a single step in the game
+func (b *breakout) act(a action) float64 {
+	reward := 0.0
+	if b.terminal {
+		return reward
+	}
+
+	// Move the paddle
+	if a == left {
+		b.pos = intutils.Max(0, b.pos-1)
+	} else if a == right {
+		b.pos = intutils.Min(Cols-1, b.pos+1)
+	}
+
+	// Move the ball
+	b.lastX, b.lastY = b.ballX, b.ballY
+	var newX, newY int
+	switch b.ballDir {
+	case 0:
+		newX, newY = b.ballX-1, b.ballY-1
+	case 1:
+		newX, newY = b.ballX+1, b.ballY-1
+	case 2:
+		newX, newY = b.ballX+1, b.ballY+1
+	case 3:
+		newX, newY = b.ballX-1, b.ballY+1
+	}
+
+	// Bounce off walls
+	strikeToggle := false
+	if newX < 0 || newX > Cols-1 {
+		newX = intutils.Min(Cols-1, intutils.Max(0, newX))
+		b.ballDir = [4]int{1, 0, 3, 2}[b.ballDir]
+	}
+
+	if newY < 0 {
+		// Bounce off ceiling
+		newY = 0
+		b.ballDir = [4]int{3, 2, 1, 0}[b.ballDir]
+	} else if b.bricks[newY][newX] {
+		// Bounce off a brick, breaking it
+		strikeToggle = true
+		if !b.strike {
+			reward++
+			b.strike = true
+			b.bricks[newY][newX] = false
+			newY = b.lastY
+			b.ballDir = [4]int{3, 2, 1, 0}[b.ballDir]
+		}
+	} else if newY == Rows-1 {
+		// Ball reaches the bottom row
+		if b.bricks.count() == 0 {
+			b.addBricks()
+		}
+
+		if b.ballX == b.pos {
+			b.ballDir = [4]int{3, 2, 1, 0}[b.ballDir]
+			newY = b.lastY
+		} else if newX == b.pos {
+			b.ballDir = [4]int{2, 3, 0, 1}[b.ballDir]
+			newY = b.lastY
+		} else {
+			b.terminal = true
+		}
+	}
+
+	if !strikeToggle {
+		b.strike = false
+	}
+
+	b.ballX, b.ballY = newX, newY
+	return reward
+}
+
+// over returns whether the game is over
+func (b *breakout) over() bool {
+	return b.terminal
+}
+
+// timeUp returns whether the game's internal time limit was reached.
+// Breakout has no internal time limit.
+func (b *breakout) timeUp() bool {
+	return false
+}
+
+// observe fills obs with the current state observation
+func (b *breakout) observe(obs []float64) {
+	set(obs, breakoutPaddle, Rows-1, b.pos)
+	set(obs, breakoutBall, b.ballY, b.ballX)
+	set(obs, breakoutTrail, b.lastY, b.lastX)
+	b.bricks.observe(obs, breakoutBrick)
+}
+
+// channels returns the number of observation channels
+func (b *breakout) channels() int {
+	return breakoutChannels
+}
+
+// actions returns the minimal action set
+func (b *breakout) actions() []action {
+	return []action{noop, left, right}
+}
+
+// colours returns the colours used to draw each channel
+func (b *breakout) colours() []color.Color {
+	return []color.Color{
+		color.RGBA{R: 230, G: 230, B: 230, A: 255}, // Paddle
+		color.RGBA{R: 255, G: 215, B: 0, A: 255},   // Ball
+		color.RGBA{R: 128, G: 108, B: 0, A: 255},   // Trail
+		color.RGBA{R: 200, G: 72, B: 72, A: 255},   // Brick
+	}
+}
@@ -0,0 +1,234 @@
+package minatar
+
+import (
+	"image/color"
+
+	env "github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/intutils"
+	"golang.org/x/exp/rand"
+)
+
+const (
+	// FreewayPlayerSpeed is the number of timesteps the chicken must
+	// wait between moves
+	FreewayPlayerSpeed int = 3
+
+	// FreewayTimeLimit is the number of timesteps in a game of Freeway
+	FreewayTimeLimit int = 2500
+
+	// freewayCars is the number of cars in Freeway, one per lane
+	freewayCars int = 8
+
+	// freewayMaxSpeed is the slowest speed of a car. Cars move
+	// once every speed timesteps, so higher values are slower.
+	freewayMaxSpeed int = 5
+)
+
+// Channels of Freeway observations
+const (
+	freewayChicken int = iota
+	freewayCar
+	freewaySpeed1   // Channels freewaySpeed1 - freewaySpeed5 are car trails
+	freewayChannels = freewaySpeed1 + freewayMaxSpeed
+)
+
+// car is a single car in the Freeway game
+type car struct {
+	x, y  int
+	timer int // Timesteps until the car moves
+	speed int // Signed speed, the sign denotes direction
+}
+
+// freeway implements the dynamics of the Freeway game
+type freeway struct {
+	rng *rand.Rand
+
+	chickenY       int
+	moveTimer      int
+	terminateTimer int
+	cars           [freewayCars]car
+	terminal       bool
+}
+
+// NewFreeway returns a new Freeway environment. In this game, the
+// player controls a chicken which starts at the bottom of the grid
+// and must cross a road of 8 lanes of traffic to reach the top of the
+// grid. A reward of +1 is given each time the chicken reaches the top
+// of the grid, after which the chicken is returned to the bottom of
+// the grid and the speeds and directions of the cars are randomized.
+// If the chicken is hit by a car, it is returned to the bottom of the
+// grid. The chicken can only move once every FreewayPlayerSpeed
+// timesteps. The game is cutoff after FreewayTimeLimit timesteps.
+//
+// State observations consist of 7 channels:
+//
+//	Channel		Meaning
+//	  0			Chicken
+//	  1			Cars
+//	  2-6		Car trails, indicating car speeds 1-5
+//
+// Actions are discrete in the set {0, 1, 2}:
+//
+//	Action		Meaning
+//	  0			No operation
+//	  1			Move chicken up
+//	  2			Move chicken down
+//
+// With probability stickyActionProb, the previous action is repeated
+// instead of the selected action.
+func NewFreeway(t env.Task, stickyActionProb, discount float64,
+	seed uint64) (env.Environment, ts.TimeStep, error) {
+	rng := rand.New(rand.NewSource(seed))
+	g := &freeway{rng: rng}
+
+	return newMinAtar(t, g, "Freeway", stickyActionProb, discount, rng)
+}
+
+// reset resets the game to a starting state
+func (f *freeway) reset() {
+	f.chickenY = Rows - 1
+	f.moveTimer = FreewayPlayerSpeed
+	f.terminateTimer = FreewayTimeLimit
+	for i := range f.cars {
+		f.cars[i].x = 0
+		f.cars[i].y = i + 1
+	}
+	f.randomizeCars()
+	f.terminal = false
+}
+
+// randomizeCars randomizes the speeds and directions of all cars
+func (f *freeway) randomizeCars() {
+	for i := range f.cars {
+		speed := f.rng.Intn(freewayMaxSpeed) + 1
+		if f.rng.Intn(2) == 0 {
+			speed = -speed
+		}
+		f.cars[i].timer = intutils.Abs(speed)
+		f.cars[i].speed = speed
+	}
+}
+
+// act takes a single step in the game
+func (f *freeway) act(a action) float64 {
+	reward := 0.0
+	if f.terminal {
+		return reward
+	}
+
+	// Move the chicken
+	if a == up && f.moveTimer == 0 {
+		f.moveTimer = FreewayPlayerSpeed
+		f.chickenY = intutils.Max(0, f.chickenY-1)
+	} else if a == down && f.moveTimer == 0 {
+		f.moveTimer = FreewayPlayerSpeed
+		f.chickenY = intutils.Min(Rows-1, f.chickenY+1)
+	}
+
+	// Check if the chicken crossed the road
+	if f.chickenY == 0 {
+		reward++
+		f.chickenY = Rows - 1
+		f.randomizeCars()
+	}
+
+	// Move the cars
+	for i := range f.cars {
+		c := &f.cars[i]
+		if c.x == Cols/2-1 && c.y == f.chickenY {
+			f.chickenY = Rows - 1
+		}
+
+		if c.timer == 0 {
+			c.timer = intutils.Abs(c.speed)
+			if c.speed > 0 {
+				c.x++
+			} else {
+				c.x--
+			}
+
+			if c.x < 0 {
+				c.x = Cols - 1
+			} else if c.x > Cols-1 {
+				c.x = 0
+			}
+
+			if c.x == Cols/2-1 && c.y == f.chickenY {
+				f.chickenY = Rows - 1
+			}
+		} else {
+			c.timer--
+		}
+	}
+
+	// Update timers
+	if f.moveTimer > 0 {
+		f.moveTimer--
+	}
+	f.terminateTimer--
+	if f.terminateTimer < 0 {
+		f.terminal = true
+	}
+
+	return reward
+}
+
+// over returns whether the game is over. Freeway can only end by
+// reaching its internal time limit, so over always returns false.
+func (f *freeway) over() bool {
+	return false
+}
+
+// timeUp returns whether the game's internal time limit was reached
+func (f *freeway) timeUp() bool {
+	return f.terminal
+}
+
+// observe fills obs with the current state observation
+func (f *freeway) observe(obs []float64) {
+	set(obs, freewayChicken, f.chickenY, Cols/2-1)
+	for _, c := range f.cars {
+		set(obs, freewayCar, c.y, c.x)
+
+		// Draw the car's trail, which indicates its speed
+		backX := c.x + 1
+		if c.speed > 0 {
+			backX = c.x - 1
+		}
+		if backX < 0 {
+			backX = Cols - 1
+		} else if backX > Cols-1 {
+			backX = 0
+		}
+		trail := freewaySpeed1 + intutils.Abs(c.speed) - 1
+		set(obs, trail, c.y, backX)
+	}
+}
+
+// channels returns the number of observation channels
+func (f *freeway) channels() int {
+	return freewayChannels
+}
+
+// actions returns the minimal action set
+func (f *freeway) actions() []action {
+	return []action{noop, up, down}
+}
+
+// colours returns the colours used to draw each channel
+func (f *freeway) colours() []color.Color {
+	colours := []color.Color{
+		color.RGBA{R: 252, G: 252, B: 84, A: 255}, // Chicken
+		color.RGBA{R: 200, G: 72, B: 72, A: 255},  // Car
+	}
+
+	// Trails are drawn darker for slower cars
+	for i := 0; i < freewayMaxSpeed; i++ {
+		shade := uint8(200 - 30*i)
+		colours = append(colours, color.RGBA{R: shade / 2, G: shade / 2,
+			B: shade, A: 255})
+	}
+
+	return colours
+}
@@ -0,0 +1,408 @@
+// Package minatar implements small, MinAtar-style pixel games. Each
+// game is played on a 10 x 10 grid, and state observations are
+// multi-channel binary images of this grid.
+//
+// The games in this package are adapted from the MinAtar testbed:
+// https://github.com/kenjyoung/MinAtar
+package minatar
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/fogleman/gg"
+	env "github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// Rows and Cols are the dimensions of the grid that all games
+	// are played on
+	Rows int = 10
+	Cols int = 10
+
+	// DefaultStickyActionProb is the default probability of repeating
+	// the previous action instead of the action selected
+	DefaultStickyActionProb float64 = 0.1
+)
+
+// action is an action in the full action set of all games
+type action int
+
+// The full action set of all games. Each game uses only a subset of
+// these actions, called its minimal action set.
+const (
+	noop action = iota
+	left
+	up
+	right
+	down
+	fire
+)
+
+// String returns the string representation of an action
+func (a action) String() string {
+	switch a {
+	case left:
+		return "left"
+	case up:
+		return "up"
+	case right:
+		return "right"
+	case down:
+		return "down"
+	case fire:
+		return "fire"
+	default:
+		return "noop"
+	}
+}
+
+// game implements the dynamics of a single MinAtar-style game
+type game interface {
+	// reset resets the game to a starting state
+	reset()
+
+	// act takes a single step in the game and returns the reward
+	// for the step
+	act(a action) float64
+
+	// over returns whether the game has reached a terminal state
+	over() bool
+
+	// timeUp returns whether the game has reached its own internal
+	// time limit. Games without an internal time limit always return
+	// false.
+	timeUp() bool
+
+	// observe fills obs with the current observation of the game.
+	// The observation has channels() * Rows * Cols elements, and
+	// the element for channel c at row i and column j is stored at
+	// index (c * Rows * Cols) + (i * Cols) + j.
+	observe(obs []float64)
+
+	// channels returns the number of channels in observations
+	channels() int
+
+	// actions returns the minimal action set of the game
+	actions() []action
+
+	// colours returns the colour to draw each channel with
+	colours() []color.Color
+}
+
+// MinAtar implements a MinAtar-style game environment. A game is
+// played on a grid of Rows rows and Cols columns, and different
+// objects in the game (e.g. the player, enemies, bullets) are
+// represented in separate channels of this grid.
+//
+// State observations are binary vectors of length Channels() * Rows
+// * Cols, representing the grid in channel-major order. That is,
+// the feature for channel c at row i and column j of the grid is
+// located at index (c * Rows * Cols) + (i * Cols) + j. Observations
+// can therefore be reshaped into a (Channels(), Rows, Cols) image.
+//
+// Actions are discrete in the set {0, 1, ..., N-1}, where N is the
+// number of actions in the minimal action set of the game being
+// played. Actions outside this range will cause an error to be
+// returned. The meanings of actions are outlined in the constructor
+// of each game.
+//
+// Sticky actions are used to introduce stochasticity into the games.
+// With probability StickyActionProb() the environment ignores the
+// selected action and instead repeats the previous action taken.
+//
+// The MinAtar environment must be used with the Play task, which
+// rewards the agent with the score obtained in the game.
+//
+// MinAtar satisfies the environment.PixelEnvironment and
+// environment.ImageEnvironment interfaces.
+type MinAtar struct {
+	env.Task
+	game
+
+	name             string
+	rng              *rand.Rand
+	stickyActionProb float64
+	lastAction       action
+	lastReward       float64
+
+	discount    float64
+	currentStep ts.TimeStep
+}
+
+// newMinAtar returns a new MinAtar environment which plays game g
+func newMinAtar(t env.Task, g game, name string, stickyActionProb,
+	discount float64, rng *rand.Rand) (env.Environment, ts.TimeStep,
+	error) {
+	if stickyActionProb < 0 || stickyActionProb > 1 {
+		return nil, ts.TimeStep{}, fmt.Errorf("sticky action probability "+
+			"must be in [0, 1] \n\thave(%v)", stickyActionProb)
+	}
+
+	play, ok := t.(*Play)
+	if !ok {
+		return nil, ts.TimeStep{}, fmt.Errorf("minatar games can only be "+
+			"used with the Play task \n\thave(%T)", t)
+	}
+
+	m := &MinAtar{
+		Task:             t,
+		game:             g,
+		name:             name,
+		rng:              rng,
+		stickyActionProb: stickyActionProb,
+		discount:         discount,
+	}
+	play.register(m)
+
+	step, err := m.Reset()
+	if err != nil {
+		return nil, ts.TimeStep{}, err
+	}
+
+	return m, step, nil
+}
+
+// Channels returns the number of channels in state observations
+func (m *MinAtar) Channels() int {
+	return m.channels()
+}
+
+// Rows returns the number of rows in the game grid
+func (m *MinAtar) Rows() int {
+	return Rows
+}
+
+// Cols returns the number of columns in the game grid
+func (m *MinAtar) Cols() int {
+	return Cols
+}
+
+// StickyActionProb returns the probability of repeating the previous
+// action instead of the selected action.
+func (m *MinAtar) StickyActionProb() float64 {
+	return m.stickyActionProb
+}
+
+// CurrentTimeStep returns the last TimeStep that occurred in the
+// environment
+func (m *MinAtar) CurrentTimeStep() ts.TimeStep {
+	return m.currentStep
+}
+
+// Reset resets the environment and returns the first TimeStep of the
+// next episode
+func (m *MinAtar) Reset() (ts.TimeStep, error) {
+	m.lastAction = noop
+	m.lastReward = 0
+
+	obs := m.Start()
+	if obs.Len() != m.ObservationSpec().Shape.Len() {
+		return ts.TimeStep{}, fmt.Errorf("reset: invalid starting "+
+			"observation length \n\twant(%v) \n\thave(%v)",
+			m.ObservationSpec().Shape.Len(), obs.Len())
+	}
+
+	step := ts.New(ts.First, 0, m.discount, obs, 0)
+	m.currentStep = step
+
+	return step, nil
+}
+
+// Step takes one environmental step given action a and returns the
+// next TimeStep and whether or not that TimeStep is the last in the
+// episode.
+func (m *MinAtar) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
+	minimalActions := m.actions()
+	if !m.ActionSpec().Contains(a) {
+		return ts.TimeStep{}, true, fmt.Errorf("step: illegal action %v "+
+			"∉ [0, %v]", a.RawVector().Data, len(minimalActions)-1)
+	}
+	index := int(a.AtVec(0))
+
+	// With some probability, repeat the last action
+	act := minimalActions[index]
+	if m.rng.Float64() < m.stickyActionProb {
+		act = m.lastAction
+	}
+	m.lastAction = act
+
+	state := m.currentStep.Observation
+	m.lastReward = m.act(act)
+	nextState := m.observation()
+
+	reward := m.GetReward(state, a, nextState)
+	nextStep := ts.New(ts.Mid, reward, m.discount, nextState,
+		m.currentStep.Number+1)
+	m.End(&nextStep)
+	m.currentStep = nextStep
+
+	return nextStep, nextStep.Last(), nil
+}
+
+// observation returns the current state observation of the game
+func (m *MinAtar) observation() *mat.VecDense {
+	obs := make([]float64, m.channels()*Rows*Cols)
+	m.observe(obs)
+
+	return mat.NewVecDense(len(obs), obs)
+}
+
+// ObservationSpec returns the observation specification of the
+// environment
+func (m *MinAtar) ObservationSpec() env.Spec {
+	return env.NewImageSpec(env.Observation, m.channels(), Rows, Cols, 0, 1,
+		env.Discrete)
+}
+
+// ActionSpec returns the action specification of the environment
+func (m *MinAtar) ActionSpec() env.Spec {
+	shape := mat.NewVecDense(1, nil)
+	lowerBound := mat.NewVecDense(1, []float64{0})
+	upperBound := mat.NewVecDense(1, []float64{float64(len(m.actions()) - 1)})
+
+	return env.NewSpec(shape, env.Action, lowerBound, upperBound,
+		env.Discrete)
+}
+
+// DiscountSpec returns the discount specification of the environment
+func (m *MinAtar) DiscountSpec() env.Spec {
+	shape := mat.NewVecDense(1, nil)
+	bound := mat.NewVecDense(1, []float64{m.discount})
+
+	return env.NewSpec(shape, env.Discount, bound, bound, env.Continuous)
+}
+
+// Pixels draws the current game grid, with each cell of the grid
+// drawn as a scale x scale square. Channels are drawn in order, so
+// that later channels are drawn on top of earlier channels.
+//
+// See environment.PixelEnvironment for more details.
+func (m *MinAtar) Pixels(scale float64, dc gg.Context,
+	save bool) image.Image {
+	ctx := env.PixelContext(dc, save)
+
+	// Draw background
+	ctx.SetColor(color.Black)
+	ctx.DrawRectangle(0, 0, scale*float64(Cols), scale*float64(Rows))
+	ctx.Fill()
+
+	obs := m.currentStep.Observation.RawVector().Data
+	colours := m.colours()
+	for c := 0; c < m.channels(); c++ {
+		ctx.SetColor(colours[c])
+		for i := 0; i < Rows; i++ {
+			for j := 0; j < Cols; j++ {
+				if obs[c*Rows*Cols+i*Cols+j] != 0 {
+					ctx.DrawRectangle(float64(j)*scale, float64(i)*scale,
+						scale, scale)
+				}
+			}
+		}
+		ctx.Fill()
+	}
+
+	return ctx.Image()
+}
+
+// String returns the string representation of the environment
+func (m *MinAtar) String() string {
+	actions := make([]string, len(m.actions()))
+	for i, a := range m.actions() {
+		actions[i] = a.String()
+	}
+
+	return fmt.Sprintf("MinAtar %v  |  Actions: [%v]  |  Sticky Action "+
+		"Probability: %v", m.name, strings.Join(actions, ", "),
+		m.stickyActionProb)
+}
+
+// set sets the cell at row i and column j of channel c in the
+// observation obs to 1.0
+func set(obs []float64, c, i, j int) {
+	obs[c*Rows*Cols+i*Cols+j] = 1.0
+}
+
+// grid is a Rows x Cols binary grid
+type grid [Rows][Cols]bool
+
+// count returns the number of non-zero cells in the grid
+func (g *grid) count() int {
+	n := 0
+	for i := range g {
+		for j := range g[i] {
+			if g[i][j] {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// observe sets the cells of channel c in the observation obs to the
+// values of the grid.
+func (g *grid) observe(obs []float64, c int) {
+	for i := range g {
+		for j := range g[i] {
+			if g[i][j] {
+				set(obs, c, i, j)
+			}
+		}
+	}
+}
+
+// rowCount returns the number of non-zero cells in row i of the grid
+func (g *grid) rowCount(i int) int {
+	n := 0
+	for j := range g[i] {
+		if g[i][j] {
+			n++
+		}
+	}
+	return n
+}
+
+// colCount returns the number of non-zero cells in column j of the
+// grid
+func (g *grid) colCount(j int) int {
+	n := 0
+	for i := range g {
+		if g[i][j] {
+			n++
+		}
+	}
+	return n
+}
+
+// shiftRows returns a copy of the grid with all rows shifted down by
+// n rows, or up if n is negative. Cells shifted off the grid are
+// discarded and vacated cells are set to false.
+func shiftRows(g grid, n int) grid {
+	var shifted grid
+	for i := range g {
+		if i+n >= 0 && i+n < Rows {
+			shifted[i+n] = g[i]
+		}
+	}
+	return shifted
+}
+
+// shiftCols returns a copy of the grid with all columns shifted right
+// by n columns, or left if n is negative. Cells shifted off the grid
+// are discarded and vacated cells are set to false.
+func shiftCols(g grid, n int) grid {
+	var shifted grid
+	for i := range g {
+		for j := range g[i] {
+			if j+n >= 0 && j+n < Cols {
+				shifted[i][j+n] = g[i][j]
+			}
+		}
+	}
+	return shifted
+}
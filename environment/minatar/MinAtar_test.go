@@ -0,0 +1,336 @@
+package minatar
+
+import (
+	"testing"
+
+	env "github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// constructor constructs a MinAtar game without ramping
+type constructor func(t env.Task, stickyActionProb, discount float64,
+	seed uint64) (env.Environment, ts.TimeStep, error)
+
+// games are the MinAtar games to test along with their number of
+// channels and actions
+var games = []struct {
+	name     string
+	new      constructor
+	channels int
+	actions  int
+}{
+	{"Breakout", NewBreakout, 4, 3},
+	{"Freeway", NewFreeway, 7, 3},
+	{
+		"Asterix",
+		func(t env.Task, stickyActionProb, discount float64,
+			seed uint64) (env.Environment, ts.TimeStep, error) {
+			return NewAsterix(t, stickyActionProb, discount, false, seed)
+		},
+		4,
+		5,
+	},
+	{
+		"SpaceInvaders",
+		func(t env.Task, stickyActionProb, discount float64,
+			seed uint64) (env.Environment, ts.TimeStep, error) {
+			return NewSpaceInvaders(t, stickyActionProb, discount, false,
+				seed)
+		},
+		6,
+		4,
+	},
+}
+
+// newGame returns a new game constructed by c with an episode cutoff
+// of 5000 timesteps
+func newGame(t *testing.T, c constructor, stickyActionProb float64,
+	seed uint64) (*MinAtar, ts.TimeStep) {
+	t.Helper()
+
+	e, step, err := c(NewPlay(5000), stickyActionProb, 0.99, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e.(*MinAtar), step
+}
+
+// act takes a step in m with action a
+func act(t *testing.T, m *MinAtar, a int) ts.TimeStep {
+	t.Helper()
+
+	step, _, err := m.Step(mat.NewVecDense(1, []float64{float64(a)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return step
+}
+
+// at returns the value of the observation at channel c, row i, and
+// column j
+func at(step ts.TimeStep, c, i, j int) float64 {
+	return step.Observation.AtVec(env.FlatIndex(c, i, j, Rows, Cols))
+}
+
+// TestSeed tests that games constructed with the same seed produce
+// the same episodes under the same actions
+func TestSeed(t *testing.T) {
+	for _, game := range games {
+		m1, step1 := newGame(t, game.new, DefaultStickyActionProb, 7)
+		m2, step2 := newGame(t, game.new, DefaultStickyActionProb, 7)
+		rng := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 1000; i++ {
+			if !mat.Equal(step1.Observation, step2.Observation) ||
+				step1.Reward != step2.Reward ||
+				step1.StepType != step2.StepType {
+				t.Fatalf("%v: timesteps differ at step %v: \n\thave(%v) "+
+					"\n\twant(%v)", game.name, i, step2, step1)
+			}
+
+			if step1.Last() {
+				var err error
+				if step1, err = m1.Reset(); err != nil {
+					t.Fatal(err)
+				}
+				if step2, err = m2.Reset(); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+
+			a := rng.Intn(game.actions)
+			step1, step2 = act(t, m1, a), act(t, m2, a)
+		}
+	}
+}
+
+// TestObservations tests the observation and action specifications of
+// each game and that observations are binary images within the
+// specification
+func TestObservations(t *testing.T) {
+	for _, game := range games {
+		m, step := newGame(t, game.new, DefaultStickyActionProb, 1)
+
+		if c := m.Channels(); c != game.channels {
+			t.Errorf("%v: channels: have(%v) want(%v)", game.name, c,
+				game.channels)
+		}
+		spec := m.ObservationSpec()
+		if l := spec.Shape.Len(); l != game.channels*Rows*Cols {
+			t.Errorf("%v: observation length: have(%v) want(%v)", game.name,
+				l, game.channels*Rows*Cols)
+		}
+		if spec.Cardinality != env.Discrete {
+			t.Errorf("%v: observation cardinality: have(%v) want(%v)",
+				game.name, spec.Cardinality, env.Discrete)
+		}
+		if u := m.ActionSpec().UpperBound.AtVec(0); int(u) != game.actions-1 {
+			t.Errorf("%v: action upper bound: have(%v) want(%v)", game.name,
+				u, game.actions-1)
+		}
+		if _, _, err := m.Step(mat.NewVecDense(1,
+			[]float64{float64(game.actions)})); err == nil {
+			t.Errorf("%v: expected an error for action %v", game.name,
+				game.actions)
+		}
+
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 1000; i++ {
+			obs := step.Observation.RawVector().Data
+			for _, v := range obs {
+				if v != 0 && v != 1 {
+					t.Fatalf("%v: observation is not binary: %v", game.name, v)
+				}
+			}
+			if floats.Sum(obs) == 0 {
+				t.Fatalf("%v: empty observation at step %v", game.name, i)
+			}
+
+			if step.Last() {
+				var err error
+				if step, err = m.Reset(); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			step = act(t, m, rng.Intn(game.actions))
+		}
+	}
+}
+
+// TestBreakoutNoop tests a game of Breakout in which the paddle never
+// moves. A ball starting on the left is bounced by the paddle, breaks
+// a brick on the 11th step, and then misses the paddle on the 16th
+// step. A ball starting on the right misses the paddle on the 6th
+// step.
+func TestBreakoutNoop(t *testing.T) {
+	for _, left := range []bool{true, false} {
+		// Find a seed which starts the ball on the correct side
+		var m *MinAtar
+		var step ts.TimeStep
+		for seed := uint64(0); ; seed++ {
+			m, step = newGame(t, NewBreakout, 0, seed)
+			if (at(step, breakoutBall, 3, 0) == 1) == left {
+				break
+			}
+		}
+
+		end, rewardStep := 6, -1
+		if left {
+			end, rewardStep = 16, 11
+		}
+
+		for i := 1; i <= end; i++ {
+			step = act(t, m, 0)
+
+			wantReward := 0.0
+			if i == rewardStep {
+				wantReward = 1.0
+			}
+			if step.Reward != wantReward {
+				t.Errorf("left(%v) step %v: reward: have(%v) want(%v)", left,
+					i, step.Reward, wantReward)
+			}
+			if wantLast := i == end; step.Last() != wantLast {
+				t.Fatalf("left(%v) step %v: last: have(%v) want(%v)", left, i,
+					step.Last(), wantLast)
+			}
+		}
+		if !step.TerminalEnd() {
+			t.Errorf("left(%v): ending: have(%v) want(%v)", left, step.EndType,
+				ts.TerminalStateReached)
+		}
+	}
+}
+
+// TestFreewayNoop tests that a chicken which never moves scores
+// nothing and that the game is cut off by its internal time limit
+func TestFreewayNoop(t *testing.T) {
+	m, step := newGame(t, NewFreeway, 0, 1)
+
+	for i := 1; i <= FreewayTimeLimit+1; i++ {
+		step = act(t, m, 0)
+		if step.Reward != 0 {
+			t.Fatalf("step %v: reward: have(%v) want(0)", i, step.Reward)
+		}
+		if at(step, freewayChicken, Rows-1, Cols/2-1) != 1 {
+			t.Fatalf("step %v: chicken left the bottom row", i)
+		}
+		if step.Last() != (i == FreewayTimeLimit+1) {
+			t.Fatalf("step %v: last: have(%v) want(%v)", i, step.Last(),
+				i == FreewayTimeLimit+1)
+		}
+	}
+	if !step.CutoffEnd() {
+		t.Errorf("ending: have(%v) want(%v)", step.EndType, ts.Timeout)
+	}
+}
+
+// TestAsterixIntercept tests that a player which moves into the row of
+// the first entity and waits for it scores a point if the entity is
+// gold and ends the game otherwise
+func TestAsterixIntercept(t *testing.T) {
+	// Indices of actions in the minimal action set
+	const (
+		noopIndex = 0
+		upIndex   = 2
+		downIndex = 4
+	)
+
+	outcomes := make(map[bool]bool)
+	for seed := uint64(0); seed < 10; seed++ {
+		m, step := newGame(t, games[2].new, 0, seed)
+
+		// The first entity spawns on the 11th step
+		for i := 0; i < asterixSpawnSpeed+1; i++ {
+			step = act(t, m, noopIndex)
+			if step.Reward != 0 || step.Last() {
+				t.Fatalf("seed %v step %v: unexpected reward or end", seed, i)
+			}
+		}
+		row, gold := -1, false
+		for i := 1; i <= asterixSlots; i++ {
+			for j := 0; j < Cols; j++ {
+				if at(step, asterixEnemy, i, j) == 1 {
+					row = i
+				} else if at(step, asterixGold, i, j) == 1 {
+					row, gold = i, true
+				}
+			}
+		}
+		if row < 0 {
+			t.Fatalf("seed %v: no entity spawned", seed)
+		}
+		outcomes[gold] = true
+
+		// Move into the entity's row and wait for it to reach the player
+		total := 0.0
+		for i := 0; i < 4*Cols && !step.Last() && total == 0; i++ {
+			playerY := Rows / 2
+			for r := 0; r < Rows; r++ {
+				if at(step, asterixPlayer, r, Cols/2) == 1 {
+					playerY = r
+				}
+			}
+
+			a := noopIndex
+			if playerY > row {
+				a = upIndex
+			} else if playerY < row {
+				a = downIndex
+			}
+			step = act(t, m, a)
+			total += step.Reward
+		}
+
+		if gold && (total != 1 || step.Last()) {
+			t.Errorf("seed %v: gold: have(reward=%v, last=%v) want(reward=1, "+
+				"last=false)", seed, total, step.Last())
+		} else if !gold && (total != 0 || !step.TerminalEnd()) {
+			t.Errorf("seed %v: enemy: have(reward=%v, end=%v) want(reward=0, "+
+				"end=%v)", seed, total, step.EndType, ts.TerminalStateReached)
+		}
+	}
+	if !outcomes[true] || !outcomes[false] {
+		t.Errorf("entities spawned: have(gold=%v, enemy=%v) want(both)",
+			outcomes[true], outcomes[false])
+	}
+}
+
+// TestSpaceInvadersSingleShot tests that a single shot fired from the
+// starting position destroys an alien on the 6th step, after which the
+// cannon is eventually destroyed by an alien bullet
+func TestSpaceInvadersSingleShot(t *testing.T) {
+	// Indices of actions in the minimal action set
+	const (
+		noopIndex = 0
+		fireIndex = 3
+	)
+
+	m, step := newGame(t, games[3].new, 0, 1)
+	total := 0.0
+	for i := 1; !step.Last(); i++ {
+		a := noopIndex
+		if i == 1 {
+			a = fireIndex
+		}
+		step = act(t, m, a)
+		total += step.Reward
+
+		if i == 6 && step.Reward != 1 {
+			t.Errorf("step 6: reward: have(%v) want(1)", step.Reward)
+		}
+	}
+
+	if total != 1 {
+		t.Errorf("total reward: have(%v) want(1)", total)
+	}
+	if !step.TerminalEnd() {
+		t.Errorf("ending: have(%v) want(%v)", step.EndType,
+			ts.TerminalStateReached)
+	}
+}
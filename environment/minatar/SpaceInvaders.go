@@ -0,0 +1,273 @@
+package minatar
+
+import (
+	"image/color"
+
+	env "github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"github.com/samuelfneumann/golearn/utils/intutils"
+	"golang.org/x/exp/rand"
+)
+
+const (
+	// spaceInvadersShotCoolDown is the number of timesteps the cannon
+	// must wait between shots
+	spaceInvadersShotCoolDown int = 5
+
+	// spaceInvadersMoveInterval is the initial maximum number of
+	// timesteps between alien movements
+	spaceInvadersMoveInterval int = 12
+
+	// spaceInvadersMinMoveInterval is the smallest maximum number of
+	// timesteps between alien movements that ramping can reach
+	spaceInvadersMinMoveInterval int = 6
+
+	// spaceInvadersShotInterval is the number of timesteps between
+	// alien shots
+	spaceInvadersShotInterval int = 10
+)
+
+// Channels of SpaceInvaders observations
+const (
+	spaceInvadersCannon int = iota
+	spaceInvadersAlien
+	spaceInvadersAlienLeft
+	spaceInvadersAlienRight
+	spaceInvadersFriendlyBullet
+	spaceInvadersEnemyBullet
+	spaceInvadersChannels
+)
+
+// spaceInvaders implements the dynamics of the SpaceInvaders game
+type spaceInvaders struct {
+	rng     *rand.Rand
+	ramping bool
+
+	pos             int // Cannon position
+	friendlyBullets grid
+	enemyBullets    grid
+	aliens          grid
+	alienDir        int // -1 for left, +1 for right
+	moveInterval    int
+	alienMoveTimer  int
+	alienShotTimer  int
+	rampIndex       int
+	shotTimer       int
+	terminal        bool
+}
+
+// NewSpaceInvaders returns a new SpaceInvaders environment. In this
+// game, the player controls a cannon at the bottom of the grid and
+// can shoot bullets upwards at a cluster of aliens above. The aliens
+// move across the grid, moving down one row each time they reach the
+// edge of the grid, and periodically shoot at the cannon. A reward of
+// +1 is given for each alien destroyed. The game ends when the cannon
+// is hit by an alien bullet or when the aliens reach the bottom of the
+// grid. When all aliens are destroyed, a new wave of aliens appears.
+//
+// If ramping is true, each new wave of aliens moves faster than the
+// last.
+//
+// State observations consist of 6 channels:
+//
+//	Channel		Meaning
+//	  0			Cannon
+//	  1			Aliens
+//	  2			Aliens, if aliens are moving left
+//	  3			Aliens, if aliens are moving right
+//	  4			Friendly bullets
+//	  5			Enemy bullets
+//
+// Actions are discrete in the set {0, 1, 2, 3}:
+//
+//	Action		Meaning
+//	  0			No operation
+//	  1			Move cannon left
+//	  2			Move cannon right
+//	  3			Fire
+//
+// With probability stickyActionProb, the previous action is repeated
+// instead of the selected action.
+func NewSpaceInvaders(t env.Task, stickyActionProb, discount float64,
+	ramping bool, seed uint64) (env.Environment, ts.TimeStep, error) {
+	rng := rand.New(rand.NewSource(seed))
+	g := &spaceInvaders{rng: rng, ramping: ramping}
+
+	return newMinAtar(t, g, "SpaceInvaders", stickyActionProb, discount,
+		rng)
+}
+
+// reset resets the game to a starting state
+func (s *spaceInvaders) reset() {
+	s.pos = Cols / 2
+	s.friendlyBullets = grid{}
+	s.enemyBullets = grid{}
+	s.aliens = grid{}
+	s.addAliens()
+	s.alienDir = -1
+	s.moveInterval = spaceInvadersMoveInterval
+	s.alienMoveTimer = s.moveInterval
+	s.alienShotTimer = spaceInvadersShotInterval
+	s.rampIndex = 0
+	s.shotTimer = 0
+	s.terminal = false
+}
+
+// addAliens adds a new wave of aliens to the top of the grid
+func (s *spaceInvaders) addAliens() {
+	for i := 0; i < 4; i++ {
+		for j := 2; j < 8; j++ {
+			s.aliens[i][j] = true
+		}
+	}
+}
+
+// act takes a single step in the game
+func (s *spaceInvaders) act(a action) float64 {
+	reward := 0.0
+	if s.terminal {
+		return reward
+	}
+
+	// Move or fire the cannon
+	if a == fire && s.shotTimer == 0 {
+		s.friendlyBullets[Rows-1][s.pos] = true
+		s.shotTimer = spaceInvadersShotCoolDown
+	} else if a == left {
+		s.pos = intutils.Max(0, s.pos-1)
+	} else if a == right {
+		s.pos = intutils.Min(Cols-1, s.pos+1)
+	}
+
+	// Move friendly bullets up and enemy bullets down
+	s.friendlyBullets = shiftRows(s.friendlyBullets, -1)
+	s.enemyBullets = shiftRows(s.enemyBullets, 1)
+	if s.enemyBullets[Rows-1][s.pos] {
+		s.terminal = true
+	}
+
+	// Move the aliens
+	if s.aliens[Rows-1][s.pos] {
+		s.terminal = true
+	}
+	if s.alienMoveTimer == 0 {
+		s.alienMoveTimer = intutils.Min(s.aliens.count(), s.moveInterval)
+		if (s.alienDir < 0 && s.aliens.colCount(0) > 0) ||
+			(s.alienDir > 0 && s.aliens.colCount(Cols-1) > 0) {
+			// Aliens reached the edge of the grid, move them down
+			s.alienDir = -s.alienDir
+			if s.aliens.rowCount(Rows-1) > 0 {
+				s.terminal = true
+			}
+			s.aliens = shiftRows(s.aliens, 1)
+		} else {
+			s.aliens = shiftCols(s.aliens, s.alienDir)
+		}
+
+		if s.aliens[Rows-1][s.pos] {
+			s.terminal = true
+		}
+	}
+
+	// Aliens shoot from the alien closest to the cannon
+	if s.alienShotTimer == 0 {
+		s.alienShotTimer = spaceInvadersShotInterval
+		if i, j, ok := s.nearestAlien(); ok {
+			s.enemyBullets[i][j] = true
+		}
+	}
+
+	// Destroy aliens hit by friendly bullets
+	for i := range s.aliens {
+		for j := range s.aliens[i] {
+			if s.aliens[i][j] && s.friendlyBullets[i][j] {
+				reward++
+				s.aliens[i][j] = false
+				s.friendlyBullets[i][j] = false
+			}
+		}
+	}
+
+	// Update timers
+	if s.shotTimer > 0 {
+		s.shotTimer--
+	}
+	s.alienMoveTimer--
+	s.alienShotTimer--
+
+	// Add a new wave of aliens if all aliens are destroyed
+	if s.aliens.count() == 0 {
+		if s.ramping && s.moveInterval > spaceInvadersMinMoveInterval {
+			s.moveInterval--
+			s.rampIndex++
+		}
+		s.addAliens()
+	}
+
+	return reward
+}
+
+// nearestAlien returns the row and column of the bottom-most alien in
+// the column closest to the cannon. If there are no aliens, ok is
+// false.
+func (s *spaceInvaders) nearestAlien() (i, j int, ok bool) {
+	for dist := 0; dist < Cols; dist++ {
+		for _, col := range []int{s.pos - dist, s.pos + dist} {
+			if col < 0 || col > Cols-1 {
+				continue
+			}
+			for row := Rows - 1; row >= 0; row-- {
+				if s.aliens[row][col] {
+					return row, col, true
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// over returns whether the game is over
+func (s *spaceInvaders) over() bool {
+	return s.terminal
+}
+
+// timeUp returns whether the game's internal time limit was reached.
+// SpaceInvaders has no internal time limit.
+func (s *spaceInvaders) timeUp() bool {
+	return false
+}
+
+// observe fills obs with the current state observation
+func (s *spaceInvaders) observe(obs []float64) {
+	set(obs, spaceInvadersCannon, Rows-1, s.pos)
+	s.aliens.observe(obs, spaceInvadersAlien)
+	if s.alienDir < 0 {
+		s.aliens.observe(obs, spaceInvadersAlienLeft)
+	} else {
+		s.aliens.observe(obs, spaceInvadersAlienRight)
+	}
+	s.friendlyBullets.observe(obs, spaceInvadersFriendlyBullet)
+	s.enemyBullets.observe(obs, spaceInvadersEnemyBullet)
+}
+
+// channels returns the number of observation channels
+func (s *spaceInvaders) channels() int {
+	return spaceInvadersChannels
+}
+
+// actions returns the minimal action set
+func (s *spaceInvaders) actions() []action {
+	return []action{noop, left, right, fire}
+}
+
+// colours returns the colours used to draw each channel
+func (s *spaceInvaders) colours() []color.Color {
+	return []color.Color{
+		color.RGBA{R: 80, G: 200, B: 120, A: 255},  // Cannon
+		color.RGBA{R: 200, G: 72, B: 200, A: 255},  // Alien
+		color.RGBA{R: 200, G: 72, B: 200, A: 255},  // Alien moving left
+		color.RGBA{R: 200, G: 72, B: 200, A: 255},  // Alien moving right
+		color.RGBA{R: 230, G: 230, B: 230, A: 255}, // Friendly bullet
+		color.RGBA{R: 255, G: 128, B: 0, A: 255},   // Enemy bullet
+	}
+}
@@ -0,0 +1,102 @@
+package minatar
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Play implements the task of playing a MinAtar-style game. In this
+// task, the agent is rewarded with the points scored in the game at
+// each timestep.
+//
+// Episodes are ended when a timestep limit is reached or when the
+// game is over. If the game has its own internal time limit (e.g.
+// Freeway), then episodes are also cutoff when this internal time
+// limit is reached.
+type Play struct {
+	env *MinAtar // Registered MinAtar environment
+
+	// registered denotes whether or not a MinAtar environment has
+	// been registered with the task
+	registered bool
+
+	stepLimit environment.Ender // Step limit ender
+}
+
+// NewPlay returns a new Play task
+func NewPlay(cutoff int) environment.Task {
+	return &Play{
+		registered: false,
+		stepLimit:  environment.NewStepLimit(cutoff),
+	}
+}
+
+// Start resets the registered game and returns its first state
+// observation
+func (p *Play) Start() *mat.VecDense {
+	if !p.registered {
+		panic("start: no registered MinAtar environment to start")
+	}
+
+	p.env.reset()
+	return p.env.observation()
+}
+
+// End checks if a timestep should be the last in the episode and
+// adjusts the timestep accordingly. End returns whether the argument
+// timestep is the last in the episode.
+func (p *Play) End(t *ts.TimeStep) bool {
+	if !p.registered {
+		panic("end: no registered MinAtar environment to end")
+	}
+
+	if p.env.over() {
+		t.StepType = ts.Last
+		t.SetEnd(ts.TerminalStateReached)
+		return true
+	}
+
+	if p.env.timeUp() {
+		t.StepType = ts.Last
+		t.SetEnd(ts.Timeout)
+		return true
+	}
+
+	return p.stepLimit.End(t)
+}
+
+// GetReward returns the reward for a state, action, next state
+// transition, which is the points scored in the game on the last
+// timestep.
+func (p *Play) GetReward(state, action, nextState mat.Vector) float64 {
+	if !p.registered {
+		panic("getReward: no registered MinAtar environment to get " +
+			"reward of")
+	}
+
+	return p.env.lastReward
+}
+
+// AtGoal satisfies the environment.Task interface. Since MinAtar
+// games have no goal state, this function simply prints an error
+// message to standard error.
+func (p *Play) AtGoal(state mat.Matrix) bool {
+	if !p.registered {
+		panic("atGoal: no registered MinAtar environment")
+	}
+
+	fmt.Fprintf(os.Stderr, "atGoal: no goal state for Play task")
+	return false
+}
+
+// register registers the Play task with a MinAtar environment. This
+// is required since the Play task needs access to the underlying
+// game to compute starting states, rewards, and ending states.
+func (p *Play) register(env *MinAtar) {
+	p.env = env
+	p.registered = true
+}
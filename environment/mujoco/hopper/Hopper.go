@@ -18,6 +18,13 @@ import (
 	"github.com/samuelfneumann/golearn/utils/floatutils"
 )
 
+// Keys of the diagnostics stored in the Info of each TimeStep after the
+// first in an episode
+const (
+	InfoForwardVelocity = "ForwardVelocity" // Torso X linear velocity
+	InfoHeight          = "Height"          // Torso Z position
+)
+
 // Hopper implements the Hopper environment. In this environment, an
 // agent control a "hopper": a creature composed of a single leg. The
 // agent can control three of its joints to hop or move around. The
@@ -115,6 +122,9 @@ func (h *Hopper) Step(action *mat.VecDense) (ts.TimeStep, bool, error) {
 
 	t := ts.New(ts.Mid, reward, h.Discount, h.getObs(),
 		h.CurrentTimeStep().Number+1)
+	t.SetInfo(InfoForwardVelocity,
+		(nextState.AtVec(0)-state.AtVec(0))/h.Dt())
+	t.SetInfo(InfoHeight, nextState.AtVec(1))
 	last := h.End(&t)
 	h.currentTimeStep = t
 
@@ -14,9 +14,20 @@ import (
 	"github.com/samuelfneumann/golearn/environment/mujoco/internal/mujocoenv"
 	ts "github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/golearn/utils/floatutils"
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/mat"
 )
 
+// Keys of the diagnostics stored in the Info of each TimeStep
+const (
+	// Distance between the fingertip and the target
+	InfoDistance = "Distance"
+
+	// Squared norm of the (unclipped) action, not present on the first
+	// TimeStep of an episode
+	InfoControlCost = "ControlCost"
+)
+
 // Reacher implements the reacher environment. In this environment,
 // a an agent controls a Reacher. The reacher is a double pendulum,
 // consisting of two arms attached by a hinge. The base of the
@@ -113,7 +124,7 @@ func (r *Reacher) Step(action *mat.VecDense) (ts.TimeStep, bool, error) {
 	}
 
 	// Run simulation, then get the next state
-	newAction := r.clipAction(action)
+	newAction := r.ActionSpec().Clip(action)
 	r.DoSimulation(newAction, r.FrameSkip)
 	nextState, err := r.BodyXPos("fingertip")
 	if err != nil {
@@ -129,6 +140,10 @@ func (r *Reacher) Step(action *mat.VecDense) (ts.TimeStep, bool, error) {
 	}
 
 	t := ts.New(ts.Mid, reward, r.Discount, obs, r.CurrentTimeStep().Number+1)
+	if err := r.setDistance(&t); err != nil {
+		return ts.TimeStep{}, true, fmt.Errorf("step: %v", err)
+	}
+	t.SetInfo(InfoControlCost, mat.Dot(action, action))
 	r.currentTimeStep = t
 	done := r.End(&t)
 
@@ -136,23 +151,6 @@ func (r *Reacher) Step(action *mat.VecDense) (ts.TimeStep, bool, error) {
 
 }
 
-// clipAction returns a copy of the argument action which is clipped to
-// be within the action bounds of the environment.
-func (r *Reacher) clipAction(action *mat.VecDense) *mat.VecDense {
-	spec := r.ActionSpec()
-	min := spec.LowerBound
-	max := spec.UpperBound
-
-	clipped := mat.VecDenseCopyOf(action)
-
-	for i := 0; i < clipped.Len(); i++ {
-		clipped.SetVec(i, floatutils.Clip(clipped.AtVec(i), min.AtVec(i),
-			max.AtVec(i)))
-	}
-
-	return clipped
-}
-
 // Reset resets the environment to begin a new episode
 func (r *Reacher) Reset() (ts.TimeStep, error) {
 	// Reset the embedded base MujocoEnv
@@ -175,6 +173,9 @@ func (r *Reacher) Reset() (ts.TimeStep, error) {
 			"state observation: %v", err)
 	}
 	firstStep := ts.New(ts.First, 0, r.Discount, obs, 0)
+	if err := r.setDistance(&firstStep); err != nil {
+		return ts.TimeStep{}, fmt.Errorf("reset: %v", err)
+	}
 	r.currentTimeStep = firstStep
 
 	return firstStep, nil
@@ -185,6 +186,18 @@ func (r *Reacher) CurrentTimeStep() ts.TimeStep {
 	return r.currentTimeStep
 }
 
+// setDistance stores the distance between the Reacher's fingertip and
+// the target in the Info of the argument TimeStep
+func (r *Reacher) setDistance(t *ts.TimeStep) error {
+	distance, err := r.fingerToTargetVector()
+	if err != nil {
+		return fmt.Errorf("setDistance: %v", err)
+	}
+	t.SetInfo(InfoDistance, floats.Norm(distance, 2))
+
+	return nil
+}
+
 // fingerToTargetVector returns f⃗ - t⃗, where f⃗ is the Reacher's fingertip
 // location and t⃗ is the location of the target.
 func (r *Reacher) fingerToTargetVector() ([]float64, error) {
@@ -194,6 +194,11 @@ func (a *AverageReward) DiscountSpec() environment.Spec {
 	return discountSpec
 }
 
+// Unwrap returns the wrapped environment
+func (a *AverageReward) Unwrap() environment.Environment {
+	return a.Environment
+}
+
 // String returns a string representation of the AverageReward
 //environment
 func (a *AverageReward) String() string {
@@ -0,0 +1,181 @@
+package wrappers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// DiscretizeConfig configures a Discretize wrapper. DiscretizeConfigs
+// are JSON serializable.
+type DiscretizeConfig struct {
+	// Bins is the number of evenly spaced actions in each action
+	// dimension, including both action bounds. If Bins has a single
+	// element, it is used for all action dimensions.
+	Bins []int
+
+	// Product determines the discrete actions of the wrapper. If
+	// Product is true, actions are 1-dimensional and enumerate the
+	// full product of the grid of continuous actions. Otherwise,
+	// actions are environment.MultiDiscrete, and the bin in each
+	// action dimension is chosen independently. Environments with
+	// 1-dimensional continuous actions always have 1-dimensional
+	// discrete actions.
+	Product bool
+}
+
+// Discretize wraps an environment with continuous actions so that it
+// can be used with agents which require discrete actions. Each
+// dimension of the wrapped environment's continuous actions is split
+// into a grid of evenly spaced actions between the action bounds, and
+// the discrete actions of the Discretize environment select an action
+// from this grid. Actions are enumerated starting from 0, where action
+// 0 is the lower action bound and the last action is the upper action
+// bound in each dimension.
+type Discretize struct {
+	environment.Environment
+	config DiscretizeConfig
+
+	bins []int            // Number of bins in each action dimension
+	grid environment.Spec // Spec of the bins in each action dimension
+	spec environment.Spec // Discrete action spec
+}
+
+// NewDiscretize creates and returns a new Discretize environment,
+// wrapping an existing environment. The wrapped environment is reset
+// when wrapped by the Discretize environment by calling the wrapped
+// environment's Reset() method.
+func NewDiscretize(env environment.Environment,
+	c DiscretizeConfig) (*Discretize, ts.TimeStep, error) {
+	envSpec := env.ActionSpec()
+	if envSpec.Cardinality != environment.Continuous {
+		return nil, ts.TimeStep{}, fmt.Errorf("newDiscretize: cannot "+
+			"discretize %v actions", envSpec.Cardinality)
+	}
+
+	dims := envSpec.Shape.Len()
+	for i := 0; i < dims; i++ {
+		lower, upper := envSpec.LowerBound.AtVec(i), envSpec.UpperBound.AtVec(i)
+		if math.IsInf(lower, 0) || math.IsInf(upper, 0) {
+			return nil, ts.TimeStep{}, fmt.Errorf("newDiscretize: action "+
+				"bounds of dimension %v must be finite", i)
+		}
+	}
+
+	// Calculate the number of bins in each action dimension
+	var bins []int
+	switch len(c.Bins) {
+	case 1:
+		bins = make([]int, dims)
+		for i := range bins {
+			bins[i] = c.Bins[0]
+		}
+	case dims:
+		bins = make([]int, dims)
+		copy(bins, c.Bins)
+	default:
+		return nil, ts.TimeStep{}, fmt.Errorf("newDiscretize: must "+
+			"specify bins for 1 or %v action dimensions (have %v)", dims,
+			len(c.Bins))
+	}
+	for i := range bins {
+		if bins[i] < 2 {
+			return nil, ts.TimeStep{}, fmt.Errorf("newDiscretize: action "+
+				"dimension %v must have at least 2 bins", i)
+		}
+	}
+
+	grid := environment.NewMultiDiscreteSpec(environment.Action, bins...)
+	spec := grid
+	if c.Product || dims == 1 {
+		// Enumerate the full product of the grid of actions
+		numActions := float64(grid.NumDiscrete())
+		spec = environment.NewSpec(
+			mat.NewVecDense(1, nil),
+			environment.Action,
+			mat.NewVecDense(1, nil),
+			mat.NewVecDense(1, []float64{numActions - 1}),
+			environment.Discrete,
+		)
+	}
+
+	step, err := env.Reset()
+	if err != nil {
+		return nil, ts.TimeStep{}, fmt.Errorf("newDiscretize: could not "+
+			"reset wrapped environment: %v", err)
+	}
+
+	d := &Discretize{
+		Environment: env,
+		config:      c,
+		bins:        bins,
+		grid:        grid,
+		spec:        spec,
+	}
+	return d, step, nil
+}
+
+// Step takes one environmental step given discrete action a and
+// returns the next state as a timestep.TimeStep and a bool indicating
+// whether or not the episode has ended. The discrete action is mapped
+// to a continuous action with ContinuousAction before being taken in
+// the wrapped environment.
+func (d *Discretize) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
+	action, err := d.ContinuousAction(a)
+	if err != nil {
+		return ts.TimeStep{}, true, fmt.Errorf("step: %v", err)
+	}
+	return d.Environment.Step(action)
+}
+
+// ContinuousAction returns the continuous action of the wrapped
+// environment which is selected by discrete action a
+func (d *Discretize) ContinuousAction(a mat.Vector) (*mat.VecDense,
+	error) {
+	if a.Len() != d.spec.Shape.Len() {
+		return nil, fmt.Errorf("continuousAction: illegal action %v",
+			mat.Formatted(a.T()))
+	}
+	for i := 0; i < a.Len(); i++ {
+		value := a.AtVec(i)
+		if value != math.Trunc(value) || value < d.spec.LowerBound.AtVec(i) ||
+			value > d.spec.UpperBound.AtVec(i) {
+			return nil, fmt.Errorf("continuousAction: illegal action %v",
+				mat.Formatted(a.T()))
+		}
+	}
+
+	// Find the bin selected in each action dimension
+	bins := a
+	if d.spec.Cardinality == environment.Discrete {
+		bins = d.grid.DiscreteValue(int(a.AtVec(0)))
+	}
+
+	envSpec := d.Environment.ActionSpec()
+	action := mat.NewVecDense(len(d.bins), nil)
+	for i := range d.bins {
+		lower, upper := envSpec.LowerBound.AtVec(i), envSpec.UpperBound.AtVec(i)
+		width := (upper - lower) / float64(d.bins[i]-1)
+		action.SetVec(i, lower+bins.AtVec(i)*width)
+	}
+	return action, nil
+}
+
+// ActionSpec returns the discrete action specification of the
+// environment
+func (d *Discretize) ActionSpec() environment.Spec {
+	return d.spec
+}
+
+// Unwrap returns the wrapped environment
+func (d *Discretize) Unwrap() environment.Environment {
+	return d.Environment
+}
+
+// String returns a string representation of the Discretize environment
+func (d *Discretize) String() string {
+	return fmt.Sprintf("Discretize %v: %v", d.bins, d.Environment)
+}
@@ -0,0 +1,101 @@
+package wrappers
+
+import (
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/box2d/lunarlander"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+)
+
+func TestDiscretize(t *testing.T) {
+	newEnv := func() environment.Environment {
+		s := environment.NewUniformStarter([]r1.Interval{
+			{Min: lunarlander.InitialX, Max: lunarlander.InitialX},
+			{Min: lunarlander.InitialY, Max: lunarlander.InitialY},
+			{Min: lunarlander.InitialRandom, Max: lunarlander.InitialRandom},
+		}, 1)
+		task := lunarlander.NewLand(s, 100)
+		l, _, err := lunarlander.NewContinuous(task, 0.99, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	}
+
+	config := DiscretizeConfig{Bins: []int{3, 5}}
+	independent, _, err := NewDiscretize(newEnv(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.Product = true
+	product, _, err := NewDiscretize(newEnv(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the discrete action specs
+	spec := independent.ActionSpec()
+	if spec.Cardinality != environment.MultiDiscrete {
+		t.Errorf("independent cardinality: have(%v) want(%v)",
+			spec.Cardinality, environment.MultiDiscrete)
+	}
+	if sizes := spec.DiscreteSizes(); sizes[0] != 3 || sizes[1] != 5 {
+		t.Errorf("independent sizes: have(%v) want([3 5])", sizes)
+	}
+	spec = product.ActionSpec()
+	if spec.Cardinality != environment.Discrete {
+		t.Errorf("product cardinality: have(%v) want(%v)",
+			spec.Cardinality, environment.Discrete)
+	}
+	if n := spec.NumDiscrete(); n != 15 {
+		t.Errorf("product actions: have(%v) want(15)", n)
+	}
+
+	// Check the mapping of discrete actions to continuous actions. The
+	// continuous actions are bounded in [-1, 1] in each dimension.
+	tests := []struct {
+		independent, product []float64
+		want                 []float64
+	}{
+		{[]float64{0, 0}, []float64{0}, []float64{-1, -1}},
+		{[]float64{1, 3}, []float64{8}, []float64{0, 0.5}},
+		{[]float64{2, 4}, []float64{14}, []float64{1, 1}},
+	}
+	for _, test := range tests {
+		for _, d := range []struct {
+			env    *Discretize
+			action []float64
+		}{{independent, test.independent}, {product, test.product}} {
+			a, err := d.env.ContinuousAction(mat.NewVecDense(len(d.action),
+				d.action))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !floats.EqualApprox(a.RawVector().Data, test.want, 1e-12) {
+				t.Errorf("action %v: have(%v) want(%v)", d.action,
+					a.RawVector().Data, test.want)
+			}
+		}
+	}
+
+	// Check that illegal actions are rejected
+	for _, a := range [][]float64{{3, 0}, {0, 0.5}, {-1, 0}} {
+		if _, err := independent.ContinuousAction(mat.NewVecDense(2,
+			a)); err == nil {
+			t.Errorf("illegal action %v: expected error", a)
+		}
+	}
+	if _, err := product.ContinuousAction(mat.NewVecDense(1,
+		[]float64{15})); err == nil {
+		t.Errorf("illegal action [15]: expected error")
+	}
+
+	// Discretize requires continuous actions
+	if _, _, err := NewDiscretize(product, config); err == nil {
+		t.Errorf("discretizing discrete actions: expected error")
+	}
+}
@@ -106,6 +106,11 @@ func (t *IndexTileCoding) ObservationSpec() environment.Spec {
 
 }
 
+// Unwrap returns the wrapped environment
+func (t *IndexTileCoding) Unwrap() environment.Environment {
+	return t.Environment
+}
+
 // String returns a string representation of the IndexTileCoding environment
 func (t *IndexTileCoding) String() string {
 	return fmt.Sprintf("IndexTileCoding: %v", t.Environment)
@@ -0,0 +1,210 @@
+package wrappers
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/fogleman/gg"
+	"github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// PixelObservationConfig configures a PixelObservation.
+// PixelObservationConfigs are JSON serializable.
+type PixelObservationConfig struct {
+	// Scale, Width, and Height determine the size of the image which
+	// the environment is drawn on before downscaling. See
+	// environment.PixelEnvironment.
+	Scale  float64
+	Width  int
+	Height int
+
+	// Rows and Cols determine the size of the downscaled image
+	// returned as observations. Rows and Cols must not be larger than
+	// Height and Width respectively.
+	Rows int
+	Cols int
+
+	// Grayscale determines whether observations are grayscale (1
+	// channel) or RGB (3 channels) images
+	Grayscale bool
+}
+
+// PixelObservation wraps an environment and returns images of the
+// environment as observations instead of the environment's states.
+// Images are drawn by the first environment.PixelEnvironment in the
+// chain of wrapped environments and then downscaled by averaging the
+// colours in non-overlapping blocks of pixels.
+//
+// PixelObservation implements the environment.ImageEnvironment
+// interface. Observations are flattened images in channel-major order,
+// where each feature is the intensity of a single channel of a single
+// pixel, in the range [0, 1]. Grayscale intensities are the luma of
+// the RGB colours.
+type PixelObservation struct {
+	environment.Environment
+	pixels environment.PixelEnvironment
+	config PixelObservationConfig
+
+	dc *gg.Context // Blank context which the environment is drawn on
+}
+
+// NewPixelObservation creates and returns a new PixelObservation
+// environment, wrapping an existing environment. The wrapped
+// environment is reset when wrapped by the PixelObservation
+// environment by calling the wrapped environment's Reset() method.
+func NewPixelObservation(env environment.Environment,
+	c PixelObservationConfig) (*PixelObservation, ts.TimeStep, error) {
+	pixels, ok := environment.Unwrap(env, func(e environment.Environment) bool {
+		_, ok := e.(environment.PixelEnvironment)
+		return ok
+	}).(environment.PixelEnvironment)
+	if !ok {
+		return nil, ts.TimeStep{}, fmt.Errorf("newPixelObservation: "+
+			"environment %T cannot be drawn", env)
+	}
+
+	if c.Scale <= 0 || c.Width <= 0 || c.Height <= 0 {
+		return nil, ts.TimeStep{}, fmt.Errorf("newPixelObservation: " +
+			"drawing scale and size must be positive")
+	}
+	if c.Rows <= 0 || c.Cols <= 0 || c.Rows > c.Height || c.Cols > c.Width {
+		return nil, ts.TimeStep{}, fmt.Errorf("newPixelObservation: "+
+			"cannot downscale image of size (%v, %v) to size (%v, %v)",
+			c.Height, c.Width, c.Rows, c.Cols)
+	}
+
+	p := &PixelObservation{
+		Environment: env,
+		pixels:      pixels,
+		config:      c,
+		dc:          gg.NewContext(c.Width, c.Height),
+	}
+
+	// Reset the wrapped environment
+	step, err := env.Reset()
+	if err != nil {
+		return nil, ts.TimeStep{}, fmt.Errorf("newPixelObservation: could "+
+			"not reset wrapped environment: %v", err)
+	}
+	step.Observation = p.observe()
+
+	return p, step, nil
+}
+
+// Reset resets the environment to some starting state
+func (p *PixelObservation) Reset() (ts.TimeStep, error) {
+	step, err := p.Environment.Reset()
+	if err != nil {
+		return ts.TimeStep{}, err
+	}
+	step.Observation = p.observe()
+
+	return step, nil
+}
+
+// Step takes one environmental step given action a and returns the next
+// state as a timestep.TimeStep and a bool indicating whether or not the
+// episode has ended
+func (p *PixelObservation) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
+	step, last, err := p.Environment.Step(a)
+	if err != nil {
+		return ts.TimeStep{}, true, err
+	}
+	step.Observation = p.observe()
+
+	return step, last, nil
+}
+
+// ObservationSpec returns the observation specification of the
+// environment
+func (p *PixelObservation) ObservationSpec() environment.Spec {
+	return environment.NewImageSpec(environment.Observation, p.Channels(),
+		p.Rows(), p.Cols(), 0, 1, environment.Continuous)
+}
+
+// Channels returns the number of channels in each observation
+func (p *PixelObservation) Channels() int {
+	if p.config.Grayscale {
+		return 1
+	}
+	return 3
+}
+
+// Rows returns the number of rows of pixels in each observation
+func (p *PixelObservation) Rows() int {
+	return p.config.Rows
+}
+
+// Cols returns the number of columns of pixels in each observation
+func (p *PixelObservation) Cols() int {
+	return p.config.Cols
+}
+
+// Unwrap returns the wrapped environment
+func (p *PixelObservation) Unwrap() environment.Environment {
+	return p.Environment
+}
+
+// String returns a string representation of the PixelObservation
+// environment
+func (p *PixelObservation) String() string {
+	return fmt.Sprintf("PixelObservation: %v", p.Environment)
+}
+
+// observe draws the current state of the environment and returns the
+// downscaled image as an observation
+func (p *PixelObservation) observe() *mat.VecDense {
+	img := p.pixels.Pixels(p.config.Scale, *p.dc, false)
+	bounds := img.Bounds()
+
+	rows, cols, channels := p.Rows(), p.Cols(), p.Channels()
+	obs := make([]float64, channels*rows*cols)
+
+	for i := 0; i < rows; i++ {
+		// Vertical pixels of the drawn image in the block for row i
+		minY := bounds.Min.Y + i*bounds.Dy()/rows
+		maxY := bounds.Min.Y + (i+1)*bounds.Dy()/rows
+
+		for j := 0; j < cols; j++ {
+			// Horizontal pixels of the drawn image in the block for
+			// column j
+			minX := bounds.Min.X + j*bounds.Dx()/cols
+			maxX := bounds.Min.X + (j+1)*bounds.Dx()/cols
+
+			rgb := averageRGB(img, minX, maxX, minY, maxY)
+			if p.config.Grayscale {
+				obs[i*cols+j] = 0.299*rgb[0] + 0.587*rgb[1] + 0.114*rgb[2]
+				continue
+			}
+			for c := range rgb {
+				obs[c*rows*cols+i*cols+j] = rgb[c]
+			}
+		}
+	}
+
+	return mat.NewVecDense(len(obs), obs)
+}
+
+// averageRGB returns the average red, green, and blue intensities in
+// [0, 1] of the pixels of img in the rectangle [minX, maxX) x
+// [minY, maxY)
+func averageRGB(img image.Image, minX, maxX, minY, maxY int) [3]float64 {
+	var rgb [3]float64
+	for y := minY; y < maxY; y++ {
+		for x := minX; x < maxX; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			rgb[0] += float64(r)
+			rgb[1] += float64(g)
+			rgb[2] += float64(b)
+		}
+	}
+
+	// RGBA() returns 16-bit colour channels
+	pixels := float64((maxX - minX) * (maxY - minY))
+	for c := range rgb {
+		rgb[c] /= pixels * 0xffff
+	}
+	return rgb
+}
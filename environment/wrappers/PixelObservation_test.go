@@ -0,0 +1,75 @@
+package wrappers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/classiccontrol/mountaincar"
+	"gonum.org/v1/gonum/spatial/r1"
+)
+
+func TestPixelObservation(t *testing.T) {
+	const rows, cols = 14, 18
+
+	newEnv := func() environment.Environment {
+		s := environment.NewUniformStarter([]r1.Interval{
+			{Min: -0.5, Max: -0.5},
+			{Min: 0.0, Max: 0.0},
+		}, 1)
+		task := mountaincar.NewGoal(s, 100, mountaincar.GoalPosition)
+		m, _, err := mountaincar.NewDiscrete(task, 0.99)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	config := PixelObservationConfig{
+		Scale:  10,
+		Width:  180,
+		Height: 140,
+		Rows:   rows,
+		Cols:   cols,
+	}
+	rgb, rgbStep, err := NewPixelObservation(newEnv(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.Grayscale = true
+	gray, grayStep, err := NewPixelObservation(newEnv(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the observation sizes
+	for _, p := range []*PixelObservation{rgb, gray} {
+		length := p.Channels() * p.Rows() * p.Cols()
+		if specLen := p.ObservationSpec().Shape.Len(); specLen != length {
+			t.Errorf("observation spec length: have(%v) want(%v)", specLen,
+				length)
+		}
+	}
+	if rgbStep.Observation.Len() != 3*rows*cols {
+		t.Errorf("RGB observation length: have(%v) want(%v)",
+			rgbStep.Observation.Len(), 3*rows*cols)
+	}
+	if grayStep.Observation.Len() != rows*cols {
+		t.Errorf("grayscale observation length: have(%v) want(%v)",
+			grayStep.Observation.Len(), rows*cols)
+	}
+
+	// Grayscale pixels should be the luma of the RGB pixels
+	r := rgbStep.Observation.RawVector().Data
+	g := grayStep.Observation.RawVector().Data
+	for i := range g {
+		luma := 0.299*r[i] + 0.587*r[rows*cols+i] + 0.114*r[2*rows*cols+i]
+		if math.Abs(luma-g[i]) > 1e-9 {
+			t.Fatalf("pixel %v: have(%v) want(%v)", i, g[i], luma)
+		}
+		if g[i] < 0 || g[i] > 1 {
+			t.Fatalf("pixel %v out of bounds: %v", i, g[i])
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package wrappers
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/fogleman/gg"
+	"github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// RecordingFormat is the file format of recordings made by a Recorder
+type RecordingFormat string
+
+const (
+	// GIF records each episode as a single animated GIF
+	GIF RecordingFormat = "gif"
+
+	// PNG records each frame of an episode as a separate PNG image
+	PNG RecordingFormat = "png"
+)
+
+// RecorderConfig configures a Recorder. RecorderConfigs are JSON
+// serializable.
+type RecorderConfig struct {
+	// Dir is the directory in which recordings are saved. It is
+	// created if it does not exist.
+	Dir    string
+	Format RecordingFormat
+
+	// EpisodeInterval determines which episodes are recorded. Every
+	// EpisodeInterval-th episode is recorded, starting with the first.
+	// If EpisodeInterval is 0, episodes are only recorded while
+	// recording is turned on with Record().
+	EpisodeInterval int
+
+	// StepInterval is the number of steps between recorded frames of
+	// an episode. The first and last frames of an episode are always
+	// recorded. If StepInterval is 0, every frame is recorded.
+	StepInterval int
+
+	// Scale, Width, and Height determine the size of the recorded
+	// frames. See environment.PixelEnvironment.
+	Scale  float64
+	Width  int
+	Height int
+
+	// Delay is the delay between frames of a GIF in 100ths of a
+	// second
+	Delay int
+}
+
+// Recorder wraps an environment and records episodes as animated GIFs
+// or sequences of PNG images. Frames are drawn by the first
+// environment.PixelEnvironment in the chain of wrapped environments,
+// so that environments wrapped in other wrappers, such as TileCoding,
+// can still be recorded.
+//
+// GIFs are saved as Dir/episodeE.gif and PNGs as
+// Dir/episodeE_stepS.png, where E is the episode number (starting at
+// 1) and S is the step number within the episode. Since frames of GIFs
+// are held in memory until the end of an episode, a Recorder should be
+// closed once it is no longer needed so that partially recorded
+// episodes are saved.
+type Recorder struct {
+	environment.Environment
+	pixels environment.PixelEnvironment
+	config RecorderConfig
+
+	episode   int  // Number of the current episode
+	recording bool // Whether the current episode is being recorded
+	record    bool // Whether recording was turned on with Record()
+	frames    []*image.Paletted
+}
+
+// NewRecorder returns a new Recorder wrapping env. The wrapped
+// environment is reset when wrapped by the Recorder, but this reset
+// does not count as the start of an episode and is not recorded.
+func NewRecorder(env environment.Environment,
+	c RecorderConfig) (*Recorder, ts.TimeStep, error) {
+	pixels, ok := environment.Unwrap(env, func(e environment.Environment) bool {
+		_, ok := e.(environment.PixelEnvironment)
+		return ok
+	}).(environment.PixelEnvironment)
+	if !ok {
+		return nil, ts.TimeStep{}, fmt.Errorf("newRecorder: environment "+
+			"%T cannot be drawn", env)
+	}
+
+	if c.Format != GIF && c.Format != PNG {
+		return nil, ts.TimeStep{}, fmt.Errorf("newRecorder: unknown "+
+			"recording format %v", c.Format)
+	}
+	if c.EpisodeInterval < 0 || c.StepInterval < 0 {
+		return nil, ts.TimeStep{}, fmt.Errorf("newRecorder: intervals " +
+			"must be non-negative")
+	}
+	if c.Scale <= 0 || c.Width <= 0 || c.Height <= 0 {
+		return nil, ts.TimeStep{}, fmt.Errorf("newRecorder: frame scale " +
+			"and size must be positive")
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, ts.TimeStep{}, fmt.Errorf("newRecorder: could not "+
+			"create directory: %v", err)
+	}
+
+	step, err := env.Reset()
+	if err != nil {
+		return nil, ts.TimeStep{}, fmt.Errorf("newRecorder: could not "+
+			"reset wrapped environment: %v", err)
+	}
+
+	return &Recorder{Environment: env, pixels: pixels, config: c}, step, nil
+}
+
+// Record sets whether all episodes starting after the call to Record
+// are recorded. If on is false, episodes are recorded as determined
+// by the EpisodeInterval of the Recorder's configuration. The current
+// episode is unaffected.
+func (r *Recorder) Record(on bool) {
+	r.record = on
+}
+
+// Reset resets the environment to some starting state. If the
+// previous episode was being recorded, its recording is saved.
+func (r *Recorder) Reset() (ts.TimeStep, error) {
+	if err := r.save(); err != nil {
+		return ts.TimeStep{}, fmt.Errorf("reset: %v", err)
+	}
+
+	step, err := r.Environment.Reset()
+	if err != nil {
+		return ts.TimeStep{}, err
+	}
+
+	r.episode++
+	interval := r.config.EpisodeInterval
+	r.recording = r.record || (interval > 0 && (r.episode-1)%interval == 0)
+
+	if err := r.capture(step); err != nil {
+		return ts.TimeStep{}, fmt.Errorf("reset: %v", err)
+	}
+	return step, nil
+}
+
+// Step takes one environmental step given action a and returns the
+// next state as a timestep.TimeStep and a bool indicating whether or
+// not the episode has ended. If the episode ends, its recording is
+// saved.
+func (r *Recorder) Step(a *mat.VecDense) (ts.TimeStep, bool, error) {
+	step, last, err := r.Environment.Step(a)
+	if err != nil {
+		return step, last, err
+	}
+
+	if err := r.capture(step); err != nil {
+		return step, last, fmt.Errorf("step: %v", err)
+	}
+	if last {
+		if err := r.save(); err != nil {
+			return step, last, fmt.Errorf("step: %v", err)
+		}
+	}
+	return step, last, nil
+}
+
+// Close saves the recording of the current episode, if any, and
+// closes the wrapped environment if it is an environment.Closer
+func (r *Recorder) Close() error {
+	if err := r.save(); err != nil {
+		return fmt.Errorf("close: %v", err)
+	}
+
+	if closer, ok := r.Environment.(environment.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Unwrap returns the wrapped environment
+func (r *Recorder) Unwrap() environment.Environment {
+	return r.Environment
+}
+
+// capture records the frame of the argument TimeStep if needed
+func (r *Recorder) capture(t ts.TimeStep) error {
+	interval := r.config.StepInterval
+	if interval == 0 {
+		interval = 1
+	}
+	if !r.recording || (t.Number%interval != 0 && !t.Last()) {
+		return nil
+	}
+
+	dc := gg.NewContext(r.config.Width, r.config.Height)
+	img := r.pixels.Pixels(r.config.Scale, *dc, true)
+
+	switch r.config.Format {
+	case PNG:
+		filename := fmt.Sprintf("episode%05d_step%05d.png", r.episode, t.Number)
+		return writeFile(filepath.Join(r.config.Dir, filename), func(
+			f *os.File) error {
+			return png.Encode(f, img)
+		})
+
+	default:
+		frame := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.Draw(frame, frame.Bounds(), img, img.Bounds().Min, draw.Src)
+		r.frames = append(r.frames, frame)
+		return nil
+	}
+}
+
+// save saves the GIF of the current episode if it is being recorded
+// and stops recording
+func (r *Recorder) save() error {
+	frames := r.frames
+	r.frames = nil
+	r.recording = false
+	if len(frames) == 0 {
+		return nil
+	}
+
+	delays := make([]int, len(frames))
+	for i := range delays {
+		delays[i] = r.config.Delay
+	}
+	anim := &gif.GIF{Image: frames, Delay: delays}
+
+	filename := fmt.Sprintf("episode%05d.gif", r.episode)
+	return writeFile(filepath.Join(r.config.Dir, filename), func(
+		f *os.File) error {
+		return gif.EncodeAll(f, anim)
+	})
+}
+
+// String returns a string representation of the Recorder environment
+func (r *Recorder) String() string {
+	return fmt.Sprintf("Recorder: %v", r.Environment)
+}
+
+// writeFile creates the file at path and writes to it using write
+func writeFile(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create file: %v", err)
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write file %v: %v", path, err)
+	}
+	return f.Close()
+}
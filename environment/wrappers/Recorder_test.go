@@ -0,0 +1,94 @@
+package wrappers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/classiccontrol/cartpole"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+)
+
+func TestRecorder(t *testing.T) {
+	const episodes = 5
+
+	bounds := r1.Interval{Min: -0.05, Max: 0.05}
+	s := environment.NewUniformStarter([]r1.Interval{
+		bounds,
+		bounds,
+		bounds,
+		bounds,
+	}, 1)
+	task := cartpole.NewBalance(s, 20, cartpole.FailAngle)
+	c, _, err := cartpole.NewDiscrete(task, 0.99)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []RecordingFormat{GIF, PNG} {
+		dir := t.TempDir()
+		r, _, err := NewRecorder(c, RecorderConfig{
+			Dir:             dir,
+			Format:          format,
+			EpisodeInterval: 3,
+			StepInterval:    5,
+			Scale:           10,
+			Width:           240,
+			Height:          120,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < episodes; i++ {
+			// Force the last episode to be recorded
+			r.Record(i == episodes-1)
+
+			if _, err := r.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			for last := false; !last; {
+				_, last, err = r.Step(mat.NewVecDense(1, []float64{0}))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Only episodes 1 and 4, as well as the forced episode 5, should
+		// be recorded
+		for episode := 1; episode <= episodes; episode++ {
+			pattern := fmt.Sprintf("episode%05d*.%v", episode, format)
+			files, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorded := len(files) > 0
+			if recorded != (episode%3 == 1 || episode == episodes) {
+				t.Errorf("%v: episode %v recorded: %v", format, episode,
+					recorded)
+			}
+		}
+	}
+}
+
+func TestRecorderNotDrawable(t *testing.T) {
+	_, _, err := NewRecorder(nil, RecorderConfig{
+		Dir:    os.TempDir(),
+		Format: GIF,
+		Scale:  1,
+		Width:  1,
+		Height: 1,
+	})
+	if err == nil {
+		t.Error("expected error recording environment which cannot " +
+			"be drawn")
+	}
+}
@@ -98,6 +98,11 @@ func (t *TileCoding) ObservationSpec() environment.Spec {
 
 }
 
+// Unwrap returns the wrapped environment
+func (t *TileCoding) Unwrap() environment.Environment {
+	return t.Environment
+}
+
 // String returns a string representation of the TileCoding environment
 func (t *TileCoding) String() string {
 	return fmt.Sprintf("TileCoding: %v", t.Environment)
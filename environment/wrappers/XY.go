@@ -109,6 +109,11 @@ func (x *XY) ObservationSpec() env.Spec {
 	return env.NewSpec(shape, env.Observation, low, high, env.Discrete)
 }
 
+// Unwrap returns the wrapped environment
+func (x *XY) Unwrap() env.Environment {
+	return x.RowColer
+}
+
 // String returns the string representation of the environment
 func (x *XY) String() string {
 	return fmt.Sprintf("XY: %v", x.RowColer)
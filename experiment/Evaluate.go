@@ -0,0 +1,56 @@
+package experiment
+
+import (
+	"fmt"
+
+	ag "github.com/samuelfneumann/golearn/agent"
+	env "github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Evaluate runs an agent in evaluation mode on an environment for a
+// number of episodes and returns the undiscounted return of each
+// episode. The agent does not learn during evaluation. If render is
+// not nil, it is called with each TimeStep of each episode, including
+// the first, and evaluation stops if render returns an error.
+func Evaluate(e env.Environment, a ag.Agent, episodes int,
+	render func(ts.TimeStep) error) ([]float64, error) {
+	a.Eval()
+	defer a.Train()
+
+	returns := make([]float64, episodes)
+	for i := 0; i < episodes; i++ {
+		step, err := e.Reset()
+		if err != nil {
+			return returns[:i], fmt.Errorf("evaluate: could not reset "+
+				"environment: %v", err)
+		}
+
+		for {
+			if render != nil {
+				if err := render(step); err != nil {
+					return returns[:i], fmt.Errorf("evaluate: could not "+
+						"render timestep: %v", err)
+				}
+			}
+			if step.Last() {
+				break
+			}
+
+			// Step with a copy of the action, since environments may
+			// clip actions in place
+			action := a.SelectAction(step)
+			step, _, err = e.Step(mat.VecDenseCopyOf(action))
+			if err != nil {
+				return returns[:i], fmt.Errorf("evaluate: could not step "+
+					"environment: %v", err)
+			}
+			returns[i] += step.Reward
+		}
+
+		a.EndEpisode()
+	}
+
+	return returns, nil
+}
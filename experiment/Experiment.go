@@ -44,6 +44,11 @@ type Experiment interface {
 	// Useful if you want to track data only after a specified event.
 	Register(t tracker.Tracker)
 
+	// Adds a new checkpointer.Checkpointer to the (possibly already
+	// running) experiment. Useful for checkpointing the experiment's
+	// agent, which is only available after the experiment is created.
+	RegisterCheckpointer(c checkpointer.Checkpointer)
+
 	// Saves the current state of all agents
 	checkpoint(ts.TimeStep)
 
@@ -67,11 +72,21 @@ type Config struct {
 	MaxSteps    uint
 	EnvConfig   envconfig.Config
 	AgentConfig agent.TypedConfigList
+
+	// EvalInterval is the number of training episodes between offline
+	// evaluations of the agent, each lasting EvalEpisodes episodes. If
+	// EvalInterval is 0, the agent is not evaluated offline.
+	EvalInterval uint
+	EvalEpisodes uint
 }
 
 // CreateExp creates the experiment determined by the Config
 func (c Config) CreateExp(i int, seed uint64, t []tracker.Tracker,
 	check []checkpointer.Checkpointer) (Experiment, error) {
+	if c.EvalInterval > 0 && c.EvalEpisodes == 0 {
+		return nil, fmt.Errorf("createExp: cannot evaluate for 0 episodes")
+	}
+
 	env, _, err := c.EnvConfig.CreateEnv(seed)
 	if err != nil {
 		return nil, fmt.Errorf("createExpL could not create environment: %v",
@@ -84,7 +99,9 @@ func (c Config) CreateExp(i int, seed uint64, t []tracker.Tracker,
 
 	switch c.Type {
 	case OnlineExp:
-		return NewOnline(env, agent, c.MaxSteps, t, check), nil
+		exp := NewOnline(env, agent, c.MaxSteps, t, check)
+		exp.EvaluateEvery(int(c.EvalInterval), int(c.EvalEpisodes))
+		return exp, nil
 	}
 
 	return nil, fmt.Errorf("createExp: no such experiment type %v", c.Type)
@@ -6,15 +6,18 @@ import (
 
 	ag "github.com/samuelfneumann/golearn/agent"
 	env "github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/wrappers"
 	"github.com/samuelfneumann/golearn/experiment/checkpointer"
 	"github.com/samuelfneumann/golearn/experiment/tracker"
 	ts "github.com/samuelfneumann/golearn/timestep"
 	"github.com/samuelfneumann/progressbar"
 	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
 )
 
-// Online is an Experiment that runs an agent online only. No offline
-// evaluation is performed.
+// Online is an Experiment that runs an agent online. Optionally, the
+// agent can also be evaluated offline every few episodes, see
+// EvaluateEvery().
 type Online struct {
 	environment   env.Environment
 	agent         ag.Agent
@@ -23,6 +26,9 @@ type Online struct {
 	savers        []tracker.Tracker
 	checkpointers []checkpointer.Checkpointer
 	progBar       *progressbar.ProgressBar
+
+	evalInterval int // Number of training episodes between evaluations
+	evalEpisodes int // Number of episodes per evaluation
 }
 
 // NewOnline creates and returns a new online experiment on a given
@@ -51,7 +57,14 @@ func NewOnline(e env.Environment, a ag.Agent, steps uint,
 	// Create a progress bar for watching experiment progress
 	progBar := progressbar.New(50, int(steps), time.Second, true)
 
-	return &Online{e, a, steps, 0, trackers, checkpointers, progBar}
+	return &Online{
+		environment:   e,
+		agent:         a,
+		maxSteps:      steps,
+		savers:        trackers,
+		checkpointers: checkpointers,
+		progBar:       progBar,
+	}
 }
 
 // Register registers a saver.Saver with an Experiment so that data
@@ -60,6 +73,23 @@ func (o *Online) Register(t tracker.Tracker) {
 	o.savers = append(o.savers, t)
 }
 
+// RegisterCheckpointer registers a checkpointer.Checkpointer with an
+// Experiment so that the state of the experiment can be checkpointed
+func (o *Online) RegisterCheckpointer(c checkpointer.Checkpointer) {
+	o.checkpointers = append(o.checkpointers, c)
+}
+
+// EvaluateEvery sets the experiment to evaluate the agent offline for
+// a number of episodes after every interval training episodes. If the
+// environment is recorded by a wrappers.Recorder, all evaluation
+// episodes are recorded. Steps taken during evaluation are neither
+// tracked nor counted towards the experiment's step limit. If interval
+// is 0, the agent is never evaluated.
+func (o *Online) EvaluateEvery(interval, episodes int) {
+	o.evalInterval = interval
+	o.evalEpisodes = episodes
+}
+
 // RunEpisode runs a single episode of the experiment and returns whether
 // the step limit has been reached as well as any errors that occurred
 // during the episode
@@ -123,13 +153,20 @@ func (o *Online) Run() error {
 	var err error
 	o.agent.Train()
 
-	for !ended {
+	for episode := 1; !ended; episode++ {
 		ended, err = o.RunEpisode()
 		if err != nil {
 			return fmt.Errorf("run: could not finsh episode: %v", err)
 		}
 
 		o.agent.EndEpisode()
+
+		// Evaluate the agent offline if needed
+		if o.evalInterval > 0 && episode%o.evalInterval == 0 {
+			if err := o.evaluate(); err != nil {
+				return fmt.Errorf("run: %v", err)
+			}
+		}
 	}
 
 	// Close the environment if needed
@@ -157,6 +194,29 @@ func (o *Online) Save() {
 	}
 }
 
+// evaluate evaluates the agent offline, recording each evaluation
+// episode if the environment is recorded
+func (o *Online) evaluate() error {
+	recorded := env.Unwrap(o.environment, func(e env.Environment) bool {
+		_, ok := e.(*wrappers.Recorder)
+		return ok
+	})
+	if recorded != nil {
+		recorder := recorded.(*wrappers.Recorder)
+		recorder.Record(true)
+		defer recorder.Record(false)
+	}
+
+	returns, err := Evaluate(o.environment, o.agent, o.evalEpisodes, nil)
+	if err != nil {
+		return fmt.Errorf("could not evaluate agent: %v", err)
+	}
+
+	o.progBar.AddMessage(fmt.Sprintf("Evaluation Return: %v",
+		stat.Mean(returns, nil)))
+	return nil
+}
+
 // track tracks the current timestep by caching its data in each saver
 func (o *Online) track(t ts.TimeStep) {
 	for _, saver := range o.savers {
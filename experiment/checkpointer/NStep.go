@@ -57,9 +57,10 @@ func (n *nStep) Checkpoint(t ts.TimeStep) error {
 		enc := gob.NewEncoder(out)
 		err = enc.Encode(n.object)
 		if err != nil {
+			out.Close()
 			return fmt.Errorf("checkpoint: could not checkpoint: %v", err)
 		}
-		return nil
+		return out.Close()
 	}
 	return nil
 }
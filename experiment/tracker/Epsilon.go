@@ -0,0 +1,52 @@
+package tracker
+
+import (
+	"encoding/gob"
+	"log"
+	"os"
+
+	"github.com/samuelfneumann/golearn/agent"
+	ts "github.com/samuelfneumann/golearn/timestep"
+)
+
+// Epsilon tracks and saves the ε of an agent's ε-greedy behaviour
+// policy in an experiment. This is useful for tracking how ε changes
+// when an agent uses an ε schedule.
+type Epsilon struct {
+	agent    agent.EGreedyAgent
+	epsilons []float64
+	filename string
+}
+
+// NewEpsilon returns a new Epsilon Tracker which tracks the ε of agent
+// a and saves its data at the specified location filename
+func NewEpsilon(filename string, a agent.EGreedyAgent) *Epsilon {
+	return &Epsilon{agent: a, filename: filename}
+}
+
+// Track tracks the ε of the agent on each timestep. Since Track is
+// called after each action is taken in the environment, the tracked ε
+// is the ε used to select the action that lead to the TimeStep t. No
+// action leads to the first timestep in an episode, so the ε is not
+// tracked on the first timestep.
+func (e *Epsilon) Track(t ts.TimeStep) {
+	if !t.First() {
+		e.epsilons = append(e.epsilons, e.agent.Epsilon())
+	}
+}
+
+// Save saves the data tracked by the Epsilon Tracker to disk.
+func (e *Epsilon) Save() {
+	// Open the file to save to
+	file, err := os.Create(e.filename)
+	if err != nil {
+		log.Fatalf("could not open save file: %v", err)
+	}
+	defer file.Close()
+
+	// Encode and save the file
+	en := gob.NewEncoder(file)
+	if err = en.Encode(e.epsilons); err != nil {
+		log.Fatalf("Could not encode epsilon data: %v", err)
+	}
+}
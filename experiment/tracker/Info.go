@@ -0,0 +1,49 @@
+package tracker
+
+import (
+	"encoding/gob"
+	"log"
+	"os"
+
+	ts "github.com/samuelfneumann/golearn/timestep"
+)
+
+// Info tracks and saves a single diagnostic stored in the Info of
+// TimeSteps in an experiment, such as the distance to a goal. The
+// diagnostic is tracked on each TimeStep which has a value for it.
+// TimeSteps without a value for the diagnostic, such as the first
+// TimeStep in an episode for some environments, are ignored.
+type Info struct {
+	key      string
+	values   []float64
+	filename string
+}
+
+// NewInfo returns a new Info Tracker which tracks the diagnostic key
+// and saves its data at the specified location filename
+func NewInfo(filename, key string) *Info {
+	return &Info{key: key, filename: filename}
+}
+
+// Track tracks the value of the diagnostic on a timestep
+func (i *Info) Track(t ts.TimeStep) {
+	if value, ok := t.GetInfo(i.key); ok {
+		i.values = append(i.values, value)
+	}
+}
+
+// Save saves the data tracked by the Info Tracker to disk.
+func (i *Info) Save() {
+	// Open the file to save to
+	file, err := os.Create(i.filename)
+	if err != nil {
+		log.Fatalf("could not open save file: %v", err)
+	}
+	defer file.Close()
+
+	// Encode and save the file
+	en := gob.NewEncoder(file)
+	if err = en.Encode(i.values); err != nil {
+		log.Fatalf("Could not encode %v data: %v", i.key, err)
+	}
+}
@@ -10,6 +10,7 @@ require (
 	github.com/samuelfneumann/progressbar v0.0.0-20210809184043-f04c52f01c18
 	golang.org/x/exp v0.0.0-20210729172720-737cce5152fc
 	gonum.org/v1/gonum v0.9.3
+	google.golang.org/protobuf v1.25.0
 	gorgonia.org/gorgonia v0.9.17
 	gorgonia.org/tensor v0.9.20
 )
@@ -31,7 +32,6 @@ require (
 	golang.org/x/image v0.0.0-20210216034530-4410531fe030 // indirect
 	golang.org/x/tools v0.1.0 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
-	google.golang.org/protobuf v1.25.0 // indirect
 	gorgonia.org/cu v0.9.3 // indirect
 	gorgonia.org/dawson v1.2.0 // indirect
 	gorgonia.org/vecf32 v0.9.0 // indirect
@@ -0,0 +1,61 @@
+// Package agenttest implements tests which are shared between agents.
+// It is only used by the tests of other packages.
+package agenttest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	"github.com/samuelfneumann/golearn/environment/constant"
+	ts "github.com/samuelfneumann/golearn/timestep"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Bootstrap tests that an agent bootstraps from the last state of
+// truncated episodes but not from the last state of terminated
+// episodes, so that its value estimates are unbiased.
+//
+// The agent is created by newAgent in a constant.Constant environment
+// with a single action and a reward of 1. After learning, value should
+// return the agent's estimate of the value of the single action or of
+// the single state, which is compared to the true value for both
+// truncated and terminated episodes.
+func Bootstrap(t *testing.T,
+	newAgent func(environment.Environment) (agent.Agent, error),
+	value func(agent.Agent) float64) {
+	t.Helper()
+
+	const discount = 0.9
+	terminate := func(*mat.VecDense) bool { return true }
+
+	tests := []struct {
+		name  string
+		ender environment.Ender
+		value float64
+	}{
+		{"Truncated", environment.NewStepLimit(3), 1 / (1 - discount)},
+		{"Terminal", environment.NewFunctionEnder(terminate,
+			ts.TerminalStateReached), 1},
+	}
+
+	for _, test := range tests {
+		env, step, err := constant.New(1, 1.0, test.ender, discount)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := newAgent(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		Run(t, a, env, step, 3000)
+
+		if v := value(a); math.Abs(v-test.value) > 1e-3 {
+			t.Errorf("%v: value estimate: have(%v) want(%v)", test.name, v,
+				test.value)
+		}
+	}
+}
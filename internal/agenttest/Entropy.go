@@ -0,0 +1,25 @@
+package agenttest
+
+import "math"
+
+// SoftmaxEntropy returns the entropy of the categorical distribution
+// with the given logits
+func SoftmaxEntropy(logits []float64) float64 {
+	max := math.Inf(-1)
+	for _, logit := range logits {
+		max = math.Max(max, logit)
+	}
+
+	sum := 0.0
+	for _, logit := range logits {
+		sum += math.Exp(logit - max)
+	}
+	logSumExp := max + math.Log(sum)
+
+	entropy := 0.0
+	for _, logit := range logits {
+		logProb := logit - logSumExp
+		entropy -= math.Exp(logProb) * logProb
+	}
+	return entropy
+}
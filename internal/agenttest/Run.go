@@ -0,0 +1,46 @@
+package agenttest
+
+import (
+	"testing"
+
+	"github.com/samuelfneumann/golearn/agent"
+	"github.com/samuelfneumann/golearn/environment"
+	ts "github.com/samuelfneumann/golearn/timestep"
+)
+
+// Run runs agent a in environment env for a number of steps, starting
+// from the first step of an episode. The agent observes every
+// transition and takes a learning step after each, and episodes are
+// restarted when they end.
+func Run(t *testing.T, a agent.Agent, env environment.Environment,
+	step ts.TimeStep, steps int) {
+	t.Helper()
+
+	if err := a.ObserveFirst(step); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < steps; i++ {
+		action := a.SelectAction(step)
+		var err error
+		step, _, err = env.Step(action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Observe(action, step); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Step(); err != nil {
+			t.Fatal(err)
+		}
+
+		if step.Last() {
+			a.EndEpisode()
+			if step, err = env.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.ObserveFirst(step); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
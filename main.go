@@ -1,53 +1,69 @@
 package main
 
 import (
+	"encoding/gob"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
 
 	// Blank imports needed for registering agents with agent package
 	// to enable TypedConfigList's
 	"github.com/samuelfneumann/gogym"
+	"github.com/samuelfneumann/golearn/agent"
 	_ "github.com/samuelfneumann/golearn/agent/linear/continuous/actorcritic"
 	_ "github.com/samuelfneumann/golearn/agent/linear/discrete/esarsa"
 	_ "github.com/samuelfneumann/golearn/agent/linear/discrete/qlearning"
 	_ "github.com/samuelfneumann/golearn/agent/nonlinear/continuous/vanillaac"
 	_ "github.com/samuelfneumann/golearn/agent/nonlinear/continuous/vanillapg"
 	_ "github.com/samuelfneumann/golearn/agent/nonlinear/discrete/deepq"
+	_ "github.com/samuelfneumann/golearn/agent/random"
 
+	"github.com/samuelfneumann/golearn/environment"
 	"github.com/samuelfneumann/golearn/experiment"
 	"github.com/samuelfneumann/golearn/experiment/checkpointer"
 	"github.com/samuelfneumann/golearn/experiment/tracker"
+	ts "github.com/samuelfneumann/golearn/timestep"
 )
 
 func main() {
-	if len(os.Args) != 3 {
-		printHelp()
-
-		os.Exit(1)
+	if len(os.Args) > 1 && os.Args[1] == "play" {
+		play(os.Args[2:])
+	} else {
+		train(os.Args[1:])
 	}
 
-	expFile, err := os.Open(os.Args[1])
-	if err != nil {
-		panic(err)
-	}
-	dec := json.NewDecoder(expFile)
+	// Clean up GoGym package before leaving
+	gogym.Close()
+}
 
-	var expConf experiment.Config
-	err = dec.Decode(&expConf)
-	if err != nil {
-		panic(fmt.Sprintf("could not decode experiment config: %v",
-			err))
+// train runs the experiment described by the command line arguments
+func train(args []string) {
+	flags := flag.NewFlagSet("train", flag.ExitOnError)
+	flags.Usage = printHelp
+	interval := flags.Int("checkpoint", 0, "checkpoint the agent every "+
+		"`n` steps of each episode")
+	info := flags.String("info", "", "track the comma-separated diagnostic "+
+		"`keys` of the environment's TimeStep Info")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		printHelp()
+
+		os.Exit(1)
 	}
-	expFile.Close()
 
+	expConf := loadConfig(flags.Arg(0))
 	numSettings := int64(expConf.AgentConfig.Len())
-	hpIndex, err := strconv.ParseInt(os.Args[2], 0, 0)
-	if err != nil {
-		panic(err)
-	}
+	hpIndex := parseIndex(flags.Arg(1))
 	run := uint64(hpIndex / numSettings)
 
 	// Print some information about the experiment
@@ -79,6 +95,18 @@ func main() {
 		expConf.EnvConfig.Environment,
 		run,
 	)
+	epsilonFilename := fmt.Sprintf(
+		"epsilon_%v_%v_run%v.bin",
+		expConf.AgentConfig.Type,
+		expConf.EnvConfig.Environment,
+		run,
+	)
+	checkpointFilename := fmt.Sprintf(
+		"checkpoint_%v_%v_run%v",
+		expConf.AgentConfig.Type,
+		expConf.EnvConfig.Environment,
+		run,
+	)
 
 	// Create trackers to track and save data from experiment
 	trackers := []tracker.Tracker{
@@ -86,31 +114,228 @@ func main() {
 		tracker.NewEpisodeLength(epLengthFilename),
 	}
 
-	// Don't checkpoint agents
+	// Track the requested environment diagnostics
+	if *info != "" {
+		for _, key := range strings.Split(*info, ",") {
+			infoFilename := fmt.Sprintf(
+				"info_%v_%v_%v_run%v.bin",
+				key,
+				expConf.AgentConfig.Type,
+				expConf.EnvConfig.Environment,
+				run,
+			)
+			trackers = append(trackers, tracker.NewInfo(infoFilename, key))
+		}
+	}
+
+	// Checkpointers are registered once the agent has been created
 	var checkpointers []checkpointer.Checkpointer = nil
 
 	exp, err := expConf.CreateExp(int(hpIndex), run, trackers, checkpointers)
 	if err != nil {
 		log.Printf("Error creating experiment: %v\n", err)
 		log.Println("Terminating...")
+		os.Exit(1)
+	}
+
+	// Track the behaviour policy's epsilon for epsilon-greedy agents
+	if a, ok := exp.Agent().(agent.EGreedyAgent); ok {
+		exp.Register(tracker.NewEpsilon(epsilonFilename, a))
 	}
+
+	// Checkpoint the agent during training if requested
+	serializable, canCheckpoint := exp.Agent().(checkpointer.Serializable)
+	if *interval > 0 {
+		if !canCheckpoint {
+			log.Printf("Agent %v cannot be checkpointed\n",
+				expConf.AgentConfig.Type)
+		} else {
+			exp.RegisterCheckpointer(checkpointer.NewNStep(*interval,
+				serializable, checkpointer.FilenameEnumerator(0,
+					checkpointFilename+"_", ".bin")))
+		}
+	}
+
 	if err := exp.Run(); err != nil {
 		log.Printf("Error in running experiment: %v\n", err)
 		log.Println("Terminating...")
 	}
 	exp.Save()
 
+	// Save the final agent so that it can be evaluated with play
+	if canCheckpoint {
+		if err := save(serializable, checkpointFilename+".bin"); err != nil {
+			log.Printf("Error checkpointing agent: %v\n", err)
+		}
+	}
+
 	// LoadData -> should be int or float specified...
 	data := tracker.LoadFData(returnFilename)
 	fmt.Println(data)
+}
 
-	// Clean up GoGym package before leaving
-	gogym.Close()
+// play evaluates a checkpointed agent as described by the command line
+// arguments
+func play(args []string) {
+	flags := flag.NewFlagSet("play", flag.ExitOnError)
+	flags.Usage = printHelp
+	renderDir := flags.String("render", "", "save rendered frames as PNG "+
+		"images in `dir`")
+	scale := flags.Float64("scale", 10, "number of pixels per unit of the "+
+		"environment's drawing size")
+	width := flags.Int("width", 500, "width of rendered frames in pixels")
+	height := flags.Int("height", 500, "height of rendered frames in pixels")
+	flags.Parse(args)
+
+	if flags.NArg() != 4 {
+		printHelp()
+
+		os.Exit(1)
+	}
+
+	expConf := loadConfig(flags.Arg(0))
+	numSettings := int64(expConf.AgentConfig.Len())
+	hpIndex := parseIndex(flags.Arg(1))
+	run := uint64(hpIndex / numSettings)
+
+	episodes, err := strconv.Atoi(flags.Arg(3))
+	if err != nil || episodes < 1 {
+		log.Fatalf("Invalid number of episodes: %v\n", flags.Arg(3))
+	}
+
+	// Rebuild the environment and agent
+	env, _, err := expConf.EnvConfig.CreateEnv(run)
+	if err != nil {
+		log.Fatalf("Error creating environment: %v\n", err)
+	}
+	a, err := expConf.AgentConfig.At(int(hpIndex)).CreateAgent(env, run)
+	if err != nil {
+		log.Fatalf("Error creating agent: %v\n", err)
+	}
+
+	serializable, ok := a.(checkpointer.Serializable)
+	if !ok {
+		log.Fatalf("Agent %v cannot be loaded from a checkpoint\n",
+			expConf.AgentConfig.Type)
+	}
+	if err := load(serializable, flags.Arg(2)); err != nil {
+		log.Fatalf("Error loading checkpoint: %v\n", err)
+	}
+
+	// Render frames if requested and possible
+	var render func(ts.TimeStep) error
+	if *renderDir != "" {
+		pixelEnv, ok := environment.Unwrap(env,
+			func(e environment.Environment) bool {
+				_, ok := e.(environment.PixelEnvironment)
+				return ok
+			}).(environment.PixelEnvironment)
+		if !ok {
+			log.Fatalf("Environment %v cannot be rendered\n",
+				expConf.EnvConfig.Environment)
+		}
+		if err := os.MkdirAll(*renderDir, 0755); err != nil {
+			log.Fatalf("Error creating render directory: %v\n", err)
+		}
+
+		episode := 0
+		render = func(t ts.TimeStep) error {
+			if t.First() {
+				episode++
+			}
+			dc := gg.NewContext(*width, *height)
+			img := pixelEnv.Pixels(*scale, *dc, true)
+
+			filename := filepath.Join(*renderDir,
+				fmt.Sprintf("ep%03d_step%05d.png", episode, t.Number))
+			return gg.SavePNG(filename, img)
+		}
+	}
+
+	returns, err := experiment.Evaluate(env, a, episodes, render)
+	if err != nil {
+		log.Printf("Error evaluating agent: %v\n", err)
+	}
+
+	// Print the returns and their statistics
+	for i, ret := range returns {
+		fmt.Printf("Episode %d: \t%v\n", i+1, ret)
+	}
+	if len(returns) > 0 {
+		mean, std := stat.MeanStdDev(returns, nil)
+		fmt.Println()
+		fmt.Printf("Mean: \t%v\n", mean)
+		fmt.Printf("Std: \t%v\n", std)
+		fmt.Printf("Min: \t%v\n", floats.Min(returns))
+		fmt.Printf("Max: \t%v\n", floats.Max(returns))
+	}
+
+	if closer, ok := env.(environment.Closer); ok {
+		closer.Close()
+	}
+	if closer, ok := a.(agent.Closer); ok {
+		closer.Close()
+	}
+}
+
+// loadConfig loads the experiment configuration from a JSON file
+func loadConfig(filename string) experiment.Config {
+	expFile, err := os.Open(filename)
+	if err != nil {
+		panic(err)
+	}
+	dec := json.NewDecoder(expFile)
+
+	var expConf experiment.Config
+	err = dec.Decode(&expConf)
+	if err != nil {
+		panic(fmt.Sprintf("could not decode experiment config: %v",
+			err))
+	}
+	expFile.Close()
+
+	return expConf
+}
+
+// parseIndex parses a hyperparameter setting index
+func parseIndex(index string) int64 {
+	hpIndex, err := strconv.ParseInt(index, 0, 0)
+	if err != nil {
+		panic(err)
+	}
+	return hpIndex
+}
+
+// save saves a checkpoint of a Serializable to a file
+func save(s checkpointer.Serializable, filename string) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := gob.NewEncoder(out).Encode(s); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// load loads a checkpoint saved by a checkpointer into a Serializable
+func load(s checkpointer.Serializable, filename string) error {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return gob.NewDecoder(in).Decode(s)
 }
 
 // printHelp prints a help menu that outlines the usage of the command
 func printHelp() {
-	msg := fmt.Sprintf("\nusage: %v config index", os.Args[0])
+	msg := fmt.Sprintf("\nusage: %v [-checkpoint n] [-info keys] config index", os.Args[0])
+	msg += fmt.Sprintf("\n       %v play [-render dir] [-scale s] "+
+		"[-width w] [-height h] config index checkpoint episodes", os.Args[0])
 
 	fmt.Println(msg)
 }
@@ -0,0 +1,27 @@
+package network
+
+// Backend describes which implementation of neural networks an agent
+// uses
+type Backend string
+
+const (
+	// Gorgonia uses the networks in this package, which are built on
+	// Gorgonia computational graphs. This is the default backend.
+	Gorgonia Backend = "Gorgonia"
+
+	// Gonum uses the networks in package gonumnet, which are built
+	// directly on gonum matrices. These networks have much less
+	// overhead than Gorgonia networks, but support only fully
+	// connected layers with no layer options.
+	Gonum Backend = "Gonum"
+)
+
+// IsValid returns whether the Backend is a valid Backend. The empty
+// Backend is valid and refers to the default Gorgonia backend.
+func (b Backend) IsValid() bool {
+	switch b {
+	case "", Gorgonia, Gonum:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,496 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"strings"
+
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// ConvLayerType determines the type of a layer in the convolutional
+// portion of a ConvMLP
+type ConvLayerType string
+
+// Types of convolutional layers
+const (
+	Conv2D  ConvLayerType = "Conv2D"
+	MaxPool ConvLayerType = "MaxPool"
+	AvgPool ConvLayerType = "AvgPool"
+)
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (c *ConvLayerType) UnmarshalJSON(data []byte) error {
+	decoded := ConvLayerType(strings.Trim(string(data), "\""))
+	switch decoded {
+	case Conv2D, MaxPool, AvgPool:
+		*c = decoded
+		return nil
+
+	default:
+		return fmt.Errorf("unmarshalJSON: illegal ConvLayerType %v", decoded)
+	}
+}
+
+// ConvLayerConfig describes a single 2D convolutional or pooling layer
+// of a ConvMLP. ConvLayerConfigs are JSON serializable.
+//
+// Kernel, Stride, and Padding should each have two elements, giving
+// the value for the height and width dimensions respectively. If
+// Stride is nil, a stride of 1 is used in both dimensions for
+// convolutional layers, and a stride equal to the kernel size is used
+// for pooling layers. If Padding is nil, no padding is used.
+//
+// The Filters, Bias, and Activation fields are only used by Conv2D
+// layers and are ignored by pooling layers. A nil Activation is
+// treated as the identity.
+type ConvLayerConfig struct {
+	Type       ConvLayerType
+	Filters    int
+	Kernel     []int
+	Stride     []int
+	Padding    []int
+	Bias       bool
+	Activation *Activation
+}
+
+// stride returns the stride of the layer, replacing a nil Stride with
+// the default stride of the layer type
+func (c ConvLayerConfig) stride() []int {
+	if c.Stride != nil {
+		return c.Stride
+	}
+	if c.Type == Conv2D {
+		return []int{1, 1}
+	}
+	return c.Kernel
+}
+
+// padding returns the padding of the layer, replacing a nil Padding
+// with no padding
+func (c ConvLayerConfig) padding() []int {
+	if c.Padding != nil {
+		return c.Padding
+	}
+	return []int{0, 0}
+}
+
+// Validate returns an error describing whether the ConvLayerConfig is
+// valid
+func (c ConvLayerConfig) Validate() error {
+	if c.Type != Conv2D && c.Type != MaxPool && c.Type != AvgPool {
+		return fmt.Errorf("validate: illegal layer type %v", c.Type)
+	}
+
+	if c.Type == Conv2D && c.Filters < 1 {
+		return fmt.Errorf("validate: convolutional layers must have a "+
+			"positive number of filters \n\thave(%v)", c.Filters)
+	}
+
+	if len(c.Kernel) != 2 || c.Kernel[0] < 1 || c.Kernel[1] < 1 {
+		return fmt.Errorf("validate: kernel must have 2 positive "+
+			"elements \n\thave(%v)", c.Kernel)
+	}
+
+	if stride := c.stride(); len(stride) != 2 || stride[0] < 1 ||
+		stride[1] < 1 {
+		return fmt.Errorf("validate: stride must have 2 positive "+
+			"elements \n\thave(%v)", stride)
+	}
+
+	if padding := c.padding(); len(padding) != 2 || padding[0] < 0 ||
+		padding[1] < 0 {
+		return fmt.Errorf("validate: padding must have 2 non-negative "+
+			"elements \n\thave(%v)", padding)
+	}
+
+	return nil
+}
+
+// OutputShape returns the (channels, height, width) shape of the
+// output of the layer given an input of shape (channels, height,
+// width).
+func (c ConvLayerConfig) OutputShape(channels, height,
+	width int) (int, int, int) {
+	stride, padding := c.stride(), c.padding()
+	outHeight := (height+2*padding[0]-c.Kernel[0])/stride[0] + 1
+	outWidth := (width+2*padding[1]-c.Kernel[1])/stride[1] + 1
+
+	if c.Type == Conv2D {
+		channels = c.Filters
+	}
+	return channels, outHeight, outWidth
+}
+
+// convLayer implements a 2D convolutional layer of a neural network.
+// Inputs to the layer should be in (batch, channels, height, width)
+// format.
+type convLayer struct {
+	weights *G.Node // Filters, shape (filters, channels, height, width)
+	bias    *G.Node // Shape (1, filters, 1, 1)
+	act     *Activation
+
+	kernel  tensor.Shape
+	stride  []int
+	padding []int
+}
+
+// fwd adds the forward pass of the convLayer to the computational
+// graph
+func (c *convLayer) fwd(x *G.Node) (*G.Node, error) {
+	x, err := G.Conv2d(x, c.Weights(), c.kernel, c.padding, c.stride, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fwd: could not compute convolution: %v", err)
+	}
+
+	if c.Bias() != nil {
+		// Broadcast the bias weights to all samples along the batch,
+		// height, and width dimensions
+		x, err = G.BroadcastAdd(x, c.Bias(), nil, []byte{0, 2, 3})
+		if err != nil {
+			return nil, fmt.Errorf("fwd: could not add bias: %v", err)
+		}
+	}
+
+	if act := c.Activation(); act == nil || act.IsIdentity() ||
+		act.IsNil() {
+		return x, nil
+	}
+	return c.Activation().fwd(x)
+}
+
+// CloneTo clones a convLayer to a new computational graph
+func (c *convLayer) CloneTo(g *G.ExprGraph) Layer {
+	var newBias *G.Node
+	if c.Bias() != nil {
+		newBias = c.Bias().CloneTo(g)
+	}
+
+	return &convLayer{
+		weights: c.Weights().CloneTo(g),
+		bias:    newBias,
+		act:     c.act,
+		kernel:  c.kernel,
+		stride:  c.stride,
+		padding: c.padding,
+	}
+}
+
+// Activation returns the activation of the layer
+func (c *convLayer) Activation() *Activation {
+	return c.act
+}
+
+// Bias returns the bias of the layer
+func (c *convLayer) Bias() *G.Node {
+	return c.bias
+}
+
+// Weights returns the filters of the layer
+func (c *convLayer) Weights() *G.Node {
+	return c.weights
+}
+
+// GobEncode implements the gob.GobEncoder interface.
+//
+// Similar to fcLayer, only the values of the filters and bias are
+// encoded. The shapes of the filters and bias are determined by
+// the ConvLayerConfig used to construct the layer.
+func (c *convLayer) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	err := enc.Encode(c.Weights().Value())
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode weights: %v", err)
+	}
+
+	hasBias := c.Bias() != nil
+	err = enc.Encode(hasBias)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode bias flag: %v",
+			err)
+	}
+
+	if hasBias {
+		err = enc.Encode(c.Bias().Value())
+		if err != nil {
+			return nil, fmt.Errorf("gobencode: could not encode bias: %v",
+				err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface.
+//
+// Similar to fcLayer, the convLayer must already be initialized with
+// filters and bias of the same shape as those of the encoded
+// convLayer.
+func (c *convLayer) GobDecode(in []byte) error {
+	if c.Weights() == nil {
+		return fmt.Errorf("gobdecode: convLayer must have all node " +
+			"pointers initialized and registered with a graph before " +
+			"decoding")
+	}
+
+	buf := bytes.NewReader(in)
+	dec := gob.NewDecoder(buf)
+
+	var weights *tensor.Dense
+	err := dec.Decode(&weights)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode weights: %v", err)
+	}
+	err = G.Let(c.Weights(), weights)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not set weights: %v", err)
+	}
+
+	var hasBias bool
+	err = dec.Decode(&hasBias)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode bias flag: %v", err)
+	}
+	if hasBias != (c.Bias() != nil) {
+		return fmt.Errorf("gobdecode: bias mismatch between encoded and " +
+			"decoded layer")
+	}
+
+	if hasBias {
+		var bias *tensor.Dense
+		err = dec.Decode(&bias)
+		if err != nil {
+			return fmt.Errorf("gobdecode: could not decode bias: %v", err)
+		}
+		err = G.Let(c.Bias(), bias)
+		if err != nil {
+			return fmt.Errorf("gobdecode: could not set bias: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// poolLayer implements a 2D max or average pooling layer of a neural
+// network. Inputs to the layer should be in (batch, channels, height,
+// width) format. Pooling layers have no weights, bias, or activation.
+type poolLayer struct {
+	poolType ConvLayerType
+	kernel   tensor.Shape
+	stride   []int
+	padding  []int
+}
+
+// fwd adds the forward pass of the poolLayer to the computational
+// graph
+func (p *poolLayer) fwd(x *G.Node) (*G.Node, error) {
+	switch p.poolType {
+	case MaxPool:
+		return G.MaxPool2D(x, p.kernel, p.padding, p.stride)
+
+	case AvgPool:
+		return avgPool2D(x, p.kernel, p.padding, p.stride)
+
+	default:
+		return nil, fmt.Errorf("fwd: illegal pooling type %v", p.poolType)
+	}
+}
+
+// CloneTo clones a poolLayer to a new computational graph
+func (p *poolLayer) CloneTo(g *G.ExprGraph) Layer {
+	return &poolLayer{
+		poolType: p.poolType,
+		kernel:   p.kernel,
+		stride:   p.stride,
+		padding:  p.padding,
+	}
+}
+
+// Activation returns the activation of the layer, which is always nil
+func (p *poolLayer) Activation() *Activation {
+	return nil
+}
+
+// Bias returns the bias of the layer, which is always nil
+func (p *poolLayer) Bias() *G.Node {
+	return nil
+}
+
+// Weights returns the weights of the layer, which are always nil
+func (p *poolLayer) Weights() *G.Node {
+	return nil
+}
+
+// avgPool2D applies average pooling to an input node in (batch,
+// channels, height, width) format. Gorgonia does not provide average
+// pooling, so the pooling windows are first extracted with Im2Col and
+// then averaged.
+func avgPool2D(x *G.Node, kernel tensor.Shape, pad, stride []int) (*G.Node,
+	error) {
+	channels := x.Shape()[1]
+	cols, err := G.Im2Col(x, kernel, tensor.Shape(pad), tensor.Shape(stride),
+		tensor.Shape{1, 1})
+	if err != nil {
+		return nil, fmt.Errorf("avgPool2D: could not extract pooling "+
+			"windows: %v", err)
+	}
+
+	// Im2Col returns shape (batch, outHeight, outWidth, channels *
+	// kernel[0] * kernel[1]), with the elements of each pooling window
+	// stored contiguously for each channel.
+	batch, outHeight, outWidth := cols.Shape()[0], cols.Shape()[1],
+		cols.Shape()[2]
+	windows, err := G.Reshape(cols, tensor.Shape{
+		batch * outHeight * outWidth * channels,
+		kernel[0] * kernel[1],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("avgPool2D: could not reshape pooling "+
+			"windows: %v", err)
+	}
+
+	pooled, err := G.Mean(windows, 1)
+	if err != nil {
+		return nil, fmt.Errorf("avgPool2D: could not average pooling "+
+			"windows: %v", err)
+	}
+
+	pooled, err = G.Reshape(pooled, tensor.Shape{batch, outHeight, outWidth,
+		channels})
+	if err != nil {
+		return nil, fmt.Errorf("avgPool2D: could not reshape output: %v",
+			err)
+	}
+
+	return G.Transpose(pooled, 0, 3, 1, 2)
+}
+
+// flattenLayer implements a layer which flattens its input to a
+// matrix of shape (batch, features). It is used to connect the
+// convolutional layers of a ConvMLP to its fully connected layers.
+type flattenLayer struct{}
+
+// fwd adds the forward pass of the flattenLayer to the computational
+// graph
+func (f *flattenLayer) fwd(x *G.Node) (*G.Node, error) {
+	shape := x.Shape()
+	features := 1
+	for _, dim := range shape[1:] {
+		features *= dim
+	}
+
+	return G.Reshape(x, tensor.Shape{shape[0], features})
+}
+
+// CloneTo clones a flattenLayer to a new computational graph
+func (f *flattenLayer) CloneTo(g *G.ExprGraph) Layer {
+	return &flattenLayer{}
+}
+
+// Activation returns the activation of the layer, which is always nil
+func (f *flattenLayer) Activation() *Activation {
+	return nil
+}
+
+// Bias returns the bias of the layer, which is always nil
+func (f *flattenLayer) Bias() *G.Node {
+	return nil
+}
+
+// Weights returns the weights of the layer, which are always nil
+func (f *flattenLayer) Weights() *G.Node {
+	return nil
+}
+
+// addConvLayers adds convolutional and pooling layers to a
+// computational graph and returns a slice of the layers which were
+// added to the graph. For integer i, configs[i] describes the ith
+// layer. The input to the first layer is assumed to have shape
+// (batch, channels, height, width).
+//
+// The parameters prefix and suffix refer to the prefix and suffix to
+// add to the names of the filters and biases of the convolutional
+// layers.
+//
+// Along with the layers, the (channels, height, width) shape of the
+// output of the final layer is returned. Similar to addfcLayers, this
+// function only adds nodes to the graph g and does not perform the
+// forward pass.
+func addConvLayers(g *G.ExprGraph, configs []ConvLayerConfig,
+	init G.InitWFn, channels, height, width int,
+	prefix, suffix string) ([]Layer, []int, error) {
+	layers := make([]Layer, 0, len(configs))
+	for i, config := range configs {
+		if err := config.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("addConvLayers: invalid layer %v: %v",
+				i, err)
+		}
+
+		kernel := tensor.Shape{config.Kernel[0], config.Kernel[1]}
+		stride, padding := config.stride(), config.padding()
+
+		outChannels, outHeight, outWidth := config.OutputShape(channels,
+			height, width)
+		if outHeight < 1 || outWidth < 1 {
+			return nil, nil, fmt.Errorf("addConvLayers: layer %v reduces "+
+				"input of shape %v to an empty output", i,
+				[]int{channels, height, width})
+		}
+
+		var layer Layer
+		if config.Type == Conv2D {
+			weightName := fmt.Sprintf("%vC%dW%v", prefix, i, suffix)
+			weights := G.NewTensor(
+				g,
+				tensor.Float64,
+				4,
+				G.WithShape(config.Filters, channels, kernel[0], kernel[1]),
+				G.WithName(weightName),
+				G.WithInit(init),
+			)
+
+			var bias *G.Node
+			if config.Bias {
+				biasName := fmt.Sprintf("%vC%dB%v", prefix, i, suffix)
+				bias = G.NewTensor(
+					g,
+					tensor.Float64,
+					4,
+					G.WithShape(1, config.Filters, 1, 1),
+					G.WithName(biasName),
+					G.WithInit(init),
+				)
+			}
+
+			act := config.Activation
+			if act == nil {
+				act = Identity()
+			}
+
+			layer = &convLayer{
+				weights: weights,
+				bias:    bias,
+				act:     act,
+				kernel:  kernel,
+				stride:  stride,
+				padding: padding,
+			}
+		} else {
+			layer = &poolLayer{
+				poolType: config.Type,
+				kernel:   kernel,
+				stride:   stride,
+				padding:  padding,
+			}
+		}
+		layers = append(layers, layer)
+
+		channels, height, width = outChannels, outHeight, outWidth
+	}
+
+	return layers, []int{channels, height, width}, nil
+}
@@ -0,0 +1,529 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// ConvMLP implements a convolutional neural network, consisting of a
+// number of 2D convolutional and pooling layers followed by a
+// multi-layered perceptron with multiple output nodes.
+//
+// Inputs to the ConvMLP are flattened images of shape (channels,
+// height, width) stored in channel-major order. That is, the input
+// for channel c at row i and column j of the image is located at
+// index (c * height * width) + (i * width) + j. Within the network,
+// inputs are reshaped to (batch, channels, height, width) before
+// the convolutional layers. The output of the final convolutional
+// layer is then flattened and passed to the fully connected layers.
+type ConvMLP struct {
+	g          *G.ExprGraph
+	layers     []Layer // Convolutional, flatten, and fully connected layers
+	input      *G.Node
+	numOutputs int
+	inputShape []int // (channels, height, width)
+	batchSize  int
+
+	// Data needed for gobbing
+	convLayers  []ConvLayerConfig
+	hiddenSizes []int
+	biases      []bool
+	activations []*Activation
+
+	learnables G.Nodes
+	model      []G.ValueGrad
+
+	prediction *G.Node
+	predVal    G.Value
+}
+
+// NewConvMLP creates and returns a new ConvMLP that has multiple
+// output nodes. The number of output nodes is equal to outputs. The
+// graph parameter g is populated with the network. Inputs to the
+// network are flattened images of shape (channels, height, width).
+//
+// For index i, convLayers[i] describes the ith convolutional or
+// pooling layer of the network. The output of the final convolutional
+// layer is flattened and passed to len(hiddenSizes) fully connected
+// layers, where hiddenSizes[i] is the number of nodes in fully
+// connected layer i; biases[i] is true if fully connected layer i will
+// contain a bias unit and false otherwise; and activations[i] is the
+// activation function for fully connected layer i. Similar to
+// NewMultiHeadMLP, a final linear layer with a bias unit is always
+// added so that the network produces outputs predictions. The
+// parameter init determines the weight initialization scheme for both
+// the convolutional and fully connected layers.
+func NewConvMLP(channels, height, width, batch, outputs int,
+	g *G.ExprGraph, convLayers []ConvLayerConfig, hiddenSizes []int,
+	biases []bool, init G.InitWFn,
+	activations []*Activation) (NeuralNet, error) {
+	// Set up the input node
+	input := G.NewMatrix(g, tensor.Float64,
+		G.WithShape(batch, channels*height*width), G.WithName("input"),
+		G.WithInit(G.Zeroes()))
+
+	return newConvMLPFromInput([]*G.Node{input}, channels, height, width,
+		outputs, g, convLayers, hiddenSizes, biases, init, activations, "",
+		"")
+}
+
+// newConvMLPFromInput returns a new ConvMLP that has a specific node
+// as its input node. If multiple input nodes are given, they are first
+// concatenated along the feature (column) dimension.
+func newConvMLPFromInput(inputs []*G.Node, channels, height, width,
+	outputs int, g *G.ExprGraph, convLayers []ConvLayerConfig,
+	hiddenSizes []int, biases []bool, init G.InitWFn,
+	activations []*Activation, prefix, suffix string) (NeuralNet, error) {
+	network := &ConvMLP{}
+	err := network.build(inputs, channels, height, width, outputs, g,
+		convLayers, hiddenSizes, biases, init, activations, prefix, suffix)
+	if err != nil {
+		return &ConvMLP{}, err
+	}
+
+	return network, nil
+}
+
+// build adds the layers of a ConvMLP to the computational graph g,
+// stores them in the receiver, and runs the forward pass on the input
+// nodes. Building the network in place ensures that the output value
+// read from the graph is stored in the receiver.
+//
+// See newConvMLPFromInput for a description of the parameters.
+func (c *ConvMLP) build(inputs []*G.Node, channels, height, width,
+	outputs int, g *G.ExprGraph, convLayers []ConvLayerConfig,
+	hiddenSizes []int, biases []bool, init G.InitWFn,
+	activations []*Activation, prefix, suffix string) error {
+	// Ensure we have one activation per layer
+	if len(hiddenSizes) != len(activations) {
+		msg := "newConvMLP: invalid number of activations" +
+			"\n\twant(%d)\n\thave(%d)"
+		return fmt.Errorf(msg, len(hiddenSizes), len(activations))
+	}
+
+	// Ensure one bias bool per layer
+	if len(hiddenSizes) != len(biases) {
+		msg := "newConvMLP: invalid number of biases\n\twant(%d)" +
+			"\n\thave(%d)"
+		return fmt.Errorf(msg, len(hiddenSizes), len(biases))
+	}
+
+	// Concatenate inputs if necessary
+	var input *G.Node
+	if len(inputs) > 1 {
+		input = G.Must(G.Concat(1, inputs...))
+	} else {
+		input = inputs[0]
+	}
+
+	if !input.IsMatrix() {
+		return fmt.Errorf("newConvMLP: input must be a matrix")
+	}
+
+	if features := input.Shape()[1]; features != channels*height*width {
+		return fmt.Errorf("newConvMLP: input features do not match "+
+			"image shape %v \n\twant(%v) \n\thave(%v)",
+			[]int{channels, height, width}, channels*height*width, features)
+	}
+
+	// Create the convolutional layers
+	layers, outShape, err := addConvLayers(g, convLayers, init, channels,
+		height, width, prefix, suffix)
+	if err != nil {
+		return fmt.Errorf("newConvMLP: could not create convolutional "+
+			"layers: %v", err)
+	}
+	layers = append(layers, &flattenLayer{})
+
+	// Create the fully connected layers, adding a final linear layer
+	// with no activation to ensure output heads are predicted by the
+	// network. Copies are made so that the arguments are not modified.
+	hiddenSizes = append(append([]int{}, hiddenSizes...), outputs)
+	biases = append(append([]bool{}, biases...), true)
+	activations = append(append([]*Activation{}, activations...),
+		Identity())
+
+	features := outShape[0] * outShape[1] * outShape[2]
+	fcLayers := addfcLayers(g, hiddenSizes, biases, activations, init,
+		features, prefix, suffix, nil)
+	layers = append(layers, fcLayers...)
+
+	// Fill the network and run the forward pass on the input node
+	*c = ConvMLP{
+		g:           g,
+		layers:      layers,
+		input:       input,
+		numOutputs:  outputs,
+		inputShape:  []int{channels, height, width},
+		batchSize:   input.Shape()[0],
+		convLayers:  convLayers,
+		hiddenSizes: hiddenSizes,
+		biases:      biases,
+		activations: activations,
+	}
+	_, err = c.fwd([]*G.Node{input})
+	if err != nil {
+		return fmt.Errorf("newConvMLP: could not compute forward pass: %v",
+			err)
+	}
+
+	return nil
+}
+
+// Layers returns the layers of the ConvMLP
+func (c *ConvMLP) Layers() []Layer {
+	return c.layers
+}
+
+// InputShape returns the (channels, height, width) shape of images
+// input to the network
+func (c *ConvMLP) InputShape() []int {
+	return c.inputShape
+}
+
+// Graph returns the computational graph of the ConvMLP.
+func (c *ConvMLP) Graph() *G.ExprGraph {
+	return c.g
+}
+
+// Clone clones a ConvMLP
+func (c *ConvMLP) Clone() (NeuralNet, error) {
+	return c.CloneWithBatch(c.batchSize)
+}
+
+// cloneWithInputTo clones a ConvMLP to a specific computational graph
+// with a specified input node. If multiple input nodes are given, then
+// they are first concatenated along the specified axis.
+func (c *ConvMLP) cloneWithInputTo(axis int, inputs []*G.Node,
+	graph *G.ExprGraph) (NeuralNet, error) {
+	// Ensure inputs share the same graph
+	for _, input := range inputs {
+		if input.Graph() != graph {
+			return nil, fmt.Errorf("clonewithinputto: not all inputs " +
+				"have the same graph")
+		}
+	}
+
+	// Concatenate inputs if necessary
+	var input *G.Node
+	if len(inputs) > 1 {
+		input = G.Must(G.Concat(axis, inputs...))
+	} else {
+		input = inputs[0]
+	}
+
+	if !input.IsMatrix() {
+		return nil, fmt.Errorf("cloneWithInputTo: input must be a matrix node")
+	}
+
+	// Copy layers
+	l := make([]Layer, len(c.layers))
+	for i := range c.layers {
+		l[i] = c.layers[i].CloneTo(graph)
+	}
+
+	// Create the network and run the forward pass on the input node
+	network := ConvMLP{
+		g:           graph,
+		layers:      l,
+		input:       input,
+		numOutputs:  c.numOutputs,
+		inputShape:  c.inputShape,
+		batchSize:   input.Shape()[0],
+		convLayers:  c.convLayers,
+		hiddenSizes: c.hiddenSizes,
+		biases:      c.biases,
+		activations: c.activations,
+	}
+	_, err := network.fwd([]*G.Node{input})
+	if err != nil {
+		return nil, fmt.Errorf("clonewithinputto: could not clone: %v", err)
+	}
+
+	return &network, nil
+}
+
+// CloneWithBatch clones a ConvMLP with a new input batch size.
+func (c *ConvMLP) CloneWithBatch(batchSize int) (NeuralNet, error) {
+	graph := G.NewGraph()
+
+	// Create the input node
+	input := G.NewMatrix(
+		graph,
+		tensor.Float64,
+		G.WithShape(batchSize, c.Features()[0]),
+		G.WithName("input"),
+		G.WithInit(G.Zeroes()),
+	)
+
+	return c.cloneWithInputTo(-1, []*G.Node{input}, graph)
+}
+
+// BatchSize returns the batch size of inputs to the network
+func (c *ConvMLP) BatchSize() int {
+	return c.batchSize
+}
+
+// architecture returns the architecture of the ConvMLP
+func (c *ConvMLP) architecture() architecture {
+	// Exclude the final linear layer, which is added when the ConvMLP
+	// is built
+	layers := len(c.hiddenSizes) - 1
+	return &convMLPArchitecture{
+		InputShape:  c.inputShape,
+		Outputs:     c.numOutputs,
+		ConvLayers:  c.convLayers,
+		HiddenSizes: c.hiddenSizes[:layers],
+		Biases:      c.biases[:layers],
+		Activations: c.activations[:layers],
+	}
+}
+
+// Features returns the number of features in a single flattened
+// image that the network takes as input.
+func (c *ConvMLP) Features() []int {
+	return []int{c.inputShape[0] * c.inputShape[1] * c.inputShape[2]}
+}
+
+// Outputs returns the number of outputs from the network
+func (c *ConvMLP) Outputs() []int {
+	return []int{c.numOutputs}
+}
+
+// OutputLayers returns the number of layers that will produce Outputs()
+// values as predictions.
+func (c *ConvMLP) OutputLayers() int {
+	return len(c.Prediction())
+}
+
+// SetInput sets the value of the input node before running the forward
+// pass.
+func (c *ConvMLP) SetInput(input []float64) error {
+	if len(input) != c.Features()[0]*c.batchSize {
+		return fmt.Errorf("setInput: invalid number of inputs\n\twant(%v)"+
+			"\n\thave(%v)", c.Features()[0]*c.batchSize, len(input))
+	}
+	inputTensor := tensor.New(
+		tensor.WithBacking(input),
+		tensor.WithShape(c.input.Shape()...),
+	)
+	return G.Let(c.input, inputTensor)
+}
+
+// Learnables returns the learnable nodes in a ConvMLP
+func (c *ConvMLP) Learnables() G.Nodes {
+	// Lazy instantiation
+	if c.learnables == nil {
+		c.learnables = c.computeLearnables()
+	}
+	return c.learnables
+}
+
+// computeLearnables computes all the learnables for the network.
+// Pooling and flatten layers have no learnables and are skipped.
+func (c *ConvMLP) computeLearnables() G.Nodes {
+	learnables := make([]*G.Node, 0, 2*len(c.layers))
+
+	for i := range c.layers {
+		if weights := c.layers[i].Weights(); weights != nil {
+			learnables = append(learnables, weights)
+		}
+		if bias := c.layers[i].Bias(); bias != nil {
+			learnables = append(learnables, bias)
+		}
+	}
+	return G.Nodes(learnables)
+}
+
+// Model returns the learnables nodes with their gradients.
+func (c *ConvMLP) Model() []G.ValueGrad {
+	// Lazy instantiation
+	if c.model == nil {
+		c.model = G.NodesToValueGrads(c.Learnables())
+	}
+	return c.model
+}
+
+// fwd performs the forward pass of the ConvMLP on the input node
+func (c *ConvMLP) fwd(inputs []*G.Node) (*G.Node, error) {
+	if len(inputs) != 1 {
+		return nil, fmt.Errorf("fwd: ConvMLP only supports a single "+
+			"input \n\twant(1) \n\thave(%v)", len(inputs))
+	}
+	input := inputs[0]
+
+	// Reshape the flattened input images to (batch, channels, height,
+	// width) for the convolutional layers
+	imageShape := append([]int{input.Shape()[0]}, c.inputShape...)
+	pred, err := G.Reshape(input, tensor.Shape(imageShape))
+	if err != nil {
+		return nil, fmt.Errorf("fwd: could not reshape input to image "+
+			"shape %v: %v", imageShape, err)
+	}
+
+	for i, l := range c.layers {
+		if pred, err = l.fwd(pred); err != nil {
+			msg := "fwd: could not compute forward pass of layer %v: %v"
+			return nil, fmt.Errorf(msg, i, err)
+		}
+	}
+
+	c.prediction = pred
+
+	G.Read(c.prediction, &c.predVal)
+
+	return pred, nil
+}
+
+// Output returns the output of the ConvMLP.
+func (c *ConvMLP) Output() []G.Value {
+	return []G.Value{c.predVal}
+}
+
+// Prediction returns the node of the computational graph the stores
+// the output of the ConvMLP
+func (c *ConvMLP) Prediction() []*G.Node {
+	return []*G.Node{c.prediction}
+}
+
+// GobEncode implements the gob.GobEncoder interface
+func (c *ConvMLP) GobEncode() ([]byte, error) {
+	gob.Register(ConvMLP{})
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	err := enc.Encode(c.numOutputs)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode number of outputs")
+	}
+
+	err = enc.Encode(c.inputShape)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode input shape")
+	}
+
+	err = enc.Encode(c.BatchSize())
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode batch size")
+	}
+
+	err = enc.Encode(c.convLayers)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode convolutional " +
+			"layers")
+	}
+
+	err = enc.Encode(c.hiddenSizes)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode hidden sizes")
+	}
+
+	err = enc.Encode(c.biases)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode biases")
+	}
+
+	err = enc.Encode(c.activations)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode activations")
+	}
+
+	// Store the layers with learnable weights
+	for i, layer := range c.layers {
+		if layer.Weights() == nil {
+			continue
+		}
+
+		err := enc.Encode(layer)
+		if err != nil {
+			msg := "gobencode: could not encode layer %v: %v"
+			return nil, fmt.Errorf(msg, i, err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (c *ConvMLP) GobDecode(in []byte) error {
+	gob.Register(ConvMLP{})
+	buf := bytes.NewReader(in)
+	dec := gob.NewDecoder(buf)
+
+	var numOutputs int
+	err := dec.Decode(&numOutputs)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode number of outputs")
+	}
+
+	var inputShape []int
+	err = dec.Decode(&inputShape)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode input shape")
+	}
+
+	var batchSize int
+	err = dec.Decode(&batchSize)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode batch size")
+	}
+
+	var convLayers []ConvLayerConfig
+	err = dec.Decode(&convLayers)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode convolutional layers")
+	}
+
+	var hiddenSizes []int
+	err = dec.Decode(&hiddenSizes)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode hidden sizes")
+	}
+	hiddenSizes = hiddenSizes[:len(hiddenSizes)-1]
+
+	var biases []bool
+	err = dec.Decode(&biases)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode biases")
+	}
+	biases = biases[:len(biases)-1]
+
+	var activations []*Activation
+	err = dec.Decode(&activations)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode activations")
+	}
+	activations = activations[:len(activations)-1]
+
+	// Build a new ConvMLP in place
+	g := G.NewGraph()
+	input := G.NewMatrix(g, tensor.Float64,
+		G.WithShape(batchSize, inputShape[0]*inputShape[1]*inputShape[2]),
+		G.WithName("input"), G.WithInit(G.Zeroes()))
+	err = c.build([]*G.Node{input}, inputShape[0], inputShape[1],
+		inputShape[2], numOutputs, g, convLayers, hiddenSizes, biases,
+		G.Zeroes(), activations, "", "")
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not construct new ConvMLP: %v",
+			err)
+	}
+
+	// Fill the new ConvMLP's layers with the decoded weights
+	for i, layer := range c.layers {
+		if layer.Weights() == nil {
+			continue
+		}
+
+		err = dec.Decode(layer)
+		if err != nil {
+			return fmt.Errorf("gobdecode: could not decode layer %v: %v", i,
+				err)
+		}
+	}
+
+	return nil
+}
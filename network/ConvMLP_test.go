@@ -0,0 +1,183 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// TestAvgPool2D tests average pooling against hand-computed averages
+func TestAvgPool2D(t *testing.T) {
+	// Input of shape (2, 2, 4, 4), where the first sample holds 0, 1,
+	// ..., 31 in channel-major order and the second sample holds the
+	// negation of the first
+	input := make([]float64, 2*2*4*4)
+	for i := 0; i < len(input)/2; i++ {
+		input[i] = float64(i)
+		input[i+len(input)/2] = -float64(i)
+	}
+
+	tests := []struct {
+		name   string
+		kernel tensor.Shape
+		stride []int
+		shape  tensor.Shape
+		want   []float64
+	}{
+		{
+			name:   "Kernel2Stride2",
+			kernel: tensor.Shape{2, 2},
+			stride: []int{2, 2},
+			shape:  tensor.Shape{2, 2, 2, 2},
+			want: []float64{
+				2.5, 4.5, 10.5, 12.5, 18.5, 20.5, 26.5, 28.5,
+				-2.5, -4.5, -10.5, -12.5, -18.5, -20.5, -26.5, -28.5,
+			},
+		},
+		{
+			name:   "Kernel3x2Stride1x2",
+			kernel: tensor.Shape{3, 2},
+			stride: []int{1, 2},
+			shape:  tensor.Shape{2, 2, 2, 2},
+			want: []float64{
+				4.5, 6.5, 8.5, 10.5, 20.5, 22.5, 24.5, 26.5,
+				-4.5, -6.5, -8.5, -10.5, -20.5, -22.5, -24.5, -26.5,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		g := G.NewGraph()
+		x := G.NewTensor(g, tensor.Float64, 4, G.WithShape(2, 2, 4, 4),
+			G.WithName("x"), G.WithValue(tensor.NewDense(tensor.Float64,
+				[]int{2, 2, 4, 4}, tensor.WithBacking(input))))
+
+		pooled, err := avgPool2D(x, test.kernel, []int{0, 0}, test.stride)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if !pooled.Shape().Eq(test.shape) {
+			t.Errorf("%v: output shape: have(%v) want(%v)", test.name,
+				pooled.Shape(), test.shape)
+		}
+
+		var out G.Value
+		G.Read(pooled, &out)
+		vm := G.NewTapeMachine(g)
+		if err := vm.RunAll(); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		vm.Close()
+
+		// The output is transposed, so its data may not be contiguous
+		// in its own shape
+		have := make([]float64, 0, len(test.want))
+		iter := out.(*tensor.Dense).Iterator()
+		data := out.Data().([]float64)
+		for i, err := iter.Start(); err == nil; i, err = iter.Next() {
+			have = append(have, data[i])
+		}
+		if !floats.EqualApprox(have, test.want, 1e-12) {
+			t.Errorf("%v: have(%v) want(%v)", test.name, have, test.want)
+		}
+	}
+}
+
+// newTestConvMLP returns a ConvMLP taking 2 x 6 x 5 images which uses
+// each type of convolutional layer
+func newTestConvMLP(g *G.ExprGraph, batch int) (NeuralNet, error) {
+	relu := ReLU()
+	conv := []ConvLayerConfig{
+		{Type: Conv2D, Filters: 3, Kernel: []int{3, 3}, Padding: []int{1, 1},
+			Bias: true, Activation: relu},
+		{Type: MaxPool, Kernel: []int{2, 1}},
+		{Type: AvgPool, Kernel: []int{2, 2}, Stride: []int{1, 1}},
+	}
+	return NewConvMLP(2, 6, 5, batch, 4, g, conv, []int{7}, []bool{true},
+		G.GlorotU(1), []*Activation{relu})
+}
+
+// TestConvMLPShape tests the shapes of the inputs and outputs of a
+// ConvMLP
+func TestConvMLPShape(t *testing.T) {
+	net, err := newTestConvMLP(G.NewGraph(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if shape := net.(*ConvMLP).InputShape(); len(shape) != 3 ||
+		shape[0] != 2 || shape[1] != 6 || shape[2] != 5 {
+		t.Errorf("input shape: have(%v) want([2 6 5])", shape)
+	}
+	if features := net.Features()[0]; features != 2*6*5 {
+		t.Errorf("features: have(%v) want(%v)", features, 2*6*5)
+	}
+
+	// The convolution preserves the 6 x 5 image, max pooling reduces it
+	// to 3 x 5, and average pooling to 2 x 4, with 3 channels
+	layers := net.(*ConvMLP).Layers()
+	flattened := layers[len(layers)-2].Weights().Shape()[0]
+	if flattened != 3*2*4 {
+		t.Errorf("flattened features: have(%v) want(%v)", flattened, 3*2*4)
+	}
+
+	outputs := predict(t, net, make([]float64, batch*2*6*5))
+	if len(outputs) != 1 || len(outputs[0]) != batch*4 {
+		t.Errorf("output size: have(%v) want(%v)", len(outputs[0]), batch*4)
+	}
+	if shape := net.Prediction()[0].Shape(); !shape.Eq(tensor.Shape{batch,
+		4}) {
+		t.Errorf("prediction shape: have(%v) want(%v)", shape,
+			tensor.Shape{batch, 4})
+	}
+}
+
+// TestConvMLPClone tests that a ConvMLP predicts the same outputs after
+// being cloned and after a gob round trip
+func TestConvMLPClone(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	net, err := newTestConvMLP(G.NewGraph(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := make([]float64, batch*2*6*5)
+	for i := range input {
+		input[i] = rng.NormFloat64()
+	}
+	want := predict(t, net, input)[0]
+
+	clone, err := net.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(net); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &ConvMLP{}
+	if err := gob.NewDecoder(&buf).Decode(decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, net := range map[string]NeuralNet{
+		"Clone":     clone,
+		"GobDecode": decoded,
+	} {
+		have := predict(t, net, input)[0]
+		for i := range have {
+			if math.Abs(have[i]-want[i]) > 1e-12 {
+				t.Errorf("%v: output mismatch \n\twant(%v) \n\thave(%v)", name,
+					want, have)
+				break
+			}
+		}
+	}
+}
@@ -169,6 +169,11 @@ func (f *fcLayer) GobDecode(in []byte) error {
 // The parameters prefix and suffix refer to the prefix and suffix to
 // add to the names of the weights and biases of the fcLayer.
 //
+// If options is non-nil, then options[i] determines the optional
+// normalization, dropout, and residual connection of layer i. Layers
+// with non-zero options are added as fcBlocks, while all other layers
+// are added as fcLayers.
+//
 //
 // Note that this function only adds fcLayers to the graph g. It does
 // not perform the forward pass or outline any relationships between
@@ -182,7 +187,7 @@ func (f *fcLayer) GobDecode(in []byte) error {
 //		return prediction
 func addfcLayers(g *G.ExprGraph, hiddenSizes []int, biases []bool,
 	activations []*Activation, init G.InitWFn, features int,
-	prefix, suffix string) []Layer {
+	prefix, suffix string, options []LayerOptions) []Layer {
 	// Create the fully connected layers
 	layers := make([]Layer, 0, len(hiddenSizes))
 	for i := range hiddenSizes {
@@ -230,6 +235,16 @@ func addfcLayers(g *G.ExprGraph, hiddenSizes []int, biases []bool,
 			bias:    Bias,
 			act:     activations[i],
 		}
+
+		// Add normalization, dropout, and residual connections if needed
+		if options != nil && !options[i].IsZero() {
+			layer.act = Identity()
+			name := fmt.Sprintf("%vL%d%v", prefix, i, suffix)
+			block := newfcBlock(g, layer, activations[i], options[i],
+				name)
+			layers = append(layers, block)
+			continue
+		}
 		layers = append(layers, layer)
 	}
 	return layers
@@ -0,0 +1,743 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/exp/rand"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+const (
+	// normEpsilon is added to variances for numerical stability when
+	// normalizing
+	normEpsilon float64 = 1e-5
+
+	// batchNormMomentum determines how quickly the running statistics
+	// of batch normalization layers are updated. After each training
+	// batch, the running statistics are updated as:
+	//
+	//	running = momentum * running + (1 - momentum) * batch
+	batchNormMomentum float64 = 0.9
+
+	// noisySigma0 determines the initial standard deviation of the
+	// noise of NoisyNet layers. Each noise scale of a layer with p
+	// inputs is initialized to noisySigma0 / √p.
+	noisySigma0 float64 = 0.5
+)
+
+// NormType determines the type of normalization applied to a fully
+// connected layer
+type NormType string
+
+// Types of normalization
+const (
+	NoNorm    NormType = ""
+	LayerNorm NormType = "LayerNorm"
+	BatchNorm NormType = "BatchNorm"
+)
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (n *NormType) UnmarshalJSON(data []byte) error {
+	decoded := NormType(strings.Trim(string(data), "\""))
+	switch decoded {
+	case NoNorm, LayerNorm, BatchNorm:
+		*n = decoded
+		return nil
+
+	case "None":
+		*n = NoNorm
+		return nil
+
+	default:
+		return fmt.Errorf("unmarshalJSON: illegal NormType %v", decoded)
+	}
+}
+
+// LayerOptions outlines optional components of a fully connected
+// layer. The zero value of LayerOptions describes a plain fully
+// connected layer. Given options, the forward pass of a layer with
+// input x is computed as:
+//
+//	y = dropout(activation(norm(x * weights + bias)))
+//	if residual, then y = x + y
+//
+// If Noisy is true, the layer is a NoisyNet layer with factorized
+// Gaussian noise (Fortunato et al., 2018). The weights and bias are
+// then replaced by:
+//
+//	weights = μ_w + σ_w ⊙ (f(ε_in) ⊗ f(ε_out))
+//	bias    = μ_b + σ_b ⊙ f(ε_out)
+//
+// where μ and σ are learned, ε_in and ε_out are sampled from a
+// standard normal before each forward pass, and f(x) = sgn(x)√|x|. In
+// evaluation mode, no noise is added to the weights and bias.
+//
+// When using batch normalization, the mean and variance of each
+// training batch are used to normalize the layer and to update the
+// layer's running statistics. In evaluation mode, or when the batch
+// size is 1, the running statistics are used instead. Dropout is only
+// applied in training mode. See SetEval for setting the mode of a
+// network.
+type LayerOptions struct {
+	Norm     NormType // Normalization applied before the activation
+	Dropout  float64  // Probability of dropping each unit
+	Residual bool     // Whether to add the layer input to its output
+	Noisy    bool     // Whether to add learned noise to weights and bias
+}
+
+// IsZero returns whether the LayerOptions describe a plain fully
+// connected layer
+func (l LayerOptions) IsZero() bool {
+	return l == LayerOptions{}
+}
+
+// Validate returns an error if the LayerOptions are invalid
+func (l LayerOptions) Validate() error {
+	if l.Dropout < 0 || l.Dropout >= 1 {
+		return fmt.Errorf("validate: dropout probability must be in "+
+			"[0, 1) \n\thave(%v)", l.Dropout)
+	}
+
+	switch l.Norm {
+	case NoNorm, LayerNorm, BatchNorm:
+		return nil
+
+	default:
+		return fmt.Errorf("validate: illegal NormType %v", l.Norm)
+	}
+}
+
+// validateLayerOptions checks that options are valid for fully
+// connected layers with hidden units hiddenSizes and features inputs.
+// If options is nil, it is considered valid.
+func validateLayerOptions(options []LayerOptions, features int,
+	hiddenSizes []int) error {
+	if options == nil {
+		return nil
+	}
+
+	if len(options) != len(hiddenSizes) {
+		msg := "invalid number of layer options\n\twant(%d)\n\thave(%d)"
+		return fmt.Errorf(msg, len(hiddenSizes), len(options))
+	}
+
+	in := features
+	for i, opt := range options {
+		if err := opt.Validate(); err != nil {
+			return fmt.Errorf("invalid options for layer %v: %v", i, err)
+		}
+
+		if opt.Residual && in != hiddenSizes[i] {
+			return fmt.Errorf("residual connection on layer %v requires "+
+				"equal input and output sizes \n\twant(%v) \n\thave(%v)", i,
+				in, hiddenSizes[i])
+		}
+		in = hiddenSizes[i]
+	}
+
+	return nil
+}
+
+// evalSetter is a NeuralNet or Layer whose forward pass differs
+// between training and evaluation mode
+type evalSetter interface {
+	setEval(bool)
+}
+
+// seeder is a NeuralNet or Layer which samples random values, such as
+// dropout masks or NoisyNet noise, during its forward pass
+type seeder interface {
+	setSeed(uint64)
+}
+
+// refresher is a Layer which must refresh some of its non-learnable
+// nodes, such as dropout masks or NoisyNet noise, before each forward
+// pass
+type refresher interface {
+	refresh() error
+}
+
+// stater is a NeuralNet or Layer with non-learnable state, such as the
+// running statistics of batch normalization layers, which should be
+// copied along with its learnables
+type stater interface {
+	state() G.Nodes
+}
+
+// learnabler is a Layer with learnable nodes other than its weights
+// and bias
+type learnabler interface {
+	learnables() G.Nodes
+}
+
+// SetEval sets a NeuralNet to evaluation mode if eval is true and to
+// training mode otherwise. Networks are in training mode by default.
+// Only networks using dropout or batch normalization are affected.
+func SetEval(net NeuralNet, eval bool) {
+	if e, ok := net.(evalSetter); ok {
+		e.setEval(eval)
+	}
+}
+
+// SetSeed seeds the random number generators which a NeuralNet uses
+// to sample dropout masks and NoisyNet noise. Networks are seeded with
+// seed 0 by default, and clones of a network are seeded using random
+// numbers sampled from the network's random number generators. Only
+// networks using dropout or NoisyNet layers are affected.
+func SetSeed(net NeuralNet, seed uint64) {
+	if s, ok := net.(seeder); ok {
+		s.setSeed(seed)
+	}
+}
+
+// layerLearnables returns the learnable nodes of each layer in layers
+func layerLearnables(layers []Layer) G.Nodes {
+	learnables := make([]*G.Node, 0, 2*len(layers))
+
+	for _, layer := range layers {
+		if l, ok := layer.(learnabler); ok {
+			learnables = append(learnables, l.learnables()...)
+			continue
+		}
+
+		if weights := layer.Weights(); weights != nil {
+			learnables = append(learnables, weights)
+		}
+		if bias := layer.Bias(); bias != nil {
+			learnables = append(learnables, bias)
+		}
+	}
+	return G.Nodes(learnables)
+}
+
+// layerState returns the non-learnable state nodes of each layer in
+// layers
+func layerState(layers []Layer) G.Nodes {
+	var state G.Nodes
+	for _, layer := range layers {
+		if s, ok := layer.(stater); ok {
+			state = append(state, s.state()...)
+		}
+	}
+	return state
+}
+
+// setLayersEval sets the mode of each layer in layers
+func setLayersEval(layers []Layer, eval bool) {
+	for _, layer := range layers {
+		if e, ok := layer.(evalSetter); ok {
+			e.setEval(eval)
+		}
+	}
+}
+
+// setLayersSeed seeds each layer in layers with a different seed
+// sampled from a random number generator seeded with seed
+func setLayersSeed(layers []Layer, seed uint64) {
+	rng := rand.New(rand.NewSource(seed))
+	for _, layer := range layers {
+		if s, ok := layer.(seeder); ok {
+			s.setSeed(rng.Uint64())
+		}
+	}
+}
+
+// refreshLayers refreshes each layer in layers before a forward pass
+func refreshLayers(layers []Layer) error {
+	for i, layer := range layers {
+		if r, ok := layer.(refresher); ok {
+			if err := r.refresh(); err != nil {
+				return fmt.Errorf("refreshLayers: could not refresh layer "+
+					"%v: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fcBlock implements a fully connected layer with normalization,
+// dropout, residual connections, and NoisyNet noise as described by
+// LayerOptions.
+type fcBlock struct {
+	fc   *fcLayer // Linear part of the layer, with no activation
+	act  *Activation
+	opts LayerOptions
+	eval bool
+	rows int        // Batch size of inputs to the layer
+	rng  *rand.Rand // Samples dropout masks and NoisyNet noise
+
+	// Learnable scale and shift of normalization layers
+	scale *G.Node
+	shift *G.Node
+
+	// Batch normalization running statistics and batch statistics
+	runMean     *G.Node
+	runVar      *G.Node
+	useBatch    *G.Node // 1 if batch statistics are used, 0 otherwise
+	batchMean   G.Value
+	batchVar    G.Value
+	batchActive bool // Whether batch statistics were used in the last run
+
+	// Dropout mask, where each element is either 0 or 1 / (1 - p)
+	mask *G.Node
+
+	// Learnable noise scales and sampled noise of NoisyNet layers
+	sigmaW *G.Node
+	sigmaB *G.Node
+	noiseW *G.Node
+	noiseB *G.Node
+}
+
+// newfcBlock returns a new fcBlock with the given linear layer fc and
+// options opts, adding normalization and noise parameters to the graph
+// g if needed. The name is used as a prefix for these parameters.
+func newfcBlock(g *G.ExprGraph, fc *fcLayer, act *Activation,
+	opts LayerOptions, name string) *fcBlock {
+	block := &fcBlock{
+		fc:   fc,
+		act:  act,
+		opts: opts,
+		rng:  rand.New(rand.NewSource(0)),
+	}
+	units := fc.Weights().Shape()[1]
+
+	if opts.Noisy {
+		inputs := fc.Weights().Shape()[0]
+		sigma := G.ValuesOf(noisySigma0 / math.Sqrt(float64(inputs)))
+
+		block.sigmaW = G.NewMatrix(g, tensor.Float64,
+			G.WithShape(fc.Weights().Shape()...),
+			G.WithName(name+"NoiseScaleW"), G.WithInit(sigma))
+		if fc.Bias() != nil {
+			block.sigmaB = G.NewVector(g, tensor.Float64, G.WithShape(units),
+				G.WithName(name+"NoiseScaleB"), G.WithInit(sigma))
+		}
+	}
+
+	if opts.Norm == NoNorm {
+		return block
+	}
+
+	block.scale = G.NewVector(g, tensor.Float64, G.WithShape(units),
+		G.WithName(name+"NormScale"), G.WithInit(G.Ones()))
+	block.shift = G.NewVector(g, tensor.Float64, G.WithShape(units),
+		G.WithName(name+"NormShift"), G.WithInit(G.Zeroes()))
+
+	if opts.Norm == BatchNorm {
+		block.runMean = G.NewVector(g, tensor.Float64, G.WithShape(units),
+			G.WithName(name+"RunningMean"), G.WithInit(G.Zeroes()))
+		block.runVar = G.NewVector(g, tensor.Float64, G.WithShape(units),
+			G.WithName(name+"RunningVar"), G.WithInit(G.Ones()))
+	}
+
+	return block
+}
+
+// fwd adds the forward pass of the fcBlock to the computational graph
+func (f *fcBlock) fwd(x *G.Node) (*G.Node, error) {
+	f.rows = x.Shape()[0]
+
+	var pred *G.Node
+	var err error
+	if f.opts.Noisy {
+		pred, err = f.noisyFwd(x)
+	} else {
+		pred, err = f.fc.fwd(x)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fwd: %v", err)
+	}
+
+	switch f.opts.Norm {
+	case LayerNorm:
+		pred, err = f.layerNorm(pred)
+
+	case BatchNorm:
+		pred, err = f.batchNorm(pred)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fwd: could not normalize: %v", err)
+	}
+
+	if act := f.Activation(); !act.IsIdentity() && !act.IsNil() {
+		if pred, err = act.fwd(pred); err != nil {
+			return nil, fmt.Errorf("fwd: could not apply activation: %v",
+				err)
+		}
+	}
+
+	if f.opts.Dropout > 0 {
+		f.mask = G.NewMatrix(x.Graph(), tensor.Float64,
+			G.WithShape(pred.Shape()...), G.WithInit(G.Ones()))
+		pred = G.Must(G.HadamardProd(pred, f.mask))
+	}
+
+	if f.opts.Residual {
+		pred = G.Must(G.Add(x, pred))
+	}
+
+	return pred, f.refresh()
+}
+
+// noisyFwd adds the forward pass of the linear part of a NoisyNet
+// layer to the computational graph
+func (f *fcBlock) noisyFwd(x *G.Node) (*G.Node, error) {
+	g := x.Graph()
+
+	f.noiseW = G.NewMatrix(g, tensor.Float64,
+		G.WithShape(f.sigmaW.Shape()...), G.WithInit(G.Zeroes()))
+	noise := G.Must(G.HadamardProd(f.sigmaW, f.noiseW))
+	weights := G.Must(G.Add(f.Weights(), noise))
+
+	pred, err := G.Mul(x, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Bias() == nil {
+		return pred, nil
+	}
+
+	f.noiseB = G.NewVector(g, tensor.Float64,
+		G.WithShape(f.sigmaB.Shape()...), G.WithInit(G.Zeroes()))
+	noise = G.Must(G.HadamardProd(f.sigmaB, f.noiseB))
+	bias := G.Must(G.Add(f.Bias(), noise))
+
+	return G.BroadcastAdd(pred, bias, nil, []byte{0})
+}
+
+// layerNorm adds layer normalization of x to the computational graph.
+// Each sample is normalized over its features.
+func (f *fcBlock) layerNorm(x *G.Node) (*G.Node, error) {
+	eps := G.NewConstant(normEpsilon)
+
+	mean := G.Must(G.Mean(x, 1))
+	centred := G.Must(G.BroadcastSub(x, mean, nil, []byte{1}))
+	variance := G.Must(G.Mean(G.Must(G.Square(centred)), 1))
+	stddev := G.Must(G.Sqrt(G.Must(G.Add(variance, eps))))
+	normalized, err := G.BroadcastHadamardDiv(centred, stddev, nil,
+		[]byte{1})
+	if err != nil {
+		return nil, err
+	}
+
+	return f.scaleAndShift(normalized)
+}
+
+// batchNorm adds batch normalization of x to the computational graph.
+// Each feature is normalized over the batch using either the batch
+// statistics or the running statistics, depending on the value of the
+// useBatch node.
+func (f *fcBlock) batchNorm(x *G.Node) (*G.Node, error) {
+	g := x.Graph()
+	eps := G.NewConstant(normEpsilon)
+	one := G.NewConstant(1.0)
+
+	f.useBatch = G.NewScalar(g, tensor.Float64, G.WithInit(G.Zeroes()))
+	useRunning := G.Must(G.Sub(one, f.useBatch))
+
+	batchMean := G.Must(G.Mean(x, 0))
+	batchCentred := G.Must(G.BroadcastSub(x, batchMean, nil, []byte{0}))
+	batchVar := G.Must(G.Mean(G.Must(G.Square(batchCentred)), 0))
+	G.Read(batchMean, &f.batchMean)
+	G.Read(batchVar, &f.batchVar)
+
+	mean := G.Must(G.Add(G.Must(G.Mul(f.useBatch, batchMean)),
+		G.Must(G.Mul(useRunning, f.runMean))))
+	variance := G.Must(G.Add(G.Must(G.Mul(f.useBatch, batchVar)),
+		G.Must(G.Mul(useRunning, f.runVar))))
+
+	centred := G.Must(G.BroadcastSub(x, mean, nil, []byte{0}))
+	stddev := G.Must(G.Sqrt(G.Must(G.Add(variance, eps))))
+	normalized, err := G.BroadcastHadamardDiv(centred, stddev, nil,
+		[]byte{0})
+	if err != nil {
+		return nil, err
+	}
+
+	return f.scaleAndShift(normalized)
+}
+
+// scaleAndShift applies the learned scale and shift of the
+// normalization layer to x
+func (f *fcBlock) scaleAndShift(x *G.Node) (*G.Node, error) {
+	scaled, err := G.BroadcastHadamardProd(x, f.scale, nil, []byte{0})
+	if err != nil {
+		return nil, err
+	}
+	return G.BroadcastAdd(scaled, f.shift, nil, []byte{0})
+}
+
+// refresh commits the batch statistics of the last forward pass to the
+// running statistics and sets the values of the batch normalization
+// mode, dropout mask, and NoisyNet noise for the next forward pass
+func (f *fcBlock) refresh() error {
+	if f.opts.Norm == BatchNorm {
+		if err := f.commit(); err != nil {
+			return fmt.Errorf("refresh: %v", err)
+		}
+
+		f.batchActive = !f.eval && f.rows > 1
+		var useBatch float64
+		if f.batchActive {
+			useBatch = 1.0
+		}
+		if err := G.Let(f.useBatch, useBatch); err != nil {
+			return fmt.Errorf("refresh: could not set batch norm mode: %v",
+				err)
+		}
+	}
+
+	if f.mask != nil {
+		p := f.opts.Dropout
+		mask := make([]float64, f.mask.Shape().TotalSize())
+		for i := range mask {
+			if f.eval || f.rng.Float64() >= p {
+				mask[i] = 1.0
+			}
+			if !f.eval {
+				mask[i] /= (1 - p)
+			}
+		}
+		maskTensor := tensor.New(tensor.WithShape(f.mask.Shape()...),
+			tensor.WithBacking(mask))
+		if err := G.Let(f.mask, maskTensor); err != nil {
+			return fmt.Errorf("refresh: could not set dropout mask: %v", err)
+		}
+	}
+
+	if f.noiseW != nil {
+		if err := f.resample(); err != nil {
+			return fmt.Errorf("refresh: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// resample samples new factorized Gaussian noise for a NoisyNet layer.
+// In evaluation mode, the noise is set to zero.
+func (f *fcBlock) resample() error {
+	shape := f.noiseW.Shape()
+	in, out := shape[0], shape[1]
+
+	// Sample the factorized noise f(ε_in) and f(ε_out)
+	noiseIn := make([]float64, in)
+	noiseOut := make([]float64, out)
+	if !f.eval {
+		for i := range noiseIn {
+			noiseIn[i] = scaleNoise(f.rng.NormFloat64())
+		}
+		for j := range noiseOut {
+			noiseOut[j] = scaleNoise(f.rng.NormFloat64())
+		}
+	}
+
+	noiseW := make([]float64, in*out)
+	for i := range noiseIn {
+		for j := range noiseOut {
+			noiseW[i*out+j] = noiseIn[i] * noiseOut[j]
+		}
+	}
+	err := G.Let(f.noiseW, tensor.New(tensor.WithShape(in, out),
+		tensor.WithBacking(noiseW)))
+	if err != nil {
+		return fmt.Errorf("resample: could not set weight noise: %v", err)
+	}
+
+	if f.noiseB != nil {
+		err := G.Let(f.noiseB, tensor.New(tensor.WithShape(out),
+			tensor.WithBacking(noiseOut)))
+		if err != nil {
+			return fmt.Errorf("resample: could not set bias noise: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// scaleNoise returns sgn(x)√|x|, which is used to scale the factorized
+// noise of NoisyNet layers
+func scaleNoise(x float64) float64 {
+	if x < 0 {
+		return -math.Sqrt(-x)
+	}
+	return math.Sqrt(x)
+}
+
+// commit updates the running statistics of a batch normalization layer
+// with the batch statistics of the last forward pass, if batch
+// statistics were used in the last forward pass
+func (f *fcBlock) commit() error {
+	if !f.batchActive || f.batchMean == nil || f.batchVar == nil {
+		return nil
+	}
+
+	update := func(running *G.Node, batch G.Value) error {
+		r := running.Value().Data().([]float64)
+		b := batch.Data().([]float64)
+		updated := make([]float64, len(r))
+		for i := range r {
+			updated[i] = batchNormMomentum*r[i] + (1-batchNormMomentum)*b[i]
+		}
+		return G.Let(running, tensor.New(tensor.WithShape(len(updated)),
+			tensor.WithBacking(updated)))
+	}
+
+	if err := update(f.runMean, f.batchMean); err != nil {
+		return fmt.Errorf("commit: could not update running mean: %v", err)
+	}
+	if err := update(f.runVar, f.batchVar); err != nil {
+		return fmt.Errorf("commit: could not update running variance: %v",
+			err)
+	}
+
+	f.batchMean, f.batchVar = nil, nil
+	return nil
+}
+
+// setEval sets the layer to evaluation mode if eval is true and to
+// training mode otherwise
+func (f *fcBlock) setEval(eval bool) {
+	f.eval = eval
+	if err := f.refresh(); err != nil {
+		panic(fmt.Sprintf("setEval: %v", err))
+	}
+}
+
+// setSeed seeds the random number generator of the layer and samples
+// a new dropout mask and NoisyNet noise
+func (f *fcBlock) setSeed(seed uint64) {
+	f.rng = rand.New(rand.NewSource(seed))
+	if err := f.refresh(); err != nil {
+		panic(fmt.Sprintf("setSeed: %v", err))
+	}
+}
+
+// learnables returns the learnable nodes of the layer
+func (f *fcBlock) learnables() G.Nodes {
+	learnables := G.Nodes{f.Weights()}
+	if bias := f.Bias(); bias != nil {
+		learnables = append(learnables, bias)
+	}
+	if f.scale != nil {
+		learnables = append(learnables, f.scale, f.shift)
+	}
+	if f.sigmaW != nil {
+		learnables = append(learnables, f.sigmaW)
+	}
+	if f.sigmaB != nil {
+		learnables = append(learnables, f.sigmaB)
+	}
+	return learnables
+}
+
+// state returns the running statistics of a batch normalization
+// layer, after committing the batch statistics of the last forward
+// pass
+func (f *fcBlock) state() G.Nodes {
+	if f.runMean == nil {
+		return nil
+	}
+	if err := f.commit(); err != nil {
+		panic(fmt.Sprintf("state: %v", err))
+	}
+	return G.Nodes{f.runMean, f.runVar}
+}
+
+// CloneTo clones an fcBlock to a new computational graph
+func (f *fcBlock) CloneTo(g *G.ExprGraph) Layer {
+	clone := &fcBlock{
+		fc:   f.fc.CloneTo(g).(*fcLayer),
+		act:  f.act,
+		opts: f.opts,
+		eval: f.eval,
+		rng:  rand.New(rand.NewSource(f.rng.Uint64())),
+	}
+
+	if f.scale != nil {
+		clone.scale = f.scale.CloneTo(g)
+		clone.shift = f.shift.CloneTo(g)
+	}
+	if f.runMean != nil {
+		state := f.state()
+		clone.runMean = state[0].CloneTo(g)
+		clone.runVar = state[1].CloneTo(g)
+	}
+	if f.sigmaW != nil {
+		clone.sigmaW = f.sigmaW.CloneTo(g)
+	}
+	if f.sigmaB != nil {
+		clone.sigmaB = f.sigmaB.CloneTo(g)
+	}
+
+	return clone
+}
+
+// Activation returns the activation of the layer
+func (f *fcBlock) Activation() *Activation {
+	return f.act
+}
+
+// Bias returns the bias of the layer
+func (f *fcBlock) Bias() *G.Node {
+	return f.fc.Bias()
+}
+
+// Weights returns the weights of the layer
+func (f *fcBlock) Weights() *G.Node {
+	return f.fc.Weights()
+}
+
+// GobEncode implements the gob.GobEncoder interface. The values of the
+// learnables and running statistics of the layer are encoded.
+func (f *fcBlock) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	nodes := append(f.learnables(), f.state()...)
+	for i, node := range nodes {
+		err := enc.Encode(node.Value().(*tensor.Dense))
+		if err != nil {
+			return nil, fmt.Errorf("gobencode: could not encode node %v: %v",
+				i, err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface. Similar to
+// fcLayer, the fcBlock must already be initialized with the same
+// architecture as the encoded fcBlock before decoding.
+func (f *fcBlock) GobDecode(in []byte) error {
+	if f.fc == nil {
+		return fmt.Errorf("gobdecode: fcBlock must have all node pointers " +
+			"initialized and registered with a graph before decoding")
+	}
+
+	buf := bytes.NewReader(in)
+	dec := gob.NewDecoder(buf)
+
+	nodes := append(f.learnables(), f.state()...)
+	for i, node := range nodes {
+		var value *tensor.Dense
+		err := dec.Decode(&value)
+		if err != nil {
+			return fmt.Errorf("gobdecode: could not decode node %v: %v", i,
+				err)
+		}
+
+		err = G.Let(node, value)
+		if err != nil {
+			return fmt.Errorf("gobdecode: could not set node %v: %v", i, err)
+		}
+	}
+
+	return nil
+}
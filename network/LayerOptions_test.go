@@ -0,0 +1,140 @@
+package network
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+)
+
+// TestSetSeed tests whether networks with dropout and NoisyNet layers
+// sample the same dropout masks and noise in training mode when seeded
+// with the same seed
+func TestSetSeed(t *testing.T) {
+	const features = 4
+	relu := ReLU()
+
+	build := func(seed uint64) NeuralNet {
+		net, err := NewMultiHeadMLPWithOptions(features, batch, 3,
+			G.NewGraph(), []int{16, 16}, []bool{true, true}, G.GlorotU(1),
+			[]*Activation{relu, relu},
+			[]LayerOptions{{Dropout: 0.5}, {Noisy: true}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		SetSeed(net, seed)
+		return net
+	}
+
+	// run returns the outputs of net in training mode over a number of
+	// forward passes
+	run := func(net NeuralNet) []float64 {
+		vm := G.NewTapeMachine(net.Graph())
+		defer vm.Close()
+
+		input := make([]float64, features*batch)
+		for i := range input {
+			input[i] = float64(i)
+		}
+
+		var outputs []float64
+		for i := 0; i < 3; i++ {
+			if err := net.SetInput(input); err != nil {
+				t.Fatal(err)
+			}
+			if err := vm.RunAll(); err != nil {
+				t.Fatal(err)
+			}
+			outputs = append(outputs, net.Output()[0].Data().([]float64)...)
+			vm.Reset()
+		}
+		return outputs
+	}
+
+	net := build(1)
+	same := build(1)
+	different := build(2)
+	for _, dest := range []NeuralNet{same, different} {
+		if err := Set(dest, net); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputs := run(net)
+	if sameOutputs := run(same); !floats.Equal(outputs, sameOutputs) {
+		t.Errorf("equal seeds: have(%v) want(%v)", sameOutputs, outputs)
+	}
+	if floats.Equal(outputs, run(different)) {
+		t.Errorf("different seeds: outputs should differ")
+	}
+}
+
+// TestNoisyNet tests that NoisyNet layers sample new noise before each
+// forward pass in training mode and add no noise in evaluation mode, so
+// that the network then predicts with the mean weights and bias
+func TestNoisyNet(t *testing.T) {
+	const (
+		features = 4
+		hidden   = 5
+		outputs  = 3
+	)
+
+	net, err := NewMultiHeadMLPWithOptions(features, batch, outputs,
+		G.NewGraph(), []int{hidden}, []bool{true}, G.GlorotU(1),
+		[]*Activation{ReLU()}, []LayerOptions{{Noisy: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := make([]float64, features*batch)
+	for i := range input {
+		input[i] = float64(i%5) - 2
+	}
+
+	// Compute the prediction relu(x W₁ + b₁) W₂ + b₂ of the network
+	// with the mean weights and bias of the NoisyNet layer
+	layers := net.(*MultiHeadMLP).Layers()
+	dense := func(n *G.Node) *mat.Dense {
+		shape := n.Shape()
+		if len(shape) == 1 {
+			return mat.NewDense(1, shape[0], n.Value().Data().([]float64))
+		}
+		return mat.NewDense(shape[0], shape[1], n.Value().Data().([]float64))
+	}
+	var hiddenOut, out mat.Dense
+	hiddenOut.Mul(mat.NewDense(batch, features, input),
+		dense(layers[0].Weights()))
+	hiddenOut.Apply(func(i, j int, v float64) float64 {
+		return math.Max(0, v+layers[0].Bias().Value().Data().([]float64)[j])
+	}, &hiddenOut)
+	out.Mul(&hiddenOut, dense(layers[1].Weights()))
+	out.Apply(func(i, j int, v float64) float64 {
+		return v + layers[1].Bias().Value().Data().([]float64)[j]
+	}, &out)
+	want := out.RawMatrix().Data
+
+	// In training mode, each forward pass samples new noise
+	first := run(t, net, input)
+	second := run(t, net, input)
+	if floats.EqualApprox(first, second, 1e-12) {
+		t.Error("training mode: noise was not resampled between forward " +
+			"passes")
+	}
+	if floats.EqualApprox(first, want, 1e-12) {
+		t.Error("training mode: no noise was added")
+	}
+
+	SetEval(net, true)
+	for i := 0; i < 2; i++ {
+		if have := run(t, net, input); !floats.EqualApprox(have, want, 1e-12) {
+			t.Errorf("evaluation mode: have(%v) want(%v)", have, want)
+		}
+	}
+
+	SetEval(net, false)
+	if have := run(t, net, input); floats.EqualApprox(have, want, 1e-12) {
+		t.Error("training mode after evaluation: no noise was added")
+	}
+}
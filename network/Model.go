@@ -0,0 +1,353 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// modelVersion is the version of the model file format. It should be
+// incremented whenever the format changes in a way that older model
+// files can no longer be read.
+const modelVersion int = 1
+
+func init() {
+	// Register architectures so that they can be gobbed as the
+	// architecture interface
+	gob.Register(&mlpArchitecture{})
+	gob.Register(&treeMLPArchitecture{})
+	gob.Register(&revTreeMLPArchitecture{})
+	gob.Register(&convMLPArchitecture{})
+	gob.Register(&recurrentMLPArchitecture{})
+}
+
+// architecture describes the architecture of a NeuralNet, independent
+// of its weights, input batch size, and computational graph
+type architecture interface {
+	// build builds a NeuralNet with the architecture on graph g. The
+	// NeuralNet takes inputs of batch size batch.
+	build(batch int, g *G.ExprGraph) (NeuralNet, error)
+}
+
+// Model holds the architecture and weights of a NeuralNet, independent
+// of any computational graph. A Model can be used to save a NeuralNet
+// to a file and to rebuild the NeuralNet, with the same weights, on a
+// new computational graph and with any input batch size.
+//
+// The weights of a Model include any non-learnable state of the
+// NeuralNet, such as the running statistics of batch normalization
+// layers.
+type Model struct {
+	arch      architecture
+	batchSize int
+	weights   []modelWeight
+}
+
+// modelWeight stores the value of a single learnable or state node of
+// a NeuralNet
+type modelWeight struct {
+	Shape []int
+	Data  []float64
+}
+
+// modelFile is the serializable form of a Model
+type modelFile struct {
+	Version      int
+	Architecture architecture
+	BatchSize    int
+	Weights      []modelWeight
+}
+
+// NewModel returns a new Model holding the architecture and current
+// weights of net. Later changes to the weights of net do not affect
+// the returned Model.
+func NewModel(net NeuralNet) (*Model, error) {
+	nodes := withState(net)
+	weights := make([]modelWeight, len(nodes))
+	for i, node := range nodes {
+		data, ok := node.Value().Data().([]float64)
+		if !ok {
+			return nil, fmt.Errorf("newModel: node %v does not hold "+
+				"float64 values", node.Name())
+		}
+
+		weights[i] = modelWeight{
+			Shape: append([]int{}, node.Shape()...),
+			Data:  append([]float64{}, data...),
+		}
+	}
+
+	return &Model{
+		arch:      net.architecture(),
+		batchSize: net.BatchSize(),
+		weights:   weights,
+	}, nil
+}
+
+// BatchSize returns the input batch size of the NeuralNet from which
+// the Model was created
+func (m *Model) BatchSize() int {
+	return m.batchSize
+}
+
+// Build builds the NeuralNet held by the Model on graph g, with the
+// same input batch size as the NeuralNet from which the Model was
+// created.
+func (m *Model) Build(g *G.ExprGraph) (NeuralNet, error) {
+	return m.BuildWithBatch(m.batchSize, g)
+}
+
+// BuildWithBatch builds the NeuralNet held by the Model on graph g.
+// The returned NeuralNet takes inputs of batch size batch.
+func (m *Model) BuildWithBatch(batch int, g *G.ExprGraph) (NeuralNet,
+	error) {
+	net, err := m.arch.build(batch, g)
+	if err != nil {
+		return nil, fmt.Errorf("buildWithBatch: could not build "+
+			"network: %v", err)
+	}
+
+	if err := m.SetWeights(net); err != nil {
+		return nil, fmt.Errorf("buildWithBatch: %v", err)
+	}
+	return net, nil
+}
+
+// SetWeights sets the weights of net to the weights held by the Model.
+// The architecture of net must be the same as that of the Model,
+// although the input batch size may differ.
+func (m *Model) SetWeights(net NeuralNet) error {
+	nodes := withState(net)
+	if len(nodes) != len(m.weights) {
+		return fmt.Errorf("setWeights: invalid number of weights "+
+			"\n\twant(%v) \n\thave(%v)", len(m.weights), len(nodes))
+	}
+
+	for i, node := range nodes {
+		weight := m.weights[i]
+		if !tensor.Shape(weight.Shape).Eq(node.Shape()) {
+			return fmt.Errorf("setWeights: invalid shape for weight %v "+
+				"\n\twant(%v) \n\thave(%v)", i, weight.Shape, node.Shape())
+		}
+
+		value := tensor.NewDense(
+			tensor.Float64,
+			append([]int{}, weight.Shape...),
+			tensor.WithBacking(append([]float64{}, weight.Data...)),
+		)
+		if err := G.Let(node, value); err != nil {
+			return fmt.Errorf("setWeights: could not set weight %v: %v", i,
+				err)
+		}
+	}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface
+func (m *Model) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	err := enc.Encode(modelFile{
+		Version:      modelVersion,
+		Architecture: m.arch,
+		BatchSize:    m.batchSize,
+		Weights:      m.weights,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode model: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (m *Model) GobDecode(in []byte) error {
+	dec := gob.NewDecoder(bytes.NewReader(in))
+
+	var file modelFile
+	if err := dec.Decode(&file); err != nil {
+		return fmt.Errorf("gobdecode: could not decode model: %v", err)
+	}
+
+	if file.Version != modelVersion {
+		return fmt.Errorf("gobdecode: unsupported model version "+
+			"\n\twant(%v) \n\thave(%v)", modelVersion, file.Version)
+	}
+
+	m.arch = file.Architecture
+	m.batchSize = file.BatchSize
+	m.weights = file.Weights
+	return nil
+}
+
+// Save saves the architecture and weights of net to a model file at
+// path. The NeuralNet can be rebuilt from the model file using Load.
+func Save(net NeuralNet, path string) error {
+	model, err := NewModel(net)
+	if err != nil {
+		return fmt.Errorf("save: %v", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("save: could not create model file: %v", err)
+	}
+
+	if err := gob.NewEncoder(file).Encode(model); err != nil {
+		file.Close()
+		return fmt.Errorf("save: could not write model file: %v", err)
+	}
+	return file.Close()
+}
+
+// Load loads the NeuralNet saved in the model file at path, building
+// it on graph g. The returned NeuralNet takes inputs of the same batch
+// size as the NeuralNet that was saved.
+func Load(path string, g *G.ExprGraph) (NeuralNet, error) {
+	model, err := loadModel(path)
+	if err != nil {
+		return nil, fmt.Errorf("load: %v", err)
+	}
+	return model.Build(g)
+}
+
+// LoadWithBatch loads the NeuralNet saved in the model file at path,
+// building it on graph g. The returned NeuralNet takes inputs of batch
+// size batch.
+func LoadWithBatch(path string, batch int, g *G.ExprGraph) (NeuralNet,
+	error) {
+	model, err := loadModel(path)
+	if err != nil {
+		return nil, fmt.Errorf("loadWithBatch: %v", err)
+	}
+	return model.BuildWithBatch(batch, g)
+}
+
+// loadModel reads the Model stored in the model file at path
+func loadModel(path string) (*Model, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open model file: %v", err)
+	}
+	defer file.Close()
+
+	model := &Model{}
+	if err := gob.NewDecoder(file).Decode(model); err != nil {
+		return nil, fmt.Errorf("could not read model file: %v", err)
+	}
+	return model, nil
+}
+
+// mlpArchitecture describes the architecture of a MultiHeadMLP. The
+// hidden layers include the final layer of the MultiHeadMLP.
+type mlpArchitecture struct {
+	Features    int
+	Outputs     int
+	HiddenSizes []int
+	Biases      []bool
+	Activations []*Activation
+	Options     []LayerOptions
+}
+
+// build implements the architecture interface
+func (a *mlpArchitecture) build(batch int, g *G.ExprGraph) (NeuralNet,
+	error) {
+	input := G.NewMatrix(g, tensor.Float64, G.WithShape(batch, a.Features),
+		G.WithName("input"), G.WithInit(G.Zeroes()))
+
+	return newMultiHeadMLPFromInput([]*G.Node{input}, a.Outputs, g,
+		a.HiddenSizes, a.Biases, G.Zeroes(), a.Activations, a.Options, "",
+		"", false)
+}
+
+// treeMLPArchitecture describes the architecture of a TreeMLP
+type treeMLPArchitecture struct {
+	Features        int
+	Outputs         int
+	RootHiddenSizes []int
+	RootBiases      []bool
+	RootActivations []*Activation
+	RootOptions     []LayerOptions
+	LeafHiddenSizes [][]int
+	LeafBiases      [][]bool
+	LeafActivations [][]*Activation
+	LeafOptions     [][]LayerOptions
+}
+
+// build implements the architecture interface
+func (a *treeMLPArchitecture) build(batch int, g *G.ExprGraph) (NeuralNet,
+	error) {
+	return NewTreeMLPWithOptions(a.Features, batch, a.Outputs, g,
+		a.RootHiddenSizes, a.RootBiases, a.RootActivations, a.RootOptions,
+		a.LeafHiddenSizes, a.LeafBiases, a.LeafActivations, a.LeafOptions,
+		G.Zeroes())
+}
+
+// revTreeMLPArchitecture describes the architecture of a RevTreeMLP
+type revTreeMLPArchitecture struct {
+	Features        []int
+	Outputs         int
+	RootHiddenSizes [][]int
+	RootBiases      [][]bool
+	RootActivations [][]*Activation
+	RootOptions     [][]LayerOptions
+	LeafHiddenSizes []int
+	LeafBiases      []bool
+	LeafActivations []*Activation
+	LeafOptions     []LayerOptions
+}
+
+// build implements the architecture interface
+func (a *revTreeMLPArchitecture) build(batch int, g *G.ExprGraph) (NeuralNet,
+	error) {
+	return NewRevTreeMLPWithOptions(a.Features, batch, a.Outputs, g,
+		a.RootHiddenSizes, a.RootBiases, a.RootActivations, a.RootOptions,
+		a.LeafHiddenSizes, a.LeafBiases, a.LeafActivations, a.LeafOptions,
+		G.Zeroes())
+}
+
+// convMLPArchitecture describes the architecture of a ConvMLP. The
+// hidden layers exclude the final layer of the ConvMLP.
+type convMLPArchitecture struct {
+	InputShape  []int // (channels, height, width)
+	Outputs     int
+	ConvLayers  []ConvLayerConfig
+	HiddenSizes []int
+	Biases      []bool
+	Activations []*Activation
+}
+
+// build implements the architecture interface
+func (a *convMLPArchitecture) build(batch int, g *G.ExprGraph) (NeuralNet,
+	error) {
+	return NewConvMLP(a.InputShape[0], a.InputShape[1], a.InputShape[2],
+		batch, a.Outputs, g, a.ConvLayers, a.HiddenSizes, a.Biases,
+		G.Zeroes(), a.Activations)
+}
+
+// recurrentMLPArchitecture describes the architecture of a
+// RecurrentMLP. The hidden layers exclude the final layer of the
+// RecurrentMLP.
+type recurrentMLPArchitecture struct {
+	Features    int
+	SeqLen      int
+	Outputs     int
+	CellType    CellType
+	CellSizes   []int
+	HiddenSizes []int
+	Biases      []bool
+	Activations []*Activation
+}
+
+// build implements the architecture interface
+func (a *recurrentMLPArchitecture) build(batch int,
+	g *G.ExprGraph) (NeuralNet, error) {
+	return NewRecurrentMLP(a.Features, batch, a.SeqLen, a.Outputs, g,
+		a.CellType, a.CellSizes, a.HiddenSizes, a.Biases, G.Zeroes(),
+		a.Activations)
+}
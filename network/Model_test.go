@@ -0,0 +1,169 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	G "gorgonia.org/gorgonia"
+)
+
+const batch = 3
+
+// modelTest describes a network to save and load in TestSaveLoad
+type modelTest struct {
+	name     string
+	features int // Number of inputs per sample
+	build    func(g *G.ExprGraph) (NeuralNet, error)
+}
+
+func modelTests() []modelTest {
+	init := G.GlorotU(1)
+	relu := ReLU()
+
+	return []modelTest{
+		{
+			name:     "MultiHeadMLP",
+			features: 4,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				return NewMultiHeadMLPWithOptions(4, batch, 3, g, []int{8, 8},
+					[]bool{true, false}, init, []*Activation{relu, relu},
+					[]LayerOptions{{Norm: BatchNorm}, {Residual: true}})
+			},
+		},
+		{
+			name:     "TreeMLP",
+			features: 4,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				return NewTreeMLP(4, batch, 2, g, []int{8}, []bool{true},
+					[]*Activation{relu}, [][]int{{5}, {6}},
+					[][]bool{{true}, {false}},
+					[][]*Activation{{relu}, {relu}}, init)
+			},
+		},
+		{
+			name:     "RevTreeMLP",
+			features: 5,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				return NewRevTreeMLP([]int{3, 2}, batch, 2, g,
+					[][]int{{4}, {5}}, [][]bool{{true}, {true}},
+					[][]*Activation{{relu}, {relu}}, []int{6}, []bool{true},
+					[]*Activation{relu}, init)
+			},
+		},
+		{
+			name:     "ConvMLP",
+			features: 2 * 6 * 6,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				conv := []ConvLayerConfig{{Type: Conv2D, Filters: 3,
+					Kernel: []int{3, 3}, Bias: true, Activation: relu}}
+				return NewConvMLP(2, 6, 6, batch, 3, g, conv, []int{7},
+					[]bool{true}, init, []*Activation{relu})
+			},
+		},
+		{
+			name:     "RecurrentMLP",
+			features: 3 * 4,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				return NewRecurrentMLP(3, batch, 4, 2, g, GRU, []int{5},
+					[]int{6}, []bool{true}, init, []*Activation{relu})
+			},
+		},
+	}
+}
+
+// predict returns the outputs of net given input
+func predict(t *testing.T, net NeuralNet, input []float64) [][]float64 {
+	SetEval(net, true)
+	if err := net.SetInput(input); err != nil {
+		t.Fatalf("could not set input: %v", err)
+	}
+
+	vm := G.NewTapeMachine(net.Graph())
+	defer vm.Close()
+	if err := vm.RunAll(); err != nil {
+		t.Fatalf("could not run vm: %v", err)
+	}
+
+	outputs := make([][]float64, len(net.Output()))
+	for i, output := range net.Output() {
+		outputs[i] = append([]float64{}, output.Data().([]float64)...)
+	}
+	return outputs
+}
+
+// TestSaveLoad tests whether each NeuralNet predicts the same outputs
+// after being saved to and loaded from a model file
+func TestSaveLoad(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	dir := t.TempDir()
+
+	for _, test := range modelTests() {
+		net, err := test.build(G.NewGraph())
+		if err != nil {
+			t.Fatalf("%v: could not build network: %v", test.name, err)
+		}
+
+		input := make([]float64, batch*test.features)
+		for i := range input {
+			input[i] = rng.NormFloat64()
+		}
+		want := predict(t, net, input)
+
+		path := filepath.Join(dir, test.name)
+		if err := Save(net, path); err != nil {
+			t.Fatalf("%v: could not save network: %v", test.name, err)
+		}
+		loaded, err := Load(path, G.NewGraph())
+		if err != nil {
+			t.Fatalf("%v: could not load network: %v", test.name, err)
+		}
+		got := predict(t, loaded, input)
+
+		for i := range want {
+			for j := range want[i] {
+				if math.Abs(want[i][j]-got[i][j]) > 1e-12 {
+					t.Errorf("%v: output %v mismatch \n\twant(%v) \n\thave(%v)",
+						test.name, i, want[i], got[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+// TestLoadWithBatch tests whether a NeuralNet loaded with a different
+// batch size predicts the same outputs as the saved NeuralNet
+func TestLoadWithBatch(t *testing.T) {
+	net, err := modelTests()[0].build(G.NewGraph())
+	if err != nil {
+		t.Fatalf("could not build network: %v", err)
+	}
+
+	input := make([]float64, batch*4)
+	for i := range input {
+		input[i] = float64(i) / 10
+	}
+	want := predict(t, net, input)[0]
+
+	path := filepath.Join(t.TempDir(), "model")
+	if err := Save(net, path); err != nil {
+		t.Fatalf("could not save network: %v", err)
+	}
+	loaded, err := LoadWithBatch(path, 1, G.NewGraph())
+	if err != nil {
+		t.Fatalf("could not load network: %v", err)
+	}
+	if size := loaded.BatchSize(); size != 1 {
+		t.Fatalf("invalid batch size \n\twant(1) \n\thave(%v)", size)
+	}
+
+	got := predict(t, loaded, input[:4])[0]
+	for i := range got {
+		if math.Abs(want[i]-got[i]) > 1e-12 {
+			t.Fatalf("output mismatch \n\twant(%v) \n\thave(%v)", want[:3],
+				got)
+		}
+	}
+}
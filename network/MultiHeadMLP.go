@@ -23,6 +23,7 @@ type MultiHeadMLP struct {
 	hiddenSizes []int
 	biases      []bool
 	activations []*Activation
+	options     []LayerOptions
 
 	learnables G.Nodes
 	model      []G.ValueGrad
@@ -34,11 +35,12 @@ type MultiHeadMLP struct {
 // newMultiHeadMLPFromInput returns a new multi-head output MLP that
 // has a specific node as its input node. If multiple input nodes are
 // given, they are first concatenated along the feature (column)
-// dimension.
+// dimension. If options is nil, all layers are plain fully connected
+// layers.
 func newMultiHeadMLPFromInput(inputs []*G.Node, outputs int, g *G.ExprGraph,
 	hiddenSizes []int, biases []bool, init G.InitWFn,
-	activations []*Activation, prefix, suffix string,
-	addFinalLayer bool) (NeuralNet, error) {
+	activations []*Activation, options []LayerOptions, prefix,
+	suffix string, addFinalLayer bool) (NeuralNet, error) {
 	// Ensure we have one activation per layer
 	if len(hiddenSizes) != len(activations) {
 		msg := "newmultiheadegreedymlp: invalid number of activations" +
@@ -69,12 +71,23 @@ func newMultiHeadMLPFromInput(inputs []*G.Node, outputs int, g *G.ExprGraph,
 	batch := input.Shape()[0]
 	features := input.Shape()[1]
 
+	// If options has one more element than hiddenSizes, then its last
+	// element determines the options of the final layer
+	var finalOptions LayerOptions
+	if addFinalLayer && len(options) == len(hiddenSizes)+1 {
+		finalOptions = options[len(options)-1]
+		options = options[:len(options)-1]
+	}
+
 	// If required, add a final linear layer with no activation to ensure
 	// outputs heads are predicted by the network
 	if addFinalLayer {
 		hiddenSizes = append(hiddenSizes, outputs)
 		biases = append(biases, true)
 		activations = append(activations, Identity())
+		if options != nil {
+			options = append(options, finalOptions)
+		}
 	} else if outputs != hiddenSizes[len(hiddenSizes)-1] {
 		msg := "newmultiheadmlpfrominput: claimed output is of size %v but " +
 			"provided final network layer of size %v != %v"
@@ -82,8 +95,12 @@ func newMultiHeadMLPFromInput(inputs []*G.Node, outputs int, g *G.ExprGraph,
 			outputs)
 	}
 
+	if err := validateLayerOptions(options, features, hiddenSizes); err != nil {
+		return nil, fmt.Errorf("newmultiheadmlpfrominput: %v", err)
+	}
+
 	layers := addfcLayers(g, hiddenSizes, biases, activations, init, features,
-		prefix, suffix)
+		prefix, suffix, options)
 
 	// Create the network and run the forward pass on the input node
 	network := MultiHeadMLP{
@@ -96,6 +113,7 @@ func newMultiHeadMLPFromInput(inputs []*G.Node, outputs int, g *G.ExprGraph,
 		hiddenSizes: hiddenSizes,
 		biases:      biases,
 		activations: activations,
+		options:     options,
 		learnables:  nil,
 		model:       nil,
 	}
@@ -127,6 +145,20 @@ func newMultiHeadMLPFromInput(inputs []*G.Node, outputs int, g *G.ExprGraph,
 func NewMultiHeadMLP(features, batch, outputs int, g *G.ExprGraph,
 	hiddenSizes []int, biases []bool, init G.InitWFn,
 	activations []*Activation) (NeuralNet, error) {
+	return NewMultiHeadMLPWithOptions(features, batch, outputs, g,
+		hiddenSizes, biases, init, activations, nil)
+}
+
+// NewMultiHeadMLPWithOptions is like NewMultiHeadMLP, but options[i]
+// determines the normalization, dropout, residual connection, and
+// noise of hidden layer i. The final layer added to the network has
+// zero options, unless options has one more element than hiddenSizes,
+// in which case the last element of options determines the options of
+// the final layer. If options is nil, the returned network is
+// equivalent to that returned by NewMultiHeadMLP.
+func NewMultiHeadMLPWithOptions(features, batch, outputs int,
+	g *G.ExprGraph, hiddenSizes []int, biases []bool, init G.InitWFn,
+	activations []*Activation, options []LayerOptions) (NeuralNet, error) {
 
 	// Ensure we have one activation per layer
 	if len(hiddenSizes) != len(activations) {
@@ -147,7 +179,7 @@ func NewMultiHeadMLP(features, batch, outputs int, g *G.ExprGraph,
 		G.WithName("input"), G.WithInit(G.Zeroes()))
 
 	net, err := newMultiHeadMLPFromInput([]*G.Node{input}, outputs, g, hiddenSizes,
-		biases, init, activations, "", "", true)
+		biases, init, activations, options, "", "", true)
 
 	return net, err
 }
@@ -194,7 +226,7 @@ func (e *MultiHeadMLP) cloneWithInputTo(axis int, inputs []*G.Node,
 	// Copy fully connected layers
 	l := make([]Layer, len(e.layers))
 	for i := range e.layers {
-		l[i] = e.layers[i].CloneTo(graph).(*fcLayer)
+		l[i] = e.layers[i].CloneTo(graph)
 	}
 
 	if !input.IsMatrix() {
@@ -214,6 +246,7 @@ func (e *MultiHeadMLP) cloneWithInputTo(axis int, inputs []*G.Node,
 		hiddenSizes: e.hiddenSizes,
 		biases:      e.biases,
 		activations: e.activations,
+		options:     e.options,
 	}
 	_, err := network.fwd([]*G.Node{input})
 	if err != nil {
@@ -254,6 +287,18 @@ func (e *MultiHeadMLP) BatchSize() int {
 	return e.batchSize
 }
 
+// architecture returns the architecture of the MultiHeadMLP
+func (e *MultiHeadMLP) architecture() architecture {
+	return &mlpArchitecture{
+		Features:    e.numInputs,
+		Outputs:     e.numOutputs,
+		HiddenSizes: e.hiddenSizes,
+		Biases:      e.biases,
+		Activations: e.activations,
+		Options:     e.options,
+	}
+}
+
 // Features returns the number of features in a single observation
 // vector that the policy takes as input.
 func (e *MultiHeadMLP) Features() []int {
@@ -289,9 +334,34 @@ func (e *MultiHeadMLP) SetInput(input []float64) error {
 		tensor.WithBacking(input),
 		tensor.WithShape(e.input.Shape()...),
 	)
+	if err := refreshLayers(e.layers); err != nil {
+		return fmt.Errorf("setInput: %v", err)
+	}
 	return G.Let(e.input, inputTensor)
 }
 
+// setEval sets the MultiHeadMLP to evaluation mode if eval is true
+// and to training mode otherwise
+func (e *MultiHeadMLP) setEval(eval bool) {
+	setLayersEval(e.layers, eval)
+}
+
+// setSeed seeds the layers of the MultiHeadMLP
+func (e *MultiHeadMLP) setSeed(seed uint64) {
+	setLayersSeed(e.layers, seed)
+}
+
+// refresh refreshes the layers of the MultiHeadMLP before a forward
+// pass
+func (e *MultiHeadMLP) refresh() error {
+	return refreshLayers(e.layers)
+}
+
+// state returns the non-learnable state nodes of the MultiHeadMLP
+func (e *MultiHeadMLP) state() G.Nodes {
+	return layerState(e.layers)
+}
+
 // Learnables returns the learnable nodes in a MultiHeadMLP
 func (m *MultiHeadMLP) Learnables() G.Nodes {
 	// Lazy instantiation
@@ -303,15 +373,7 @@ func (m *MultiHeadMLP) Learnables() G.Nodes {
 
 // computeLearnables computes all the learnables for the network
 func (e *MultiHeadMLP) computeLearnables() G.Nodes {
-	learnables := make([]*G.Node, 0, 2*len(e.layers))
-
-	for i := range e.layers {
-		learnables = append(learnables, e.layers[i].Weights())
-		if bias := e.layers[i].Bias(); bias != nil {
-			learnables = append(learnables, bias)
-		}
-	}
-	return G.Nodes(learnables)
+	return layerLearnables(e.layers)
 }
 
 // Model returns the learnables nodes with their gradients.
@@ -410,8 +472,14 @@ func (e *MultiHeadMLP) GobEncode() ([]byte, error) {
 		return nil, fmt.Errorf("gobencode: could not encode activations")
 	}
 
-	// Store the fcLayers
+	err = enc.Encode(e.options)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode layer options")
+	}
+
+	// Store the fcLayers and fcBlocks
 	gob.Register(fcLayer{})
+	gob.Register(fcBlock{})
 	for i, layer := range e.layers {
 		// Encode layer
 		err := enc.Encode(layer)
@@ -469,10 +537,16 @@ func (e *MultiHeadMLP) GobDecode(in []byte) error {
 	}
 	activations = activations[:len(activations)-1]
 
+	var options []LayerOptions
+	err = dec.Decode(&options)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode layer options")
+	}
+
 	// Create a new MLP
 	g := G.NewGraph()
-	newNet, err := NewMultiHeadMLP(numInputs, batchSize, numOutputs, g,
-		hiddenSizes, biases, G.Zeroes(), activations)
+	newNet, err := NewMultiHeadMLPWithOptions(numInputs, batchSize,
+		numOutputs, g, hiddenSizes, biases, G.Zeroes(), activations, options)
 	if err != nil {
 		return fmt.Errorf("gobdecode: could not construct new MLP")
 	}
@@ -485,6 +559,7 @@ func (e *MultiHeadMLP) GobDecode(in []byte) error {
 	// for i in 0, 1, 2, ... N:
 	//     newMLP.layer[i].Weights().Value <- fcLayer[i].Weights.Value
 	gob.Register(fcLayer{})
+	gob.Register(fcBlock{})
 	numLayers := len(newMLP.layers)
 	layers := newMLP.layers
 	for i := 0; i < numLayers; i++ {
@@ -46,6 +46,10 @@ type NeuralNet interface {
 	// input and cloning the network to a given computational graph g.
 	cloneWithInputTo(axis int, input []*G.Node,
 		graph *G.ExprGraph) (NeuralNet, error)
+
+	// architecture returns the architecture of the NeuralNet, used to
+	// rebuild the NeuralNet from a Model
+	architecture() architecture
 }
 
 // Layer implements a single layer of a NeuralNet. This could be a
@@ -59,10 +63,12 @@ type Layer interface {
 	Activation() *Activation
 }
 
-// Set sets the weights of a dest to be equal to the weights of source
+// Set sets the weights of a dest to be equal to the weights of source.
+// Any non-learnable state of source, such as the running statistics of
+// batch normalization layers, is also copied to dest.
 func Set(dest, source NeuralNet) error {
-	sourceNodes := source.Learnables()
-	nodes := dest.Learnables()
+	sourceNodes := withState(source)
+	nodes := withState(dest)
 	for i, destLearnable := range nodes {
 		sourceLearnable := sourceNodes[i].Clone() // Is Clone() needed?
 		err := G.Let(destLearnable, sourceLearnable.(*G.Node).Value())
@@ -73,12 +79,24 @@ func Set(dest, source NeuralNet) error {
 	return nil
 }
 
+// withState returns the learnables of net followed by its
+// non-learnable state nodes
+func withState(net NeuralNet) G.Nodes {
+	nodes := append(G.Nodes{}, net.Learnables()...)
+	if s, ok := net.(stater); ok {
+		nodes = append(nodes, s.state()...)
+	}
+	return nodes
+}
+
 // Polyak compute the polyak average of weights of dest with the weights
 // of source and stores these averaged weights as the new weights of
-// dest.
+// dest. Any non-learnable state of the networks, such as the running
+// statistics of batch normalization layers, is averaged in the same
+// way.
 func Polyak(dest, source NeuralNet, tau float64) error {
-	sourceNodes := source.Learnables()
-	nodes := dest.Learnables()
+	sourceNodes := withState(source)
+	nodes := withState(dest)
 	for i := range nodes {
 		weights := nodes[i].Value().(*tensor.Dense)
 		sourceWeights := sourceNodes[i].Value().(*tensor.Dense)
@@ -0,0 +1,456 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/network/onnx"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// Metadata keys under which the architecture of an exported NeuralNet
+// is stored in an ONNX file
+const (
+	onnxArchTypeKey = "golearn.architecture.type"
+	onnxArchKey     = "golearn.architecture"
+)
+
+// ExportONNX writes net to an ONNX file at path so that it can be used
+// for inference outside of Gorgonia. Only MLP-family networks can be
+// exported: MultiHeadMLP (including networks created with
+// NewSingleHeadMLP), TreeMLP, and RevTreeMLP.
+//
+// The exported graph computes the forward pass of net in evaluation
+// mode, using single precision floats. That is, batch normalization
+// layers use their running statistics, dropout is not applied, and
+// NoisyNet layers use their mean weights and bias. The batch dimension
+// of each input and output of the graph is symbolic, so that the
+// exported network accepts inputs of any batch size.
+//
+// The inputs of the exported graph are named "input" for networks with
+// a single input and "input0", "input1", ... for a RevTreeMLP, which
+// has one input per root network. Similarly, the outputs are named
+// "output" for networks with a single output and "output0",
+// "output1", ... for a TreeMLP, which has one output per leaf network.
+func ExportONNX(net NeuralNet, path string) error {
+	b := newONNXBuilder()
+	if err := b.build(net); err != nil {
+		return fmt.Errorf("exportONNX: %v", err)
+	}
+
+	// Store the architecture so that the network can be imported with
+	// ImportONNX
+	arch := net.architecture()
+	archJSON, err := json.Marshal(arch)
+	if err != nil {
+		return fmt.Errorf("exportONNX: could not encode architecture: %v",
+			err)
+	}
+
+	model := onnx.NewModel(b.graph)
+	model.MetadataProps[onnxArchTypeKey] = onnxArchType(arch)
+	model.MetadataProps[onnxArchKey] = string(archJSON)
+
+	if err := onnx.Save(model, path); err != nil {
+		return fmt.Errorf("exportONNX: %v", err)
+	}
+	return nil
+}
+
+// ImportONNX reads a NeuralNet from an ONNX file at path written by
+// ExportONNX, building the NeuralNet on graph g. The returned NeuralNet
+// takes inputs of batch size batch. The weights of the NeuralNet are
+// read from the initializers of the ONNX graph.
+//
+// Since the ONNX graph only describes the network in evaluation mode,
+// the noise scales of NoisyNet layers are not exported and are reset
+// to their initial values when importing.
+func ImportONNX(path string, batch int, g *G.ExprGraph) (NeuralNet, error) {
+	model, err := onnx.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("importONNX: %v", err)
+	}
+
+	arch, err := decodeONNXArch(model.MetadataProps[onnxArchTypeKey],
+		model.MetadataProps[onnxArchKey])
+	if err != nil {
+		return nil, fmt.Errorf("importONNX: %v", err)
+	}
+
+	net, err := arch.build(batch, g)
+	if err != nil {
+		return nil, fmt.Errorf("importONNX: could not build network: %v",
+			err)
+	}
+
+	// Find the nodes of the network which the ONNX graph stores as
+	// initializers and set their values
+	b := newONNXBuilder()
+	if err := b.build(net); err != nil {
+		return nil, fmt.Errorf("importONNX: %v", err)
+	}
+
+	for _, name := range b.paramNames {
+		node := b.params[name]
+		init, ok := model.Graph.InitializerByName(name)
+		if !ok {
+			return nil, fmt.Errorf("importONNX: missing initializer %v", name)
+		}
+		if !tensor.Shape(init.Shape()).Eq(node.Shape()) {
+			return nil, fmt.Errorf("importONNX: invalid shape for "+
+				"initializer %v \n\twant(%v) \n\thave(%v)", name,
+				node.Shape(), init.Shape())
+		}
+
+		value := tensor.NewDense(tensor.Float64, init.Shape(),
+			tensor.WithBacking(append([]float64{}, init.Data...)))
+		if err := G.Let(node, value); err != nil {
+			return nil, fmt.Errorf("importONNX: could not set %v: %v", name,
+				err)
+		}
+	}
+
+	return net, nil
+}
+
+// onnxArchType returns the name of the type of architecture arch
+func onnxArchType(arch architecture) string {
+	switch arch.(type) {
+	case *mlpArchitecture:
+		return "MultiHeadMLP"
+
+	case *treeMLPArchitecture:
+		return "TreeMLP"
+
+	case *revTreeMLPArchitecture:
+		return "RevTreeMLP"
+	}
+	return ""
+}
+
+// decodeONNXArch decodes the architecture stored in an ONNX file with
+// type archType and JSON encoding archJSON
+func decodeONNXArch(archType, archJSON string) (architecture, error) {
+	var arch architecture
+	switch archType {
+	case "MultiHeadMLP":
+		arch = &mlpArchitecture{}
+
+	case "TreeMLP":
+		arch = &treeMLPArchitecture{}
+
+	case "RevTreeMLP":
+		arch = &revTreeMLPArchitecture{}
+
+	case "":
+		return nil, fmt.Errorf("file does not describe a golearn network")
+
+	default:
+		return nil, fmt.Errorf("unknown architecture type %v", archType)
+	}
+
+	if err := json.Unmarshal([]byte(archJSON), arch); err != nil {
+		return nil, fmt.Errorf("could not decode architecture: %v", err)
+	}
+	return arch, nil
+}
+
+// onnxBuilder builds an ONNX graph which computes the forward pass of
+// a NeuralNet in evaluation mode
+type onnxBuilder struct {
+	graph *onnx.Graph
+	ops   int // Number of operators added to the graph
+
+	// Nodes of the NeuralNet whose values are stored as initializers,
+	// keyed by initializer name, in the order they were added
+	params     map[string]*G.Node
+	paramNames []string
+
+	constants map[float64]string
+}
+
+// newONNXBuilder returns a new onnxBuilder with an empty graph
+func newONNXBuilder() *onnxBuilder {
+	return &onnxBuilder{
+		graph:     &onnx.Graph{Name: "golearn"},
+		params:    make(map[string]*G.Node),
+		constants: make(map[float64]string),
+	}
+}
+
+// build adds the forward pass of net to the graph
+func (b *onnxBuilder) build(net NeuralNet) error {
+	switch net := net.(type) {
+	case *MultiHeadMLP:
+		input := b.input("input", net.Features()[0])
+		output, err := b.mlp(net, input, "")
+		if err != nil {
+			return err
+		}
+		b.output(output, "output", net.Outputs()[0])
+
+	case *TreeMLP:
+		input := b.input("input", net.Features()[0])
+		root, err := b.mlp(net.rootNetwork, input, "root.")
+		if err != nil {
+			return err
+		}
+
+		for i, leaf := range net.leafNetworks {
+			output, err := b.mlp(leaf, root, fmt.Sprintf("leaf%d.", i))
+			if err != nil {
+				return err
+			}
+			b.output(output, fmt.Sprintf("output%d", i), net.Outputs()[i])
+		}
+
+	case *RevTreeMLP:
+		roots := make([]string, len(net.rootNetworks))
+		for i, rootNet := range net.rootNetworks {
+			input := b.input(fmt.Sprintf("input%d", i), net.Features()[i])
+			root, err := b.mlp(rootNet, input, fmt.Sprintf("root%d.", i))
+			if err != nil {
+				return err
+			}
+			roots[i] = root
+		}
+
+		concat := b.op("Concat", roots, onnx.IntAttribute("axis", 1))
+		output, err := b.mlp(net.leafNetwork, concat, "leaf.")
+		if err != nil {
+			return err
+		}
+		b.output(output, "output", net.Outputs()[0])
+
+	default:
+		return fmt.Errorf("cannot export network of type %T to ONNX", net)
+	}
+
+	return nil
+}
+
+// input adds an input with the given name and number of features to
+// the graph and returns its name
+func (b *onnxBuilder) input(name string, features int) string {
+	b.graph.Inputs = append(b.graph.Inputs, &onnx.ValueInfo{
+		Name:     name,
+		ElemType: onnx.Float,
+		Shape:    []onnx.Dim{{Param: "batch"}, {Value: int64(features)}},
+	})
+	return name
+}
+
+// output marks x as an output of the graph with the given name and
+// number of outputs
+func (b *onnxBuilder) output(x, name string, outputs int) {
+	b.graph.Nodes = append(b.graph.Nodes, &onnx.Node{
+		Name:    name,
+		OpType:  "Identity",
+		Inputs:  []string{x},
+		Outputs: []string{name},
+	})
+	b.graph.Outputs = append(b.graph.Outputs, &onnx.ValueInfo{
+		Name:     name,
+		ElemType: onnx.Float,
+		Shape:    []onnx.Dim{{Param: "batch"}, {Value: int64(outputs)}},
+	})
+}
+
+// param adds the value of node to the graph as an initializer with the
+// given name and returns the name
+func (b *onnxBuilder) param(name string, node *G.Node) string {
+	shape := node.Shape()
+	dims := make([]int64, len(shape))
+	for i := range shape {
+		dims[i] = int64(shape[i])
+	}
+
+	b.graph.Initializer = append(b.graph.Initializer, &onnx.Tensor{
+		Name:     name,
+		Dims:     dims,
+		DataType: onnx.Float,
+		Data:     append([]float64{}, node.Value().Data().([]float64)...),
+	})
+	b.params[name] = node
+	b.paramNames = append(b.paramNames, name)
+
+	return name
+}
+
+// constant returns the name of a scalar initializer holding value,
+// adding the initializer to the graph if needed
+func (b *onnxBuilder) constant(value float64) string {
+	if name, ok := b.constants[value]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("const%d", len(b.constants))
+	b.graph.Initializer = append(b.graph.Initializer, &onnx.Tensor{
+		Name:     name,
+		DataType: onnx.Float,
+		Data:     []float64{value},
+	})
+	b.constants[value] = name
+
+	return name
+}
+
+// op adds an operator of type opType with the given inputs and
+// attributes to the graph and returns the name of its output
+func (b *onnxBuilder) op(opType string, inputs []string,
+	attrs ...*onnx.Attribute) string {
+	b.ops++
+	name := fmt.Sprintf("%v%d", opType, b.ops)
+
+	b.graph.Nodes = append(b.graph.Nodes, &onnx.Node{
+		Name:       name,
+		OpType:     opType,
+		Inputs:     inputs,
+		Outputs:    []string{name},
+		Attributes: attrs,
+	})
+	return name
+}
+
+// mlp adds the forward pass of the MultiHeadMLP net with input x to
+// the graph and returns the name of its output. The prefix is
+// prepended to the names of the initializers of the network.
+func (b *onnxBuilder) mlp(net NeuralNet, x, prefix string) (string, error) {
+	mlp, ok := net.(*MultiHeadMLP)
+	if !ok {
+		return "", fmt.Errorf("cannot export network of type %T to ONNX",
+			net)
+	}
+
+	var err error
+	for i, layer := range mlp.layers {
+		x, err = b.layer(layer, x, fmt.Sprintf("%vlayer%d.", prefix, i))
+		if err != nil {
+			return "", fmt.Errorf("could not export layer %v: %v", i, err)
+		}
+	}
+	return x, nil
+}
+
+// layer adds the forward pass of layer l with input x to the graph and
+// returns the name of its output. The prefix is prepended to the names
+// of the initializers of the layer.
+func (b *onnxBuilder) layer(l Layer, x, prefix string) (string, error) {
+	var block *fcBlock
+	switch l := l.(type) {
+	case *fcLayer:
+
+	case *fcBlock:
+		block = l
+
+	default:
+		return "", fmt.Errorf("unsupported layer type %T", l)
+	}
+
+	// NoisyNet layers add no noise in evaluation mode, so only their
+	// mean weights and bias are needed
+	pred := b.op("MatMul", []string{x, b.param(prefix+"weight", l.Weights())})
+	if bias := l.Bias(); bias != nil {
+		pred = b.op("Add", []string{pred, b.param(prefix+"bias", bias)})
+	}
+
+	if block != nil {
+		switch block.opts.Norm {
+		case LayerNorm:
+			pred = b.layerNorm(pred, block, prefix)
+
+		case BatchNorm:
+			state := block.state()
+			pred = b.op("BatchNormalization", []string{
+				pred,
+				b.param(prefix+"norm.scale", block.scale),
+				b.param(prefix+"norm.shift", block.shift),
+				b.param(prefix+"norm.running_mean", state[0]),
+				b.param(prefix+"norm.running_var", state[1]),
+			}, onnx.FloatAttribute("epsilon", float32(normEpsilon)))
+		}
+	}
+
+	pred, err := b.activation(l.Activation(), pred)
+	if err != nil {
+		return "", err
+	}
+
+	// Dropout is not applied in evaluation mode
+	if block != nil && block.opts.Residual {
+		pred = b.op("Add", []string{x, pred})
+	}
+
+	return pred, nil
+}
+
+// layerNorm adds layer normalization of x, using the learned scale and
+// shift of block, to the graph and returns the name of its output
+func (b *onnxBuilder) layerNorm(x string, block *fcBlock,
+	prefix string) string {
+	axes := onnx.IntsAttribute("axes", 1)
+
+	mean := b.op("ReduceMean", []string{x}, axes)
+	centred := b.op("Sub", []string{x, mean})
+	variance := b.op("ReduceMean",
+		[]string{b.op("Mul", []string{centred, centred})}, axes)
+	stddev := b.op("Sqrt", []string{
+		b.op("Add", []string{variance, b.constant(normEpsilon)}),
+	})
+	normalized := b.op("Div", []string{centred, stddev})
+
+	scaled := b.op("Mul", []string{
+		normalized,
+		b.param(prefix+"norm.scale", block.scale),
+	})
+	return b.op("Add", []string{
+		scaled,
+		b.param(prefix+"norm.shift", block.shift),
+	})
+}
+
+// activation adds activation a of x to the graph and returns the name
+// of its output
+func (b *onnxBuilder) activation(a *Activation, x string) (string, error) {
+	switch a.activationType {
+	case identity, nil_:
+		return x, nil
+
+	case relu:
+		return b.op("Relu", []string{x}), nil
+
+	case tanh:
+		return b.op("Tanh", []string{x}), nil
+
+	case sigmoid:
+		return b.op("Sigmoid", []string{x}), nil
+
+	case sin:
+		return b.op("Sin", []string{x}), nil
+
+	case cos:
+		return b.op("Cos", []string{x}), nil
+
+	case softplus:
+		return b.op("Softplus", []string{x}), nil
+
+	case sqrt:
+		return b.op("Sqrt", []string{b.op("Abs", []string{x})}), nil
+
+	case log1p:
+		abs := b.op("Abs", []string{x})
+		return b.op("Log", []string{
+			b.op("Add", []string{abs, b.constant(1.0)}),
+		}), nil
+
+	case mish:
+		// mish(x) = x * tanh(softplus(x))
+		soft := b.op("Softplus", []string{x})
+		return b.op("Mul", []string{x, b.op("Tanh", []string{soft})}),
+			nil
+
+	default:
+		return "", fmt.Errorf("unsupported activation %v", a)
+	}
+}
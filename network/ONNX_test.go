@@ -0,0 +1,353 @@
+package network
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/network/onnx"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// onnxTol is the tolerance when comparing outputs of exported networks,
+// which use single precision floats
+const onnxTol = 1e-4
+
+// onnxTests returns networks to export to ONNX in TestONNXRoundTrip
+func onnxTests() []modelTest {
+	init := G.GlorotU(1)
+	relu := ReLU()
+
+	return []modelTest{
+		{
+			name:     "SingleHeadMLP",
+			features: 4,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				return NewSingleHeadMLP(4, batch, g, []int{8, 8},
+					[]bool{true, false}, init, []*Activation{TanH(), Sigmoid()})
+			},
+		},
+		{
+			name:     "MultiHeadMLP",
+			features: 4,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				return NewMultiHeadMLPWithOptions(4, batch, 3, g,
+					[]int{6, 6, 6, 6, 6},
+					[]bool{true, true, false, true, true}, init,
+					[]*Activation{Mish(), Log1p(), Sqrt(), Sin(), Softplus()},
+					[]LayerOptions{
+						{Norm: BatchNorm},
+						{Norm: LayerNorm, Residual: true},
+						{Dropout: 0.5},
+						{Noisy: true},
+						{Residual: true},
+					})
+			},
+		},
+		{
+			name:     "TreeMLP",
+			features: 4,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				return NewTreeMLP(4, batch, 2, g, []int{8}, []bool{true},
+					[]*Activation{relu}, [][]int{{5}, {6}},
+					[][]bool{{true}, {false}},
+					[][]*Activation{{Cos()}, {relu}}, init)
+			},
+		},
+		{
+			name:     "RevTreeMLP",
+			features: 5,
+			build: func(g *G.ExprGraph) (NeuralNet, error) {
+				return NewRevTreeMLP([]int{3, 2}, batch, 2, g,
+					[][]int{{4}, {5}}, [][]bool{{true}, {true}},
+					[][]*Activation{{relu}, {TanH()}}, []int{6}, []bool{true},
+					[]*Activation{relu}, init)
+			},
+		},
+	}
+}
+
+// TestONNXRoundTrip tests whether networks exported to ONNX compute the
+// same outputs as the original networks, both when the ONNX graph is
+// evaluated directly and when it is imported as a NeuralNet
+func TestONNXRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	dir := t.TempDir()
+
+	for _, test := range onnxTests() {
+		net, err := test.build(G.NewGraph())
+		if err != nil {
+			t.Fatalf("%v: could not build network: %v", test.name, err)
+		}
+
+		// Use non-trivial batch normalization statistics
+		if s, ok := net.(stater); ok {
+			for _, node := range s.state() {
+				values := make([]float64, node.Shape().TotalSize())
+				for i := range values {
+					values[i] = 0.5 + rng.Float64()
+				}
+				G.Let(node, tensor.New(tensor.WithShape(node.Shape()...),
+					tensor.WithBacking(values)))
+			}
+		}
+
+		input := make([]float64, batch*test.features)
+		for i := range input {
+			input[i] = rng.NormFloat64()
+		}
+		want := predict(t, net, input)
+
+		path := filepath.Join(dir, test.name+".onnx")
+		if err := ExportONNX(net, path); err != nil {
+			t.Fatalf("%v: could not export network: %v", test.name, err)
+		}
+
+		// Evaluate the exported graph
+		model, err := onnx.Load(path)
+		if err != nil {
+			t.Fatalf("%v: could not load ONNX file: %v", test.name, err)
+		}
+		inputs := make(map[string]onnxValue)
+		start := 0
+		for _, in := range model.Graph.Inputs {
+			features := int(in.Shape[1].Value)
+			stop := start + batch*features
+			inputs[in.Name] = onnxValue{batch, features, input[start:stop]}
+			start = stop
+		}
+		values, err := evalONNX(model.Graph, inputs)
+		if err != nil {
+			t.Fatalf("%v: could not evaluate ONNX graph: %v", test.name, err)
+		}
+		for i, out := range model.Graph.Outputs {
+			compare(t, test.name+" ONNX "+out.Name, want[i],
+				values[out.Name].data, onnxTol)
+		}
+
+		// Import the exported graph
+		imported, err := ImportONNX(path, batch, G.NewGraph())
+		if err != nil {
+			t.Fatalf("%v: could not import network: %v", test.name, err)
+		}
+		got := predict(t, imported, input)
+		for i := range want {
+			compare(t, test.name+" imported", want[i], got[i], onnxTol)
+		}
+	}
+}
+
+// TestONNXUnsupported tests whether exporting an unsupported network
+// fails
+func TestONNXUnsupported(t *testing.T) {
+	net, err := NewConvMLP(1, 4, 4, 1, 2, G.NewGraph(), nil, []int{3},
+		[]bool{true}, G.GlorotU(1), []*Activation{ReLU()})
+	if err != nil {
+		t.Fatalf("could not build network: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "conv.onnx")
+	if err := ExportONNX(net, path); err == nil {
+		t.Errorf("expected error when exporting ConvMLP")
+	}
+}
+
+// compare fails the test if want and got differ by more than tol
+func compare(t *testing.T, name string, want, got []float64, tol float64) {
+	if len(want) != len(got) {
+		t.Errorf("%v: invalid number of outputs \n\twant(%v) \n\thave(%v)",
+			name, len(want), len(got))
+		return
+	}
+	for i := range want {
+		if math.Abs(want[i]-got[i]) > tol {
+			t.Errorf("%v: output mismatch \n\twant(%v) \n\thave(%v)", name,
+				want, got)
+			return
+		}
+	}
+}
+
+// onnxValue is a value in an ONNX graph. Scalars have shape (1, 1)
+// and vectors have shape (1, n).
+type onnxValue struct {
+	rows, cols int
+	data       []float64
+}
+
+// evalONNX evaluates the ONNX graph g with the given inputs, returning
+// the values of all nodes in the graph. Only the operators used by
+// ExportONNX are supported.
+func evalONNX(g *onnx.Graph, inputs map[string]onnxValue) (
+	map[string]onnxValue, error) {
+	values := make(map[string]onnxValue)
+	for name, value := range inputs {
+		values[name] = value
+	}
+	for _, init := range g.Initializer {
+		shape := append([]int{1, 1}, init.Shape()...)
+		shape = shape[len(shape)-2:]
+		values[init.Name] = onnxValue{shape[0], shape[1], init.Data}
+	}
+
+	for _, node := range g.Nodes {
+		in := make([]onnxValue, len(node.Inputs))
+		for i, name := range node.Inputs {
+			value, ok := values[name]
+			if !ok {
+				return nil, fmt.Errorf("node %v: undefined input %v", node.Name,
+					name)
+			}
+			in[i] = value
+		}
+
+		var out onnxValue
+		switch node.OpType {
+		case "Identity":
+			out = in[0]
+
+		case "MatMul":
+			out = onnxValue{in[0].rows, in[1].cols,
+				make([]float64, in[0].rows*in[1].cols)}
+			for i := 0; i < in[0].rows; i++ {
+				for j := 0; j < in[1].cols; j++ {
+					for k := 0; k < in[0].cols; k++ {
+						out.data[i*out.cols+j] += in[0].data[i*in[0].cols+k] *
+							in[1].data[k*in[1].cols+j]
+					}
+				}
+			}
+
+		case "Add":
+			out = broadcast(in[0], in[1], func(x, y float64) float64 {
+				return x + y
+			})
+
+		case "Sub":
+			out = broadcast(in[0], in[1], func(x, y float64) float64 {
+				return x - y
+			})
+
+		case "Mul":
+			out = broadcast(in[0], in[1], func(x, y float64) float64 {
+				return x * y
+			})
+
+		case "Div":
+			out = broadcast(in[0], in[1], func(x, y float64) float64 {
+				return x / y
+			})
+
+		case "Relu":
+			out = apply(in[0], func(x float64) float64 {
+				return math.Max(x, 0)
+			})
+
+		case "Sigmoid":
+			out = apply(in[0], func(x float64) float64 {
+				return 1 / (1 + math.Exp(-x))
+			})
+
+		case "Softplus":
+			out = apply(in[0], func(x float64) float64 {
+				return math.Log(math.Exp(x) + 1)
+			})
+
+		case "Tanh":
+			out = apply(in[0], math.Tanh)
+
+		case "Sin":
+			out = apply(in[0], math.Sin)
+
+		case "Cos":
+			out = apply(in[0], math.Cos)
+
+		case "Sqrt":
+			out = apply(in[0], math.Sqrt)
+
+		case "Abs":
+			out = apply(in[0], math.Abs)
+
+		case "Log":
+			out = apply(in[0], math.Log)
+
+		case "ReduceMean":
+			out = onnxValue{in[0].rows, 1, make([]float64, in[0].rows)}
+			for i := 0; i < in[0].rows; i++ {
+				for j := 0; j < in[0].cols; j++ {
+					out.data[i] += in[0].data[i*in[0].cols+j] /
+						float64(in[0].cols)
+				}
+			}
+
+		case "Concat":
+			cols := 0
+			for _, value := range in {
+				cols += value.cols
+			}
+			out = onnxValue{in[0].rows, cols, nil}
+			for i := 0; i < in[0].rows; i++ {
+				for _, value := range in {
+					row := value.data[i*value.cols : (i+1)*value.cols]
+					out.data = append(out.data, row...)
+				}
+			}
+
+		case "BatchNormalization":
+			eps, _ := node.Attribute("epsilon")
+			x, scale, shift, mean, variance := in[0], in[1], in[2], in[3],
+				in[4]
+			out = onnxValue{x.rows, x.cols, make([]float64, len(x.data))}
+			for i := range x.data {
+				j := i % x.cols
+				stddev := math.Sqrt(variance.data[j] + float64(eps.F))
+				out.data[i] = (x.data[i]-mean.data[j])/stddev*scale.data[j] +
+					shift.data[j]
+			}
+
+		default:
+			return nil, fmt.Errorf("node %v: unsupported operator %v", node.Name,
+				node.OpType)
+		}
+
+		values[node.Outputs[0]] = out
+	}
+
+	return values, nil
+}
+
+// broadcast applies f elementwise to a and b, broadcasting dimensions
+// of size 1
+func broadcast(a, b onnxValue, f func(x, y float64) float64) onnxValue {
+	rows, cols := a.rows, a.cols
+	if b.rows > rows {
+		rows = b.rows
+	}
+	if b.cols > cols {
+		cols = b.cols
+	}
+
+	at := func(v onnxValue, i, j int) float64 {
+		return v.data[(i%v.rows)*v.cols+j%v.cols]
+	}
+
+	out := onnxValue{rows, cols, make([]float64, rows*cols)}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			out.data[i*cols+j] = f(at(a, i, j), at(b, i, j))
+		}
+	}
+	return out
+}
+
+// apply applies f elementwise to v
+func apply(v onnxValue, f func(float64) float64) onnxValue {
+	out := onnxValue{v.rows, v.cols, make([]float64, len(v.data))}
+	for i := range v.data {
+		out.data[i] = f(v.data[i])
+	}
+	return out
+}
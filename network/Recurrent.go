@@ -0,0 +1,315 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// Recurrent is a NeuralNet which carries a hidden state between
+// forward passes. The forward pass of a Recurrent network is unrolled
+// over SequenceLength() timesteps, starting from the current hidden
+// state. After running the forward pass, the hidden state at the end
+// of the sequence can be carried over to become the starting hidden
+// state of the next forward pass.
+type Recurrent interface {
+	NeuralNet
+
+	// SequenceLength returns the number of timesteps that the forward
+	// pass is unrolled over
+	SequenceLength() int
+
+	// ResetState sets the starting hidden state of the network to
+	// zeroes. This should be called at the end of each episode.
+	ResetState() error
+
+	// CarryState sets the starting hidden state of the network to the
+	// final hidden state computed by the last forward pass. This
+	// should be called after each forward pass and before the VM
+	// running the forward pass is reset.
+	CarryState() error
+}
+
+// CellType determines the type of recurrent cell used in a recurrent
+// layer
+type CellType string
+
+// Types of recurrent cells
+const (
+	LSTM CellType = "LSTM"
+	GRU  CellType = "GRU"
+)
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (c *CellType) UnmarshalJSON(data []byte) error {
+	decoded := CellType(strings.Trim(string(data), "\""))
+	switch decoded {
+	case LSTM, GRU:
+		*c = decoded
+		return nil
+
+	default:
+		return fmt.Errorf("unmarshalJSON: illegal CellType %v", decoded)
+	}
+}
+
+// recurrentCell implements a single recurrent layer, which computes a
+// new hidden state from an input and the previous hidden state
+type recurrentCell interface {
+	// step computes one timestep of the recurrent cell. The input x
+	// has shape (batch, features) and the state has stateSize()
+	// nodes, each of shape (batch, hidden()). The new state is
+	// returned, with the first element of the new state being the
+	// output of the cell.
+	step(x *G.Node, state []*G.Node) ([]*G.Node, error)
+
+	// stateSize returns the number of nodes in the hidden state
+	stateSize() int
+
+	// hidden returns the number of hidden units in the cell
+	hidden() int
+
+	// learnables returns the learnable nodes of the cell
+	learnables() G.Nodes
+
+	// cloneTo clones the cell to a new computational graph
+	cloneTo(g *G.ExprGraph) recurrentCell
+}
+
+// newRecurrentCell adds a new recurrent cell of type cellType to the
+// computational graph g. The cell takes inputs of size features and
+// has hidden hidden units. The parameter name is prepended to the
+// names of the weights and bias of the cell.
+func newRecurrentCell(g *G.ExprGraph, cellType CellType, features,
+	hidden int, init G.InitWFn, name string) (recurrentCell, error) {
+	var gates int
+	switch cellType {
+	case LSTM:
+		gates = 4
+
+	case GRU:
+		gates = 3
+
+	default:
+		return nil, fmt.Errorf("newRecurrentCell: illegal cell type %v",
+			cellType)
+	}
+
+	inputWeights := G.NewMatrix(
+		g,
+		tensor.Float64,
+		G.WithShape(features, gates*hidden),
+		G.WithName(name+"Wx"),
+		G.WithInit(init),
+	)
+	hiddenWeights := G.NewMatrix(
+		g,
+		tensor.Float64,
+		G.WithShape(hidden, gates*hidden),
+		G.WithName(name+"Wh"),
+		G.WithInit(init),
+	)
+	bias := G.NewVector(
+		g,
+		tensor.Float64,
+		G.WithShape(gates*hidden),
+		G.WithName(name+"B"),
+		G.WithInit(G.Zeroes()),
+	)
+
+	if cellType == LSTM {
+		return &lstmCell{
+			inputWeights:  inputWeights,
+			hiddenWeights: hiddenWeights,
+			bias:          bias,
+			size:          hidden,
+		}, nil
+	}
+	return &gruCell{
+		inputWeights:  inputWeights,
+		hiddenWeights: hiddenWeights,
+		bias:          bias,
+		size:          hidden,
+	}, nil
+}
+
+// lstmCell implements a long short-term memory cell. The hidden state
+// of the cell consists of the output h and the cell memory c.
+//
+// The weights for the input, forget, cell, and output gates are stored
+// concatenated along the column dimension of each weight matrix, in
+// that order.
+type lstmCell struct {
+	inputWeights  *G.Node // Shape (features, 4 * size)
+	hiddenWeights *G.Node // Shape (size, 4 * size)
+	bias          *G.Node // Shape (4 * size)
+	size          int
+}
+
+// step computes one timestep of the LSTM cell
+func (l *lstmCell) step(x *G.Node, state []*G.Node) ([]*G.Node, error) {
+	h, c := state[0], state[1]
+
+	gates, err := linearGates(x, h, l.inputWeights, l.hiddenWeights, l.bias)
+	if err != nil {
+		return nil, fmt.Errorf("step: %v", err)
+	}
+
+	input := G.Must(G.Sigmoid(gateSlice(gates, 0, l.size)))
+	forget := G.Must(G.Sigmoid(gateSlice(gates, 1, l.size)))
+	cell := G.Must(G.Tanh(gateSlice(gates, 2, l.size)))
+	output := G.Must(G.Sigmoid(gateSlice(gates, 3, l.size)))
+
+	// c' = σ(f) ⊙ c + σ(i) ⊙ tanh(g)
+	newC := G.Must(G.Add(
+		G.Must(G.HadamardProd(forget, c)),
+		G.Must(G.HadamardProd(input, cell)),
+	))
+
+	// h' = σ(o) ⊙ tanh(c')
+	newH := G.Must(G.HadamardProd(output, G.Must(G.Tanh(newC))))
+
+	return []*G.Node{newH, newC}, nil
+}
+
+// stateSize returns the number of nodes in the hidden state
+func (l *lstmCell) stateSize() int {
+	return 2
+}
+
+// hidden returns the number of hidden units in the cell
+func (l *lstmCell) hidden() int {
+	return l.size
+}
+
+// learnables returns the learnable nodes of the cell
+func (l *lstmCell) learnables() G.Nodes {
+	return G.Nodes{l.inputWeights, l.hiddenWeights, l.bias}
+}
+
+// cloneTo clones the cell to a new computational graph
+func (l *lstmCell) cloneTo(g *G.ExprGraph) recurrentCell {
+	return &lstmCell{
+		inputWeights:  l.inputWeights.CloneTo(g),
+		hiddenWeights: l.hiddenWeights.CloneTo(g),
+		bias:          l.bias.CloneTo(g),
+		size:          l.size,
+	}
+}
+
+// gruCell implements a gated recurrent unit. The hidden state of the
+// cell consists only of the output h.
+//
+// The weights for the update, reset, and new gates are stored
+// concatenated along the column dimension of each weight matrix, in
+// that order.
+type gruCell struct {
+	inputWeights  *G.Node // Shape (features, 3 * size)
+	hiddenWeights *G.Node // Shape (size, 3 * size)
+	bias          *G.Node // Shape (3 * size)
+	size          int
+}
+
+// step computes one timestep of the GRU cell
+func (u *gruCell) step(x *G.Node, state []*G.Node) ([]*G.Node, error) {
+	h := state[0]
+
+	xGates, err := G.Mul(x, u.inputWeights)
+	if err != nil {
+		return nil, fmt.Errorf("step: could not compute input gates: %v",
+			err)
+	}
+	xGates, err = G.BroadcastAdd(xGates, u.bias, nil, []byte{0})
+	if err != nil {
+		return nil, fmt.Errorf("step: could not add bias: %v", err)
+	}
+
+	hGates, err := G.Mul(h, u.hiddenWeights)
+	if err != nil {
+		return nil, fmt.Errorf("step: could not compute hidden gates: %v",
+			err)
+	}
+
+	update := G.Must(G.Sigmoid(G.Must(G.Add(gateSlice(xGates, 0, u.size),
+		gateSlice(hGates, 0, u.size)))))
+	reset := G.Must(G.Sigmoid(G.Must(G.Add(gateSlice(xGates, 1, u.size),
+		gateSlice(hGates, 1, u.size)))))
+
+	// n = tanh(x Wn + r ⊙ (h Un) + bn)
+	newGate := G.Must(G.Tanh(G.Must(G.Add(gateSlice(xGates, 2, u.size),
+		G.Must(G.HadamardProd(reset, gateSlice(hGates, 2, u.size)))))))
+
+	// h' = (1 - z) ⊙ n + z ⊙ h = n + z ⊙ (h - n)
+	newH := G.Must(G.Add(newGate, G.Must(G.HadamardProd(update,
+		G.Must(G.Sub(h, newGate))))))
+
+	return []*G.Node{newH}, nil
+}
+
+// stateSize returns the number of nodes in the hidden state
+func (u *gruCell) stateSize() int {
+	return 1
+}
+
+// hidden returns the number of hidden units in the cell
+func (u *gruCell) hidden() int {
+	return u.size
+}
+
+// learnables returns the learnable nodes of the cell
+func (u *gruCell) learnables() G.Nodes {
+	return G.Nodes{u.inputWeights, u.hiddenWeights, u.bias}
+}
+
+// cloneTo clones the cell to a new computational graph
+func (u *gruCell) cloneTo(g *G.ExprGraph) recurrentCell {
+	return &gruCell{
+		inputWeights:  u.inputWeights.CloneTo(g),
+		hiddenWeights: u.hiddenWeights.CloneTo(g),
+		bias:          u.bias.CloneTo(g),
+		size:          u.size,
+	}
+}
+
+// linearGates computes x * inputWeights + h * hiddenWeights + bias,
+// broadcasting the bias along the batch dimension
+func linearGates(x, h, inputWeights, hiddenWeights,
+	bias *G.Node) (*G.Node, error) {
+	xGates, err := G.Mul(x, inputWeights)
+	if err != nil {
+		return nil, fmt.Errorf("linearGates: could not compute input "+
+			"gates: %v", err)
+	}
+
+	hGates, err := G.Mul(h, hiddenWeights)
+	if err != nil {
+		return nil, fmt.Errorf("linearGates: could not compute hidden "+
+			"gates: %v", err)
+	}
+
+	gates, err := G.Add(xGates, hGates)
+	if err != nil {
+		return nil, fmt.Errorf("linearGates: could not add gates: %v", err)
+	}
+
+	return G.BroadcastAdd(gates, bias, nil, []byte{0})
+}
+
+// gateSlice returns the columns of the ith gate of size columns from
+// a matrix of concatenated gates
+func gateSlice(gates *G.Node, i, size int) *G.Node {
+	rows := gates.Shape()[0]
+	slice := G.Must(G.Slice(gates, nil, G.S(i*size, (i+1)*size)))
+	return G.Must(G.Reshape(slice, tensor.Shape{rows, size}))
+}
+
+// rowSlice returns rows [start, end) of a matrix node. Unlike G.Slice,
+// the returned node is always a matrix, even if only a single row is
+// sliced.
+func rowSlice(x *G.Node, start, end int) *G.Node {
+	cols := x.Shape()[1]
+	slice := G.Must(G.Slice(x, G.S(start, end)))
+	return G.Must(G.Reshape(slice, tensor.Shape{end - start, cols}))
+}
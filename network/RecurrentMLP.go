@@ -0,0 +1,688 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// RecurrentMLP implements a recurrent neural network, consisting of a
+// stack of recurrent (LSTM or GRU) layers followed by a multi-layered
+// perceptron with multiple output nodes. The multi-layered perceptron
+// is applied to the output of the final recurrent layer at each
+// timestep.
+//
+// The forward pass of a RecurrentMLP is unrolled over a fixed number
+// of timesteps, the sequence length. Inputs to the network are
+// time-major: the input matrix has shape (sequenceLength * batch,
+// features), where the row at index t * batch + b holds the features
+// of sample b at timestep t. Outputs are ordered in the same way, and
+// have shape (sequenceLength * batch, outputs).
+//
+// Each forward pass starts from the initial hidden state of the
+// network, which is zero by default. After running the forward pass,
+// CarryState can be called to set the initial hidden state to the
+// hidden state at the end of the sequence, so that the hidden state
+// is carried over to the next forward pass. ResetState resets the
+// initial hidden state to zero. Gradients are never propagated
+// through the initial hidden state, so training a RecurrentMLP on
+// sequences performs truncated backpropagation through time.
+type RecurrentMLP struct {
+	g          *G.ExprGraph
+	cells      []recurrentCell
+	layers     []Layer // Fully connected layers
+	input      *G.Node
+	numOutputs int
+	features   int
+	batchSize  int
+	seqLen     int
+
+	// Hidden state of the network
+	initialState []*G.Node // Hidden state at the start of the sequence
+	finalVals    []G.Value // Hidden state at the end of the sequence
+
+	// Data needed for gobbing
+	cellType    CellType
+	cellSizes   []int
+	hiddenSizes []int
+	biases      []bool
+	activations []*Activation
+
+	learnables G.Nodes
+	model      []G.ValueGrad
+
+	prediction *G.Node
+	predVal    G.Value
+}
+
+// NewRecurrentMLP creates and returns a new RecurrentMLP that has
+// multiple output nodes. The number of output nodes is equal to
+// outputs. The graph parameter g is populated with the network. The
+// forward pass of the network is unrolled over seqLen timesteps, and
+// the network takes inputs of shape (seqLen * batch, features).
+//
+// For index i, cellSizes[i] is the number of hidden units in the ith
+// recurrent layer of the network, each of which is of type cellType.
+// The output of the final recurrent layer is passed to
+// len(hiddenSizes) fully connected layers, where hiddenSizes[i] is the
+// number of nodes in fully connected layer i; biases[i] is true if
+// fully connected layer i will contain a bias unit and false
+// otherwise; and activations[i] is the activation function for fully
+// connected layer i. Similar to NewMultiHeadMLP, a final linear layer
+// with a bias unit is always added so that the network produces
+// outputs predictions. The parameter init determines the weight
+// initialization scheme for both the recurrent and fully connected
+// layers.
+func NewRecurrentMLP(features, batch, seqLen, outputs int, g *G.ExprGraph,
+	cellType CellType, cellSizes, hiddenSizes []int, biases []bool,
+	init G.InitWFn, activations []*Activation) (Recurrent, error) {
+	if seqLen < 1 {
+		return nil, fmt.Errorf("newRecurrentMLP: sequence length must be "+
+			"positive \n\thave(%v)", seqLen)
+	}
+
+	// Set up the input node
+	input := G.NewMatrix(g, tensor.Float64,
+		G.WithShape(seqLen*batch, features), G.WithName("input"),
+		G.WithInit(G.Zeroes()))
+
+	network := &RecurrentMLP{}
+	err := network.build(input, features, seqLen, outputs, g, cellType,
+		cellSizes, hiddenSizes, biases, init, activations)
+	if err != nil {
+		return nil, err
+	}
+
+	return network, nil
+}
+
+// build adds the layers of a RecurrentMLP to the computational graph
+// g, stores them in the receiver, and runs the forward pass on the
+// input node. Building the network in place ensures that the values
+// read from the graph are stored in the receiver.
+//
+// See NewRecurrentMLP for a description of the parameters.
+func (r *RecurrentMLP) build(input *G.Node, features, seqLen,
+	outputs int, g *G.ExprGraph, cellType CellType, cellSizes,
+	hiddenSizes []int, biases []bool, init G.InitWFn,
+	activations []*Activation) error {
+	if len(cellSizes) == 0 {
+		return fmt.Errorf("newRecurrentMLP: at least one recurrent layer " +
+			"is required")
+	}
+
+	// Ensure we have one activation per layer
+	if len(hiddenSizes) != len(activations) {
+		msg := "newRecurrentMLP: invalid number of activations" +
+			"\n\twant(%d)\n\thave(%d)"
+		return fmt.Errorf(msg, len(hiddenSizes), len(activations))
+	}
+
+	// Ensure one bias bool per layer
+	if len(hiddenSizes) != len(biases) {
+		msg := "newRecurrentMLP: invalid number of biases\n\twant(%d)" +
+			"\n\thave(%d)"
+		return fmt.Errorf(msg, len(hiddenSizes), len(biases))
+	}
+
+	if !input.IsMatrix() {
+		return fmt.Errorf("newRecurrentMLP: input must be a matrix")
+	}
+
+	if input.Shape()[0]%seqLen != 0 {
+		return fmt.Errorf("newRecurrentMLP: input rows (%v) not divisible "+
+			"by sequence length (%v)", input.Shape()[0], seqLen)
+	}
+
+	// Create the recurrent layers
+	cells := make([]recurrentCell, len(cellSizes))
+	cellFeatures := features
+	for i, size := range cellSizes {
+		cell, err := newRecurrentCell(g, cellType, cellFeatures, size, init,
+			fmt.Sprintf("R%d", i))
+		if err != nil {
+			return fmt.Errorf("newRecurrentMLP: could not create recurrent "+
+				"layer %v: %v", i, err)
+		}
+		cells[i] = cell
+		cellFeatures = size
+	}
+
+	// Create the fully connected layers, adding a final linear layer
+	// with no activation to ensure output heads are predicted by the
+	// network. Copies are made so that the arguments are not modified.
+	hiddenSizes = append(append([]int{}, hiddenSizes...), outputs)
+	biases = append(append([]bool{}, biases...), true)
+	activations = append(append([]*Activation{}, activations...),
+		Identity())
+	layers := addfcLayers(g, hiddenSizes, biases, activations, init,
+		cellFeatures, "", "", nil)
+
+	// Fill the network and run the forward pass on the input node
+	*r = RecurrentMLP{
+		g:           g,
+		cells:       cells,
+		layers:      layers,
+		input:       input,
+		numOutputs:  outputs,
+		features:    features,
+		batchSize:   input.Shape()[0] / seqLen,
+		seqLen:      seqLen,
+		cellType:    cellType,
+		cellSizes:   cellSizes,
+		hiddenSizes: hiddenSizes,
+		biases:      biases,
+		activations: activations,
+	}
+	r.initialState = r.newInitialState()
+	_, err := r.fwd([]*G.Node{input})
+	if err != nil {
+		return fmt.Errorf("newRecurrentMLP: could not compute forward "+
+			"pass: %v", err)
+	}
+
+	return nil
+}
+
+// newInitialState adds the nodes holding the initial hidden state of
+// each recurrent layer to the network's computational graph and
+// returns them. The initial hidden state is zero.
+func (r *RecurrentMLP) newInitialState() []*G.Node {
+	state := make([]*G.Node, 0, 2*len(r.cells))
+	for i, cell := range r.cells {
+		for j := 0; j < cell.stateSize(); j++ {
+			node := G.NewMatrix(
+				r.g,
+				tensor.Float64,
+				G.WithShape(r.batchSize, cell.hidden()),
+				G.WithName(fmt.Sprintf("R%dState%d", i, j)),
+				G.WithInit(G.Zeroes()),
+			)
+			state = append(state, node)
+		}
+	}
+	return state
+}
+
+// SequenceLength returns the number of timesteps the forward pass is
+// unrolled over
+func (r *RecurrentMLP) SequenceLength() int {
+	return r.seqLen
+}
+
+// CellType returns the type of the recurrent layers of the network
+func (r *RecurrentMLP) CellType() CellType {
+	return r.cellType
+}
+
+// ResetState sets the initial hidden state of the network to zero
+func (r *RecurrentMLP) ResetState() error {
+	for i, node := range r.initialState {
+		zeroes := tensor.New(
+			tensor.WithShape(node.Shape()...),
+			tensor.WithBacking(make([]float64, node.Shape().TotalSize())),
+		)
+		if err := G.Let(node, zeroes); err != nil {
+			return fmt.Errorf("resetState: could not reset state %v: %v",
+				i, err)
+		}
+	}
+	return nil
+}
+
+// CarryState sets the initial hidden state of the network to the
+// hidden state at the end of the sequence computed by the last
+// forward pass
+func (r *RecurrentMLP) CarryState() error {
+	for i, node := range r.initialState {
+		if r.finalVals[i] == nil {
+			return fmt.Errorf("carryState: forward pass has not been run")
+		}
+
+		// Clone the final state, since the VM may reuse its memory
+		final := r.finalVals[i].(tensor.Tensor).Clone().(tensor.Tensor)
+		if err := G.Let(node, final); err != nil {
+			return fmt.Errorf("carryState: could not carry state %v: %v",
+				i, err)
+		}
+	}
+	return nil
+}
+
+// Graph returns the computational graph of the RecurrentMLP.
+func (r *RecurrentMLP) Graph() *G.ExprGraph {
+	return r.g
+}
+
+// Clone clones a RecurrentMLP
+func (r *RecurrentMLP) Clone() (NeuralNet, error) {
+	return r.CloneWithBatch(r.batchSize)
+}
+
+// CloneWithBatch clones a RecurrentMLP with a new input batch size.
+// The sequence length of the clone is the same as that of the
+// receiver.
+func (r *RecurrentMLP) CloneWithBatch(batchSize int) (NeuralNet, error) {
+	return r.CloneWithSequence(batchSize, r.seqLen)
+}
+
+// CloneWithSequence clones a RecurrentMLP with a new input batch size
+// and sequence length.
+func (r *RecurrentMLP) CloneWithSequence(batchSize,
+	seqLen int) (Recurrent, error) {
+	if seqLen < 1 {
+		return nil, fmt.Errorf("cloneWithSequence: sequence length must "+
+			"be positive \n\thave(%v)", seqLen)
+	}
+	graph := G.NewGraph()
+
+	// Create the input node
+	input := G.NewMatrix(
+		graph,
+		tensor.Float64,
+		G.WithShape(seqLen*batchSize, r.features),
+		G.WithName("input"),
+		G.WithInit(G.Zeroes()),
+	)
+
+	return r.cloneTo(input, seqLen, graph)
+}
+
+// cloneWithInputTo clones a RecurrentMLP to a specific computational
+// graph with a specified input node. If multiple input nodes are
+// given, then they are first concatenated along the specified axis.
+// The sequence length of the clone is the same as that of the
+// receiver.
+func (r *RecurrentMLP) cloneWithInputTo(axis int, inputs []*G.Node,
+	graph *G.ExprGraph) (NeuralNet, error) {
+	// Ensure inputs share the same graph
+	for _, input := range inputs {
+		if input.Graph() != graph {
+			return nil, fmt.Errorf("clonewithinputto: not all inputs " +
+				"have the same graph")
+		}
+	}
+
+	// Concatenate inputs if necessary
+	var input *G.Node
+	if len(inputs) > 1 {
+		input = G.Must(G.Concat(axis, inputs...))
+	} else {
+		input = inputs[0]
+	}
+
+	return r.cloneTo(input, r.seqLen, graph)
+}
+
+// cloneTo clones a RecurrentMLP to a specific computational graph
+// with a specified input node and sequence length
+func (r *RecurrentMLP) cloneTo(input *G.Node, seqLen int,
+	graph *G.ExprGraph) (Recurrent, error) {
+	if !input.IsMatrix() {
+		return nil, fmt.Errorf("cloneTo: input must be a matrix node")
+	}
+
+	if input.Shape()[0]%seqLen != 0 {
+		return nil, fmt.Errorf("cloneTo: input rows (%v) not divisible "+
+			"by sequence length (%v)", input.Shape()[0], seqLen)
+	}
+
+	// Copy layers
+	cells := make([]recurrentCell, len(r.cells))
+	for i := range r.cells {
+		cells[i] = r.cells[i].cloneTo(graph)
+	}
+	l := make([]Layer, len(r.layers))
+	for i := range r.layers {
+		l[i] = r.layers[i].CloneTo(graph)
+	}
+
+	// Create the network and run the forward pass on the input node
+	network := &RecurrentMLP{
+		g:           graph,
+		cells:       cells,
+		layers:      l,
+		input:       input,
+		numOutputs:  r.numOutputs,
+		features:    r.features,
+		batchSize:   input.Shape()[0] / seqLen,
+		seqLen:      seqLen,
+		cellType:    r.cellType,
+		cellSizes:   r.cellSizes,
+		hiddenSizes: r.hiddenSizes,
+		biases:      r.biases,
+		activations: r.activations,
+	}
+	network.initialState = network.newInitialState()
+	_, err := network.fwd([]*G.Node{input})
+	if err != nil {
+		return nil, fmt.Errorf("cloneTo: could not clone: %v", err)
+	}
+
+	return network, nil
+}
+
+// BatchSize returns the batch size of inputs to the network at each
+// timestep
+func (r *RecurrentMLP) BatchSize() int {
+	return r.batchSize
+}
+
+// architecture returns the architecture of the RecurrentMLP
+func (r *RecurrentMLP) architecture() architecture {
+	// Exclude the final linear layer, which is added when the
+	// RecurrentMLP is built
+	layers := len(r.hiddenSizes) - 1
+	return &recurrentMLPArchitecture{
+		Features:    r.features,
+		SeqLen:      r.seqLen,
+		Outputs:     r.numOutputs,
+		CellType:    r.cellType,
+		CellSizes:   r.cellSizes,
+		HiddenSizes: r.hiddenSizes[:layers],
+		Biases:      r.biases[:layers],
+		Activations: r.activations[:layers],
+	}
+}
+
+// Features returns the number of features in a single input at a
+// single timestep
+func (r *RecurrentMLP) Features() []int {
+	return []int{r.features}
+}
+
+// Outputs returns the number of outputs from the network
+func (r *RecurrentMLP) Outputs() []int {
+	return []int{r.numOutputs}
+}
+
+// OutputLayers returns the number of layers that will produce Outputs()
+// values as predictions.
+func (r *RecurrentMLP) OutputLayers() int {
+	return len(r.Prediction())
+}
+
+// SetInput sets the value of the input node before running the forward
+// pass. The input should be time-major, with the features of sample b
+// at timestep t stored starting at index (t * batch + b) * features.
+func (r *RecurrentMLP) SetInput(input []float64) error {
+	want := r.features * r.batchSize * r.seqLen
+	if len(input) != want {
+		return fmt.Errorf("setInput: invalid number of inputs\n\twant(%v)"+
+			"\n\thave(%v)", want, len(input))
+	}
+	inputTensor := tensor.New(
+		tensor.WithBacking(input),
+		tensor.WithShape(r.input.Shape()...),
+	)
+	return G.Let(r.input, inputTensor)
+}
+
+// Learnables returns the learnable nodes in a RecurrentMLP
+func (r *RecurrentMLP) Learnables() G.Nodes {
+	// Lazy instantiation
+	if r.learnables == nil {
+		r.learnables = r.computeLearnables()
+	}
+	return r.learnables
+}
+
+// computeLearnables computes all the learnables for the network. The
+// learnables of the recurrent layers are ordered before those of the
+// fully connected layers.
+func (r *RecurrentMLP) computeLearnables() G.Nodes {
+	learnables := make([]*G.Node, 0, 3*len(r.cells)+2*len(r.layers))
+
+	for i := range r.cells {
+		learnables = append(learnables, r.cells[i].learnables()...)
+	}
+	for i := range r.layers {
+		if weights := r.layers[i].Weights(); weights != nil {
+			learnables = append(learnables, weights)
+		}
+		if bias := r.layers[i].Bias(); bias != nil {
+			learnables = append(learnables, bias)
+		}
+	}
+	return G.Nodes(learnables)
+}
+
+// Model returns the learnables nodes with their gradients.
+func (r *RecurrentMLP) Model() []G.ValueGrad {
+	// Lazy instantiation
+	if r.model == nil {
+		r.model = G.NodesToValueGrads(r.Learnables())
+	}
+	return r.model
+}
+
+// fwd performs the forward pass of the RecurrentMLP on the input node
+func (r *RecurrentMLP) fwd(inputs []*G.Node) (*G.Node, error) {
+	if len(inputs) != 1 {
+		return nil, fmt.Errorf("fwd: RecurrentMLP only supports a single "+
+			"input \n\twant(1) \n\thave(%v)", len(inputs))
+	}
+	input := inputs[0]
+
+	// Unroll the recurrent layers over the sequence
+	state := append([]*G.Node{}, r.initialState...)
+	outputs := make([]*G.Node, r.seqLen)
+	for t := 0; t < r.seqLen; t++ {
+		x := rowSlice(input, t*r.batchSize, (t+1)*r.batchSize)
+
+		offset := 0
+		for i, cell := range r.cells {
+			n := cell.stateSize()
+			newState, err := cell.step(x, state[offset:offset+n])
+			if err != nil {
+				msg := "fwd: could not compute recurrent layer %v at " +
+					"timestep %v: %v"
+				return nil, fmt.Errorf(msg, i, t, err)
+			}
+			copy(state[offset:offset+n], newState)
+
+			x = newState[0]
+			offset += n
+		}
+		outputs[t] = x
+	}
+
+	// Store the hidden state at the end of the sequence
+	r.finalVals = make([]G.Value, len(state))
+	for i := range state {
+		G.Read(state[i], &r.finalVals[i])
+	}
+
+	// Apply the fully connected layers to each timestep
+	var pred *G.Node
+	if len(outputs) > 1 {
+		pred = G.Must(G.Concat(0, outputs...))
+	} else {
+		pred = outputs[0]
+	}
+
+	var err error
+	for i, l := range r.layers {
+		if pred, err = l.fwd(pred); err != nil {
+			msg := "fwd: could not compute forward pass of layer %v: %v"
+			return nil, fmt.Errorf(msg, i, err)
+		}
+	}
+
+	r.prediction = pred
+
+	G.Read(r.prediction, &r.predVal)
+
+	return pred, nil
+}
+
+// Output returns the output of the RecurrentMLP.
+func (r *RecurrentMLP) Output() []G.Value {
+	return []G.Value{r.predVal}
+}
+
+// Prediction returns the node of the computational graph the stores
+// the output of the RecurrentMLP
+func (r *RecurrentMLP) Prediction() []*G.Node {
+	return []*G.Node{r.prediction}
+}
+
+// GobEncode implements the gob.GobEncoder interface
+func (r *RecurrentMLP) GobEncode() ([]byte, error) {
+	gob.Register(RecurrentMLP{})
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	err := enc.Encode(r.numOutputs)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode number of outputs")
+	}
+
+	err = enc.Encode(r.features)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode features")
+	}
+
+	err = enc.Encode(r.BatchSize())
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode batch size")
+	}
+
+	err = enc.Encode(r.seqLen)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode sequence length")
+	}
+
+	err = enc.Encode(r.cellType)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode cell type")
+	}
+
+	err = enc.Encode(r.cellSizes)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode cell sizes")
+	}
+
+	err = enc.Encode(r.hiddenSizes)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode hidden sizes")
+	}
+
+	err = enc.Encode(r.biases)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode biases")
+	}
+
+	err = enc.Encode(r.activations)
+	if err != nil {
+		return nil, fmt.Errorf("gobencode: could not encode activations")
+	}
+
+	// Store the learnable weights
+	for i, learnable := range r.Learnables() {
+		err := enc.Encode(learnable.Value().(*tensor.Dense))
+		if err != nil {
+			msg := "gobencode: could not encode learnable %v: %v"
+			return nil, fmt.Errorf(msg, i, err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (r *RecurrentMLP) GobDecode(in []byte) error {
+	gob.Register(RecurrentMLP{})
+	buf := bytes.NewReader(in)
+	dec := gob.NewDecoder(buf)
+
+	var numOutputs int
+	err := dec.Decode(&numOutputs)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode number of outputs")
+	}
+
+	var features int
+	err = dec.Decode(&features)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode features")
+	}
+
+	var batchSize int
+	err = dec.Decode(&batchSize)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode batch size")
+	}
+
+	var seqLen int
+	err = dec.Decode(&seqLen)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode sequence length")
+	}
+
+	var cellType CellType
+	err = dec.Decode(&cellType)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode cell type")
+	}
+
+	var cellSizes []int
+	err = dec.Decode(&cellSizes)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode cell sizes")
+	}
+
+	var hiddenSizes []int
+	err = dec.Decode(&hiddenSizes)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode hidden sizes")
+	}
+	hiddenSizes = hiddenSizes[:len(hiddenSizes)-1]
+
+	var biases []bool
+	err = dec.Decode(&biases)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode biases")
+	}
+	biases = biases[:len(biases)-1]
+
+	var activations []*Activation
+	err = dec.Decode(&activations)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not decode activations")
+	}
+	activations = activations[:len(activations)-1]
+
+	// Build a new RecurrentMLP in place
+	g := G.NewGraph()
+	input := G.NewMatrix(g, tensor.Float64,
+		G.WithShape(seqLen*batchSize, features), G.WithName("input"),
+		G.WithInit(G.Zeroes()))
+	err = r.build(input, features, seqLen, numOutputs, g, cellType,
+		cellSizes, hiddenSizes, biases, G.Zeroes(), activations)
+	if err != nil {
+		return fmt.Errorf("gobdecode: could not construct new "+
+			"RecurrentMLP: %v", err)
+	}
+
+	// Fill the new RecurrentMLP's learnables with the decoded weights
+	for i, learnable := range r.Learnables() {
+		weights := &tensor.Dense{}
+		err = dec.Decode(weights)
+		if err != nil {
+			return fmt.Errorf("gobdecode: could not decode learnable %v: %v",
+				i, err)
+		}
+
+		err = G.Let(learnable, weights)
+		if err != nil {
+			return fmt.Errorf("gobdecode: could not set learnable %v: %v",
+				i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// newTestRecurrentMLP returns a RecurrentMLP with two recurrent layers
+// of type cellType taking 3 features and predicting 4 outputs
+func newTestRecurrentMLP(g *G.ExprGraph, cellType CellType, batch,
+	seqLen int) (*RecurrentMLP, error) {
+	relu := ReLU()
+	net, err := NewRecurrentMLP(3, batch, seqLen, 4, g, cellType,
+		[]int{5, 2}, []int{6}, []bool{true}, G.GlorotU(1),
+		[]*Activation{relu})
+	if err != nil {
+		return nil, err
+	}
+	return net.(*RecurrentMLP), nil
+}
+
+// run runs the forward pass of net on input and returns a copy of the
+// output
+func run(t *testing.T, net NeuralNet, input []float64) []float64 {
+	t.Helper()
+
+	if err := net.SetInput(input); err != nil {
+		t.Fatal(err)
+	}
+	vm := G.NewTapeMachine(net.Graph())
+	defer vm.Close()
+	if err := vm.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+	return append([]float64{}, net.Output()[0].Data().([]float64)...)
+}
+
+// equal returns whether a and b are equal up to numerical error
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-12 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestRecurrentMLPShape tests the shapes of the outputs of
+// RecurrentMLPs unrolled over a number of timesteps
+func TestRecurrentMLPShape(t *testing.T) {
+	const seqLen = 3
+
+	for _, cellType := range []CellType{LSTM, GRU} {
+		net, err := newTestRecurrentMLP(G.NewGraph(), cellType, batch, seqLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if length := net.SequenceLength(); length != seqLen {
+			t.Errorf("%v: sequence length: have(%v) want(%v)", cellType,
+				length, seqLen)
+		}
+		if err := net.SetInput(make([]float64, 3*batch)); err == nil {
+			t.Errorf("%v: expected an error for an input of a single "+
+				"timestep", cellType)
+		}
+
+		want := tensor.Shape{seqLen * batch, 4}
+		if shape := net.Prediction()[0].Shape(); !shape.Eq(want) {
+			t.Errorf("%v: prediction shape: have(%v) want(%v)", cellType,
+				shape, want)
+		}
+		if out := run(t, net, make([]float64, seqLen*batch*3)); len(out) !=
+			want.TotalSize() {
+			t.Errorf("%v: output size: have(%v) want(%v)", cellType, len(out),
+				want.TotalSize())
+		}
+	}
+}
+
+// TestRecurrentMLPState tests that carrying the hidden state between
+// forward passes of single timesteps is equivalent to unrolling the
+// network over the sequence, and that resetting the hidden state
+// restarts the sequence
+func TestRecurrentMLPState(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, cellType := range []CellType{LSTM, GRU} {
+		step, err := newTestRecurrentMLP(G.NewGraph(), cellType, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := step.CarryState(); err == nil {
+			t.Errorf("%v: expected an error when carrying the state "+
+				"before a forward pass", cellType)
+		}
+
+		sequence, err := step.CloneWithSequence(1, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input := make([]float64, 3*3)
+		for i := range input {
+			input[i] = rng.NormFloat64()
+		}
+		want := run(t, sequence, input)
+
+		// Carry the state between timesteps
+		var first []float64
+		for i := 0; i < 3; i++ {
+			have := run(t, step, input[i*3:(i+1)*3])
+			if !equal(have, want[i*4:(i+1)*4]) {
+				t.Errorf("%v: timestep %v: have(%v) want(%v)", cellType, i,
+					have, want[i*4:(i+1)*4])
+			}
+			if i == 0 {
+				first = have
+			}
+			if err := step.CarryState(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Resetting the state restarts the sequence
+		if err := step.ResetState(); err != nil {
+			t.Fatal(err)
+		}
+		if have := run(t, step, input[:3]); !equal(have, first) {
+			t.Errorf("%v: after reset: have(%v) want(%v)", cellType, have,
+				first)
+		}
+	}
+}
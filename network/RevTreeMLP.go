@@ -3,6 +3,7 @@ package network
 import (
 	"fmt"
 
+	"golang.org/x/exp/rand"
 	G "gorgonia.org/gorgonia"
 	"gorgonia.org/tensor"
 	"github.com/samuelfneumann/golearn/utils/intutils"
@@ -43,6 +44,8 @@ type RevTreeMLP struct {
 	leafHiddenSizes []int
 	leafBiases      []bool
 	leafActivations []*Activation
+	rootOptions     [][]LayerOptions
+	leafOptions     []LayerOptions
 
 	predVal    []G.Value // Values predicted by each leaf node
 	prediction []*G.Node // Nodes holding the predictions
@@ -140,6 +143,22 @@ func NewRevTreeMLP(features []int, batch, outputs int, g *G.ExprGraph,
 	rootHiddenSizes [][]int, rootBiases [][]bool,
 	rootActivations [][]*Activation, leafHiddenSizes []int, leafBiases []bool,
 	leafActivations []*Activation, init G.InitWFn) (NeuralNet, error) {
+	return NewRevTreeMLPWithOptions(features, batch, outputs, g,
+		rootHiddenSizes, rootBiases, rootActivations, nil, leafHiddenSizes,
+		leafBiases, leafActivations, nil, init)
+}
+
+// NewRevTreeMLPWithOptions is like NewRevTreeMLP, but rootOptions[i][j]
+// determines the normalization, dropout, and residual connection of
+// layer j of root network i, and leafOptions[i] those of layer i of
+// the leaf network. Either rootOptions or leafOptions may be nil, in
+// which case the corresponding networks use plain fully connected
+// layers.
+func NewRevTreeMLPWithOptions(features []int, batch, outputs int,
+	g *G.ExprGraph, rootHiddenSizes [][]int, rootBiases [][]bool,
+	rootActivations [][]*Activation, rootOptions [][]LayerOptions,
+	leafHiddenSizes []int, leafBiases []bool, leafActivations []*Activation,
+	leafOptions []LayerOptions, init G.InitWFn) (NeuralNet, error) {
 	// Ensure the input is valid
 	err := validateRevTreeMLP(features, rootHiddenSizes, rootBiases,
 		rootActivations, leafHiddenSizes, leafBiases, leafActivations)
@@ -147,6 +166,12 @@ func NewRevTreeMLP(features []int, batch, outputs int, g *G.ExprGraph,
 		return nil, fmt.Errorf("newtreemlp: %v", err)
 	}
 
+	if rootOptions != nil && len(rootOptions) != len(rootHiddenSizes) {
+		msg := "newrevtreemlp: invalid number of root network options " +
+			"\n\twant(%v) \n\thave(%v)"
+		return nil, fmt.Errorf(msg, len(rootHiddenSizes), len(rootOptions))
+	}
+
 	// Construct root networks
 	rootNetworks := make([]NeuralNet, len(rootHiddenSizes))
 	rootPredictions := make([]*G.Node, 0, len(rootHiddenSizes))
@@ -157,12 +182,18 @@ func NewRevTreeMLP(features []int, batch, outputs int, g *G.ExprGraph,
 			G.WithName(fmt.Sprintf("Root%dInput", i)), G.WithInit(G.Zeroes()))
 
 		// Create individual root networks and run each's forward pass
-		rootOutputs := rootHiddenSizes[i][len(rootHiddenSizes)-1]
+		rootOutputs := rootHiddenSizes[i][len(rootHiddenSizes[i])-1]
 		prefix := fmt.Sprintf("Root%d", i)
 		rootInput := []*G.Node{inputs[i]}
+
+		var options []LayerOptions
+		if rootOptions != nil {
+			options = rootOptions[i]
+		}
+
 		rootNetwork, err := newMultiHeadMLPFromInput(rootInput, rootOutputs, g,
 			rootHiddenSizes[i], rootBiases[i], init, rootActivations[i],
-			prefix, "", false)
+			options, prefix, "", false)
 		if err != nil {
 			return nil, fmt.Errorf("newrevtreemlp: could not construct root "+
 				"network %v: %v", i, err)
@@ -177,7 +208,7 @@ func NewRevTreeMLP(features []int, batch, outputs int, g *G.ExprGraph,
 	// Create leaf networks and run its forward pass
 	leafNetwork, err := newMultiHeadMLPFromInput(rootOutput, outputs, g,
 		leafHiddenSizes, leafBiases, init, leafActivations,
-		"Leaf", "", true)
+		leafOptions, "Leaf", "", true)
 	if err != nil {
 		return nil, fmt.Errorf("newtreemlp: could not construct leaf "+
 			"network: %v", err)
@@ -188,7 +219,7 @@ func NewRevTreeMLP(features []int, batch, outputs int, g *G.ExprGraph,
 		rootNetworks:    rootNetworks,
 		leafNetwork:     leafNetwork,
 		inputs:          inputs,
-		numOutputs:      outputs * len(leafHiddenSizes),
+		numOutputs:      outputs,
 		numInputs:       features,
 		batchSize:       batch,
 		rootHiddenSizes: rootHiddenSizes,
@@ -197,6 +228,8 @@ func NewRevTreeMLP(features []int, batch, outputs int, g *G.ExprGraph,
 		leafHiddenSizes: leafHiddenSizes,
 		leafBiases:      leafBiases,
 		leafActivations: leafActivations,
+		rootOptions:     rootOptions,
+		leafOptions:     leafOptions,
 		learnables:      nil,
 		model:           nil,
 	}
@@ -237,28 +270,81 @@ func NewRevTreeMLP(features []int, batch, outputs int, g *G.ExprGraph,
 // forming the first sample in the batch, the next 7 features forming
 // the second sample in the batch, etc.
 func (t *RevTreeMLP) SetInput(input []float64) error {
-	if len(input) != intutils.Prod(t.Features()...)*t.batchSize {
+	if len(input) != intutils.Sum(t.Features()...)*t.batchSize {
 		msg := fmt.Sprintf("invalid number of inputs\n\twant(%v)"+
-			"\n\thave(%v)", intutils.Prod(t.Features()...)*t.batchSize,
+			"\n\thave(%v)", intutils.Sum(t.Features()...)*t.batchSize,
 			len(input))
 		panic(msg)
 	}
 
+	if err := t.refresh(); err != nil {
+		return fmt.Errorf("setInput: %v", err)
+	}
+
 	start := 0
 	stop := 0
 	for i, rootInput := range t.inputs {
 		start = stop
-		stop = t.numInputs[i] * t.BatchSize()
+		stop += t.numInputs[i] * t.BatchSize()
 		inputTensor := tensor.New(
 			tensor.WithBacking(input[start:stop]),
 			tensor.WithShape(rootInput.Shape()...),
 		)
-		return G.Let(rootInput, inputTensor)
+		if err := G.Let(rootInput, inputTensor); err != nil {
+			return fmt.Errorf("setInput: could not set input to root "+
+				"network %v: %v", i, err)
+		}
 	}
 
 	return nil
 }
 
+// subNetworks returns each root network followed by the leaf network
+func (t *RevTreeMLP) subNetworks() []NeuralNet {
+	return append(append([]NeuralNet{}, t.rootNetworks...), t.leafNetwork)
+}
+
+// setEval sets the RevTreeMLP to evaluation mode if eval is true and
+// to training mode otherwise
+func (t *RevTreeMLP) setEval(eval bool) {
+	for _, net := range t.subNetworks() {
+		SetEval(net, eval)
+	}
+}
+
+// setSeed seeds each sub-network of the RevTreeMLP with a different seed
+// sampled from a random number generator seeded with seed
+func (t *RevTreeMLP) setSeed(seed uint64) {
+	rng := rand.New(rand.NewSource(seed))
+	for _, net := range t.subNetworks() {
+		SetSeed(net, rng.Uint64())
+	}
+}
+
+// refresh refreshes the layers of each sub-network before a forward
+// pass
+func (t *RevTreeMLP) refresh() error {
+	for _, net := range t.subNetworks() {
+		if r, ok := net.(refresher); ok {
+			if err := r.refresh(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// state returns the non-learnable state nodes of the RevTreeMLP
+func (t *RevTreeMLP) state() G.Nodes {
+	var state G.Nodes
+	for _, net := range t.subNetworks() {
+		if s, ok := net.(stater); ok {
+			state = append(state, s.state()...)
+		}
+	}
+	return state
+}
+
 // Outputs returns the number of outputs per leaf network
 func (t *RevTreeMLP) Outputs() []int {
 	return []int{t.numOutputs}
@@ -295,7 +381,13 @@ func (t *RevTreeMLP) CloneWithBatch(batchSize int) (NeuralNet, error) {
 
 	for i, input := range t.inputs {
 		if input.IsMatrix() {
-			inputs[i] = input.CloneTo(graph)
+			inputs[i] = G.NewMatrix(
+				graph,
+				tensor.Float64,
+				G.WithShape(batchSize, input.Shape()[1]),
+				G.WithName(input.Name()),
+				G.WithInit(G.Zeroes()),
+			)
 		} else {
 			return nil, fmt.Errorf("clonewithbatch: invalid input type")
 		}
@@ -348,8 +440,9 @@ func (t *RevTreeMLP) cloneWithInputTo(axis int, inputs []*G.Node,
 	}
 	batchSize := inputs[0].Shape()[0]
 
-	// Clone the leaf network
-	leafClone, err := t.leafNetwork.cloneWithInputTo(axis, rootOutputs, graph)
+	// Clone the leaf network, concatenating the root outputs along
+	// the feature axis as is done when constructing the RevTreeMLP
+	leafClone, err := t.leafNetwork.cloneWithInputTo(1, rootOutputs, graph)
 	if err != nil {
 		msg := "cloneWithInputTo: could not clone leaf network: %v"
 		return nil, fmt.Errorf(msg, err)
@@ -371,9 +464,16 @@ func (t *RevTreeMLP) cloneWithInputTo(axis int, inputs []*G.Node,
 		leafHiddenSizes: t.leafHiddenSizes,
 		leafBiases:      t.leafBiases,
 		leafActivations: t.leafActivations,
+		rootOptions:     t.rootOptions,
+		leafOptions:     t.leafOptions,
 		learnables:      nil,
 		model:           nil,
 	}
+	_, err = net.fwd(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("cloneWithInputTo: could not compute "+
+			"forward pass: %v", err)
+	}
 
 	return net, nil
 }
@@ -383,6 +483,22 @@ func (t *RevTreeMLP) BatchSize() int {
 	return t.rootNetworks[0].BatchSize()
 }
 
+// architecture returns the architecture of the RevTreeMLP
+func (t *RevTreeMLP) architecture() architecture {
+	return &revTreeMLPArchitecture{
+		Features:        t.numInputs,
+		Outputs:         t.numOutputs,
+		RootHiddenSizes: t.rootHiddenSizes,
+		RootBiases:      t.rootBiases,
+		RootActivations: t.rootActivations,
+		RootOptions:     t.rootOptions,
+		LeafHiddenSizes: t.leafHiddenSizes,
+		LeafBiases:      t.leafBiases,
+		LeafActivations: t.leafActivations,
+		LeafOptions:     t.leafOptions,
+	}
+}
+
 // fwd computes the remaining steps of the forward pass of the RevTreeMLP
 // that its root and leaf networks did not compute.
 func (t *RevTreeMLP) fwd(inputs []*G.Node) (*G.Node, error) {
@@ -394,6 +510,12 @@ func (t *RevTreeMLP) fwd(inputs []*G.Node) (*G.Node, error) {
 			"network \n\twant(%v) \n\thave(%v)", len(t.rootNetworks), num)
 	}
 
+	t.prediction = t.leafNetwork.Prediction()
+	t.predVal = make([]G.Value, len(t.prediction))
+	for i, pred := range t.prediction {
+		G.Read(pred, &t.predVal[i])
+	}
+
 	return nil, nil
 }
 
@@ -12,3 +12,15 @@ func NewSingleHeadMLP(features, batch int, g *G.ExprGraph, hiddenSizes []int,
 	return NewMultiHeadMLP(features, batch, 1, g, hiddenSizes,
 		biases, init, activations)
 }
+
+// NewSingleHeadMLPWithOptions returns an MLP with a single output node
+// and optional normalization, dropout, and residual connections for
+// each hidden layer.
+//
+// See NewMultiHeadMLPWithOptions for more details.
+func NewSingleHeadMLPWithOptions(features, batch int, g *G.ExprGraph,
+	hiddenSizes []int, biases []bool, init G.InitWFn,
+	activations []*Activation, options []LayerOptions) (NeuralNet, error) {
+	return NewMultiHeadMLPWithOptions(features, batch, 1, g, hiddenSizes,
+		biases, init, activations, options)
+}
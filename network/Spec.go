@@ -0,0 +1,325 @@
+package network
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/initwfn"
+	"github.com/samuelfneumann/golearn/utils/intutils"
+	G "gorgonia.org/gorgonia"
+)
+
+// LayerSpec describes a single fully connected layer of a Spec. If
+// Activation is nil, the layer uses the identity activation. Optional
+// normalization, dropout, and residual connections are described by
+// the embedded LayerOptions, whose fields are set at the same level as
+// the other fields of the LayerSpec in JSON:
+//
+//	{"Units": 64, "Bias": true, "Activation": "relu", "Norm": "LayerNorm"}
+type LayerSpec struct {
+	Units      int
+	Bias       bool
+	Activation *Activation
+	LayerOptions
+}
+
+// BranchSpec describes a named branch of a Spec, which is a sequence
+// of fully connected layers. Features determines the number of input
+// features to a root branch and is only used by Specs with multiple
+// roots.
+type BranchSpec struct {
+	Name     string
+	Features int
+	Layers   []LayerSpec
+}
+
+// RecurrentSpec describes the recurrent layers of a Spec
+type RecurrentSpec struct {
+	CellType  CellType
+	CellSizes []int
+}
+
+// Spec is a declarative, JSON serializable description of a NeuralNet
+// architecture. A Spec is a graph of named branches of fully connected
+// layers. Inputs flow through the root branches, whose outputs are
+// concatenated and sent through each of the head branches. Each head
+// outputs a separate prediction, and a final linear layer is always
+// added to each head so that it produces the number of outputs
+// requested when building the network.
+//
+// The kind of NeuralNet built depends on the graph described:
+//
+//	Conv layers                 → ConvMLP
+//	Recurrent layers            → RecurrentMLP
+//	Multiple roots              → RevTreeMLP
+//	Multiple heads              → TreeMLP
+//	At most one root and head   → MultiHeadMLP
+//
+// Convolutional and recurrent layers are applied to the input before
+// the root branch. For networks with at most one root and one head,
+// the layers of the head directly follow those of the root.
+//
+// The Init field determines the weight initializer of all layers. The
+// Output field determines the options of the final linear layer. Only
+// NoisyNet output layers are supported, and only by networks which
+// build a MultiHeadMLP.
+type Spec struct {
+	Init *initwfn.InitWFn
+
+	Conv      []ConvLayerConfig // Convolutional and pooling layers
+	Recurrent *RecurrentSpec    // Recurrent layers
+
+	Roots []BranchSpec
+	Heads []BranchSpec
+
+	Output LayerOptions // Options of the final linear layer
+}
+
+// Validate returns an error if the Spec does not describe a legal
+// network architecture
+func (s *Spec) Validate() error {
+	if s.Init == nil {
+		return fmt.Errorf("validate: a weight initializer must be specified")
+	}
+
+	// Ensure names and layers are legal
+	names := make(map[string]bool)
+	for _, branch := range append(append([]BranchSpec{}, s.Roots...),
+		s.Heads...) {
+		if branch.Name != "" {
+			if names[branch.Name] {
+				return fmt.Errorf("validate: duplicate branch name %v",
+					branch.Name)
+			}
+			names[branch.Name] = true
+		}
+
+		for i, layer := range branch.Layers {
+			if layer.Units <= 0 {
+				return fmt.Errorf("validate: layer %v of branch %q must "+
+					"have a positive number of units \n\thave(%v)", i,
+					branch.Name, layer.Units)
+			}
+			if err := layer.LayerOptions.Validate(); err != nil {
+				return fmt.Errorf("validate: layer %v of branch %q: %v", i,
+					branch.Name, err)
+			}
+		}
+	}
+
+	// Ensure the graph describes a supported network
+	if len(s.Roots) > 1 && len(s.Heads) > 1 {
+		return fmt.Errorf("validate: cannot use multiple roots and multiple " +
+			"heads")
+	}
+
+	if len(s.Heads) > 1 && (len(s.Roots) != 1 ||
+		len(s.Roots[0].Layers) == 0) {
+		return fmt.Errorf("validate: multiple heads require a single root " +
+			"with at least one layer")
+	}
+
+	if len(s.Roots) > 1 {
+		for i, root := range s.Roots {
+			if len(root.Layers) == 0 {
+				return fmt.Errorf("validate: root %v must have at least one "+
+					"layer when using multiple roots", i)
+			}
+		}
+	}
+
+	if len(s.Conv) > 0 && s.Recurrent != nil {
+		return fmt.Errorf("validate: cannot use both convolutional and " +
+			"recurrent layers")
+	}
+
+	if len(s.Conv) > 0 || s.Recurrent != nil {
+		if len(s.Roots) > 1 || len(s.Heads) > 1 {
+			return fmt.Errorf("validate: convolutional and recurrent " +
+				"networks support at most one root and one head")
+		}
+
+		_, _, _, options := s.trunk()
+		if options != nil {
+			return fmt.Errorf("validate: layer options are not supported " +
+				"by convolutional and recurrent networks")
+		}
+	}
+
+	if !s.Output.IsZero() {
+		if s.Output != (LayerOptions{Noisy: true}) {
+			return fmt.Errorf("validate: only noisy output layers are " +
+				"supported")
+		}
+		if len(s.Conv) > 0 || s.Recurrent != nil || len(s.Roots) > 1 ||
+			len(s.Heads) > 1 {
+			return fmt.Errorf("validate: output layer options are only " +
+				"supported by networks with at most one root and one head")
+		}
+	}
+
+	if s.Recurrent != nil && len(s.Recurrent.CellSizes) == 0 {
+		return fmt.Errorf("validate: at least one recurrent layer is " +
+			"required")
+	}
+
+	return nil
+}
+
+// Build builds the NeuralNet described by the Spec on graph g. The
+// network takes inputs with batch size batch and each head of the
+// network produces outputs predictions.
+//
+// For convolutional networks, features should be the shape of input
+// images, (channels, height, width). For networks with multiple roots,
+// features[i] should be the number of input features to root i. If
+// instead a single feature size is given to a network with multiple
+// roots, the input is split between roots as described by the
+// Features field of each root. For all other networks, the number of
+// input features is the product of features.
+//
+// Recurrent networks are built with a sequence length of 1. See
+// Recurrent.CloneWithSequence for creating recurrent networks with
+// other sequence lengths.
+func (s *Spec) Build(features []int, batch, outputs int,
+	g *G.ExprGraph) (NeuralNet, error) {
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("build: %v", err)
+	}
+	init := s.Init.InitWFn()
+
+	switch {
+	case len(s.Conv) > 0:
+		if len(features) != 3 {
+			return nil, fmt.Errorf("build: convolutional networks require "+
+				"features of shape (channels, height, width) \n\thave(%v)",
+				features)
+		}
+		hiddenSizes, biases, activations, _ := s.trunk()
+		return NewConvMLP(features[0], features[1], features[2], batch,
+			outputs, g, s.Conv, hiddenSizes, biases, init, activations)
+
+	case s.Recurrent != nil:
+		hiddenSizes, biases, activations, _ := s.trunk()
+		net, err := NewRecurrentMLP(intutils.Prod(features...), batch, 1,
+			outputs, g, s.Recurrent.CellType, s.Recurrent.CellSizes,
+			hiddenSizes, biases, init, activations)
+		if err != nil {
+			return nil, err
+		}
+		return net, nil
+
+	case len(s.Roots) > 1:
+		return s.buildRevTree(features, batch, outputs, g, init)
+
+	case len(s.Heads) > 1:
+		root := s.Roots[0]
+		rootSizes, rootBiases, rootActivations, rootOptions :=
+			branchLayers(root.Layers)
+
+		leafSizes := make([][]int, len(s.Heads))
+		leafBiases := make([][]bool, len(s.Heads))
+		leafActivations := make([][]*Activation, len(s.Heads))
+		leafOptions := make([][]LayerOptions, len(s.Heads))
+		for i, head := range s.Heads {
+			leafSizes[i], leafBiases[i], leafActivations[i],
+				leafOptions[i] = branchLayers(head.Layers)
+		}
+
+		return NewTreeMLPWithOptions(intutils.Prod(features...), batch,
+			outputs, g, rootSizes, rootBiases, rootActivations, rootOptions,
+			leafSizes, leafBiases, leafActivations, leafOptions, init)
+
+	default:
+		hiddenSizes, biases, activations, options := s.trunk()
+		if !s.Output.IsZero() {
+			if options == nil {
+				options = make([]LayerOptions, len(hiddenSizes))
+			}
+			options = append(options, s.Output)
+		}
+		return NewMultiHeadMLPWithOptions(intutils.Prod(features...), batch,
+			outputs, g, hiddenSizes, biases, init, activations, options)
+	}
+}
+
+// buildRevTree builds the RevTreeMLP described by a Spec with multiple
+// roots
+func (s *Spec) buildRevTree(features []int, batch, outputs int,
+	g *G.ExprGraph, init G.InitWFn) (NeuralNet, error) {
+	// Determine the input features of each root
+	rootFeatures := features
+	if len(features) != len(s.Roots) {
+		rootFeatures = make([]int, len(s.Roots))
+		for i, root := range s.Roots {
+			rootFeatures[i] = root.Features
+		}
+
+		if intutils.Prod(features...) != intutils.Sum(rootFeatures...) {
+			return nil, fmt.Errorf("build: input features must be split "+
+				"between roots \n\twant(%v) \n\thave(%v)",
+				intutils.Prod(features...), rootFeatures)
+		}
+	}
+
+	rootSizes := make([][]int, len(s.Roots))
+	rootBiases := make([][]bool, len(s.Roots))
+	rootActivations := make([][]*Activation, len(s.Roots))
+	rootOptions := make([][]LayerOptions, len(s.Roots))
+	for i, root := range s.Roots {
+		rootSizes[i], rootBiases[i], rootActivations[i],
+			rootOptions[i] = branchLayers(root.Layers)
+	}
+
+	var leaf []LayerSpec
+	if len(s.Heads) == 1 {
+		leaf = s.Heads[0].Layers
+	}
+	leafSizes, leafBiases, leafActivations, leafOptions := branchLayers(leaf)
+
+	return NewRevTreeMLPWithOptions(rootFeatures, batch, outputs, g,
+		rootSizes, rootBiases, rootActivations, rootOptions, leafSizes,
+		leafBiases, leafActivations, leafOptions, init)
+}
+
+// trunk returns the layers of a Spec with at most one root and one
+// head, which are the layers of the root followed by those of the head
+func (s *Spec) trunk() ([]int, []bool, []*Activation, []LayerOptions) {
+	var layers []LayerSpec
+	if len(s.Roots) == 1 {
+		layers = append(layers, s.Roots[0].Layers...)
+	}
+	if len(s.Heads) == 1 {
+		layers = append(layers, s.Heads[0].Layers...)
+	}
+	return branchLayers(layers)
+}
+
+// branchLayers returns the hidden sizes, biases, activations, and
+// options of layers as used by the network constructors. If no layer
+// uses any options, then the returned options are nil.
+func branchLayers(layers []LayerSpec) ([]int, []bool, []*Activation,
+	[]LayerOptions) {
+	hiddenSizes := make([]int, len(layers))
+	biases := make([]bool, len(layers))
+	activations := make([]*Activation, len(layers))
+	options := make([]LayerOptions, len(layers))
+
+	useOptions := false
+	for i, layer := range layers {
+		hiddenSizes[i] = layer.Units
+		biases[i] = layer.Bias
+
+		activations[i] = layer.Activation
+		if activations[i] == nil {
+			activations[i] = Identity()
+		}
+
+		options[i] = layer.LayerOptions
+		useOptions = useOptions || !options[i].IsZero()
+	}
+
+	if !useOptions {
+		options = nil
+	}
+	return hiddenSizes, biases, activations, options
+}
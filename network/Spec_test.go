@@ -0,0 +1,259 @@
+package network
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/utils/intutils"
+	G "gorgonia.org/gorgonia"
+)
+
+// initJSON is the JSON description of the weight initializer of the
+// Specs tested
+const initJSON = `"Init": {"Type": "GlorotU", "Config": {"Gain": 1.0}}`
+
+// unmarshalSpec returns the Spec described by the JSON data
+func unmarshalSpec(data string) (*Spec, error) {
+	spec := &Spec{}
+	if err := json.Unmarshal([]byte(data), spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// TestSpecBuild tests that Specs unmarshalled from JSON build the
+// network described by their graph of branches
+func TestSpecBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		features []int
+		want     reflect.Type
+		outputs  []int // Number of outputs of each prediction
+		weights  [][]int
+	}{
+		{
+			name: "MultiHeadMLP",
+			data: `{` + initJSON + `, "Roots": [{"Name": "torso", "Layers": [
+				{"Units": 8, "Bias": true, "Activation": "relu",
+					"Norm": "LayerNorm"},
+				{"Units": 8, "Bias": true, "Activation": "tanh",
+					"Residual": true}]}],
+				"Heads": [{"Layers": [{"Units": 4, "Activation": "relu"}]}]}`,
+			features: []int{5},
+			want:     reflect.TypeOf(&MultiHeadMLP{}),
+			outputs:  []int{3},
+			weights:  [][]int{{5, 8}, {8, 8}, {8, 4}, {4, 3}},
+		},
+		{
+			name: "NoisyMultiHeadMLP",
+			data: `{` + initJSON + `, "Heads": [{"Layers": [
+				{"Units": 4, "Bias": true, "Activation": "relu",
+					"Noisy": true}]}],
+				"Output": {"Noisy": true}}`,
+			features: []int{5},
+			want:     reflect.TypeOf(&MultiHeadMLP{}),
+			outputs:  []int{3},
+			weights:  [][]int{{5, 4}, {4, 3}},
+		},
+		{
+			name: "TreeMLP",
+			data: `{` + initJSON + `, "Roots": [{"Name": "torso", "Layers": [
+				{"Units": 6, "Bias": true, "Activation": "relu"}]}],
+				"Heads": [
+					{"Name": "mean", "Layers": [{"Units": 4, "Bias": true}]},
+					{"Name": "std", "Layers": [{"Units": 2, "Bias": true,
+						"Activation": "relu"}, {"Units": 2}]}]}`,
+			features: []int{5},
+			want:     reflect.TypeOf(&TreeMLP{}),
+			outputs:  []int{3, 3},
+		},
+		{
+			name: "RevTreeMLP",
+			data: `{` + initJSON + `, "Roots": [
+				{"Name": "state", "Layers": [{"Units": 4, "Bias": true,
+					"Activation": "relu"}]},
+				{"Name": "action", "Layers": [{"Units": 2, "Bias": true}]}],
+				"Heads": [{"Layers": [{"Units": 6, "Activation": "relu"}]}]}`,
+			features: []int{3, 2},
+			want:     reflect.TypeOf(&RevTreeMLP{}),
+			outputs:  []int{3},
+		},
+		{
+			// Input features are split between roots by their
+			// Features fields
+			name: "RevTreeMLPSplitFeatures",
+			data: `{` + initJSON + `, "Roots": [
+				{"Name": "state", "Features": 3, "Layers": [{"Units": 4}]},
+				{"Name": "action", "Features": 2, "Layers": [{"Units": 2}]}]}`,
+			features: []int{5},
+			want:     reflect.TypeOf(&RevTreeMLP{}),
+			outputs:  []int{3},
+		},
+	}
+
+	for _, test := range tests {
+		spec, err := unmarshalSpec(test.data)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		net, err := spec.Build(test.features, batch, 3, G.NewGraph())
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		if have := reflect.TypeOf(net); have != test.want {
+			t.Errorf("%v: network: have(%v) want(%v)", test.name, have,
+				test.want)
+			continue
+		}
+		if n := intutils.Sum(net.Features()...); n !=
+			intutils.Sum(test.features...) {
+			t.Errorf("%v: features: have(%v) want(%v)", test.name,
+				net.Features(), test.features)
+		}
+
+		outputs := predict(t, net, make([]float64,
+			batch*intutils.Sum(test.features...)))
+		if len(outputs) != len(test.outputs) {
+			t.Errorf("%v: predictions: have(%v) want(%v)", test.name,
+				len(outputs), len(test.outputs))
+			continue
+		}
+		for i := range outputs {
+			if len(outputs[i]) != batch*test.outputs[i] {
+				t.Errorf("%v: prediction %v size: have(%v) want(%v)",
+					test.name, i, len(outputs[i]), batch*test.outputs[i])
+			}
+		}
+
+		if test.weights == nil {
+			continue
+		}
+		layers := net.(*MultiHeadMLP).Layers()
+		if len(layers) != len(test.weights) {
+			t.Errorf("%v: layers: have(%v) want(%v)", test.name, len(layers),
+				len(test.weights))
+			continue
+		}
+		for i, layer := range layers {
+			if shape := layer.Weights().Shape(); !shape.Eq(test.weights[i]) {
+				t.Errorf("%v: layer %v weights: have(%v) want(%v)", test.name,
+					i, shape, test.weights[i])
+			}
+		}
+	}
+}
+
+// TestSpecInvalid tests that Specs describing illegal layers or graphs
+// cannot be unmarshalled or built. Branches are connected by their
+// position as roots or heads rather than by reference, so that the
+// graph of a Spec cannot contain cycles.
+func TestSpecInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		features []int
+	}{
+		{
+			name: "UnknownActivation",
+			data: `{` + initJSON + `, "Heads": [{"Layers": [{"Units": 4,
+				"Activation": "swish"}]}]}`,
+		},
+		{
+			name: "UnknownNorm",
+			data: `{` + initJSON + `, "Heads": [{"Layers": [{"Units": 4,
+				"Norm": "GroupNorm"}]}]}`,
+		},
+		{
+			name: "UnknownConvLayer",
+			data: `{` + initJSON + `, "Conv": [{"Type": "Conv3D",
+				"Filters": 2, "Kernel": [3, 3]}]}`,
+			features: []int{2, 6, 6},
+		},
+		{
+			name: "MissingInit",
+			data: `{"Heads": [{"Layers": [{"Units": 4}]}]}`,
+		},
+		{
+			name: "NoUnits",
+			data: `{` + initJSON + `, "Heads": [{"Layers": [{"Units": 0}]}]}`,
+		},
+		{
+			name: "DuplicateBranch",
+			data: `{` + initJSON + `, "Roots": [
+				{"Name": "torso", "Layers": [{"Units": 4}]}],
+				"Heads": [{"Name": "torso", "Layers": [{"Units": 4}]}]}`,
+		},
+		{
+			name: "HeadsWithoutRoot",
+			data: `{` + initJSON + `, "Heads": [
+				{"Layers": [{"Units": 4}]}, {"Layers": [{"Units": 4}]}]}`,
+		},
+		{
+			name: "HeadsWithEmptyRoot",
+			data: `{` + initJSON + `, "Roots": [{"Layers": []}],
+				"Heads": [{"Layers": [{"Units": 4}]},
+					{"Layers": [{"Units": 4}]}]}`,
+		},
+		{
+			name: "RootsAndHeads",
+			data: `{` + initJSON + `, "Roots": [
+				{"Layers": [{"Units": 4}]}, {"Layers": [{"Units": 4}]}],
+				"Heads": [{"Layers": [{"Units": 4}]},
+					{"Layers": [{"Units": 4}]}]}`,
+			features: []int{2, 3},
+		},
+		{
+			name: "EmptyRoot",
+			data: `{` + initJSON + `, "Roots": [
+				{"Layers": [{"Units": 4}]}, {"Layers": []}]}`,
+			features: []int{2, 3},
+		},
+		{
+			name: "RootFeatures",
+			data: `{` + initJSON + `, "Roots": [
+				{"Features": 2, "Layers": [{"Units": 4}]},
+				{"Features": 2, "Layers": [{"Units": 4}]}]}`,
+			features: []int{5},
+		},
+		{
+			name: "ConvAndRecurrent",
+			data: `{` + initJSON + `, "Conv": [{"Type": "MaxPool",
+				"Kernel": [2, 2]}], "Recurrent": {"CellType": "GRU",
+				"CellSizes": [4]}}`,
+			features: []int{2, 6, 6},
+		},
+		{
+			name: "NoRecurrentCells",
+			data: `{` + initJSON + `, "Recurrent": {"CellType": "GRU",
+				"CellSizes": []}}`,
+		},
+		{
+			name: "ConvFeatures",
+			data: `{` + initJSON + `, "Conv": [{"Type": "MaxPool",
+				"Kernel": [2, 2]}]}`,
+		},
+		{
+			name: "OutputOptions",
+			data: `{` + initJSON + `, "Heads": [{"Layers": [{"Units": 4}]}],
+				"Output": {"Dropout": 0.5}}`,
+		},
+	}
+
+	for _, test := range tests {
+		features := test.features
+		if features == nil {
+			features = []int{5}
+		}
+
+		spec, err := unmarshalSpec(test.data)
+		if err != nil {
+			continue
+		}
+		if _, err := spec.Build(features, batch, 3, G.NewGraph()); err == nil {
+			t.Errorf("%v: expected an error", test.name)
+		}
+	}
+}
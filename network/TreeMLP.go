@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"golang.org/x/exp/rand"
 	"gonum.org/v1/gonum/floats"
 	G "gorgonia.org/gorgonia"
 	"gorgonia.org/tensor"
@@ -46,6 +47,8 @@ type TreeMLP struct {
 	leafHiddenSizes [][]int
 	leafBiases      [][]bool
 	leafActivations [][]*Activation
+	rootOptions     []LayerOptions
+	leafOptions     [][]LayerOptions
 
 	predVal    []G.Value // Values predicted by each leaf node
 	prediction []*G.Node // Nodes holding the predictions
@@ -148,6 +151,22 @@ func NewTreeMLP(features, batch, outputs int, g *G.ExprGraph,
 	rootHiddenSizes []int, rootBiases []bool, rootActivations []*Activation,
 	leafHiddenSizes [][]int, leafBiases [][]bool,
 	leafActivations [][]*Activation, init G.InitWFn) (NeuralNet, error) {
+	return NewTreeMLPWithOptions(features, batch, outputs, g,
+		rootHiddenSizes, rootBiases, rootActivations, nil, leafHiddenSizes,
+		leafBiases, leafActivations, nil, init)
+}
+
+// NewTreeMLPWithOptions is like NewTreeMLP, but rootOptions[i]
+// determines the normalization, dropout, and residual connection of
+// layer i of the root network, and leafOptions[i][j] those of layer j
+// of leaf network i. Either rootOptions or leafOptions may be nil, in
+// which case the corresponding networks use plain fully connected
+// layers.
+func NewTreeMLPWithOptions(features, batch, outputs int, g *G.ExprGraph,
+	rootHiddenSizes []int, rootBiases []bool, rootActivations []*Activation,
+	rootOptions []LayerOptions, leafHiddenSizes [][]int,
+	leafBiases [][]bool, leafActivations [][]*Activation,
+	leafOptions [][]LayerOptions, init G.InitWFn) (NeuralNet, error) {
 
 	err := validateTreeMLP(outputs, rootHiddenSizes, rootBiases, rootActivations,
 		leafHiddenSizes, leafBiases, leafActivations)
@@ -155,6 +174,12 @@ func NewTreeMLP(features, batch, outputs int, g *G.ExprGraph,
 		return nil, fmt.Errorf("newtreemlp: %v", err)
 	}
 
+	if leafOptions != nil && len(leafOptions) != len(leafHiddenSizes) {
+		msg := "newtreemlp: invalid number of leaf network options " +
+			"\n\twant(%v) \n\thave(%v)"
+		return nil, fmt.Errorf(msg, len(leafHiddenSizes), len(leafOptions))
+	}
+
 	// Set up the input node
 	input := G.NewMatrix(g, tensor.Float64, G.WithShape(batch, features),
 		G.WithName("input"), G.WithInit(G.Zeroes()))
@@ -163,7 +188,7 @@ func NewTreeMLP(features, batch, outputs int, g *G.ExprGraph,
 	observationOutputs := rootHiddenSizes[len(rootHiddenSizes)-1]
 	rootNetwork, err := newMultiHeadMLPFromInput([]*G.Node{input},
 		observationOutputs, g, rootHiddenSizes, rootBiases, init,
-		rootActivations, "Root", "", false)
+		rootActivations, rootOptions, "Root", "", false)
 	if err != nil {
 		return nil, fmt.Errorf("newtreemlp: could not construct root "+
 			"network: %v", err)
@@ -181,9 +206,14 @@ func NewTreeMLP(features, batch, outputs int, g *G.ExprGraph,
 	for i := 0; i < len(leafHiddenSizes); i++ {
 		prefix := fmt.Sprintf("Leaf%d", i)
 
+		var options []LayerOptions
+		if leafOptions != nil {
+			options = leafOptions[i]
+		}
+
 		leafNetworks[i], err = newMultiHeadMLPFromInput(rootOutput, outputs, g,
 			leafHiddenSizes[i], leafBiases[i], init, leafActivations[i],
-			prefix, "", true)
+			options, prefix, "", true)
 
 		if err != nil {
 			return nil, fmt.Errorf("newtreemlp: could not construct leaf "+
@@ -206,6 +236,8 @@ func NewTreeMLP(features, batch, outputs int, g *G.ExprGraph,
 		leafHiddenSizes: leafHiddenSizes,
 		leafBiases:      leafBiases,
 		leafActivations: leafActivations,
+		rootOptions:     rootOptions,
+		leafOptions:     leafOptions,
 		learnables:      nil,
 		model:           nil,
 	}
@@ -243,9 +275,58 @@ func (t *TreeMLP) SetInput(input []float64) error {
 		log.Fatal("w has NaN")
 	}
 
+	if err := t.refresh(); err != nil {
+		return fmt.Errorf("setInput: %v", err)
+	}
 	return G.Let(t.input, inputTensor)
 }
 
+// subNetworks returns the root network followed by each leaf network
+func (t *TreeMLP) subNetworks() []NeuralNet {
+	return append([]NeuralNet{t.rootNetwork}, t.leafNetworks...)
+}
+
+// setEval sets the TreeMLP to evaluation mode if eval is true and to
+// training mode otherwise
+func (t *TreeMLP) setEval(eval bool) {
+	for _, net := range t.subNetworks() {
+		SetEval(net, eval)
+	}
+}
+
+// setSeed seeds each sub-network of the TreeMLP with a different seed
+// sampled from a random number generator seeded with seed
+func (t *TreeMLP) setSeed(seed uint64) {
+	rng := rand.New(rand.NewSource(seed))
+	for _, net := range t.subNetworks() {
+		SetSeed(net, rng.Uint64())
+	}
+}
+
+// refresh refreshes the layers of each sub-network before a forward
+// pass
+func (t *TreeMLP) refresh() error {
+	for _, net := range t.subNetworks() {
+		if r, ok := net.(refresher); ok {
+			if err := r.refresh(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// state returns the non-learnable state nodes of the TreeMLP
+func (t *TreeMLP) state() G.Nodes {
+	var state G.Nodes
+	for _, net := range t.subNetworks() {
+		if s, ok := net.(stater); ok {
+			state = append(state, s.state()...)
+		}
+	}
+	return state
+}
+
 // Outputs returns the number of outputs per leaf network
 func (t *TreeMLP) Outputs() []int {
 	if len(t.numOutputs) != len(t.Prediction()) {
@@ -367,6 +448,8 @@ func (t *TreeMLP) cloneWithInputTo(axis int, inputs []*G.Node,
 		leafHiddenSizes: t.leafHiddenSizes,
 		leafBiases:      t.leafBiases,
 		leafActivations: t.leafActivations,
+		rootOptions:     t.rootOptions,
+		leafOptions:     t.leafOptions,
 		learnables:      nil,
 		model:           nil,
 	}
@@ -384,6 +467,22 @@ func (t *TreeMLP) BatchSize() int {
 	return t.rootNetwork.BatchSize()
 }
 
+// architecture returns the architecture of the TreeMLP
+func (t *TreeMLP) architecture() architecture {
+	return &treeMLPArchitecture{
+		Features:        t.numInputs,
+		Outputs:         t.numOutputs[0],
+		RootHiddenSizes: t.rootHiddenSizes,
+		RootBiases:      t.rootBiases,
+		RootActivations: t.rootActivations,
+		RootOptions:     t.rootOptions,
+		LeafHiddenSizes: t.leafHiddenSizes,
+		LeafBiases:      t.leafBiases,
+		LeafActivations: t.leafActivations,
+		LeafOptions:     t.leafOptions,
+	}
+}
+
 // fwd computes the remaining steps of the forward pass of the TreeMLP
 // that its root and leaf networks did not compute.
 func (t *TreeMLP) fwd(inputs []*G.Node) (*G.Node, error) {
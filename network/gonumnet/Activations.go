@@ -0,0 +1,130 @@
+package gonumnet
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelfneumann/golearn/network"
+)
+
+// activation implements an element-wise activation function and its
+// derivative for the forward and backward passes of a layer
+type activation struct {
+	// f computes the activation of x
+	f func(x float64) float64
+
+	// df computes the derivative of the activation at x, given the
+	// activation y = f(x)
+	df func(x, y float64) float64
+}
+
+// newActivation returns the activation corresponding to the
+// network.Activation a. If a is the identity or nil activation, then
+// newActivation returns nil.
+func newActivation(a *network.Activation) (*activation, error) {
+	if a == nil || a.IsIdentity() || a.IsNil() {
+		return nil, nil
+	}
+
+	switch a.String() {
+	case network.ReLU().String():
+		return &activation{
+			f: func(x float64) float64 { return math.Max(x, 0) },
+			df: func(x, _ float64) float64 {
+				if x > 0 {
+					return 1
+				}
+				return 0
+			},
+		}, nil
+
+	case network.TanH().String():
+		return &activation{
+			f:  math.Tanh,
+			df: func(_, y float64) float64 { return 1 - y*y },
+		}, nil
+
+	case network.Sigmoid().String():
+		return &activation{
+			f:  sigmoid,
+			df: func(_, y float64) float64 { return y * (1 - y) },
+		}, nil
+
+	case network.Sin().String():
+		return &activation{
+			f:  math.Sin,
+			df: func(x, _ float64) float64 { return math.Cos(x) },
+		}, nil
+
+	case network.Cos().String():
+		return &activation{
+			f:  math.Cos,
+			df: func(x, _ float64) float64 { return -math.Sin(x) },
+		}, nil
+
+	case network.Sqrt().String():
+		// sqrt(|x|)
+		return &activation{
+			f: func(x float64) float64 { return math.Sqrt(math.Abs(x)) },
+			df: func(x, y float64) float64 {
+				if y == 0 {
+					return 0
+				}
+				return sign(x) / (2 * y)
+			},
+		}, nil
+
+	case network.Mish().String():
+		// x * tanh(softplus(x))
+		return &activation{
+			f: func(x float64) float64 { return x * math.Tanh(softplus(x)) },
+			df: func(x, _ float64) float64 {
+				t := math.Tanh(softplus(x))
+				return t + x*(1-t*t)*sigmoid(x)
+			},
+		}, nil
+
+	case network.Log1p().String():
+		// log(|x| + 1)
+		return &activation{
+			f: func(x float64) float64 { return math.Log1p(math.Abs(x)) },
+			df: func(x, _ float64) float64 {
+				return sign(x) / (1 + math.Abs(x))
+			},
+		}, nil
+
+	case network.Softplus().String():
+		return &activation{
+			f:  softplus,
+			df: func(x, _ float64) float64 { return sigmoid(x) },
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("newActivation: unsupported activation %v", a)
+	}
+}
+
+// sigmoid computes the logistic sigmoid of x
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// softplus computes log(1 + exp(x)) in a numerically stable manner
+func softplus(x float64) float64 {
+	if x > 0 {
+		return x + math.Log1p(math.Exp(-x))
+	}
+	return math.Log1p(math.Exp(x))
+}
+
+// sign returns the sign of x, which is 0 if x is 0
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
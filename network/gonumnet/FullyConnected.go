@@ -0,0 +1,244 @@
+package gonumnet
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// param implements a learnable parameter of a network. The value and
+// gradient of the parameter are stored as gonum matrices, which share
+// their backing data with tensors so that the parameter can be
+// updated in place by Gorgonia solvers. A param therefore satisfies
+// the gorgonia.ValueGrad interface.
+type param struct {
+	value *mat.Dense
+	grad  *mat.Dense
+
+	valueTensor *tensor.Dense
+	gradTensor  *tensor.Dense
+}
+
+// newParam returns a new param of the given shape, with values
+// initialized by init. Weight matrices should have shape
+// (rows, cols), and bias vectors should have shape (cols). The
+// returned param is always stored as a matrix.
+func newParam(init G.InitWFn, shape ...int) *param {
+	rows, cols := 1, shape[0]
+	if len(shape) == 2 {
+		rows, cols = shape[0], shape[1]
+	}
+
+	backing := make([]float64, rows*cols)
+	copy(backing, init(tensor.Float64, shape...).([]float64))
+
+	return newParamFrom(rows, cols, backing)
+}
+
+// newParamFrom returns a new param with the given values, which are
+// used as the backing data of the param
+func newParamFrom(rows, cols int, values []float64) *param {
+	grad := make([]float64, rows*cols)
+
+	return &param{
+		value: mat.NewDense(rows, cols, values),
+		grad:  mat.NewDense(rows, cols, grad),
+
+		valueTensor: tensor.NewDense(tensor.Float64, []int{rows, cols},
+			tensor.WithBacking(values)),
+		gradTensor: tensor.NewDense(tensor.Float64, []int{rows, cols},
+			tensor.WithBacking(grad)),
+	}
+}
+
+// Value returns the value of the param as a tensor
+func (p *param) Value() G.Value {
+	return p.valueTensor
+}
+
+// Grad returns the gradient of the param as a tensor
+func (p *param) Grad() (G.Value, error) {
+	return p.gradTensor, nil
+}
+
+// data returns the backing data of the param's value
+func (p *param) data() []float64 {
+	return p.value.RawMatrix().Data
+}
+
+// clone returns a copy of the param with zero gradient
+func (p *param) clone() *param {
+	rows, cols := p.value.Dims()
+	values := make([]float64, rows*cols)
+	copy(values, p.data())
+
+	return newParamFrom(rows, cols, values)
+}
+
+// fcLayer implements a fully connected layer of a feed forward neural
+// network. The layer caches its input and output on each forward pass
+// so that the gradient of its weights can be computed in the backward
+// pass.
+type fcLayer struct {
+	weights *param // Shape (in, out)
+	bias    *param // Shape (1, out), nil if the layer has no bias
+	act     *activation
+
+	input     *mat.Dense
+	preOutput *mat.Dense
+	output    *mat.Dense
+	gradPre   *mat.Dense
+	gradInput *mat.Dense
+}
+
+// newfcLayer returns a new fully connected layer with in inputs, out
+// outputs, and the argument activation. If act is nil, then the
+// layer has no activation.
+func newfcLayer(in, out int, bias bool, act *activation,
+	init G.InitWFn) *fcLayer {
+	layer := &fcLayer{
+		weights: newParam(init, in, out),
+		act:     act,
+	}
+	if bias {
+		layer.bias = newParam(init, out)
+	}
+
+	return layer
+}
+
+// fwd performs the forward pass of the layer on input x, which has
+// one sample per row. The returned matrix is owned by the layer and is
+// overwritten by the next forward pass.
+func (f *fcLayer) fwd(x *mat.Dense) *mat.Dense {
+	batch, _ := x.Dims()
+	_, out := f.weights.value.Dims()
+
+	if f.output == nil || f.output.RawMatrix().Rows != batch {
+		f.preOutput = mat.NewDense(batch, out, nil)
+		f.output = f.preOutput
+		if f.act != nil {
+			f.output = mat.NewDense(batch, out, nil)
+		}
+	}
+	f.input = x
+
+	f.preOutput.Mul(x, f.weights.value)
+	pre := f.preOutput.RawMatrix().Data
+	if f.bias != nil {
+		bias := f.bias.data()
+		for i := range pre {
+			pre[i] += bias[i%out]
+		}
+	}
+
+	if f.act != nil {
+		output := f.output.RawMatrix().Data
+		for i, x := range pre {
+			output[i] = f.act.f(x)
+		}
+	}
+
+	return f.output
+}
+
+// bwd performs the backward pass of the layer given the gradient of
+// the loss with respect to the output of the previous forward pass.
+// The gradients of the layer's weights are overwritten with the
+// computed gradients. If needInput is true, then the gradient of the
+// loss with respect to the layer's input is returned, otherwise nil is
+// returned.
+func (f *fcLayer) bwd(gradOutput *mat.Dense, needInput bool) *mat.Dense {
+	batch, out := gradOutput.Dims()
+
+	gradPre := gradOutput
+	if f.act != nil {
+		if f.gradPre == nil || f.gradPre.RawMatrix().Rows != batch {
+			f.gradPre = mat.NewDense(batch, out, nil)
+		}
+		gradPre = f.gradPre
+
+		pre := f.preOutput.RawMatrix().Data
+		output := f.output.RawMatrix().Data
+		grad := gradOutput.RawMatrix().Data
+		data := gradPre.RawMatrix().Data
+		for i := range data {
+			data[i] = grad[i] * f.act.df(pre[i], output[i])
+		}
+	}
+
+	f.weights.grad.Mul(f.input.T(), gradPre)
+
+	if f.bias != nil {
+		biasGrad := f.bias.grad.RawMatrix().Data
+		for j := range biasGrad {
+			biasGrad[j] = 0
+		}
+		data := gradPre.RawMatrix().Data
+		for i := range data {
+			biasGrad[i%out] += data[i]
+		}
+	}
+
+	if !needInput {
+		return nil
+	}
+
+	in, _ := f.weights.value.Dims()
+	if f.gradInput == nil || f.gradInput.RawMatrix().Rows != batch {
+		f.gradInput = mat.NewDense(batch, in, nil)
+	}
+	f.gradInput.Mul(gradPre, f.weights.value.T())
+
+	return f.gradInput
+}
+
+// params returns the learnable parameters of the layer
+func (f *fcLayer) params() []*param {
+	if f.bias != nil {
+		return []*param{f.weights, f.bias}
+	}
+	return []*param{f.weights}
+}
+
+// clone returns a copy of the layer
+func (f *fcLayer) clone() *fcLayer {
+	layer := &fcLayer{
+		weights: f.weights.clone(),
+		act:     f.act,
+	}
+	if f.bias != nil {
+		layer.bias = f.bias.clone()
+	}
+
+	return layer
+}
+
+// addfcLayers returns the fully connected layers with the given sizes,
+// biases, and activations, where the first layer has features inputs
+func addfcLayers(features int, sizes []int, biases []bool,
+	activations []*activation, init G.InitWFn) ([]*fcLayer, error) {
+	if len(sizes) != len(biases) {
+		return nil, fmt.Errorf("invalid number of biases \n\twant(%d)"+
+			"\n\thave(%d)", len(sizes), len(biases))
+	}
+	if len(sizes) != len(activations) {
+		return nil, fmt.Errorf("invalid number of activations \n\twant(%d)"+
+			"\n\thave(%d)", len(sizes), len(activations))
+	}
+
+	layers := make([]*fcLayer, len(sizes))
+	in := features
+	for i := range sizes {
+		if sizes[i] <= 0 {
+			return nil, fmt.Errorf("layer %d must have a positive number of "+
+				"units \n\thave(%d)", i, sizes[i])
+		}
+		layers[i] = newfcLayer(in, sizes[i], biases[i], activations[i], init)
+		in = sizes[i]
+	}
+
+	return layers, nil
+}
@@ -0,0 +1,192 @@
+package gonumnet
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/network"
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+)
+
+// MLP implements a multi-layered perceptron with a single output
+// layer. This is the gonum counterpart of network.MultiHeadMLP.
+type MLP struct {
+	features int
+	outputs  int
+	layers   []*fcLayer
+}
+
+// NewMLP creates and returns a new multi-layered perceptron with
+// outputs output units.
+//
+// The MLP has number of layers equal to len(hiddenSizes) + 1. A final
+// linear layer with a bias unit and no activation is always added
+// such that given any input, the output will be outputs. For index i,
+// hiddenSizes[i] is the number of nodes in hidden layer i, biases[i]
+// is true if the hidden layer will contain a bias unit and false
+// otherwise, and activations[i] is the activation function for hidden
+// layer i. The parameter init determines the weight initialization
+// scheme.
+//
+// Given the same arguments, NewMLP constructs the same architecture
+// as network.NewMultiHeadMLP.
+func NewMLP(features, outputs int, hiddenSizes []int, biases []bool,
+	activations []*network.Activation, init G.InitWFn) (*MLP, error) {
+	if len(hiddenSizes) != len(activations) {
+		return nil, fmt.Errorf("newMLP: invalid number of activations "+
+			"\n\twant(%d) \n\thave(%d)", len(hiddenSizes), len(activations))
+	}
+
+	sizes := append(append([]int{}, hiddenSizes...), outputs)
+	bias := append(append([]bool{}, biases...), true)
+	acts := append(append([]*network.Activation{}, activations...),
+		network.Identity())
+
+	net, err := newMLP(features, sizes, bias, acts, init)
+	if err != nil {
+		return nil, fmt.Errorf("newMLP: %v", err)
+	}
+	return net, nil
+}
+
+// newMLP returns a new MLP with layers described by sizes, biases, and
+// activations, without adding a final linear layer
+func newMLP(features int, sizes []int, biases []bool,
+	activations []*network.Activation, init G.InitWFn) (*MLP, error) {
+	if features <= 0 {
+		return nil, fmt.Errorf("there must be a positive number of input "+
+			"features \n\thave(%d)", features)
+	}
+	if len(sizes) == 0 {
+		return nil, fmt.Errorf("there must be at least one layer")
+	}
+
+	acts := make([]*activation, len(activations))
+	for i := range activations {
+		var err error
+		if acts[i], err = newActivation(activations[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	layers, err := addfcLayers(features, sizes, biases, acts, init)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MLP{
+		features: features,
+		outputs:  sizes[len(sizes)-1],
+		layers:   layers,
+	}, nil
+}
+
+// Features returns the number of input features of the MLP
+func (m *MLP) Features() int {
+	return m.features
+}
+
+// Outputs returns the number of outputs of the MLP
+func (m *MLP) Outputs() []int {
+	return []int{m.outputs}
+}
+
+// OutputLayers returns the number of output layers of the MLP, which
+// is always 1
+func (m *MLP) OutputLayers() int {
+	return 1
+}
+
+// Forward performs the forward pass on the input x and returns the
+// output of the MLP
+func (m *MLP) Forward(x *mat.Dense) ([]*mat.Dense, error) {
+	output, err := m.fwd(x)
+	if err != nil {
+		return nil, fmt.Errorf("forward: %v", err)
+	}
+	return []*mat.Dense{output}, nil
+}
+
+// fwd performs the forward pass on the input x
+func (m *MLP) fwd(x *mat.Dense) (*mat.Dense, error) {
+	if _, cols := x.Dims(); cols != m.features {
+		return nil, fmt.Errorf("invalid number of input features "+
+			"\n\twant(%v) \n\thave(%v)", m.features, cols)
+	}
+
+	for _, layer := range m.layers {
+		x = layer.fwd(x)
+	}
+	return x, nil
+}
+
+// Backward performs the backward pass given the gradient of some loss
+// with respect to the output of the previous forward pass
+func (m *MLP) Backward(grads []*mat.Dense) error {
+	if len(grads) != 1 {
+		return fmt.Errorf("backward: invalid number of gradients "+
+			"\n\twant(1) \n\thave(%v)", len(grads))
+	}
+
+	if _, err := m.bwd(grads[0], false); err != nil {
+		return fmt.Errorf("backward: %v", err)
+	}
+	return nil
+}
+
+// bwd performs the backward pass given the gradient of some loss with
+// respect to the output of the previous forward pass. If needInput is
+// true, then the gradient of the loss with respect to the input of the
+// previous forward pass is returned.
+func (m *MLP) bwd(grad *mat.Dense, needInput bool) (*mat.Dense, error) {
+	output := m.layers[len(m.layers)-1].output
+	if output == nil {
+		return nil, fmt.Errorf("no forward pass has been performed")
+	}
+
+	rows, cols := output.Dims()
+	if gradRows, gradCols := grad.Dims(); gradRows != rows ||
+		gradCols != cols {
+		return nil, fmt.Errorf("invalid gradient shape \n\twant(%v, %v) "+
+			"\n\thave(%v, %v)", rows, cols, gradRows, gradCols)
+	}
+
+	for i := len(m.layers) - 1; i >= 0; i-- {
+		grad = m.layers[i].bwd(grad, needInput || i > 0)
+	}
+	return grad, nil
+}
+
+// Model returns the learnable parameters of the MLP and their
+// gradients
+func (m *MLP) Model() []G.ValueGrad {
+	return model(m.params())
+}
+
+// params returns the learnable parameters of the MLP
+func (m *MLP) params() []*param {
+	var params []*param
+	for _, layer := range m.layers {
+		params = append(params, layer.params()...)
+	}
+	return params
+}
+
+// Clone returns a copy of the MLP
+func (m *MLP) Clone() Net {
+	return m.clone()
+}
+
+// clone returns a copy of the MLP
+func (m *MLP) clone() *MLP {
+	layers := make([]*fcLayer, len(m.layers))
+	for i := range m.layers {
+		layers[i] = m.layers[i].clone()
+	}
+
+	return &MLP{
+		features: m.features,
+		outputs:  m.outputs,
+		layers:   layers,
+	}
+}
@@ -0,0 +1,401 @@
+package gonumnet
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/network"
+	"github.com/samuelfneumann/golearn/solver"
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+const (
+	features = 4
+	actions  = 3
+	batch    = 32
+	tol      = 1e-9
+)
+
+var (
+	hiddenSizes = []int{64, 64}
+	biases      = []bool{true, true}
+)
+
+// seededInit returns a weight initializer which draws weights from a
+// normal distribution using a fixed seed, so that networks constructed
+// with initializers returned by seededInit are identical.
+func seededInit() G.InitWFn {
+	rng := rand.New(rand.NewSource(1))
+	return func(dt tensor.Dtype, s ...int) interface{} {
+		size := 1
+		for _, dim := range s {
+			size *= dim
+		}
+
+		weights := make([]float64, size)
+		for i := range weights {
+			weights[i] = rng.NormFloat64() * 0.5
+		}
+		return weights
+	}
+}
+
+// randomData returns a slice of n random floats
+func randomData(n int) []float64 {
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = rand.NormFloat64()
+	}
+	return data
+}
+
+// allActivations returns each activation supported by the package
+func allActivations() []*network.Activation {
+	return []*network.Activation{
+		network.Identity(),
+		network.ReLU(),
+		network.TanH(),
+		network.Sigmoid(),
+		network.Sin(),
+		network.Cos(),
+		network.Sqrt(),
+		network.Mish(),
+		network.Log1p(),
+		network.Softplus(),
+	}
+}
+
+// gorgoniaGrad returns the output of the Gorgonia network net and the
+// gradient of Σ output ⊙ outGrad with respect to its learnables
+func gorgoniaGrad(t *testing.T, net network.NeuralNet, x, outGrad []float64,
+	outputs int) ([]float64, [][]float64) {
+	outGradNode := G.NewMatrix(net.Graph(), tensor.Float64,
+		G.WithShape(net.BatchSize(), outputs),
+		G.WithValue(tensor.NewDense(tensor.Float64,
+			[]int{net.BatchSize(), outputs}, tensor.WithBacking(outGrad))))
+	loss := G.Must(G.Sum(G.Must(G.HadamardProd(net.Prediction()[0],
+		outGradNode))))
+
+	if _, err := G.Grad(loss, net.Learnables()...); err != nil {
+		t.Fatal(err)
+	}
+	vm := G.NewTapeMachine(net.Graph(), G.BindDualValues(net.Learnables()...))
+	defer vm.Close()
+
+	if err := net.SetInput(x); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	var grads [][]float64
+	for _, node := range net.Model() {
+		grad, err := node.Grad()
+		if err != nil {
+			t.Fatal(err)
+		}
+		grads = append(grads, append([]float64{},
+			grad.Data().([]float64)...))
+	}
+	return append([]float64{}, net.Output()[0].Data().([]float64)...), grads
+}
+
+// checkClose fails the test if any element of have and want differ by
+// more than tol
+func checkClose(t *testing.T, name string, have, want []float64) {
+	if len(have) != len(want) {
+		t.Fatalf("%v: lengths differ \n\twant(%v) \n\thave(%v)", name,
+			len(want), len(have))
+	}
+	for i := range have {
+		if math.Abs(have[i]-want[i]) > tol*math.Max(1, math.Abs(want[i])) {
+			t.Errorf("%v: element %v differs \n\twant(%v) \n\thave(%v)", name,
+				i, want[i], have[i])
+			return
+		}
+	}
+}
+
+func TestMLPMatchesGorgonia(t *testing.T) {
+	activations := allActivations()
+	sizes := make([]int, len(activations))
+	bias := make([]bool, len(activations))
+	for i := range sizes {
+		sizes[i] = 8
+		bias[i] = i%2 == 0
+	}
+
+	gorgoniaNet, err := network.NewMultiHeadMLP(features, batch, actions,
+		G.NewGraph(), sizes, bias, seededInit(), activations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gonumNet, err := NewMLP(features, actions, sizes, bias, activations,
+		seededInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := randomData(batch * features)
+	outGrad := randomData(batch * actions)
+	wantOutput, wantGrads := gorgoniaGrad(t, gorgoniaNet, x, outGrad, actions)
+
+	output, err := gonumNet.Forward(mat.NewDense(batch, features, x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkClose(t, "output", output[0].RawMatrix().Data, wantOutput)
+
+	err = gonumNet.Backward([]*mat.Dense{mat.NewDense(batch, actions,
+		outGrad)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := gonumNet.params()
+	if len(params) != len(wantGrads) {
+		t.Fatalf("number of parameters differ \n\twant(%v) \n\thave(%v)",
+			len(wantGrads), len(params))
+	}
+	for i := range params {
+		checkClose(t, "gradient", params[i].grad.RawMatrix().Data,
+			wantGrads[i])
+	}
+}
+
+func TestTreeMLPMatchesGorgonia(t *testing.T) {
+	leafSizes := [][]int{{8}, {}}
+	leafBiases := [][]bool{{true}, {}}
+	leafActivations := [][]*network.Activation{{network.TanH()}, {}}
+
+	gorgoniaNet, err := network.NewTreeMLP(features, batch, actions,
+		G.NewGraph(), hiddenSizes, biases,
+		[]*network.Activation{network.ReLU(), network.Mish()}, leafSizes,
+		leafBiases, leafActivations, seededInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gonumNet, err := NewTreeMLP(features, actions, hiddenSizes, biases,
+		[]*network.Activation{network.ReLU(), network.Mish()}, leafSizes,
+		leafBiases, leafActivations, seededInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compute the gradient of the sum of both leaf outputs
+	g := gorgoniaNet.Graph()
+	outGrads := [][]float64{randomData(batch * actions),
+		randomData(batch * actions)}
+	var loss *G.Node
+	for i, pred := range gorgoniaNet.Prediction() {
+		outGrad := G.NewMatrix(g, tensor.Float64,
+			G.WithShape(batch, actions),
+			G.WithName(fmt.Sprintf("outGrad%d", i)),
+			G.WithValue(tensor.NewDense(tensor.Float64,
+				[]int{batch, actions}, tensor.WithBacking(outGrads[i]))))
+		leafLoss := G.Must(G.Sum(G.Must(G.HadamardProd(pred, outGrad))))
+		if loss == nil {
+			loss = leafLoss
+		} else {
+			loss = G.Must(G.Add(loss, leafLoss))
+		}
+	}
+	if _, err := G.Grad(loss, gorgoniaNet.Learnables()...); err != nil {
+		t.Fatal(err)
+	}
+	vm := G.NewTapeMachine(g, G.BindDualValues(gorgoniaNet.Learnables()...))
+	defer vm.Close()
+
+	x := randomData(batch * features)
+	if err := gorgoniaNet.SetInput(x); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	outputs, err := gonumNet.Forward(mat.NewDense(batch, features, x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range outputs {
+		checkClose(t, "output", outputs[i].RawMatrix().Data,
+			gorgoniaNet.Output()[i].Data().([]float64))
+	}
+
+	err = gonumNet.Backward([]*mat.Dense{
+		mat.NewDense(batch, actions, outGrads[0]),
+		mat.NewDense(batch, actions, outGrads[1]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare gradients by name, since the order of parameters may
+	// differ between the networks
+	want := make(map[string][]float64)
+	for _, node := range gorgoniaNet.Learnables() {
+		grad, err := node.Grad()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[node.Name()] = grad.Data().([]float64)
+	}
+	names := []string{"RootL0W", "RootL0B", "RootL1W", "RootL1B", "Leaf0L0W",
+		"Leaf0L0B", "Leaf0L1W", "Leaf0L1B", "Leaf1L0W", "Leaf1L0B"}
+	params := gonumNet.params()
+	if len(params) != len(names) {
+		t.Fatalf("number of parameters differ \n\twant(%v) \n\thave(%v)",
+			len(names), len(params))
+	}
+	for i := range params {
+		checkClose(t, names[i], params[i].grad.RawMatrix().Data,
+			want[names[i]])
+	}
+}
+
+func TestSolverUpdatesInPlace(t *testing.T) {
+	net, err := NewMLP(features, actions, hiddenSizes, biases,
+		[]*network.Activation{network.ReLU(), network.ReLU()}, seededInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := net.Clone()
+
+	sol, err := solver.NewDefaultAdam(0.01, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := mat.NewDense(batch, features, randomData(batch*features))
+	if _, err := net.Forward(x); err != nil {
+		t.Fatal(err)
+	}
+	err = net.Backward([]*mat.Dense{mat.NewDense(batch, actions,
+		randomData(batch*actions))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sol.Step(net.Model()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each parameter should have been updated in place
+	beforeParams := before.params()
+	for i, p := range net.params() {
+		updated := false
+		for j, w := range p.data() {
+			updated = updated || w != beforeParams[i].data()[j]
+		}
+		if !updated {
+			t.Fatalf("parameter %v was not updated by the solver", i)
+		}
+	}
+}
+
+// benchmarkGorgoniaStep benchmarks a forward and backward pass of a
+// Gorgonia MLP with the given batch size
+func benchmarkGorgoniaStep(b *testing.B, batch int) {
+	net, err := network.NewMultiHeadMLP(features, batch, actions,
+		G.NewGraph(), hiddenSizes, biases, seededInit(),
+		[]*network.Activation{network.ReLU(), network.ReLU()})
+	if err != nil {
+		b.Fatal(err)
+	}
+	loss := G.Must(G.Mean(G.Must(G.Square(net.Prediction()[0]))))
+	if _, err := G.Grad(loss, net.Learnables()...); err != nil {
+		b.Fatal(err)
+	}
+	vm := G.NewTapeMachine(net.Graph(), G.BindDualValues(net.Learnables()...))
+	defer vm.Close()
+
+	x := randomData(batch * features)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		net.SetInput(x)
+		if err := vm.RunAll(); err != nil {
+			b.Fatal(err)
+		}
+		vm.Reset()
+	}
+}
+
+// benchmarkGonumStep benchmarks a forward and backward pass of a gonum
+// MLP with the given batch size
+func benchmarkGonumStep(b *testing.B, batch int) {
+	net, err := NewMLP(features, actions, hiddenSizes, biases,
+		[]*network.Activation{network.ReLU(), network.ReLU()}, seededInit())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	x := mat.NewDense(batch, features, randomData(batch*features))
+	grad := mat.NewDense(batch, actions, nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		output, err := net.Forward(x)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		// Gradient of the mean squared output
+		grad.Scale(2/float64(batch*actions), output[0])
+		if err := net.Backward([]*mat.Dense{grad}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkGorgoniaForward benchmarks a forward pass of a Gorgonia MLP
+// with the given batch size
+func benchmarkGorgoniaForward(b *testing.B, batch int) {
+	net, err := network.NewMultiHeadMLP(features, batch, actions,
+		G.NewGraph(), hiddenSizes, biases, seededInit(),
+		[]*network.Activation{network.ReLU(), network.ReLU()})
+	if err != nil {
+		b.Fatal(err)
+	}
+	vm := G.NewTapeMachine(net.Graph())
+	defer vm.Close()
+
+	x := randomData(batch * features)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		net.SetInput(x)
+		if err := vm.RunAll(); err != nil {
+			b.Fatal(err)
+		}
+		vm.Reset()
+	}
+}
+
+// benchmarkGonumForward benchmarks a forward pass of a gonum MLP with
+// the given batch size
+func benchmarkGonumForward(b *testing.B, batch int) {
+	net, err := NewMLP(features, actions, hiddenSizes, biases,
+		[]*network.Activation{network.ReLU(), network.ReLU()}, seededInit())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	x := mat.NewDense(batch, features, randomData(batch*features))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := net.Forward(x); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGorgoniaForward1(b *testing.B)  { benchmarkGorgoniaForward(b, 1) }
+func BenchmarkGonumForward1(b *testing.B)     { benchmarkGonumForward(b, 1) }
+func BenchmarkGorgoniaForward32(b *testing.B) { benchmarkGorgoniaForward(b, 32) }
+func BenchmarkGonumForward32(b *testing.B)    { benchmarkGonumForward(b, 32) }
+func BenchmarkGorgoniaStep32(b *testing.B)    { benchmarkGorgoniaStep(b, 32) }
+func BenchmarkGonumStep32(b *testing.B)       { benchmarkGonumStep(b, 32) }
+func BenchmarkGorgoniaStep256(b *testing.B)   { benchmarkGorgoniaStep(b, 256) }
+func BenchmarkGonumStep256(b *testing.B)      { benchmarkGonumStep(b, 256) }
@@ -0,0 +1,150 @@
+// Package gonumnet implements neural networks directly on gonum
+// matrices, with hand-written forward and backward passes.
+//
+// Gorgonia's computational graphs and VMs have a large per-call
+// overhead compared to the cost of the computations performed by the
+// small networks typically used on classic control problems.
+// Furthermore, a Gorgonia network has a fixed batch size, so separate
+// clones of each network (each with its own VM) are needed for action
+// selection and learning. The networks in this package have no such
+// overhead and accept inputs of any batch size.
+//
+// Networks in this package use the same activations
+// (network.Activation) and weight initializers (gorgonia.InitWFn) as
+// those in the network package, and their learnable parameters satisfy
+// the gorgonia.ValueGrad interface so that they can be updated by the
+// solvers in the solver package.
+package gonumnet
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+)
+
+// Net implements a neural network using gonum matrices.
+//
+// Inputs to a Net are matrices with one sample per row. A Net caches
+// the intermediate values of its most recent forward pass, which are
+// used to compute the gradients of its parameters in the backward
+// pass. Inputs to, and outputs of, the forward pass should therefore
+// not be modified before the backward pass.
+type Net interface {
+	Features() int     // Number of input features
+	Outputs() []int    // Number of outputs per output layer
+	OutputLayers() int // Layers that will output Outputs() values
+
+	// Forward performs the forward pass on the input x and returns the
+	// output of each output layer. The returned matrices are owned by
+	// the Net and are overwritten on the next forward pass.
+	Forward(x *mat.Dense) ([]*mat.Dense, error)
+
+	// Backward performs the backward pass given the gradient of some
+	// loss with respect to each output of the previous forward pass.
+	// The gradients of the Net's learnable parameters are overwritten
+	// with the computed gradients.
+	Backward(grads []*mat.Dense) error
+
+	// Model returns the learnable parameters of the network and their
+	// gradients, which can be stepped by a Gorgonia solver
+	Model() []G.ValueGrad
+
+	// Clone returns a copy of the Net
+	Clone() Net
+
+	params() []*param
+}
+
+// Set sets the weights of dest to be equal to the weights of source.
+func Set(dest, source Net) error {
+	destParams, sourceParams := dest.params(), source.params()
+	if err := compatible(destParams, sourceParams); err != nil {
+		return fmt.Errorf("set: %v", err)
+	}
+
+	for i := range destParams {
+		copy(destParams[i].data(), sourceParams[i].data())
+	}
+	return nil
+}
+
+// Polyak sets the weights of dest to the polyak average of the weights
+// of dest and source:
+//
+//	dest = (1 - tau) * dest + tau * source
+func Polyak(dest, source Net, tau float64) error {
+	destParams, sourceParams := dest.params(), source.params()
+	if err := compatible(destParams, sourceParams); err != nil {
+		return fmt.Errorf("polyak: %v", err)
+	}
+
+	for i := range destParams {
+		weights := destParams[i].data()
+		sourceWeights := sourceParams[i].data()
+		for j := range weights {
+			weights[j] = (1-tau)*weights[j] + tau*sourceWeights[j]
+		}
+	}
+	return nil
+}
+
+// Weights returns a copy of the weights of net, with one slice per
+// learnable parameter
+func Weights(net Net) [][]float64 {
+	params := net.params()
+	weights := make([][]float64, len(params))
+	for i := range params {
+		weights[i] = append([]float64{}, params[i].data()...)
+	}
+	return weights
+}
+
+// SetWeights sets the weights of net to weights, which should have
+// been returned by Weights for a Net with the same architecture.
+func SetWeights(net Net, weights [][]float64) error {
+	params := net.params()
+	if len(params) != len(weights) {
+		return fmt.Errorf("setWeights: invalid number of parameters "+
+			"\n\twant(%v) \n\thave(%v)", len(params), len(weights))
+	}
+
+	for i := range params {
+		data := params[i].data()
+		if len(data) != len(weights[i]) {
+			return fmt.Errorf("setWeights: parameter %d has invalid size "+
+				"\n\twant(%v) \n\thave(%v)", i, len(data), len(weights[i]))
+		}
+		copy(data, weights[i])
+	}
+	return nil
+}
+
+// compatible returns an error if the parameters of two networks do
+// not have the same shapes
+func compatible(dest, source []*param) error {
+	if len(dest) != len(source) {
+		return fmt.Errorf("networks have different numbers of parameters "+
+			"\n\twant(%v) \n\thave(%v)", len(dest), len(source))
+	}
+
+	for i := range dest {
+		destRows, destCols := dest[i].value.Dims()
+		sourceRows, sourceCols := source[i].value.Dims()
+		if destRows != sourceRows || destCols != sourceCols {
+			return fmt.Errorf("parameter %d has incompatible shape "+
+				"\n\twant(%v, %v) \n\thave(%v, %v)", i, destRows, destCols,
+				sourceRows, sourceCols)
+		}
+	}
+	return nil
+}
+
+// model returns params as a slice of gorgonia.ValueGrad
+func model(params []*param) []G.ValueGrad {
+	model := make([]G.ValueGrad, len(params))
+	for i := range params {
+		model[i] = params[i]
+	}
+	return model
+}
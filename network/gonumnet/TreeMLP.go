@@ -0,0 +1,180 @@
+package gonumnet
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/golearn/network"
+	"gonum.org/v1/gonum/mat"
+	G "gorgonia.org/gorgonia"
+)
+
+// TreeMLP implements a tree MLP, which has a single root network that
+// breaks off into a number of leaf networks. The output of the root
+// network is the input to each leaf network, and each leaf network
+// has its own output layer. This is the gonum counterpart of
+// network.TreeMLP.
+type TreeMLP struct {
+	root   *MLP
+	leaves []*MLP
+
+	rootGrad *mat.Dense
+}
+
+// NewTreeMLP returns a new tree MLP.
+//
+// The root network has number of layers equal to len(rootHiddenSizes),
+// which must be at least 1. For index i, rootHiddenSizes[i] determines
+// the number of hidden units in that layer, rootBiases[i] determines
+// if a bias unit is added to the hidden layer, and rootActivations[i]
+// determines the activation function to apply to that hidden layer.
+//
+// The number of leaf networks is defined by len(leafHiddenSizes). For
+// indices i and j, leafHiddenSizes[i][j], leafBiases[i][j], and
+// leafActivations[i][j] determine the number of hidden units of layer
+// j in leaf network i, whether a bias is added to layer j of leaf
+// network i, and the activation of layer j of leaf network i
+// respectively. For all leaf networks, a final linear layer with a
+// bias and no activation is added to ensure the output of each leaf
+// network has outputs units.
+//
+// Given the same arguments, NewTreeMLP constructs the same
+// architecture as network.NewTreeMLP.
+func NewTreeMLP(features, outputs int, rootHiddenSizes []int,
+	rootBiases []bool, rootActivations []*network.Activation,
+	leafHiddenSizes [][]int, leafBiases [][]bool,
+	leafActivations [][]*network.Activation,
+	init G.InitWFn) (*TreeMLP, error) {
+	if len(rootHiddenSizes) == 0 {
+		return nil, fmt.Errorf("newTreeMLP: root network must have at " +
+			"least one hidden layer")
+	}
+	if len(leafHiddenSizes) == 0 {
+		return nil, fmt.Errorf("newTreeMLP: there must be at least one " +
+			"leaf network specified")
+	}
+	if len(leafHiddenSizes) != len(leafBiases) ||
+		len(leafHiddenSizes) != len(leafActivations) {
+		return nil, fmt.Errorf("newTreeMLP: leaf sizes, biases, and " +
+			"activations must describe the same number of leaf networks")
+	}
+
+	root, err := newMLP(features, rootHiddenSizes, rootBiases,
+		rootActivations, init)
+	if err != nil {
+		return nil, fmt.Errorf("newTreeMLP: could not construct root "+
+			"network: %v", err)
+	}
+
+	leaves := make([]*MLP, len(leafHiddenSizes))
+	for i := range leafHiddenSizes {
+		leaves[i], err = NewMLP(root.outputs, outputs, leafHiddenSizes[i],
+			leafBiases[i], leafActivations[i], init)
+		if err != nil {
+			return nil, fmt.Errorf("newTreeMLP: could not construct leaf "+
+				"network %d: %v", i, err)
+		}
+	}
+
+	return &TreeMLP{
+		root:   root,
+		leaves: leaves,
+	}, nil
+}
+
+// Features returns the number of input features of the TreeMLP
+func (t *TreeMLP) Features() int {
+	return t.root.features
+}
+
+// Outputs returns the number of outputs of each leaf network
+func (t *TreeMLP) Outputs() []int {
+	outputs := make([]int, len(t.leaves))
+	for i := range t.leaves {
+		outputs[i] = t.leaves[i].outputs
+	}
+	return outputs
+}
+
+// OutputLayers returns the number of leaf networks
+func (t *TreeMLP) OutputLayers() int {
+	return len(t.leaves)
+}
+
+// Forward performs the forward pass on the input x and returns the
+// output of each leaf network
+func (t *TreeMLP) Forward(x *mat.Dense) ([]*mat.Dense, error) {
+	rootOutput, err := t.root.fwd(x)
+	if err != nil {
+		return nil, fmt.Errorf("forward: %v", err)
+	}
+
+	outputs := make([]*mat.Dense, len(t.leaves))
+	for i := range t.leaves {
+		if outputs[i], err = t.leaves[i].fwd(rootOutput); err != nil {
+			return nil, fmt.Errorf("forward: leaf network %d: %v", i, err)
+		}
+	}
+	return outputs, nil
+}
+
+// Backward performs the backward pass given the gradient of some loss
+// with respect to the output of each leaf network on the previous
+// forward pass
+func (t *TreeMLP) Backward(grads []*mat.Dense) error {
+	if len(grads) != len(t.leaves) {
+		return fmt.Errorf("backward: invalid number of gradients "+
+			"\n\twant(%v) \n\thave(%v)", len(t.leaves), len(grads))
+	}
+
+	// The gradient with respect to the output of the root network is
+	// the sum of the gradients with respect to the leaf network inputs
+	for i := range t.leaves {
+		grad, err := t.leaves[i].bwd(grads[i], true)
+		if err != nil {
+			return fmt.Errorf("backward: leaf network %d: %v", i, err)
+		}
+
+		if i == 0 {
+			rows, cols := grad.Dims()
+			if t.rootGrad == nil || t.rootGrad.RawMatrix().Rows != rows {
+				t.rootGrad = mat.NewDense(rows, cols, nil)
+			}
+			t.rootGrad.Copy(grad)
+		} else {
+			t.rootGrad.Add(t.rootGrad, grad)
+		}
+	}
+
+	if _, err := t.root.bwd(t.rootGrad, false); err != nil {
+		return fmt.Errorf("backward: root network: %v", err)
+	}
+	return nil
+}
+
+// Model returns the learnable parameters of the TreeMLP and their
+// gradients
+func (t *TreeMLP) Model() []G.ValueGrad {
+	return model(t.params())
+}
+
+// params returns the learnable parameters of the TreeMLP
+func (t *TreeMLP) params() []*param {
+	params := t.root.params()
+	for _, leaf := range t.leaves {
+		params = append(params, leaf.params()...)
+	}
+	return params
+}
+
+// Clone returns a copy of the TreeMLP
+func (t *TreeMLP) Clone() Net {
+	leaves := make([]*MLP, len(t.leaves))
+	for i := range t.leaves {
+		leaves[i] = t.leaves[i].clone()
+	}
+
+	return &TreeMLP{
+		root:   t.root.clone(),
+		leaves: leaves,
+	}
+}
@@ -0,0 +1,263 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the ONNX protobuf messages
+const (
+	modelIRVersion       protowire.Number = 1
+	modelProducerName    protowire.Number = 2
+	modelProducerVersion protowire.Number = 3
+	modelDocString       protowire.Number = 6
+	modelGraph           protowire.Number = 7
+	modelOpsetImport     protowire.Number = 8
+	modelMetadataProps   protowire.Number = 14
+
+	opsetDomain  protowire.Number = 1
+	opsetVersion protowire.Number = 2
+
+	entryKey   protowire.Number = 1
+	entryValue protowire.Number = 2
+
+	graphNode        protowire.Number = 1
+	graphName        protowire.Number = 2
+	graphInitializer protowire.Number = 5
+	graphInput       protowire.Number = 11
+	graphOutput      protowire.Number = 12
+
+	nodeInput     protowire.Number = 1
+	nodeOutput    protowire.Number = 2
+	nodeName      protowire.Number = 3
+	nodeOpType    protowire.Number = 4
+	nodeAttribute protowire.Number = 5
+
+	attributeName protowire.Number = 1
+	attributeF    protowire.Number = 2
+	attributeI    protowire.Number = 3
+	attributeInts protowire.Number = 8
+	attributeType protowire.Number = 20
+
+	tensorDims       protowire.Number = 1
+	tensorDataType   protowire.Number = 2
+	tensorFloatData  protowire.Number = 4
+	tensorName       protowire.Number = 8
+	tensorRawData    protowire.Number = 9
+	tensorDoubleData protowire.Number = 10
+
+	valueInfoName protowire.Number = 1
+	valueInfoType protowire.Number = 2
+
+	typeTensorType protowire.Number = 1
+
+	tensorTypeElemType protowire.Number = 1
+	tensorTypeShape    protowire.Number = 2
+
+	shapeDim protowire.Number = 1
+
+	dimValue protowire.Number = 1
+	dimParam protowire.Number = 2
+)
+
+// Marshal returns the protobuf encoding of the Model
+func (m *Model) Marshal() ([]byte, error) {
+	if m.Graph == nil {
+		return nil, fmt.Errorf("marshal: model has no graph")
+	}
+
+	var b []byte
+	b = appendVarint(b, modelIRVersion, uint64(m.IRVersion))
+	b = appendString(b, modelProducerName, m.ProducerName)
+	b = appendString(b, modelProducerVersion, m.ProducerVersion)
+	b = appendString(b, modelDocString, m.DocString)
+
+	graph, err := m.Graph.marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %v", err)
+	}
+	b = appendMessage(b, modelGraph, graph)
+
+	for _, opset := range m.OpsetImport {
+		var o []byte
+		o = appendString(o, opsetDomain, opset.Domain)
+		o = appendVarint(o, opsetVersion, uint64(opset.Version))
+		b = appendMessage(b, modelOpsetImport, o)
+	}
+
+	// Sort metadata so that encoding is deterministic
+	keys := make([]string, 0, len(m.MetadataProps))
+	for key := range m.MetadataProps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var e []byte
+		e = appendString(e, entryKey, key)
+		e = appendString(e, entryValue, m.MetadataProps[key])
+		b = appendMessage(b, modelMetadataProps, e)
+	}
+
+	return b, nil
+}
+
+// marshal returns the protobuf encoding of the Graph
+func (g *Graph) marshal() ([]byte, error) {
+	var b []byte
+	for _, node := range g.Nodes {
+		b = appendMessage(b, graphNode, node.marshal())
+	}
+	b = appendString(b, graphName, g.Name)
+
+	for _, tensor := range g.Initializer {
+		t, err := tensor.marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, graphInitializer, t)
+	}
+
+	for _, input := range g.Inputs {
+		b = appendMessage(b, graphInput, input.marshal())
+	}
+	for _, output := range g.Outputs {
+		b = appendMessage(b, graphOutput, output.marshal())
+	}
+
+	return b, nil
+}
+
+// marshal returns the protobuf encoding of the Node
+func (n *Node) marshal() []byte {
+	var b []byte
+	for _, input := range n.Inputs {
+		b = protowire.AppendTag(b, nodeInput, protowire.BytesType)
+		b = protowire.AppendString(b, input)
+	}
+	for _, output := range n.Outputs {
+		b = protowire.AppendTag(b, nodeOutput, protowire.BytesType)
+		b = protowire.AppendString(b, output)
+	}
+	b = appendString(b, nodeName, n.Name)
+	b = appendString(b, nodeOpType, n.OpType)
+
+	for _, attr := range n.Attributes {
+		b = appendMessage(b, nodeAttribute, attr.marshal())
+	}
+
+	return b
+}
+
+// marshal returns the protobuf encoding of the Attribute
+func (a *Attribute) marshal() []byte {
+	var b []byte
+	b = appendString(b, attributeName, a.Name)
+
+	switch a.Type {
+	case AttributeFloat:
+		b = protowire.AppendTag(b, attributeF, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, math.Float32bits(a.F))
+
+	case AttributeInt:
+		b = protowire.AppendTag(b, attributeI, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(a.I))
+
+	case AttributeInts:
+		for _, i := range a.Ints {
+			b = protowire.AppendTag(b, attributeInts, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(i))
+		}
+	}
+
+	return appendVarint(b, attributeType, uint64(a.Type))
+}
+
+// marshal returns the protobuf encoding of the Tensor. Data is stored
+// as little endian raw data.
+func (t *Tensor) marshal() ([]byte, error) {
+	var b []byte
+	for _, dim := range t.Dims {
+		b = protowire.AppendTag(b, tensorDims, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(dim))
+	}
+	b = appendVarint(b, tensorDataType, uint64(t.DataType))
+	b = appendString(b, tensorName, t.Name)
+
+	var raw []byte
+	switch t.DataType {
+	case Float:
+		raw = make([]byte, 4*len(t.Data))
+		for i, v := range t.Data {
+			binary.LittleEndian.PutUint32(raw[4*i:],
+				math.Float32bits(float32(v)))
+		}
+
+	case Double:
+		raw = make([]byte, 8*len(t.Data))
+		for i, v := range t.Data {
+			binary.LittleEndian.PutUint64(raw[8*i:], math.Float64bits(v))
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported data type %v for tensor %v",
+			t.DataType, t.Name)
+	}
+	b = protowire.AppendTag(b, tensorRawData, protowire.BytesType)
+	b = protowire.AppendBytes(b, raw)
+
+	return b, nil
+}
+
+// marshal returns the protobuf encoding of the ValueInfo
+func (v *ValueInfo) marshal() []byte {
+	var shape []byte
+	for _, dim := range v.Shape {
+		var d []byte
+		if dim.Param != "" {
+			d = appendString(d, dimParam, dim.Param)
+		} else {
+			d = protowire.AppendTag(d, dimValue, protowire.VarintType)
+			d = protowire.AppendVarint(d, uint64(dim.Value))
+		}
+		shape = appendMessage(shape, shapeDim, d)
+	}
+
+	var tensorType []byte
+	tensorType = appendVarint(tensorType, tensorTypeElemType,
+		uint64(v.ElemType))
+	tensorType = appendMessage(tensorType, tensorTypeShape, shape)
+
+	var b []byte
+	b = appendString(b, valueInfoName, v.Name)
+	typ := appendMessage(nil, typeTensorType, tensorType)
+	return appendMessage(b, valueInfoType, typ)
+}
+
+// appendVarint appends field num with value v to b, if v is not the
+// zero value
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendString appends field num with value s to b, if s is not empty
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendMessage appends field num holding the encoded message m to b
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
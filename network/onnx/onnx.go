@@ -0,0 +1,198 @@
+// Package onnx implements reading and writing of the subset of the ONNX
+// protobuf format needed to describe feedforward neural networks. Only
+// the messages and fields used by GoLearn are implemented, and any
+// other fields are skipped when reading a file.
+//
+// See https://github.com/onnx/onnx/blob/main/onnx/onnx.proto for the
+// full specification of the format.
+package onnx
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	// IRVersion is the ONNX IR version of models written by this
+	// package
+	IRVersion int64 = 7
+
+	// OpsetVersion is the version of the default ONNX operator set
+	// used by models written by this package
+	OpsetVersion int64 = 13
+)
+
+// DataType is the element type of a Tensor
+type DataType int32
+
+// Supported element types
+const (
+	Float  DataType = 1
+	Double DataType = 11
+)
+
+// AttributeType is the type of the value held by an Attribute
+type AttributeType int32
+
+// Supported attribute types
+const (
+	AttributeFloat AttributeType = 1
+	AttributeInt   AttributeType = 2
+	AttributeInts  AttributeType = 7
+)
+
+// Model is an ONNX ModelProto
+type Model struct {
+	IRVersion       int64
+	OpsetImport     []OperatorSetID
+	ProducerName    string
+	ProducerVersion string
+	DocString       string
+	Graph           *Graph
+	MetadataProps   map[string]string
+}
+
+// NewModel returns a new Model holding graph g which uses the default
+// ONNX operator set
+func NewModel(g *Graph) *Model {
+	return &Model{
+		IRVersion:     IRVersion,
+		OpsetImport:   []OperatorSetID{{Version: OpsetVersion}},
+		ProducerName:  "golearn",
+		Graph:         g,
+		MetadataProps: make(map[string]string),
+	}
+}
+
+// OperatorSetID is an ONNX OperatorSetIdProto. An empty domain refers
+// to the default ONNX operator set.
+type OperatorSetID struct {
+	Domain  string
+	Version int64
+}
+
+// Graph is an ONNX GraphProto
+type Graph struct {
+	Name        string
+	Nodes       []*Node
+	Initializer []*Tensor
+	Inputs      []*ValueInfo
+	Outputs     []*ValueInfo
+}
+
+// InitializerByName returns the initializer of the Graph with the given name
+// and whether such an initializer exists
+func (g *Graph) InitializerByName(name string) (*Tensor, bool) {
+	for _, t := range g.Initializer {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Node is an ONNX NodeProto, which computes its outputs by applying an
+// operator to its inputs
+type Node struct {
+	Name       string
+	OpType     string
+	Inputs     []string
+	Outputs    []string
+	Attributes []*Attribute
+}
+
+// Attribute returns the attribute of the Node with the given name and
+// whether such an attribute exists
+func (n *Node) Attribute(name string) (*Attribute, bool) {
+	for _, a := range n.Attributes {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// Attribute is an ONNX AttributeProto. Only the field corresponding
+// to the Type of the Attribute is used.
+type Attribute struct {
+	Name string
+	Type AttributeType
+	F    float32
+	I    int64
+	Ints []int64
+}
+
+// FloatAttribute returns a new float Attribute
+func FloatAttribute(name string, f float32) *Attribute {
+	return &Attribute{Name: name, Type: AttributeFloat, F: f}
+}
+
+// IntAttribute returns a new integer Attribute
+func IntAttribute(name string, i int64) *Attribute {
+	return &Attribute{Name: name, Type: AttributeInt, I: i}
+}
+
+// IntsAttribute returns a new integer list Attribute
+func IntsAttribute(name string, ints ...int64) *Attribute {
+	return &Attribute{Name: name, Type: AttributeInts, Ints: ints}
+}
+
+// Tensor is an ONNX TensorProto. Regardless of the DataType of the
+// Tensor, its data is stored as float64s in row major order.
+type Tensor struct {
+	Name     string
+	Dims     []int64
+	DataType DataType
+	Data     []float64
+}
+
+// Shape returns the dimensions of the Tensor
+func (t *Tensor) Shape() []int {
+	shape := make([]int, len(t.Dims))
+	for i, dim := range t.Dims {
+		shape[i] = int(dim)
+	}
+	return shape
+}
+
+// ValueInfo is an ONNX ValueInfoProto describing a tensor input to or
+// output from a Graph
+type ValueInfo struct {
+	Name     string
+	ElemType DataType
+	Shape    []Dim
+}
+
+// Dim is a dimension of a ValueInfo. If Param is not empty, then the
+// dimension is symbolic and Value is ignored.
+type Dim struct {
+	Value int64
+	Param string
+}
+
+// Save writes the Model to an ONNX file at path
+func Save(m *Model, path string) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return fmt.Errorf("save: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("save: could not write file: %v", err)
+	}
+	return nil
+}
+
+// Load reads a Model from the ONNX file at path
+func Load(path string) (*Model, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load: could not read file: %v", err)
+	}
+
+	m := &Model{}
+	if err := m.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("load: %v", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,367 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"golang.org/x/exp/rand"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// newTestModel returns a Model which uses each message and field
+// implemented by the package
+func newTestModel() *Model {
+	m := NewModel(&Graph{
+		Name: "graph",
+		Nodes: []*Node{
+			{
+				Name:    "gemm",
+				OpType:  "Gemm",
+				Inputs:  []string{"input", "weights", "bias"},
+				Outputs: []string{"output"},
+				Attributes: []*Attribute{
+					FloatAttribute("alpha", -0.5),
+					IntAttribute("transB", -1),
+					IntsAttribute("perm", 0, 1, -1, math.MaxInt64,
+						math.MinInt64),
+				},
+			},
+		},
+		Initializer: []*Tensor{
+			{Name: "weights", Dims: []int64{2, 3}, DataType: Float,
+				Data: []float64{1, -2, 0.5, 0, math.Inf(1), -0.25}},
+			{Name: "bias", Dims: []int64{3}, DataType: Double,
+				Data: []float64{math.Pi, -math.MaxFloat64,
+					math.SmallestNonzeroFloat64}},
+			{Name: "scalar", Dims: []int64{}, DataType: Double,
+				Data: []float64{1}},
+			{Name: "empty", Dims: []int64{0, 3}, DataType: Float,
+				Data: []float64{}},
+		},
+		Inputs: []*ValueInfo{
+			{Name: "input", ElemType: Double,
+				Shape: []Dim{{Param: "batch"}, {Value: 2}}},
+		},
+		Outputs: []*ValueInfo{
+			{Name: "output", ElemType: Double,
+				Shape: []Dim{{Value: math.MaxInt64}, {Value: -1}}},
+		},
+	})
+	m.IRVersion = math.MinInt64
+	m.ProducerVersion = "1.0"
+	m.DocString = "test model"
+	m.OpsetImport = append(m.OpsetImport, OperatorSetID{
+		Domain:  "ai.onnx.ml",
+		Version: 1 << 40,
+	})
+	m.MetadataProps["key"] = "value"
+	m.MetadataProps[""] = ""
+
+	return m
+}
+
+// TestMarshalRoundTrip tests that an encoded Model decodes to the
+// original Model, including negative and maximal varints
+func TestMarshalRoundTrip(t *testing.T) {
+	want := newTestModel()
+	b, err := want.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have := &Model{}
+	if err := have.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+
+	// Scalar tensors decode to nil dimensions
+	want.Graph.Initializer[2].Dims = nil
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("round trip: \n\thave(%+v) \n\twant(%+v)", have, want)
+	}
+
+	if _, err := (&Model{}).Marshal(); err == nil {
+		t.Error("expected an error for a model without a graph")
+	}
+	bad := newTestModel()
+	bad.Graph.Initializer[0].DataType = 7
+	if _, err := bad.Marshal(); err == nil {
+		t.Error("expected an error for an unsupported data type")
+	}
+}
+
+// TestAppendVarint tests that zero valued varints are omitted and that
+// all other varints are encoded in the fewest bytes
+func TestAppendVarint(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		want int // Length of the encoded value
+	}{
+		{0, 0},
+		{1, 1},
+		{127, 1},
+		{128, 2},
+		{1<<14 - 1, 2},
+		{1 << 14, 3},
+		{1 << 63, 10},
+		{math.MaxUint64, 10},
+	}
+
+	for _, test := range tests {
+		b := appendVarint(nil, 1, test.v)
+		if test.want == 0 {
+			if len(b) != 0 {
+				t.Errorf("%v: have(%v) want(omitted)", test.v, b)
+			}
+			continue
+		}
+
+		if len(b) != test.want+1 {
+			t.Errorf("%v: length: have(%v) want(%v)", test.v, len(b)-1,
+				test.want)
+		}
+		num, typ, n := protowire.ConsumeTag(b)
+		if num != 1 || typ != protowire.VarintType || n != 1 {
+			t.Errorf("%v: tag: have(%v, %v) want(1, %v)", test.v, num, typ,
+				protowire.VarintType)
+			continue
+		}
+		if v, _ := protowire.ConsumeVarint(b[n:]); v != test.v {
+			t.Errorf("%v: decoded: have(%v) want(%v)", test.v, v, test.v)
+		}
+	}
+}
+
+// TestPackedFields tests that repeated fields are decoded whether they
+// are packed, unpacked, or both
+func TestPackedFields(t *testing.T) {
+	ints := []int64{0, 1, 300, -1, math.MaxInt64, math.MinInt64}
+	floats := []float64{0, 1.5, -2, math.Inf(-1)}
+
+	var packedInts []byte
+	for _, i := range ints {
+		packedInts = protowire.AppendVarint(packedInts, uint64(i))
+	}
+	packedFloats := make([]byte, 4*len(floats))
+	packedDoubles := make([]byte, 8*len(floats))
+	for i, f := range floats {
+		binary.LittleEndian.PutUint32(packedFloats[4*i:],
+			math.Float32bits(float32(f)))
+		binary.LittleEndian.PutUint64(packedDoubles[8*i:],
+			math.Float64bits(f))
+	}
+
+	tests := []struct {
+		name string
+		enc  func([]byte) []byte
+		want []int64
+	}{
+		{
+			name: "Packed",
+			enc: func(b []byte) []byte {
+				b = protowire.AppendTag(b, attributeInts, protowire.BytesType)
+				return protowire.AppendBytes(b, packedInts)
+			},
+			want: ints,
+		},
+		{
+			name: "Unpacked",
+			enc: func(b []byte) []byte {
+				for _, i := range ints {
+					b = protowire.AppendTag(b, attributeInts,
+						protowire.VarintType)
+					b = protowire.AppendVarint(b, uint64(i))
+				}
+				return b
+			},
+			want: ints,
+		},
+		{
+			name: "Mixed",
+			enc: func(b []byte) []byte {
+				b = protowire.AppendTag(b, attributeInts, protowire.VarintType)
+				b = protowire.AppendVarint(b, 7)
+				b = protowire.AppendTag(b, attributeInts, protowire.BytesType)
+				return protowire.AppendBytes(b, packedInts)
+			},
+			want: append([]int64{7}, ints...),
+		},
+		{
+			name: "EmptyPacked",
+			enc: func(b []byte) []byte {
+				b = protowire.AppendTag(b, attributeInts, protowire.BytesType)
+				return protowire.AppendBytes(b, nil)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		a := &Attribute{}
+		if err := a.unmarshal(test.enc(nil)); err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if len(a.Ints) != len(test.want) ||
+			(len(a.Ints) > 0 && !reflect.DeepEqual(a.Ints, test.want)) {
+			t.Errorf("%v: have(%v) want(%v)", test.name, a.Ints, test.want)
+		}
+	}
+
+	// Typed tensor data, with one element of each type unpacked
+	for _, dataType := range []DataType{Float, Double} {
+		var b []byte
+		b = appendVarint(b, tensorDataType, uint64(dataType))
+		b = protowire.AppendTag(b, tensorDims, protowire.BytesType)
+		b = protowire.AppendBytes(b, protowire.AppendVarint(nil,
+			uint64(len(floats)+1)))
+		if dataType == Float {
+			b = protowire.AppendTag(b, tensorFloatData, protowire.BytesType)
+			b = protowire.AppendBytes(b, packedFloats)
+			b = protowire.AppendTag(b, tensorFloatData, protowire.Fixed32Type)
+			b = protowire.AppendFixed32(b, math.Float32bits(0.25))
+		} else {
+			b = protowire.AppendTag(b, tensorDoubleData, protowire.BytesType)
+			b = protowire.AppendBytes(b, packedDoubles)
+			b = protowire.AppendTag(b, tensorDoubleData, protowire.Fixed64Type)
+			b = protowire.AppendFixed64(b, math.Float64bits(0.25))
+		}
+
+		tensor := &Tensor{}
+		if err := tensor.unmarshal(b); err != nil {
+			t.Errorf("%v: %v", dataType, err)
+			continue
+		}
+		want := append(append([]float64{}, floats...), 0.25)
+		if !reflect.DeepEqual(tensor.Data, want) {
+			t.Errorf("%v: data: have(%v) want(%v)", dataType, tensor.Data, want)
+		}
+		if !reflect.DeepEqual(tensor.Dims, []int64{int64(len(want))}) {
+			t.Errorf("%v: dims: have(%v) want(%v)", dataType, tensor.Dims,
+				[]int64{int64(len(want))})
+		}
+	}
+}
+
+// TestUnmarshalMalformed tests that malformed encodings return an error
+func TestUnmarshalMalformed(t *testing.T) {
+	// graph returns an encoded Model holding the encoded Graph g
+	graph := func(g []byte) []byte {
+		return appendMessage(nil, modelGraph, g)
+	}
+
+	// tensor returns an encoded Model holding the encoded Tensor b
+	tensor := func(b []byte) []byte {
+		return graph(appendMessage(nil, graphInitializer, b))
+	}
+
+	// attribute returns an encoded Model holding the encoded Attribute b
+	attribute := func(b []byte) []byte {
+		return graph(appendMessage(nil, graphNode,
+			appendMessage(nil, nodeAttribute, b)))
+	}
+
+	floatTensor := appendVarint(nil, tensorDataType, uint64(Float))
+	doubleTensor := appendVarint(nil, tensorDataType, uint64(Double))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Empty", nil},
+		{"NoGraph", appendString(nil, modelProducerName, "golearn")},
+		{"TruncatedTag", []byte{0x80}},
+		{"ZeroFieldNumber", []byte{0x00, 0x01}},
+		{"EndGroup", protowire.AppendTag(nil, 1, protowire.EndGroupType)},
+		{"TruncatedVarint", []byte{byte(modelIRVersion << 3), 0xff, 0xff}},
+		{"OverlongVarint", append([]byte{byte(modelIRVersion << 3)},
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01)},
+		{"OverlongLength", append(protowire.AppendTag(nil, modelGraph,
+			protowire.BytesType), 0x05, 0x00)},
+		{"TruncatedFixed32", attribute(append(protowire.AppendTag(nil,
+			attributeF, protowire.Fixed32Type), 0x00, 0x00))},
+		{"TruncatedNode", graph(append(protowire.AppendTag(nil, graphNode,
+			protowire.BytesType), 0x02, byte(nodeName<<3|2)))},
+		{"TruncatedPackedInts", attribute(appendMessage(nil, attributeInts,
+			[]byte{0x01, 0x80}))},
+		{"OverlongPackedInts", attribute(append(protowire.AppendTag(nil,
+			attributeInts, protowire.BytesType), 0x03, 0x01))},
+		{"PackedFloats", tensor(appendMessage(floatTensor, tensorFloatData,
+			make([]byte, 5)))},
+		{"PackedDoubles", tensor(appendMessage(doubleTensor,
+			tensorDoubleData, make([]byte, 12)))},
+		{"RawFloats", tensor(appendMessage(floatTensor, tensorRawData,
+			make([]byte, 6)))},
+		{"RawDoubles", tensor(appendMessage(doubleTensor, tensorRawData,
+			make([]byte, 4)))},
+		{"RawDataType", tensor(appendMessage(nil, tensorRawData,
+			make([]byte, 8)))},
+		{"TooFewElements", tensor(appendMessage(appendVarint(floatTensor,
+			tensorDims, 3), tensorRawData, make([]byte, 8)))},
+		{"TooManyElements", tensor(appendMessage(doubleTensor,
+			tensorFloatData, make([]byte, 8)))},
+		{"NegativeDim", tensor(appendVarint(doubleTensor, tensorDims,
+			math.MaxUint64))},
+		{"DimOverflow", tensor(appendVarint(appendVarint(doubleTensor,
+			tensorDims, 1<<32), tensorDims, 1<<32))},
+		{"TruncatedDim", graph(appendMessage(nil, graphInput, appendMessage(
+			nil, valueInfoType, appendMessage(nil, typeTensorType,
+				appendMessage(nil, tensorTypeShape, appendMessage(nil, shapeDim,
+					[]byte{byte(dimValue << 3)}))))))},
+	}
+
+	for _, test := range tests {
+		m := &Model{}
+		if err := m.Unmarshal(test.data); err == nil {
+			t.Errorf("%v: expected an error", test.name)
+		}
+	}
+}
+
+// TestUnmarshalCorrupted tests that truncated and corrupted encodings
+// of a Model are decoded without panicking
+func TestUnmarshalCorrupted(t *testing.T) {
+	b, err := newTestModel().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Find the offsets at which top-level fields end, and the end of the
+	// graph field
+	ends := make(map[int]bool)
+	graphEnd := -1
+	for i := 0; i < len(b); {
+		num, typ, n := protowire.ConsumeTag(b[i:])
+		i += n + protowire.ConsumeFieldValue(num, typ, b[i+n:])
+		ends[i] = true
+		if num == modelGraph {
+			graphEnd = i
+		}
+	}
+
+	// Truncated encodings are only valid if they end at a top-level
+	// field after the graph
+	for i := 0; i < len(b); i++ {
+		m := &Model{}
+		err := m.Unmarshal(b[:i])
+		if valid := ends[i] && i >= graphEnd; valid != (err == nil) {
+			t.Errorf("truncated to %v bytes: error: have(%v) want(%v)", i,
+				err, !valid)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		corrupted := append([]byte{}, b...)
+		for j := rng.Intn(4); j >= 0; j-- {
+			corrupted[rng.Intn(len(corrupted))] = byte(rng.Intn(256))
+		}
+		m := &Model{}
+		m.Unmarshal(corrupted)
+
+		garbage := make([]byte, rng.Intn(64))
+		rng.Read(garbage)
+		m.Unmarshal(garbage)
+	}
+}
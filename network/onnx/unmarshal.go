@@ -0,0 +1,504 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Unmarshal decodes the protobuf encoding of a Model from b into the
+// receiver
+func (m *Model) Unmarshal(b []byte) error {
+	*m = Model{MetadataProps: make(map[string]string)}
+
+	err := fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		switch {
+		case num == modelIRVersion && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			m.IRVersion = int64(v)
+			return n, nil
+
+		case num == modelProducerName && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			m.ProducerName = v
+			return n, nil
+
+		case num == modelProducerVersion && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			m.ProducerVersion = v
+			return n, nil
+
+		case num == modelDocString && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			m.DocString = v
+			return n, nil
+
+		case num == modelGraph && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			m.Graph = &Graph{}
+			return n, m.Graph.unmarshal(v)
+
+		case num == modelOpsetImport && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			opset, err := unmarshalOpset(v)
+			m.OpsetImport = append(m.OpsetImport, opset)
+			return n, err
+
+		case num == modelMetadataProps && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			key, value, err := unmarshalEntry(v)
+			m.MetadataProps[key] = value
+			return n, err
+		}
+		return 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("unmarshal: %v", err)
+	}
+
+	if m.Graph == nil {
+		return fmt.Errorf("unmarshal: model has no graph")
+	}
+	return nil
+}
+
+// unmarshalOpset decodes an OperatorSetIdProto
+func unmarshalOpset(b []byte) (OperatorSetID, error) {
+	var opset OperatorSetID
+	err := fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		switch {
+		case num == opsetDomain && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			opset.Domain = v
+			return n, nil
+
+		case num == opsetVersion && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			opset.Version = int64(v)
+			return n, nil
+		}
+		return 0, nil
+	})
+	return opset, err
+}
+
+// unmarshalEntry decodes a StringStringEntryProto
+func unmarshalEntry(b []byte) (string, string, error) {
+	var key, value string
+	err := fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		if typ != protowire.BytesType {
+			return 0, nil
+		}
+
+		switch num {
+		case entryKey:
+			v, n := protowire.ConsumeString(b)
+			key = v
+			return n, nil
+
+		case entryValue:
+			v, n := protowire.ConsumeString(b)
+			value = v
+			return n, nil
+		}
+		return 0, nil
+	})
+	return key, value, err
+}
+
+// unmarshal decodes a GraphProto into the receiver
+func (g *Graph) unmarshal(b []byte) error {
+	return fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		if typ != protowire.BytesType {
+			return 0, nil
+		}
+
+		switch num {
+		case graphNode:
+			v, n := protowire.ConsumeBytes(b)
+			node := &Node{}
+			g.Nodes = append(g.Nodes, node)
+			return n, node.unmarshal(v)
+
+		case graphName:
+			v, n := protowire.ConsumeString(b)
+			g.Name = v
+			return n, nil
+
+		case graphInitializer:
+			v, n := protowire.ConsumeBytes(b)
+			tensor := &Tensor{}
+			g.Initializer = append(g.Initializer, tensor)
+			return n, tensor.unmarshal(v)
+
+		case graphInput:
+			v, n := protowire.ConsumeBytes(b)
+			input := &ValueInfo{}
+			g.Inputs = append(g.Inputs, input)
+			return n, input.unmarshal(v)
+
+		case graphOutput:
+			v, n := protowire.ConsumeBytes(b)
+			output := &ValueInfo{}
+			g.Outputs = append(g.Outputs, output)
+			return n, output.unmarshal(v)
+		}
+		return 0, nil
+	})
+}
+
+// unmarshal decodes a NodeProto into the receiver
+func (node *Node) unmarshal(b []byte) error {
+	return fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		if typ != protowire.BytesType {
+			return 0, nil
+		}
+
+		switch num {
+		case nodeInput:
+			v, n := protowire.ConsumeString(b)
+			node.Inputs = append(node.Inputs, v)
+			return n, nil
+
+		case nodeOutput:
+			v, n := protowire.ConsumeString(b)
+			node.Outputs = append(node.Outputs, v)
+			return n, nil
+
+		case nodeName:
+			v, n := protowire.ConsumeString(b)
+			node.Name = v
+			return n, nil
+
+		case nodeOpType:
+			v, n := protowire.ConsumeString(b)
+			node.OpType = v
+			return n, nil
+
+		case nodeAttribute:
+			v, n := protowire.ConsumeBytes(b)
+			attr := &Attribute{}
+			node.Attributes = append(node.Attributes, attr)
+			return n, attr.unmarshal(v)
+		}
+		return 0, nil
+	})
+}
+
+// unmarshal decodes an AttributeProto into the receiver
+func (a *Attribute) unmarshal(b []byte) error {
+	return fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		switch {
+		case num == attributeName && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			a.Name = v
+			return n, nil
+
+		case num == attributeF && typ == protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(b)
+			a.F = math.Float32frombits(v)
+			return n, nil
+
+		case num == attributeI && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			a.I = int64(v)
+			return n, nil
+
+		case num == attributeInts:
+			return consumeInts(typ, b, &a.Ints)
+
+		case num == attributeType && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			a.Type = AttributeType(v)
+			return n, nil
+		}
+		return 0, nil
+	})
+}
+
+// unmarshal decodes a TensorProto into the receiver. The data of the
+// tensor may be stored in either its raw data or its typed data field.
+func (t *Tensor) unmarshal(b []byte) error {
+	var raw []byte
+	var floats []float64
+
+	err := fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		switch {
+		case num == tensorDims:
+			return consumeInts(typ, b, &t.Dims)
+
+		case num == tensorDataType && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			t.DataType = DataType(v)
+			return n, nil
+
+		case num == tensorName && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			t.Name = v
+			return n, nil
+
+		case num == tensorRawData && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			raw = v
+			return n, nil
+
+		case num == tensorFloatData:
+			return consumeFloats(typ, b, &floats)
+
+		case num == tensorDoubleData:
+			return consumeDoubles(typ, b, &floats)
+		}
+		return 0, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if raw == nil {
+		t.Data = floats
+		return t.checkSize()
+	}
+
+	switch t.DataType {
+	case Float:
+		if len(raw)%4 != 0 {
+			return fmt.Errorf("invalid raw data length for tensor %v", t.Name)
+		}
+		t.Data = make([]float64, len(raw)/4)
+		for i := range t.Data {
+			bits := binary.LittleEndian.Uint32(raw[4*i:])
+			t.Data[i] = float64(math.Float32frombits(bits))
+		}
+
+	case Double:
+		if len(raw)%8 != 0 {
+			return fmt.Errorf("invalid raw data length for tensor %v", t.Name)
+		}
+		t.Data = make([]float64, len(raw)/8)
+		for i := range t.Data {
+			bits := binary.LittleEndian.Uint64(raw[8*i:])
+			t.Data[i] = math.Float64frombits(bits)
+		}
+
+	default:
+		return fmt.Errorf("unsupported data type %v for tensor %v",
+			t.DataType, t.Name)
+	}
+
+	return t.checkSize()
+}
+
+// checkSize returns an error if the dimensions of the Tensor are
+// negative or do not match the number of elements in its data
+func (t *Tensor) checkSize() error {
+	size := int64(1)
+	for _, dim := range t.Dims {
+		if dim < 0 || (dim > 0 && size > math.MaxInt64/dim) {
+			return fmt.Errorf("invalid dimensions %v for tensor %v", t.Dims,
+				t.Name)
+		}
+		size *= dim
+	}
+
+	if size != int64(len(t.Data)) {
+		return fmt.Errorf("tensor %v has %v elements but dimensions %v",
+			t.Name, len(t.Data), t.Dims)
+	}
+	return nil
+}
+
+// unmarshal decodes a ValueInfoProto into the receiver
+func (v *ValueInfo) unmarshal(b []byte) error {
+	var typeProto []byte
+	err := fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		if typ != protowire.BytesType {
+			return 0, nil
+		}
+
+		switch num {
+		case valueInfoName:
+			s, n := protowire.ConsumeString(b)
+			v.Name = s
+			return n, nil
+
+		case valueInfoType:
+			t, n := protowire.ConsumeBytes(b)
+			typeProto = t
+			return n, nil
+		}
+		return 0, nil
+	})
+	if err != nil || typeProto == nil {
+		return err
+	}
+
+	// Find the tensor type of the value
+	var tensorType []byte
+	err = fields(typeProto, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		if num == typeTensorType && typ == protowire.BytesType {
+			t, n := protowire.ConsumeBytes(b)
+			tensorType = t
+			return n, nil
+		}
+		return 0, nil
+	})
+	if err != nil || tensorType == nil {
+		return err
+	}
+
+	return fields(tensorType, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		switch {
+		case num == tensorTypeElemType && typ == protowire.VarintType:
+			t, n := protowire.ConsumeVarint(b)
+			v.ElemType = DataType(t)
+			return n, nil
+
+		case num == tensorTypeShape && typ == protowire.BytesType:
+			shape, n := protowire.ConsumeBytes(b)
+			return n, fields(shape, func(num protowire.Number,
+				typ protowire.Type, b []byte) (int, error) {
+				if num != shapeDim || typ != protowire.BytesType {
+					return 0, nil
+				}
+				d, n := protowire.ConsumeBytes(b)
+				dim, err := unmarshalDim(d)
+				v.Shape = append(v.Shape, dim)
+				return n, err
+			})
+		}
+		return 0, nil
+	})
+}
+
+// unmarshalDim decodes a TensorShapeProto.Dimension
+func unmarshalDim(b []byte) (Dim, error) {
+	var dim Dim
+	err := fields(b, func(num protowire.Number, typ protowire.Type,
+		b []byte) (int, error) {
+		switch {
+		case num == dimValue && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			dim.Value = int64(v)
+			return n, nil
+
+		case num == dimParam && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			dim.Param = v
+			return n, nil
+		}
+		return 0, nil
+	})
+	return dim, err
+}
+
+// fields calls f with the number, wire type, and encoded value of each
+// field in the encoded message b. The function f should consume the
+// value of the field and return the number of bytes consumed, or 0 if
+// the field should be skipped.
+func fields(b []byte, f func(protowire.Number, protowire.Type,
+	[]byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, err := f(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// consumeInts appends the packed or unpacked repeated int64 field
+// value in b to ints
+func consumeInts(typ protowire.Type, b []byte, ints *[]int64) (int, error) {
+	switch typ {
+	case protowire.VarintType:
+		v, n := protowire.ConsumeVarint(b)
+		*ints = append(*ints, int64(v))
+		return n, nil
+
+	case protowire.BytesType:
+		packed, n := protowire.ConsumeBytes(b)
+		for len(packed) > 0 {
+			v, m := protowire.ConsumeVarint(packed)
+			if m < 0 {
+				return 0, protowire.ParseError(m)
+			}
+			*ints = append(*ints, int64(v))
+			packed = packed[m:]
+		}
+		return n, nil
+	}
+	return 0, nil
+}
+
+// consumeFloats appends the packed or unpacked repeated float field
+// value in b to floats
+func consumeFloats(typ protowire.Type, b []byte, floats *[]float64) (int,
+	error) {
+	switch typ {
+	case protowire.Fixed32Type:
+		v, n := protowire.ConsumeFixed32(b)
+		*floats = append(*floats, float64(math.Float32frombits(v)))
+		return n, nil
+
+	case protowire.BytesType:
+		packed, n := protowire.ConsumeBytes(b)
+		if len(packed)%4 != 0 {
+			return 0, fmt.Errorf("invalid packed float data")
+		}
+		for i := 0; i < len(packed); i += 4 {
+			bits := binary.LittleEndian.Uint32(packed[i:])
+			*floats = append(*floats, float64(math.Float32frombits(bits)))
+		}
+		return n, nil
+	}
+	return 0, nil
+}
+
+// consumeDoubles appends the packed or unpacked repeated double field
+// value in b to doubles
+func consumeDoubles(typ protowire.Type, b []byte, doubles *[]float64) (int,
+	error) {
+	switch typ {
+	case protowire.Fixed64Type:
+		v, n := protowire.ConsumeFixed64(b)
+		*doubles = append(*doubles, math.Float64frombits(v))
+		return n, nil
+
+	case protowire.BytesType:
+		packed, n := protowire.ConsumeBytes(b)
+		if len(packed)%8 != 0 {
+			return 0, fmt.Errorf("invalid packed double data")
+		}
+		for i := 0; i < len(packed); i += 8 {
+			bits := binary.LittleEndian.Uint64(packed[i:])
+			*doubles = append(*doubles, math.Float64frombits(bits))
+		}
+		return n, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,43 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+)
+
+// CosineConfig describes a schedule which anneals a value from Start
+// to End over Steps steps following a half-cosine, after which the
+// value remains at End.
+type CosineConfig struct {
+	Start float64
+	End   float64
+	Steps int
+}
+
+// NewCosine returns a new cosine Schedule
+func NewCosine(start, end float64, steps int) (*Schedule, error) {
+	return New(CosineConfig{Start: start, End: end, Steps: steps})
+}
+
+// Value returns the value of the hyperparameter after step steps
+func (c CosineConfig) Value(step int) float64 {
+	if step >= c.Steps {
+		return c.End
+	}
+	frac := float64(step) / float64(c.Steps)
+	return c.End + (c.Start-c.End)*(1.0+math.Cos(math.Pi*frac))/2.0
+}
+
+// Validate returns an error if the Config is not valid
+func (c CosineConfig) Validate() error {
+	if c.Steps < 0 {
+		return fmt.Errorf("validate: steps must be non-negative "+
+			"\n\thave(%v)", c.Steps)
+	}
+	return nil
+}
+
+// Type returns the type of Schedule described by the Config
+func (c CosineConfig) Type() Type {
+	return Cosine
+}
@@ -0,0 +1,38 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+)
+
+// ExponentialConfig describes a schedule which starts at Start and
+// decays by a factor of Decay every step, never falling below Min.
+type ExponentialConfig struct {
+	Start float64
+	Decay float64
+	Min   float64
+}
+
+// NewExponential returns a new exponential Schedule
+func NewExponential(start, decay, min float64) (*Schedule, error) {
+	return New(ExponentialConfig{Start: start, Decay: decay, Min: min})
+}
+
+// Value returns the value of the hyperparameter after step steps
+func (e ExponentialConfig) Value(step int) float64 {
+	return math.Max(e.Min, e.Start*math.Pow(e.Decay, float64(step)))
+}
+
+// Validate returns an error if the Config is not valid
+func (e ExponentialConfig) Validate() error {
+	if e.Decay < 0 || e.Decay > 1 {
+		return fmt.Errorf("validate: decay must be in [0, 1] \n\thave(%v)",
+			e.Decay)
+	}
+	return nil
+}
+
+// Type returns the type of Schedule described by the Config
+func (e ExponentialConfig) Type() Type {
+	return Exponential
+}
@@ -0,0 +1,40 @@
+package schedule
+
+import "fmt"
+
+// LinearConfig describes a schedule which linearly anneals a value
+// from Start to End over Steps steps, after which the value remains
+// at End.
+type LinearConfig struct {
+	Start float64
+	End   float64
+	Steps int
+}
+
+// NewLinear returns a new linear Schedule
+func NewLinear(start, end float64, steps int) (*Schedule, error) {
+	return New(LinearConfig{Start: start, End: end, Steps: steps})
+}
+
+// Value returns the value of the hyperparameter after step steps
+func (l LinearConfig) Value(step int) float64 {
+	if step >= l.Steps {
+		return l.End
+	}
+	frac := float64(step) / float64(l.Steps)
+	return l.Start + frac*(l.End-l.Start)
+}
+
+// Validate returns an error if the Config is not valid
+func (l LinearConfig) Validate() error {
+	if l.Steps < 0 {
+		return fmt.Errorf("validate: steps must be non-negative "+
+			"\n\thave(%v)", l.Steps)
+	}
+	return nil
+}
+
+// Type returns the type of Schedule described by the Config
+func (l LinearConfig) Type() Type {
+	return Linear
+}
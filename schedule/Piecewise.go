@@ -0,0 +1,67 @@
+package schedule
+
+import "fmt"
+
+// PiecewiseConfig describes a piecewise linear schedule. The value at
+// step Steps[i] is Values[i], and values between consecutive steps are
+// linearly interpolated. Before Steps[0] the value is Values[0], and
+// after the last step the value is the last of Values.
+//
+// For example, the following PiecewiseConfig anneals a value from 1.0
+// to 0.1 over the first 10,000 steps, and then to 0.01 over the next
+// 90,000 steps:
+//
+//	PiecewiseConfig{
+//		Steps:  []int{0, 10000, 100000},
+//		Values: []float64{1.0, 0.1, 0.01},
+//	}
+type PiecewiseConfig struct {
+	Steps  []int
+	Values []float64
+}
+
+// NewPiecewise returns a new piecewise linear Schedule
+func NewPiecewise(steps []int, values []float64) (*Schedule, error) {
+	return New(PiecewiseConfig{Steps: steps, Values: values})
+}
+
+// Value returns the value of the hyperparameter after step steps
+func (p PiecewiseConfig) Value(step int) float64 {
+	if step <= p.Steps[0] {
+		return p.Values[0]
+	}
+
+	for i := 1; i < len(p.Steps); i++ {
+		if step < p.Steps[i] {
+			frac := float64(step-p.Steps[i-1]) /
+				float64(p.Steps[i]-p.Steps[i-1])
+			return p.Values[i-1] + frac*(p.Values[i]-p.Values[i-1])
+		}
+	}
+	return p.Values[len(p.Values)-1]
+}
+
+// Validate returns an error if the Config is not valid
+func (p PiecewiseConfig) Validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("validate: at least one step must be specified")
+	}
+
+	if len(p.Steps) != len(p.Values) {
+		return fmt.Errorf("validate: each step must have a value "+
+			"\n\twant(%v) \n\thave(%v)", len(p.Steps), len(p.Values))
+	}
+
+	for i := 1; i < len(p.Steps); i++ {
+		if p.Steps[i] <= p.Steps[i-1] {
+			return fmt.Errorf("validate: steps must be strictly "+
+				"increasing \n\thave(%v)", p.Steps)
+		}
+	}
+	return nil
+}
+
+// Type returns the type of Schedule described by the Config
+func (p PiecewiseConfig) Type() Type {
+	return Piecewise
+}
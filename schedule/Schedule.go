@@ -0,0 +1,119 @@
+// Package schedule implements schedules for hyperparameters, such as
+// the ε of ε-greedy policies or the step sizes of solvers, that change
+// as an agent takes steps in an environment. Schedules can be JSON
+// serialized into configuration files.
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Type describes different types of Schedules that are available
+type Type string
+
+// Available Schedule types
+const (
+	Linear      Type = "Linear"
+	Exponential Type = "Exponential"
+	Piecewise   Type = "Piecewise"
+	Cosine      Type = "Cosine"
+	StepDecay   Type = "Step"
+	Warmup      Type = "Warmup"
+)
+
+// Schedule wraps schedule Configs so that they can be JSON marshalled
+// and unmarshalled.
+type Schedule struct {
+	Type
+	Config
+}
+
+// New returns a new Schedule described by the Config c
+func New(c Config) (*Schedule, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("new: %v", err)
+	}
+	return &Schedule{Type: c.Type(), Config: c}, nil
+}
+
+// String implements the fmt.Stringer interface
+func (s *Schedule) String() string {
+	return fmt.Sprintf("{%v Schedule: %v}", s.Type, s.Config)
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	config, typeName, err := unmarshalConfig(
+		data,
+		"Type",
+		"Config",
+		map[string]reflect.Type{
+			string(Linear):      reflect.TypeOf(LinearConfig{}),
+			string(Exponential): reflect.TypeOf(ExponentialConfig{}),
+			string(Piecewise):   reflect.TypeOf(PiecewiseConfig{}),
+			string(Cosine):      reflect.TypeOf(CosineConfig{}),
+			string(StepDecay):   reflect.TypeOf(StepConfig{}),
+			string(Warmup):      reflect.TypeOf(WarmupConfig{}),
+		})
+	if err != nil {
+		return err
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+
+	s.Type = typeName
+	s.Config = config
+
+	return nil
+}
+
+// unmarshalConfig uses reflection to unmarshall a Config into its
+// concrete type. Both the Config and its Type are returned.
+func unmarshalConfig(data []byte, typeJsonField, valueJsonField string,
+	customTypes map[string]reflect.Type) (Config, Type, error) {
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, "", err
+	}
+
+	typeName, ok := m[typeJsonField].(string)
+	if !ok {
+		return nil, "", fmt.Errorf("unmarshalConfig: missing %v field",
+			typeJsonField)
+	}
+	ty, found := customTypes[typeName]
+	if !found {
+		return nil, "", fmt.Errorf("unmarshalConfig: unknown type %v",
+			typeName)
+	}
+	value := reflect.New(ty).Interface()
+
+	valueBytes, err := json.Marshal(m[valueJsonField])
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = json.Unmarshal(valueBytes, value); err != nil {
+		return nil, "", err
+	}
+	concreteValue := reflect.ValueOf(value).Elem().Interface().(Config)
+
+	return concreteValue, Type(typeName), nil
+}
+
+// Config implements a schedule configuration, which determines the
+// value of a hyperparameter at each step.
+type Config interface {
+	// Value returns the value of the hyperparameter after step steps
+	Value(step int) float64
+
+	// Validate returns an error if the Config is not valid
+	Validate() error
+
+	// Type returns the type of Schedule described by the Config
+	Type() Type
+}
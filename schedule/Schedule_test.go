@@ -0,0 +1,127 @@
+package schedule
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// valueTest describes the expected value of a schedule at some step
+type valueTest struct {
+	step int
+	want float64
+}
+
+// testValues checks the values of config at each step of tests
+func testValues(t *testing.T, config Config, tests []valueTest) {
+	t.Helper()
+	for _, test := range tests {
+		if value := config.Value(test.step); math.Abs(value-test.want) > 1e-12 {
+			t.Errorf("%v value(%v): have(%v) want(%v)", config.Type(),
+				test.step, value, test.want)
+		}
+	}
+}
+
+// TestPiecewise tests the values of a piecewise linear schedule before
+// its first step, between its steps, and past its last step
+func TestPiecewise(t *testing.T) {
+	config := PiecewiseConfig{
+		Steps:  []int{10, 20, 60},
+		Values: []float64{1.0, 0.5, 0.1},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	testValues(t, config, []valueTest{
+		{0, 1.0},
+		{10, 1.0},
+		{15, 0.75},
+		{20, 0.5},
+		{40, 0.3},
+		{60, 0.1},
+		{1000, 0.1},
+	})
+
+	for _, invalid := range []PiecewiseConfig{
+		{},
+		{Steps: []int{0, 1}, Values: []float64{1}},
+		{Steps: []int{1, 1}, Values: []float64{1, 0}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("validate(%v): expected error", invalid)
+		}
+	}
+}
+
+// TestLinear tests the values of a linear schedule, including a
+// schedule with no annealing steps
+func TestLinear(t *testing.T) {
+	testValues(t, LinearConfig{Start: 1, End: 0.1, Steps: 10}, []valueTest{
+		{0, 1.0},
+		{5, 0.55},
+		{10, 0.1},
+		{100, 0.1},
+	})
+
+	testValues(t, LinearConfig{Start: 1, End: 0.1, Steps: 0}, []valueTest{
+		{0, 0.1},
+		{1, 0.1},
+	})
+}
+
+// TestWarmup tests the values of a warmup schedule which is followed
+// by a cosine schedule
+func TestWarmup(t *testing.T) {
+	after, err := NewCosine(1.0, 0.0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warmup, err := NewWarmup(0.0, 1.0, 10, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testValues(t, warmup.Config, []valueTest{
+		{0, 0.0},
+		{5, 0.5},
+		{10, 1.0},
+		{15, 0.5},
+		{20, 0.0},
+		{100, 0.0},
+	})
+}
+
+// TestScheduleJSON tests that nested Schedules can be JSON marshalled
+// and unmarshalled
+func TestScheduleJSON(t *testing.T) {
+	after, err := NewStep(1.0, 0.5, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule, err := NewWarmup(0.1, 1.0, 100, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Schedule
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, step := range []int{0, 50, 100, 125, 1000} {
+		if have, want := decoded.Value(step), schedule.Value(step); have != want {
+			t.Errorf("value(%v): have(%v) want(%v)", step, have, want)
+		}
+	}
+
+	invalid := []byte(`{"Type": "Linear", "Config": {"Steps": -1}}`)
+	if err := json.Unmarshal(invalid, &decoded); err == nil {
+		t.Errorf("unmarshalJSON: expected error for negative steps")
+	}
+}
@@ -0,0 +1,42 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+)
+
+// StepConfig describes a schedule which starts at Start and decays by
+// a factor of Gamma every Interval steps. If Interval is 0, the value
+// is never decayed.
+type StepConfig struct {
+	Start    float64
+	Gamma    float64
+	Interval int
+}
+
+// NewStep returns a new step decay Schedule
+func NewStep(start, gamma float64, interval int) (*Schedule, error) {
+	return New(StepConfig{Start: start, Gamma: gamma, Interval: interval})
+}
+
+// Value returns the value of the hyperparameter after step steps
+func (s StepConfig) Value(step int) float64 {
+	if s.Interval == 0 {
+		return s.Start
+	}
+	return s.Start * math.Pow(s.Gamma, float64(step/s.Interval))
+}
+
+// Validate returns an error if the Config is not valid
+func (s StepConfig) Validate() error {
+	if s.Interval < 0 {
+		return fmt.Errorf("validate: interval must be non-negative "+
+			"\n\thave(%v)", s.Interval)
+	}
+	return nil
+}
+
+// Type returns the type of Schedule described by the Config
+func (s StepConfig) Type() Type {
+	return StepDecay
+}
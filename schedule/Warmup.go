@@ -0,0 +1,56 @@
+package schedule
+
+import "fmt"
+
+// WarmupConfig describes a schedule which linearly increases a value
+// from Start to End over Steps steps. After warmup, the value follows
+// the After schedule, started from its first step. If After is nil,
+// the value remains at End after warmup.
+type WarmupConfig struct {
+	Start float64
+	End   float64
+	Steps int
+	After *Schedule `json:",omitempty"`
+}
+
+// NewWarmup returns a new warmup Schedule which follows the after
+// Schedule once warmup has completed. If after is nil, the value
+// remains at end after warmup.
+func NewWarmup(start, end float64, steps int,
+	after *Schedule) (*Schedule, error) {
+	return New(WarmupConfig{Start: start, End: end, Steps: steps,
+		After: after})
+}
+
+// Value returns the value of the hyperparameter after step steps
+func (w WarmupConfig) Value(step int) float64 {
+	if step < w.Steps {
+		frac := float64(step) / float64(w.Steps)
+		return w.Start + frac*(w.End-w.Start)
+	}
+
+	if w.After == nil {
+		return w.End
+	}
+	return w.After.Value(step - w.Steps)
+}
+
+// Validate returns an error if the Config is not valid
+func (w WarmupConfig) Validate() error {
+	if w.Steps < 0 {
+		return fmt.Errorf("validate: steps must be non-negative "+
+			"\n\thave(%v)", w.Steps)
+	}
+	if w.After != nil {
+		if err := w.After.Validate(); err != nil {
+			return fmt.Errorf("validate: invalid schedule after warmup: %v",
+				err)
+		}
+	}
+	return nil
+}
+
+// Type returns the type of Schedule described by the Config
+func (w WarmupConfig) Type() Type {
+	return Warmup
+}
@@ -0,0 +1,96 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+
+	G "gorgonia.org/gorgonia"
+)
+
+// AdaGradConfig describes a configuration of the AdaGrad solver
+type AdaGradConfig struct {
+	StepSize float64
+	Epsilon  float64 // Smoothing factor
+	Batch    int
+	Clip     float64 // <= 0 if no clipping
+}
+
+// NewDefaultAdaGrad returns a new AdaGrad Solver with default
+// hyperparameters
+func NewDefaultAdaGrad(stepSize float64, batchSize int) (*Solver, error) {
+	return NewAdaGrad(stepSize, 1e-8, batchSize, -1.0)
+}
+
+// NewAdaGrad returns a new AdaGrad Solver
+func NewAdaGrad(stepSize, epsilon float64, batchSize int,
+	clip float64) (*Solver, error) {
+	adagrad := AdaGradConfig{
+		StepSize: stepSize,
+		Epsilon:  epsilon,
+		Batch:    int(batchSize),
+		Clip:     clip,
+	}
+
+	return newSolver(AdaGrad, adagrad)
+}
+
+// Create returns a new AdaGrad Solver as described by the
+// AdaGradConfig
+func (a AdaGradConfig) Create() G.Solver {
+	return &adaGradSolver{config: a}
+}
+
+// ValidType returns if the given Solver type is a valid type to be
+// created with this config.
+func (a AdaGradConfig) ValidType(t Type) bool {
+	return t == AdaGrad
+}
+
+// stepSize returns the base step size of the AdaGradConfig
+func (a AdaGradConfig) stepSize() float64 {
+	return a.StepSize
+}
+
+// adaGradSolver implements the AdaGrad solver. Gorgonia's AdaGrad
+// solver ignores the step size, smoothing factor, and clipping
+// options, so it is re-implemented here. Given gradient g, the sum of
+// squared gradients G and weights w are updated as:
+//
+//	G ← G + g²
+//	w ← w - αg / (√G + ε)
+type adaGradSolver struct {
+	config    AdaGradConfig
+	sumSqGrad [][]float64
+}
+
+// Step performs a single update of the weights of model
+func (a *adaGradSolver) Step(model []G.ValueGrad) error {
+	if a.sumSqGrad == nil {
+		a.sumSqGrad = make([][]float64, len(model))
+	}
+
+	for i, node := range model {
+		weights, grad, err := weightsAndGrad(node)
+		if err != nil {
+			return fmt.Errorf("step: %v", err)
+		}
+		grad = processGrad(grad, a.config.Batch, a.config.Clip)
+
+		if a.sumSqGrad[i] == nil {
+			a.sumSqGrad[i] = make([]float64, len(weights))
+		}
+		sumSq := a.sumSqGrad[i]
+
+		for j := range weights {
+			sumSq[j] += grad[j] * grad[j]
+			weights[j] -= a.config.StepSize * grad[j] /
+				(math.Sqrt(sumSq[j]) + a.config.Epsilon)
+		}
+	}
+	return nil
+}
+
+// setStepSize sets the step size of the solver
+func (a *adaGradSolver) setStepSize(stepSize float64) {
+	a.config.StepSize = stepSize
+}
@@ -65,3 +65,8 @@ func (a AdamConfig) Create() G.Solver {
 func (a AdamConfig) ValidType(t Type) bool {
 	return t == Adam
 }
+
+// stepSize returns the base step size of the AdamConfig
+func (a AdamConfig) stepSize() float64 {
+	return a.StepSize
+}
@@ -0,0 +1,116 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+
+	G "gorgonia.org/gorgonia"
+)
+
+// AdamWConfig describes a configuration of the AdamW solver, which is
+// the Adam solver with decoupled weight decay
+type AdamWConfig struct {
+	StepSize    float64
+	Epsilon     float64 // Smoothing factor
+	Beta1       float64
+	Beta2       float64
+	WeightDecay float64
+	Batch       int
+	Clip        float64 // <= 0 if no clipping
+}
+
+// NewDefaultAdamW returns a new AdamW Solver with default
+// hyperparameters
+func NewDefaultAdamW(stepSize float64, batchSize int) (*Solver, error) {
+	return NewAdamW(stepSize, 1e-8, 0.9, 0.999, 0.01, batchSize, -1.0)
+}
+
+// NewAdamW returns a new AdamW Solver
+func NewAdamW(stepSize, epsilon, beta1, beta2, weightDecay float64,
+	batchSize int, clip float64) (*Solver, error) {
+	adamw := AdamWConfig{
+		StepSize:    stepSize,
+		Epsilon:     epsilon,
+		Beta1:       beta1,
+		Beta2:       beta2,
+		WeightDecay: weightDecay,
+		Batch:       int(batchSize),
+		Clip:        clip,
+	}
+
+	return newSolver(AdamW, adamw)
+}
+
+// Create returns a new AdamW Solver as described by the AdamWConfig
+func (a AdamWConfig) Create() G.Solver {
+	return &adamWSolver{config: a}
+}
+
+// ValidType returns if the given Solver type is a valid type to be
+// created with this config.
+func (a AdamWConfig) ValidType(t Type) bool {
+	return t == AdamW
+}
+
+// stepSize returns the base step size of the AdamWConfig
+func (a AdamWConfig) stepSize() float64 {
+	return a.StepSize
+}
+
+// adamWSolver implements the AdamW solver, which is not provided by
+// Gorgonia. The weight decay is decoupled from the gradient, so that
+// on iteration t with gradient g, the weights w are updated as:
+//
+//	m ← β₁m + (1 - β₁)g
+//	v ← β₂v + (1 - β₂)g²
+//	w ← w - α(m̂ / (√v̂ + ε) + λw)
+//
+// where m̂ = m / (1 - β₁ᵗ) and v̂ = v / (1 - β₂ᵗ).
+type adamWSolver struct {
+	config AdamWConfig
+	iter   int
+	mean   [][]float64
+	vari   [][]float64
+}
+
+// Step performs a single update of the weights of model
+func (a *adamWSolver) Step(model []G.ValueGrad) error {
+	if a.mean == nil {
+		a.mean = make([][]float64, len(model))
+		a.vari = make([][]float64, len(model))
+	}
+
+	a.iter++
+	correction1 := 1 - math.Pow(a.config.Beta1, float64(a.iter))
+	correction2 := 1 - math.Pow(a.config.Beta2, float64(a.iter))
+
+	for i, node := range model {
+		weights, grad, err := weightsAndGrad(node)
+		if err != nil {
+			return fmt.Errorf("step: %v", err)
+		}
+		grad = processGrad(grad, a.config.Batch, a.config.Clip)
+
+		if a.mean[i] == nil {
+			a.mean[i] = make([]float64, len(weights))
+			a.vari[i] = make([]float64, len(weights))
+		}
+		m, v := a.mean[i], a.vari[i]
+
+		for j := range weights {
+			m[j] = a.config.Beta1*m[j] + (1-a.config.Beta1)*grad[j]
+			v[j] = a.config.Beta2*v[j] + (1-a.config.Beta2)*grad[j]*grad[j]
+
+			mHat := m[j] / correction1
+			vHat := v[j] / correction2
+			weights[j] -= a.config.StepSize * (mHat/(math.Sqrt(vHat)+
+				a.config.Epsilon) + a.config.WeightDecay*weights[j])
+		}
+	}
+	return nil
+}
+
+// setStepSize sets the step size of the solver
+func (a *adamWSolver) setStepSize(stepSize float64) {
+	a.config.StepSize = stepSize
+}
@@ -0,0 +1,64 @@
+package solver
+
+import G "gorgonia.org/gorgonia"
+
+// MomentumConfig describes a configuration of the stochastic gradient
+// descent solver with momentum
+type MomentumConfig struct {
+	StepSize float64
+	Momentum float64
+	Batch    int
+	Clip     float64 // <= 0 if no clipping
+}
+
+// NewDefaultMomentum returns a new Momentum Solver with default
+// hyperparameters
+func NewDefaultMomentum(stepSize float64, batchSize int) (*Solver, error) {
+	return NewMomentum(stepSize, 0.9, batchSize, -1.0)
+}
+
+// NewMomentum returns a new Momentum Solver
+func NewMomentum(stepSize, momentum float64, batchSize int,
+	clip float64) (*Solver, error) {
+	m := MomentumConfig{
+		StepSize: stepSize,
+		Momentum: momentum,
+		Batch:    int(batchSize),
+		Clip:     clip,
+	}
+
+	return newSolver(Momentum, m)
+}
+
+// Create returns a new Gorgonia Momentum Solver as described by the
+// MomentumConfig
+func (m MomentumConfig) Create() G.Solver {
+	var solver G.Solver
+
+	if m.Clip <= 0 {
+		solver = G.NewMomentum(
+			G.WithLearnRate(m.StepSize),
+			G.WithMomentum(m.Momentum),
+			G.WithBatchSize(float64(m.Batch)),
+		)
+	} else {
+		solver = G.NewMomentum(
+			G.WithLearnRate(m.StepSize),
+			G.WithMomentum(m.Momentum),
+			G.WithBatchSize(float64(m.Batch)),
+			G.WithClip(m.Clip),
+		)
+	}
+	return solver
+}
+
+// ValidType returns if the given Solver type is a valid type to be
+// created with this config.
+func (m MomentumConfig) ValidType(t Type) bool {
+	return t == Momentum
+}
+
+// stepSize returns the base step size of the MomentumConfig
+func (m MomentumConfig) stepSize() float64 {
+	return m.StepSize
+}
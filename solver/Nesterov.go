@@ -0,0 +1,94 @@
+package solver
+
+import (
+	"fmt"
+
+	G "gorgonia.org/gorgonia"
+)
+
+// NesterovConfig describes a configuration of the stochastic gradient
+// descent solver with Nesterov momentum
+type NesterovConfig struct {
+	StepSize float64
+	Momentum float64
+	Batch    int
+	Clip     float64 // <= 0 if no clipping
+}
+
+// NewDefaultNesterov returns a new Nesterov Solver with default
+// hyperparameters
+func NewDefaultNesterov(stepSize float64, batchSize int) (*Solver, error) {
+	return NewNesterov(stepSize, 0.9, batchSize, -1.0)
+}
+
+// NewNesterov returns a new Nesterov Solver
+func NewNesterov(stepSize, momentum float64, batchSize int,
+	clip float64) (*Solver, error) {
+	nesterov := NesterovConfig{
+		StepSize: stepSize,
+		Momentum: momentum,
+		Batch:    int(batchSize),
+		Clip:     clip,
+	}
+
+	return newSolver(Nesterov, nesterov)
+}
+
+// Create returns a new Nesterov Solver as described by the
+// NesterovConfig
+func (n NesterovConfig) Create() G.Solver {
+	return &nesterovSolver{config: n}
+}
+
+// ValidType returns if the given Solver type is a valid type to be
+// created with this config.
+func (n NesterovConfig) ValidType(t Type) bool {
+	return t == Nesterov
+}
+
+// stepSize returns the base step size of the NesterovConfig
+func (n NesterovConfig) stepSize() float64 {
+	return n.StepSize
+}
+
+// nesterovSolver implements stochastic gradient descent with Nesterov
+// momentum, which is not provided by Gorgonia. Given gradient g, the
+// velocity v and weights w are updated as:
+//
+//	v ← μv + g
+//	w ← w - α(g + μv)
+type nesterovSolver struct {
+	config   NesterovConfig
+	velocity [][]float64
+}
+
+// Step performs a single update of the weights of model
+func (n *nesterovSolver) Step(model []G.ValueGrad) error {
+	if n.velocity == nil {
+		n.velocity = make([][]float64, len(model))
+	}
+
+	for i, node := range model {
+		weights, grad, err := weightsAndGrad(node)
+		if err != nil {
+			return fmt.Errorf("step: %v", err)
+		}
+		grad = processGrad(grad, n.config.Batch, n.config.Clip)
+
+		if n.velocity[i] == nil {
+			n.velocity[i] = make([]float64, len(weights))
+		}
+		v := n.velocity[i]
+
+		for j := range weights {
+			v[j] = n.config.Momentum*v[j] + grad[j]
+			weights[j] -= n.config.StepSize * (grad[j] + n.config.Momentum*v[j])
+		}
+	}
+	return nil
+}
+
+// setStepSize sets the step size of the solver
+func (n *nesterovSolver) setStepSize(stepSize float64) {
+	n.config.StepSize = stepSize
+}
@@ -74,3 +74,8 @@ func (r RMSPropConfig) Create() G.Solver {
 func (r RMSPropConfig) ValidType(t Type) bool {
 	return t == RMSProp
 }
+
+// stepSize returns the base step size of the RMSPropConfig
+func (r RMSPropConfig) stepSize() float64 {
+	return r.StepSize
+}
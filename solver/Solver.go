@@ -3,11 +3,16 @@
 package solver
 
 import (
+	"bytes"
+	"encoding/gob"
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 
+	"github.com/samuelfneumann/golearn/schedule"
 	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
 )
 
 // Type describes different types of solvers that are available
@@ -15,17 +20,34 @@ type Type string
 
 // Available solver types
 const (
-	Adam    Type = "Adam"
-	RMSProp Type = "RMSProp"
-	Vanilla Type = "Vanilla"
+	Adam     Type = "Adam"
+	AdamW    Type = "AdamW"
+	RMSProp  Type = "RMSProp"
+	Vanilla  Type = "Vanilla"
+	Momentum Type = "Momentum"
+	Nesterov Type = "Nesterov"
+	AdaGrad  Type = "AdaGrad"
 )
 
 // Solver wraps Gorgonia Solvers so that they can be JSON marshalled and
 // unmarshalled.
+//
+// A Solver can optionally clip gradients before they are passed to the
+// underlying Gorgonia Solver, either element-wise by value or by their
+// global norm, and can adjust its step size over time using a
+// schedule.Schedule. On each step, the step size of the Solver is the
+// base step size of its Config scaled by the value of the Schedule.
+// Each call to Step advances the Schedule by one step.
 type Solver struct {
 	G.Solver `json:"-"`
 	Type
 	Config
+
+	Schedule  *schedule.Schedule `json:",omitempty"` // nil if constant step size
+	ClipValue float64            `json:",omitempty"` // <= 0 if no clipping
+	ClipNorm  float64            `json:",omitempty"` // <= 0 if no clipping
+
+	step int // Number of steps taken
 }
 
 // newSolver returns a new solver with the given type and configuration.
@@ -52,18 +74,162 @@ func (s *Solver) UnmarshalJSON(data []byte) error {
 		"Type",
 		"Config",
 		map[string]reflect.Type{
-			string(Vanilla): reflect.TypeOf(VanillaConfig{}),
-			string(Adam):    reflect.TypeOf(AdamConfig{}),
-			string(RMSProp): reflect.TypeOf(RMSPropConfig{}),
+			string(Vanilla):  reflect.TypeOf(VanillaConfig{}),
+			string(Adam):     reflect.TypeOf(AdamConfig{}),
+			string(AdamW):    reflect.TypeOf(AdamWConfig{}),
+			string(RMSProp):  reflect.TypeOf(RMSPropConfig{}),
+			string(Momentum): reflect.TypeOf(MomentumConfig{}),
+			string(Nesterov): reflect.TypeOf(NesterovConfig{}),
+			string(AdaGrad):  reflect.TypeOf(AdaGradConfig{}),
 		})
 	if err != nil {
 		return err
 	}
 
+	// Unmarshal the optional schedule and gradient clipping
+	options := struct {
+		Schedule  *schedule.Schedule
+		ClipValue float64
+		ClipNorm  float64
+	}{}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return err
+	}
+
 	s.Type = typeName
 	s.Config = config
 	s.Solver = s.Config.Create()
+	s.Schedule = options.Schedule
+	s.ClipValue = options.ClipValue
+	s.ClipNorm = options.ClipNorm
+	s.step = 0
+
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface. The Solver is
+// encoded along with the number of steps it has taken so that its
+// Schedule resumes from the same point when decoded. The internal
+// state of the underlying Gorgonia Solver is not encoded.
+func (s *Solver) GobEncode() ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("gobEncode: %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(data); err != nil {
+		return nil, fmt.Errorf("gobEncode: %v", err)
+	}
+	if err := enc.Encode(s.step); err != nil {
+		return nil, fmt.Errorf("gobEncode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (s *Solver) GobDecode(in []byte) error {
+	dec := gob.NewDecoder(bytes.NewReader(in))
 
+	var data []byte
+	if err := dec.Decode(&data); err != nil {
+		return fmt.Errorf("gobDecode: %v", err)
+	}
+	var step int
+	if err := dec.Decode(&step); err != nil {
+		return fmt.Errorf("gobDecode: %v", err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("gobDecode: %v", err)
+	}
+	s.step = step
+	return nil
+}
+
+// Steps returns the number of steps the Solver has taken
+func (s *Solver) Steps() int {
+	return s.step
+}
+
+// StepSize returns the step size that the Solver will use on its next
+// step
+func (s *Solver) StepSize() float64 {
+	stepSize := s.Config.(stepSizer).stepSize()
+	if s.Schedule != nil {
+		stepSize *= s.Schedule.Value(s.step)
+	}
+	return stepSize
+}
+
+// Step clips the gradients of model, sets the step size of the
+// underlying Gorgonia Solver as described by the Schedule, and then
+// updates the weights of model using the underlying Gorgonia Solver.
+func (s *Solver) Step(model []G.ValueGrad) error {
+	if err := clipGrads(model, s.ClipValue, s.ClipNorm); err != nil {
+		return fmt.Errorf("step: %v", err)
+	}
+
+	if s.Schedule != nil {
+		stepSize := s.StepSize()
+		if setter, ok := s.Solver.(stepSizeSetter); ok {
+			setter.setStepSize(stepSize)
+		} else {
+			G.WithLearnRate(stepSize)(s.Solver)
+		}
+	}
+
+	if err := s.Solver.Step(model); err != nil {
+		return err
+	}
+	s.step++
+	return nil
+}
+
+// clipGrads clips the gradients of model in place, first element-wise
+// to [-clipValue, clipValue] and then so that the global norm of all
+// gradients is at most clipNorm. If either of clipValue or clipNorm
+// are <= 0, the respective clipping is not performed.
+func clipGrads(model []G.ValueGrad, clipValue, clipNorm float64) error {
+	if clipValue <= 0 && clipNorm <= 0 {
+		return nil
+	}
+
+	grads := make([][]float64, len(model))
+	for i, node := range model {
+		var err error
+		if _, grads[i], err = weightsAndGrad(node); err != nil {
+			return fmt.Errorf("clipGrads: %v", err)
+		}
+	}
+
+	if clipValue > 0 {
+		for _, grad := range grads {
+			for j := range grad {
+				grad[j] = math.Max(-clipValue, math.Min(clipValue, grad[j]))
+			}
+		}
+	}
+
+	if clipNorm > 0 {
+		sumSq := 0.0
+		for _, grad := range grads {
+			for _, g := range grad {
+				sumSq += g * g
+			}
+		}
+
+		norm := math.Sqrt(sumSq)
+		if norm > clipNorm {
+			scale := clipNorm / norm
+			for _, grad := range grads {
+				for j := range grad {
+					grad[j] *= scale
+				}
+			}
+		}
+	}
 	return nil
 }
 
@@ -76,18 +242,24 @@ func unmarshalConfig(data []byte, typeJsonField, valueJsonField string,
 		return nil, "", err
 	}
 
-	typeName := m[typeJsonField].(string)
-	var value Config
-	if ty, found := customTypes[typeName]; found {
-		value = reflect.New(ty).Interface().(Config)
+	typeName, ok := m[typeJsonField].(string)
+	if !ok {
+		return nil, "", fmt.Errorf("unmarshalConfig: missing %v field",
+			typeJsonField)
+	}
+	ty, found := customTypes[typeName]
+	if !found {
+		return nil, "", fmt.Errorf("unmarshalConfig: unknown type %v",
+			typeName)
 	}
+	value := reflect.New(ty).Interface()
 
 	valueBytes, err := json.Marshal(m[valueJsonField])
 	if err != nil {
 		return nil, "", err
 	}
 
-	if err = json.Unmarshal(valueBytes, &value); err != nil {
+	if err = json.Unmarshal(valueBytes, value); err != nil {
 		return nil, "", err
 	}
 	concreteValue := reflect.ValueOf(value).Elem().Interface().(Config)
@@ -104,3 +276,57 @@ type Config interface {
 	// with the Config
 	ValidType(Type) bool
 }
+
+// stepSizer is a Config that has a base step size
+type stepSizer interface {
+	stepSize() float64
+}
+
+// stepSizeSetter is a Gorgonia Solver whose step size can be adjusted
+// without G.WithLearnRate
+type stepSizeSetter interface {
+	setStepSize(float64)
+}
+
+// weightsAndGrad returns the backing data of the weights and gradient
+// of a learnable node. Only float64 tensors are supported.
+func weightsAndGrad(n G.ValueGrad) ([]float64, []float64, error) {
+	weights, ok := n.Value().(*tensor.Dense)
+	if !ok || weights.Dtype() != tensor.Float64 {
+		return nil, nil, fmt.Errorf("weightsAndGrad: weights must be a " +
+			"float64 tensor")
+	}
+
+	grad, err := n.Grad()
+	if err != nil {
+		return nil, nil, fmt.Errorf("weightsAndGrad: %v", err)
+	}
+	gradient, ok := grad.(*tensor.Dense)
+	if !ok || gradient.Dtype() != tensor.Float64 {
+		return nil, nil, fmt.Errorf("weightsAndGrad: gradient must be a " +
+			"float64 tensor")
+	}
+
+	return weights.Float64s(), gradient.Float64s(), nil
+}
+
+// processGrad returns the gradient g averaged over a batch of size
+// batch and clipped to [-clip, clip]. If clip <= 0, the gradient is
+// not clipped. As with Gorgonia solvers, the input gradient is zeroed
+// so that gradients do not accumulate between steps.
+func processGrad(g []float64, batch int, clip float64) []float64 {
+	scale := 1.0
+	if batch > 1 {
+		scale = 1.0 / float64(batch)
+	}
+
+	processed := make([]float64, len(g))
+	for i := range g {
+		processed[i] = g[i] * scale
+		if clip > 0 {
+			processed[i] = math.Max(-clip, math.Min(clip, processed[i]))
+		}
+		g[i] = 0
+	}
+	return processed
+}
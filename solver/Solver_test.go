@@ -0,0 +1,261 @@
+package solver
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/samuelfneumann/golearn/schedule"
+	G "gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// TestSolverGob tests that a Solver's configuration, gradient clipping,
+// and progress through its step size schedule are restored when it is
+// gob encoded and decoded
+func TestSolverGob(t *testing.T) {
+	s, err := NewDefaultAdam(0.01, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Schedule, err = schedule.NewLinear(1.0, 0.0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ClipNorm = 0.5
+	s.step = 25
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		t.Fatal(err)
+	}
+	var decoded *Solver
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Type != Adam {
+		t.Errorf("type: have(%v) want(%v)", decoded.Type, Adam)
+	}
+	if decoded.Steps() != s.Steps() {
+		t.Errorf("steps: have(%v) want(%v)", decoded.Steps(), s.Steps())
+	}
+	if decoded.StepSize() != s.StepSize() {
+		t.Errorf("step size: have(%v) want(%v)", decoded.StepSize(),
+			s.StepSize())
+	}
+	if decoded.ClipNorm != s.ClipNorm {
+		t.Errorf("clip norm: have(%v) want(%v)", decoded.ClipNorm,
+			s.ClipNorm)
+	}
+	if decoded.Solver == nil {
+		t.Errorf("gorgonia solver was not created")
+	}
+}
+
+// TestSolverJSON tests that Solvers of each type are unmarshalled from
+// JSON into the configuration given by their constructor, and that the
+// configuration survives a round trip through JSON
+func TestSolverJSON(t *testing.T) {
+	newSolver := func(s *Solver, err error) *Solver {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		data string
+		want *Solver
+	}{
+		{
+			data: `{"Type": "AdaGrad", "Config": {"StepSize": 0.1,
+				"Epsilon": 1e-6, "Batch": 4, "Clip": 2}}`,
+			want: newSolver(NewAdaGrad(0.1, 1e-6, 4, 2)),
+		},
+		{
+			data: `{"Type": "AdamW", "Config": {"StepSize": 0.01,
+				"Epsilon": 1e-7, "Beta1": 0.8, "Beta2": 0.99,
+				"WeightDecay": 0.05, "Batch": 2, "Clip": -1}}`,
+			want: newSolver(NewAdamW(0.01, 1e-7, 0.8, 0.99, 0.05, 2, -1)),
+		},
+		{
+			data: `{"Type": "Momentum", "Config": {"StepSize": 0.5,
+				"Momentum": 0.7, "Batch": 1, "Clip": 3}, "ClipNorm": 1}`,
+			want: newSolver(NewMomentum(0.5, 0.7, 1, 3)),
+		},
+		{
+			data: `{"Type": "Nesterov", "Config": {"StepSize": 0.2,
+				"Momentum": 0.95, "Batch": 8, "Clip": -1}}`,
+			want: newSolver(NewNesterov(0.2, 0.95, 8, -1)),
+		},
+	}
+	tests[2].want.ClipNorm = 1
+
+	for _, test := range tests {
+		var s Solver
+		if err := json.Unmarshal([]byte(test.data), &s); err != nil {
+			t.Fatalf("%v: %v", test.want.Type, err)
+		}
+		checkSolver(t, "unmarshal", &s, test.want)
+
+		data, err := json.Marshal(&s)
+		if err != nil {
+			t.Fatalf("%v: %v", test.want.Type, err)
+		}
+		var decoded Solver
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%v: %v", test.want.Type, err)
+		}
+		checkSolver(t, "round trip", &decoded, test.want)
+	}
+}
+
+// checkSolver checks that have has the same type, configuration, and
+// gradient clipping as want and that its Gorgonia solver was created
+func checkSolver(t *testing.T, name string, have, want *Solver) {
+	t.Helper()
+
+	if have.Type != want.Type {
+		t.Errorf("%v %v: type: have(%v) want(%v)", want.Type, name,
+			have.Type, want.Type)
+	}
+	if !reflect.DeepEqual(have.Config, want.Config) {
+		t.Errorf("%v %v: config: have(%+v) want(%+v)", want.Type, name,
+			have.Config, want.Config)
+	}
+	if have.ClipNorm != want.ClipNorm || have.ClipValue != want.ClipValue {
+		t.Errorf("%v %v: clipping: have(%v, %v) want(%v, %v)", want.Type,
+			name, have.ClipValue, have.ClipNorm, want.ClipValue,
+			want.ClipNorm)
+	}
+	if reflect.TypeOf(have.Solver) != reflect.TypeOf(want.Solver) {
+		t.Errorf("%v %v: gorgonia solver: have(%T) want(%T)", want.Type,
+			name, have.Solver, want.Solver)
+	}
+}
+
+// param is a single learnable parameter with a gradient
+type param struct {
+	weights, grad *tensor.Dense
+}
+
+// Value returns the weights of the parameter
+func (p param) Value() G.Value { return p.weights }
+
+// Grad returns the gradient of the parameter
+func (p param) Grad() (G.Value, error) { return p.grad, nil }
+
+// TestSolverStep tests two steps of each Solver against the
+// closed-form updates of its weights, given the initial weights w and
+// the gradients g₁ and g₂ of each step
+func TestSolverStep(t *testing.T) {
+	const (
+		α  = 0.1
+		ε  = 1e-8
+		μ  = 0.9
+		β1 = 0.8
+		β2 = 0.9
+		λ  = 0.5
+	)
+
+	// adamW returns the weights after a single AdamW step t with
+	// gradient g and first and second moment estimates m and v
+	adamW := func(w, g float64, m, v *float64, t float64) float64 {
+		*m = β1**m + (1-β1)*g
+		*v = β2**v + (1-β2)*g*g
+		mHat := *m / (1 - math.Pow(β1, t))
+		vHat := *v / (1 - math.Pow(β2, t))
+		return w - α*(mHat/(math.Sqrt(vHat)+ε)+λ*w)
+	}
+
+	tests := []struct {
+		name   string
+		solver func() (*Solver, error)
+		grads  [2][]float64
+		want   func(w, g1, g2 float64) float64
+	}{
+		{
+			name:   "AdaGrad",
+			solver: func() (*Solver, error) { return NewAdaGrad(α, ε, 1, -1) },
+			grads:  [2][]float64{{1, -2, 0.5}, {-1, 3, 0}},
+			want: func(w, g1, g2 float64) float64 {
+				w -= α * g1 / (math.Abs(g1) + ε)
+				return w - α*g2/(math.Sqrt(g1*g1+g2*g2)+ε)
+			},
+		},
+		{
+			name: "AdamW",
+			solver: func() (*Solver, error) {
+				return NewAdamW(α, ε, β1, β2, λ, 1, -1)
+			},
+			grads: [2][]float64{{1, -2, 0.5}, {-1, 3, 0}},
+			want: func(w, g1, g2 float64) float64 {
+				var m, v float64
+				w = adamW(w, g1, &m, &v, 1)
+				return adamW(w, g2, &m, &v, 2)
+			},
+		},
+		{
+			// Without gradients, AdamW only decays the weights, since
+			// the decay is decoupled from the moment estimates
+			name: "AdamWDecay",
+			solver: func() (*Solver, error) {
+				return NewAdamW(α, ε, β1, β2, λ, 1, -1)
+			},
+			grads: [2][]float64{{0, 0, 0}, {0, 0, 0}},
+			want: func(w, _, _ float64) float64 {
+				return w * (1 - α*λ) * (1 - α*λ)
+			},
+		},
+		{
+			name:   "Momentum",
+			solver: func() (*Solver, error) { return NewMomentum(α, μ, 1, -1) },
+			grads:  [2][]float64{{1, -2, 0.5}, {-1, 3, 0}},
+			want: func(w, g1, g2 float64) float64 {
+				return w - α*g1 - α*(μ*g1+g2)
+			},
+		},
+		{
+			name:   "Nesterov",
+			solver: func() (*Solver, error) { return NewNesterov(α, μ, 1, -1) },
+			grads:  [2][]float64{{1, -2, 0.5}, {-1, 3, 0}},
+			want: func(w, g1, g2 float64) float64 {
+				w -= α * (g1 + μ*g1)
+				return w - α*(g2+μ*(μ*g1+g2))
+			},
+		},
+	}
+
+	initial := []float64{0.5, -1, 2}
+	for _, test := range tests {
+		s, err := test.solver()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := param{
+			weights: tensor.New(tensor.WithShape(len(initial)),
+				tensor.WithBacking(append([]float64{}, initial...))),
+			grad: tensor.New(tensor.WithShape(len(initial)),
+				tensor.WithBacking(make([]float64, len(initial)))),
+		}
+		for _, grad := range test.grads {
+			copy(p.grad.Float64s(), grad)
+			if err := s.Step([]G.ValueGrad{p}); err != nil {
+				t.Fatalf("%v: %v", test.name, err)
+			}
+		}
+
+		for i, w := range initial {
+			want := test.want(w, test.grads[0][i], test.grads[1][i])
+			if have := p.weights.Float64s()[i]; math.Abs(have-want) > 1e-10 {
+				t.Errorf("%v: weight %v: have(%v) want(%v)", test.name, i,
+					have, want)
+			}
+		}
+	}
+}
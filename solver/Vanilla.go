@@ -47,3 +47,8 @@ func (v VanillaConfig) Create() G.Solver {
 func (v VanillaConfig) ValidType(t Type) bool {
 	return t == Vanilla
 }
+
+// stepSize returns the base step size of the VanillaConfig
+func (v VanillaConfig) stepSize() float64 {
+	return v.StepSize
+}
@@ -46,6 +46,12 @@ func (s StepType) String() string {
 
 // Transition packages together a SARSA tuple (S_{t}, A_{t}, R_{t+1},
 // S_{t+1}, A_{t+1})
+//
+// Discount is the discount with which to bootstrap from S_{t+1}, which
+// is 0 if S_{t+1} is a terminal state. Terminal and Truncated denote
+// whether the episode terminated or was cut off by a timeout at
+// S_{t+1} respectively. Agents should bootstrap from S_{t+1} if the
+// episode was truncated.
 type Transition struct {
 	State      *mat.VecDense
 	Action     *mat.VecDense
@@ -53,6 +59,8 @@ type Transition struct {
 	Discount   float64
 	NextState  *mat.VecDense
 	NextAction *mat.VecDense
+	Terminal   bool
+	Truncated  bool
 }
 
 // NewTransition creates and returns a new transition struct
@@ -60,28 +68,56 @@ func NewTransition(step TimeStep, action *mat.VecDense, nextStep TimeStep,
 	nextAction *mat.VecDense) Transition {
 	state := step.Observation
 	reward := nextStep.Reward // reward for the action argument
-	discount := nextStep.Discount
+	discount := nextStep.BootstrapDiscount()
 	nextState := nextStep.Observation
-	return Transition{state, action, reward, discount, nextState, nextAction}
+	return Transition{state, action, reward, discount, nextState, nextAction,
+		nextStep.Terminal(), nextStep.Truncated()}
 }
 
+// Info stores environment-specific diagnostic information about a
+// TimeStep which is not part of the state observation, such as the
+// distance to a goal. Each key names a single diagnostic.
+type Info map[string]float64
+
 // TimeStep packages together a single timestep in an environment.
 // Given a SARSA tuple (S_{t}, A_{t}, R_{t+1}, S_{t+1}, A_{t+1}), a
 // TimeStep packages together the (R_{t+1}, S_{t+1}) portion, together
 // with the discount value, step number, and whether the step is the
 // last environmental step.
+//
+// Environments may additionally store diagnostic information in the
+// Info of a TimeStep. Since Info is a map, copies of a TimeStep share
+// the same Info, so that environment wrappers which modify other
+// fields of a TimeStep keep the Info of the wrapped environment.
 type TimeStep struct {
 	StepType    StepType
 	Reward      float64
 	Discount    float64
 	Observation *mat.VecDense
 	Number      int
+	Info        Info
 	EndType
 }
 
 // New constructs a new TimeStep
 func New(t StepType, r, d float64, o *mat.VecDense, n int) TimeStep {
-	return TimeStep{t, r, d, o, n, nilEnd}
+	return TimeStep{t, r, d, o, n, nil, nilEnd}
+}
+
+// SetInfo sets the value of the diagnostic key in the TimeStep's Info,
+// creating the Info if needed
+func (t *TimeStep) SetInfo(key string, value float64) {
+	if t.Info == nil {
+		t.Info = make(Info)
+	}
+	t.Info[key] = value
+}
+
+// GetInfo returns the value of the diagnostic key in the TimeStep's
+// Info and whether the TimeStep has such a diagnostic
+func (t *TimeStep) GetInfo(key string) (float64, bool) {
+	value, ok := t.Info[key]
+	return value, ok
 }
 
 // SetEnd sets the ending type for the timestep
@@ -113,6 +149,32 @@ func (t *TimeStep) UnspecifiedEnd() bool {
 	return t.EndType == nilEnd
 }
 
+// Terminal returns whether a TimeStep is the last in an episode because
+// the episode terminated. The value of the observation of a terminal
+// TimeStep is 0, and agents should not bootstrap from it. Last
+// TimeSteps with an unspecified ending type are considered terminal.
+func (t *TimeStep) Terminal() bool {
+	return t.Last() && !t.CutoffEnd()
+}
+
+// Truncated returns whether a TimeStep is the last in an episode
+// because the episode was cut off by a timeout. Since the episode
+// could have continued, agents should bootstrap from the observation
+// of a truncated TimeStep.
+func (t *TimeStep) Truncated() bool {
+	return t.Last() && t.CutoffEnd()
+}
+
+// BootstrapDiscount returns the discount with which to bootstrap from
+// the observation of a TimeStep. This is 0 if the TimeStep is terminal
+// and otherwise is the TimeStep's discount.
+func (t *TimeStep) BootstrapDiscount() float64 {
+	if t.Terminal() {
+		return 0
+	}
+	return t.Discount
+}
+
 // First returns whether a TimeStep is the first in an environment
 func (t *TimeStep) First() bool {
 	return t.StepType == First
@@ -0,0 +1,47 @@
+package timestep
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestNewTransition(t *testing.T) {
+	const discount = 0.9
+	obs := mat.NewVecDense(1, []float64{1})
+	action := mat.NewVecDense(1, []float64{0})
+
+	tests := []struct {
+		name      string
+		stepType  StepType
+		endType   EndType
+		discount  float64
+		terminal  bool
+		truncated bool
+	}{
+		{"Mid", Mid, nilEnd, discount, false, false},
+		{"Timeout", Last, Timeout, discount, false, true},
+		{"Terminal", Last, TerminalStateReached, 0, true, false},
+		{"Unspecified", Last, nilEnd, 0, true, false},
+	}
+
+	for _, test := range tests {
+		step := New(First, 0, discount, obs, 0)
+		nextStep := New(test.stepType, 1, discount, obs, 1)
+		nextStep.EndType = test.endType
+
+		tr := NewTransition(step, action, nextStep, action)
+		if tr.Discount != test.discount {
+			t.Errorf("%v: discount: have(%v) want(%v)", test.name,
+				tr.Discount, test.discount)
+		}
+		if tr.Terminal != test.terminal {
+			t.Errorf("%v: terminal: have(%v) want(%v)", test.name,
+				tr.Terminal, test.terminal)
+		}
+		if tr.Truncated != test.truncated {
+			t.Errorf("%v: truncated: have(%v) want(%v)", test.name,
+				tr.Truncated, test.truncated)
+		}
+	}
+}
@@ -247,3 +247,23 @@ func Duplicate(slice []float64) []float64 {
 	copy(newSlice, slice)
 	return newSlice
 }
+
+// Softmax returns the softmax of values with the given temperature,
+// which is the probability distribution proportional to
+// exp(values[i] / temperature)
+func Softmax(temperature float64, values ...float64) []float64 {
+	max := Max(values...)
+
+	probs := make([]float64, len(values))
+	sum := 0.0
+	for i, value := range values {
+		// Subtract the max value for numerical stability
+		probs[i] = math.Exp((value - max) / temperature)
+		sum += probs[i]
+	}
+
+	for i := range probs {
+		probs[i] /= sum
+	}
+	return probs
+}
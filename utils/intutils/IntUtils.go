@@ -13,13 +13,21 @@ func Min(ints ...int) int {
 
 // Max calculates and returns the maximum int in a list
 func Max(ints ...int) int {
-	min := ints[0]
+	max := ints[0]
 	for _, val := range ints {
-		if val < min {
-			min = val
+		if val > max {
+			max = val
 		}
 	}
-	return min
+	return max
+}
+
+// Abs returns the absolute value of an int
+func Abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
 }
 
 // Prod calculates the product of a number of ints
@@ -31,6 +39,15 @@ func Prod(ints ...int) int {
 	return prod
 }
 
+// Sum calculates the sum of a number of ints
+func Sum(ints ...int) int {
+	sum := 0
+	for _, i := range ints {
+		sum += i
+	}
+	return sum
+}
+
 // Contains returns true if slice contains value and false otherwise
 func Contains(slice []int, value int) bool {
 	for i := range slice {
@@ -258,3 +258,37 @@ func GaussianLogPdf(mean, std, actions *G.Node) *G.Node {
 		return logProb
 	}
 }
+
+// GaussianEntropy calculates the entropy of a diagonal Gaussian
+// distribution with standard deviation std.
+//
+// The argument should be two-dimensional of size m x n, where the rows
+// (m) denote the samples in the batch and the columns (n) denote the
+// main diagonal of the standard deviation. The returned node has one
+// entropy per sample in the batch:
+//
+//	H = Σᵢ ln(σᵢ) + (n/2) ln(2πe)
+func GaussianEntropy(std *G.Node) *G.Node {
+	dims := float64(std.Shape()[1])
+	term := G.NewConstant((dims / 2.0) * math.Log(2*math.Pi*math.E))
+
+	entropy := G.Must(G.Sum(G.Must(G.Log(std)), 1))
+	return G.Must(G.Add(entropy, term))
+}
+
+// CategoricalEntropy calculates the entropy of a categorical
+// distribution with probabilities softmax(logits).
+//
+// The argument should be two-dimensional of size m x n, where the rows
+// (m) denote the samples in the batch and the columns (n) denote the
+// logits of each category. The returned node has one entropy per
+// sample in the batch.
+func CategoricalEntropy(logits *G.Node) *G.Node {
+	logSumExp := LogSumExp(logits, 1)
+	logProbs := G.Must(G.BroadcastSub(logits, logSumExp, nil, []byte{1}))
+	probs := G.Must(G.Exp(logProbs))
+
+	entropy := G.Must(G.HadamardProd(probs, logProbs))
+	entropy = G.Must(G.Sum(entropy, 1))
+	return G.Must(G.Neg(entropy))
+}